@@ -61,6 +61,7 @@ func newRegistrationCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(hub.NewRegistrationController())
+	cmd.AddCommand(hub.NewClusterProfileCredential())
 	cmd.AddCommand(spoke.NewRegistrationAgent())
 	cmd.AddCommand(webhook.NewRegistrationWebhook())
 	cmd.AddCommand(grpc.NewGRPCServer())
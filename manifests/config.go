@@ -40,6 +40,9 @@ type HubConfig struct {
 	AwsResourceTags                   string
 	Labels                            map[string]string
 	LabelsString                      string
+	GRPCServer                        GRPCServer
+	// TopologySpreadConstraints is to spread the pods of the deployments across the zones and the nodes.
+	TopologySpreadConstraints bool
 }
 
 type Webhook struct {
@@ -47,3 +50,13 @@ type Webhook struct {
 	Port       int32
 	Address    string
 }
+
+type GRPCServer struct {
+	Enabled     bool
+	Port        int32
+	ServiceType string
+	// URL is the address that the klusterlets use to connect to the gRPC server.
+	URL string
+	// CABundle is the base64 encoded ca bundle of the gRPC server.
+	CABundle string
+}
@@ -35,6 +35,8 @@ func (d *cmaProgressingReconciler) reconcile(
 			placementNode.countAddonUpgradeSucceed(),
 			placementNode.countAddonUpgradeFailed(),
 			placementNode.countAddonTimeOut(),
+			len(placementNode.waitingClusters),
+			len(placementNode.frozenClusters),
 			len(placementNode.clusters),
 		)
 	}
@@ -48,7 +50,7 @@ func (d *cmaProgressingReconciler) reconcile(
 
 func setAddOnInstallProgressionsAndLastApplied(
 	installProgression *addonv1alpha1.InstallProgression,
-	progressing, done, failed, timeout, total int) {
+	progressing, done, failed, timeout, waiting, frozen, total int) {
 
 	condition := metav1.Condition{
 		Type: addonv1alpha1.ManagedClusterAddOnConditionProgressing,
@@ -57,6 +59,12 @@ func setAddOnInstallProgressionsAndLastApplied(
 		condition.Status = metav1.ConditionTrue
 		condition.Reason = addonv1alpha1.ProgressingReasonProgressing
 		condition.Message = fmt.Sprintf("%d/%d progressing..., %d failed %d timeout.", progressing+done, total, failed, timeout)
+		if waiting > 0 {
+			condition.Message += fmt.Sprintf(" %d waiting for maintenance window.", waiting)
+		}
+		if frozen > 0 {
+			condition.Message += fmt.Sprintf(" %d frozen.", frozen)
+		}
 	} else {
 		for i, configRef := range installProgression.ConfigReferences {
 			installProgression.ConfigReferences[i].LastAppliedConfig = configRef.DesiredConfig.DeepCopy()
@@ -8,7 +8,11 @@ import (
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -16,8 +20,12 @@ import (
 	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clusterinformersv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterinformersv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
 	clusterlisterv1beta1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
 	addonindex "open-cluster-management.io/ocm/pkg/addon/index"
@@ -28,6 +36,9 @@ import (
 const (
 	// maxRequeueTime is the minimum informer resync period
 	maxRequeueTime = 10 * time.Minute
+
+	// frozenRecheckTime is the interval to recheck the clusters held by the freeze
+	frozenRecheckTime = time.Minute
 )
 
 // addonConfigurationController is a controller to update configuration of mca with the following order
@@ -41,6 +52,10 @@ type addonConfigurationController struct {
 	addonFilterFunc              factory.EventFilterFunc
 	placementLister              clusterlisterv1beta1.PlacementLister
 	placementDecisionGetter      helpers.PlacementDecisionGetter
+	workLister                   worklister.ManifestWorkLister
+	gateEvaluator                *helpers.RolloutGateEvaluator
+	windowFilter                 *helpers.MaintenanceWindowFilter
+	freezeFilter                 *helpers.ClusterFreezeFilter
 
 	reconcilers []addonConfigurationReconcile
 }
@@ -63,9 +78,14 @@ func NewAddonConfigurationController(
 	clusterManagementAddonInformers addoninformerv1alpha1.ClusterManagementAddOnInformer,
 	placementInformer clusterinformersv1beta1.PlacementInformer,
 	placementDecisionInformer clusterinformersv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformersv1.ManagedClusterInformer,
+	workInformer workinformers.ManifestWorkInformer,
 	addonFilterFunc factory.EventFilterFunc,
 	recorder events.Recorder,
 ) factory.Controller {
+	gateEvaluator, err := helpers.NewRolloutGateEvaluator()
+	utilruntime.Must(err)
+
 	c := &addonConfigurationController{
 		addonClient:                  addonClient,
 		clusterManagementAddonLister: clusterManagementAddonInformers.Lister(),
@@ -73,6 +93,10 @@ func NewAddonConfigurationController(
 		placementLister:              placementInformer.Lister(),
 		placementDecisionGetter:      helpers.PlacementDecisionGetter{Client: placementDecisionInformer.Lister()},
 		addonFilterFunc:              addonFilterFunc,
+		workLister:                   workInformer.Lister(),
+		gateEvaluator:                gateEvaluator,
+		windowFilter:                 helpers.NewMaintenanceWindowFilter(clusterInformer.Lister()),
+		freezeFilter:                 helpers.NewClusterFreezeFilter(clusterInformer.Lister()),
 	}
 
 	c.reconcilers = []addonConfigurationReconcile{
@@ -91,6 +115,8 @@ func NewAddonConfigurationController(
 		c.addonFilterFunc,
 		clusterManagementAddonInformers.Informer()).
 		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, addonInformers.Informer()).
+		// the status feedback of the addon works is evaluated by the rollout gate.
+		WithInformersQueueKeysFunc(queue.QueueKeyByLabel(addonv1alpha1.AddonLabelKey), workInformer.Informer()).
 		WithInformersQueueKeysFunc(
 			addonindex.ClusterManagementAddonByPlacementDecisionQueueKey(clusterManagementAddonInformers), placementDecisionInformer.Informer()).
 		WithInformersQueueKeysFunc(
@@ -122,6 +148,15 @@ func (c *addonConfigurationController) sync(ctx context.Context, syncCtx factory
 		return err
 	}
 
+	// apply the rollout gate on the succeeded addons before generating the rollout result
+	gateRequeue, err := c.applyRolloutGate(ctx, cma, graph)
+	if err != nil {
+		return err
+	}
+
+	// hold the addons on the clusters outside their maintenance windows before generating the rollout result
+	windowRequeue := c.applyMaintenanceWindows(graph)
+
 	// generate the rollout result before calling reconcile()
 	// so that all the reconcilers are using the same rollout result
 	err = graph.generateRolloutResult()
@@ -129,9 +164,21 @@ func (c *addonConfigurationController) sync(ctx context.Context, syncCtx factory
 		return err
 	}
 
+	// hold the addons on the frozen clusters
+	freezeRequeue := c.applyClusterFreeze(cma, graph)
+
 	var state reconcileState
 	var errs []error
 	minRequeue := maxRequeueTime
+	if gateRequeue > 0 && gateRequeue < minRequeue {
+		minRequeue = gateRequeue
+	}
+	if windowRequeue > 0 && windowRequeue < minRequeue {
+		minRequeue = windowRequeue
+	}
+	if freezeRequeue > 0 && freezeRequeue < minRequeue {
+		minRequeue = freezeRequeue
+	}
 	for _, reconciler := range c.reconcilers {
 		cma, state, err = reconciler.reconcile(ctx, cma, graph)
 		var rqe helpers.RequeueError
@@ -194,3 +241,74 @@ func (c *addonConfigurationController) buildConfigurationGraph(logger klog.Logge
 
 	return graph, utilerrors.NewAggregate(errs)
 }
+
+// applyRolloutGate evaluates the rollout gate of the cma over the status feedback of the addon works on each cluster
+// where the addon succeeded. It returns the minimum remaining soak period of the addons.
+func (c *addonConfigurationController) applyRolloutGate(ctx context.Context, cma *addonv1alpha1.ClusterManagementAddOn,
+	graph *configurationGraph) (time.Duration, error) {
+	gate, err := helpers.GetRolloutGate(cma.Annotations)
+	if err != nil || gate == nil {
+		return 0, err
+	}
+
+	requirement, _ := labels.NewRequirement(addonv1alpha1.AddonLabelKey, selection.Equals, []string{cma.Name})
+	namespaceNotExistRequirement, _ := labels.NewRequirement(addonv1alpha1.AddonNamespaceLabelKey, selection.DoesNotExist, []string{})
+	selector := labels.NewSelector().Add(*requirement).Add(*namespaceNotExistRequirement)
+
+	var minRecheck time.Duration
+	for _, node := range append(graph.nodes, graph.defaults) {
+		for cluster, addon := range node.children {
+			if addon.status == nil || addon.status.Status != clustersdkv1alpha1.Succeeded {
+				continue
+			}
+			works, err := c.workLister.ManifestWorks(cluster).List(selector)
+			if err != nil {
+				return 0, err
+			}
+			status, recheck := c.gateEvaluator.Evaluate(ctx, gate, *addon.status, works)
+			addon.status = &status
+			if recheck > 0 && (minRecheck == 0 || recheck < minRecheck) {
+				minRecheck = recheck
+			}
+		}
+	}
+	return minRecheck, nil
+}
+
+// applyMaintenanceWindows holds the addons to apply on the clusters outside their maintenance windows, the held
+// addons are skipped when the rollout results are generated. It returns the minimum duration until one of the
+// windows opens.
+func (c *addonConfigurationController) applyMaintenanceWindows(graph *configurationGraph) time.Duration {
+	var minRecheck time.Duration
+	for _, node := range append(graph.nodes, graph.defaults) {
+		clusters := sets.New[string]()
+		var existingRolloutClusters []clustersdkv1alpha1.ClusterRolloutStatus
+		for name, addon := range node.children {
+			clusters.Insert(name)
+			if addon.status != nil {
+				existingRolloutClusters = append(existingRolloutClusters, *addon.status)
+			}
+		}
+
+		var recheck time.Duration
+		node.waitingClusters, recheck = c.windowFilter.Hold(clusters, existingRolloutClusters)
+		if recheck > 0 && (minRecheck == 0 || recheck < minRecheck) {
+			minRecheck = recheck
+		}
+	}
+	return minRecheck
+}
+
+// applyClusterFreeze removes the addons to apply on the frozen clusters from the rollout results unless the cma
+// has the freeze override annotation. It returns the duration to recheck the frozen clusters.
+func (c *addonConfigurationController) applyClusterFreeze(cma *addonv1alpha1.ClusterManagementAddOn,
+	graph *configurationGraph) time.Duration {
+	var recheck time.Duration
+	for _, node := range append(graph.nodes, graph.defaults) {
+		node.frozenClusters = c.freezeFilter.Filter(&node.rolloutResult, cma.Annotations)
+		if len(node.frozenClusters) > 0 {
+			recheck = frozenRecheckTime
+		}
+	}
+	return recheck
+}
@@ -0,0 +1,101 @@
+package addonconfiguration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"open-cluster-management.io/addon-framework/pkg/addonmanager/addontesting"
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	fakework "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+func newAddonWork(cluster string, readyReplicas int64) *workapiv1.ManifestWork {
+	return &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-test-deploy-0",
+			Namespace: cluster,
+			Labels:    map[string]string{addonv1alpha1.AddonLabelKey: "test"},
+		},
+		Status: workapiv1.ManifestWorkStatus{
+			ResourceStatus: workapiv1.ManifestResourceStatus{
+				Manifests: []workapiv1.ManifestCondition{
+					{
+						StatusFeedbacks: workapiv1.StatusFeedbackResult{
+							Values: []workapiv1.FeedbackValue{
+								{
+									Name:  "readyReplicas",
+									Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: ptr.To(readyReplicas)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyRolloutGate(t *testing.T) {
+	completedTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	newCompletedAddon := func(cluster string) *addonv1alpha1.ManagedClusterAddOn {
+		addon := addontesting.NewAddon("test", cluster)
+		addon.Status.Conditions = []metav1.Condition{
+			{
+				Type:               addonv1alpha1.ManagedClusterAddOnConditionProgressing,
+				Status:             metav1.ConditionFalse,
+				Reason:             addonv1alpha1.ProgressingReasonCompleted,
+				LastTransitionTime: completedTime,
+			},
+		}
+		return addon
+	}
+
+	cma := addontesting.NewClusterManagementAddon("test", "", "").Build()
+	cma.Annotations = map[string]string{
+		helpers.RolloutGateAnnotationKey: `{"expression":"feedback.readyReplicas > 0","soakPeriod":"2h"}`,
+	}
+
+	workInformerFactory := workinformers.NewSharedInformerFactory(fakework.NewSimpleClientset(), 10*time.Minute)
+	for _, work := range []*workapiv1.ManifestWork{newAddonWork("cluster1", 1), newAddonWork("cluster2", 0)} {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gateEvaluator, err := helpers.NewRolloutGateEvaluator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := &addonConfigurationController{
+		workLister:    workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		gateEvaluator: gateEvaluator,
+	}
+
+	graph := newGraph(nil, nil)
+	graph.addAddonNode(newCompletedAddon("cluster1"))
+	graph.addAddonNode(newCompletedAddon("cluster2"))
+
+	recheck, err := controller.applyRolloutGate(context.TODO(), cma, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recheck <= 0 || recheck > time.Hour {
+		t.Errorf("expected recheck after the remaining soak period, got %v", recheck)
+	}
+
+	// cluster1 is soaking and the gate of cluster2 fails.
+	if status := graph.defaults.children["cluster1"].status.Status; status != clustersdkv1alpha1.Progressing {
+		t.Errorf("expected cluster1 progressing, got %v", status)
+	}
+	if status := graph.defaults.children["cluster2"].status.Status; status != clustersdkv1alpha1.Failed {
+		t.Errorf("expected cluster2 failed, got %v", status)
+	}
+}
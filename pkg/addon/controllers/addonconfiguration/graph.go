@@ -2,6 +2,7 @@ package addonconfiguration
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"time"
 
@@ -34,7 +35,11 @@ type installStrategyNode struct {
 	pdTracker       *clustersdkv1beta1.PlacementDecisionClustersTracker
 	rolloutStrategy clusterv1alpha1.RolloutStrategy
 	rolloutResult   clustersdkv1alpha1.RolloutResult
-	desiredConfigs  addonConfigMap
+	// waitingClusters are the clusters to apply held until their maintenance windows open
+	waitingClusters []string
+	// frozenClusters are the clusters to apply held by the freeze of the clusters
+	frozenClusters []string
+	desiredConfigs addonConfigMap
 	// children keeps a map of addons node as the children of this node
 	children map[string]*addonNode
 	clusters sets.Set[string]
@@ -406,7 +411,7 @@ func (n *installStrategyNode) generateRolloutResult() error {
 			if addon.status == nil {
 				return fmt.Errorf("failed to get rollout status on cluster %v", name)
 			}
-			if addon.status.Status != clustersdkv1alpha1.Succeeded {
+			if addon.status.Status != clustersdkv1alpha1.Succeeded && !slices.Contains(n.waitingClusters, name) {
 				rolloutResult.ClustersToRollout = append(rolloutResult.ClustersToRollout, *addon.status)
 			}
 		}
@@ -433,7 +438,8 @@ func (n *installStrategyNode) generateRolloutResult() error {
 			return existingRolloutClusters[i].ClusterName < existingRolloutClusters[j].ClusterName
 		})
 
-		_, rolloutResult, err := rolloutHandler.GetRolloutCluster(n.rolloutStrategy, existingRolloutClusters)
+		_, rolloutResult, err := rolloutHandler.GetRolloutCluster(n.rolloutStrategy,
+			helpers.SkipClusters(existingRolloutClusters, n.waitingClusters))
 		if err != nil {
 			return err
 		}
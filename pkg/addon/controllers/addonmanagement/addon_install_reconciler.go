@@ -2,6 +2,7 @@ package addonmanagement
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -18,6 +19,7 @@ import (
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 
 	addonindex "open-cluster-management.io/ocm/pkg/addon/index"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 type managedClusterAddonInstallReconciler struct {
@@ -26,6 +28,7 @@ type managedClusterAddonInstallReconciler struct {
 	placementLister            clusterlisterv1beta1.PlacementLister
 	placementDecisionLister    clusterlisterv1beta1.PlacementDecisionLister
 	addonFilterFunc            factory.EventFilterFunc
+	freezeFilter               *helpers.ClusterFreezeFilter
 }
 
 func (d *managedClusterAddonInstallReconciler) reconcile(
@@ -62,6 +65,16 @@ func (d *managedClusterAddonInstallReconciler) reconcile(
 	toAdd := requiredDeployed.Difference(existingDeployed)
 	toRemove := existingDeployed.Difference(requiredDeployed)
 
+	// the addons on the frozen clusters are neither installed nor removed unless the cma overrides the freeze.
+	frozen := 0
+	for cluster := range toAdd.Union(toRemove) {
+		if d.freezeFilter.IsFrozen(cluster, cma.Annotations) {
+			toAdd.Delete(cluster)
+			toRemove.Delete(cluster)
+			frozen++
+		}
+	}
+
 	var errs []error
 	for cluster := range toAdd {
 		_, err := d.addonClient.AddonV1alpha1().ManagedClusterAddOns(cluster).Create(ctx, &addonv1alpha1.ManagedClusterAddOn{
@@ -85,6 +98,10 @@ func (d *managedClusterAddonInstallReconciler) reconcile(
 		}
 	}
 
+	if len(errs) == 0 && frozen > 0 {
+		return cma, reconcileContinue, helpers.NewRequeueError(
+			fmt.Sprintf("%d clusters are frozen", frozen), frozenRecheckTime)
+	}
 	return cma, reconcileContinue, utilerrors.NewAggregate(errs)
 }
 
@@ -2,10 +2,12 @@ package addonmanagement
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
@@ -14,9 +16,11 @@ import (
 	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clusterinformersv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterinformersv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
 
 	addonindex "open-cluster-management.io/ocm/pkg/addon/index"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/common/queue"
 )
 
@@ -40,12 +44,16 @@ const (
 	reconcileContinue
 )
 
+// frozenRecheckTime is the interval to recheck the clusters held by the freeze
+const frozenRecheckTime = time.Minute
+
 func NewAddonManagementController(
 	addonClient addonv1alpha1client.Interface,
 	addonInformers addoninformerv1alpha1.ManagedClusterAddOnInformer,
 	clusterManagementAddonInformers addoninformerv1alpha1.ClusterManagementAddOnInformer,
 	placementInformer clusterinformersv1beta1.PlacementInformer,
 	placementDecisionInformer clusterinformersv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformersv1.ManagedClusterInformer,
 	addonFilterFunc factory.EventFilterFunc,
 	recorder events.Recorder,
 ) factory.Controller {
@@ -61,6 +69,7 @@ func NewAddonManagementController(
 				placementLister:            placementInformer.Lister(),
 				managedClusterAddonIndexer: addonInformers.Informer().GetIndexer(),
 				addonFilterFunc:            addonFilterFunc,
+				freezeFilter:               helpers.NewClusterFreezeFilter(clusterInformer.Lister()),
 			},
 		},
 	}
@@ -86,7 +95,7 @@ func (c *addonManagementController) sync(ctx context.Context, syncCtx factory.Sy
 
 	cma, err := c.clusterManagementAddonLister.Get(addonName)
 	switch {
-	case errors.IsNotFound(err):
+	case apierrors.IsNotFound(err):
 		return nil
 	case err != nil:
 		return err
@@ -96,9 +105,15 @@ func (c *addonManagementController) sync(ctx context.Context, syncCtx factory.Sy
 
 	var state reconcileState
 	var errs []error
+	var requeue time.Duration
 	for _, reconciler := range c.reconcilers {
 		cma, state, err = reconciler.reconcile(ctx, cma)
-		if err != nil {
+		var rqe helpers.RequeueError
+		if err != nil && errors.As(err, &rqe) {
+			if requeue == 0 || rqe.RequeueTime < requeue {
+				requeue = rqe.RequeueTime
+			}
+		} else if err != nil {
 			errs = append(errs, err)
 		}
 		if state == reconcileStop {
@@ -106,5 +121,11 @@ func (c *addonManagementController) sync(ctx context.Context, syncCtx factory.Sy
 		}
 	}
 
-	return utilerrors.NewAggregate(errs)
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	if requeue > 0 {
+		syncCtx.Queue().AddAfter(addonName, requeue)
+	}
+	return nil
 }
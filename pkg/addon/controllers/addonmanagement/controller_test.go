@@ -17,9 +17,11 @@ import (
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
 	fakecluster "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 
 	addonindex "open-cluster-management.io/ocm/pkg/addon/index"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 func TestAddonInstallReconcile(t *testing.T) {
@@ -29,6 +31,7 @@ func TestAddonInstallReconcile(t *testing.T) {
 		clusterManagementAddon *addonv1alpha1.ClusterManagementAddOn
 		placements             []runtime.Object
 		placementDecisions     []runtime.Object
+		clusters               []runtime.Object
 		validateAddonActions   func(t *testing.T, actions []clienttesting.Action)
 		expectErr              bool
 	}{
@@ -216,6 +219,54 @@ func TestAddonInstallReconcile(t *testing.T) {
 				addontesting.AssertActions(t, actions, "create", "create", "delete")
 			},
 		},
+		{
+			name: "frozen clusters",
+			managedClusteraddon: []runtime.Object{
+				addontesting.NewAddon("test", "cluster0"),
+				addontesting.NewAddon("test", "cluster3"),
+			},
+			clusterManagementAddon: func() *addonv1alpha1.ClusterManagementAddOn {
+				addon := addontesting.NewClusterManagementAddon("test", "", "").Build()
+				addon.Spec.InstallStrategy = addonv1alpha1.InstallStrategy{
+					Type: addonv1alpha1.AddonInstallStrategyPlacements,
+					Placements: []addonv1alpha1.PlacementStrategy{
+						{
+							PlacementRef: addonv1alpha1.PlacementRef{Name: "test-placement", Namespace: "default"},
+						},
+					},
+				}
+				return addon
+			}(),
+			placements: []runtime.Object{
+				&clusterv1beta1.Placement{ObjectMeta: metav1.ObjectMeta{Name: "test-placement", Namespace: "default"}},
+			},
+			placementDecisions: []runtime.Object{
+				&clusterv1beta1.PlacementDecision{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-placement",
+						Namespace: "default",
+						Labels:    map[string]string{clusterv1beta1.PlacementLabel: "test-placement"},
+					},
+					Status: clusterv1beta1.PlacementDecisionStatus{
+						Decisions: []clusterv1beta1.ClusterDecision{{ClusterName: "cluster1"}, {ClusterName: "cluster2"}},
+					},
+				},
+			},
+			clusters: []runtime.Object{
+				&clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster0", Labels: map[string]string{helpers.ClusterFrozenLabelKey: "true"}}},
+				&clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster1", Labels: map[string]string{helpers.ClusterFrozenLabelKey: "true"}}},
+				&clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster2"}},
+			},
+			validateAddonActions: func(t *testing.T, actions []clienttesting.Action) {
+				addontesting.AssertActions(t, actions, "create", "delete")
+				if actions[0].GetNamespace() != "cluster2" || actions[1].GetNamespace() != "cluster3" {
+					t.Errorf("unexpected actions %v", actions)
+				}
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, c := range cases {
@@ -247,6 +298,12 @@ func TestAddonInstallReconcile(t *testing.T) {
 				}
 			}
 
+			for _, obj := range c.clusters {
+				if err := clusterInformers.Cluster().V1().ManagedClusters().Informer().GetStore().Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+
 			for _, obj := range c.managedClusteraddon {
 				if err := addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(obj); err != nil {
 					t.Fatal(err)
@@ -259,6 +316,7 @@ func TestAddonInstallReconcile(t *testing.T) {
 				placementDecisionLister:    clusterInformers.Cluster().V1beta1().PlacementDecisions().Lister(),
 				managedClusterAddonIndexer: addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetIndexer(),
 				addonFilterFunc:            utils.ManagedByAddonManager,
+				freezeFilter:               helpers.NewClusterFreezeFilter(clusterInformers.Cluster().V1().ManagedClusters().Lister()),
 			}
 
 			_, _, err = reconcile.reconcile(context.TODO(), c.clusterManagementAddon)
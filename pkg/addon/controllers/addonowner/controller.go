@@ -2,11 +2,15 @@ package addonowner
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -16,6 +20,8 @@ import (
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
 
+	addonindex "open-cluster-management.io/ocm/pkg/addon/index"
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/common/queue"
 )
 
@@ -26,6 +32,7 @@ const UnsupportedConfigurationType = "UnsupportedConfiguration"
 type addonOwnerController struct {
 	addonClient                  addonv1alpha1client.Interface
 	managedClusterAddonLister    addonlisterv1alpha1.ManagedClusterAddOnLister
+	managedClusterAddonIndexer   cache.Indexer
 	clusterManagementAddonLister addonlisterv1alpha1.ClusterManagementAddOnLister
 	addonFilterFunc              factory.EventFilterFunc
 }
@@ -40,14 +47,17 @@ func NewAddonOwnerController(
 	c := &addonOwnerController{
 		addonClient:                  addonClient,
 		managedClusterAddonLister:    addonInformers.Lister(),
+		managedClusterAddonIndexer:   addonInformers.Informer().GetIndexer(),
 		clusterManagementAddonLister: clusterManagementAddonInformers.Lister(),
 		addonFilterFunc:              addonFilterFunc,
 	}
 
+	// the ClusterManagementAddOn events are not filtered by addonFilterFunc, since the health probes
+	// are synced to the addons regardless of who manages the ClusterManagementAddOn.
 	return factory.New().
-		WithFilteredEventsInformersQueueKeysFunc(
-			queue.QueueKeyByMetaNamespaceName,
-			c.addonFilterFunc, clusterManagementAddonInformers.Informer()).
+		WithInformersQueueKeysFunc(
+			c.clusterManagementAddonQueueKeys,
+			clusterManagementAddonInformers.Informer()).
 		WithInformersQueueKeysFunc(
 			queue.QueueKeyByMetaNamespaceName,
 			addonInformers.Informer()).
@@ -55,6 +65,29 @@ func NewAddonOwnerController(
 		ToController("addon-owner-controller", recorder)
 }
 
+// clusterManagementAddonQueueKeys returns the keys of all the ManagedClusterAddOns with the name of the
+// ClusterManagementAddOn.
+func (c *addonOwnerController) clusterManagementAddonQueueKeys(obj runtime.Object) []string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+
+	addons, err := c.managedClusterAddonIndexer.ByIndex(addonindex.ManagedClusterAddonByName, accessor.GetName())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+
+	var keys []string
+	for _, o := range addons {
+		addon := o.(*addonapiv1alpha1.ManagedClusterAddOn)
+		keys = append(keys, fmt.Sprintf("%s/%s", addon.Namespace, addon.Name))
+	}
+	return keys
+}
+
 func (c *addonOwnerController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	logger := klog.FromContext(ctx)
 	key := syncCtx.QueueKey()
@@ -86,12 +119,14 @@ func (c *addonOwnerController) sync(ctx context.Context, syncCtx factory.SyncCon
 		return err
 	}
 
-	if !c.addonFilterFunc(clusterManagementAddon) {
-		return nil
-	}
+	// the health probes are defined by the owner of the ClusterManagementAddOn, sync them to the
+	// addon so the registration agent is able to run them on the managed cluster.
+	modified = syncHealthProbes(addonCopy, clusterManagementAddon)
 
-	owner := metav1.NewControllerRef(clusterManagementAddon, addonapiv1alpha1.GroupVersion.WithKind("ClusterManagementAddOn"))
-	modified = utils.MergeOwnerRefs(&addonCopy.OwnerReferences, *owner, false)
+	if c.addonFilterFunc(clusterManagementAddon) {
+		owner := metav1.NewControllerRef(clusterManagementAddon, addonapiv1alpha1.GroupVersion.WithKind("ClusterManagementAddOn"))
+		modified = utils.MergeOwnerRefs(&addonCopy.OwnerReferences, *owner, false) || modified
+	}
 	if modified {
 		_, err = c.addonClient.AddonV1alpha1().ManagedClusterAddOns(namespace).Update(ctx, addonCopy, metav1.UpdateOptions{})
 		return err
@@ -99,3 +134,23 @@ func (c *addonOwnerController) sync(ctx context.Context, syncCtx factory.SyncCon
 
 	return nil
 }
+
+func syncHealthProbes(addon *addonapiv1alpha1.ManagedClusterAddOn, cma *addonapiv1alpha1.ClusterManagementAddOn) bool {
+	probes, ok := cma.Annotations[commonhelpers.AddonHealthProbesAnnotationKey]
+	if !ok {
+		if _, exists := addon.Annotations[commonhelpers.AddonHealthProbesAnnotationKey]; !exists {
+			return false
+		}
+		delete(addon.Annotations, commonhelpers.AddonHealthProbesAnnotationKey)
+		return true
+	}
+
+	if addon.Annotations[commonhelpers.AddonHealthProbesAnnotationKey] == probes {
+		return false
+	}
+	if addon.Annotations == nil {
+		addon.Annotations = map[string]string{}
+	}
+	addon.Annotations[commonhelpers.AddonHealthProbesAnnotationKey] = probes
+	return true
+}
@@ -2,12 +2,15 @@ package addonowner
 
 import (
 	"context"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 
 	"open-cluster-management.io/addon-framework/pkg/addonmanager/addontesting"
 	"open-cluster-management.io/addon-framework/pkg/utils"
@@ -15,6 +18,8 @@ import (
 	fakeaddon "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
 
+	addonindex "open-cluster-management.io/ocm/pkg/addon/index"
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 )
 
@@ -54,6 +59,42 @@ func TestReconcile(t *testing.T) {
 			clusterManagementAddon: []runtime.Object{addontesting.NewClusterManagementAddon("test", "testcrd", "testcr").Build()},
 			validateAddonActions:   addontesting.AssertNoActions,
 		},
+		{
+			name:    "sync health probes to managedclusteraddon",
+			syncKey: "cluster1/test",
+			managedClusteraddon: []runtime.Object{
+				addontesting.NewAddon("test", "cluster1", newClusterManagementOwner("test")),
+			},
+			clusterManagementAddon: []runtime.Object{func() *addonapiv1alpha1.ClusterManagementAddOn {
+				cma := addontesting.NewClusterManagementAddon("test", "testcrd", "testcr").Build()
+				cma.Annotations = map[string]string{commonhelpers.AddonHealthProbesAnnotationKey: `[{"name":"tcp","type":"TCP"}]`}
+				return cma
+			}()},
+			validateAddonActions: func(t *testing.T, actions []clienttesting.Action) {
+				addontesting.AssertActions(t, actions, "update")
+				addon := actions[0].(clienttesting.UpdateActionImpl).Object.(*addonapiv1alpha1.ManagedClusterAddOn)
+				if addon.Annotations[commonhelpers.AddonHealthProbesAnnotationKey] != `[{"name":"tcp","type":"TCP"}]` {
+					t.Errorf("expected health probes synced, got %v", addon.Annotations)
+				}
+			},
+		},
+		{
+			name:    "remove health probes from managedclusteraddon",
+			syncKey: "cluster1/test",
+			managedClusteraddon: []runtime.Object{func() *addonapiv1alpha1.ManagedClusterAddOn {
+				addon := addontesting.NewAddon("test", "cluster1", newClusterManagementOwner("test"))
+				addon.Annotations = map[string]string{commonhelpers.AddonHealthProbesAnnotationKey: `[{"name":"tcp","type":"TCP"}]`}
+				return addon
+			}()},
+			clusterManagementAddon: []runtime.Object{addontesting.NewClusterManagementAddon("test", "testcrd", "testcr").Build()},
+			validateAddonActions: func(t *testing.T, actions []clienttesting.Action) {
+				addontesting.AssertActions(t, actions, "update")
+				addon := actions[0].(clienttesting.UpdateActionImpl).Object.(*addonapiv1alpha1.ManagedClusterAddOn)
+				if _, ok := addon.Annotations[commonhelpers.AddonHealthProbesAnnotationKey]; ok {
+					t.Errorf("expected health probes removed, got %v", addon.Annotations)
+				}
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -92,3 +133,31 @@ func TestReconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterManagementAddonQueueKeys(t *testing.T) {
+	addons := []runtime.Object{
+		addontesting.NewAddon("test", "cluster1"),
+		addontesting.NewAddon("test", "cluster2"),
+		addontesting.NewAddon("other", "cluster1"),
+	}
+	fakeAddonClient := fakeaddon.NewSimpleClientset(addons...)
+	addonInformers := addoninformers.NewSharedInformerFactory(fakeAddonClient, 10*time.Minute)
+	addonInformer := addonInformers.Addon().V1alpha1().ManagedClusterAddOns()
+	if err := addonInformer.Informer().AddIndexers(cache.Indexers{
+		addonindex.ManagedClusterAddonByName: addonindex.IndexManagedClusterAddonByName,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range addons {
+		if err := addonInformer.Informer().GetStore().Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &addonOwnerController{managedClusterAddonIndexer: addonInformer.Informer().GetIndexer()}
+	keys := c.clusterManagementAddonQueueKeys(addontesting.NewClusterManagementAddon("test", "testcrd", "testcr").Build())
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"cluster1/test", "cluster2/test"}) {
+		t.Errorf("unexpected queue keys %v", keys)
+	}
+}
@@ -137,6 +137,7 @@ func RunControllerManagerWithInformers(
 		addonInformers.Addon().V1alpha1().ClusterManagementAddOns(),
 		clusterInformers.Cluster().V1beta1().Placements(),
 		clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+		clusterInformers.Cluster().V1().ManagedClusters(),
 		utils.ManagedByAddonManager,
 		controllerContext.EventRecorder,
 	)
@@ -147,6 +148,8 @@ func RunControllerManagerWithInformers(
 		addonInformers.Addon().V1alpha1().ClusterManagementAddOns(),
 		clusterInformers.Cluster().V1beta1().Placements(),
 		clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		workinformers.Work().V1().ManifestWorks(),
 		utils.ManagedByAddonManager,
 		controllerContext.EventRecorder,
 	)
@@ -0,0 +1,49 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"open-cluster-management.io/ocm/pkg/registration/hub/clusterprofile"
+)
+
+// NewClusterProfileCredential returns the credential plugin command which prints the ExecCredential to access
+// the cluster of a ClusterProfile.
+func NewClusterProfileCredential() *cobra.Command {
+	var kubeconfig, clusterName string
+	cmd := &cobra.Command{
+		Use:   "credential",
+		Short: "Print the credential to access the cluster of a ClusterProfile",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(clusterName) == 0 {
+				return fmt.Errorf("cluster name is required")
+			}
+			loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+			loadingRules.ExplicitPath = kubeconfig
+			config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+				loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+			if err != nil {
+				return err
+			}
+			kubeClient, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+
+			credential, err := clusterprofile.NewExecCredential(cmd.Context(), kubeClient, clusterName)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(credential)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&kubeconfig, "kubeconfig", kubeconfig, "The kubeconfig to connect to the hub cluster.")
+	flags.StringVar(&clusterName, "cluster", clusterName, "The name of the ClusterProfile.")
+	return cmd
+}
@@ -48,6 +48,9 @@ func NewKlusterletOperatorCmd() *cobra.Command {
 
 	flags.BoolVar(&klOptions.EnableSyncLabels, "enable-sync-labels", false,
 		"If set, will sync the labels of Klusterlet CR to all agent resources")
+	flags.DurationVar(&klOptions.AgentUpgradeTimeout, "agent-upgrade-timeout", 0,
+		"If set, an upgraded agent which is not available and connected to the hub within this duration "+
+			"will be rolled back to the last healthy deployment spec")
 
 	opts.AddFlags(flags)
 
@@ -0,0 +1,393 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// PublicKeyClusterClaimName is the name of the cluster claim published by the registration agent, its value
+	// is the base64 encoded PKIX public key of the managed cluster. The hub encrypts the manifests of the
+	// manifestworks of the cluster with this key.
+	PublicKeyClusterClaimName = "publickey.work.open-cluster-management.io"
+
+	// KeySecretName is the name of the secret in the agent namespace which stores the key pair of the managed
+	// cluster. Only the agents on the managed cluster are able to read the private key.
+	KeySecretName = "work-encryption-key"
+
+	// PrivateKeyDataKey is the data key of the PEM encoded private key in the key secret
+	PrivateKeyDataKey = "private-key.pem"
+
+	// PublicKeyDataKey is the data key of the base64 encoded public key in the key secret
+	PublicKeyDataKey = "public-key"
+
+	// EncryptManifestsLabelKey is a label key on a manifestwork, the manifests of the manifestwork are
+	// encrypted by the hub with the public key of the cluster before the manifestwork is stored when the
+	// value is "true". The Secrets and the manifests with the EncryptAnnotationKey annotation are encrypted.
+	EncryptManifestsLabelKey = "work.open-cluster-management.io/encrypt-manifests"
+
+	// EncryptAnnotationKey is an annotation key on a manifest, the manifest is encrypted if the value is "true"
+	// and the manifestwork has the EncryptManifestsLabelKey label.
+	EncryptAnnotationKey = "work.open-cluster-management.io/encrypt"
+
+	// EncryptedManifestKind is the kind of an encrypted manifest. The name and namespace of the original
+	// manifest are kept in the metadata of the encrypted manifest, everything else is encrypted.
+	EncryptedManifestKind       = "EncryptedManifest"
+	EncryptedManifestAPIVersion = "work.open-cluster-management.io/v1"
+
+	// SealedManifestKind is the kind of a manifest in the template of a manifestworkreplicaset sealed with the
+	// key of the hub. The sealed manifest is only opened by the hub before it is encrypted with the public key of
+	// each cluster, so the manifest is not stored in plaintext before the clusters are decided.
+	SealedManifestKind = "SealedManifest"
+
+	// sealKeyContext derives the seal key from the digest key of the hub, so the digest and the seal keys are
+	// different keys while only the digest key is stored.
+	sealKeyContext = "work.open-cluster-management.io/sealed-manifest"
+
+	keySize = 3072
+)
+
+// EncryptedManifest is the manifest encrypted with a hybrid scheme, the manifest is encrypted with a random
+// AES-256-GCM key, and the key is encrypted with the RSA-OAEP public key of the cluster.
+type EncryptedManifest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	EncryptedData `json:",inline"`
+
+	// Digest is the keyed digest of the manifest before it is encrypted, it is used to reuse the encrypted
+	// manifest when the manifest is not changed, since the manifest is encrypted with a random key each time.
+	Digest []byte `json:"digest,omitempty"`
+}
+
+// EncryptedData is the data encrypted with the hybrid scheme.
+type EncryptedData struct {
+	// KeyID identifies the public key the data is encrypted with.
+	KeyID string `json:"keyID"`
+	// EncryptedKey is the AES key encrypted with the public key.
+	EncryptedKey []byte `json:"encryptedKey"`
+	// Data is the nonce followed by the encrypted data.
+	Data []byte `json:"data"`
+}
+
+// SealedManifest is the manifest sealed with the AES-256-GCM key of the hub.
+type SealedManifest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// Data is the nonce followed by the sealed manifest.
+	Data []byte `json:"data"`
+}
+
+// GenerateKey returns a new RSA private key.
+func GenerateKey() (*rsa.PrivateKey, error) {
+	return rsa.GenerateKey(rand.Reader, keySize)
+}
+
+// EncodePrivateKey returns the PEM encoded PKCS8 private key.
+func EncodePrivateKey(key *rsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKey parses the PEM encoded PKCS8 private key.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode the private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the private key is not a RSA key")
+	}
+	return rsaKey, nil
+}
+
+// EncodePublicKey returns the base64 encoded PKIX public key, it is short enough to be the value of a cluster claim.
+func EncodePublicKey(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(der), nil
+}
+
+// ParsePublicKey parses the base64 encoded PKIX public key.
+func ParsePublicKey(value string) (*rsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the public key is not a RSA key")
+	}
+	return rsaKey, nil
+}
+
+// KeyID returns the id of the public key.
+func KeyID(key *rsa.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(key)
+	return fmt.Sprintf("%x", sha256.Sum256(der))[:16]
+}
+
+// ShouldEncrypt returns true if the manifest should be encrypted, the Secrets and the manifests with the
+// EncryptAnnotationKey annotation are encrypted. An encrypted or sealed manifest is not encrypted again.
+func ShouldEncrypt(raw []byte) (bool, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return false, err
+	}
+	switch {
+	case isEncryptedManifest(obj), isSealedManifest(obj):
+		return false, nil
+	case obj.GetAPIVersion() == "v1" && obj.GetKind() == "Secret":
+		return true, nil
+	default:
+		return obj.GetAnnotations()[EncryptAnnotationKey] == "true", nil
+	}
+}
+
+// IsEncryptedManifest returns true if the raw manifest is an encrypted manifest.
+func IsEncryptedManifest(raw []byte) bool {
+	typeMeta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, typeMeta); err != nil {
+		return false
+	}
+	return typeMeta.APIVersion == EncryptedManifestAPIVersion && typeMeta.Kind == EncryptedManifestKind
+}
+
+func isEncryptedManifest(obj *unstructured.Unstructured) bool {
+	return obj.GetAPIVersion() == EncryptedManifestAPIVersion && obj.GetKind() == EncryptedManifestKind
+}
+
+func isSealedManifest(obj *unstructured.Unstructured) bool {
+	return obj.GetAPIVersion() == EncryptedManifestAPIVersion && obj.GetKind() == SealedManifestKind
+}
+
+// EncryptManifest encrypts the raw manifest with the public key and returns the raw encrypted manifest. The
+// digest of the manifest is kept in the encrypted manifest if the digest key is set.
+func EncryptManifest(key *rsa.PublicKey, digestKey, raw []byte) ([]byte, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := EncryptData(key, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&EncryptedManifest{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: EncryptedManifestAPIVersion,
+			Kind:       EncryptedManifestKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		},
+		EncryptedData: *encrypted,
+		Digest:        manifestDigest(key, digestKey, raw),
+	})
+}
+
+// IsSealedManifest returns true if the raw manifest is a sealed manifest.
+func IsSealedManifest(raw []byte) bool {
+	typeMeta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, typeMeta); err != nil {
+		return false
+	}
+	return typeMeta.APIVersion == EncryptedManifestAPIVersion && typeMeta.Kind == SealedManifestKind
+}
+
+// SealManifest seals the raw manifest with the key derived from the digest key of the hub and returns the raw
+// sealed manifest.
+func SealManifest(digestKey, raw []byte) ([]byte, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+
+	sealed, err := SealData(sealKey(digestKey), raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&SealedManifest{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: EncryptedManifestAPIVersion,
+			Kind:       SealedManifestKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		},
+		Data: sealed,
+	})
+}
+
+// UnsealManifest opens the raw sealed manifest with the key derived from the digest key of the hub and returns
+// the raw manifest.
+func UnsealManifest(digestKey, raw []byte) ([]byte, error) {
+	sealed := &SealedManifest{}
+	if err := json.Unmarshal(raw, sealed); err != nil {
+		return nil, err
+	}
+
+	manifest, err := OpenData(sealKey(digestKey), sealed.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unseal the manifest %s: %v", sealed.Name, err)
+	}
+	return manifest, nil
+}
+
+// sealKey derives the AES-256 seal key from the digest key.
+func sealKey(digestKey []byte) []byte {
+	mac := hmac.New(sha256.New, digestKey)
+	mac.Write([]byte(sealKeyContext))
+	return mac.Sum(nil)
+}
+
+// MatchEncryptedManifest returns true if the raw encrypted manifest is the raw manifest encrypted with the
+// public key, so the encrypted manifest can be reused instead of encrypting the manifest again.
+func MatchEncryptedManifest(encryptedRaw []byte, key *rsa.PublicKey, digestKey, raw []byte) bool {
+	if len(digestKey) == 0 || !IsEncryptedManifest(encryptedRaw) {
+		return false
+	}
+	encrypted := &EncryptedManifest{}
+	if err := json.Unmarshal(encryptedRaw, encrypted); err != nil {
+		return false
+	}
+	return encrypted.KeyID == KeyID(key) && hmac.Equal(encrypted.Digest, manifestDigest(key, digestKey, raw))
+}
+
+// manifestDigest returns the HMAC-SHA256 of the manifest and the public key, the digest is keyed so the
+// content of the manifest cannot be guessed from the digest.
+func manifestDigest(key *rsa.PublicKey, digestKey, raw []byte) []byte {
+	if len(digestKey) == 0 {
+		return nil
+	}
+	mac := hmac.New(sha256.New, digestKey)
+	mac.Write([]byte(KeyID(key)))
+	mac.Write([]byte{0})
+	mac.Write(raw)
+	return mac.Sum(nil)
+}
+
+// DecryptManifest decrypts the raw encrypted manifest with the private key and returns the raw manifest.
+func DecryptManifest(key *rsa.PrivateKey, raw []byte) ([]byte, error) {
+	encrypted := &EncryptedManifest{}
+	if err := json.Unmarshal(raw, encrypted); err != nil {
+		return nil, err
+	}
+
+	manifest, err := DecryptData(key, &encrypted.EncryptedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the manifest %s: %v", encrypted.Name, err)
+	}
+	return manifest, nil
+}
+
+// EncryptData encrypts the data with a random AES-256-GCM key, and encrypts the key with the public key.
+func EncryptData(key *rsa.PublicKey, data []byte) (*EncryptedData, error) {
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := SealData(dataKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, dataKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedData{
+		KeyID:        KeyID(key),
+		EncryptedKey: encryptedKey,
+		Data:         sealed,
+	}, nil
+}
+
+// DecryptData decrypts the data encrypted by EncryptData with the private key.
+func DecryptData(key *rsa.PrivateKey, encrypted *EncryptedData) ([]byte, error) {
+	if keyID := KeyID(&key.PublicKey); encrypted.KeyID != keyID {
+		return nil, fmt.Errorf("the data is encrypted with the key %s instead of the key %s", encrypted.KeyID, keyID)
+	}
+
+	dataKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, encrypted.EncryptedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the key: %v", err)
+	}
+	return OpenData(dataKey, encrypted.Data)
+}
+
+// GenerateDataKey returns a random AES-256 key.
+func GenerateDataKey() ([]byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	return dataKey, nil
+}
+
+// SealData encrypts the data with the AES-256-GCM key, and returns the random nonce followed by the encrypted data.
+func SealData(dataKey, data []byte) ([]byte, error) {
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// OpenData decrypts the data sealed by SealData with the AES-256-GCM key.
+func OpenData(dataKey, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("the encrypted data is too short")
+	}
+
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, data, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
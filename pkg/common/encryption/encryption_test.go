@@ -0,0 +1,127 @@
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const (
+	testSecret    = `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s1","namespace":"ns1"},"data":{"password":"cGFzc3dvcmQ="}}`
+	testConfigMap = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm1","namespace":"ns1"}}`
+	testAnnotated = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm1","namespace":"ns1",` +
+		`"annotations":{"work.open-cluster-management.io/encrypt":"true"}}}`
+)
+
+func TestKeyEncoding(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKeyData, err := EncodePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := ParsePrivateKey(privateKeyData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !privateKey.Equal(key) {
+		t.Errorf("expected the same private key")
+	}
+
+	publicKeyValue, err := EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the public key is the value of a cluster claim
+	if len(publicKeyValue) > 1024 {
+		t.Errorf("the public key is too long: %d", len(publicKeyValue))
+	}
+	publicKey, err := ParsePublicKey(publicKeyValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !publicKey.Equal(&key.PublicKey) {
+		t.Errorf("expected the same public key")
+	}
+
+	if _, err := ParsePublicKey("invalid"); err == nil {
+		t.Errorf("expected error")
+	}
+	if _, err := ParsePrivateKey([]byte("invalid")); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestEncryptManifest(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := EncryptManifest(&key.PublicKey, nil, []byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncryptedManifest(encrypted) || IsEncryptedManifest([]byte(testSecret)) {
+		t.Errorf("expected the encrypted manifest is recognized")
+	}
+	if strings.Contains(string(encrypted), "cGFzc3dvcmQ=") {
+		t.Errorf("expected the data is not in plaintext")
+	}
+	if !strings.Contains(string(encrypted), `"name":"s1"`) {
+		t.Errorf("expected the name is kept")
+	}
+
+	decrypted, err := DecryptManifest(key, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, []byte(testSecret)) {
+		t.Errorf("expected %s, got %s", testSecret, decrypted)
+	}
+
+	otherKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptManifest(otherKey, encrypted); err == nil {
+		t.Errorf("expected the manifest cannot be decrypted by other keys")
+	}
+}
+
+func TestShouldEncrypt(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := EncryptManifest(&key.PublicKey, nil, []byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		raw      []byte
+		expected bool
+	}{
+		{name: "secret", raw: []byte(testSecret), expected: true},
+		{name: "configmap", raw: []byte(testConfigMap)},
+		{name: "annotated", raw: []byte(testAnnotated), expected: true},
+		{name: "encrypted", raw: encrypted},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := ShouldEncrypt(c.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// DigestKeySecretName is the name of the secret in the namespace of the cluster manager on the hub which
+	// stores the key of the digests of the encrypted manifests. The secret is created by the cluster manager
+	// operator, and only read by the components encrypting the manifests.
+	DigestKeySecretName = "work-encryption-digest-key"
+
+	// DigestKeyDataKey is the data key of the digest key in the digest key secret
+	DigestKeyDataKey = "key"
+)
+
+// EnsureDigestKey creates the digest key secret in the namespace if it does not exist.
+func EnsureDigestKey(ctx context.Context, kubeClient kubernetes.Interface, namespace string) error {
+	_, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, DigestKeySecretName, metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	key, err := GenerateDataKey()
+	if err != nil {
+		return err
+	}
+	_, err = kubeClient.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DigestKeySecretName,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{DigestKeyDataKey: key},
+	}, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// ClusterGetter returns the managed cluster with the name.
+type ClusterGetter func(ctx context.Context, name string) (*clusterv1.ManagedCluster, error)
+
+// ManifestEncryptor encrypts the manifests of the manifestworks with the public keys published by the clusters.
+// It is used by the work webhook for the manifestworks stored on the hub, and by the sources publishing the
+// manifestworks with the cloudevents drivers, which are not admitted by the webhook.
+type ManifestEncryptor struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	getCluster ClusterGetter
+
+	lock      sync.Mutex
+	digestKey []byte
+}
+
+// NewManifestEncryptor returns an encryptor reading the digest key from the namespace of the cluster manager.
+func NewManifestEncryptor(kubeClient kubernetes.Interface, namespace string, getCluster ClusterGetter) *ManifestEncryptor {
+	return &ManifestEncryptor{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		getCluster: getCluster,
+	}
+}
+
+// Encrypt encrypts the manifests of the manifestwork when the manifestwork has the encrypt manifests label. The
+// encrypted manifests of the existing manifestwork are reused if the manifests are not changed, so the manifestwork
+// is not changed each time it is applied. The existing manifestwork is nil if the manifestwork is created.
+func (e *ManifestEncryptor) Encrypt(ctx context.Context, work, existing *workv1.ManifestWork) error {
+	if work.Labels[EncryptManifestsLabelKey] != "true" {
+		return nil
+	}
+
+	var toEncrypt []int
+	for index, manifest := range work.Spec.Workload.Manifests {
+		encrypt, err := ShouldEncrypt(manifest.Raw)
+		if err != nil {
+			return fmt.Errorf("invalid manifest %d: %v", index, err)
+		}
+		if encrypt {
+			toEncrypt = append(toEncrypt, index)
+		}
+	}
+	if len(toEncrypt) == 0 {
+		return nil
+	}
+
+	publicKey, err := e.clusterPublicKey(ctx, work.Namespace)
+	if err != nil {
+		return err
+	}
+	digestKey, err := e.getDigestKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range toEncrypt {
+		raw := work.Spec.Workload.Manifests[index].Raw
+		if existing != nil && index < len(existing.Spec.Workload.Manifests) &&
+			MatchEncryptedManifest(existing.Spec.Workload.Manifests[index].Raw, publicKey, digestKey, raw) {
+			work.Spec.Workload.Manifests[index] = *existing.Spec.Workload.Manifests[index].DeepCopy()
+			continue
+		}
+
+		encrypted, err := EncryptManifest(publicKey, digestKey, raw)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt the manifest %d: %v", index, err)
+		}
+		work.Spec.Workload.Manifests[index] = workv1.Manifest{RawExtension: runtime.RawExtension{Raw: encrypted}}
+	}
+	return nil
+}
+
+// Seal seals the sensitive manifests of the template of a manifestworkreplicaset with the key of the hub. The
+// sealed manifests of the existing template are reused if the manifests are not changed. The existing manifests
+// are nil if the manifestworkreplicaset is created.
+func (e *ManifestEncryptor) Seal(ctx context.Context, manifests, existing []workv1.Manifest) error {
+	var digestKey []byte
+	for index, manifest := range manifests {
+		seal, err := ShouldEncrypt(manifest.Raw)
+		if err != nil {
+			return fmt.Errorf("invalid manifest %d: %v", index, err)
+		}
+		if !seal {
+			continue
+		}
+
+		if digestKey == nil {
+			if digestKey, err = e.getDigestKey(ctx); err != nil {
+				return err
+			}
+		}
+
+		if index < len(existing) && IsSealedManifest(existing[index].Raw) {
+			unsealed, err := UnsealManifest(digestKey, existing[index].Raw)
+			if err == nil && bytes.Equal(unsealed, manifest.Raw) {
+				manifests[index] = *existing[index].DeepCopy()
+				continue
+			}
+		}
+
+		sealed, err := SealManifest(digestKey, manifest.Raw)
+		if err != nil {
+			return fmt.Errorf("failed to seal the manifest %d: %v", index, err)
+		}
+		manifests[index] = workv1.Manifest{RawExtension: runtime.RawExtension{Raw: sealed}}
+	}
+	return nil
+}
+
+// Unseal opens the sealed manifests of the template of a manifestworkreplicaset, so the manifests are encrypted
+// with the public key of each cluster before the manifestworks are applied.
+func (e *ManifestEncryptor) Unseal(ctx context.Context, manifests []workv1.Manifest) error {
+	var digestKey []byte
+	for index, manifest := range manifests {
+		if !IsSealedManifest(manifest.Raw) {
+			continue
+		}
+
+		if digestKey == nil {
+			var err error
+			if digestKey, err = e.getDigestKey(ctx); err != nil {
+				return err
+			}
+		}
+
+		unsealed, err := UnsealManifest(digestKey, manifest.Raw)
+		if err != nil {
+			return err
+		}
+		manifests[index] = workv1.Manifest{RawExtension: runtime.RawExtension{Raw: unsealed}}
+	}
+	return nil
+}
+
+func (e *ManifestEncryptor) clusterPublicKey(ctx context.Context, clusterName string) (*rsa.PublicKey, error) {
+	cluster, err := e.getCluster(ctx, clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the managed cluster %s: %v", clusterName, err)
+	}
+	var publicKeyValue string
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == PublicKeyClusterClaimName {
+			publicKeyValue = claim.Value
+		}
+	}
+	if len(publicKeyValue) == 0 {
+		return nil, fmt.Errorf("the managed cluster %s does not publish the encryption key with the cluster claim %s",
+			clusterName, PublicKeyClusterClaimName)
+	}
+	publicKey, err := ParsePublicKey(publicKeyValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key of the managed cluster %s: %v", clusterName, err)
+	}
+	return publicKey, nil
+}
+
+// getDigestKey returns the digest key, the key is cached since it is never rotated.
+func (e *ManifestEncryptor) getDigestKey(ctx context.Context) ([]byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.digestKey != nil {
+		return e.digestKey, nil
+	}
+
+	secret, err := e.kubeClient.CoreV1().Secrets(e.namespace).Get(ctx, DigestKeySecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the digest key of the encrypted manifests: %v", err)
+	}
+	if len(secret.Data[DigestKeyDataKey]) != 32 {
+		return nil, fmt.Errorf("the digest key in secret %s/%s is not a 256 bits key", e.namespace, DigestKeySecretName)
+	}
+	e.digestKey = secret.Data[DigestKeyDataKey]
+	return e.digestKey, nil
+}
@@ -0,0 +1,253 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestEnsureDigestKey(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	if err := EnsureDigestKey(context.TODO(), kubeClient, "hub"); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := kubeClient.CoreV1().Secrets("hub").Get(context.TODO(), DigestKeySecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the existing key is kept
+	if err := EnsureDigestKey(context.TODO(), kubeClient, "hub"); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := kubeClient.CoreV1().Secrets("hub").Get(context.TODO(), DigestKeySecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual.Data[DigestKeyDataKey], secret.Data[DigestKeyDataKey]) {
+		t.Errorf("expected the digest key is not changed")
+	}
+}
+
+func TestMatchEncryptedManifest(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digestKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherDigestKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := EncryptManifest(&key.PublicKey, digestKey, []byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutDigest, err := EncryptManifest(&key.PublicKey, nil, []byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		encrypted []byte
+		key       *rsa.PublicKey
+		digestKey []byte
+		raw       []byte
+		expected  bool
+	}{
+		{name: "match", encrypted: encrypted, key: &key.PublicKey, digestKey: digestKey, raw: []byte(testSecret),
+			expected: true},
+		{name: "manifest changed", encrypted: encrypted, key: &key.PublicKey, digestKey: digestKey,
+			raw: []byte(testAnnotated)},
+		{name: "key changed", encrypted: encrypted, key: &otherKey.PublicKey, digestKey: digestKey,
+			raw: []byte(testSecret)},
+		{name: "digest key changed", encrypted: encrypted, key: &key.PublicKey, digestKey: otherDigestKey,
+			raw: []byte(testSecret)},
+		{name: "no digest", encrypted: withoutDigest, key: &key.PublicKey, digestKey: digestKey,
+			raw: []byte(testSecret)},
+		{name: "not encrypted", encrypted: []byte(testSecret), key: &key.PublicKey, digestKey: digestKey,
+			raw: []byte(testSecret)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := MatchEncryptedManifest(c.encrypted, c.key, c.digestKey, c.raw); actual != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestManifestEncryptor(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}
+	cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{{Name: PublicKeyClusterClaimName, Value: publicKey}}
+	getCluster := func(ctx context.Context, name string) (*clusterv1.ManagedCluster, error) {
+		if name != cluster.Name {
+			return nil, fmt.Errorf("cluster %s not found", name)
+		}
+		return cluster, nil
+	}
+	newWork := func(manifests ...string) *workv1.ManifestWork {
+		work := &workv1.ManifestWork{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "work1",
+				Namespace: "cluster1",
+				Labels:    map[string]string{EncryptManifestsLabelKey: "true"},
+			},
+		}
+		for _, manifest := range manifests {
+			work.Spec.Workload.Manifests = append(work.Spec.Workload.Manifests,
+				workv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(manifest)}})
+		}
+		return work
+	}
+
+	// the manifests are not encrypted without the digest key
+	encryptor := NewManifestEncryptor(kubefake.NewSimpleClientset(), "hub", getCluster)
+	if err := encryptor.Encrypt(context.TODO(), newWork(testSecret), nil); err == nil {
+		t.Errorf("expected error without the digest key")
+	}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	if err := EnsureDigestKey(context.TODO(), kubeClient, "hub"); err != nil {
+		t.Fatal(err)
+	}
+	encryptor = NewManifestEncryptor(kubeClient, "hub", getCluster)
+
+	existing := newWork(testSecret, testConfigMap)
+	if err := encryptor.Encrypt(context.TODO(), existing, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncryptedManifest(existing.Spec.Workload.Manifests[0].Raw) ||
+		IsEncryptedManifest(existing.Spec.Workload.Manifests[1].Raw) {
+		t.Errorf("expected only the secret is encrypted")
+	}
+
+	// the encrypted manifests of the existing manifestwork are reused
+	work := newWork(testSecret, testConfigMap)
+	if err := encryptor.Encrypt(context.TODO(), work, existing); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(work.Spec.Workload.Manifests[0].Raw, existing.Spec.Workload.Manifests[0].Raw) {
+		t.Errorf("expected the encrypted manifest is reused")
+	}
+
+	// the manifests are encrypted again if they are changed
+	work = newWork(testAnnotated, testSecret)
+	if err := encryptor.Encrypt(context.TODO(), work, existing); err != nil {
+		t.Fatal(err)
+	}
+	for index, expected := range []string{testAnnotated, testSecret} {
+		decrypted, err := DecryptManifest(key, work.Spec.Workload.Manifests[index].Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decrypted) != expected {
+			t.Errorf("expected %s, got %s", expected, decrypted)
+		}
+	}
+
+	// the manifestworks without the label are not encrypted
+	work = newWork(testSecret)
+	work.Labels = nil
+	if err := encryptor.Encrypt(context.TODO(), work, nil); err != nil {
+		t.Fatal(err)
+	}
+	if IsEncryptedManifest(work.Spec.Workload.Manifests[0].Raw) {
+		t.Errorf("expected the manifest is not encrypted")
+	}
+}
+
+func TestSealManifests(t *testing.T) {
+	newManifests := func(manifests ...string) []workv1.Manifest {
+		var result []workv1.Manifest
+		for _, manifest := range manifests {
+			result = append(result, workv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(manifest)}})
+		}
+		return result
+	}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	if err := EnsureDigestKey(context.TODO(), kubeClient, "hub"); err != nil {
+		t.Fatal(err)
+	}
+	encryptor := NewManifestEncryptor(kubeClient, "hub", nil)
+
+	existing := newManifests(testSecret, testConfigMap)
+	if err := encryptor.Seal(context.TODO(), existing, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealedManifest(existing[0].Raw) || IsSealedManifest(existing[1].Raw) {
+		t.Errorf("expected only the secret is sealed")
+	}
+
+	// the sealed manifests of the existing template are reused
+	manifests := newManifests(testSecret, testConfigMap)
+	if err := encryptor.Seal(context.TODO(), manifests, existing); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(manifests[0].Raw, existing[0].Raw) {
+		t.Errorf("expected the sealed manifest is reused")
+	}
+
+	// the sealed manifests are not sealed again
+	if err := encryptor.Seal(context.TODO(), manifests, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(manifests[0].Raw, existing[0].Raw) {
+		t.Errorf("expected the sealed manifest is not sealed again")
+	}
+
+	// the manifests are sealed again if they are changed
+	manifests = newManifests(testAnnotated, testSecret)
+	if err := encryptor.Seal(context.TODO(), manifests, existing); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(manifests[1].Raw, existing[0].Raw) {
+		t.Errorf("expected the manifest is sealed again")
+	}
+
+	if err := encryptor.Unseal(context.TODO(), manifests); err != nil {
+		t.Fatal(err)
+	}
+	for index, expected := range []string{testAnnotated, testSecret} {
+		if string(manifests[index].Raw) != expected {
+			t.Errorf("expected %s, got %s", expected, manifests[index].Raw)
+		}
+	}
+
+	// the sealed manifests are not opened with another digest key
+	otherClient := kubefake.NewSimpleClientset()
+	if err := EnsureDigestKey(context.TODO(), otherClient, "hub"); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewManifestEncryptor(otherClient, "hub", nil).Unseal(context.TODO(), existing); err == nil {
+		t.Errorf("expected error to unseal with another key")
+	}
+}
@@ -0,0 +1,308 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+)
+
+const (
+	// ClusterFrozenLabelKey is the label on the ManagedCluster to freeze the cluster. When the value is "true", the
+	// ManifestWorkReplicaSets, the addon configuration rollouts and the work agent hold the new applies and the
+	// deletions on the cluster, while the status is still reported.
+	ClusterFrozenLabelKey = "cluster.open-cluster-management.io/frozen"
+
+	// FreezeOverrideAnnotationKey is the break-glass annotation to let the changes of an object go through the
+	// freeze of the clusters. It is set to "true" on the ManifestWorkReplicaSet, the ClusterManagementAddOn or the
+	// ManifestWork by a user authorized to freeze the clusters, and only takes effect once the webhook records the
+	// override in the freeze audit of the object.
+	FreezeOverrideAnnotationKey = "cluster.open-cluster-management.io/freeze-override"
+
+	// FreezeAuditAnnotationKey is the annotation recording who froze and unfroze the ManagedCluster, or who
+	// overrode the freeze with the ManifestWorkReplicaSet, the ClusterManagementAddOn or the ManifestWork. It is
+	// maintained by the webhooks, the value is a json list of FreezeRecord.
+	FreezeAuditAnnotationKey = "cluster.open-cluster-management.io/freeze-audit"
+
+	// FreezeSubresource is the subresource of the ManagedCluster checked with the SubjectAccessReview when the
+	// cluster is frozen, unfrozen or the freeze of the cluster is overridden.
+	FreezeSubresource = "freeze"
+
+	// MaxFreezeAuditRecords is the number of the latest records kept in the freeze audit annotation.
+	MaxFreezeAuditRecords = 10
+
+	// DecommissionHookWorkNamePrefix is the name prefix of the manifestworks of the pre-delete hooks run by the
+	// decommission of the cluster.
+	DecommissionHookWorkNamePrefix = "decommission-hook-"
+	// DecommissionAgentWorkName is the name of the manifestwork removing the agents by the decommission of the
+	// cluster.
+	DecommissionAgentWorkName = "decommission-klusterlet"
+	// ClusterProfileAccessWorkName is the name of the manifestwork provisioning the ClusterProfile access service
+	// account on the cluster.
+	ClusterProfileAccessWorkName = "cluster-profile-access"
+)
+
+type FreezeAction string
+
+const (
+	FreezeActionFreeze   FreezeAction = "Freeze"
+	FreezeActionUnfreeze FreezeAction = "Unfreeze"
+	FreezeActionOverride FreezeAction = "Override"
+)
+
+// FreezeRecord is a record of freezing or unfreezing a cluster.
+type FreezeRecord struct {
+	Action FreezeAction `json:"action"`
+	User   string       `json:"user"`
+	Time   metav1.Time  `json:"time"`
+}
+
+// IsClusterFrozen returns true if the cluster is frozen.
+func IsClusterFrozen(cluster *clusterv1.ManagedCluster) bool {
+	return cluster != nil && cluster.Labels[ClusterFrozenLabelKey] == "true"
+}
+
+// IsFreezeOverridden returns true if the object with the annotations has the break-glass override annotation, and
+// the override is the latest record in the freeze audit of the object.
+func IsFreezeOverridden(annotations map[string]string) bool {
+	if annotations[FreezeOverrideAnnotationKey] != "true" {
+		return false
+	}
+	records, err := GetFreezeRecords(annotations)
+	if err != nil || len(records) == 0 {
+		return false
+	}
+	return records[len(records)-1].Action == FreezeActionOverride
+}
+
+// CopyFreezeOverride copies the break-glass override annotation and the freeze audit from the annotations of the
+// object the override is decided on to the object, if the override is in effect.
+func CopyFreezeOverride(obj metav1.Object, annotations map[string]string) {
+	if !IsFreezeOverridden(annotations) {
+		return
+	}
+	objAnnotations := obj.GetAnnotations()
+	if objAnnotations == nil {
+		objAnnotations = map[string]string{}
+	}
+	objAnnotations[FreezeOverrideAnnotationKey] = annotations[FreezeOverrideAnnotationKey]
+	objAnnotations[FreezeAuditAnnotationKey] = annotations[FreezeAuditAnnotationKey]
+	obj.SetAnnotations(objAnnotations)
+}
+
+// IsFreezeExempted returns true if the manifestwork is owned by the system, the addon, the decommission and the
+// ClusterProfile access manifestworks are not held on the frozen cluster, since they are decided by the
+// controllers on the hub.
+func IsFreezeExempted(work metav1.Object) bool {
+	name, labels := work.GetName(), work.GetLabels()
+	switch {
+	case len(labels[addonv1alpha1.AddonLabelKey]) > 0:
+		return true
+	case strings.HasPrefix(name, DecommissionHookWorkNamePrefix), name == DecommissionAgentWorkName:
+		return true
+	default:
+		return name == ClusterProfileAccessWorkName
+	}
+}
+
+// ValidateClusterFreeze validates the frozen label and the freeze override annotation, their values must be "true"
+// or "false".
+func ValidateClusterFreeze(labels, annotations map[string]string) error {
+	if value, ok := labels[ClusterFrozenLabelKey]; ok && value != "true" && value != "false" {
+		return fmt.Errorf("invalid value %q of label %s, it must be true or false", value, ClusterFrozenLabelKey)
+	}
+	if value, ok := annotations[FreezeOverrideAnnotationKey]; ok && value != "true" && value != "false" {
+		return fmt.Errorf("invalid value %q of annotation %s, it must be true or false", value, FreezeOverrideAnnotationKey)
+	}
+	return nil
+}
+
+// GetFreezeRecords returns the freeze records in the annotations of the cluster.
+func GetFreezeRecords(annotations map[string]string) ([]FreezeRecord, error) {
+	value, ok := annotations[FreezeAuditAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var records []FreezeRecord
+	if err := json.Unmarshal([]byte(value), &records); err != nil {
+		return nil, fmt.Errorf("failed to decode freeze records: %w", err)
+	}
+	return records, nil
+}
+
+// AppendFreezeRecord appends the record into the freeze audit annotation of the object, only the latest
+// MaxFreezeAuditRecords records are kept.
+func AppendFreezeRecord(obj metav1.Object, record FreezeRecord) error {
+	records, err := GetFreezeRecords(obj.GetAnnotations())
+	if err != nil {
+		// the broken records are dropped, so the new record is always kept.
+		klog.Warningf("drop the freeze records of %s: %v", obj.GetName(), err)
+		records = nil
+	}
+	records = append(records, record)
+	if len(records) > MaxFreezeAuditRecords {
+		records = records[len(records)-MaxFreezeAuditRecords:]
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[FreezeAuditAnnotationKey] = string(data)
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// KeepFreezeAudit reverts the changes of the freeze audit annotation of the object from a request, so the
+// annotation is only changed by the webhooks. The old annotations are nil if the object is created.
+func KeepFreezeAudit(obj metav1.Object, oldAnnotations map[string]string) {
+	annotations := obj.GetAnnotations()
+	oldAudit, ok := oldAnnotations[FreezeAuditAnnotationKey]
+	if !ok {
+		delete(annotations, FreezeAuditAnnotationKey)
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[FreezeAuditAnnotationKey] = oldAudit
+	obj.SetAnnotations(annotations)
+}
+
+// AllowClusterFreeze checks with the SubjectAccessReview whether the user is authorized to freeze, unfreeze and
+// override the freeze of the cluster. The cluster name is empty to check the authorization on all the clusters.
+func AllowClusterFreeze(ctx context.Context, kubeClient kubernetes.Interface,
+	userInfo authenticationv1.UserInfo, clusterName string) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range userInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       "register.open-cluster-management.io",
+				Resource:    "managedclusters",
+				Verb:        "update",
+				Subresource: FreezeSubresource,
+				Name:        clusterName,
+			},
+		},
+	}
+	sar, err := kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed, nil
+}
+
+// AdmitFreezeOverride is called by the webhooks of the objects supporting the break-glass override annotation.
+// When the annotation is set, it checks whether the user is authorized to override the freeze of the cluster, or
+// all the clusters if the cluster name is empty, and records the override in the freeze audit of the object. The
+// old annotations are nil if the object is created.
+func AdmitFreezeOverride(ctx context.Context, kubeClient kubernetes.Interface, userInfo authenticationv1.UserInfo,
+	clusterName string, obj metav1.Object, oldAnnotations map[string]string, now time.Time) error {
+	KeepFreezeAudit(obj, oldAnnotations)
+	if obj.GetAnnotations()[FreezeOverrideAnnotationKey] != "true" || oldAnnotations[FreezeOverrideAnnotationKey] == "true" {
+		return nil
+	}
+
+	resource := clusterv1.Resource("managedclusters/" + FreezeSubresource)
+	allowed, err := AllowClusterFreeze(ctx, kubeClient, userInfo, clusterName)
+	if err != nil {
+		return errors.NewForbidden(resource, clusterName, err)
+	}
+	if !allowed {
+		return errors.NewForbidden(resource, clusterName,
+			fmt.Errorf("user %q cannot override the freeze of the clusters", userInfo.Username))
+	}
+
+	if err := AppendFreezeRecord(obj, FreezeRecord{
+		Action: FreezeActionOverride,
+		User:   userInfo.Username,
+		Time:   metav1.NewTime(now),
+	}); err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+// ClusterFreezeFilter holds the changes on the frozen clusters.
+type ClusterFreezeFilter struct {
+	clusterLister clusterlisterv1.ManagedClusterLister
+}
+
+func NewClusterFreezeFilter(clusterLister clusterlisterv1.ManagedClusterLister) *ClusterFreezeFilter {
+	return &ClusterFreezeFilter{clusterLister: clusterLister}
+}
+
+// IsFrozen returns true if the changes of the object with the annotations on the cluster are held. A cluster
+// failed to get is regarded as frozen, while a cluster not found is not.
+func (f *ClusterFreezeFilter) IsFrozen(clusterName string, annotations map[string]string) bool {
+	if f == nil || IsFreezeOverridden(annotations) {
+		return false
+	}
+
+	cluster, err := f.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		return false
+	case err != nil:
+		klog.Errorf("failed to get cluster %s: %v", clusterName, err)
+		return true
+	}
+	return IsClusterFrozen(cluster)
+}
+
+// Filter removes the frozen clusters from the clusters to apply and the clusters removed of the rollout result of
+// the object with the annotations, so they are neither applied nor deleted. It returns the held clusters.
+func (f *ClusterFreezeFilter) Filter(result *clustersdkv1alpha1.RolloutResult, annotations map[string]string) []string {
+	if f == nil || IsFreezeOverridden(annotations) {
+		return nil
+	}
+
+	var frozen []string
+	var clustersToRollout []clustersdkv1alpha1.ClusterRolloutStatus
+	for _, status := range result.ClustersToRollout {
+		if status.Status == clustersdkv1alpha1.ToApply && f.IsFrozen(status.ClusterName, annotations) {
+			frozen = append(frozen, status.ClusterName)
+			continue
+		}
+		clustersToRollout = append(clustersToRollout, status)
+	}
+
+	var clustersRemoved []clustersdkv1alpha1.ClusterRolloutStatus
+	for _, status := range result.ClustersRemoved {
+		if f.IsFrozen(status.ClusterName, annotations) {
+			frozen = append(frozen, status.ClusterName)
+			continue
+		}
+		clustersRemoved = append(clustersRemoved, status)
+	}
+
+	result.ClustersToRollout = clustersToRollout
+	result.ClustersRemoved = clustersRemoved
+	return frozen
+}
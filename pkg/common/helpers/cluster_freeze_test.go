@@ -0,0 +1,325 @@
+package helpers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakecluster "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+)
+
+func TestClusterFreezeFilter(t *testing.T) {
+	clusterInformers := clusterv1informers.NewSharedInformerFactory(fakecluster.NewSimpleClientset(), 10*time.Minute)
+	for _, cluster := range []*clusterv1.ManagedCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster2", Labels: map[string]string{ClusterFrozenLabelKey: "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster3", Labels: map[string]string{ClusterFrozenLabelKey: "true"}}},
+	} {
+		if err := clusterInformers.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filter := NewClusterFreezeFilter(clusterInformers.Cluster().V1().ManagedClusters().Lister())
+
+	newResult := func() *clustersdkv1alpha1.RolloutResult {
+		return &clustersdkv1alpha1.RolloutResult{
+			ClustersToRollout: []clustersdkv1alpha1.ClusterRolloutStatus{
+				{ClusterName: "cluster1", Status: clustersdkv1alpha1.ToApply},
+				{ClusterName: "cluster2", Status: clustersdkv1alpha1.ToApply},
+				{ClusterName: "cluster3", Status: clustersdkv1alpha1.Progressing},
+				{ClusterName: "cluster4", Status: clustersdkv1alpha1.ToApply},
+			},
+			ClustersRemoved: []clustersdkv1alpha1.ClusterRolloutStatus{
+				{ClusterName: "cluster3"},
+				{ClusterName: "cluster4"},
+			},
+		}
+	}
+	clusterNames := func(statuses []clustersdkv1alpha1.ClusterRolloutStatus) []string {
+		var names []string
+		for _, status := range statuses {
+			names = append(names, status.ClusterName)
+		}
+		return names
+	}
+
+	cases := []struct {
+		name              string
+		annotations       map[string]string
+		expectedFrozen    []string
+		expectedToRollout []string
+		expectedRemoved   []string
+	}{
+		{
+			name:              "frozen clusters are held",
+			expectedFrozen:    []string{"cluster2", "cluster3"},
+			expectedToRollout: []string{"cluster1", "cluster3", "cluster4"},
+			expectedRemoved:   []string{"cluster4"},
+		},
+		{
+			name: "freeze is overridden",
+			annotations: map[string]string{
+				FreezeOverrideAnnotationKey: "true",
+				FreezeAuditAnnotationKey:    `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`,
+			},
+			expectedToRollout: []string{"cluster1", "cluster2", "cluster3", "cluster4"},
+			expectedRemoved:   []string{"cluster3", "cluster4"},
+		},
+		{
+			name:              "override is not recorded",
+			annotations:       map[string]string{FreezeOverrideAnnotationKey: "true"},
+			expectedFrozen:    []string{"cluster2", "cluster3"},
+			expectedToRollout: []string{"cluster1", "cluster3", "cluster4"},
+			expectedRemoved:   []string{"cluster4"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result := newResult()
+			frozen := filter.Filter(result, c.annotations)
+			if !reflect.DeepEqual(frozen, c.expectedFrozen) {
+				t.Errorf("expected frozen clusters %v, got %v", c.expectedFrozen, frozen)
+			}
+			if actual := clusterNames(result.ClustersToRollout); !reflect.DeepEqual(actual, c.expectedToRollout) {
+				t.Errorf("expected clusters to rollout %v, got %v", c.expectedToRollout, actual)
+			}
+			if actual := clusterNames(result.ClustersRemoved); !reflect.DeepEqual(actual, c.expectedRemoved) {
+				t.Errorf("expected clusters removed %v, got %v", c.expectedRemoved, actual)
+			}
+		})
+	}
+}
+
+func TestValidateClusterFreeze(t *testing.T) {
+	cases := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no freeze",
+		},
+		{
+			name:        "valid freeze",
+			labels:      map[string]string{ClusterFrozenLabelKey: "true"},
+			annotations: map[string]string{FreezeOverrideAnnotationKey: "false"},
+		},
+		{
+			name:        "invalid frozen label",
+			labels:      map[string]string{ClusterFrozenLabelKey: "yes"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid override annotation",
+			annotations: map[string]string{FreezeOverrideAnnotationKey: "True"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateClusterFreeze(c.labels, c.annotations)
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCopyFreezeOverride(t *testing.T) {
+	audit := `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+	}{
+		{
+			name: "no override",
+		},
+		{
+			name:        "override not admitted",
+			annotations: map[string]string{FreezeOverrideAnnotationKey: "true"},
+		},
+		{
+			name:        "override",
+			annotations: map[string]string{FreezeOverrideAnnotationKey: "true", FreezeAuditAnnotationKey: audit},
+			expected:    map[string]string{FreezeOverrideAnnotationKey: "true", FreezeAuditAnnotationKey: audit},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work := &metav1.ObjectMeta{Name: "work1"}
+			CopyFreezeOverride(work, c.annotations)
+			if !reflect.DeepEqual(work.Annotations, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, work.Annotations)
+			}
+		})
+	}
+}
+
+func TestIsFreezeExempted(t *testing.T) {
+	cases := []struct {
+		name     string
+		work     metav1.ObjectMeta
+		expected bool
+	}{
+		{
+			name: "manifestwork",
+			work: metav1.ObjectMeta{Name: "work1"},
+		},
+		{
+			name:     "addon",
+			work:     metav1.ObjectMeta{Name: "addon-test-deploy-0", Labels: map[string]string{"open-cluster-management.io/addon-name": "test"}},
+			expected: true,
+		},
+		{
+			name:     "decommission hook",
+			work:     metav1.ObjectMeta{Name: DecommissionHookWorkNamePrefix + "backup"},
+			expected: true,
+		},
+		{
+			name:     "decommission agent",
+			work:     metav1.ObjectMeta{Name: DecommissionAgentWorkName},
+			expected: true,
+		},
+		{
+			name:     "cluster profile access",
+			work:     metav1.ObjectMeta{Name: ClusterProfileAccessWorkName},
+			expected: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := IsFreezeExempted(&c.work); actual != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAppendFreezeRecord(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:        "cluster1",
+		Annotations: map[string]string{FreezeAuditAnnotationKey: "{"},
+	}}
+
+	for i := 0; i < MaxFreezeAuditRecords+2; i++ {
+		if err := AppendFreezeRecord(cluster, FreezeRecord{Action: FreezeActionFreeze, User: "user1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AppendFreezeRecord(cluster, FreezeRecord{Action: FreezeActionUnfreeze, User: "user2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := GetFreezeRecords(cluster.Annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != MaxFreezeAuditRecords {
+		t.Errorf("expected %d records, got %d", MaxFreezeAuditRecords, len(records))
+	}
+	if last := records[len(records)-1]; last.Action != FreezeActionUnfreeze || last.User != "user2" {
+		t.Errorf("unexpected last record %v", last)
+	}
+}
+
+func TestAdmitFreezeOverride(t *testing.T) {
+	audit := `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`
+	overridden := map[string]string{FreezeOverrideAnnotationKey: "true", FreezeAuditAnnotationKey: audit}
+
+	cases := []struct {
+		name             string
+		annotations      map[string]string
+		oldAnnotations   map[string]string
+		allowed          bool
+		expectedError    bool
+		expectedRecords  int
+		expectedOverride bool
+	}{
+		{
+			name: "no override",
+		},
+		{
+			name:          "override without permission",
+			annotations:   map[string]string{FreezeOverrideAnnotationKey: "true"},
+			expectedError: true,
+		},
+		{
+			name:             "override with permission",
+			annotations:      map[string]string{FreezeOverrideAnnotationKey: "true"},
+			allowed:          true,
+			expectedRecords:  1,
+			expectedOverride: true,
+		},
+		{
+			name:             "override is kept",
+			annotations:      map[string]string{FreezeOverrideAnnotationKey: "true"},
+			oldAnnotations:   overridden,
+			expectedRecords:  1,
+			expectedOverride: true,
+		},
+		{
+			name:        "audit is forged",
+			annotations: map[string]string{FreezeOverrideAnnotationKey: "true", FreezeAuditAnnotationKey: audit},
+			oldAnnotations: map[string]string{
+				FreezeOverrideAnnotationKey: "true",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					if sar.Spec.ResourceAttributes.Subresource != FreezeSubresource ||
+						sar.Spec.ResourceAttributes.Name != "cluster1" {
+						t.Errorf("unexpected subject access review %v", sar.Spec.ResourceAttributes)
+					}
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: c.allowed},
+					}, nil
+				},
+			)
+
+			obj := &metav1.ObjectMeta{Name: "work1", Annotations: c.annotations}
+			err := AdmitFreezeOverride(context.TODO(), kubeClient, authenticationv1.UserInfo{Username: "user1"},
+				"cluster1", obj, c.oldAnnotations, time.Now())
+			if c.expectedError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			records, err := GetFreezeRecords(obj.Annotations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != c.expectedRecords {
+				t.Errorf("expected %d records, got %v", c.expectedRecords, records)
+			}
+			if actual := IsFreezeOverridden(obj.Annotations); actual != c.expectedOverride {
+				t.Errorf("expected override %v, got %v", c.expectedOverride, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package helpers
+
+// The resources on the managed cluster to provide the access of a ClusterProfile. The hub provisions the service
+// account and the token configmap with a ManifestWork, the registration agent requests a token of the service
+// account, encrypts it with the public key of the hub in the annotation of the token configmap, and writes the
+// encrypted token into the configmap. The hub reads the encrypted token with the status feedback of the
+// ManifestWork.
+const (
+	ClusterProfileAccessNamespace          = "open-cluster-management-cluster-profile"
+	ClusterProfileAccessServiceAccount     = "cluster-profile-access"
+	ClusterProfileAccessTokenConfigMapName = "cluster-profile-access-token"
+
+	// ClusterProfileAccessGenerationAnnotationKey is the annotation on the token configmap set by the hub, a new
+	// token is requested once the generation changes.
+	ClusterProfileAccessGenerationAnnotationKey = "open-cluster-management.io/access-generation"
+	// ClusterProfileAccessExpirationAnnotationKey is the annotation on the token configmap set by the hub for the
+	// expiration seconds of the token.
+	ClusterProfileAccessExpirationAnnotationKey = "open-cluster-management.io/access-expiration-seconds"
+	// ClusterProfileAccessPublicKeyAnnotationKey is the annotation on the token configmap set by the hub for the
+	// base64 encoded PKIX public key to encrypt the token.
+	ClusterProfileAccessPublicKeyAnnotationKey = "open-cluster-management.io/access-public-key"
+
+	// The data keys of the token configmap written by the registration agent. The token is the base64 encoded json
+	// of the encrypted token, the expiration is the time the token expires in RFC3339.
+	ClusterProfileAccessTokenKey      = "token"
+	ClusterProfileAccessGenerationKey = "generation"
+	ClusterProfileAccessExpirationKey = "expiration"
+)
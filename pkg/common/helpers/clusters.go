@@ -12,6 +12,10 @@ import (
 const (
 	// GcFinalizer is added to the managedCluster for resource cleanup, which maintained by gc controller.
 	GcFinalizer = "cluster.open-cluster-management.io/resource-cleanup"
+	// DecommissionFinalizer is added to the managedCluster with a decommission mode, which is maintained by the
+	// decommission controller. The resource cleanup and the removal of the cluster rbac wait until the
+	// decommission completes.
+	DecommissionFinalizer = "cluster.open-cluster-management.io/decommission"
 )
 
 type PlacementDecisionGetter struct {
@@ -0,0 +1,160 @@
+package helpers
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	clustersdkv1beta2 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta2"
+)
+
+const (
+	// ParentClusterSetLabel is the label on a ManagedClusterSet to nest it under the parent ManagedClusterSet. The
+	// clusters of a child set are also the clusters of its ancestors, so the bindings of an ancestor set grant the
+	// visibility to the clusters of the child set.
+	ParentClusterSetLabel = "cluster.open-cluster-management.io/parent-clusterset"
+
+	// ChildClusterSetsAnnotation is the annotation on a ManagedClusterSet with the comma separated names of the child
+	// sets to create under it. The admins of a set can create the child sets with it without the rights to create
+	// ManagedClusterSets.
+	ChildClusterSetsAnnotation = "cluster.open-cluster-management.io/child-clustersets"
+)
+
+// GetValidManagedClusterSetBindings returns the bindings in the namespace which refer to an existing clusterset.
+func GetValidManagedClusterSetBindings(
+	namespace string,
+	clusterSetBindingLister clusterlisterv1beta2.ManagedClusterSetBindingLister,
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister) ([]*clusterv1beta2.ManagedClusterSetBinding, error) {
+	// get all clusterset bindings under the namespace
+	bindings, err := clusterSetBindingLister.ManagedClusterSetBindings(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var validBindings []*clusterv1beta2.ManagedClusterSetBinding
+	for _, binding := range bindings {
+		// ignore clustersetbinding refers to a non-existent clusterset
+		_, err := clusterSetLister.Get(binding.Name)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		validBindings = append(validBindings, binding)
+	}
+
+	return validBindings, nil
+}
+
+// GetClusterSetAncestors returns the ancestors of the clusterset from its parent to the root. It stops at a
+// non-existent parent or a cycle of the parent references.
+func GetClusterSetAncestors(
+	clusterSet *clusterv1beta2.ManagedClusterSet,
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister) ([]*clusterv1beta2.ManagedClusterSet, error) {
+	var ancestors []*clusterv1beta2.ManagedClusterSet
+	visited := sets.New[string](clusterSet.Name)
+	for parentName := clusterSet.Labels[ParentClusterSetLabel]; len(parentName) > 0 && !visited.Has(parentName); {
+		parent, err := clusterSetLister.Get(parentName)
+		if errors.IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		visited.Insert(parentName)
+		ancestors = append(ancestors, parent)
+		parentName = parent.Labels[ParentClusterSetLabel]
+	}
+	return ancestors, nil
+}
+
+// GetClusterSetDescendants returns the children of the clusterset and their descendants.
+func GetClusterSetDescendants(
+	clusterSet *clusterv1beta2.ManagedClusterSet,
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister) ([]*clusterv1beta2.ManagedClusterSet, error) {
+	var descendants []*clusterv1beta2.ManagedClusterSet
+	visited := sets.New[string](clusterSet.Name)
+	parents := []string{clusterSet.Name}
+	for len(parents) > 0 {
+		requirement, err := labels.NewRequirement(ParentClusterSetLabel, selection.In, parents)
+		if err != nil {
+			return nil, err
+		}
+		children, err := clusterSetLister.List(labels.NewSelector().Add(*requirement))
+		if err != nil {
+			return nil, err
+		}
+
+		parents = nil
+		for _, child := range children {
+			if visited.Has(child.Name) {
+				continue
+			}
+			visited.Insert(child.Name)
+			descendants = append(descendants, child)
+			parents = append(parents, child.Name)
+		}
+	}
+	return descendants, nil
+}
+
+// GetClustersFromClusterSetTree returns the clusters of the clusterset and all its descendants.
+func GetClustersFromClusterSetTree(
+	clusterSet *clusterv1beta2.ManagedClusterSet,
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister,
+	clusterLister clusterlisterv1.ManagedClusterLister) ([]*clusterv1.ManagedCluster, error) {
+	descendants, err := GetClusterSetDescendants(clusterSet, clusterSetLister)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []*clusterv1.ManagedCluster
+	selected := sets.New[string]()
+	for _, set := range append([]*clusterv1beta2.ManagedClusterSet{clusterSet}, descendants...) {
+		members, err := clustersdkv1beta2.GetClustersFromClusterSet(set, clusterLister)
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range members {
+			if selected.Has(cluster.Name) {
+				continue
+			}
+			selected.Insert(cluster.Name)
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters, nil
+}
+
+// GetClusterSetsOfClusterWithAncestors returns the clustersets selecting the cluster and their ancestors.
+func GetClusterSetsOfClusterWithAncestors(
+	cluster *clusterv1.ManagedCluster,
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister) ([]*clusterv1beta2.ManagedClusterSet, error) {
+	clusterSets, err := clustersdkv1beta2.GetClusterSetsOfCluster(cluster, clusterSetLister)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*clusterv1beta2.ManagedClusterSet
+	found := sets.New[string]()
+	for _, clusterSet := range clusterSets {
+		ancestors, err := GetClusterSetAncestors(clusterSet, clusterSetLister)
+		if err != nil {
+			return nil, err
+		}
+		for _, set := range append([]*clusterv1beta2.ManagedClusterSet{clusterSet}, ancestors...) {
+			if found.Has(set.Name) {
+				continue
+			}
+			found.Insert(set.Name)
+			result = append(result, set)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+func newTreeClusterSet(name, parent string) *clusterv1beta2.ManagedClusterSet {
+	clusterSet := &clusterv1beta2.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: clusterv1beta2.ManagedClusterSetSpec{
+			ClusterSelector: clusterv1beta2.ManagedClusterSelector{
+				SelectorType: clusterv1beta2.ExclusiveClusterSetLabel,
+			},
+		},
+	}
+	if len(parent) > 0 {
+		clusterSet.Labels = map[string]string{ParentClusterSetLabel: parent}
+	}
+	return clusterSet
+}
+
+func newTreeCluster(name, clusterSet string) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{clusterv1beta2.ClusterSetLabel: clusterSet},
+		},
+	}
+}
+
+func clusterSetNames(clusterSets []*clusterv1beta2.ManagedClusterSet) []string {
+	var names []string
+	for _, clusterSet := range clusterSets {
+		names = append(names, clusterSet.Name)
+	}
+	return names
+}
+
+func TestClusterSetTree(t *testing.T) {
+	// root -> child1 -> grandchild, root -> child2, and cycle1 <-> cycle2
+	clusterSets := []*clusterv1beta2.ManagedClusterSet{
+		newTreeClusterSet("root", ""),
+		newTreeClusterSet("child1", "root"),
+		newTreeClusterSet("child2", "root"),
+		newTreeClusterSet("grandchild", "child1"),
+		newTreeClusterSet("orphan", "missing"),
+		newTreeClusterSet("cycle1", "cycle2"),
+		newTreeClusterSet("cycle2", "cycle1"),
+	}
+	clusters := []*clusterv1.ManagedCluster{
+		newTreeCluster("cluster1", "root"),
+		newTreeCluster("cluster2", "child1"),
+		newTreeCluster("cluster3", "grandchild"),
+		newTreeCluster("cluster4", "child2"),
+	}
+
+	informerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), 10*time.Minute)
+	for _, clusterSet := range clusterSets {
+		if err := informerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().GetStore().Add(clusterSet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, cluster := range clusters {
+		if err := informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setLister := informerFactory.Cluster().V1beta2().ManagedClusterSets().Lister()
+	clusterLister := informerFactory.Cluster().V1().ManagedClusters().Lister()
+
+	ancestors, err := GetClusterSetAncestors(clusterSets[3], setLister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := clusterSetNames(ancestors); !reflect.DeepEqual(names, []string{"child1", "root"}) {
+		t.Errorf("unexpected ancestors %v", names)
+	}
+	ancestors, err = GetClusterSetAncestors(clusterSets[4], setLister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ancestors) != 0 {
+		t.Errorf("expected no ancestors, but got %v", clusterSetNames(ancestors))
+	}
+	ancestors, err = GetClusterSetAncestors(clusterSets[5], setLister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := clusterSetNames(ancestors); !reflect.DeepEqual(names, []string{"cycle2"}) {
+		t.Errorf("unexpected ancestors %v", names)
+	}
+
+	descendants, err := GetClusterSetDescendants(clusterSets[0], setLister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := clusterSetNames(descendants)
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"child1", "child2", "grandchild"}) {
+		t.Errorf("unexpected descendants %v", names)
+	}
+
+	members, err := GetClustersFromClusterSetTree(clusterSets[1], setLister, clusterLister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clusterNames []string
+	for _, cluster := range members {
+		clusterNames = append(clusterNames, cluster.Name)
+	}
+	sort.Strings(clusterNames)
+	if !reflect.DeepEqual(clusterNames, []string{"cluster2", "cluster3"}) {
+		t.Errorf("unexpected clusters %v", clusterNames)
+	}
+
+	clusterSetsOfCluster, err := GetClusterSetsOfClusterWithAncestors(clusters[2], setLister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = clusterSetNames(clusterSetsOfCluster)
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"child1", "grandchild", "root"}) {
+		t.Errorf("unexpected clustersets of cluster %v", names)
+	}
+}
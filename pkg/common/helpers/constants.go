@@ -7,3 +7,14 @@ const (
 )
 
 const GRPCCAuthSigner = "open-cluster-management.io/grpc"
+
+// AddonHealthProbesAnnotationKey is the annotation on the ClusterManagementAddOn and ManagedClusterAddOn
+// to define the health probes run by the registration agent against the addon agent pods.
+const AddonHealthProbesAnnotationKey = "addon.open-cluster-management.io/health-probes"
+
+// The cluster claims reported by the registration agent with the name and the install mode of the klusterlet the
+// agent is deployed by, so the hub is able to remove the klusterlet when the cluster is decommissioned.
+const (
+	KlusterletNameClusterClaimName        = "name.klusterlet.open-cluster-management.io"
+	KlusterletInstallModeClusterClaimName = "installmode.klusterlet.open-cluster-management.io"
+)
@@ -0,0 +1,110 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+)
+
+// HealthProbeType is the type of the health probe.
+type HealthProbeType string
+
+const (
+	// HTTPHealthProbe sends a GET request to the addon pods, the probe succeeds if the expression
+	// evaluates to true, or the response code is 2xx/3xx when no expression is set.
+	HTTPHealthProbe HealthProbeType = "HTTP"
+	// TCPHealthProbe opens a tcp connection to the addon pods, the probe succeeds if the connection
+	// is established.
+	TCPHealthProbe HealthProbeType = "TCP"
+)
+
+// HealthProbe defines a probe run by the registration agent against the addon agent pods. The probes
+// of an addon are a json list set in the health-probes annotation of the addon.
+type HealthProbe struct {
+	// Name is the unique name of the probe, it is used in the type of the probe condition.
+	Name string          `json:"name"`
+	Type HealthProbeType `json:"type"`
+	// Selector selects the addon agent pods in the addon installation namespace. All running pods
+	// selected must pass the probe.
+	Selector metav1.LabelSelector `json:"selector"`
+	// Port is used by the HTTP and TCP probes.
+	Port int32 `json:"port,omitempty"`
+	// Path and Scheme are used by the HTTP probe, Scheme is HTTP or HTTPS, default is HTTP.
+	Path   string `json:"path,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+	// Expression is a CEL expression evaluated on the http response. The response is accessed with
+	// the variable "response", which has the fields:
+	// - statusCode: the http status code.
+	// - body: the response body.
+	// - json: the response body decoded as json, it is null if the body is not json.
+	// - metrics: the sum of the samples of each metric if the body is in prometheus text format.
+	// For example: `response.statusCode == 200 && response.metrics["queue_depth"] < 100.0`
+	Expression string `json:"expression,omitempty"`
+	// TimeoutSeconds is the timeout of each probe request, default is 5 seconds.
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ParseHealthProbes decodes the health probes in the value of the AddonHealthProbesAnnotationKey annotation.
+func ParseHealthProbes(value string) ([]HealthProbe, error) {
+	var probes []HealthProbe
+	if err := json.Unmarshal([]byte(value), &probes); err != nil {
+		return nil, fmt.Errorf("failed to decode health probes: %v", err)
+	}
+	names := map[string]bool{}
+	for _, probe := range probes {
+		if len(probe.Name) == 0 || names[probe.Name] {
+			return nil, fmt.Errorf("the name of the health probe is empty or duplicated")
+		}
+		names[probe.Name] = true
+	}
+	return probes, nil
+}
+
+// NewHealthProbeEnv returns the CEL environment of the expressions of the health probes, the http response is
+// accessed with the variable "response".
+func NewHealthProbeEnv() (*cel.Env, error) {
+	return cel.NewEnv(slices.Concat(
+		[]cel.EnvOption{cel.Variable("response", cel.DynType)},
+		ocmcelcommon.BaseEnvOpts,
+	)...)
+}
+
+// ValidateHealthProbes validates the health probes in the annotations of the addon. The type of each probe must
+// be supported, and the selector and the expression of the probe must be valid.
+func ValidateHealthProbes(annotations map[string]string) error {
+	value, ok := annotations[AddonHealthProbesAnnotationKey]
+	if !ok {
+		return nil
+	}
+
+	probes, err := ParseHealthProbes(value)
+	if err != nil {
+		return err
+	}
+	env, err := NewHealthProbeEnv()
+	if err != nil {
+		return err
+	}
+	for _, probe := range probes {
+		switch probe.Type {
+		case HTTPHealthProbe, TCPHealthProbe:
+		default:
+			return fmt.Errorf("unsupported type %q of the health probe %q", probe.Type, probe.Name)
+		}
+		if _, err := metav1.LabelSelectorAsSelector(&probe.Selector); err != nil {
+			return fmt.Errorf("invalid selector of the health probe %q: %v", probe.Name, err)
+		}
+		if len(probe.Expression) == 0 {
+			continue
+		}
+		if _, iss := env.Compile(probe.Expression); iss.Err() != nil {
+			return fmt.Errorf("invalid expression of the health probe %q: %v", probe.Name, iss.Err())
+		}
+	}
+	return nil
+}
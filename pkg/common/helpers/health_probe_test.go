@@ -0,0 +1,63 @@
+package helpers
+
+import "testing"
+
+func TestValidateHealthProbes(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no probes",
+		},
+		{
+			name: "valid probes",
+			annotations: map[string]string{
+				AddonHealthProbesAnnotationKey: `[{"name":"http","type":"HTTP","port":8080,"expression":"response.statusCode == 200"}]`,
+			},
+		},
+		{
+			name:        "invalid json",
+			annotations: map[string]string{AddonHealthProbesAnnotationKey: `{`},
+			expectedErr: true,
+		},
+		{
+			name: "unsupported type",
+			annotations: map[string]string{
+				AddonHealthProbesAnnotationKey: `[{"name":"grpc","type":"GRPC"}]`,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "exec probe",
+			annotations: map[string]string{
+				AddonHealthProbesAnnotationKey: `[{"name":"exec","type":"Exec","command":["true"]}]`,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid selector",
+			annotations: map[string]string{
+				AddonHealthProbesAnnotationKey: `[{"name":"tcp","type":"TCP","selector":{"matchLabels":{"app":"a b"}}}]`,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid expression",
+			annotations: map[string]string{
+				AddonHealthProbesAnnotationKey: `[{"name":"http","type":"HTTP","expression":"response.statusCode =="}]`,
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateHealthProbes(c.annotations)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+)
+
+// MaintenanceWindowsAnnotationKey is the annotation on the ManagedCluster to define the maintenance windows of the
+// cluster. The rollouts of the ManifestWorkReplicaSets and the addon configurations only start to apply changes to
+// the cluster inside its maintenance windows. The value is a json list of MaintenanceWindow, for example:
+//
+//	[{"schedule": "0 2 * * 6", "duration": "4h", "timeZone": "Europe/Berlin"}]
+const MaintenanceWindowsAnnotationKey = "cluster.open-cluster-management.io/maintenance-windows"
+
+// MaintenanceWindow is a recurring window in which changes are allowed to be applied to a cluster.
+type MaintenanceWindow struct {
+	// Schedule is the standard cron expression of the start time of the window.
+	Schedule string `json:"schedule"`
+	// Duration is the length of the window.
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone is the IANA time zone of the schedule, default is UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// GetMaintenanceWindows returns the maintenance windows in the annotations, nil is returned if it is not set.
+func GetMaintenanceWindows(annotations map[string]string) ([]MaintenanceWindow, error) {
+	value, ok := annotations[MaintenanceWindowsAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var windows []MaintenanceWindow
+	if err := json.Unmarshal([]byte(value), &windows); err != nil {
+		return nil, fmt.Errorf("failed to decode maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+// UntilMaintenanceWindow returns the duration until the next maintenance window opens, 0 is returned if one of the
+// windows is open now or there is no window.
+func UntilMaintenanceWindow(windows []MaintenanceWindow, now time.Time) (time.Duration, error) {
+	if len(windows) == 0 {
+		return 0, nil
+	}
+
+	var until time.Duration
+	for _, window := range windows {
+		schedule, location, err := parseMaintenanceWindow(window)
+		if err != nil {
+			return 0, err
+		}
+
+		// the window is open if it started after now minus the duration and no later than now.
+		start := schedule.Next(now.In(location).Add(-window.Duration.Duration))
+		if start.IsZero() {
+			continue
+		}
+		if !start.After(now) {
+			return 0, nil
+		}
+		if remaining := start.Sub(now); until == 0 || remaining < until {
+			until = remaining
+		}
+	}
+	if until == 0 {
+		return 0, fmt.Errorf("the maintenance windows never open")
+	}
+	return until, nil
+}
+
+// ValidateMaintenanceWindows validates the maintenance windows in the annotations, each window must be valid and
+// open in the future.
+func ValidateMaintenanceWindows(annotations map[string]string) error {
+	windows, err := GetMaintenanceWindows(annotations)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, window := range windows {
+		schedule, location, err := parseMaintenanceWindow(window)
+		if err != nil {
+			return err
+		}
+		if schedule.Next(now.In(location)).IsZero() {
+			return fmt.Errorf("the maintenance window %q never opens", window.Schedule)
+		}
+	}
+	return nil
+}
+
+// parseMaintenanceWindow returns the schedule and the time zone of the maintenance window.
+func parseMaintenanceWindow(window MaintenanceWindow) (cron.Schedule, *time.Location, error) {
+	if window.Duration.Duration <= 0 {
+		return nil, nil, fmt.Errorf("the duration of the maintenance window %q must be positive", window.Schedule)
+	}
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule of the maintenance window %q: %w", window.Schedule, err)
+	}
+	location := time.UTC
+	if len(window.TimeZone) > 0 {
+		location, err = time.LoadLocation(window.TimeZone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time zone of the maintenance window %q: %w", window.Schedule, err)
+		}
+	}
+	return schedule, location, nil
+}
+
+// MaintenanceWindowFilter holds the clusters outside their maintenance windows in a rollout.
+type MaintenanceWindowFilter struct {
+	clusterLister clusterlisterv1.ManagedClusterLister
+	// now is replaceable in unit tests.
+	now func() time.Time
+}
+
+func NewMaintenanceWindowFilter(clusterLister clusterlisterv1.ManagedClusterLister) *MaintenanceWindowFilter {
+	return &MaintenanceWindowFilter{clusterLister: clusterLister, now: time.Now}
+}
+
+// Hold returns the clusters to apply outside their maintenance windows and the duration until the first window of
+// them opens. It is called before the clusters to rollout are selected, and the held clusters are passed to the
+// rollout handler with the Skip status by SkipClusters, so they do not take the places of the other clusters in the
+// MaxConcurrency of the rollout strategy. The clusters without an existing rollout status are to apply, the clusters
+// already being rolled out are not held. A cluster with invalid maintenance windows is held until the windows are
+// fixed.
+func (f *MaintenanceWindowFilter) Hold(clusters sets.Set[string],
+	existingClusterStatus []clustersdkv1alpha1.ClusterRolloutStatus) (waiting []string, recheckAfter time.Duration) {
+	if f == nil {
+		return nil, 0
+	}
+
+	toApply := clusters.Clone()
+	for _, status := range existingClusterStatus {
+		if status.Status != clustersdkv1alpha1.ToApply {
+			toApply.Delete(status.ClusterName)
+		}
+	}
+
+	now := f.now()
+	for _, clusterName := range sets.List(toApply) {
+		cluster, err := f.clusterLister.Get(clusterName)
+		switch {
+		case errors.IsNotFound(err):
+			continue
+		case err != nil:
+			klog.Errorf("failed to get cluster %s: %v", clusterName, err)
+			waiting = append(waiting, clusterName)
+			continue
+		}
+
+		windows, err := GetMaintenanceWindows(cluster.Annotations)
+		if err != nil {
+			klog.Errorf("failed to get the maintenance windows of cluster %s: %v", clusterName, err)
+			waiting = append(waiting, clusterName)
+			continue
+		}
+		until, err := UntilMaintenanceWindow(windows, now)
+		if err != nil {
+			klog.Errorf("failed to get the maintenance windows of cluster %s: %v", clusterName, err)
+			waiting = append(waiting, clusterName)
+			continue
+		}
+		if until == 0 {
+			continue
+		}
+
+		waiting = append(waiting, clusterName)
+		if recheckAfter == 0 || until < recheckAfter {
+			recheckAfter = until
+		}
+	}
+	return waiting, recheckAfter
+}
+
+// SkipClusters returns the rollout statuses with the status of the clusters set to Skip, the clusters without an
+// existing rollout status are added with the Skip status.
+func SkipClusters(existingClusterStatus []clustersdkv1alpha1.ClusterRolloutStatus,
+	clusters []string) []clustersdkv1alpha1.ClusterRolloutStatus {
+	if len(clusters) == 0 {
+		return existingClusterStatus
+	}
+
+	toSkip := sets.New(clusters...)
+	var statuses []clustersdkv1alpha1.ClusterRolloutStatus
+	for _, status := range existingClusterStatus {
+		if toSkip.Has(status.ClusterName) {
+			status.Status = clustersdkv1alpha1.Skip
+			toSkip.Delete(status.ClusterName)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, clusterName := range sets.List(toSkip) {
+		statuses = append(statuses, clustersdkv1alpha1.ClusterRolloutStatus{
+			ClusterName: clusterName,
+			Status:      clustersdkv1alpha1.Skip,
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,263 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	fakecluster "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+	clustersdkv1beta1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta1"
+)
+
+// a saturday
+var maintenanceNow = time.Date(2024, 1, 6, 3, 0, 0, 0, time.UTC)
+
+func TestUntilMaintenanceWindow(t *testing.T) {
+	cases := []struct {
+		name          string
+		windows       []MaintenanceWindow
+		expectedUntil time.Duration
+		expectedErr   bool
+	}{
+		{
+			name: "no window",
+		},
+		{
+			name: "window is open",
+			windows: []MaintenanceWindow{
+				{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			},
+		},
+		{
+			name: "window is closed",
+			windows: []MaintenanceWindow{
+				{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 30 * time.Minute}},
+				{Schedule: "0 2 * * 0", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			},
+			expectedUntil: 23 * time.Hour,
+		},
+		{
+			name: "window in time zone",
+			windows: []MaintenanceWindow{
+				{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}, TimeZone: "Asia/Tokyo"},
+			},
+			expectedUntil: 6*24*time.Hour + 14*time.Hour,
+		},
+		{
+			name: "invalid schedule",
+			windows: []MaintenanceWindow{
+				{Schedule: "every day", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid duration",
+			windows: []MaintenanceWindow{
+				{Schedule: "0 2 * * 6"},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid time zone",
+			windows: []MaintenanceWindow{
+				{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Mars/Olympus"},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			until, err := UntilMaintenanceWindow(c.windows, maintenanceNow)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if until != c.expectedUntil {
+				t.Errorf("expected %v, got %v", c.expectedUntil, until)
+			}
+		})
+	}
+}
+
+func TestValidateMaintenanceWindows(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no window",
+		},
+		{
+			name: "valid windows",
+			annotations: map[string]string{
+				MaintenanceWindowsAnnotationKey: `[{"schedule": "0 2 * * 6", "duration": "4h", "timeZone": "Europe/Berlin"}]`,
+			},
+		},
+		{
+			name:        "invalid json",
+			annotations: map[string]string{MaintenanceWindowsAnnotationKey: `{`},
+			expectedErr: true,
+		},
+		{
+			name: "invalid window after an open window",
+			annotations: map[string]string{
+				MaintenanceWindowsAnnotationKey: `[{"schedule": "* * * * *", "duration": "1h"}, {"schedule": "0 2 * * 6"}]`,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "window never opens",
+			annotations: map[string]string{
+				MaintenanceWindowsAnnotationKey: `[{"schedule": "0 2 30 2 *", "duration": "1h"}]`,
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateMaintenanceWindows(c.annotations)
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowFilter(t *testing.T) {
+	newCluster := func(name, windows string) *clusterv1.ManagedCluster {
+		cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if len(windows) > 0 {
+			cluster.Annotations = map[string]string{MaintenanceWindowsAnnotationKey: windows}
+		}
+		return cluster
+	}
+
+	clusterInformers := clusterv1informers.NewSharedInformerFactory(fakecluster.NewSimpleClientset(), 10*time.Minute)
+	for _, cluster := range []*clusterv1.ManagedCluster{
+		newCluster("cluster1", ""),
+		newCluster("cluster2", `[{"schedule":"0 2 * * 6","duration":"4h"}]`),
+		newCluster("cluster3", `[{"schedule":"0 2 * * 0","duration":"4h"}]`),
+		newCluster("cluster4", `[{"schedule":"0 4 * * 6","duration":"4h"}]`),
+		newCluster("cluster5", `{`),
+	} {
+		if err := clusterInformers.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := NewMaintenanceWindowFilter(clusterInformers.Cluster().V1().ManagedClusters().Lister())
+	filter.now = func() time.Time { return maintenanceNow }
+
+	// cluster3 is being rolled out, cluster6 has no existing rollout status
+	clusters := sets.New("cluster1", "cluster2", "cluster3", "cluster4", "cluster5", "cluster6", "cluster7")
+	existing := []clustersdkv1alpha1.ClusterRolloutStatus{
+		{ClusterName: "cluster1", Status: clustersdkv1alpha1.ToApply},
+		{ClusterName: "cluster2", Status: clustersdkv1alpha1.ToApply},
+		{ClusterName: "cluster3", Status: clustersdkv1alpha1.Progressing},
+		{ClusterName: "cluster4", Status: clustersdkv1alpha1.ToApply},
+		{ClusterName: "cluster5", Status: clustersdkv1alpha1.ToApply},
+		{ClusterName: "cluster7", Status: clustersdkv1alpha1.ToApply},
+	}
+	if err := clusterInformers.Cluster().V1().ManagedClusters().Informer().GetStore().Add(
+		newCluster("cluster6", `[{"schedule":"0 2 * * 0","duration":"4h"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	waiting, recheckAfter := filter.Hold(clusters, existing)
+
+	if !reflect.DeepEqual(waiting, []string{"cluster4", "cluster5", "cluster6"}) {
+		t.Errorf("unexpected waiting clusters %v", waiting)
+	}
+	if recheckAfter != time.Hour {
+		t.Errorf("expected recheck after 1h, got %v", recheckAfter)
+	}
+
+	var nilFilter *MaintenanceWindowFilter
+	if waiting, _ := nilFilter.Hold(clusters, existing); len(waiting) != 0 {
+		t.Errorf("expected no waiting clusters with nil filter, got %v", waiting)
+	}
+}
+
+func TestSkipClusters(t *testing.T) {
+	existing := []clustersdkv1alpha1.ClusterRolloutStatus{
+		{ClusterName: "cluster1", Status: clustersdkv1alpha1.ToApply},
+		{ClusterName: "cluster2", Status: clustersdkv1alpha1.ToApply},
+	}
+
+	actual := SkipClusters(existing, []string{"cluster2", "cluster3"})
+	expected := []clustersdkv1alpha1.ClusterRolloutStatus{
+		{ClusterName: "cluster1", Status: clustersdkv1alpha1.ToApply},
+		{ClusterName: "cluster2", Status: clustersdkv1alpha1.Skip},
+		{ClusterName: "cluster3", Status: clustersdkv1alpha1.Skip},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if existing[1].Status != clustersdkv1alpha1.ToApply {
+		t.Errorf("expected the existing statuses are not changed")
+	}
+}
+
+func TestHoldBeforeRollout(t *testing.T) {
+	clusterInformers := clusterv1informers.NewSharedInformerFactory(fakecluster.NewSimpleClientset(), 10*time.Minute)
+	closed := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:        "cluster1",
+		Annotations: map[string]string{MaintenanceWindowsAnnotationKey: `[{"schedule":"0 2 * * 0","duration":"4h"}]`},
+	}}
+	if err := clusterInformers.Cluster().V1().ManagedClusters().Informer().GetStore().Add(closed); err != nil {
+		t.Fatal(err)
+	}
+	filter := NewMaintenanceWindowFilter(clusterInformers.Cluster().V1().ManagedClusters().Lister())
+	filter.now = func() time.Time { return maintenanceNow }
+
+	// the held cluster does not take the place of the other clusters in the max concurrency
+	clusters := sets.New("cluster1", "cluster2", "cluster3")
+	waiting, _ := filter.Hold(clusters, nil)
+	placement := &clusterv1beta1.Placement{ObjectMeta: metav1.ObjectMeta{Name: "placement", Namespace: "default"}}
+	decision := &clusterv1beta1.PlacementDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "placement-decision-1",
+			Namespace: "default",
+			Labels:    map[string]string{clusterv1beta1.PlacementLabel: "placement", clusterv1beta1.DecisionGroupIndexLabel: "0"},
+		},
+		Status: clusterv1beta1.PlacementDecisionStatus{
+			Decisions: []clusterv1beta1.ClusterDecision{{ClusterName: "cluster1"}, {ClusterName: "cluster2"}, {ClusterName: "cluster3"}},
+		},
+	}
+	decisionInformers := clusterv1informers.NewSharedInformerFactory(fakecluster.NewSimpleClientset(), 10*time.Minute)
+	if err := decisionInformers.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(decision); err != nil {
+		t.Fatal(err)
+	}
+	tracker := clustersdkv1beta1.NewPlacementDecisionClustersTracker(placement,
+		PlacementDecisionGetter{Client: decisionInformers.Cluster().V1beta1().PlacementDecisions().Lister()}, nil)
+	if err := tracker.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	handler, err := clustersdkv1alpha1.NewRolloutHandler(tracker,
+		func(clusterName string, status clustersdkv1alpha1.ClusterRolloutStatus) (clustersdkv1alpha1.ClusterRolloutStatus, error) {
+			return status, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, result, err := handler.GetRolloutCluster(clusterv1alpha1.RolloutStrategy{
+		Type:        clusterv1alpha1.Progressive,
+		Progressive: &clusterv1alpha1.RolloutProgressive{MaxConcurrency: intstr.FromInt32(1)},
+	}, SkipClusters(nil, waiting))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.ClustersToRollout) != 1 || result.ClustersToRollout[0].ClusterName != "cluster2" {
+		t.Errorf("expected cluster2 to rollout, got %v", result.ClustersToRollout)
+	}
+}
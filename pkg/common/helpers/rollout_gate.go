@@ -0,0 +1,228 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/utils/lru"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+)
+
+// RolloutGateAnnotationKey is the annotation on the ManifestWorkReplicaSet and ClusterManagementAddOn to define
+// a gate evaluated on each cluster before the cluster is considered as succeeded in a rollout.
+const RolloutGateAnnotationKey = "open-cluster-management.io/rollout-gate"
+
+// RolloutGate is a CEL expression evaluated over the status feedback of the ManifestWorks on a cluster. A cluster
+// that is succeeded in a rollout is kept progressing for the soak period, and it is failed once the expression is
+// false. So the next clusters or decision groups are only rolled out after the canary clusters pass the soak period.
+type RolloutGate struct {
+	// Expression is evaluated with the variables:
+	// - cluster: the name of the cluster.
+	// - feedback: the status feedback values of all the manifests keyed by the feedback name. Integer, string and
+	//   boolean values are converted to CEL int, string and bool, and JsonRaw values are decoded.
+	// - manifests: the list of the manifests, each has group, version, kind, resource, namespace, name and feedback.
+	// For example: `feedback.readyReplicas == feedback.replicas && feedback.errorRate < 0.01`
+	Expression string `json:"expression"`
+	// SoakPeriod is the duration the expression is evaluated on a cluster after it succeeded, default is 0.
+	SoakPeriod metav1.Duration `json:"soakPeriod,omitempty"`
+}
+
+// GetRolloutGate returns the rollout gate in the annotations, nil is returned if it is not set.
+func GetRolloutGate(annotations map[string]string) (*RolloutGate, error) {
+	value, ok := annotations[RolloutGateAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	gate := &RolloutGate{}
+	if err := json.Unmarshal([]byte(value), gate); err != nil {
+		return nil, fmt.Errorf("failed to decode rollout gate: %w", err)
+	}
+	if len(gate.Expression) == 0 {
+		return nil, fmt.Errorf("the expression of the rollout gate is empty")
+	}
+	if gate.SoakPeriod.Duration < 0 {
+		return nil, fmt.Errorf("the soak period of the rollout gate must not be negative")
+	}
+	return gate, nil
+}
+
+// ValidateRolloutGate validates the rollout gate in the annotations, the expression of the gate must compile.
+func ValidateRolloutGate(annotations map[string]string) error {
+	gate, err := GetRolloutGate(annotations)
+	if err != nil || gate == nil {
+		return err
+	}
+	evaluator, err := NewRolloutGateEvaluator()
+	if err != nil {
+		return err
+	}
+	if _, err := evaluator.program(gate.Expression); err != nil {
+		return fmt.Errorf("invalid expression of the rollout gate: %w", err)
+	}
+	return nil
+}
+
+// maxCachedRolloutGates is the number of the compiled expressions of the rollout gates kept in the cache.
+const maxCachedRolloutGates = 256
+
+// RolloutGateEvaluator evaluates the rollout gates.
+type RolloutGateEvaluator struct {
+	env *cel.Env
+	// programs caches the compiled programs keyed by the expressions, so an expression is compiled once rather than
+	// for each cluster on each sync.
+	programs *lru.Cache
+	// now is replaceable in unit tests.
+	now func() time.Time
+}
+
+func NewRolloutGateEvaluator() (*RolloutGateEvaluator, error) {
+	env, err := cel.NewEnv(slices.Concat(
+		[]cel.EnvOption{
+			cel.Variable("cluster", cel.StringType),
+			cel.Variable("feedback", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("manifests", cel.ListType(cel.DynType)),
+		},
+		ocmcelcommon.BaseEnvOpts,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	return &RolloutGateEvaluator{env: env, programs: lru.New(maxCachedRolloutGates), now: time.Now}, nil
+}
+
+// Evaluate applies the gate on the rollout status of a cluster with the ManifestWorks of the rollout on the cluster.
+// Only a succeeded status is changed. It is changed to progressing until the soak period since the last transition
+// time of the status passes, and it is changed to failed if the expression is false or can not be evaluated. The
+// expression is not evaluated anymore once the soak period passes, and it is evaluated on each call if there is no
+// soak period. The last transition time is kept in any case, so a failed cluster times out in the progress deadline.
+// The returned duration is the remaining soak period, the rollout should be rechecked after it.
+func (e *RolloutGateEvaluator) Evaluate(ctx context.Context, gate *RolloutGate, status clustersdkv1alpha1.ClusterRolloutStatus,
+	works []*workv1.ManifestWork) (clustersdkv1alpha1.ClusterRolloutStatus, time.Duration) {
+	if gate == nil || status.Status != clustersdkv1alpha1.Succeeded {
+		return status, 0
+	}
+
+	now := e.now()
+	var remaining time.Duration
+	if status.LastTransitionTime != nil {
+		remaining = status.LastTransitionTime.Add(gate.SoakPeriod.Duration).Sub(now)
+		if gate.SoakPeriod.Duration > 0 && remaining <= 0 {
+			return status, 0
+		}
+	}
+
+	passed, err := e.evaluate(ctx, gate.Expression, status.ClusterName, works)
+	if err != nil || !passed {
+		status.Status = clustersdkv1alpha1.Failed
+		if status.LastTransitionTime == nil {
+			status.LastTransitionTime = &metav1.Time{Time: now}
+		}
+		return status, 0
+	}
+
+	if remaining > 0 {
+		// keep the last transition time so the soak period is counted in the progress deadline.
+		status.Status = clustersdkv1alpha1.Progressing
+		return status, remaining
+	}
+	return status, 0
+}
+
+// program returns the compiled program of the expression from the cache, the expression is compiled if it is
+// not in the cache.
+func (e *RolloutGateEvaluator) program(expression string) (cel.Program, error) {
+	if prg, ok := e.programs.Get(expression); ok {
+		return prg.(cel.Program), nil
+	}
+
+	ast, iss := e.env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	prg, err := e.env.Program(ast,
+		cel.CostLimit(celconfig.PerCallLimit),
+		cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.programs.Add(expression, prg)
+	return prg, nil
+}
+
+func (e *RolloutGateEvaluator) evaluate(ctx context.Context, expression, clusterName string, works []*workv1.ManifestWork) (bool, error) {
+	prg, err := e.program(expression)
+	if err != nil {
+		return false, err
+	}
+
+	feedback := map[string]any{}
+	manifests := []any{}
+	for _, work := range works {
+		for _, manifest := range work.Status.ResourceStatus.Manifests {
+			values := map[string]any{}
+			for _, value := range manifest.StatusFeedbacks.Values {
+				v, ok := feedbackValue(value.Value)
+				if !ok {
+					continue
+				}
+				values[value.Name] = v
+				if _, exists := feedback[value.Name]; !exists {
+					feedback[value.Name] = v
+				}
+			}
+			manifests = append(manifests, map[string]any{
+				"group":     manifest.ResourceMeta.Group,
+				"version":   manifest.ResourceMeta.Version,
+				"kind":      manifest.ResourceMeta.Kind,
+				"resource":  manifest.ResourceMeta.Resource,
+				"namespace": manifest.ResourceMeta.Namespace,
+				"name":      manifest.ResourceMeta.Name,
+				"feedback":  values,
+			})
+		}
+	}
+
+	out, _, err := prg.ContextEval(ctx, map[string]any{
+		"cluster":   clusterName,
+		"feedback":  feedback,
+		"manifests": manifests,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate expression %q: %w", expression, err)
+	}
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expected bool result of expression %q, got %v", expression, reflect.TypeOf(out.Value()))
+	}
+	return result, nil
+}
+
+func feedbackValue(value workv1.FieldValue) (any, bool) {
+	switch {
+	case value.Type == workv1.Integer && value.Integer != nil:
+		return *value.Integer, true
+	case value.Type == workv1.String && value.String != nil:
+		return *value.String, true
+	case value.Type == workv1.Boolean && value.Boolean != nil:
+		return *value.Boolean, true
+	case value.Type == workv1.JsonRaw && value.JsonRaw != nil:
+		var v any
+		if err := json.Unmarshal([]byte(*value.JsonRaw), &v); err != nil {
+			return nil, false
+		}
+		return v, true
+	}
+	return nil, false
+}
@@ -0,0 +1,192 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+)
+
+func newFeedbackWork(values ...workv1.FeedbackValue) *workv1.ManifestWork {
+	return &workv1.ManifestWork{
+		Status: workv1.ManifestWorkStatus{
+			ResourceStatus: workv1.ManifestResourceStatus{
+				Manifests: []workv1.ManifestCondition{
+					{
+						ResourceMeta: workv1.ManifestResourceMeta{
+							Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments",
+							Namespace: "default", Name: "app",
+						},
+						StatusFeedbacks: workv1.StatusFeedbackResult{Values: values},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetRolloutGate(t *testing.T) {
+	cases := []struct {
+		name         string
+		annotations  map[string]string
+		expectedGate *RolloutGate
+		expectedErr  bool
+	}{
+		{
+			name: "no gate",
+		},
+		{
+			name:        "gate",
+			annotations: map[string]string{RolloutGateAnnotationKey: `{"expression":"true","soakPeriod":"10m"}`},
+			expectedGate: &RolloutGate{
+				Expression: "true",
+				SoakPeriod: metav1.Duration{Duration: 10 * time.Minute},
+			},
+		},
+		{
+			name:        "invalid gate",
+			annotations: map[string]string{RolloutGateAnnotationKey: `{"soakPeriod":"10m"}`},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gate, err := GetRolloutGate(c.annotations)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if (gate == nil) != (c.expectedGate == nil) || (gate != nil && *gate != *c.expectedGate) {
+				t.Errorf("expected gate %v, got %v", c.expectedGate, gate)
+			}
+		})
+	}
+}
+
+func TestEvaluateRolloutGate(t *testing.T) {
+	now := time.Now()
+	work := newFeedbackWork(
+		workv1.FeedbackValue{Name: "readyReplicas", Value: workv1.FieldValue{Type: workv1.Integer, Integer: ptr.To[int64](3)}},
+		workv1.FeedbackValue{Name: "stats", Value: workv1.FieldValue{Type: workv1.JsonRaw, JsonRaw: ptr.To(`{"errorRate":0.001}`)}},
+	)
+
+	cases := []struct {
+		name            string
+		gate            *RolloutGate
+		status          clustersdkv1alpha1.RolloutStatus
+		succeededSince  time.Duration
+		expectedStatus  clustersdkv1alpha1.RolloutStatus
+		expectedRecheck time.Duration
+	}{
+		{
+			name:           "not succeeded",
+			gate:           &RolloutGate{Expression: "false"},
+			status:         clustersdkv1alpha1.Progressing,
+			expectedStatus: clustersdkv1alpha1.Progressing,
+		},
+		{
+			name:           "expression passed",
+			gate:           &RolloutGate{Expression: `feedback.readyReplicas >= 3 && feedback.stats.errorRate < 0.01`},
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Succeeded,
+		},
+		{
+			name: "soaking",
+			gate: &RolloutGate{
+				Expression: `manifests.all(m, m.kind != "Deployment" || m.feedback.readyReplicas >= 3)`,
+				SoakPeriod: metav1.Duration{Duration: 10 * time.Minute},
+			},
+			status:          clustersdkv1alpha1.Succeeded,
+			succeededSince:  4 * time.Minute,
+			expectedStatus:  clustersdkv1alpha1.Progressing,
+			expectedRecheck: 6 * time.Minute,
+		},
+		{
+			name: "soaked",
+			gate: &RolloutGate{
+				Expression: `cluster == "cluster1"`,
+				SoakPeriod: metav1.Duration{Duration: 10 * time.Minute},
+			},
+			status:         clustersdkv1alpha1.Succeeded,
+			succeededSince: 11 * time.Minute,
+			expectedStatus: clustersdkv1alpha1.Succeeded,
+		},
+		{
+			name: "not evaluated after soaked",
+			gate: &RolloutGate{
+				Expression: `feedback.stats.errorRate > 0.01`,
+				SoakPeriod: metav1.Duration{Duration: 10 * time.Minute},
+			},
+			status:         clustersdkv1alpha1.Succeeded,
+			succeededSince: 11 * time.Minute,
+			expectedStatus: clustersdkv1alpha1.Succeeded,
+		},
+		{
+			name:           "expression failed",
+			gate:           &RolloutGate{Expression: `feedback.stats.errorRate > 0.01`},
+			status:         clustersdkv1alpha1.Succeeded,
+			succeededSince: 4 * time.Minute,
+			expectedStatus: clustersdkv1alpha1.Failed,
+		},
+		{
+			name:           "invalid expression",
+			gate:           &RolloutGate{Expression: `feedback.missing > 1`},
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Failed,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			evaluator, err := NewRolloutGateEvaluator()
+			if err != nil {
+				t.Fatal(err)
+			}
+			evaluator.now = func() time.Time { return now }
+
+			lastTransitionTime := now.Add(-c.succeededSince)
+			status, recheck := evaluator.Evaluate(context.TODO(), c.gate, clustersdkv1alpha1.ClusterRolloutStatus{
+				ClusterName:        "cluster1",
+				Status:             c.status,
+				LastTransitionTime: &metav1.Time{Time: lastTransitionTime},
+			}, []*workv1.ManifestWork{work})
+			if status.Status != c.expectedStatus {
+				t.Errorf("expected status %v, got %v", c.expectedStatus, status.Status)
+			}
+			if !status.LastTransitionTime.Time.Equal(lastTransitionTime) {
+				t.Errorf("expected last transition time %v is kept, got %v", lastTransitionTime, status.LastTransitionTime)
+			}
+			if recheck != c.expectedRecheck {
+				t.Errorf("expected recheck after %v, got %v", c.expectedRecheck, recheck)
+			}
+		})
+	}
+}
+
+func TestRolloutGateProgramCache(t *testing.T) {
+	evaluator, err := NewRolloutGateEvaluator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gate := &RolloutGate{Expression: `feedback.readyReplicas >= 3`}
+	work := newFeedbackWork(
+		workv1.FeedbackValue{Name: "readyReplicas", Value: workv1.FieldValue{Type: workv1.Integer, Integer: ptr.To[int64](3)}})
+
+	for _, cluster := range []string{"cluster1", "cluster2", "cluster3"} {
+		status, _ := evaluator.Evaluate(context.TODO(), gate, clustersdkv1alpha1.ClusterRolloutStatus{
+			ClusterName: cluster,
+			Status:      clustersdkv1alpha1.Succeeded,
+		}, []*workv1.ManifestWork{work})
+		if status.Status != clustersdkv1alpha1.Succeeded {
+			t.Errorf("expected cluster %s succeeded, got %v", cluster, status.Status)
+		}
+	}
+	if evaluator.programs.Len() != 1 {
+		t.Errorf("expected the expression is compiled once, got %d programs", evaluator.programs.Len())
+	}
+}
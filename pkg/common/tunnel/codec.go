@@ -0,0 +1,56 @@
+package tunnel
+
+import (
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic/types"
+)
+
+var TunnelRequestEventDataType = types.CloudEventsDataType{
+	Group:    "proxy.open-cluster-management.io",
+	Version:  "v1alpha1",
+	Resource: "tunnelrequests",
+}
+
+// TunnelRequestCodec is a codec to encode/decode a tunnel request/cloudevent for the hub and the agent.
+type TunnelRequestCodec struct{}
+
+func NewTunnelRequestCodec() *TunnelRequestCodec {
+	return &TunnelRequestCodec{}
+}
+
+// EventDataType always returns the event data type `proxy.open-cluster-management.io.v1alpha1.tunnelrequests`.
+func (c *TunnelRequestCodec) EventDataType() types.CloudEventsDataType {
+	return TunnelRequestEventDataType
+}
+
+// Encode the tunnel request to a cloudevent
+func (c *TunnelRequestCodec) Encode(source string, eventType types.CloudEventsType, req *TunnelRequest) (*cloudevents.Event, error) {
+	if eventType.CloudEventsDataType != TunnelRequestEventDataType {
+		return nil, fmt.Errorf("unsupported cloudevents data type %s", eventType.CloudEventsDataType)
+	}
+
+	evt := types.NewEventBuilder(source, eventType).
+		WithResourceID(req.ID).
+		WithResourceVersion(0).
+		WithClusterName(req.ClusterName).
+		NewEvent()
+
+	if err := evt.SetData(cloudevents.ApplicationJSON, req); err != nil {
+		return nil, fmt.Errorf("failed to encode tunnel request to a cloudevent: %v", err)
+	}
+
+	return &evt, nil
+}
+
+// Decode a cloudevent to a tunnel request
+func (c *TunnelRequestCodec) Decode(evt *cloudevents.Event) (*TunnelRequest, error) {
+	req := &TunnelRequest{}
+	if err := evt.DataAs(req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event data %s, %v", string(evt.Data()), err)
+	}
+
+	return req, nil
+}
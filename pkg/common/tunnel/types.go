@@ -0,0 +1,61 @@
+package tunnel
+
+import (
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubetypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ImpersonationPrefix is prepended to the user and groups of the hub user when the agent impersonates the hub
+	// user on the managed cluster, so the hub identities never collide with the identities of the managed cluster.
+	ImpersonationPrefix = "open-cluster-management:hub:"
+
+	// MaxBodySize is the max size of the request and response body carried by the tunnel, it is bounded by the
+	// max message size of the gRPC connection.
+	MaxBodySize = 2 * 1024 * 1024
+)
+
+// TunnelRequest is a request proxied from the hub to the kube-apiserver of a managed cluster over the outbound
+// connection of the registration agent. The agent sends the request back with the Response once it is served.
+type TunnelRequest struct {
+	// ID is the unique identifier of the request.
+	ID string `json:"id"`
+	// ClusterName is the name of the managed cluster to serve the request.
+	ClusterName string `json:"clusterName"`
+	// User and Groups are the hub user which sends the request, the agent impersonates them with the
+	// ImpersonationPrefix on the managed cluster.
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+	// Method is the http method of the request.
+	Method string `json:"method"`
+	// Path is the path of the request on the kube-apiserver including the query.
+	Path   string      `json:"path"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+
+	Response *TunnelResponse `json:"response,omitempty"`
+}
+
+// TunnelResponse is the response of the kube-apiserver of the managed cluster.
+type TunnelResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	// Error is set when the agent fails to serve the request.
+	Error string `json:"error,omitempty"`
+}
+
+func (r *TunnelRequest) GetUID() kubetypes.UID {
+	return kubetypes.UID(r.ID)
+}
+
+// GetResourceVersion always returns "0", a request is never changed once it is sent.
+func (r *TunnelRequest) GetResourceVersion() string {
+	return "0"
+}
+
+func (r *TunnelRequest) GetDeletionTimestamp() *metav1.Time {
+	return nil
+}
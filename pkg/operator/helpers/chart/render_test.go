@@ -53,7 +53,7 @@ func TestClusterManagerConfig(t *testing.T) {
 				config.NodeSelector = map[string]string{"kubernetes.io/os": "linux"}
 				return config
 			},
-			expectedObjCnt: 5,
+			expectedObjCnt: 7,
 		},
 		{
 			name:      "enable bootstrap token",
@@ -63,7 +63,7 @@ func TestClusterManagerConfig(t *testing.T) {
 				config.CreateBootstrapToken = true
 				return config
 			},
-			expectedObjCnt: 8,
+			expectedObjCnt: 10,
 		},
 		{
 			name:      "enable bootstrap sa",
@@ -73,7 +73,7 @@ func TestClusterManagerConfig(t *testing.T) {
 				config.CreateBootstrapSA = true
 				return config
 			},
-			expectedObjCnt: 8,
+			expectedObjCnt: 10,
 		},
 		{
 			name:      "change images config",
@@ -92,7 +92,7 @@ func TestClusterManagerConfig(t *testing.T) {
 				}
 				return config
 			},
-			expectedObjCnt: 6,
+			expectedObjCnt: 8,
 		},
 		{
 			name:      "change images config dockerConfigJson",
@@ -110,7 +110,7 @@ func TestClusterManagerConfig(t *testing.T) {
 				}
 				return config
 			},
-			expectedObjCnt: 6,
+			expectedObjCnt: 8,
 		},
 		{
 			name:      "create namespace",
@@ -121,7 +121,7 @@ func TestClusterManagerConfig(t *testing.T) {
 				config.CreateNamespace = true
 				return config
 			},
-			expectedObjCnt: 9,
+			expectedObjCnt: 11,
 		},
 	}
 
@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+)
+
+const (
+	// GRPCServerAnnotationKey is an annotation key on the clustermanager, the cloudevents gRPC server is
+	// deployed as a component of the cluster manager when the value is "true", so the klusterlets are able
+	// to register and receive works with the grpc registration auth.
+	GRPCServerAnnotationKey = "operator.open-cluster-management.io/grpc-server"
+
+	// GRPCServerEndpointAnnotationKey is an annotation key on the clustermanager, its value is the address in
+	// the format of host:port that the klusterlets use to connect to the gRPC server, e.g. the address of a
+	// load balancer or a route. The host is added to the serving certificate of the gRPC server. The address
+	// of the gRPC server service is used if it is not set.
+	GRPCServerEndpointAnnotationKey = "operator.open-cluster-management.io/grpc-server-endpoint"
+
+	// GRPCServerServiceTypeAnnotationKey is an annotation key on the clustermanager, its value is the type of
+	// the gRPC server service, ClusterIP is used if it is not set.
+	GRPCServerServiceTypeAnnotationKey = "operator.open-cluster-management.io/grpc-server-service-type"
+
+	GRPCServerSecret  = "grpc-server-serving-cert"
+	GRPCServerService = "cluster-manager-grpc-server"
+	GRPCServerPort    = 8090
+
+	// GRPCClientSignerSecret is the signer of the client certificates of the klusterlets connecting to the gRPC
+	// server, and GRPCClientCABundleConfigmap is the ca bundle of the signer to verify the client certificates.
+	// They are separated from the webhook signer, so the certificates signed by the webhook signer are never
+	// accepted as the client certificates.
+	GRPCClientSignerSecret      = "grpc-client-signer-secret"
+	GRPCClientCABundleConfigmap = "grpc-client-ca-bundle-configmap"
+)
+
+// GRPCServerConfiguration is the configuration of the gRPC server of a clustermanager.
+type GRPCServerConfiguration struct {
+	Enabled     bool
+	Endpoint    string
+	ServiceType corev1.ServiceType
+}
+
+// GetGRPCServerConfiguration returns the gRPC server configuration from the annotations of the clustermanager.
+func GetGRPCServerConfiguration(cm *operatorapiv1.ClusterManager) (GRPCServerConfiguration, error) {
+	config := GRPCServerConfiguration{ServiceType: corev1.ServiceTypeClusterIP}
+	enabled, ok := cm.Annotations[GRPCServerAnnotationKey]
+	if !ok {
+		return config, nil
+	}
+	var err error
+	if config.Enabled, err = strconv.ParseBool(enabled); err != nil {
+		return config, fmt.Errorf("invalid value %q of annotation %s: %v", enabled, GRPCServerAnnotationKey, err)
+	}
+
+	if endpoint := cm.Annotations[GRPCServerEndpointAnnotationKey]; len(endpoint) > 0 {
+		if _, _, err := net.SplitHostPort(endpoint); err != nil {
+			return config, fmt.Errorf("invalid value %q of annotation %s: %v", endpoint, GRPCServerEndpointAnnotationKey, err)
+		}
+		config.Endpoint = endpoint
+	}
+
+	switch serviceType := corev1.ServiceType(cm.Annotations[GRPCServerServiceTypeAnnotationKey]); serviceType {
+	case "":
+	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		config.ServiceType = serviceType
+	default:
+		return config, fmt.Errorf("unsupported value %q of annotation %s", serviceType, GRPCServerServiceTypeAnnotationKey)
+	}
+
+	return config, nil
+}
+
+// URL returns the address that the klusterlets use to connect to the gRPC server.
+func (c GRPCServerConfiguration) URL(clusterManagerNamespace string) string {
+	if len(c.Endpoint) > 0 {
+		return c.Endpoint
+	}
+	return fmt.Sprintf("%s.%s.svc:%d", GRPCServerService, clusterManagerNamespace, GRPCServerPort)
+}
+
+// HostNames returns the host names of the serving certificate of the gRPC server.
+func (c GRPCServerConfiguration) HostNames(clusterManagerNamespace string) []string {
+	hostNames := []string{fmt.Sprintf("%s.%s.svc", GRPCServerService, clusterManagerNamespace)}
+	if len(c.Endpoint) > 0 {
+		host, _, _ := net.SplitHostPort(c.Endpoint)
+		if !strings.EqualFold(host, hostNames[0]) {
+			hostNames = append(hostNames, host)
+		}
+	}
+	return hostNames
+}
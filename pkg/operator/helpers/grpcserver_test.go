@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+)
+
+func TestGetGRPCServerConfiguration(t *testing.T) {
+	cases := []struct {
+		name              string
+		annotations       map[string]string
+		expectedErr       bool
+		expectedEnabled   bool
+		expectedType      corev1.ServiceType
+		expectedURL       string
+		expectedHostNames []string
+	}{
+		{
+			name:              "not enabled",
+			expectedType:      corev1.ServiceTypeClusterIP,
+			expectedURL:       "cluster-manager-grpc-server.open-cluster-management-hub.svc:8090",
+			expectedHostNames: []string{"cluster-manager-grpc-server.open-cluster-management-hub.svc"},
+		},
+		{
+			name:              "enabled with default service",
+			annotations:       map[string]string{GRPCServerAnnotationKey: "true"},
+			expectedEnabled:   true,
+			expectedType:      corev1.ServiceTypeClusterIP,
+			expectedURL:       "cluster-manager-grpc-server.open-cluster-management-hub.svc:8090",
+			expectedHostNames: []string{"cluster-manager-grpc-server.open-cluster-management-hub.svc"},
+		},
+		{
+			name: "enabled with endpoint",
+			annotations: map[string]string{
+				GRPCServerAnnotationKey:            "true",
+				GRPCServerEndpointAnnotationKey:    "grpc.example.com:443",
+				GRPCServerServiceTypeAnnotationKey: "NodePort",
+			},
+			expectedEnabled: true,
+			expectedType:    corev1.ServiceTypeNodePort,
+			expectedURL:     "grpc.example.com:443",
+			expectedHostNames: []string{
+				"cluster-manager-grpc-server.open-cluster-management-hub.svc",
+				"grpc.example.com",
+			},
+		},
+		{
+			name:        "invalid enabled value",
+			annotations: map[string]string{GRPCServerAnnotationKey: "yes"},
+			expectedErr: true,
+		},
+		{
+			name: "endpoint without port",
+			annotations: map[string]string{
+				GRPCServerAnnotationKey:         "true",
+				GRPCServerEndpointAnnotationKey: "grpc.example.com",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "unsupported service type",
+			annotations: map[string]string{
+				GRPCServerAnnotationKey:            "true",
+				GRPCServerServiceTypeAnnotationKey: "ExternalName",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cm := &operatorapiv1.ClusterManager{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-manager", Annotations: c.annotations},
+			}
+			config, err := GetGRPCServerConfiguration(cm)
+			if c.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if config.Enabled != c.expectedEnabled {
+				t.Errorf("expected enabled %v, but got %v", c.expectedEnabled, config.Enabled)
+			}
+			if config.ServiceType != c.expectedType {
+				t.Errorf("expected service type %s, but got %s", c.expectedType, config.ServiceType)
+			}
+			if url := config.URL(ClusterManagerDefaultNamespace); url != c.expectedURL {
+				t.Errorf("expected url %s, but got %s", c.expectedURL, url)
+			}
+			if hostNames := config.HostNames(ClusterManagerDefaultNamespace); !reflect.DeepEqual(hostNames, c.expectedHostNames) {
+				t.Errorf("expected host names %v, but got %v", c.expectedHostNames, hostNames)
+			}
+		})
+	}
+}
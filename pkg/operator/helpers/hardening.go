@@ -0,0 +1,506 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	networkingclientv1 "k8s.io/client-go/kubernetes/typed/networking/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"open-cluster-management.io/sdk-go/pkg/certrotation"
+)
+
+const (
+	// HardeningAnnotationKey is an annotation key on the clustermanager and the klusterlet, its value is a comma
+	// separated list of the production hardening resources rendered by the operator for the deployed components.
+	// The supported values are PodDisruptionBudget, NetworkPolicy, TopologySpreadConstraints and ServiceMonitor.
+	// The resources of a value are removed once it is removed from the annotation.
+	HardeningAnnotationKey = "operator.open-cluster-management.io/hardening"
+
+	HardeningPodDisruptionBudget       = "PodDisruptionBudget"
+	HardeningNetworkPolicy             = "NetworkPolicy"
+	HardeningTopologySpreadConstraints = "TopologySpreadConstraints"
+	HardeningServiceMonitor            = "ServiceMonitor"
+
+	// MetricsServiceLabelKey is the label key of the metrics services created for the ServiceMonitors, its value
+	// is the name of the deployment exposing the metrics.
+	MetricsServiceLabelKey = "operator.open-cluster-management.io/metrics-service"
+
+	// MetricsPort is the port of the secure serving of the controllers, which serves the metrics and health checks.
+	MetricsPort = 8443
+
+	// MetricsServingSignerSecret, MetricsServingCABundleConfigMap and MetricsServingCertSecret are the signer, the
+	// ca bundle and the serving certificate of the metrics endpoints in a namespace. The deployments mount the
+	// serving certificate to MetricsServingCertDir, where the controllers load their serving certificate from,
+	// and the ServiceMonitors verify the metrics endpoints with the ca bundle.
+	MetricsServingSignerSecret      = "metrics-serving-signer"
+	MetricsServingCABundleConfigMap = "metrics-serving-ca-bundle"
+	MetricsServingCertSecret        = "metrics-serving-cert"
+	MetricsServingCertDir           = "/var/run/secrets/serving-cert"
+
+	metricsServingSignerValidity = 365 * 24 * time.Hour
+	metricsServingCertValidity   = 30 * 24 * time.Hour
+)
+
+// Hardening is the production hardening resources enabled on a clustermanager or a klusterlet.
+type Hardening struct {
+	PodDisruptionBudget       bool
+	NetworkPolicy             bool
+	TopologySpreadConstraints bool
+	ServiceMonitor            bool
+}
+
+// GetHardening returns the production hardening resources enabled by the HardeningAnnotationKey annotation.
+func GetHardening(annotations map[string]string) (Hardening, error) {
+	hardening := Hardening{}
+	value, ok := annotations[HardeningAnnotationKey]
+	if !ok {
+		return hardening, nil
+	}
+	for _, resource := range strings.Split(value, ",") {
+		switch strings.TrimSpace(resource) {
+		case "":
+		case HardeningPodDisruptionBudget:
+			hardening.PodDisruptionBudget = true
+		case HardeningNetworkPolicy:
+			hardening.NetworkPolicy = true
+		case HardeningTopologySpreadConstraints:
+			hardening.TopologySpreadConstraints = true
+		case HardeningServiceMonitor:
+			hardening.ServiceMonitor = true
+		default:
+			return hardening, fmt.Errorf("unsupported value %q of annotation %s", resource, HardeningAnnotationKey)
+		}
+	}
+	return hardening, nil
+}
+
+// SyncPodDisruptionBudget ensures a PodDisruptionBudget allowing at most one unavailable pod of the deployment.
+// The PodDisruptionBudget is removed if it is not enabled or the deployment runs a single replica, since the
+// budget would block the node drains in that case.
+func SyncPodDisruptionBudget(ctx context.Context, client kubernetes.Interface, recorder events.Recorder,
+	deployment *appsv1.Deployment, enabled bool) error {
+	maxUnavailable := intstr.FromInt32(1)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deployment.Name,
+			Namespace: deployment.Namespace,
+			Labels:    deployment.Labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector:       deployment.Spec.Selector,
+		},
+	}
+
+	if !enabled || deployment.Spec.Replicas == nil || *deployment.Spec.Replicas <= 1 {
+		_, _, err := resourceapply.DeletePodDisruptionBudget(ctx, client.PolicyV1(), recorder, pdb)
+		return err
+	}
+
+	_, _, err := resourceapply.ApplyPodDisruptionBudget(ctx, client.PolicyV1(), recorder, pdb)
+	return err
+}
+
+var serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+// SyncServiceMonitor ensures a metrics service and a ServiceMonitor scraping the metrics served on the secure port
+// of the deployment, the serving certificate is verified with the metrics serving ca bundle of the namespace. They
+// are removed if it is not enabled.
+func SyncServiceMonitor(ctx context.Context, client kubernetes.Interface, dynamicClient dynamic.Interface,
+	recorder events.Recorder, deployment *appsv1.Deployment, enabled bool) error {
+	name := fmt.Sprintf("%s-metrics", deployment.Name)
+	labels := map[string]string{MetricsServiceLabelKey: deployment.Name}
+	for k, v := range deployment.Labels {
+		labels[k] = v
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: deployment.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: deployment.Spec.Selector.MatchLabels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "https",
+					Port:       MetricsPort,
+					TargetPort: intstr.FromInt32(MetricsPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	serviceMonitor.SetName(name)
+	serviceMonitor.SetNamespace(deployment.Namespace)
+	serviceMonitor.SetLabels(labels)
+	serviceMonitor.Object["spec"] = map[string]interface{}{
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"port":            "https",
+				"scheme":          "https",
+				"path":            "/metrics",
+				"bearerTokenFile": "/var/run/secrets/kubernetes.io/serviceaccount/token",
+				"tlsConfig": map[string]interface{}{
+					"ca": map[string]interface{}{
+						"configMap": map[string]interface{}{
+							"name": MetricsServingCABundleConfigMap,
+							"key":  "ca-bundle.crt",
+						},
+					},
+					"serverName": fmt.Sprintf("%s.%s.svc", name, deployment.Namespace),
+				},
+			},
+		},
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				MetricsServiceLabelKey: deployment.Name,
+			},
+		},
+	}
+
+	if !enabled {
+		if _, _, err := resourceapply.DeleteServiceMonitor(ctx, dynamicClient, recorder, serviceMonitor); err != nil {
+			return err
+		}
+		err := client.CoreV1().Services(service.Namespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if _, _, err := resourceapply.ApplyService(ctx, client.CoreV1(), recorder, service); err != nil {
+		return err
+	}
+	_, _, err := resourceapply.ApplyServiceMonitor(ctx, dynamicClient, recorder, serviceMonitor)
+	return err
+}
+
+// SyncMetricsServingCert ensures the signer, the ca bundle and the serving certificate of the metrics endpoints in
+// the namespace. The serving certificate is valid for all the services in the namespace and is rotated before it
+// expires. They are removed if it is not enabled, and the controllers fall back to the self signed certificates.
+func SyncMetricsServingCert(ctx context.Context, client kubernetes.Interface, namespace string, enabled bool) error {
+	if !enabled {
+		for _, name := range []string{MetricsServingCertSecret, MetricsServingSignerSecret} {
+			err := client.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		err := client.CoreV1().ConfigMaps(namespace).Delete(ctx, MetricsServingCABundleConfigMap, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	secretLister := &clientSecretLister{ctx: ctx, client: client.CoreV1()}
+	signingRotation := certrotation.SigningRotation{
+		Namespace:        namespace,
+		Name:             MetricsServingSignerSecret,
+		SignerNamePrefix: "metrics-serving",
+		Validity:         metricsServingSignerValidity,
+		Lister:           secretLister,
+		Client:           client.CoreV1(),
+	}
+	caBundleRotation := certrotation.CABundleRotation{
+		Namespace: namespace,
+		Name:      MetricsServingCABundleConfigMap,
+		Lister:    &clientConfigMapLister{ctx: ctx, client: client.CoreV1()},
+		Client:    client.CoreV1(),
+	}
+	targetRotation := certrotation.TargetRotation{
+		Namespace: namespace,
+		Name:      MetricsServingCertSecret,
+		Validity:  metricsServingCertValidity,
+		HostNames: []string{fmt.Sprintf("*.%s.svc", namespace)},
+		Lister:    secretLister,
+		Client:    client.CoreV1(),
+	}
+
+	signingCertKeyPair, err := signingRotation.EnsureSigningCertKeyPair()
+	if err != nil {
+		return err
+	}
+	caBundleCerts, err := caBundleRotation.EnsureConfigMapCABundle(signingCertKeyPair)
+	if err != nil {
+		return err
+	}
+	return targetRotation.EnsureTargetCertKeyPair(signingCertKeyPair, caBundleCerts)
+}
+
+// clientSecretLister lists the secrets with the client, the operator does not watch the secrets of the metrics
+// serving certificates, since they are only reconciled with the deployments.
+type clientSecretLister struct {
+	ctx       context.Context
+	client    corev1client.SecretsGetter
+	namespace string
+}
+
+func (l *clientSecretLister) List(selector labels.Selector) ([]*corev1.Secret, error) {
+	secrets, err := l.client.Secrets(l.namespace).List(l.ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	var ret []*corev1.Secret
+	for i := range secrets.Items {
+		ret = append(ret, &secrets.Items[i])
+	}
+	return ret, nil
+}
+
+func (l *clientSecretLister) Secrets(namespace string) corev1listers.SecretNamespaceLister {
+	return &clientSecretLister{ctx: l.ctx, client: l.client, namespace: namespace}
+}
+
+func (l *clientSecretLister) Get(name string) (*corev1.Secret, error) {
+	return l.client.Secrets(l.namespace).Get(l.ctx, name, metav1.GetOptions{})
+}
+
+// clientConfigMapLister lists the configmaps with the client.
+type clientConfigMapLister struct {
+	ctx       context.Context
+	client    corev1client.ConfigMapsGetter
+	namespace string
+}
+
+func (l *clientConfigMapLister) List(selector labels.Selector) ([]*corev1.ConfigMap, error) {
+	configMaps, err := l.client.ConfigMaps(l.namespace).List(l.ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	var ret []*corev1.ConfigMap
+	for i := range configMaps.Items {
+		ret = append(ret, &configMaps.Items[i])
+	}
+	return ret, nil
+}
+
+func (l *clientConfigMapLister) ConfigMaps(namespace string) corev1listers.ConfigMapNamespaceLister {
+	return &clientConfigMapLister{ctx: l.ctx, client: l.client, namespace: namespace}
+}
+
+func (l *clientConfigMapLister) Get(name string) (*corev1.ConfigMap, error) {
+	return l.client.ConfigMaps(l.namespace).Get(l.ctx, name, metav1.GetOptions{})
+}
+
+// NetworkPolicyPeer is a destination of the egress traffic of a NetworkPolicy.
+type NetworkPolicyPeer struct {
+	IP   string
+	Port int32
+}
+
+// HostResolver resolves a host name to its ip addresses, it is implemented by the net.Resolver.
+type HostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NetworkPolicyPeersFromURL returns the destinations of the url, the port is defaulted by the scheme. The host
+// name is resolved to its ip addresses, so the egress traffic is only allowed to these addresses.
+func NetworkPolicyPeersFromURL(ctx context.Context, resolver HostResolver, rawURL string) ([]NetworkPolicyPeer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.Hostname()) == 0 {
+		return nil, fmt.Errorf("no host in url %q", rawURL)
+	}
+
+	port := int64(443)
+	if u.Scheme == "http" {
+		port = 80
+	}
+	if len(u.Port()) > 0 {
+		if port, err = strconv.ParseInt(u.Port(), 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid port in url %q: %v", rawURL, err)
+		}
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		return []NetworkPolicyPeer{{IP: ip.String(), Port: int32(port)}}, nil
+	}
+	addrs, err := resolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the host of url %q: %v", rawURL, err)
+	}
+	var peers []NetworkPolicyPeer
+	for _, addr := range addrs {
+		peers = append(peers, NetworkPolicyPeer{IP: addr.IP.String(), Port: int32(port)})
+	}
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no address of the host of url %q", rawURL)
+	}
+	return peers, nil
+}
+
+// KubernetesAPIServerPeers returns the endpoints of the kubernetes service in the default namespace. The egress
+// traffic to the service ip is matched with the endpoints after the service ip is translated, so the endpoints
+// rather than the service ip are allowed.
+func KubernetesAPIServerPeers(ctx context.Context, client kubernetes.Interface) ([]NetworkPolicyPeer, error) {
+	endpointSlices, err := client.DiscoveryV1().EndpointSlices(metav1.NamespaceDefault).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=kubernetes", discoveryv1.LabelServiceName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var peers []NetworkPolicyPeer
+	for _, endpointSlice := range endpointSlices.Items {
+		for _, port := range endpointSlice.Ports {
+			if port.Port == nil || (port.Protocol != nil && *port.Protocol != corev1.ProtocolTCP) {
+				continue
+			}
+			for _, endpoint := range endpointSlice.Endpoints {
+				for _, address := range endpoint.Addresses {
+					peers = append(peers, NetworkPolicyPeer{IP: address, Port: *port.Port})
+				}
+			}
+		}
+	}
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no endpoint of the kubernetes service")
+	}
+	return peers, nil
+}
+
+// NewNetworkPolicy returns a NetworkPolicy of the pods selected by the pod labels in the namespace, which only
+// allows the ingress traffic to the ingress ports. If egress peers are specified, the egress traffic is only
+// allowed to the peers and the DNS.
+func NewNetworkPolicy(name, namespace string, labels, podLabels map[string]string,
+	ingressPorts []int32, egressPeers []NetworkPolicyPeer) *networkingv1.NetworkPolicy {
+	networkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{Ports: networkPolicyPorts(corev1.ProtocolTCP, ingressPorts...)},
+			},
+		},
+	}
+	if len(egressPeers) == 0 {
+		return networkPolicy
+	}
+
+	networkPolicy.Spec.PolicyTypes = append(networkPolicy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+	networkPolicy.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{
+		{Ports: append(networkPolicyPorts(corev1.ProtocolUDP, 53), networkPolicyPorts(corev1.ProtocolTCP, 53)...)},
+	}
+
+	ipPorts := map[string]sets.Set[int32]{}
+	for _, peer := range egressPeers {
+		if _, ok := ipPorts[peer.IP]; !ok {
+			ipPorts[peer.IP] = sets.New[int32]()
+		}
+		ipPorts[peer.IP].Insert(peer.Port)
+	}
+	ips := make([]string, 0, len(ipPorts))
+	for ip := range ipPorts {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		cidr := ip + "/32"
+		if net.ParseIP(ip).To4() == nil {
+			cidr = ip + "/128"
+		}
+		networkPolicy.Spec.Egress = append(networkPolicy.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+			Ports: networkPolicyPorts(corev1.ProtocolTCP, sets.List(ipPorts[ip])...),
+			To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}},
+		})
+	}
+	return networkPolicy
+}
+
+func networkPolicyPorts(protocol corev1.Protocol, ports ...int32) []networkingv1.NetworkPolicyPort {
+	var policyPorts []networkingv1.NetworkPolicyPort
+	for _, port := range sets.List(sets.New(ports...)) {
+		p := intstr.FromInt32(port)
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p})
+	}
+	return policyPorts
+}
+
+// SyncNetworkPolicy applies the NetworkPolicy if it is enabled, otherwise removes it.
+func SyncNetworkPolicy(ctx context.Context, client networkingclientv1.NetworkPoliciesGetter, recorder events.Recorder,
+	required *networkingv1.NetworkPolicy, enabled bool) error {
+	if !enabled {
+		err := client.NetworkPolicies(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err == nil {
+			recorder.Eventf("NetworkPolicyDeleted", "networkpolicy %s/%s is deleted", required.Namespace, required.Name)
+		}
+		return err
+	}
+
+	_, _, err := ApplyNetworkPolicy(ctx, client, recorder, required)
+	return err
+}
+
+// ApplyNetworkPolicy merges objectmeta and requires the spec of the NetworkPolicy.
+func ApplyNetworkPolicy(ctx context.Context, client networkingclientv1.NetworkPoliciesGetter, recorder events.Recorder,
+	required *networkingv1.NetworkPolicy) (*networkingv1.NetworkPolicy, bool, error) {
+	existing, err := client.NetworkPolicies(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		actual, err := client.NetworkPolicies(required.Namespace).Create(ctx, required.DeepCopy(), metav1.CreateOptions{})
+		if err == nil {
+			recorder.Eventf("NetworkPolicyCreated", "networkpolicy %s/%s is created", required.Namespace, required.Name)
+		}
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := resourcemerge.BoolPtr(false)
+	existingCopy := existing.DeepCopy()
+	resourcemerge.EnsureObjectMeta(modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+
+	if !*modified && equality.Semantic.DeepEqual(existingCopy.Spec, required.Spec) {
+		return existingCopy, false, nil
+	}
+
+	existingCopy.Spec = required.Spec
+	actual, err := client.NetworkPolicies(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	if err == nil {
+		recorder.Eventf("NetworkPolicyUpdated", "networkpolicy %s/%s is updated", required.Namespace, required.Name)
+	}
+	return actual, true, err
+}
@@ -0,0 +1,283 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestGetHardening(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    Hardening
+		expectedErr bool
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:        "all resources",
+			annotations: map[string]string{HardeningAnnotationKey: "PodDisruptionBudget, NetworkPolicy,TopologySpreadConstraints,ServiceMonitor"},
+			expected: Hardening{
+				PodDisruptionBudget:       true,
+				NetworkPolicy:             true,
+				TopologySpreadConstraints: true,
+				ServiceMonitor:            true,
+			},
+		},
+		{
+			name:        "some resources",
+			annotations: map[string]string{HardeningAnnotationKey: "PodDisruptionBudget,"},
+			expected:    Hardening{PodDisruptionBudget: true},
+		},
+		{
+			name:        "unsupported resource",
+			annotations: map[string]string{HardeningAnnotationKey: "PodSecurityPolicy"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hardening, err := GetHardening(c.annotations)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if err == nil && hardening != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, hardening)
+			}
+		})
+	}
+}
+
+type fakeHostResolver map[string][]string
+
+func (r fakeHostResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	var addrs []net.IPAddr
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestNetworkPolicyPeersFromURL(t *testing.T) {
+	resolver := fakeHostResolver{
+		"hub.example.com":   {"10.0.0.1", "10.0.0.2"},
+		"empty.example.com": {},
+	}
+	cases := []struct {
+		url         string
+		expected    []NetworkPolicyPeer
+		expectedErr bool
+	}{
+		{url: "https://hub.example.com:6443", expected: []NetworkPolicyPeer{{IP: "10.0.0.1", Port: 6443}, {IP: "10.0.0.2", Port: 6443}}},
+		{url: "https://hub.example.com", expected: []NetworkPolicyPeer{{IP: "10.0.0.1", Port: 443}, {IP: "10.0.0.2", Port: 443}}},
+		{url: "https://10.0.0.1:8443", expected: []NetworkPolicyPeer{{IP: "10.0.0.1", Port: 8443}}},
+		{url: "https://[fd00::1]:6443", expected: []NetworkPolicyPeer{{IP: "fd00::1", Port: 6443}}},
+		{url: "https://unknown.example.com", expectedErr: true},
+		{url: "https://empty.example.com", expectedErr: true},
+		{url: "hub.example.com", expectedErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			peers, err := NetworkPolicyPeersFromURL(context.TODO(), resolver, c.url)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if err == nil && !reflect.DeepEqual(peers, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, peers)
+			}
+		})
+	}
+}
+
+func TestKubernetesAPIServerPeers(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	if _, err := KubernetesAPIServerPeers(context.TODO(), kubeClient); err == nil {
+		t.Errorf("expected error without the endpoints of the kubernetes service")
+	}
+
+	kubeClient = fakekube.NewSimpleClientset(&discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kubernetes",
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "kubernetes"},
+		},
+		Endpoints: []discoveryv1.Endpoint{{Addresses: []string{"172.18.0.2", "172.18.0.3"}}},
+		Ports:     []discoveryv1.EndpointPort{{Port: ptr.To[int32](6443), Protocol: ptr.To(corev1.ProtocolTCP)}},
+	})
+	peers, err := KubernetesAPIServerPeers(context.TODO(), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []NetworkPolicyPeer{{IP: "172.18.0.2", Port: 6443}, {IP: "172.18.0.3", Port: 6443}}
+	if !reflect.DeepEqual(peers, expected) {
+		t.Errorf("expected %v, got %v", expected, peers)
+	}
+}
+
+func TestNewNetworkPolicy(t *testing.T) {
+	podLabels := map[string]string{HubLabelKey: "cluster-manager"}
+	networkPolicy := NewNetworkPolicy("test", "test", nil, podLabels, []int32{8443, 9443, 8443}, nil)
+	if !reflect.DeepEqual(networkPolicy.Spec.PolicyTypes, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}) {
+		t.Errorf("expected only the ingress is restricted, got %v", networkPolicy.Spec.PolicyTypes)
+	}
+	if !reflect.DeepEqual(networkPolicy.Spec.PodSelector.MatchLabels, podLabels) {
+		t.Errorf("expected the pods are selected by %v, got %v", podLabels, networkPolicy.Spec.PodSelector)
+	}
+	testingcommon.AssertEqualNumber(t, len(networkPolicy.Spec.Ingress[0].Ports), 2)
+
+	networkPolicy = NewNetworkPolicy("test", "test", nil, podLabels, []int32{8443}, []NetworkPolicyPeer{
+		{IP: "10.0.0.1", Port: 443}, {IP: "10.0.0.1", Port: 6443}, {IP: "fd00::1", Port: 6443},
+	})
+	testingcommon.AssertEqualNumber(t, len(networkPolicy.Spec.PolicyTypes), 2)
+	// dns and the two ip blocks
+	testingcommon.AssertEqualNumber(t, len(networkPolicy.Spec.Egress), 3)
+	testingcommon.AssertEqualNumber(t, len(networkPolicy.Spec.Egress[0].Ports), 2)
+	for _, egress := range networkPolicy.Spec.Egress[1:] {
+		if len(egress.To) != 1 || egress.To[0].IPBlock == nil {
+			t.Errorf("expected the egress is restricted to an ip block, got %v", egress)
+		}
+	}
+	if cidr := networkPolicy.Spec.Egress[1].To[0].IPBlock.CIDR; cidr != "10.0.0.1/32" {
+		t.Errorf("unexpected cidr %s", cidr)
+	}
+	testingcommon.AssertEqualNumber(t, len(networkPolicy.Spec.Egress[1].Ports), 2)
+	if cidr := networkPolicy.Spec.Egress[2].To[0].IPBlock.CIDR; cidr != "fd00::1/128" {
+		t.Errorf("unexpected cidr %s", cidr)
+	}
+}
+
+func TestSyncMetricsServingCert(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	if err := SyncMetricsServingCert(context.TODO(), kubeClient, "test", true); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{MetricsServingSignerSecret, MetricsServingCertSecret} {
+		secret, err := kubeClient.CoreV1().Secrets("test").Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(secret.Data["tls.crt"]) == 0 || len(secret.Data["tls.key"]) == 0 {
+			t.Errorf("expected the certificate key pair in secret %s", name)
+		}
+	}
+	caBundle, err := kubeClient.CoreV1().ConfigMaps("test").Get(context.TODO(), MetricsServingCABundleConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(caBundle.Data["ca-bundle.crt"]) == 0 {
+		t.Errorf("expected the ca bundle")
+	}
+
+	// the certificates are not rotated if they are valid
+	kubeClient.ClearActions()
+	if err := SyncMetricsServingCert(context.TODO(), kubeClient, "test", true); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, kubeClient.Actions(), "get", "get", "get")
+
+	kubeClient.ClearActions()
+	if err := SyncMetricsServingCert(context.TODO(), kubeClient, "test", false); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, kubeClient.Actions(), "delete", "delete", "delete")
+}
+
+func TestSyncPodDisruptionBudget(t *testing.T) {
+	newDeployment := func(replicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To(replicas),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+			},
+		}
+	}
+
+	cases := []struct {
+		name            string
+		deployment      *appsv1.Deployment
+		enabled         bool
+		expectedActions []string
+	}{
+		{
+			name:            "enabled",
+			deployment:      newDeployment(3),
+			enabled:         true,
+			expectedActions: []string{"get", "create"},
+		},
+		{
+			name:            "single replica",
+			deployment:      newDeployment(1),
+			enabled:         true,
+			expectedActions: []string{"delete"},
+		},
+		{
+			name:            "disabled",
+			deployment:      newDeployment(3),
+			expectedActions: []string{"delete"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := fakekube.NewSimpleClientset()
+			err := SyncPodDisruptionBudget(context.TODO(), kubeClient, eventstesting.NewTestingEventRecorder(t),
+				c.deployment, c.enabled)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testingcommon.AssertActions(t, kubeClient.Actions(), c.expectedActions...)
+		})
+	}
+}
+
+func TestSyncNetworkPolicy(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	recorder := eventstesting.NewTestingEventRecorder(t)
+	networkPolicy := NewNetworkPolicy("test", "test", nil, nil, []int32{8443}, nil)
+
+	if err := SyncNetworkPolicy(context.TODO(), kubeClient.NetworkingV1(), recorder, networkPolicy, true); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, kubeClient.Actions(), "get", "create")
+
+	kubeClient.ClearActions()
+	if err := SyncNetworkPolicy(context.TODO(), kubeClient.NetworkingV1(), recorder, networkPolicy, true); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, kubeClient.Actions(), "get")
+
+	kubeClient.ClearActions()
+	updated := NewNetworkPolicy("test", "test", nil, nil, []int32{8443, 9443}, nil)
+	if err := SyncNetworkPolicy(context.TODO(), kubeClient.NetworkingV1(), recorder, updated, true); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, kubeClient.Actions(), "get", "update")
+
+	kubeClient.ClearActions()
+	if err := SyncNetworkPolicy(context.TODO(), kubeClient.NetworkingV1(), recorder, networkPolicy, false); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, kubeClient.Actions(), "delete")
+}
@@ -26,7 +26,8 @@ import (
 )
 
 const (
-	signerNamePrefix = "cluster-manager-webhook"
+	signerNamePrefix           = "cluster-manager-webhook"
+	grpcClientSignerNamePrefix = "cluster-manager-grpc-client"
 )
 
 // Follow the rules below to set the value of SigningCertValidity/TargetCertValidity/ResyncInterval:
@@ -49,7 +50,7 @@ type certRotationController struct {
 	rotationMap          map[string]rotations // key is clusterManager's name, value is a rotations struct
 	kubeClient           kubernetes.Interface
 	secretInformers      map[string]corev1informers.SecretInformer
-	configMapInformer    corev1informers.ConfigMapInformer
+	configMapInformers   map[string]corev1informers.ConfigMapInformer
 	recorder             events.Recorder
 	clusterManagerLister operatorlister.ClusterManagerLister
 }
@@ -63,7 +64,7 @@ type rotations struct {
 func NewCertRotationController(
 	kubeClient kubernetes.Interface,
 	secretInformers map[string]corev1informers.SecretInformer,
-	configMapInformer corev1informers.ConfigMapInformer,
+	configMapInformers map[string]corev1informers.ConfigMapInformer,
 	clusterManagerInformer operatorinformer.ClusterManagerInformer,
 	recorder events.Recorder,
 ) factory.Controller {
@@ -71,7 +72,7 @@ func NewCertRotationController(
 		rotationMap:          make(map[string]rotations),
 		kubeClient:           kubeClient,
 		secretInformers:      secretInformers,
-		configMapInformer:    configMapInformer,
+		configMapInformers:   configMapInformers,
 		recorder:             recorder,
 		clusterManagerLister: clusterManagerInformer.Lister(),
 	}
@@ -80,10 +81,13 @@ func NewCertRotationController(
 		WithSync(c.sync).
 		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, clusterManagerInformer.Informer()).
 		WithInformersQueueKeysFunc(helpers.ClusterManagerQueueKeyFunc(c.clusterManagerLister),
-			configMapInformer.Informer(),
+			configMapInformers[helpers.CaBundleConfigmap].Informer(),
+			configMapInformers[helpers.GRPCClientCABundleConfigmap].Informer(),
 			secretInformers[helpers.SignerSecret].Informer(),
 			secretInformers[helpers.RegistrationWebhookSecret].Informer(),
-			secretInformers[helpers.WorkWebhookSecret].Informer()).
+			secretInformers[helpers.WorkWebhookSecret].Informer(),
+			secretInformers[helpers.GRPCServerSecret].Informer(),
+			secretInformers[helpers.GRPCClientSignerSecret].Informer()).
 		ToController("CertRotationController", recorder)
 }
 
@@ -163,6 +167,11 @@ func (c certRotationController) syncOne(ctx context.Context, syncCtx factory.Syn
 				return fmt.Errorf("clean up deleted cluster-manager, deleting work webhook secret failed, err:%s", err.Error())
 			}
 
+			// delete grpc server secret and grpc client signer
+			if err := c.removeGRPCServerCerts(ctx, clustermanagerNamespace); err != nil {
+				return fmt.Errorf("clean up deleted cluster-manager, deleting grpc server certs failed, err:%s", err.Error())
+			}
+
 			delete(c.rotationMap, clustermanagerName)
 		}
 		return nil
@@ -189,7 +198,7 @@ func (c certRotationController) syncOne(ctx context.Context, syncCtx factory.Syn
 		caBundleRotation := certrotation.CABundleRotation{
 			Namespace: clustermanagerNamespace,
 			Name:      helpers.CaBundleConfigmap,
-			Lister:    c.configMapInformer.Lister(),
+			Lister:    c.configMapInformers[helpers.CaBundleConfigmap].Lister(),
 			Client:    c.kubeClient.CoreV1(),
 		}
 		targetRotations := []certrotation.TargetRotation{
@@ -217,6 +226,11 @@ func (c certRotationController) syncOne(ctx context.Context, syncCtx factory.Syn
 		}
 	}
 
+	grpcServerConfig, err := helpers.GetGRPCServerConfiguration(clustermanager)
+	if err != nil {
+		return err
+	}
+
 	// Ensure certificates are exists
 	rotations := c.rotationMap[clustermanagerName] // reconcile cert/key pair for signer
 	signingCertKeyPair, err := rotations.signingRotation.EnsureSigningCertKeyPair()
@@ -231,8 +245,26 @@ func (c certRotationController) syncOne(ctx context.Context, syncCtx factory.Syn
 	}
 
 	// reconcile target cert/key pairs
+	targetRotations := rotations.targetRotations
+	if grpcServerConfig.Enabled {
+		// the host names of the grpc server may be changed, so its target rotation is built on each sync.
+		targetRotations = append(targetRotations, certrotation.TargetRotation{
+			Namespace: clustermanagerNamespace,
+			Name:      helpers.GRPCServerSecret,
+			Validity:  TargetCertValidity,
+			HostNames: grpcServerConfig.HostNames(clustermanagerNamespace),
+			Lister:    c.secretInformers[helpers.GRPCServerSecret].Lister(),
+			Client:    c.kubeClient.CoreV1(),
+		})
+		if err := c.ensureGRPCClientSigner(clustermanagerNamespace); err != nil {
+			return err
+		}
+	} else if err := c.removeGRPCServerCerts(ctx, clustermanagerNamespace); err != nil {
+		return err
+	}
+
 	var errs []error
-	for _, targetRotation := range rotations.targetRotations {
+	for _, targetRotation := range targetRotations {
 		if err := targetRotation.EnsureTargetCertKeyPair(signingCertKeyPair, cabundleCerts); err != nil {
 			errs = append(errs, err)
 		}
@@ -240,3 +272,58 @@ func (c certRotationController) syncOne(ctx context.Context, syncCtx factory.Syn
 
 	return errorhelpers.NewMultiLineAggregate(errs)
 }
+
+// ensureGRPCClientSigner ensures the signer of the client certificates of the grpc server and its ca bundle.
+func (c certRotationController) ensureGRPCClientSigner(namespace string) error {
+	signingRotation := certrotation.SigningRotation{
+		Namespace:        namespace,
+		Name:             helpers.GRPCClientSignerSecret,
+		SignerNamePrefix: grpcClientSignerNamePrefix,
+		Validity:         SigningCertValidity,
+		Lister:           c.secretInformers[helpers.GRPCClientSignerSecret].Lister(),
+		Client:           c.kubeClient.CoreV1(),
+	}
+	caBundleRotation := certrotation.CABundleRotation{
+		Namespace: namespace,
+		Name:      helpers.GRPCClientCABundleConfigmap,
+		Lister:    c.configMapInformers[helpers.GRPCClientCABundleConfigmap].Lister(),
+		Client:    c.kubeClient.CoreV1(),
+	}
+	signingCertKeyPair, err := signingRotation.EnsureSigningCertKeyPair()
+	if err != nil {
+		return err
+	}
+	_, err = caBundleRotation.EnsureConfigMapCABundle(signingCertKeyPair)
+	return err
+}
+
+// removeGRPCServerCerts deletes the serving cert and the client signer of the grpc server once it is disabled.
+func (c certRotationController) removeGRPCServerCerts(ctx context.Context, namespace string) error {
+	for _, name := range []string{helpers.GRPCServerSecret, helpers.GRPCClientSignerSecret} {
+		_, err := c.secretInformers[name].Lister().Secrets(namespace).Get(name)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = c.kubeClient.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	_, err := c.configMapInformers[helpers.GRPCClientCABundleConfigmap].Lister().ConfigMaps(namespace).Get(
+		helpers.GRPCClientCABundleConfigmap)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = c.kubeClient.CoreV1().ConfigMaps(namespace).Delete(ctx, helpers.GRPCClientCABundleConfigmap, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
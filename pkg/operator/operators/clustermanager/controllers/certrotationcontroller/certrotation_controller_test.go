@@ -2,6 +2,7 @@ package certrotationcontroller
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -46,6 +47,15 @@ func newClusterManager(name string, mode operatorapiv1.InstallMode) *operatorapi
 	}
 }
 
+func newGRPCServerClusterManager(name, endpoint string) *operatorapiv1.ClusterManager {
+	clusterManager := newClusterManager(name, operatorapiv1.InstallModeDefault)
+	clusterManager.Annotations = map[string]string{
+		helpers.GRPCServerAnnotationKey:         "true",
+		helpers.GRPCServerEndpointAnnotationKey: endpoint,
+	}
+	return clusterManager
+}
+
 type validateFunc func(t *testing.T, kubeClient kubernetes.Interface, err error)
 
 func TestCertRotation(t *testing.T) {
@@ -118,6 +128,103 @@ func TestCertRotation(t *testing.T) {
 				assertResourcesNotExist(t, kubeClient, helpers.ClusterManagerNamespace(testClusterManagerNameHosted, operatorapiv1.InstallModeHosted))
 			},
 		},
+		{
+			name: "Sync clustermanager with the grpc server enabled",
+			clusterManagers: []*operatorapiv1.ClusterManager{
+				newGRPCServerClusterManager(testClusterManagerNameDefault, "grpc.example.com:443"),
+			},
+			existingObjects: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: helpers.ClusterManagerNamespace(testClusterManagerNameDefault, operatorapiv1.InstallModeDefault),
+					},
+				},
+			},
+			queueKey: testClusterManagerNameDefault,
+			validate: func(t *testing.T, kubeClient kubernetes.Interface, err error) {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				namespace := helpers.ClusterManagerNamespace(testClusterManagerNameDefault, operatorapiv1.InstallModeDefault)
+				assertResourcesExistAndValid(t, kubeClient, namespace)
+				secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.Background(), helpers.GRPCServerSecret, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				certificates, err := cert.ParseCertsPEM(secret.Data["tls.crt"])
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				expected := []string{"cluster-manager-grpc-server.open-cluster-management-hub.svc", "grpc.example.com"}
+				if !reflect.DeepEqual(certificates[0].DNSNames, expected) {
+					t.Errorf("expected dns names %v, but got %v", expected, certificates[0].DNSNames)
+				}
+
+				// the client certificates are verified with a dedicated ca bundle
+				signer, err := kubeClient.CoreV1().Secrets(namespace).Get(context.Background(), helpers.GRPCClientSignerSecret, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				signerCerts, err := cert.ParseCertsPEM(signer.Data["tls.crt"])
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				clientCABundle, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(context.Background(), helpers.GRPCClientCABundleConfigmap, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				clientCACerts, err := cert.ParseCertsPEM([]byte(clientCABundle.Data["ca-bundle.crt"]))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(clientCACerts) != 1 || !clientCACerts[0].Equal(signerCerts[0]) {
+					t.Errorf("expected only the grpc client signer in the client ca bundle")
+				}
+				if clientCACerts[0].Issuer.CommonName == certificates[0].Issuer.CommonName {
+					t.Errorf("expected the grpc client signer is not the webhook signer")
+				}
+			},
+		},
+		{
+			name: "Sync clustermanager with the grpc server disabled",
+			clusterManagers: []*operatorapiv1.ClusterManager{
+				newClusterManager(testClusterManagerNameDefault, operatorapiv1.InstallModeDefault),
+			},
+			existingObjects: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: helpers.ClusterManagerNamespace(testClusterManagerNameDefault, operatorapiv1.InstallModeDefault),
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      helpers.GRPCClientSignerSecret,
+						Namespace: helpers.ClusterManagerNamespace(testClusterManagerNameDefault, operatorapiv1.InstallModeDefault),
+					},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      helpers.GRPCClientCABundleConfigmap,
+						Namespace: helpers.ClusterManagerNamespace(testClusterManagerNameDefault, operatorapiv1.InstallModeDefault),
+					},
+				},
+			},
+			queueKey: testClusterManagerNameDefault,
+			validate: func(t *testing.T, kubeClient kubernetes.Interface, err error) {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				namespace := helpers.ClusterManagerNamespace(testClusterManagerNameDefault, operatorapiv1.InstallModeDefault)
+				if _, err := kubeClient.CoreV1().Secrets(namespace).Get(
+					context.Background(), helpers.GRPCClientSignerSecret, metav1.GetOptions{}); !errors.IsNotFound(err) {
+					t.Errorf("expected the grpc client signer is deleted, but got %v", err)
+				}
+				if _, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(
+					context.Background(), helpers.GRPCClientCABundleConfigmap, metav1.GetOptions{}); !errors.IsNotFound(err) {
+					t.Errorf("expected the grpc client ca bundle is deleted, but got %v", err)
+				}
+			},
+		},
 		{
 			name: "Sync all clustermanagaers",
 			clusterManagers: []*operatorapiv1.ClusterManager{
@@ -162,9 +269,26 @@ func TestCertRotation(t *testing.T) {
 				helpers.SignerSecret:              newOnTermInformer(helpers.SignerSecret).Core().V1().Secrets(),
 				helpers.RegistrationWebhookSecret: newOnTermInformer(helpers.RegistrationWebhookSecret).Core().V1().Secrets(),
 				helpers.WorkWebhookSecret:         newOnTermInformer(helpers.WorkWebhookSecret).Core().V1().Secrets(),
+				helpers.GRPCServerSecret:          newOnTermInformer(helpers.GRPCServerSecret).Core().V1().Secrets(),
+				helpers.GRPCClientSignerSecret:    newOnTermInformer(helpers.GRPCClientSignerSecret).Core().V1().Secrets(),
 			}
 
-			configmapInformer := newOnTermInformer(helpers.CaBundleConfigmap).Core().V1().ConfigMaps()
+			configMapInformers := map[string]corev1informers.ConfigMapInformer{
+				helpers.CaBundleConfigmap:           newOnTermInformer(helpers.CaBundleConfigmap).Core().V1().ConfigMaps(),
+				helpers.GRPCClientCABundleConfigmap: newOnTermInformer(helpers.GRPCClientCABundleConfigmap).Core().V1().ConfigMaps(),
+			}
+			for _, obj := range c.existingObjects {
+				switch obj := obj.(type) {
+				case *corev1.Secret:
+					if err := secretInformers[obj.Name].Informer().GetStore().Add(obj); err != nil {
+						t.Fatal(err)
+					}
+				case *corev1.ConfigMap:
+					if err := configMapInformers[obj.Name].Informer().GetStore().Add(obj); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
 
 			clusterManagers := []runtime.Object{}
 			for i := range c.clusterManagers {
@@ -182,7 +306,7 @@ func TestCertRotation(t *testing.T) {
 			syncContext := testingcommon.NewFakeSyncContext(t, c.queueKey)
 			recorder := syncContext.Recorder()
 
-			controller := NewCertRotationController(kubeClient, secretInformers, configmapInformer, operatorInformers.Operator().V1().ClusterManagers(), recorder)
+			controller := NewCertRotationController(kubeClient, secretInformers, configMapInformers, operatorInformers.Operator().V1().ClusterManagers(), recorder)
 
 			err := controller.Sync(context.TODO(), syncContext)
 			c.validate(t, kubeClient, err)
@@ -17,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -50,13 +51,15 @@ type clusterManagerController struct {
 	clusterManagerLister operatorlister.ClusterManagerLister
 	operatorKubeClient   kubernetes.Interface
 	operatorKubeconfig   *rest.Config
-	configMapLister      corev1listers.ConfigMapLister
-	recorder             events.Recorder
-	cache                resourceapply.ResourceCache
+	// operatorDynamicClient is used to apply the ServiceMonitors on the management cluster.
+	operatorDynamicClient dynamic.Interface
+	configMapLister       corev1listers.ConfigMapLister
+	recorder              events.Recorder
+	cache                 resourceapply.ResourceCache
 	// For testcases which don't need these functions, we could set fake funcs
 	ensureSAKubeconfigs func(ctx context.Context, clusterManagerName, clusterManagerNamespace string,
 		hubConfig *rest.Config, hubClient, managementClient kubernetes.Interface, recorder events.Recorder,
-		mwctrEnabled, addonManagerEnabled bool) error
+		mwctrEnabled, addonManagerEnabled, grpcServerEnabled bool) error
 	generateHubClusterClients func(hubConfig *rest.Config) (kubernetes.Interface, apiextensionsclient.Interface,
 		migrationclient.StorageVersionMigrationsGetter, error)
 	skipRemoveCRDs                bool
@@ -82,6 +85,7 @@ const (
 func NewClusterManagerController(
 	operatorKubeClient kubernetes.Interface,
 	operatorKubeconfig *rest.Config,
+	operatorDynamicClient dynamic.Interface,
 	clusterManagerClient operatorv1client.ClusterManagerInterface,
 	clusterManagerInformer operatorinformer.ClusterManagerInformer,
 	deploymentInformer appsinformer.DeploymentInformer,
@@ -94,8 +98,9 @@ func NewClusterManagerController(
 	enableSyncLabels bool,
 ) factory.Controller {
 	controller := &clusterManagerController{
-		operatorKubeClient: operatorKubeClient,
-		operatorKubeconfig: operatorKubeconfig,
+		operatorKubeClient:    operatorKubeClient,
+		operatorKubeconfig:    operatorKubeconfig,
+		operatorDynamicClient: operatorDynamicClient,
 		patcher: patcher.NewPatcher[
 			*operatorapiv1.ClusterManager, operatorapiv1.ClusterManagerSpec, operatorapiv1.ClusterManagerStatus](
 			clusterManagerClient),
@@ -214,6 +219,25 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 	// Check if addon management is enabled by the feature gate
 	config.AddOnManagerEnabled = helpers.FeatureGateEnabled(addonFeatureGates, ocmfeature.DefaultHubAddonManagerFeatureGates, ocmfeature.AddonManagement)
 
+	grpcServerConfig, err := helpers.GetGRPCServerConfiguration(clusterManager)
+	if err != nil {
+		klog.Errorf("failed to parse grpc server configuration for cluster manager %s: %v", clusterManager.Name, err)
+		return err
+	}
+	config.GRPCServer = manifests.GRPCServer{
+		Enabled:     grpcServerConfig.Enabled,
+		Port:        helpers.GRPCServerPort,
+		ServiceType: string(grpcServerConfig.ServiceType),
+		URL:         grpcServerConfig.URL(clusterManagerNamespace),
+	}
+
+	hardening, err := helpers.GetHardening(clusterManager.Annotations)
+	if err != nil {
+		klog.Errorf("failed to parse hardening configuration for cluster manager %s: %v", clusterManager.Name, err)
+		return err
+	}
+	config.TopologySpreadConstraints = hardening.TopologySpreadConstraints
+
 	// Compute and populate the value of managed cluster identity creator role to be used in cluster manager registration service account
 	config.ManagedClusterIdentityCreatorRole = getIdentityCreatorRoleAndTags(*clusterManager)
 
@@ -255,7 +279,8 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 			hubKubeClient: hubClient, operatorNamespace: n.operatorNamespace, enableSyncLabels: n.enableSyncLabels},
 		&hubReconcile{cache: n.cache, recorder: n.recorder, hubKubeClient: hubClient},
 		&runtimeReconcile{cache: n.cache, recorder: n.recorder, hubKubeConfig: hubKubeConfig, hubKubeClient: hubClient,
-			kubeClient: managementClient, ensureSAKubeconfigs: n.ensureSAKubeconfigs},
+			kubeClient: managementClient, dynamicClient: n.operatorDynamicClient, ensureSAKubeconfigs: n.ensureSAKubeconfigs,
+			hardening: hardening},
 		&webhookReconcile{cache: n.cache, recorder: n.recorder, hubKubeClient: hubClient, kubeClient: managementClient},
 	}
 
@@ -287,6 +312,7 @@ func (n *clusterManagerController) sync(ctx context.Context, controllerContext f
 	encodedCaBundle := base64.StdEncoding.EncodeToString([]byte(caBundle))
 	config.RegistrationAPIServiceCABundle = encodedCaBundle
 	config.WorkAPIServiceCABundle = encodedCaBundle
+	config.GRPCServer.CABundle = encodedCaBundle
 
 	// check imagePulSecret here because there will be a warning event FailedToRetrieveImagePullSecret
 	// if imagePullSecret does not exist.
@@ -357,8 +383,8 @@ func generateHubClients(hubKubeConfig *rest.Config) (kubernetes.Interface, apiex
 // Finally, a deployment on the management cluster would use the kubeconfig to access resources on the hub cluster.
 func ensureSAKubeconfigs(ctx context.Context, clusterManagerName, clusterManagerNamespace string,
 	hubKubeConfig *rest.Config, hubClient, managementClient kubernetes.Interface, recorder events.Recorder,
-	mwctrEnabled, addonManagerEnabled bool) error {
-	for _, sa := range getSAs(mwctrEnabled, addonManagerEnabled) {
+	mwctrEnabled, addonManagerEnabled, grpcServerEnabled bool) error {
+	for _, sa := range getSAs(mwctrEnabled, addonManagerEnabled, grpcServerEnabled) {
 		tokenGetter := helpers.SATokenGetter(ctx, sa, clusterManagerNamespace, hubClient)
 		err := helpers.SyncKubeConfigSecret(ctx, sa+"-kubeconfig", clusterManagerNamespace,
 			"/var/run/secrets/hub/kubeconfig", &rest.Config{
@@ -13,6 +13,8 @@ import (
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	fakeapiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
@@ -20,7 +22,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	fakekube "k8s.io/client-go/kubernetes/fake"
@@ -36,6 +41,7 @@ import (
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
 	"open-cluster-management.io/ocm/manifests"
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
 )
@@ -48,6 +54,7 @@ var (
 type testController struct {
 	clusterManagerController *clusterManagerController
 	managementKubeClient     *fakekube.Clientset
+	managementDynamicClient  *fakedynamic.FakeDynamicClient
 	hubKubeClient            *fakekube.Clientset
 	apiExtensionClient       *fakeapiextensions.Clientset
 	operatorClient           *fakeoperatorlient.Clientset
@@ -258,15 +265,18 @@ func setup(t *testing.T, tc *testController, cd []runtime.Object, crds ...runtim
 	fakeManagementKubeClient := fakekube.NewSimpleClientset(cd...)
 	fakeAPIExtensionClient := fakeapiextensions.NewSimpleClientset(crds...)
 	fakeMigrationClient := fakemigrationclient.NewSimpleClientset()
+	fakeManagementDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
 
 	// set clients in test controller
 	tc.apiExtensionClient = fakeAPIExtensionClient
 	tc.hubKubeClient = fakeHubKubeClient
 	tc.managementKubeClient = fakeManagementKubeClient
+	tc.managementDynamicClient = fakeManagementDynamicClient
 
 	// set clients in clustermanager controller
 	tc.clusterManagerController.recorder = eventstesting.NewTestingEventRecorder(t)
 	tc.clusterManagerController.operatorKubeClient = fakeManagementKubeClient
+	tc.clusterManagerController.operatorDynamicClient = fakeManagementDynamicClient
 	tc.clusterManagerController.generateHubClusterClients = func(hubKubeConfig *rest.Config) (
 		kubernetes.Interface, apiextensionsclient.Interface, migrationclient.StorageVersionMigrationsGetter, error) {
 		return fakeHubKubeClient, fakeAPIExtensionClient, fakeMigrationClient.MigrationV1alpha1(), nil
@@ -274,7 +284,7 @@ func setup(t *testing.T, tc *testController, cd []runtime.Object, crds ...runtim
 	tc.clusterManagerController.ensureSAKubeconfigs = func(ctx context.Context,
 		clusterManagerName, clusterManagerNamespace string, hubConfig *rest.Config,
 		hubClient, managementClient kubernetes.Interface, recorder events.Recorder,
-		mwctrEnabled, addonManagerEnabled bool) error {
+		mwctrEnabled, addonManagerEnabled, grpcServerEnabled bool) error {
 		return nil
 	}
 }
@@ -462,7 +472,7 @@ func TestSyncDeploy(t *testing.T) {
 
 	// Check if resources are created as expected
 	// We expect create the namespace twice respectively in the management cluster and the hub cluster.
-	testingcommon.AssertEqualNumber(t, len(createKubeObjects), 28)
+	testingcommon.AssertEqualNumber(t, len(createKubeObjects), 35)
 	for _, object := range createKubeObjects {
 		ensureObject(t, object, clusterManager, true)
 	}
@@ -479,6 +489,165 @@ func TestSyncDeploy(t *testing.T) {
 	testingcommon.AssertEqualNumber(t, len(createCRDObjects), 12)
 }
 
+func TestSyncDeployGRPCServer(t *testing.T) {
+	clusterManager := newClusterManager("testhub")
+	clusterManager.Annotations = map[string]string{
+		helpers.GRPCServerAnnotationKey:            "true",
+		helpers.GRPCServerEndpointAnnotationKey:    "grpc.example.com:443",
+		helpers.GRPCServerServiceTypeAnnotationKey: "LoadBalancer",
+	}
+	tc := newTestController(t, clusterManager)
+	setup(t, tc, nil)
+
+	syncContext := testingcommon.NewFakeSyncContext(t, "testhub")
+
+	err := tc.clusterManagerController.sync(ctx, syncContext)
+	if err != nil {
+		t.Fatalf("Expected no error when sync, %v", err)
+	}
+
+	var createKubeObjects []runtime.Object
+	kubeActions := append(tc.hubKubeClient.Actions(), tc.managementKubeClient.Actions()...) // record objects from both hub and management cluster
+	for _, action := range kubeActions {
+		if action.GetVerb() == createVerb {
+			object := action.(clienttesting.CreateActionImpl).Object
+			createKubeObjects = append(createKubeObjects, object)
+		}
+	}
+
+	// Check if the grpc server deployment, service, rbac and bootstrap config are created in addition
+	testingcommon.AssertEqualNumber(t, len(createKubeObjects), 41)
+	for _, object := range createKubeObjects {
+		ensureObject(t, object, clusterManager, false)
+		switch o := object.(type) {
+		case *appsv1.Deployment:
+			if o.Name != "testhub-registration-controller" {
+				continue
+			}
+			args := strings.Join(o.Spec.Template.Spec.Containers[0].Args, " ")
+			if !strings.Contains(args, "--enabled-registration-drivers=csr,grpc") || !strings.Contains(args, "--grpc-ca-file") {
+				t.Errorf("expected the grpc registration driver is enabled, but got %s", args)
+			}
+		case *corev1.Service:
+			if o.Name == helpers.GRPCServerService && o.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				t.Errorf("expected the grpc server service type is LoadBalancer, but got %s", o.Spec.Type)
+			}
+		case *corev1.ConfigMap:
+			if o.Name == "grpc-server-bootstrap-config" && !strings.Contains(o.Data["config.yaml"], "url: grpc.example.com:443") {
+				t.Errorf("unexpected grpc bootstrap config %s", o.Data["config.yaml"])
+			}
+		}
+	}
+}
+
+func TestSyncDeployGRPCServerWithGRPCDriver(t *testing.T) {
+	clusterManager := newClusterManager("testhub")
+	clusterManager.Annotations = map[string]string{
+		helpers.GRPCServerAnnotationKey: "true",
+	}
+	clusterManager.Spec.RegistrationConfiguration = &operatorapiv1.RegistrationHubConfiguration{
+		RegistrationDrivers: []operatorapiv1.RegistrationDriverHub{
+			{AuthType: commonhelpers.CSRAuthType},
+			{AuthType: commonhelpers.GRPCCAuthType},
+		},
+	}
+	tc := newTestController(t, clusterManager)
+	setup(t, tc, nil)
+
+	syncContext := testingcommon.NewFakeSyncContext(t, "testhub")
+	if err := tc.clusterManagerController.sync(ctx, syncContext); err != nil {
+		t.Fatalf("Expected no error when sync, %v", err)
+	}
+
+	for _, action := range tc.managementKubeClient.Actions() {
+		if action.GetVerb() != createVerb {
+			continue
+		}
+		deployment, ok := action.(clienttesting.CreateActionImpl).Object.(*appsv1.Deployment)
+		if !ok || deployment.Name != "testhub-registration-controller" {
+			continue
+		}
+		args := strings.Join(deployment.Spec.Template.Spec.Containers[0].Args, " ")
+		if !strings.Contains(args, "--enabled-registration-drivers=csr,grpc ") {
+			t.Errorf("expected the grpc registration driver is enabled once, but got %s", args)
+		}
+	}
+}
+
+func TestSyncDeployHardening(t *testing.T) {
+	clusterManager := newClusterManager("testhub")
+	clusterManager.Annotations = map[string]string{
+		helpers.HardeningAnnotationKey: "PodDisruptionBudget,NetworkPolicy,TopologySpreadConstraints,ServiceMonitor",
+	}
+	tc := newTestController(t, clusterManager)
+	setup(t, tc, nil)
+	tc.clusterManagerController.deploymentReplicas = 3
+
+	syncContext := testingcommon.NewFakeSyncContext(t, "testhub")
+	if err := tc.clusterManagerController.sync(ctx, syncContext); err != nil {
+		t.Fatalf("Expected no error when sync, %v", err)
+	}
+
+	var pdbs, metricsServices, secrets []string
+	var networkPolicy *networkingv1.NetworkPolicy
+	for _, action := range tc.managementKubeClient.Actions() {
+		if action.GetVerb() != createVerb {
+			continue
+		}
+		switch o := action.(clienttesting.CreateActionImpl).Object.(type) {
+		case *appsv1.Deployment:
+			if len(o.Spec.Template.Spec.TopologySpreadConstraints) != 2 {
+				t.Errorf("expected the topology spread constraints of deployment %s, got %v",
+					o.Name, o.Spec.Template.Spec.TopologySpreadConstraints)
+			}
+		case *policyv1.PodDisruptionBudget:
+			pdbs = append(pdbs, o.Name)
+		case *corev1.Service:
+			metricsServices = append(metricsServices, o.Name)
+		case *corev1.Secret:
+			secrets = append(secrets, o.Name)
+		case *networkingv1.NetworkPolicy:
+			networkPolicy = o
+		}
+	}
+
+	// the webhook servers have pdbs but no metrics services.
+	testingcommon.AssertEqualNumber(t, len(pdbs), 6)
+	testingcommon.AssertEqualNumber(t, len(metricsServices), 4)
+	if networkPolicy == nil {
+		t.Fatalf("expected the networkpolicy is created")
+	}
+	if len(networkPolicy.Spec.Egress) != 0 || len(networkPolicy.Spec.Ingress[0].Ports) != 3 {
+		t.Errorf("unexpected networkpolicy %v", networkPolicy.Spec)
+	}
+	if networkPolicy.Spec.PodSelector.MatchLabels[helpers.HubLabelKey] != "testhub" {
+		t.Errorf("expected the networkpolicy selects the hub pods, got %v", networkPolicy.Spec.PodSelector)
+	}
+	if !sets.New(secrets...).HasAll(helpers.MetricsServingSignerSecret, helpers.MetricsServingCertSecret) {
+		t.Errorf("expected the metrics serving certificate is created, got %v", secrets)
+	}
+
+	var serviceMonitors int
+	for _, action := range tc.managementDynamicClient.Actions() {
+		if action.GetVerb() != createVerb || action.GetResource().Resource != "servicemonitors" {
+			continue
+		}
+		serviceMonitors++
+		serviceMonitor := action.(clienttesting.CreateActionImpl).Object.(*unstructured.Unstructured)
+		endpoints, _, _ := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+		tlsConfig, _, _ := unstructured.NestedMap(endpoints[0].(map[string]interface{}), "tlsConfig")
+		if _, ok := tlsConfig["insecureSkipVerify"]; ok {
+			t.Errorf("expected the metrics endpoint of %s is verified, got %v", serviceMonitor.GetName(), tlsConfig)
+		}
+		caName, _, _ := unstructured.NestedString(tlsConfig, "ca", "configMap", "name")
+		if caName != helpers.MetricsServingCABundleConfigMap {
+			t.Errorf("expected the metrics endpoint of %s is verified with the serving ca, got %v",
+				serviceMonitor.GetName(), tlsConfig)
+		}
+	}
+	testingcommon.AssertEqualNumber(t, serviceMonitors, 4)
+}
+
 func TestSyncDeployNoWebhook(t *testing.T) {
 	clusterManager := newClusterManager("testhub")
 	tc := newTestController(t, clusterManager)
@@ -502,7 +671,7 @@ func TestSyncDeployNoWebhook(t *testing.T) {
 
 	// Check if resources are created as expected
 	// We expect create the namespace twice respectively in the management cluster and the hub cluster.
-	testingcommon.AssertEqualNumber(t, len(createKubeObjects), 30)
+	testingcommon.AssertEqualNumber(t, len(createKubeObjects), 35)
 	for _, object := range createKubeObjects {
 		ensureObject(t, object, clusterManager, false)
 	}
@@ -544,7 +713,7 @@ func TestSyncDelete(t *testing.T) {
 			deleteKubeActions = append(deleteKubeActions, deleteKubeAction)
 		}
 	}
-	testingcommon.AssertEqualNumber(t, len(deleteKubeActions), 30) // delete namespace both from the hub cluster and the mangement cluster
+	testingcommon.AssertEqualNumber(t, len(deleteKubeActions), 36) // delete namespace both from the hub cluster and the mangement cluster
 
 	var deleteCRDActions []clienttesting.DeleteActionImpl
 	crdActions := tc.apiExtensionClient.Actions()
@@ -735,6 +904,7 @@ func newFakeHubConfigWithResourceRequirement(t *testing.T, r *operatorapiv1.Reso
 func getManifestFiles() []string {
 	return []string{
 		"cluster-manager/management/cluster-manager-addon-manager-deployment.yaml",
+		"cluster-manager/management/cluster-manager-grpc-server-deployment.yaml",
 		"cluster-manager/management/cluster-manager-manifestworkreplicaset-deployment.yaml",
 		"cluster-manager/management/cluster-manager-placement-deployment.yaml",
 		"cluster-manager/management/cluster-manager-registration-deployment.yaml",
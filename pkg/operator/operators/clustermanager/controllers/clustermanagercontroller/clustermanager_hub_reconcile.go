@@ -19,6 +19,7 @@ import (
 	operatorapiv1 "open-cluster-management.io/api/operator/v1"
 
 	"open-cluster-management.io/ocm/manifests"
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
 )
 
@@ -38,6 +39,8 @@ var (
 		// work-webhook
 		"cluster-manager/hub/cluster-manager-work-webhook-clusterrole.yaml",
 		"cluster-manager/hub/cluster-manager-work-webhook-clusterrolebinding.yaml",
+		"cluster-manager/hub/cluster-manager-work-webhook-role.yaml",
+		"cluster-manager/hub/cluster-manager-work-webhook-rolebinding.yaml",
 		"cluster-manager/hub/cluster-manager-work-webhook-serviceaccount.yaml",
 		// work executor admin
 		"cluster-manager/hub/cluster-manager-work-executor-admin-clusterrole.yaml",
@@ -51,9 +54,31 @@ var (
 		// manifestworkreplicaset
 		"cluster-manager/hub/cluster-manager-manifestworkreplicaset-clusterrole.yaml",
 		"cluster-manager/hub/cluster-manager-manifestworkreplicaset-clusterrolebinding.yaml",
+		"cluster-manager/hub/cluster-manager-manifestworkreplicaset-role.yaml",
+		"cluster-manager/hub/cluster-manager-manifestworkreplicaset-rolebinding.yaml",
 		"cluster-manager/hub/cluster-manager-manifestworkreplicaset-serviceaccount.yaml",
 	}
 
+	clusterProfileResourceFiles = []string{
+		// the secrets of the clusterprofiles
+		"cluster-manager/hub/cluster-manager-registration-clusterprofile-role.yaml",
+		"cluster-manager/hub/cluster-manager-registration-clusterprofile-rolebinding.yaml",
+	}
+
+	clusterImporterResourceFiles = []string{
+		// the secrets of the import bundles
+		"cluster-manager/hub/cluster-manager-registration-importer-role.yaml",
+		"cluster-manager/hub/cluster-manager-registration-importer-rolebinding.yaml",
+	}
+
+	grpcServerResourceFiles = []string{
+		// grpc-server
+		"cluster-manager/hub/cluster-manager-grpc-server-clusterrole.yaml",
+		"cluster-manager/hub/cluster-manager-grpc-server-clusterrolebinding.yaml",
+		"cluster-manager/hub/cluster-manager-grpc-server-serviceaccount.yaml",
+		"cluster-manager/hub/cluster-manager-grpc-server-bootstrap-configmap.yaml",
+	}
+
 	hubAddOnManagerRbacResourceFiles = []string{
 		// addon-manager
 		"cluster-manager/hub/cluster-manager-addon-manager-clusterrole.yaml",
@@ -101,6 +126,30 @@ func (c *hubReconcile) reconcile(ctx context.Context, cm *operatorapiv1.ClusterM
 		}
 	}
 
+	// Remove the clusterprofile resources if it is not enabled
+	if !config.ClusterProfileEnabled {
+		_, _, err := cleanResources(ctx, c.hubKubeClient, cm, config, clusterProfileResourceFiles...)
+		if err != nil {
+			return cm, reconcileStop, err
+		}
+	}
+
+	// Remove the cluster importer resources if it is not enabled
+	if !config.ClusterImporterEnabled {
+		_, _, err := cleanResources(ctx, c.hubKubeClient, cm, config, clusterImporterResourceFiles...)
+		if err != nil {
+			return cm, reconcileStop, err
+		}
+	}
+
+	// Remove the grpc server resources if it is not enabled
+	if !config.GRPCServer.Enabled {
+		_, _, err := cleanResources(ctx, c.hubKubeClient, cm, config, grpcServerResourceFiles...)
+		if err != nil {
+			return cm, reconcileStop, err
+		}
+	}
+
 	hubResources := getHubResources(cm.Spec.DeployOption.Mode, config)
 	var appliedErrs []error
 
@@ -137,6 +186,12 @@ func (c *hubReconcile) reconcile(ctx context.Context, cm *operatorapiv1.ClusterM
 		return cm, reconcileStop, utilerrors.NewAggregate(appliedErrs)
 	}
 
+	// the digest key of the encrypted manifests is shared by the work webhook and the manifestworkreplicaset
+	// controller, it is created by the operator so the components only need to read it.
+	if err := encryption.EnsureDigestKey(ctx, c.hubKubeClient, config.ClusterManagerNamespace); err != nil {
+		return cm, reconcileStop, err
+	}
+
 	return cm, reconcileContinue, nil
 }
 
@@ -157,6 +212,18 @@ func getHubResources(mode operatorapiv1.InstallMode, config manifests.HubConfig)
 		hubResources = append(hubResources, mwReplicaSetResourceFiles...)
 	}
 
+	if config.ClusterProfileEnabled {
+		hubResources = append(hubResources, clusterProfileResourceFiles...)
+	}
+
+	if config.ClusterImporterEnabled {
+		hubResources = append(hubResources, clusterImporterResourceFiles...)
+	}
+
+	if config.GRPCServer.Enabled {
+		hubResources = append(hubResources, grpcServerResourceFiles...)
+	}
+
 	// the hubHostedWebhookServiceFiles are only used in hosted mode
 	if helpers.IsHosted(mode) {
 		hubResources = append(hubResources, hubHostedWebhookServiceFiles...)
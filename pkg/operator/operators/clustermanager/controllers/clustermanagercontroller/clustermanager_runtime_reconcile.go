@@ -12,9 +12,14 @@ import (
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	appsv1 "k8s.io/api/apps/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -41,16 +46,37 @@ var (
 	mwReplicaSetDeploymentFiles = []string{
 		"cluster-manager/management/cluster-manager-manifestworkreplicaset-deployment.yaml",
 	}
+
+	grpcServerDeploymentFiles = []string{
+		"cluster-manager/management/cluster-manager-grpc-server-deployment.yaml",
+	}
+
+	// The grpc server service is deployed in the management cluster together with the grpc server,
+	// since the klusterlets connect to the grpc server directly.
+	grpcServerServiceFiles = []string{
+		"cluster-manager/management/cluster-manager-grpc-server-service.yaml",
+	}
+
+	// The webhook servers do not serve metrics on the secure port, so no ServiceMonitor is rendered for them.
+	webhookDeploymentFiles = sets.New(
+		"cluster-manager/management/cluster-manager-registration-webhook-deployment.yaml",
+		"cluster-manager/management/cluster-manager-work-webhook-deployment.yaml",
+	)
 )
 
+// webhookHealthPort is the port of the health checks of the webhook servers.
+const webhookHealthPort = int32(8000)
+
 type runtimeReconcile struct {
 	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
 	hubKubeClient kubernetes.Interface
 	hubKubeConfig *rest.Config
+	hardening     helpers.Hardening
 
 	ensureSAKubeconfigs func(ctx context.Context, clusterManagerName, clusterManagerNamespace string,
 		hubConfig *rest.Config, hubClient, managementClient kubernetes.Interface, recorder events.Recorder,
-		mwctrEnabled, addonManagerEnabled bool) error
+		mwctrEnabled, addonManagerEnabled, grpcServerEnabled bool) error
 
 	cache    resourceapply.ResourceCache
 	recorder events.Recorder
@@ -64,6 +90,9 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 		if err != nil {
 			return cm, reconcileStop, err
 		}
+		if err := c.cleanHardening(ctx, config, addOnManagerDeploymentFiles...); err != nil {
+			return cm, reconcileStop, err
+		}
 	}
 
 	// Remove ManifestWokReplicaSet deployment if feature not enabled
@@ -72,6 +101,21 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 		if err != nil {
 			return cm, reconcileStop, err
 		}
+		if err := c.cleanHardening(ctx, config, mwReplicaSetDeploymentFiles...); err != nil {
+			return cm, reconcileStop, err
+		}
+	}
+
+	// Remove the grpc server deployment and service if it is not enabled
+	if !config.GRPCServer.Enabled {
+		grpcServerFiles := append(append([]string{}, grpcServerDeploymentFiles...), grpcServerServiceFiles...)
+		_, _, err := cleanResources(ctx, c.kubeClient, cm, config, grpcServerFiles...)
+		if err != nil {
+			return cm, reconcileStop, err
+		}
+		if err := c.cleanHardening(ctx, config, grpcServerDeploymentFiles...); err != nil {
+			return cm, reconcileStop, err
+		}
 	}
 
 	if cm.Spec.RegistrationConfiguration != nil && cm.Spec.RegistrationConfiguration.RegistrationDrivers != nil {
@@ -89,6 +133,18 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 		config.EnabledRegistrationDrivers = strings.Join(enabledRegistrationDrivers, ",")
 	}
 
+	// The grpc registration driver signs the client certificates of the klusterlets connecting to the grpc server.
+	if config.GRPCServer.Enabled {
+		enabledRegistrationDrivers := []string{commonhelpers.CSRAuthType}
+		if len(config.EnabledRegistrationDrivers) > 0 {
+			enabledRegistrationDrivers = strings.Split(config.EnabledRegistrationDrivers, ",")
+		}
+		if !sets.New(enabledRegistrationDrivers...).Has(commonhelpers.GRPCCAuthType) {
+			enabledRegistrationDrivers = append(enabledRegistrationDrivers, commonhelpers.GRPCCAuthType)
+		}
+		config.EnabledRegistrationDrivers = strings.Join(enabledRegistrationDrivers, ",")
+	}
+
 	// In the Hosted mode, ensure the rbac kubeconfig secrets is existed for deployments to mount.
 	// In this step, we get serviceaccount token from the hub cluster to form a kubeconfig and set it as a secret on the management cluster.
 	// Before this step, the serviceaccounts in the hub cluster and the namespace in the management cluster should be applied first.
@@ -96,7 +152,7 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 		clusterManagerNamespace := helpers.ClusterManagerNamespace(cm.Name, cm.Spec.DeployOption.Mode)
 		err := c.ensureSAKubeconfigs(ctx, cm.Name, clusterManagerNamespace,
 			c.hubKubeConfig, c.hubKubeClient, c.kubeClient, c.recorder,
-			config.MWReplicaSetEnabled, config.AddOnManagerEnabled)
+			config.MWReplicaSetEnabled, config.AddOnManagerEnabled, config.GRPCServer.Enabled)
 		if err != nil {
 			meta.SetStatusCondition(&cm.Status.Conditions, metav1.Condition{
 				Type:    operatorapiv1.ConditionClusterManagerApplied,
@@ -112,6 +168,9 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 	// Note: the certrotation-controller will create CABundle after the namespace applied.
 	// And CABundle is used to render apiservice resources.
 	managementResources := []string{namespaceResource}
+	if config.GRPCServer.Enabled {
+		managementResources = append(managementResources, grpcServerServiceFiles...)
+	}
 
 	var appliedErrs []error
 	resourceResults := helpers.ApplyDirectly(
@@ -144,6 +203,14 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 	if config.MWReplicaSetEnabled {
 		deployResources = append(deployResources, mwReplicaSetDeploymentFiles...)
 	}
+	if config.GRPCServer.Enabled {
+		deployResources = append(deployResources, grpcServerDeploymentFiles...)
+	}
+	// the serving certificate of the metrics endpoints is mounted by the deployments
+	if err := helpers.SyncMetricsServingCert(ctx, c.kubeClient, config.ClusterManagerNamespace,
+		c.hardening.ServiceMonitor); err != nil {
+		appliedErrs = append(appliedErrs, err)
+	}
 	for _, file := range deployResources {
 		updatedDeployment, currentGeneration, err := helpers.ApplyDeployment(
 			ctx,
@@ -167,11 +234,20 @@ func (c *runtimeReconcile) reconcile(ctx context.Context, cm *operatorapiv1.Clus
 		}
 		helpers.SetGenerationStatuses(&cm.Status.Generations, currentGeneration)
 
+		if err := c.syncHardening(ctx, updatedDeployment, file, true); err != nil {
+			appliedErrs = append(appliedErrs, err)
+		}
+
 		if updatedDeployment.Generation != updatedDeployment.Status.ObservedGeneration || *updatedDeployment.Spec.Replicas != updatedDeployment.Status.ReadyReplicas {
 			progressingDeployments = append(progressingDeployments, updatedDeployment.Name)
 		}
 	}
 
+	if err := helpers.SyncNetworkPolicy(ctx, c.kubeClient.NetworkingV1(), c.recorder,
+		newHubNetworkPolicy(config), c.hardening.NetworkPolicy); err != nil {
+		appliedErrs = append(appliedErrs, err)
+	}
+
 	if len(progressingDeployments) > 0 {
 		meta.SetStatusCondition(&cm.Status.Conditions, metav1.Condition{
 			Type:    operatorapiv1.ConditionProgressing,
@@ -208,8 +284,50 @@ func (c *runtimeReconcile) clean(ctx context.Context, cm *operatorapiv1.ClusterM
 	return cleanResources(ctx, c.kubeClient, cm, config, managementResources...)
 }
 
+// syncHardening reconciles the PodDisruptionBudget and the ServiceMonitor of the deployment rendered from the file.
+// They are removed if the deployment is not deployed.
+func (c *runtimeReconcile) syncHardening(ctx context.Context, deployment *appsv1.Deployment, file string, deployed bool) error {
+	if err := helpers.SyncPodDisruptionBudget(ctx, c.kubeClient, c.recorder, deployment,
+		deployed && c.hardening.PodDisruptionBudget); err != nil {
+		return err
+	}
+	if webhookDeploymentFiles.Has(file) {
+		return nil
+	}
+	return helpers.SyncServiceMonitor(ctx, c.kubeClient, c.dynamicClient, c.recorder, deployment,
+		deployed && c.hardening.ServiceMonitor)
+}
+
+// cleanHardening removes the hardening resources of the deployments rendered from the files.
+func (c *runtimeReconcile) cleanHardening(ctx context.Context, config manifests.HubConfig, files ...string) error {
+	for _, file := range files {
+		template, err := manifests.ClusterManagerManifestFiles.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		deployment := resourceread.ReadDeploymentV1OrDie(assets.MustCreateAssetFromTemplate(file, template, config).Data)
+		if err := c.syncHardening(ctx, deployment, file, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newHubNetworkPolicy returns the NetworkPolicy of the pods of the hub components, which only allows the ingress
+// traffic to the webhook servers, the gRPC server and the secure ports of the controllers serving the metrics and
+// health checks. The webhook ports of the hosted mode are allowed as well, since they are the endpoints the hub apiserver reaches.
+func newHubNetworkPolicy(config manifests.HubConfig) *networkingv1.NetworkPolicy {
+	ingressPorts := []int32{helpers.MetricsPort, webhookHealthPort, defaultWebhookPort,
+		config.RegistrationWebhook.Port, config.WorkWebhook.Port}
+	if config.GRPCServer.Enabled {
+		ingressPorts = append(ingressPorts, config.GRPCServer.Port)
+	}
+	return helpers.NewNetworkPolicy(config.ClusterManagerName, config.ClusterManagerNamespace, config.Labels,
+		map[string]string{helpers.HubLabelKey: config.ClusterManagerName}, ingressPorts, nil)
+}
+
 // getSAs return serviceaccount names of all hub components
-func getSAs(mwctrEnabled, addonManagerEnabled bool) []string {
+func getSAs(mwctrEnabled, addonManagerEnabled, grpcServerEnabled bool) []string {
 	sas := []string{
 		"registration-controller-sa",
 		"registration-webhook-sa",
@@ -222,5 +340,8 @@ func getSAs(mwctrEnabled, addonManagerEnabled bool) []string {
 	if addonManagerEnabled {
 		sas = append(sas, "addon-manager-controller-sa")
 	}
+	if grpcServerEnabled {
+		sas = append(sas, "grpc-server-sa")
+	}
 	return sas
 }
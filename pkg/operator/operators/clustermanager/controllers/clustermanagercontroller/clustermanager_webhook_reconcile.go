@@ -30,9 +30,11 @@ var (
 		"cluster-manager/hub/cluster-manager-registration-webhook-validatingconfiguration.yaml",
 		"cluster-manager/hub/cluster-manager-registration-webhook-mutatingconfiguration.yaml",
 		"cluster-manager/hub/cluster-manager-registration-webhook-clustersetbinding-validatingconfiguration.yaml",
+		"cluster-manager/hub/cluster-manager-registration-webhook-clusterset-validatingconfiguration.yaml",
 	}
 	hubWorkWebhookResourceFiles = []string{
 		"cluster-manager/hub/cluster-manager-work-webhook-validatingconfiguration.yaml",
+		"cluster-manager/hub/cluster-manager-work-webhook-mutatingconfiguration.yaml",
 	}
 )
 
@@ -7,6 +7,7 @@ import (
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -36,6 +37,10 @@ func (o *Options) RunClusterManagerOperator(ctx context.Context, controllerConte
 	if err != nil {
 		return err
 	}
+	dynamicClient, err := dynamic.NewForConfig(controllerContext.KubeConfig)
+	if err != nil {
+		return err
+	}
 
 	newOneTermInformer := func(name string) informers.SharedInformerFactory {
 		return informers.NewSharedInformerFactoryWithOptions(kubeClient, 5*time.Minute,
@@ -47,7 +52,10 @@ func (o *Options) RunClusterManagerOperator(ctx context.Context, controllerConte
 	signerSecretInformer := newOneTermInformer(helpers.SignerSecret)
 	registrationSecretInformer := newOneTermInformer(helpers.RegistrationWebhookSecret)
 	workSecretInformer := newOneTermInformer(helpers.WorkWebhookSecret)
+	grpcServerSecretInformer := newOneTermInformer(helpers.GRPCServerSecret)
+	grpcClientSignerSecretInformer := newOneTermInformer(helpers.GRPCClientSignerSecret)
 	configmapInformer := newOneTermInformer(helpers.CaBundleConfigmap)
+	grpcClientConfigmapInformer := newOneTermInformer(helpers.GRPCClientCABundleConfigmap)
 
 	deploymentInformer := informers.NewSharedInformerFactoryWithOptions(kubeClient, 5*time.Minute,
 		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
@@ -66,6 +74,13 @@ func (o *Options) RunClusterManagerOperator(ctx context.Context, controllerConte
 		helpers.SignerSecret:              signerSecretInformer.Core().V1().Secrets(),
 		helpers.RegistrationWebhookSecret: registrationSecretInformer.Core().V1().Secrets(),
 		helpers.WorkWebhookSecret:         workSecretInformer.Core().V1().Secrets(),
+		helpers.GRPCServerSecret:          grpcServerSecretInformer.Core().V1().Secrets(),
+		helpers.GRPCClientSignerSecret:    grpcClientSignerSecretInformer.Core().V1().Secrets(),
+	}
+
+	configMapInformers := map[string]corev1informers.ConfigMapInformer{
+		helpers.CaBundleConfigmap:           configmapInformer.Core().V1().ConfigMaps(),
+		helpers.GRPCClientCABundleConfigmap: grpcClientConfigmapInformer.Core().V1().ConfigMaps(),
 	}
 
 	// Build operator client and informer
@@ -78,6 +93,7 @@ func (o *Options) RunClusterManagerOperator(ctx context.Context, controllerConte
 	clusterManagerController := clustermanagercontroller.NewClusterManagerController(
 		kubeClient,
 		controllerContext.KubeConfig,
+		dynamicClient,
 		operatorClient.OperatorV1().ClusterManagers(),
 		operatorInformer.Operator().V1().ClusterManagers(),
 		deploymentInformer.Apps().V1().Deployments(),
@@ -99,7 +115,7 @@ func (o *Options) RunClusterManagerOperator(ctx context.Context, controllerConte
 	certRotationController := certrotationcontroller.NewCertRotationController(
 		kubeClient,
 		secretInformers,
-		configmapInformer.Core().V1().ConfigMaps(),
+		configMapInformers,
 		operatorInformer.Operator().V1().ClusterManagers(),
 		controllerContext.EventRecorder)
 
@@ -121,7 +137,10 @@ func (o *Options) RunClusterManagerOperator(ctx context.Context, controllerConte
 	go signerSecretInformer.Start(ctx.Done())
 	go registrationSecretInformer.Start(ctx.Done())
 	go workSecretInformer.Start(ctx.Done())
+	go grpcServerSecretInformer.Start(ctx.Done())
+	go grpcClientSignerSecretInformer.Start(ctx.Done())
 	go configmapInformer.Start(ctx.Done())
+	go grpcClientConfigmapInformer.Start(ctx.Done())
 	go clusterManagerController.Run(ctx, 1)
 	go statusController.Run(ctx, 1)
 	go certRotationController.Run(ctx, 1)
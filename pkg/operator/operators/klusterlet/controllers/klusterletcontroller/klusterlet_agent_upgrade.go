@@ -0,0 +1,363 @@
+/*
+ * Copyright 2022 Contributors to the Open Cluster Management project
+ */
+
+package klusterletcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/yaml"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/operator/helpers"
+)
+
+const (
+	// conditionAgentUpgradeDegraded is true when the latest agent upgrade did not become healthy
+	// in time. The reason tells whether the agent was rolled back to the last healthy spec.
+	conditionAgentUpgradeDegraded = "AgentUpgradeDegraded"
+
+	reasonAgentUpgradeSucceeded   = "AgentUpgradeSucceeded"
+	reasonAgentUpgradeProgressing = "AgentUpgradeProgressing"
+	reasonAgentUpgradeRolledBack  = "AgentUpgradeRolledBack"
+	reasonAgentUpgradeFailed      = "AgentUpgradeFailed"
+
+	// the upgrade state is recorded in the annotations of the agent deployment, so it survives
+	// operator restarts and is removed together with the deployment.
+	agentUpgradeTargetHashAnnotation = "operator.open-cluster-management.io/agent-upgrade-target-hash"
+	agentUpgradeStartTimeAnnotation  = "operator.open-cluster-management.io/agent-upgrade-start-time"
+	agentHealthySpecHashAnnotation   = "operator.open-cluster-management.io/agent-healthy-spec-hash"
+	agentHealthySpecAnnotation       = "operator.open-cluster-management.io/agent-healthy-spec"
+	agentFailedSpecHashAnnotation    = "operator.open-cluster-management.io/agent-failed-spec-hash"
+	agentFailedReasonAnnotation      = "operator.open-cluster-management.io/agent-failed-reason"
+
+	managedClusterLeaseName   = "managed-cluster-lease"
+	agentUpgradeCheckInterval = 30 * time.Second
+)
+
+// agentUpgradeStatus is the upgrade result of one agent deployment.
+type agentUpgradeStatus struct {
+	deploymentName string
+	reason         string
+	message        string
+}
+
+// agentUpgradeGate rolls out a new agent deployment spec only when the agent becomes healthy
+// within the timeout. The agent is healthy when the deployment is fully rolled out and, for the
+// agent running the registration, the managed cluster lease on the hub is renewed with the hub
+// kubeconfig after the upgrade started. Otherwise the deployment is reverted to the last healthy
+// spec, and stays there until the klusterlet renders a different spec.
+type agentUpgradeGate struct {
+	kubeClient kubernetes.Interface
+	timeout    time.Duration
+	clock      clock.Clock
+	// hubKubeClientBuilder builds the hub client from the hub kubeconfig secret, it is
+	// replaceable in unit tests.
+	hubKubeClientBuilder func(secret *corev1.Secret) (kubernetes.Interface, error)
+}
+
+func newAgentUpgradeGate(kubeClient kubernetes.Interface, timeout time.Duration) *agentUpgradeGate {
+	if timeout <= 0 {
+		return nil
+	}
+	return &agentUpgradeGate{
+		kubeClient:           kubeClient,
+		timeout:              timeout,
+		clock:                clock.RealClock{},
+		hubKubeClientBuilder: buildHubKubeClient,
+	}
+}
+
+func buildHubKubeClient(secret *corev1.Secret) (kubernetes.Interface, error) {
+	restConfig, err := helpers.LoadClientConfigFromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// applyDeployment applies the rendered agent deployment, or the last healthy spec if the
+// rendered one has been rolled back, and then checks the health of the rollout.
+func (g *agentUpgradeGate) applyDeployment(
+	ctx context.Context,
+	klusterlet *operatorapiv1.Klusterlet,
+	manifests resourceapply.AssetFunc,
+	recorder events.Recorder,
+	file string,
+	checkHubConnection bool) (operatorapiv1.GenerationStatus, agentUpgradeStatus, error) {
+	objData, err := manifests(file)
+	if err != nil {
+		return operatorapiv1.GenerationStatus{}, agentUpgradeStatus{}, err
+	}
+	required := &appsv1.Deployment{}
+	if err := yaml.Unmarshal(objData, required); err != nil {
+		return operatorapiv1.GenerationStatus{}, agentUpgradeStatus{}, fmt.Errorf("%q: %v", file, err)
+	}
+	desiredHash, err := deploymentSpecHash(required, klusterlet.Spec.NodePlacement)
+	if err != nil {
+		return operatorapiv1.GenerationStatus{}, agentUpgradeStatus{}, err
+	}
+
+	status := agentUpgradeStatus{deploymentName: required.Name}
+	annotations := map[string]string{}
+	existing, err := g.kubeClient.AppsV1().Deployments(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return operatorapiv1.GenerationStatus{}, status, err
+	default:
+		annotations = existing.Annotations
+	}
+
+	rollback := annotations[agentFailedSpecHashAnnotation] == desiredHash && len(annotations[agentHealthySpecAnnotation]) > 0
+	deployment, generationStatus, err := g.apply(ctx, klusterlet, required, objData, annotations, rollback, recorder, file)
+	if err != nil {
+		return generationStatus, status, err
+	}
+
+	switch {
+	case rollback:
+		status.reason = reasonAgentUpgradeRolledBack
+		status.message = annotations[agentFailedReasonAnnotation]
+		return generationStatus, status, nil
+	case annotations[agentHealthySpecHashAnnotation] == desiredHash:
+		status.reason = reasonAgentUpgradeSucceeded
+		return generationStatus, status, nil
+	case annotations[agentUpgradeTargetHashAnnotation] != desiredHash:
+		// a new spec is rolled out, start to watch it.
+		status.reason = reasonAgentUpgradeProgressing
+		status.message = fmt.Sprintf("deployment %q is upgrading", required.Name)
+		return generationStatus, status, g.patchAnnotations(ctx, deployment, map[string]interface{}{
+			agentUpgradeTargetHashAnnotation: desiredHash,
+			agentUpgradeStartTimeAnnotation:  g.clock.Now().UTC().Format(time.RFC3339),
+			agentFailedSpecHashAnnotation:    nil,
+			agentFailedReasonAnnotation:      nil,
+		})
+	}
+
+	startTime, err := time.Parse(time.RFC3339, annotations[agentUpgradeStartTimeAnnotation])
+	if err != nil {
+		startTime = g.clock.Now()
+	}
+
+	// the hub connection is only checked after the agent has ever been healthy, so a cluster
+	// which has not joined the hub yet is not blocked.
+	checkHubConnection = checkHubConnection && len(annotations[agentHealthySpecAnnotation]) > 0
+	unhealthyMsg := g.checkHealth(ctx, deployment, startTime, checkHubConnection)
+	switch {
+	case len(unhealthyMsg) == 0:
+		healthySpec, err := json.Marshal(deployment.Spec)
+		if err != nil {
+			return generationStatus, status, err
+		}
+		status.reason = reasonAgentUpgradeSucceeded
+		return generationStatus, status, g.patchAnnotations(ctx, deployment, map[string]interface{}{
+			agentHealthySpecHashAnnotation:   desiredHash,
+			agentHealthySpecAnnotation:       string(healthySpec),
+			agentUpgradeTargetHashAnnotation: nil,
+			agentUpgradeStartTimeAnnotation:  nil,
+		})
+	case g.clock.Since(startTime) < g.timeout:
+		status.reason = reasonAgentUpgradeProgressing
+		status.message = unhealthyMsg
+		return generationStatus, status, nil
+	case len(annotations[agentHealthySpecAnnotation]) == 0:
+		status.reason = reasonAgentUpgradeFailed
+		status.message = fmt.Sprintf("deployment %q is not healthy after %s, no healthy spec to roll back to: %s",
+			required.Name, g.timeout, unhealthyMsg)
+		return generationStatus, status, nil
+	}
+
+	// roll back to the last healthy spec
+	status.message = fmt.Sprintf("deployment %q is not healthy after %s: %s", required.Name, g.timeout, unhealthyMsg)
+	if err := g.patchAnnotations(ctx, deployment, map[string]interface{}{
+		agentFailedSpecHashAnnotation:    desiredHash,
+		agentFailedReasonAnnotation:      status.message,
+		agentUpgradeTargetHashAnnotation: nil,
+		agentUpgradeStartTimeAnnotation:  nil,
+	}); err != nil {
+		return generationStatus, status, err
+	}
+	recorder.Warningf("AgentUpgradeRolledBack", "deployment %s/%s is rolled back: %s",
+		required.Namespace, required.Name, status.message)
+	helpers.SetGenerationStatuses(&klusterlet.Status.Generations, generationStatus)
+	_, generationStatus, err = g.apply(ctx, klusterlet, required, objData, annotations, true, recorder, file)
+	status.reason = reasonAgentUpgradeRolledBack
+	return generationStatus, status, err
+}
+
+func (g *agentUpgradeGate) apply(
+	ctx context.Context,
+	klusterlet *operatorapiv1.Klusterlet,
+	required *appsv1.Deployment,
+	objData []byte,
+	annotations map[string]string,
+	rollback bool,
+	recorder events.Recorder,
+	file string) (*appsv1.Deployment, operatorapiv1.GenerationStatus, error) {
+	return helpers.ApplyDeployment(
+		ctx,
+		g.kubeClient,
+		klusterlet.Status.Generations,
+		klusterlet.Spec.NodePlacement,
+		func(name string) ([]byte, error) {
+			if !rollback {
+				return objData, nil
+			}
+			rolledBack := required.DeepCopy()
+			if err := json.Unmarshal([]byte(annotations[agentHealthySpecAnnotation]), &rolledBack.Spec); err != nil {
+				return nil, fmt.Errorf("failed to decode the healthy spec of deployment %q: %v", required.Name, err)
+			}
+			return json.Marshal(rolledBack)
+		},
+		recorder,
+		file)
+}
+
+// checkHealth returns an empty message if the agent deployment is healthy, otherwise returns
+// why it is not healthy.
+func (g *agentUpgradeGate) checkHealth(ctx context.Context, deployment *appsv1.Deployment,
+	startTime time.Time, checkHubConnection bool) string {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	switch {
+	case deployment.Status.ObservedGeneration < deployment.Generation:
+		return fmt.Sprintf("deployment %q generation %d is not observed", deployment.Name, deployment.Generation)
+	case deployment.Status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("%d of %d replicas of deployment %q are updated",
+			deployment.Status.UpdatedReplicas, replicas, deployment.Name)
+	case deployment.Status.AvailableReplicas < replicas:
+		return fmt.Sprintf("%d of %d replicas of deployment %q are available",
+			deployment.Status.AvailableReplicas, replicas, deployment.Name)
+	case deployment.Status.Replicas > deployment.Status.UpdatedReplicas:
+		return fmt.Sprintf("%d old replicas of deployment %q are pending termination",
+			deployment.Status.Replicas-deployment.Status.UpdatedReplicas, deployment.Name)
+	}
+
+	if !checkHubConnection || replicas == 0 {
+		return ""
+	}
+	if err := g.checkManagedClusterLease(ctx, deployment.Namespace, startTime); err != nil {
+		return fmt.Sprintf("agent of deployment %q is not connected to the hub: %v", deployment.Name, err)
+	}
+	return ""
+}
+
+// checkManagedClusterLease checks the hub kubeconfig of the agent is valid, and the managed cluster
+// lease on the hub is renewed after the given time.
+func (g *agentUpgradeGate) checkManagedClusterLease(ctx context.Context, agentNamespace string, since time.Time) error {
+	hubConfigSecret, err := g.kubeClient.CoreV1().Secrets(agentNamespace).Get(ctx, helpers.HubKubeConfig, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	clusterName := string(hubConfigSecret.Data["cluster-name"])
+	if len(clusterName) == 0 {
+		return fmt.Errorf("the cluster name in secret %s/%s is empty", agentNamespace, helpers.HubKubeConfig)
+	}
+	hubClient, err := g.hubKubeClientBuilder(hubConfigSecret)
+	if err != nil {
+		return fmt.Errorf("invalid hub kubeconfig: %v", err)
+	}
+	lease, err := hubClient.CoordinationV1().Leases(clusterName).Get(ctx, managedClusterLeaseName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if lease.Spec.RenewTime == nil || lease.Spec.RenewTime.Time.Before(since) {
+		return fmt.Errorf("lease %s/%s is not renewed since %s", clusterName, managedClusterLeaseName, since.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (g *agentUpgradeGate) patchAnnotations(ctx context.Context, deployment *appsv1.Deployment, annotations map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = g.kubeClient.AppsV1().Deployments(deployment.Namespace).Patch(
+		ctx, deployment.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// deploymentSpecHash returns the hash of the deployment spec which will be applied with the node placement.
+func deploymentSpecHash(required *appsv1.Deployment, nodePlacement operatorapiv1.NodePlacement) (string, error) {
+	deployment := required.DeepCopy()
+	deployment.Spec.Template.Spec.NodeSelector = nodePlacement.NodeSelector
+	deployment.Spec.Template.Spec.Tolerations = nodePlacement.Tolerations
+	objMeta := metav1.ObjectMeta{}
+	if err := resourceapply.SetSpecHashAnnotation(&objMeta, deployment.Spec); err != nil {
+		return "", err
+	}
+	for _, hash := range objMeta.Annotations {
+		return hash, nil
+	}
+	return "", fmt.Errorf("failed to hash the spec of deployment %q", required.Name)
+}
+
+// setAgentUpgradeCondition aggregates the upgrade status of the agent deployments into the
+// AgentUpgradeDegraded condition, and returns a requeue error if any upgrade is in progress.
+func setAgentUpgradeCondition(klusterlet *operatorapiv1.Klusterlet, statuses []agentUpgradeStatus) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	var rolledBack, failed, progressing []string
+	for _, status := range statuses {
+		switch status.reason {
+		case reasonAgentUpgradeRolledBack:
+			rolledBack = append(rolledBack, status.message)
+		case reasonAgentUpgradeFailed:
+			failed = append(failed, status.message)
+		case reasonAgentUpgradeProgressing:
+			progressing = append(progressing, status.message)
+		}
+	}
+
+	cond := metav1.Condition{
+		Type:    conditionAgentUpgradeDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  reasonAgentUpgradeSucceeded,
+		Message: "Agents are upgraded",
+	}
+	switch {
+	case len(rolledBack) > 0:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = reasonAgentUpgradeRolledBack
+		cond.Message = fmt.Sprintf("Agents are rolled back to the last healthy spec: %s", strings.Join(rolledBack, "; "))
+	case len(failed) > 0:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = reasonAgentUpgradeFailed
+		cond.Message = strings.Join(failed, "; ")
+	case len(progressing) > 0:
+		cond.Reason = reasonAgentUpgradeProgressing
+		cond.Message = strings.Join(progressing, "; ")
+	}
+	meta.SetStatusCondition(&klusterlet.Status.Conditions, cond)
+
+	if len(progressing) > 0 {
+		return commonhelpers.NewRequeueError("Agent upgrade is in progress", agentUpgradeCheckInterval)
+	}
+	return nil
+}
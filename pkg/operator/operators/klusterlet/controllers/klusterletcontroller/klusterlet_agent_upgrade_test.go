@@ -0,0 +1,251 @@
+package klusterletcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
+
+	operatorapiv1 "open-cluster-management.io/api/operator/v1"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/operator/helpers"
+)
+
+const (
+	testAgentDeploymentName = "klusterlet-registration-agent"
+	testAgentNamespace      = "open-cluster-management-agent"
+)
+
+func newAgentDeploymentManifests(image string) func(string) ([]byte, error) {
+	return func(string) ([]byte, error) {
+		return []byte(fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: %s
+  namespace: %s
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: klusterlet-registration-agent
+  template:
+    metadata:
+      labels:
+        app: klusterlet-registration-agent
+    spec:
+      containers:
+      - name: registration-controller
+        image: %s
+`, testAgentDeploymentName, testAgentNamespace, image)), nil
+	}
+}
+
+func newTestAgentUpgradeGate(clock *testingclock.FakeClock, objects ...runtime.Object) (*agentUpgradeGate, *fakekube.Clientset) {
+	kubeClient := fakekube.NewSimpleClientset(objects...)
+	gate := newAgentUpgradeGate(kubeClient, 5*time.Minute)
+	gate.clock = clock
+	return gate, kubeClient
+}
+
+// setDeploymentAvailable simulates the deployment controller rolling out the latest spec.
+func setDeploymentAvailable(t *testing.T, kubeClient kubernetes.Interface, available bool) {
+	deployment, err := kubeClient.AppsV1().Deployments(testAgentNamespace).Get(
+		context.TODO(), testAgentDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: deployment.Generation,
+		Replicas:           1,
+		UpdatedReplicas:    1,
+	}
+	if available {
+		deployment.Status.AvailableReplicas = 1
+	}
+	if _, err := kubeClient.AppsV1().Deployments(testAgentNamespace).UpdateStatus(
+		context.TODO(), deployment, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getAgentImage(t *testing.T, kubeClient kubernetes.Interface) string {
+	deployment, err := kubeClient.AppsV1().Deployments(testAgentNamespace).Get(
+		context.TODO(), testAgentDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deployment.Spec.Template.Spec.Containers[0].Image
+}
+
+func applyAgentDeployment(t *testing.T, gate *agentUpgradeGate, klusterlet *operatorapiv1.Klusterlet,
+	image string, checkHubConnection bool) agentUpgradeStatus {
+	generationStatus, status, err := gate.applyDeployment(context.TODO(), klusterlet, newAgentDeploymentManifests(image),
+		events.NewInMemoryRecorder("test", clock.RealClock{}), "klusterlet-registration-deployment.yaml", checkHubConnection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.SetGenerationStatuses(&klusterlet.Status.Generations, generationStatus)
+	return status
+}
+
+func TestAgentUpgradeRollback(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	gate, kubeClient := newTestAgentUpgradeGate(fakeClock)
+	klusterlet := newKlusterlet("klusterlet", testAgentNamespace, "cluster1")
+
+	// install the agent, it is progressing until the deployment is available.
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v1", false); status.reason != reasonAgentUpgradeProgressing {
+		t.Errorf("expected progressing, got %v", status)
+	}
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v1", false); status.reason != reasonAgentUpgradeProgressing {
+		t.Errorf("expected progressing, got %v", status)
+	}
+	setDeploymentAvailable(t, kubeClient, true)
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v1", false); status.reason != reasonAgentUpgradeSucceeded {
+		t.Errorf("expected succeeded, got %v", status)
+	}
+
+	// upgrade the agent, the new agent never becomes available.
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v2", false); status.reason != reasonAgentUpgradeProgressing {
+		t.Errorf("expected progressing, got %v", status)
+	}
+	setDeploymentAvailable(t, kubeClient, false)
+	if image := getAgentImage(t, kubeClient); image != "registration:v2" {
+		t.Errorf("expected image registration:v2, got %s", image)
+	}
+	fakeClock.Step(time.Minute)
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v2", false); status.reason != reasonAgentUpgradeProgressing {
+		t.Errorf("expected progressing, got %v", status)
+	}
+	fakeClock.Step(5 * time.Minute)
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v2", false); status.reason != reasonAgentUpgradeRolledBack {
+		t.Errorf("expected rolled back, got %v", status)
+	}
+	if image := getAgentImage(t, kubeClient); image != "registration:v1" {
+		t.Errorf("expected image registration:v1 after rollback, got %s", image)
+	}
+
+	// the rolled back spec is kept until a different spec is rendered.
+	status := applyAgentDeployment(t, gate, klusterlet, "registration:v2", false)
+	if status.reason != reasonAgentUpgradeRolledBack || len(status.message) == 0 {
+		t.Errorf("expected rolled back with message, got %v", status)
+	}
+	if image := getAgentImage(t, kubeClient); image != "registration:v1" {
+		t.Errorf("expected image registration:v1 after rollback, got %s", image)
+	}
+
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v3", false); status.reason != reasonAgentUpgradeProgressing {
+		t.Errorf("expected progressing, got %v", status)
+	}
+	if image := getAgentImage(t, kubeClient); image != "registration:v3" {
+		t.Errorf("expected image registration:v3, got %s", image)
+	}
+}
+
+func TestAgentUpgradeHubConnection(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	hubConfigSecret := newSecret(helpers.HubKubeConfig, testAgentNamespace)
+	hubConfigSecret.Data["cluster-name"] = []byte("cluster1")
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: managedClusterLeaseName, Namespace: "cluster1"},
+	}
+	hubKubeClient := fakekube.NewSimpleClientset(lease)
+
+	gate, kubeClient := newTestAgentUpgradeGate(fakeClock, hubConfigSecret)
+	gate.hubKubeClientBuilder = func(_ *corev1.Secret) (kubernetes.Interface, error) {
+		return hubKubeClient, nil
+	}
+	klusterlet := newKlusterlet("klusterlet", testAgentNamespace, "cluster1")
+
+	// the hub connection is not checked for the first install.
+	applyAgentDeployment(t, gate, klusterlet, "registration:v1", true)
+	setDeploymentAvailable(t, kubeClient, true)
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v1", true); status.reason != reasonAgentUpgradeSucceeded {
+		t.Errorf("expected succeeded, got %v", status)
+	}
+
+	applyAgentDeployment(t, gate, klusterlet, "registration:v2", true)
+	setDeploymentAvailable(t, kubeClient, true)
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v2", true); status.reason != reasonAgentUpgradeProgressing {
+		t.Errorf("expected progressing since lease is not renewed, got %v", status)
+	}
+
+	fakeClock.Step(time.Minute)
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: fakeClock.Now()}
+	if _, err := hubKubeClient.CoordinationV1().Leases("cluster1").Update(
+		context.TODO(), lease, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if status := applyAgentDeployment(t, gate, klusterlet, "registration:v2", true); status.reason != reasonAgentUpgradeSucceeded {
+		t.Errorf("expected succeeded, got %v", status)
+	}
+}
+
+func TestSetAgentUpgradeCondition(t *testing.T) {
+	cases := []struct {
+		name           string
+		statuses       []agentUpgradeStatus
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+		expectRequeue  bool
+	}{
+		{
+			name: "succeeded",
+			statuses: []agentUpgradeStatus{
+				{reason: reasonAgentUpgradeSucceeded},
+				{reason: reasonAgentUpgradeSucceeded},
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonAgentUpgradeSucceeded,
+		},
+		{
+			name: "progressing",
+			statuses: []agentUpgradeStatus{
+				{reason: reasonAgentUpgradeSucceeded},
+				{reason: reasonAgentUpgradeProgressing},
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonAgentUpgradeProgressing,
+			expectRequeue:  true,
+		},
+		{
+			name: "rolled back",
+			statuses: []agentUpgradeStatus{
+				{reason: reasonAgentUpgradeRolledBack},
+				{reason: reasonAgentUpgradeProgressing},
+			},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: reasonAgentUpgradeRolledBack,
+			expectRequeue:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", testAgentNamespace, "cluster1")
+			err := setAgentUpgradeCondition(klusterlet, c.statuses)
+			var rqe commonhelpers.RequeueError
+			if requeue := errors.As(err, &rqe); requeue != c.expectRequeue {
+				t.Errorf("expected requeue %v, got error %v", c.expectRequeue, err)
+			}
+			cond := meta.FindStatusCondition(klusterlet.Status.Conditions, conditionAgentUpgradeDegraded)
+			if cond == nil || cond.Status != c.expectedStatus || cond.Reason != c.expectedReason {
+				t.Errorf("unexpected condition %v", cond)
+			}
+		})
+	}
+}
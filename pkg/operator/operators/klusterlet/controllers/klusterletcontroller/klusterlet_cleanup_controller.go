@@ -127,8 +127,9 @@ func (n *klusterletCleanupController) sync(ctx context.Context, controllerContex
 		HubApiServerHostAlias:                       klusterlet.Spec.HubApiServerHostAlias,
 		DisableAddonNamespace:                       n.disableAddonNamespace,
 
-		RegistrationServiceAccount: serviceAccountName("registration-sa", klusterlet),
-		WorkServiceAccount:         serviceAccountName("work-sa", klusterlet),
+		RegistrationServiceAccount:    serviceAccountName("registration-sa", klusterlet),
+		WorkServiceAccount:            serviceAccountName("work-sa", klusterlet),
+		ManifestWorkSnapshotNamespace: manifestWorkSnapshotNamespace(klusterlet),
 	}
 
 	config.populateBootstrap(klusterlet)
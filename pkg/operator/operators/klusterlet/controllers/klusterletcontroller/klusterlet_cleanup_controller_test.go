@@ -52,9 +52,9 @@ func TestSyncDelete(t *testing.T) {
 		}
 	}
 
-	// 11 managed static manifests + 12 management static manifests + 1 hub kubeconfig + 2 namespaces + 2 deployments
-	if len(deleteActions) != 28 {
-		t.Errorf("Expected 28 delete actions, but got %d", len(deleteActions))
+	// 14 managed static manifests + 12 management static manifests + 1 hub kubeconfig + 2 namespaces + 2 deployments
+	if len(deleteActions) != 31 {
+		t.Errorf("Expected 31 delete actions, but got %d", len(deleteActions))
 	}
 
 	var updateWorkActions []clienttesting.PatchActionImpl
@@ -126,9 +126,9 @@ func TestSyncDeleteHosted(t *testing.T) {
 		}
 	}
 
-	// 12 static manifests + 2 namespaces
-	if len(deleteActionsManaged) != 14 {
-		t.Errorf("Expected 14 delete actions, but got %d", len(deleteActionsManaged))
+	// 15 static manifests + 2 namespaces
+	if len(deleteActionsManaged) != 17 {
+		t.Errorf("Expected 17 delete actions, but got %d", len(deleteActionsManaged))
 	}
 
 	var updateWorkActions []clienttesting.PatchActionImpl
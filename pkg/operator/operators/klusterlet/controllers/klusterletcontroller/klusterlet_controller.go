@@ -2,7 +2,9 @@ package klusterletcontroller
 
 import (
 	"context"
+	errorhelpers "errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/dynamic"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	coreinformer "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -42,12 +45,35 @@ const (
 	klusterletFinalizer                   = "operator.open-cluster-management.io/klusterlet-cleanup"
 	managedResourcesEvictionTimestampAnno = "operator.open-cluster-management.io/managed-resources-eviction-timestamp"
 	klusterletNamespaceLabelKey           = "operator.open-cluster-management.io/klusterlet"
+	// enableClusterFreezeAnnotation is the annotation on the klusterlet to let the work agent hold the applies
+	// and the deletions of the manifestworks when the managed cluster is frozen on the hub.
+	enableClusterFreezeAnnotation = "operator.open-cluster-management.io/enable-cluster-freeze"
+	// enableRelatedObjectFeedbackAnnotation is the annotation on the klusterlet to let the work agent watch the
+	// related pods, replicasets and events of the resources to return them in the status feedback.
+	enableRelatedObjectFeedbackAnnotation = "operator.open-cluster-management.io/enable-related-object-feedback"
+	// enableEncryptionKeyAnnotation is the annotation on the klusterlet to let the registration agent generate the
+	// encryption key of the managed cluster and publish the public key with a cluster claim.
+	enableEncryptionKeyAnnotation = "operator.open-cluster-management.io/enable-encryption-key"
+	// enableClusterProfileAccessAnnotation is the annotation on the klusterlet to let the registration agent request
+	// the tokens of the ClusterProfile access service account provisioned by the hub.
+	enableClusterProfileAccessAnnotation = "operator.open-cluster-management.io/enable-cluster-profile-access"
+	// enableHubTunnelAnnotation is the annotation on the klusterlet to let the registration agent serve the requests
+	// proxied by the hub tunnel with the hub tunnel service account.
+	enableHubTunnelAnnotation = "operator.open-cluster-management.io/enable-hub-tunnel"
+	// enableManifestWorkSnapshotAnnotation is the annotation on the klusterlet to let the work agent persist the
+	// snapshot of the manifestworks and reconcile them from the snapshot when the hub is unreachable.
+	enableManifestWorkSnapshotAnnotation = "operator.open-cluster-management.io/enable-manifestwork-snapshot"
+	// enableManifestDecryptionAnnotation is the annotation on the klusterlet to let the work agent decrypt the
+	// manifests encrypted by the hub. It implies the encryption key, since the manifests are encrypted with the
+	// public key published by the registration agent.
+	enableManifestDecryptionAnnotation = "operator.open-cluster-management.io/enable-manifest-decryption"
 )
 
 type klusterletController struct {
 	patcher                       patcher.Patcher[*operatorapiv1.Klusterlet, operatorapiv1.KlusterletSpec, operatorapiv1.KlusterletStatus]
 	klusterletLister              operatorlister.KlusterletLister
 	kubeClient                    kubernetes.Interface
+	dynamicClient                 dynamic.Interface
 	kubeVersion                   *version.Version
 	operatorNamespace             string
 	cache                         resourceapply.ResourceCache
@@ -56,6 +82,8 @@ type klusterletController struct {
 	deploymentReplicas            int32
 	disableAddonNamespace         bool
 	enableSyncLabels              bool
+	agentUpgradeTimeout           time.Duration
+	hostResolver                  helpers.HostResolver
 }
 
 type klusterletReconcile interface {
@@ -73,6 +101,7 @@ const (
 // NewKlusterletController construct klusterlet controller
 func NewKlusterletController(
 	kubeClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
 	apiExtensionClient apiextensionsclient.Interface,
 	klusterletClient operatorv1client.KlusterletInterface,
 	klusterletInformer operatorinformer.KlusterletInformer,
@@ -85,9 +114,11 @@ func NewKlusterletController(
 	deploymentReplicas int32,
 	disableAddonNamespace bool,
 	enableSyncLabels bool,
+	agentUpgradeTimeout time.Duration,
 	recorder events.Recorder) factory.Controller {
 	controller := &klusterletController{
-		kubeClient: kubeClient,
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
 		patcher: patcher.NewPatcher[
 			*operatorapiv1.Klusterlet, operatorapiv1.KlusterletSpec, operatorapiv1.KlusterletStatus](klusterletClient),
 		klusterletLister:              klusterletInformer.Lister(),
@@ -99,6 +130,8 @@ func NewKlusterletController(
 		deploymentReplicas:            deploymentReplicas,
 		disableAddonNamespace:         disableAddonNamespace,
 		enableSyncLabels:              enableSyncLabels,
+		agentUpgradeTimeout:           agentUpgradeTimeout,
+		hostResolver:                  net.DefaultResolver,
 	}
 
 	return factory.New().WithSync(controller.sync).
@@ -175,6 +208,14 @@ type klusterletConfig struct {
 	WorkHubKubeAPIBurst                         int32
 	AppliedManifestWorkEvictionGracePeriod      string
 	WorkStatusSyncInterval                      string
+	EnableClusterFreeze                         bool
+	EnableRelatedObjectFeedback                 bool
+	EnableEncryptionKey                         bool
+	EnableClusterProfileAccess                  bool
+	EnableHubTunnel                             bool
+	EnableManifestWorkSnapshot                  bool
+	ManifestWorkSnapshotNamespace               string
+	EnableManifestDecryption                    bool
 	AgentKubeAPIQPS                             float32
 	AgentKubeAPIBurst                           int32
 	ExternalManagedKubeConfigSecret             string
@@ -212,6 +253,9 @@ type klusterletConfig struct {
 
 	// flag to enable about about-api
 	AboutAPIEnabled bool
+
+	// TopologySpreadConstraints is to spread the pods of the agents across the zones and the nodes.
+	TopologySpreadConstraints bool
 }
 
 // If multiplehubs feature gate is enabled, using the bootstrapkubeconfigs from klusterlet CR.
@@ -286,10 +330,18 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 		ResourceRequirementResourceType: helpers.ResourceType(klusterlet),
 		ResourceRequirements:            resourceRequirements,
 		DisableAddonNamespace:           n.disableAddonNamespace,
+		ManifestWorkSnapshotNamespace:   manifestWorkSnapshotNamespace(klusterlet),
 	}
 
 	config.populateBootstrap(klusterlet)
 
+	hardening, err := helpers.GetHardening(klusterlet.Annotations)
+	if err != nil {
+		klog.Errorf("Failed to parse hardening configuration for klusterlet %s: %v", klusterlet.Name, err)
+		return err
+	}
+	config.TopologySpreadConstraints = hardening.TopologySpreadConstraints
+
 	config.Labels = helpers.GetKlusterletAgentLabels(klusterlet, n.enableSyncLabels)
 
 	managedClusterClients, err := n.managedClusterClientsBuilder.
@@ -412,6 +464,14 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 	}
 
 	config.WorkFeatureGates, workFeatureMsgs = helpers.ConvertToFeatureGateFlags("Work", workFeatureGates, ocmfeature.DefaultSpokeWorkFeatureGates)
+	config.EnableClusterFreeze = klusterlet.Annotations[enableClusterFreezeAnnotation] == "true"
+	config.EnableRelatedObjectFeedback = klusterlet.Annotations[enableRelatedObjectFeedbackAnnotation] == "true"
+	config.EnableManifestDecryption = klusterlet.Annotations[enableManifestDecryptionAnnotation] == "true"
+	config.EnableEncryptionKey = klusterlet.Annotations[enableEncryptionKeyAnnotation] == "true" ||
+		config.EnableManifestDecryption
+	config.EnableClusterProfileAccess = klusterlet.Annotations[enableClusterProfileAccessAnnotation] == "true"
+	config.EnableHubTunnel = klusterlet.Annotations[enableHubTunnelAnnotation] == "true"
+	config.EnableManifestWorkSnapshot = klusterlet.Annotations[enableManifestWorkSnapshotAnnotation] == "true"
 	meta.SetStatusCondition(&klusterlet.Status.Conditions, helpers.BuildFeatureCondition(registrationFeatureMsgs, workFeatureMsgs))
 
 	// for singleton agent, the QPS and Burst use the max one between the configurations of registration and work
@@ -446,9 +506,13 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 		&runtimeReconcile{
 			managedClusterClients: managedClusterClients,
 			kubeClient:            n.kubeClient,
+			dynamicClient:         n.dynamicClient,
+			hardening:             hardening,
+			hostResolver:          n.hostResolver,
 			recorder:              controllerContext.Recorder(),
 			cache:                 n.cache,
-			enableSyncLabels:      n.enableSyncLabels},
+			enableSyncLabels:      n.enableSyncLabels,
+			upgradeGate:           newAgentUpgradeGate(n.kubeClient, n.agentUpgradeTimeout)},
 		&namespaceReconcile{
 			managedClusterClients: managedClusterClients,
 		},
@@ -457,8 +521,11 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 	var errs []error
 	for _, reconciler := range reconcilers {
 		var state reconcileState
+		var rqe commonhelpers.RequeueError
 		klusterlet, state, err = reconciler.reconcile(ctx, klusterlet, config)
-		if err != nil {
+		if errorhelpers.As(err, &rqe) {
+			controllerContext.Queue().AddAfter(klusterletName, rqe.RequeueTime)
+		} else if err != nil {
 			errs = append(errs, err)
 		}
 		if state == reconcileStop {
@@ -563,6 +630,12 @@ func ensureNamespace(
 	return nil
 }
 
+// manifestWorkSnapshotNamespace returns the namespace on the management cluster to persist the snapshot of the
+// manifestworks.
+func manifestWorkSnapshotNamespace(klusterlet *operatorapiv1.Klusterlet) string {
+	return fmt.Sprintf("%s-work-snapshot", helpers.AgentNamespace(klusterlet))
+}
+
 func serviceAccountName(suffix string, klusterlet *operatorapiv1.Klusterlet) string {
 	// in singleton mode, we only need one sa, so the name of work and registration sa are
 	// the same. We need to use the name of work sa for now, since the work sa permission can be
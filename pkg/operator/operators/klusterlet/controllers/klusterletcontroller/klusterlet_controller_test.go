@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -16,6 +17,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	fakeapiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -26,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 	clienttesting "k8s.io/client-go/testing"
@@ -33,6 +39,7 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 
 	fakeoperatorclient "open-cluster-management.io/api/client/operator/clientset/versioned/fake"
 	operatorinformers "open-cluster-management.io/api/client/operator/informers/externalversions"
@@ -192,6 +199,7 @@ func newTestController(t *testing.T, klusterlet *operatorapiv1.Klusterlet, recor
 		patcher: patcher.NewPatcher[
 			*operatorapiv1.Klusterlet, operatorapiv1.KlusterletSpec, operatorapiv1.KlusterletStatus](fakeOperatorClient.OperatorV1().Klusterlets()),
 		kubeClient:        fakeKubeClient,
+		dynamicClient:     fakedynamic.NewSimpleDynamicClient(runtime.NewScheme()),
 		klusterletLister:  operatorInformers.Operator().V1().Klusterlets().Lister(),
 		kubeVersion:       kubeVersion,
 		operatorNamespace: "open-cluster-management",
@@ -291,6 +299,7 @@ func newTestControllerHosted(
 		patcher: patcher.NewPatcher[
 			*operatorapiv1.Klusterlet, operatorapiv1.KlusterletSpec, operatorapiv1.KlusterletStatus](fakeOperatorClient.OperatorV1().Klusterlets()),
 		kubeClient:        fakeKubeClient,
+		dynamicClient:     fakedynamic.NewSimpleDynamicClient(runtime.NewScheme()),
 		klusterletLister:  operatorInformers.Operator().V1().Klusterlets().Lister(),
 		kubeVersion:       kubeVersion,
 		operatorNamespace: "open-cluster-management",
@@ -404,7 +413,7 @@ func assertKlusterletDeployment(t *testing.T, registrationAuthType string, actio
 	}
 
 	expectedArgs = append(expectedArgs, "--agent-id=", "--workload-source-driver=kube", "--workload-source-config=/spoke/hub-kubeconfig/kubeconfig",
-		"--status-sync-interval=60s", "--kube-api-qps=20", "--kube-api-burst=60", "--hub-kube-api-qps=40", "--hub-kube-api-burst=80")
+		"--status-sync-interval=60s", "--klusterlet-name=klusterlet", "--klusterlet-install-mode=Singleton", "--kube-api-qps=20", "--kube-api-burst=60", "--hub-kube-api-qps=40", "--hub-kube-api-burst=80")
 
 	if serverURL != "" {
 		expectedArgs = append(expectedArgs, fmt.Sprintf("--spoke-external-server-urls=%s", serverURL))
@@ -480,7 +489,7 @@ func assertRegistrationDeployment(t *testing.T, actions []clienttesting.Action,
 		expectedArgs = append(expectedArgs, fmt.Sprintf("--spoke-external-server-urls=%s", serverURL))
 	}
 
-	expectedArgs = append(expectedArgs, "--kube-api-qps=10", "--kube-api-burst=60")
+	expectedArgs = append(expectedArgs, "--klusterlet-name=klusterlet", "--kube-api-qps=10", "--kube-api-burst=60")
 	if awsAuth {
 		expectedArgs = append(expectedArgs, "--registration-auth=awsirsa",
 			"--hub-cluster-arn=arn:aws:eks:us-west-2:123456789012:cluster/hub-cluster1",
@@ -719,7 +728,7 @@ func TestSyncDeploySingleton(t *testing.T) {
 			// Check if resources are created as expected
 			// 10 managed static manifests + 11 management static manifests - 1 service account manifests + 1 addon namespace + 1 deployments
 			if len(createObjects) != 22 {
-				t.Errorf("Expect 21 objects created in the sync loop, actual %d", len(createObjects))
+				t.Errorf("Expect 22 objects created in the sync loop, actual %d", len(createObjects))
 			}
 			for _, object := range createObjects {
 				ensureObject(t, object, klusterlet, false)
@@ -1244,6 +1253,252 @@ func TestWorkConfig(t *testing.T) {
 
 }
 
+func TestAgentConfigAnnotations(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation string
+		deployment string
+		arg        string
+	}{
+		{
+			name:       "cluster freeze",
+			annotation: enableClusterFreezeAnnotation,
+			deployment: "work-agent",
+			arg:        "--enable-cluster-freeze",
+		},
+		{
+			name:       "related object feedback",
+			annotation: enableRelatedObjectFeedbackAnnotation,
+			deployment: "work-agent",
+			arg:        "--enable-related-object-feedback",
+		},
+		{
+			name:       "encryption key",
+			annotation: enableEncryptionKeyAnnotation,
+			deployment: "registration-agent",
+			arg:        "--enable-encryption-key",
+		},
+		{
+			name:       "cluster profile access",
+			annotation: enableClusterProfileAccessAnnotation,
+			deployment: "registration-agent",
+			arg:        "--enable-cluster-profile-access",
+		},
+		{
+			name:       "hub tunnel",
+			annotation: enableHubTunnelAnnotation,
+			deployment: "registration-agent",
+			arg:        "--enable-hub-tunnel",
+		},
+		{
+			name:       "manifestwork snapshot",
+			annotation: enableManifestWorkSnapshotAnnotation,
+			deployment: "work-agent",
+			arg:        "--enable-manifestwork-snapshot",
+		},
+		{
+			name:       "manifest decryption",
+			annotation: enableManifestDecryptionAnnotation,
+			deployment: "work-agent",
+			arg:        "--enable-manifest-decryption",
+		},
+		{
+			name:       "manifest decryption with the encryption key",
+			annotation: enableManifestDecryptionAnnotation,
+			deployment: "registration-agent",
+			arg:        "--enable-encryption-key",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			klusterlet.Annotations = map[string]string{c.annotation: "true"}
+			klusterlet.Status.Conditions = []metav1.Condition{
+				{
+					Type:   operatorapiv1.ConditionHubConnectionDegraded,
+					Status: metav1.ConditionFalse,
+				},
+			}
+			hubSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			hubSecret.Data["cluster-name"] = []byte("cluster1")
+			objects := []runtime.Object{
+				newNamespace("testns"),
+				newSecret(helpers.BootstrapHubKubeConfig, "testns"),
+				hubSecret,
+			}
+
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+			controller := newTestController(t, klusterlet, syncContext.Recorder(), nil, false,
+				objects...)
+
+			err := controller.controller.sync(context.TODO(), syncContext)
+			if err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			deployment := getDeployments(controller.kubeClient.Actions(), createVerb, c.deployment)
+			if deployment == nil {
+				t.Fatalf("%s deployment not found", c.deployment)
+			}
+			if args := deployment.Spec.Template.Spec.Containers[0].Args; !slices.Contains(args, c.arg) {
+				t.Errorf("Expect the arg %s is set, but got args %v", c.arg, args)
+			}
+		})
+	}
+}
+
+func TestSyncDeployHubTunnel(t *testing.T) {
+	cases := []struct {
+		name    string
+		enabled bool
+	}{
+		{
+			name: "hub tunnel disabled",
+		},
+		{
+			name:    "hub tunnel enabled",
+			enabled: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			if c.enabled {
+				klusterlet.Annotations = map[string]string{enableHubTunnelAnnotation: "true"}
+			}
+			hubSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+			controller := newTestController(t, klusterlet, syncContext.Recorder(), nil, false,
+				newNamespace("testns"), newSecret(helpers.BootstrapHubKubeConfig, "testns"), hubSecret)
+
+			if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			serviceAccountCreated, tokenRuleCreated := false, false
+			for _, action := range controller.kubeClient.Actions() {
+				if action.GetVerb() != createVerb {
+					continue
+				}
+				switch object := action.(clienttesting.CreateActionImpl).Object.(type) {
+				case *corev1.ServiceAccount:
+					if object.Name == "klusterlet-hub-tunnel" {
+						serviceAccountCreated = true
+					}
+				case *rbacv1.ClusterRole:
+					if object.Name != "open-cluster-management:klusterlet-registration:agent" {
+						continue
+					}
+					for _, rule := range object.Rules {
+						if slices.Contains(rule.Resources, "serviceaccounts/token") &&
+							slices.Contains(rule.ResourceNames, "klusterlet-hub-tunnel") {
+							tokenRuleCreated = true
+						}
+					}
+				}
+			}
+			if serviceAccountCreated != c.enabled {
+				t.Errorf("Expect the hub tunnel service account created %t, but got %t", c.enabled, serviceAccountCreated)
+			}
+			if tokenRuleCreated != c.enabled {
+				t.Errorf("Expect the hub tunnel token rule created %t, but got %t", c.enabled, tokenRuleCreated)
+			}
+
+			deployment := getDeployments(controller.kubeClient.Actions(), createVerb, "registration-agent")
+			if deployment == nil {
+				t.Fatalf("registration-agent deployment not found")
+			}
+			args := deployment.Spec.Template.Spec.Containers[0].Args
+			if slices.Contains(args, "--hub-tunnel-namespace=testns") != c.enabled {
+				t.Errorf("Expect the hub tunnel namespace arg set %t, but got args %v", c.enabled, args)
+			}
+		})
+	}
+}
+
+func TestSyncDeployManifestWorkSnapshot(t *testing.T) {
+	cases := []struct {
+		name              string
+		enabled           bool
+		existingNamespace bool
+	}{
+		{
+			name: "snapshot disabled",
+		},
+		{
+			name:              "snapshot disabled with the existing snapshot namespace",
+			existingNamespace: true,
+		},
+		{
+			name:    "snapshot enabled",
+			enabled: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			if c.enabled {
+				klusterlet.Annotations = map[string]string{enableManifestWorkSnapshotAnnotation: "true"}
+			}
+			hubSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			objects := []runtime.Object{newNamespace("testns"), newSecret(helpers.BootstrapHubKubeConfig, "testns"), hubSecret}
+			if c.existingNamespace {
+				objects = append(objects, newNamespace("testns-work-snapshot"))
+			}
+			syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+			controller := newTestController(t, klusterlet, syncContext.Recorder(), nil, false, objects...)
+
+			if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			namespaceCreated, roleCreated, namespaceDeleted := false, false, false
+			for _, action := range controller.kubeClient.Actions() {
+				switch {
+				case action.GetVerb() == createVerb:
+					switch object := action.(clienttesting.CreateActionImpl).Object.(type) {
+					case *corev1.Namespace:
+						if object.Name == "testns-work-snapshot" {
+							namespaceCreated = true
+						}
+					case *rbacv1.Role:
+						if object.Namespace == "testns-work-snapshot" {
+							roleCreated = true
+						}
+					}
+				case action.GetVerb() == deleteVerb && action.GetResource().Resource == "namespaces":
+					if action.(clienttesting.DeleteActionImpl).Name == "testns-work-snapshot" {
+						namespaceDeleted = true
+					}
+				}
+			}
+			if namespaceCreated != c.enabled || roleCreated != c.enabled {
+				t.Errorf("Expect the snapshot namespace and role created %t, but got %t and %t",
+					c.enabled, namespaceCreated, roleCreated)
+			}
+			if namespaceDeleted != c.existingNamespace {
+				t.Errorf("Expect the snapshot namespace deleted %t, but got %t", c.existingNamespace, namespaceDeleted)
+			}
+
+			deployment := getDeployments(controller.kubeClient.Actions(), createVerb, "work-agent")
+			if deployment == nil {
+				t.Fatalf("work-agent deployment not found")
+			}
+			args := deployment.Spec.Template.Spec.Containers[0].Args
+			arg := "--manifestwork-snapshot-namespace=testns-work-snapshot"
+			if slices.Contains(args, arg) != c.enabled {
+				t.Errorf("Expect the snapshot namespace arg set %t, but got args %v", c.enabled, args)
+			}
+		})
+	}
+}
+
 func TestClusterNameChange(t *testing.T) {
 	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
 	namespace := newNamespace("testns")
@@ -1622,3 +1877,84 @@ func (f *fakeManagedClusterBuilder) build(_ context.Context) (*managedClusterCli
 		kubeconfigSecretCreationTime: creationTime,
 	}, nil
 }
+
+func TestSyncDeployHardening(t *testing.T) {
+	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+	klusterlet.Annotations = map[string]string{
+		helpers.HardeningAnnotationKey: "PodDisruptionBudget,NetworkPolicy,TopologySpreadConstraints",
+	}
+	klusterlet.Spec.HubApiServerHostAlias = &operatorapiv1.HubApiServerHostAlias{
+		IP:       "10.0.0.1",
+		Hostname: "hub.example.com",
+	}
+	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
+	bootStrapSecret.Data["kubeconfig"] = newKubeConfig("https://hub.example.com:6443")
+	hubSecret := newSecret(helpers.HubKubeConfig, "testns")
+	hubSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+	hubSecret.Data["cluster-name"] = []byte("cluster1")
+
+	apiServerEndpoints := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kubernetes",
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "kubernetes"},
+		},
+		Endpoints: []discoveryv1.Endpoint{{Addresses: []string{"172.18.0.2"}}},
+		Ports:     []discoveryv1.EndpointPort{{Port: ptr.To[int32](6443)}},
+	}
+
+	syncContext := testingcommon.NewFakeSyncContext(t, "klusterlet")
+	controller := newTestController(t, klusterlet, syncContext.Recorder(), nil, false,
+		newNamespace("testns"), bootStrapSecret, hubSecret, apiServerEndpoints)
+	controller.controller.deploymentReplicas = 3
+
+	if err := controller.controller.sync(context.TODO(), syncContext); err != nil {
+		t.Errorf("Expected non error when sync, %v", err)
+	}
+
+	var pdbs []string
+	var networkPolicy *networkingv1.NetworkPolicy
+	for _, action := range controller.kubeClient.Actions() {
+		if action.GetVerb() != createVerb {
+			continue
+		}
+		switch o := action.(clienttesting.CreateActionImpl).Object.(type) {
+		case *appsv1.Deployment:
+			if len(o.Spec.Template.Spec.TopologySpreadConstraints) != 2 {
+				t.Errorf("expected the topology spread constraints of deployment %s, got %v",
+					o.Name, o.Spec.Template.Spec.TopologySpreadConstraints)
+			}
+		case *policyv1.PodDisruptionBudget:
+			pdbs = append(pdbs, o.Name)
+		case *networkingv1.NetworkPolicy:
+			networkPolicy = o
+		}
+	}
+
+	// the work agent is scaled to 0 before the hub connection is checked, so it has no pdb.
+	if len(pdbs) != 1 || pdbs[0] != "klusterlet-registration-agent" {
+		t.Errorf("expected the pdb of the registration agent, got %v", pdbs)
+	}
+	if networkPolicy == nil {
+		t.Fatalf("expected the networkpolicy is created")
+	}
+	if networkPolicy.Spec.PodSelector.MatchLabels[helpers.AgentLabelKey] != "klusterlet" {
+		t.Errorf("expected the networkpolicy selects the agent pods, got %v", networkPolicy.Spec.PodSelector)
+	}
+	egressCIDRs := sets.New[string]()
+	for _, egress := range networkPolicy.Spec.Egress {
+		if len(egress.To) == 0 {
+			continue
+		}
+		if len(egress.To) != 1 || egress.To[0].IPBlock == nil || egress.Ports[0].Port.IntVal != 6443 {
+			t.Errorf("unexpected egress %v", egress)
+			continue
+		}
+		egressCIDRs.Insert(egress.To[0].IPBlock.CIDR)
+	}
+	// the hub apiserver host is resolved to the ip of the host alias
+	if !egressCIDRs.Equal(sets.New("10.0.0.1/32", "172.18.0.2/32")) {
+		t.Errorf("expected the egress to the hub apiserver host alias and the apiserver endpoint, got %v",
+			networkPolicy.Spec.Egress)
+	}
+}
@@ -42,6 +42,13 @@ var managedStaticResourceFiles = []string{
 	"klusterlet/managed/klusterlet-work-clusterrolebinding-execution-admin.yaml",
 }
 
+// hubTunnelStaticResourceFiles are applied only when the hub tunnel is enabled on the klusterlet, and removed otherwise.
+var hubTunnelStaticResourceFiles = []string{
+	"klusterlet/managed/klusterlet-registration-serviceaccount-hub-tunnel.yaml",
+	"klusterlet/managed/klusterlet-registration-clusterrole-hub-tunnel.yaml",
+	"klusterlet/managed/klusterlet-registration-clusterrolebinding-hub-tunnel.yaml",
+}
+
 // managedReconcile apply resources to managed clusters
 type managedReconcile struct {
 	managedClusterClients *managedClusterClients
@@ -89,6 +96,14 @@ func (r *managedReconcile) reconcile(ctx context.Context, klusterlet *operatorap
 		return klusterlet, reconcileStop, err
 	}
 
+	staticResourceFiles := managedStaticResourceFiles
+	if config.EnableHubTunnel {
+		staticResourceFiles = append(append([]string{}, managedStaticResourceFiles...), hubTunnelStaticResourceFiles...)
+	} else if err := removeStaticResources(ctx, r.managedClusterClients.kubeClient,
+		r.managedClusterClients.apiExtensionClient, hubTunnelStaticResourceFiles, config); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+
 	resourceResults := helpers.ApplyDirectly(
 		ctx,
 		r.managedClusterClients.kubeClient,
@@ -104,7 +119,7 @@ func (r *managedReconcile) reconcile(ctx context.Context, klusterlet *operatorap
 			helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
 			return objData, nil
 		},
-		managedStaticResourceFiles...,
+		staticResourceFiles...,
 	)
 
 	var errs []error
@@ -170,7 +185,7 @@ func (r *managedReconcile) clean(ctx context.Context, klusterlet *operatorapiv1.
 	}
 
 	if err := removeStaticResources(ctx, r.managedClusterClients.kubeClient, r.managedClusterClients.apiExtensionClient,
-		managedStaticResourceFiles, config); err != nil {
+		append(append([]string{}, managedStaticResourceFiles...), hubTunnelStaticResourceFiles...), config); err != nil {
 		return klusterlet, reconcileStop, err
 	}
 
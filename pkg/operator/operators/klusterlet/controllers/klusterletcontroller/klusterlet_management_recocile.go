@@ -37,6 +37,13 @@ var (
 		"klusterlet/management/klusterlet-work-rolebinding.yaml",
 		"klusterlet/management/klusterlet-work-rolebinding-extension-apiserver.yaml",
 	}
+
+	// snapshotStaticResourceFiles are applied in the snapshot namespace only when the manifestwork snapshot is
+	// enabled on the klusterlet, and removed with the snapshot namespace otherwise.
+	snapshotStaticResourceFiles = []string{
+		"klusterlet/management/klusterlet-work-role-snapshot.yaml",
+		"klusterlet/management/klusterlet-work-rolebinding-snapshot.yaml",
+	}
 )
 
 type managementReconcile struct {
@@ -63,6 +70,17 @@ func (r *managementReconcile) reconcile(ctx context.Context, klusterlet *operato
 		return klusterlet, reconcileStop, err
 	}
 
+	staticResourceFiles := managementStaticResourceFiles
+	if config.EnableManifestWorkSnapshot {
+		err = ensureNamespace(ctx, r.kubeClient, klusterlet, config.ManifestWorkSnapshotNamespace, labels, r.recorder)
+		if err != nil {
+			return klusterlet, reconcileStop, err
+		}
+		staticResourceFiles = append(append([]string{}, managementStaticResourceFiles...), snapshotStaticResourceFiles...)
+	} else if err := r.removeSnapshotNamespace(ctx, config); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+
 	resourceResults := helpers.ApplyDirectly(
 		ctx,
 		r.kubeClient,
@@ -78,7 +96,7 @@ func (r *managementReconcile) reconcile(ctx context.Context, klusterlet *operato
 			helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
 			return objData, nil
 		},
-		managementStaticResourceFiles...,
+		staticResourceFiles...,
 	)
 
 	var errs []error
@@ -122,6 +140,10 @@ func (r *managementReconcile) clean(ctx context.Context, klusterlet *operatorapi
 		return klusterlet, reconcileStop, err
 	}
 
+	if err := r.removeSnapshotNamespace(ctx, config); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+
 	// The agent namespace on the management cluster should be removed **at the end**. Otherwise if any failure occurred,
 	// the managed-external-kubeconfig secret would be removed and the next reconcile will fail due to can not build the
 	// managed cluster clients.
@@ -135,3 +157,24 @@ func (r *managementReconcile) clean(ctx context.Context, klusterlet *operatorapi
 
 	return klusterlet, reconcileContinue, nil
 }
+
+// removeSnapshotNamespace removes the snapshot namespace with the snapshot and its rbac if it exists.
+func (r *managementReconcile) removeSnapshotNamespace(ctx context.Context, config klusterletConfig) error {
+	_, err := r.kubeClient.CoreV1().Namespaces().Get(ctx, config.ManifestWorkSnapshotNamespace, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if err := removeStaticResources(ctx, r.kubeClient, nil, snapshotStaticResourceFiles, config); err != nil {
+		return err
+	}
+	err = r.kubeClient.CoreV1().Namespaces().Delete(ctx, config.ManifestWorkSnapshotNamespace, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	r.recorder.Eventf("NamespaceDeleted", "namespace %s is deleted", config.ManifestWorkSnapshotNamespace)
+	return nil
+}
@@ -7,15 +7,20 @@ package klusterletcontroller
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
 
 	operatorapiv1 "open-cluster-management.io/api/operator/v1"
 
@@ -27,13 +32,26 @@ import (
 type runtimeReconcile struct {
 	managedClusterClients *managedClusterClients
 	kubeClient            kubernetes.Interface
+	dynamicClient         dynamic.Interface
 	recorder              events.Recorder
 	cache                 resourceapply.ResourceCache
 	enableSyncLabels      bool
+	hardening             helpers.Hardening
+	// hostResolver resolves the hosts of the apiservers the agents connect to.
+	hostResolver helpers.HostResolver
+	// upgradeGate rolls back the agent deployments when an upgrade is not healthy, it is nil
+	// if the health-gated upgrade is disabled.
+	upgradeGate *agentUpgradeGate
 }
 
 func (r *runtimeReconcile) reconcile(ctx context.Context, klusterlet *operatorapiv1.Klusterlet,
 	config klusterletConfig) (*operatorapiv1.Klusterlet, reconcileState, error) {
+	// the serving certificate of the metrics endpoints is mounted by the agent deployments
+	if err := helpers.SyncMetricsServingCert(ctx, r.kubeClient, config.AgentNamespace,
+		r.hardening.ServiceMonitor); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+
 	if helpers.IsSingleton(config.InstallMode) {
 		return r.installSingletonAgent(ctx, klusterlet, config)
 	}
@@ -57,27 +75,14 @@ func (r *runtimeReconcile) installAgent(ctx context.Context, klusterlet *operato
 		}
 	}
 	// Deploy registration agent
-	_, generationStatus, err := helpers.ApplyDeployment(
-		ctx,
-		r.kubeClient,
-		klusterlet.Status.Generations,
-		klusterlet.Spec.NodePlacement,
-		func(name string) ([]byte, error) {
-			template, err := manifests.KlusterletManifestFiles.ReadFile(name)
-			if err != nil {
-				return nil, err
-			}
-			objData := assets.MustCreateAssetFromTemplate(name, template, runtimeConfig).Data
-			helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
-			return objData, nil
-		},
-		r.recorder,
-		"klusterlet/management/klusterlet-registration-deployment.yaml")
-
+	var upgradeStatuses []agentUpgradeStatus
+	generationStatus, upgradeStatus, err := r.applyAgentDeployment(ctx, klusterlet, runtimeConfig,
+		"klusterlet/management/klusterlet-registration-deployment.yaml", true)
 	if err != nil {
 		// TODO update condition
 		return klusterlet, reconcileStop, err
 	}
+	upgradeStatuses = append(upgradeStatuses, upgradeStatus...)
 
 	helpers.SetGenerationStatuses(&klusterlet.Status.Generations, generationStatus)
 
@@ -106,27 +111,13 @@ func (r *runtimeReconcile) installAgent(ctx context.Context, klusterlet *operato
 	}
 
 	// Deploy work agent
-	_, generationStatus, err = helpers.ApplyDeployment(
-		ctx,
-		r.kubeClient,
-		klusterlet.Status.Generations,
-		klusterlet.Spec.NodePlacement,
-		func(name string) ([]byte, error) {
-			template, err := manifests.KlusterletManifestFiles.ReadFile(name)
-			if err != nil {
-				return nil, err
-			}
-			objData := assets.MustCreateAssetFromTemplate(name, template, workConfig).Data
-			helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
-			return objData, nil
-		},
-		r.recorder,
-		"klusterlet/management/klusterlet-work-deployment.yaml")
-
+	generationStatus, upgradeStatus, err = r.applyAgentDeployment(ctx, klusterlet, workConfig,
+		"klusterlet/management/klusterlet-work-deployment.yaml", false)
 	if err != nil {
 		// TODO update condition
 		return klusterlet, reconcileStop, err
 	}
+	upgradeStatuses = append(upgradeStatuses, upgradeStatus...)
 
 	// clean singleton agent if there is any
 	deployments := []string{fmt.Sprintf("%s-agent", runtimeConfig.KlusterletName)}
@@ -135,13 +126,20 @@ func (r *runtimeReconcile) installAgent(ctx context.Context, klusterlet *operato
 		if err != nil && !errors.IsNotFound(err) {
 			return klusterlet, reconcileStop, err
 		}
+		if err := r.cleanHardening(ctx, runtimeConfig.AgentNamespace, deployment); err != nil {
+			return klusterlet, reconcileStop, err
+		}
 	}
 
 	helpers.SetGenerationStatuses(&klusterlet.Status.Generations, generationStatus)
 
+	if err := r.syncNetworkPolicy(ctx, runtimeConfig); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+
 	// TODO check progressing condition
 
-	return klusterlet, reconcileContinue, nil
+	return klusterlet, reconcileContinue, setAgentUpgradeCondition(klusterlet, upgradeStatuses)
 }
 
 func (r *runtimeReconcile) installSingletonAgent(ctx context.Context, klusterlet *operatorapiv1.Klusterlet,
@@ -156,23 +154,8 @@ func (r *runtimeReconcile) installSingletonAgent(ctx context.Context, klusterlet
 		}
 	}
 	// Deploy singleton agent
-	_, generationStatus, err := helpers.ApplyDeployment(
-		ctx,
-		r.kubeClient,
-		klusterlet.Status.Generations,
-		klusterlet.Spec.NodePlacement,
-		func(name string) ([]byte, error) {
-			template, err := manifests.KlusterletManifestFiles.ReadFile(name)
-			if err != nil {
-				return nil, err
-			}
-			objData := assets.MustCreateAssetFromTemplate(name, template, config).Data
-			helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
-			return objData, nil
-		},
-		r.recorder,
-		"klusterlet/management/klusterlet-agent-deployment.yaml")
-
+	generationStatus, upgradeStatus, err := r.applyAgentDeployment(ctx, klusterlet, config,
+		"klusterlet/management/klusterlet-agent-deployment.yaml", true)
 	if err != nil {
 		// TODO update condition
 		return klusterlet, reconcileStop, err
@@ -188,10 +171,158 @@ func (r *runtimeReconcile) installSingletonAgent(ctx context.Context, klusterlet
 		if err != nil && !errors.IsNotFound(err) {
 			return klusterlet, reconcileStop, err
 		}
+		if err := r.cleanHardening(ctx, config.AgentNamespace, deployment); err != nil {
+			return klusterlet, reconcileStop, err
+		}
 	}
 
 	helpers.SetGenerationStatuses(&klusterlet.Status.Generations, generationStatus)
-	return klusterlet, reconcileContinue, nil
+
+	if err := r.syncNetworkPolicy(ctx, config); err != nil {
+		return klusterlet, reconcileStop, err
+	}
+	return klusterlet, reconcileContinue, setAgentUpgradeCondition(klusterlet, upgradeStatus)
+}
+
+// applyAgentDeployment renders and applies the agent deployment. If the health-gated upgrade is
+// enabled, the upgrade status of the deployment is returned as well.
+func (r *runtimeReconcile) applyAgentDeployment(ctx context.Context, klusterlet *operatorapiv1.Klusterlet,
+	config klusterletConfig, file string, checkHubConnection bool) (
+	operatorapiv1.GenerationStatus, []agentUpgradeStatus, error) {
+	manifestFunc := func(name string) ([]byte, error) {
+		template, err := manifests.KlusterletManifestFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		objData := assets.MustCreateAssetFromTemplate(name, template, config).Data
+		helpers.SetRelatedResourcesStatusesWithObj(&klusterlet.Status.RelatedResources, objData)
+		return objData, nil
+	}
+
+	var generationStatus operatorapiv1.GenerationStatus
+	var upgradeStatuses []agentUpgradeStatus
+	var err error
+	if r.upgradeGate == nil {
+		_, generationStatus, err = helpers.ApplyDeployment(ctx, r.kubeClient, klusterlet.Status.Generations,
+			klusterlet.Spec.NodePlacement, manifestFunc, r.recorder, file)
+	} else {
+		var upgradeStatus agentUpgradeStatus
+		generationStatus, upgradeStatus, err = r.upgradeGate.applyDeployment(ctx, klusterlet, manifestFunc, r.recorder,
+			file, checkHubConnection)
+		upgradeStatuses = []agentUpgradeStatus{upgradeStatus}
+	}
+	if err != nil {
+		return generationStatus, upgradeStatuses, err
+	}
+
+	objData, err := manifestFunc(file)
+	if err != nil {
+		return generationStatus, upgradeStatuses, err
+	}
+	return generationStatus, upgradeStatuses, r.syncHardening(ctx, resourceread.ReadDeploymentV1OrDie(objData), true)
+}
+
+// syncHardening reconciles the PodDisruptionBudget and the ServiceMonitor of the agent deployment. They are
+// removed if the deployment is not deployed.
+func (r *runtimeReconcile) syncHardening(ctx context.Context, deployment *appsv1.Deployment, deployed bool) error {
+	if err := helpers.SyncPodDisruptionBudget(ctx, r.kubeClient, r.recorder, deployment,
+		deployed && r.hardening.PodDisruptionBudget); err != nil {
+		return err
+	}
+	return helpers.SyncServiceMonitor(ctx, r.kubeClient, r.dynamicClient, r.recorder, deployment,
+		deployed && r.hardening.ServiceMonitor)
+}
+
+// cleanHardening removes the hardening resources of a removed agent deployment.
+func (r *runtimeReconcile) cleanHardening(ctx context.Context, namespace, name string) error {
+	return r.syncHardening(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{}},
+	}, false)
+}
+
+// syncNetworkPolicy reconciles the NetworkPolicy of the agent pods, which only allows the ingress traffic to the
+// secure port serving the metrics and health checks. The egress traffic is only allowed to the DNS, the endpoints of
+// the kubernetes apiserver, the apiserver of the managed cluster in the hosted mode and the hub apiservers in the
+// bootstrap kubeconfigs. The egress traffic is not restricted if any of them is unknown, e.g. the bootstrap
+// kubeconfig secret is not created yet.
+func (r *runtimeReconcile) syncNetworkPolicy(ctx context.Context, config klusterletConfig) error {
+	var egressPeers []helpers.NetworkPolicyPeer
+	if r.hardening.NetworkPolicy {
+		var err error
+		if egressPeers, err = r.agentEgressPeers(ctx, config); err != nil {
+			klog.Warningf("The egress traffic of the klusterlet %s is not restricted: %v", config.KlusterletName, err)
+			egressPeers = nil
+		}
+	}
+
+	networkPolicy := helpers.NewNetworkPolicy(config.KlusterletName, config.AgentNamespace, config.Labels,
+		map[string]string{helpers.AgentLabelKey: config.KlusterletName}, []int32{helpers.MetricsPort}, egressPeers)
+	return helpers.SyncNetworkPolicy(ctx, r.kubeClient.NetworkingV1(), r.recorder, networkPolicy, r.hardening.NetworkPolicy)
+}
+
+func (r *runtimeReconcile) agentEgressPeers(ctx context.Context, config klusterletConfig) ([]helpers.NetworkPolicyPeer, error) {
+	// the agents connect to the kubernetes apiserver of the cluster they are running on with the service ip, which
+	// is translated to the endpoints before the egress traffic is matched.
+	peers, err := helpers.KubernetesAPIServerPeers(ctx, r.kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	if helpers.IsHosted(config.InstallMode) && r.managedClusterClients != nil && r.managedClusterClients.kubeconfig != nil {
+		managedPeers, err := helpers.NetworkPolicyPeersFromURL(ctx, r.hostResolver, r.managedClusterClients.kubeconfig.Host)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, managedPeers...)
+	}
+
+	bootstrapKubeConfigSecrets := append([]string{}, config.BootStrapKubeConfigSecrets...)
+	if len(config.BootStrapKubeConfigSecret) > 0 {
+		bootstrapKubeConfigSecrets = append(bootstrapKubeConfigSecrets, config.BootStrapKubeConfigSecret)
+	}
+	if len(bootstrapKubeConfigSecrets) == 0 {
+		return nil, fmt.Errorf("no bootstrap kubeconfig secret")
+	}
+	for _, name := range bootstrapKubeConfigSecrets {
+		secret, err := r.kubeClient.CoreV1().Secrets(config.AgentNamespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		hubConfig, err := helpers.LoadClientConfigFromSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+		hubPeers, err := helpers.NetworkPolicyPeersFromURL(ctx, r.hubHostResolver(config), hubConfig.Host)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, hubPeers...)
+	}
+	return peers, nil
+}
+
+// hubHostResolver returns the resolver of the hub apiserver hosts, the host of the host alias is resolved to the ip
+// of the host alias as it is in the agent pods.
+func (r *runtimeReconcile) hubHostResolver(config klusterletConfig) helpers.HostResolver {
+	if config.HubApiServerHostAlias == nil {
+		return r.hostResolver
+	}
+	return &hostAliasResolver{
+		hostAlias: config.HubApiServerHostAlias,
+		resolver:  r.hostResolver,
+	}
+}
+
+type hostAliasResolver struct {
+	hostAlias *operatorapiv1.HubApiServerHostAlias
+	resolver  helpers.HostResolver
+}
+
+func (h *hostAliasResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if host == h.hostAlias.Hostname {
+		return []net.IPAddr{{IP: net.ParseIP(h.hostAlias.IP)}}, nil
+	}
+	return h.resolver.LookupIPAddr(ctx, host)
 }
 
 func (r *runtimeReconcile) createManagedClusterKubeconfig(
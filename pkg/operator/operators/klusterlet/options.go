@@ -9,6 +9,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	versionutil "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -30,6 +31,7 @@ type Options struct {
 	DeploymentReplicas            int32
 	DisableAddonNamespace         bool
 	EnableSyncLabels              bool
+	AgentUpgradeTimeout           time.Duration
 }
 
 // RunKlusterletOperator starts a new klusterlet operator
@@ -43,6 +45,10 @@ func (o *Options) RunKlusterletOperator(ctx context.Context, controllerContext *
 	if err != nil {
 		return err
 	}
+	dynamicClient, err := dynamic.NewForConfig(controllerContext.KubeConfig)
+	if err != nil {
+		return err
+	}
 
 	version, err := kubeClient.ServerVersion()
 	if err != nil {
@@ -100,6 +106,7 @@ func (o *Options) RunKlusterletOperator(ctx context.Context, controllerContext *
 
 	klusterletController := klusterletcontroller.NewKlusterletController(
 		kubeClient,
+		dynamicClient,
 		apiExtensionClient,
 		operatorClient.OperatorV1().Klusterlets(),
 		operatorInformer.Operator().V1().Klusterlets(),
@@ -112,6 +119,7 @@ func (o *Options) RunKlusterletOperator(ctx context.Context, controllerContext *
 		o.DeploymentReplicas,
 		o.DisableAddonNamespace,
 		o.EnableSyncLabels,
+		o.AgentUpgradeTimeout,
 		controllerContext.EventRecorder)
 
 	klusterletCleanupController := klusterletcontroller.NewKlusterletCleanupController(
@@ -16,6 +16,7 @@ const (
 	BindDurationKey       = "bind_duration_seconds"
 	PluginDurationKey     = "plugin_duration_seconds"
 	CelRuntimeDurationKey = "cel_runtime_duration_seconds"
+	PendingChangesKey     = "pending_decision_changes"
 )
 
 // Metric histograms for tracking various durations.
@@ -52,8 +53,15 @@ var (
 		Buckets:        k8smetrics.ExponentialBuckets(10e-7, 10, 10),
 	}, []string{"name"})
 
+	pendingChanges = k8smetrics.NewGaugeVec(&k8smetrics.GaugeOpts{
+		Subsystem:      SchedulingSubsystem,
+		Name:           PendingChangesKey,
+		StabilityLevel: k8smetrics.ALPHA,
+		Help:           "Number of cluster decision changes of a placement held by its churn budget.",
+	}, []string{"placement"})
+
 	metrics = []k8smetrics.Registerable{
-		schedulingDuration, bindDuration, PluginDuration, CelDuration,
+		schedulingDuration, bindDuration, PluginDuration, CelDuration, pendingChanges,
 	}
 )
 
@@ -129,3 +137,16 @@ func (m *ScheduleMetrics) Done(key string) {
 		delete(m.bindStartTimes, key)
 	}
 }
+
+// SetPendingChanges records the number of the decision changes held by the churn budget of the placement.
+func (m *ScheduleMetrics) SetPendingChanges(key string, pending int) {
+	if m == nil {
+		return
+	}
+
+	if pending == 0 {
+		pendingChanges.DeleteLabelValues(key)
+		return
+	}
+	pendingChanges.WithLabelValues(key).Set(float64(pending))
+}
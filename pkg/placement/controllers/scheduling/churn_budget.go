@@ -0,0 +1,372 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+const (
+	// ChurnBudgetAnnotationKey is the annotation on the placement to bound how many cluster decisions change at
+	// once. The value is the json of ChurnBudget, for example:
+	//
+	//	{"maxChanges": 5, "interval": "10m", "minDwellTime": "1h"}
+	ChurnBudgetAnnotationKey = "cluster.open-cluster-management.io/churn-budget"
+
+	// PlacementConditionDecisionChangesPending is the condition type of the placement with the churn budget. It is
+	// true when some decision changes are held by the churn budget.
+	PlacementConditionDecisionChangesPending = "DecisionChangesPending"
+
+	// DecisionTimesAnnotationKey is the annotation on the placement decisions of the placement with the churn
+	// budget. The value is the json of the times the clusters in the placement decision were added into the
+	// decisions, keyed by the cluster names.
+	DecisionTimesAnnotationKey = "cluster.open-cluster-management.io/decision-times"
+
+	// DecisionChangeTimesAnnotationKey is the annotation on the first placement decision of the placement with the
+	// churn budget. The value is the json of the times of the decision changes in the latest interval.
+	DecisionChangeTimesAnnotationKey = "cluster.open-cluster-management.io/decision-change-times"
+
+	defaultChurnInterval = 10 * time.Minute
+)
+
+// ChurnBudget bounds the changes of the cluster decisions of a placement.
+type ChurnBudget struct {
+	// MaxChanges is the maximum number of the clusters added into or removed from the decisions in an interval.
+	// There is no limitation if it is 0.
+	MaxChanges int32 `json:"maxChanges,omitempty"`
+	// Interval is the interval of MaxChanges, default is 10m.
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// MinDwellTime is the minimum time a cluster stays in the decisions before it can be removed.
+	MinDwellTime metav1.Duration `json:"minDwellTime,omitempty"`
+}
+
+// getChurnBudget returns the churn budget in the annotations, nil is returned if it is not set.
+func getChurnBudget(annotations map[string]string) (*ChurnBudget, error) {
+	value, ok := annotations[ChurnBudgetAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	budget := &ChurnBudget{}
+	if err := json.Unmarshal([]byte(value), budget); err != nil {
+		return nil, fmt.Errorf("failed to decode churn budget: %w", err)
+	}
+	if budget.MaxChanges < 0 || budget.Interval.Duration < 0 || budget.MinDwellTime.Duration < 0 {
+		return nil, fmt.Errorf("the values of the churn budget must not be negative")
+	}
+	if budget.Interval.Duration == 0 {
+		budget.Interval.Duration = defaultChurnInterval
+	}
+	return budget, nil
+}
+
+// churnInput is the input of applying the churn budget on the scheduled decisions.
+type churnInput struct {
+	// current are the names of the clusters in the existing decisions.
+	current sets.Set[string]
+	// desired are the names of the scheduled clusters in order.
+	desired []string
+	// feasible are the names of the clusters passing the filters of the placement. The existing decisions which
+	// are not feasible are removed without the churn budget, only the clusters scored out are held.
+	feasible sets.Set[string]
+	// maxClusters is the number of the clusters of the placement, there is no limitation if it is 0.
+	maxClusters int
+	// history is the decision history restored from the placement decisions, it is used when the tracker has no
+	// history of the placement, for example after the controller restarts.
+	history *placementChurn
+}
+
+// churnResult is the result of applying the churn budget on the scheduled decisions.
+type churnResult struct {
+	// clusters are the names of the clusters in the decisions after applying the churn budget.
+	clusters []string
+	// pendingAdditions and pendingRemovals are the numbers of the decision changes held by the churn budget.
+	pendingAdditions int
+	pendingRemovals  int
+	// recheckAfter is the duration until the held changes can be applied.
+	recheckAfter time.Duration
+	// history is the decision history after applying the churn budget, which is persisted in the placement
+	// decisions.
+	history *placementChurn
+}
+
+func (r churnResult) pending() int {
+	return r.pendingAdditions + r.pendingRemovals
+}
+
+// placementChurn is the decision history of a placement.
+type placementChurn struct {
+	// decidedTimes are the times the clusters were added into the decisions.
+	decidedTimes map[string]time.Time
+	// changeTimes are the times of the decision changes in the latest interval.
+	changeTimes []time.Time
+}
+
+func (c *placementChurn) deepCopy() *placementChurn {
+	copied := &placementChurn{
+		decidedTimes: make(map[string]time.Time, len(c.decidedTimes)),
+		changeTimes:  append([]time.Time{}, c.changeTimes...),
+	}
+	for cluster, decidedTime := range c.decidedTimes {
+		copied.decidedTimes[cluster] = decidedTime
+	}
+	return copied
+}
+
+// churnTracker tracks the decision changes of the placements to enforce the churn budgets. The history is kept
+// in memory and persisted in the annotations of the placement decisions, so it is restored after the controller
+// restarts.
+type churnTracker struct {
+	lock       sync.Mutex
+	placements map[string]*placementChurn
+	// now is replaceable in unit tests.
+	now func() time.Time
+}
+
+func newChurnTracker() *churnTracker {
+	return &churnTracker{
+		placements: map[string]*placementChurn{},
+		now:        time.Now,
+	}
+}
+
+// forget drops the decision history of the placement.
+func (t *churnTracker) forget(key string) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.placements, key)
+}
+
+// apply limits the changes from the current decided clusters to the desired clusters with the churn budget of the
+// placement. The clusters are added in the order of the desired clusters. An addition is paired with a removal
+// when the decisions reach the number of the clusters of the placement, so the decisions never exceed it.
+func (t *churnTracker) apply(key string, budget *ChurnBudget, input churnInput) churnResult {
+	if t == nil || budget == nil {
+		return churnResult{clusters: input.desired}
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	churn, ok := t.placements[key]
+	if !ok {
+		churn = &placementChurn{decidedTimes: map[string]time.Time{}}
+		if input.history != nil {
+			churn = input.history.deepCopy()
+		}
+		t.placements[key] = churn
+	}
+
+	// the existing decisions which are no longer feasible are removed immediately
+	current := input.current.Intersection(input.feasible)
+	for cluster := range current {
+		if _, ok := churn.decidedTimes[cluster]; !ok {
+			churn.decidedTimes[cluster] = now
+		}
+	}
+
+	// drop the changes out of the interval
+	var changeTimes []time.Time
+	for _, changeTime := range churn.changeTimes {
+		if now.Sub(changeTime) < budget.Interval.Duration {
+			changeTimes = append(changeTimes, changeTime)
+		}
+	}
+	churn.changeTimes = changeTimes
+
+	result := churnResult{}
+	recheck := func(after time.Duration) {
+		if after > 0 && (result.recheckAfter == 0 || after < result.recheckAfter) {
+			result.recheckAfter = after
+		}
+	}
+
+	// the clusters to add in the desired order, the clusters to remove after their dwell time and the clusters
+	// held by the dwell time
+	var additions, removals, dwelling []string
+	desiredSet := sets.New[string](input.desired...)
+	for _, cluster := range input.desired {
+		if !current.Has(cluster) {
+			additions = append(additions, cluster)
+		}
+	}
+	for _, cluster := range sets.List(current.Difference(desiredSet)) {
+		if dwell := churn.decidedTimes[cluster].Add(budget.MinDwellTime.Duration).Sub(now); dwell > 0 {
+			dwelling = append(dwelling, cluster)
+			recheck(dwell)
+			continue
+		}
+		removals = append(removals, cluster)
+	}
+
+	remaining := len(additions) + len(removals)
+	if budget.MaxChanges > 0 && int(budget.MaxChanges)-len(churn.changeTimes) < remaining {
+		remaining = max(int(budget.MaxChanges)-len(churn.changeTimes), 0)
+	}
+
+	decided := current.Clone()
+	full := func() bool {
+		return input.maxClusters > 0 && decided.Len() >= input.maxClusters
+	}
+	var added, removed int
+changes:
+	for ; remaining > 0; remaining-- {
+		switch {
+		case added < len(additions) && !full():
+			decided.Insert(additions[added])
+			churn.decidedTimes[additions[added]] = now
+			added++
+		case removed < len(removals):
+			decided.Delete(removals[removed])
+			removed++
+		default:
+			// the additions are blocked until the held clusters are removed
+			break changes
+		}
+		churn.changeTimes = append(churn.changeTimes, now)
+	}
+
+	// the held clusters are removed when the decisions exceed the number of the clusters of the placement
+	for _, cluster := range append(dwelling, removals[removed:]...) {
+		if input.maxClusters == 0 || decided.Len() <= input.maxClusters {
+			break
+		}
+		decided.Delete(cluster)
+	}
+	for cluster := range churn.decidedTimes {
+		if !decided.Has(cluster) {
+			delete(churn.decidedTimes, cluster)
+		}
+	}
+
+	// the changes held by the budget of the interval or the dwell time
+	for _, cluster := range additions {
+		if !decided.Has(cluster) {
+			result.pendingAdditions++
+		}
+	}
+	for _, cluster := range removals {
+		if decided.Has(cluster) {
+			result.pendingRemovals++
+		}
+	}
+	if result.pending() > 0 && len(churn.changeTimes) > 0 {
+		recheck(churn.changeTimes[0].Add(budget.Interval.Duration).Sub(now))
+	}
+	for _, cluster := range dwelling {
+		if decided.Has(cluster) {
+			result.pendingRemovals++
+		}
+	}
+
+	// keep the desired order of the clusters, followed by the clusters held in the decisions
+	for _, cluster := range input.desired {
+		if decided.Has(cluster) {
+			result.clusters = append(result.clusters, cluster)
+		}
+	}
+	result.clusters = append(result.clusters, sets.List(decided.Difference(desiredSet))...)
+	result.history = churn.deepCopy()
+	return result
+}
+
+// getChurnHistory restores the decision history of the placement from the annotations of its placement decisions.
+// The invalid annotations are ignored, and the clusters without the decided times are regarded as just added.
+func getChurnHistory(logger klog.Logger, pds []*clusterapiv1beta1.PlacementDecision) *placementChurn {
+	history := &placementChurn{decidedTimes: map[string]time.Time{}}
+	for _, pd := range pds {
+		decidedTimes := map[string]metav1.Time{}
+		if value, ok := pd.Annotations[DecisionTimesAnnotationKey]; ok {
+			if err := json.Unmarshal([]byte(value), &decidedTimes); err != nil {
+				logger.Info("Ignore the invalid decision times", "placementDecision", klog.KObj(pd), "error", err)
+			}
+		}
+		for cluster, decidedTime := range decidedTimes {
+			history.decidedTimes[cluster] = decidedTime.Time
+		}
+
+		var changeTimes []metav1.Time
+		if value, ok := pd.Annotations[DecisionChangeTimesAnnotationKey]; ok {
+			if err := json.Unmarshal([]byte(value), &changeTimes); err != nil {
+				logger.Info("Ignore the invalid decision change times", "placementDecision", klog.KObj(pd), "error", err)
+			}
+		}
+		for _, changeTime := range changeTimes {
+			history.changeTimes = append(history.changeTimes, changeTime.Time)
+		}
+	}
+	sort.Slice(history.changeTimes, func(i, j int) bool {
+		return history.changeTimes[i].Before(history.changeTimes[j])
+	})
+	return history
+}
+
+// setChurnHistory persists the decision history in the annotations of the placement decisions. The decided times
+// are set on the placement decision of each cluster, and the change times are set on the first placement decision.
+func setChurnHistory(pds []*clusterapiv1beta1.PlacementDecision, history *placementChurn) error {
+	for index, pd := range pds {
+		decidedTimes := map[string]metav1.Time{}
+		for _, decision := range pd.Status.Decisions {
+			if decidedTime, ok := history.decidedTimes[decision.ClusterName]; ok {
+				decidedTimes[decision.ClusterName] = metav1.NewTime(decidedTime)
+			}
+		}
+		value, err := json.Marshal(decidedTimes)
+		if err != nil {
+			return err
+		}
+		if pd.Annotations == nil {
+			pd.Annotations = map[string]string{}
+		}
+		pd.Annotations[DecisionTimesAnnotationKey] = string(value)
+
+		if index > 0 {
+			continue
+		}
+		changeTimes := []metav1.Time{}
+		for _, changeTime := range history.changeTimes {
+			changeTimes = append(changeTimes, metav1.NewTime(changeTime))
+		}
+		value, err = json.Marshal(changeTimes)
+		if err != nil {
+			return err
+		}
+		pd.Annotations[DecisionChangeTimesAnnotationKey] = string(value)
+	}
+	return nil
+}
+
+// newDecisionChangesPendingCondition returns the condition of the decision changes held by the churn budget.
+func newDecisionChangesPendingCondition(result churnResult, budgetErr error) metav1.Condition {
+	condition := metav1.Condition{
+		Type: PlacementConditionDecisionChangesPending,
+	}
+	switch {
+	case budgetErr != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidChurnBudget"
+		condition.Message = budgetErr.Error()
+	case result.pending() > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ChurnBudgetExceeded"
+		condition.Message = fmt.Sprintf("%d cluster additions and %d cluster removals are held by the churn budget",
+			result.pendingAdditions, result.pendingRemovals)
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoPendingChanges"
+		condition.Message = "All decision changes are applied"
+	}
+	return condition
+}
@@ -0,0 +1,256 @@
+package scheduling
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+func TestGetChurnBudget(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedBudget *ChurnBudget
+		expectedErr    bool
+	}{
+		{
+			name: "no churn budget",
+		},
+		{
+			name:        "invalid churn budget",
+			annotations: map[string]string{ChurnBudgetAnnotationKey: "invalid"},
+			expectedErr: true,
+		},
+		{
+			name:        "negative churn budget",
+			annotations: map[string]string{ChurnBudgetAnnotationKey: `{"maxChanges": -1}`},
+			expectedErr: true,
+		},
+		{
+			name:        "default interval",
+			annotations: map[string]string{ChurnBudgetAnnotationKey: `{"maxChanges": 2, "minDwellTime": "1h"}`},
+			expectedBudget: &ChurnBudget{
+				MaxChanges:   2,
+				Interval:     metav1.Duration{Duration: defaultChurnInterval},
+				MinDwellTime: metav1.Duration{Duration: time.Hour},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			budget, err := getChurnBudget(c.annotations)
+			if (err != nil) != c.expectedErr {
+				t.Fatalf("expected error %v, but got %v", c.expectedErr, err)
+			}
+			if !reflect.DeepEqual(budget, c.expectedBudget) {
+				t.Errorf("expected budget %v, but got %v", c.expectedBudget, budget)
+			}
+		})
+	}
+}
+
+func TestChurnTrackerApply(t *testing.T) {
+	start := time.Now()
+	now := start
+	tracker := newChurnTracker()
+	tracker.now = func() time.Time { return now }
+	budget := &ChurnBudget{
+		MaxChanges:   2,
+		Interval:     metav1.Duration{Duration: 10 * time.Minute},
+		MinDwellTime: metav1.Duration{Duration: time.Hour},
+	}
+
+	steps := []struct {
+		name             string
+		elapsed          time.Duration
+		current          []string
+		desired          []string
+		infeasible       []string
+		expectedClusters []string
+		expectedAdded    int
+		expectedRemoved  int
+		expectedRecheck  time.Duration
+	}{
+		{
+			name:             "additions exceed the budget",
+			current:          []string{"cluster1"},
+			desired:          []string{"cluster1", "cluster2", "cluster3", "cluster4"},
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3"},
+			expectedAdded:    1,
+			expectedRecheck:  10 * time.Minute,
+		},
+		{
+			name:             "budget used up in the interval",
+			elapsed:          5 * time.Minute,
+			current:          []string{"cluster1", "cluster2", "cluster3"},
+			desired:          []string{"cluster1", "cluster2", "cluster3", "cluster4"},
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3"},
+			expectedAdded:    1,
+			expectedRecheck:  5 * time.Minute,
+		},
+		{
+			name:             "removal held by the dwell time",
+			elapsed:          10 * time.Minute,
+			current:          []string{"cluster1", "cluster2", "cluster3"},
+			desired:          []string{"cluster1", "cluster3", "cluster4"},
+			expectedClusters: []string{"cluster1", "cluster3", "cluster4", "cluster2"},
+			expectedRemoved:  1,
+			expectedRecheck:  50 * time.Minute,
+		},
+		{
+			name:             "removal after the dwell time",
+			elapsed:          time.Hour,
+			current:          []string{"cluster1", "cluster2", "cluster3", "cluster4"},
+			desired:          []string{"cluster1", "cluster3", "cluster4"},
+			expectedClusters: []string{"cluster1", "cluster3", "cluster4"},
+		},
+		{
+			name:             "infeasible cluster removed in the dwell time",
+			elapsed:          time.Hour + time.Minute,
+			current:          []string{"cluster1", "cluster3", "cluster4"},
+			desired:          []string{"cluster1", "cluster3"},
+			infeasible:       []string{"cluster4"},
+			expectedClusters: []string{"cluster1", "cluster3"},
+		},
+	}
+
+	for _, step := range steps {
+		now = start.Add(step.elapsed)
+		feasible := sets.New[string]("cluster1", "cluster2", "cluster3", "cluster4").Delete(step.infeasible...)
+		result := tracker.apply("ns1/placement1", budget, churnInput{
+			current:  sets.New[string](step.current...),
+			desired:  step.desired,
+			feasible: feasible,
+		})
+		if !reflect.DeepEqual(result.clusters, step.expectedClusters) {
+			t.Errorf("%s: expected clusters %v, but got %v", step.name, step.expectedClusters, result.clusters)
+		}
+		if result.pendingAdditions != step.expectedAdded || result.pendingRemovals != step.expectedRemoved {
+			t.Errorf("%s: expected %d/%d pending changes, but got %d/%d", step.name,
+				step.expectedAdded, step.expectedRemoved, result.pendingAdditions, result.pendingRemovals)
+		}
+		if result.recheckAfter != step.expectedRecheck {
+			t.Errorf("%s: expected recheck after %v, but got %v", step.name, step.expectedRecheck, result.recheckAfter)
+		}
+	}
+}
+
+func TestChurnTrackerApplyNumberOfClusters(t *testing.T) {
+	now := time.Now()
+	budget := &ChurnBudget{
+		MaxChanges:   2,
+		Interval:     metav1.Duration{Duration: 10 * time.Minute},
+		MinDwellTime: metav1.Duration{Duration: time.Hour},
+	}
+
+	cases := []struct {
+		name             string
+		current          []string
+		desired          []string
+		history          *placementChurn
+		expectedClusters []string
+		expectedAdded    int
+		expectedRemoved  int
+	}{
+		{
+			name:    "addition paired with removal",
+			current: []string{"cluster1", "cluster2"},
+			desired: []string{"cluster3", "cluster4"},
+			history: &placementChurn{decidedTimes: map[string]time.Time{
+				"cluster1": now.Add(-2 * time.Hour),
+				"cluster2": now,
+			}},
+			expectedClusters: []string{"cluster3", "cluster2"},
+			expectedAdded:    1,
+			expectedRemoved:  1,
+		},
+		{
+			name:             "addition blocked by the dwell time",
+			current:          []string{"cluster1", "cluster2"},
+			desired:          []string{"cluster3", "cluster4"},
+			expectedClusters: []string{"cluster1", "cluster2"},
+			expectedAdded:    2,
+			expectedRemoved:  2,
+		},
+		{
+			name:             "held clusters exceed the number of clusters",
+			current:          []string{"cluster1", "cluster2", "cluster3"},
+			desired:          []string{"cluster1", "cluster2"},
+			expectedClusters: []string{"cluster1", "cluster2"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tracker := newChurnTracker()
+			tracker.now = func() time.Time { return now }
+			result := tracker.apply("ns1/placement1", budget, churnInput{
+				current:     sets.New[string](c.current...),
+				desired:     c.desired,
+				feasible:    sets.New[string]("cluster1", "cluster2", "cluster3", "cluster4"),
+				maxClusters: 2,
+				history:     c.history,
+			})
+			if !reflect.DeepEqual(result.clusters, c.expectedClusters) {
+				t.Errorf("expected clusters %v, but got %v", c.expectedClusters, result.clusters)
+			}
+			if result.pendingAdditions != c.expectedAdded || result.pendingRemovals != c.expectedRemoved {
+				t.Errorf("expected %d/%d pending changes, but got %d/%d",
+					c.expectedAdded, c.expectedRemoved, result.pendingAdditions, result.pendingRemovals)
+			}
+		})
+	}
+}
+
+func TestChurnHistory(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	history := &placementChurn{
+		decidedTimes: map[string]time.Time{
+			"cluster1": now.Add(-time.Hour),
+			"cluster2": now,
+		},
+		changeTimes: []time.Time{now.Add(-time.Minute), now},
+	}
+	pds := []*clusterapiv1beta1.PlacementDecision{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "placement1-decision-1"},
+			Status: clusterapiv1beta1.PlacementDecisionStatus{
+				Decisions: []clusterapiv1beta1.ClusterDecision{{ClusterName: "cluster1"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "placement1-decision-2"},
+			Status: clusterapiv1beta1.PlacementDecisionStatus{
+				Decisions: []clusterapiv1beta1.ClusterDecision{{ClusterName: "cluster2"}},
+			},
+		},
+	}
+	if err := setChurnHistory(pds, history); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pds[1].Annotations[DecisionChangeTimesAnnotationKey]; ok {
+		t.Errorf("expected the change times are only set on the first placement decision")
+	}
+
+	restored := getChurnHistory(klog.Background(), pds)
+	if !reflect.DeepEqual(restored.decidedTimes, history.decidedTimes) {
+		t.Errorf("expected decided times %v, but got %v", history.decidedTimes, restored.decidedTimes)
+	}
+	if !reflect.DeepEqual(restored.changeTimes, history.changeTimes) {
+		t.Errorf("expected change times %v, but got %v", history.changeTimes, restored.changeTimes)
+	}
+
+	// the invalid annotations are ignored
+	pds[0].Annotations[DecisionTimesAnnotationKey] = "invalid"
+	restored = getChurnHistory(klog.Background(), pds)
+	if _, ok := restored.decidedTimes["cluster1"]; ok {
+		t.Errorf("expected the invalid decided times are ignored")
+	}
+}
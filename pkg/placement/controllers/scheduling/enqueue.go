@@ -18,7 +18,8 @@ import (
 	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	clusterapiv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
-	clustersdkv1beta2 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta2"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 const (
@@ -131,6 +132,26 @@ func (e *enqueuer) enqueueClusterSet(obj interface{}) {
 		return
 	}
 
+	// the clusters of a child clusterset are selected by the placements bound to its ancestors
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if clusterSet, ok := obj.(*clusterapiv1beta2.ManagedClusterSet); ok {
+		ancestors, err := commonhelpers.GetClusterSetAncestors(clusterSet, e.clusterSetLister)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		for _, ancestor := range ancestors {
+			ancestorObjs, err := e.clusterSetBindingIndexer.ByIndex(clustersetBindingsByClusterSet, ancestor.Name)
+			if err != nil {
+				runtime.HandleError(err)
+				return
+			}
+			objs = append(objs, ancestorObjs...)
+		}
+	}
+
 	for _, o := range objs {
 		clusterSetBinding := o.(*clusterapiv1beta2.ManagedClusterSetBinding)
 		e.logger.V(4).Info("Enqueue clustersetbinding because of clusterset", "clusterSetBinding", klog.KObj(clusterSetBinding), "clustersetKey", key)
@@ -145,7 +166,7 @@ func (e *enqueuer) enqueueCluster(obj interface{}) {
 		return
 	}
 
-	clusterSets, err := clustersdkv1beta2.GetClusterSetsOfCluster(cluster, e.clusterSetLister)
+	clusterSets, err := commonhelpers.GetClusterSetsOfClusterWithAncestors(cluster, e.clusterSetLister)
 	if err != nil {
 		e.logger.V(4).Error(err, "Unable to get clusterSets of cluster", "clusterName", cluster.GetName())
 		return
@@ -185,7 +206,7 @@ func (e *enqueuer) enqueuePlacementScore(obj interface{}) {
 		e.logger.V(4).Error(err, "Unable to get cluster", "clusterNamespace", namespace)
 	}
 
-	clusterSets, err := clustersdkv1beta2.GetClusterSetsOfCluster(cluster, e.clusterSetLister)
+	clusterSets, err := commonhelpers.GetClusterSetsOfClusterWithAncestors(cluster, e.clusterSetLister)
 	if err != nil {
 		e.logger.V(4).Error(err, "Unable to get clusterSets of cluster", "clusterName", cluster.GetName())
 		return
@@ -37,9 +37,9 @@ import (
 	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	clusterapiv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
-	clustersdkv1beta2 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta2"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/common/queue"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/metrics"
@@ -71,6 +71,7 @@ type schedulingController struct {
 	scheduler               Scheduler
 	eventsRecorder          kevents.EventRecorder
 	metricsRecorder         *metrics.ScheduleMetrics
+	churnTracker            *churnTracker
 }
 
 // NewSchedulingController return an instance of schedulingController
@@ -102,6 +103,7 @@ func NewSchedulingController(
 		scheduler:               scheduler,
 		eventsRecorder:          krecorder,
 		metricsRecorder:         metricsRecorder,
+		churnTracker:            newChurnTracker(),
 	}
 
 	// setup event handler for cluster informer.
@@ -126,6 +128,8 @@ func NewSchedulingController(
 	_, err = clusterSetInformer.Informer().AddEventHandler(&cache.ResourceEventHandlerFuncs{
 		AddFunc: enQueuer.enqueueClusterSet,
 		UpdateFunc: func(oldObj, newObj interface{}) {
+			// the parent of the clusterset may be changed
+			enQueuer.enqueueClusterSet(oldObj)
 			enQueuer.enqueueClusterSet(newObj)
 		},
 		DeleteFunc: enQueuer.enqueueClusterSet,
@@ -188,6 +192,8 @@ func (c *schedulingController) sync(ctx context.Context, syncCtx factory.SyncCon
 	placement, err := c.getPlacement(queueKey)
 	if errors.IsNotFound(err) {
 		// no work if placement is deleted
+		c.churnTracker.forget(queueKey)
+		c.metricsRecorder.SetPendingChanges(queueKey, 0)
 		return nil
 	}
 	if err != nil {
@@ -243,11 +249,23 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 	// schedule placement with scheduler
 	c.metricsRecorder.StartSchedule(queueKey)
 	scheduleResult, status := c.scheduler.Schedule(ctx, placement, clusters)
+	// hold the decision changes exceeding the churn budget of the placement
+	decidedClusters, churnHistory, churnCondition, err := c.applyChurnBudget(
+		ctx, syncCtx, queueKey, placement, scheduleResult)
+	if err != nil {
+		return err
+	}
 	// generate placement decision and status
-	decisions, groupStatus, s := c.generatePlacementDecisionsAndStatus(placement, scheduleResult.Decisions())
+	decisions, groupStatus, s := c.generatePlacementDecisionsAndStatus(placement, decidedClusters)
 	if s.IsError() {
 		status = s
 	}
+	// persist the decision history of the churn budget in the placement decisions
+	if churnHistory != nil {
+		if err := setChurnHistory(decisions, churnHistory); err != nil {
+			return err
+		}
+	}
 	misconfiguredCondition := newMisconfiguredCondition(status)
 	satisfiedCondition := newSatisfiedCondition(
 		placement.Spec.ClusterSets,
@@ -276,8 +294,12 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 	}
 
 	// update placement status if necessary to signal no bindings
+	conditions := []metav1.Condition{misconfiguredCondition, satisfiedCondition}
+	if churnCondition != nil {
+		conditions = append(conditions, *churnCondition)
+	}
 	if err := c.updateStatus(
-		ctx, placement, groupStatus, int32(len(scheduleResult.Decisions())), misconfiguredCondition, satisfiedCondition); err != nil { // nolint:gosec
+		ctx, placement, groupStatus, int32(len(decidedClusters)), conditions...); err != nil { // nolint:gosec
 		return err
 	}
 
@@ -286,29 +308,7 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 
 // getManagedClusterSetBindings returns all bindings found in the placement namespace.
 func (c *schedulingController) getValidManagedClusterSetBindings(placementNamespace string) ([]*clusterapiv1beta2.ManagedClusterSetBinding, error) {
-	// get all clusterset bindings under the placement namespace
-	bindings, err := c.clusterSetBindingLister.ManagedClusterSetBindings(placementNamespace).List(labels.Everything())
-	if err != nil {
-		return nil, err
-	}
-	if len(bindings) == 0 {
-		bindings = nil
-	}
-
-	var validBindings []*clusterapiv1beta2.ManagedClusterSetBinding
-	for _, binding := range bindings {
-		// ignore clustersetbinding refers to a non-existent clusterset
-		_, err := c.clusterSetLister.Get(binding.Name)
-		if errors.IsNotFound(err) {
-			continue
-		}
-		if err != nil {
-			return nil, err
-		}
-		validBindings = append(validBindings, binding)
-	}
-
-	return validBindings, nil
+	return commonhelpers.GetValidManagedClusterSetBindings(placementNamespace, c.clusterSetBindingLister, c.clusterSetLister)
 }
 
 // getEligibleClusterSets returns the names of clusterset that eligible for the placement
@@ -348,7 +348,8 @@ func (c *schedulingController) getAvailableClusters(clusterSetNames []string) ([
 		if err != nil {
 			return nil, err
 		}
-		clusters, err := clustersdkv1beta2.GetClustersFromClusterSet(clusterSet, c.clusterLister)
+		// the clusters of the child clustersets are available to the bindings of the ancestors
+		clusters, err := commonhelpers.GetClustersFromClusterSetTree(clusterSet, c.clusterSetLister, c.clusterLister)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get clusterset: %v, clusters, Error: %v", clusterSet.Name, err)
 		}
@@ -371,6 +372,105 @@ func (c *schedulingController) getAvailableClusters(clusterSetNames []string) ([
 	return result, nil
 }
 
+// applyChurnBudget holds the decision changes of the placement exceeding its churn budget. It returns the clusters
+// to decide, the decision history to persist and the DecisionChangesPending condition. The history and the
+// condition are nil if the placement has no churn budget.
+func (c *schedulingController) applyChurnBudget(
+	ctx context.Context,
+	syncCtx factory.SyncContext,
+	queueKey string,
+	placement *clusterapiv1beta1.Placement,
+	scheduleResult ScheduleResult,
+) ([]*clusterapiv1.ManagedCluster, *placementChurn, *metav1.Condition, error) {
+	clusters := scheduleResult.Decisions()
+	if c.churnTracker == nil {
+		return clusters, nil, nil, nil
+	}
+
+	budget, err := getChurnBudget(placement.Annotations)
+	if err != nil || budget == nil {
+		c.churnTracker.forget(queueKey)
+		c.metricsRecorder.SetPendingChanges(queueKey, 0)
+		if err != nil {
+			// the invalid churn budget is ignored
+			condition := newDecisionChangesPendingCondition(churnResult{}, err)
+			return clusters, nil, &condition, nil
+		}
+		return clusters, nil, nil, nil
+	}
+
+	current, pds, err := c.getDecidedClusters(placement)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	desired := make([]string, 0, len(clusters))
+	clustersByName := map[string]*clusterapiv1.ManagedCluster{}
+	for _, cluster := range clusters {
+		desired = append(desired, cluster.Name)
+		clustersByName[cluster.Name] = cluster
+	}
+	var maxClusters int
+	if placement.Spec.NumberOfClusters != nil {
+		maxClusters = int(*placement.Spec.NumberOfClusters)
+	}
+
+	result := c.churnTracker.apply(queueKey, budget, churnInput{
+		current:     sets.KeySet(current),
+		desired:     desired,
+		feasible:    sets.KeySet(scheduleResult.PrioritizerScores()),
+		maxClusters: maxClusters,
+		history:     getChurnHistory(klog.FromContext(ctx), pds),
+	})
+	decided := make([]*clusterapiv1.ManagedCluster, 0, len(result.clusters))
+	for _, name := range result.clusters {
+		cluster, ok := clustersByName[name]
+		if !ok {
+			// the cluster is no longer selected but held in the decisions
+			cluster = current[name]
+		}
+		decided = append(decided, cluster)
+	}
+
+	c.metricsRecorder.SetPendingChanges(queueKey, result.pending())
+	if syncCtx != nil && result.recheckAfter > 0 {
+		syncCtx.Queue().AddAfter(queueKey, result.recheckAfter)
+	}
+
+	condition := newDecisionChangesPendingCondition(result, nil)
+	return decided, result.history, &condition, nil
+}
+
+// getDecidedClusters returns the existing clusters in the placement decisions of the placement, and the placement
+// decisions.
+func (c *schedulingController) getDecidedClusters(placement *clusterapiv1beta1.Placement) (
+	map[string]*clusterapiv1.ManagedCluster, []*clusterapiv1beta1.PlacementDecision, error) {
+	requirement, err := labels.NewRequirement(clusterapiv1beta1.PlacementLabel, selection.Equals, []string{placement.Name})
+	if err != nil {
+		return nil, nil, err
+	}
+	pds, err := c.placementDecisionLister.PlacementDecisions(placement.Namespace).List(labels.NewSelector().Add(*requirement))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusters := map[string]*clusterapiv1.ManagedCluster{}
+	for _, pd := range pds {
+		for _, decision := range pd.Status.Decisions {
+			cluster, err := c.clusterLister.Get(decision.ClusterName)
+			switch {
+			case errors.IsNotFound(err):
+				// the deleted cluster is removed from the decisions without the churn budget
+				continue
+			case err != nil:
+				return nil, nil, err
+			}
+			clusters[cluster.Name] = cluster
+		}
+	}
+	return clusters, pds, nil
+}
+
 // updateStatus updates the status of the placement according to intermediate scheduling data.
 func (c *schedulingController) updateStatus(
 	ctx context.Context,
@@ -387,8 +487,16 @@ func (c *schedulingController) updateStatus(
 		newPlacement.Status.DecisionGroups = append(newPlacement.Status.DecisionGroups, *status)
 	}
 
+	// the DecisionChangesPending condition is only kept on the placement with the churn budget
+	hasChurnCondition := false
 	for _, c := range conditions {
 		meta.SetStatusCondition(&newPlacement.Status.Conditions, c)
+		if c.Type == PlacementConditionDecisionChangesPending {
+			hasChurnCondition = true
+		}
+	}
+	if !hasChurnCondition {
+		meta.RemoveStatusCondition(&newPlacement.Status.Conditions, PlacementConditionDecisionChangesPending)
 	}
 	if reflect.DeepEqual(newPlacement.Status, placement.Status) {
 		return nil
@@ -703,6 +811,18 @@ func (c *schedulingController) createOrUpdatePlacementDecision(
 
 	newPlacementDecision := existPlacementDecision.DeepCopy()
 	newPlacementDecision.Labels = placementDecision.Labels
+	// the decision history of the churn budget is kept in the annotations
+	for _, key := range []string{DecisionTimesAnnotationKey, DecisionChangeTimesAnnotationKey} {
+		value, ok := placementDecision.Annotations[key]
+		if !ok {
+			delete(newPlacementDecision.Annotations, key)
+			continue
+		}
+		if newPlacementDecision.Annotations == nil {
+			newPlacementDecision.Annotations = map[string]string{}
+		}
+		newPlacementDecision.Annotations[key] = value
+	}
 	newPlacementDecision.Status.Decisions = clusterDecisions
 	updated, err := placementDecisionPatcher.PatchStatus(ctx, newPlacementDecision, newPlacementDecision.Status, existPlacementDecision.Status)
 	// If status has been updated, just return, this is to avoid conflict when updating the label later.
@@ -8,7 +8,9 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -533,6 +535,97 @@ func TestSchedulingController_sync(t *testing.T) {
 			},
 			validateActions: testingcommon.AssertNoActions,
 		},
+		{
+			name: "placement with churn budget",
+			placement: testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName,
+				map[string]string{
+					ChurnBudgetAnnotationKey: `{"maxChanges": 1}`,
+				}).Build(),
+			scheduleResult: &scheduleResult{
+				feasibleClusters: []*clusterapiv1.ManagedCluster{
+					testinghelpers.NewManagedCluster("cluster1").Build(),
+					testinghelpers.NewManagedCluster("cluster2").Build(),
+				},
+				scheduledDecisions: []*clusterapiv1.ManagedCluster{
+					testinghelpers.NewManagedCluster("cluster1").Build(),
+					testinghelpers.NewManagedCluster("cluster2").Build(),
+				},
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create", "patch")
+				// check if the decision history is persisted in the PlacementDecision
+				placementDecision := actions[0].(clienttesting.CreateActionImpl).Object.(*clusterapiv1beta1.PlacementDecision)
+				decidedTimes := map[string]metav1.Time{}
+				if err := json.Unmarshal(
+					[]byte(placementDecision.Annotations[DecisionTimesAnnotationKey]), &decidedTimes); err != nil {
+					t.Fatal(err)
+				}
+				if len(decidedTimes) != 1 {
+					t.Errorf("expect the decided time of %d cluster, but got %v", 1, decidedTimes)
+				}
+				placement := &clusterapiv1beta1.Placement{}
+				patchData := actions[1].(clienttesting.PatchActionImpl).Patch
+				err := json.Unmarshal(patchData, placement)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if placement.Status.NumberOfSelectedClusters != int32(1) {
+					t.Errorf("expect %d cluster selected, but got %d", 1, placement.Status.NumberOfSelectedClusters)
+				}
+				if !util.HasCondition(
+					placement.Status.Conditions,
+					PlacementConditionDecisionChangesPending,
+					"ChurnBudgetExceeded",
+					metav1.ConditionTrue,
+				) {
+					t.Errorf("expect condition %s, but got %v", PlacementConditionDecisionChangesPending, placement.Status.Conditions)
+				}
+			},
+		},
+		{
+			name: "placement with churn budget keeps the pending condition",
+			placement: func() *clusterapiv1beta1.Placement {
+				placement := testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName,
+					map[string]string{
+						ChurnBudgetAnnotationKey: `{"maxChanges": 1}`,
+					}).Build()
+				placement.Status.Conditions = []metav1.Condition{{
+					Type:               PlacementConditionDecisionChangesPending,
+					Status:             metav1.ConditionTrue,
+					Reason:             "ChurnBudgetExceeded",
+					Message:            "1 cluster additions and 0 cluster removals are held by the churn budget",
+					LastTransitionTime: metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+				}}
+				return placement
+			}(),
+			scheduleResult: &scheduleResult{
+				feasibleClusters: []*clusterapiv1.ManagedCluster{
+					testinghelpers.NewManagedCluster("cluster1").Build(),
+					testinghelpers.NewManagedCluster("cluster2").Build(),
+				},
+				scheduledDecisions: []*clusterapiv1.ManagedCluster{
+					testinghelpers.NewManagedCluster("cluster1").Build(),
+					testinghelpers.NewManagedCluster("cluster2").Build(),
+				},
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create", "patch")
+				placement := &clusterapiv1beta1.Placement{}
+				patchData := actions[1].(clienttesting.PatchActionImpl).Patch
+				err := json.Unmarshal(patchData, placement)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				condition := meta.FindStatusCondition(placement.Status.Conditions, PlacementConditionDecisionChangesPending)
+				if condition == nil ||
+					!condition.LastTransitionTime.Equal(&metav1.Time{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}) {
+					t.Errorf("expect the last transition time of condition %s is kept, but got %v",
+						PlacementConditionDecisionChangesPending, condition)
+				}
+			},
+		},
 		{
 			name: "placement schedule controller is disabled",
 			placement: testinghelpers.NewPlacementWithAnnotations(placementNamespace, placementName,
@@ -568,6 +661,7 @@ func TestSchedulingController_sync(t *testing.T) {
 				scheduler:               s,
 				eventsRecorder:          kevents.NewFakeRecorder(100),
 				metricsRecorder:         metrics.NewScheduleMetrics(clock.RealClock{}),
+				churnTracker:            newChurnTracker(),
 			}
 
 			sysCtx := testingcommon.NewFakeSyncContext(t, c.placement.Namespace+"/"+c.placement.Name)
@@ -0,0 +1,534 @@
+package clusterprofile
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
+	"k8s.io/klog/v2"
+	cpv1alpha1 "sigs.k8s.io/cluster-inventory-api/apis/v1alpha1"
+	cpclientset "sigs.k8s.io/cluster-inventory-api/client/clientset/versioned"
+	cpinformerv1alpha1 "sigs.k8s.io/cluster-inventory-api/client/informers/externalversions/apis/v1alpha1"
+	cplisterv1alpha1 "sigs.k8s.io/cluster-inventory-api/client/listers/apis/v1alpha1"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/common/queue"
+)
+
+const (
+	// ClusterProfileAccessAnnotationKey is the annotation on the ClusterProfile to request the access to the
+	// cluster. The value is the name of the ClusterRole granted to the service account provisioned on the managed
+	// cluster, e.g. view. Only the ClusterRoles allowed by the hub admin with the
+	// --cluster-profile-access-cluster-roles flag are granted.
+	ClusterProfileAccessAnnotationKey = "open-cluster-management.io/cluster-profile-access"
+	// ClusterProfileAccessRotationAnnotationKey is the annotation on the ClusterProfile to set the interval to
+	// rotate the token, the default is 24h and the minimum is 5m. The token expires after twice the interval.
+	ClusterProfileAccessRotationAnnotationKey = "open-cluster-management.io/cluster-profile-access-rotation"
+
+	// ClusterConditionAccessProvided is the condition of the ClusterProfile which is true when the credential
+	// of the cluster is published in the access secret.
+	ClusterConditionAccessProvided = "AccessProvided"
+
+	// The keys of the access secret in the ClusterProfile namespace.
+	AccessSecretServerKey     = "server"
+	AccessSecretCAKey         = "ca.crt"
+	AccessSecretTokenKey      = "token"
+	AccessSecretKubeconfigKey = "kubeconfig"
+	// AccessSecretExpirationAnnotationKey is the annotation on the access secret for the time the token expires.
+	AccessSecretExpirationAnnotationKey = "open-cluster-management.io/expiration"
+
+	accessWorkName           = helpers.ClusterProfileAccessWorkName
+	accessWorkLabelKey       = "open-cluster-management.io/cluster-profile-access"
+	accessClusterRoleBinding = "open-cluster-management:cluster-profile-access"
+	accessIssuedAtAnnoKey    = "open-cluster-management.io/access-issued-at"
+	// accessKeySecretName is the secret in the ClusterProfile namespace storing the private key of the hub, the
+	// tokens are encrypted with the public key by the registration agents.
+	accessKeySecretName   = "cluster-profile-access-key"
+	defaultAccessRotation = 24 * time.Hour
+	minAccessRotation     = 5 * time.Minute
+)
+
+// clusterProfileAccessController provisions a service account on the managed cluster with a ManifestWork when
+// the access is requested on the ClusterProfile, and publishes the token of the service account with the
+// endpoint of the cluster in the access secret <cluster>-access in the ClusterProfile namespace. The token is
+// requested by the registration agent with the TokenRequest API, and returned by the status feedback encrypted
+// with the public key of the hub. It is rotated by changing the generation of the token configmap, and the
+// previous token is valid until it expires.
+type clusterProfileAccessController struct {
+	kubeClient           kubernetes.Interface
+	clusterLister        listerv1.ManagedClusterLister
+	clusterProfileLister cplisterv1alpha1.ClusterProfileLister
+	secretLister         corev1listers.SecretLister
+	workLister           worklisterv1.ManifestWorkLister
+	workApplier          *workapplier.WorkApplier
+	patcher              patcher.Patcher[*cpv1alpha1.ClusterProfile, cpv1alpha1.ClusterProfileSpec, cpv1alpha1.ClusterProfileStatus]
+	allowedClusterRoles  sets.Set[string]
+	eventRecorder        events.Recorder
+	// now is replaceable in unit tests.
+	now func() time.Time
+}
+
+// NewClusterProfileAccessController creates a new cluster profile access controller. The secret informer should
+// only watch the secrets of the cluster manager in the ClusterProfile namespace.
+func NewClusterProfileAccessController(
+	kubeClient kubernetes.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	clusterProfileClient cpclientset.Interface,
+	clusterProfileInformer cpinformerv1alpha1.ClusterProfileInformer,
+	secretInformer corev1informers.SecretInformer,
+	workClient workclientset.Interface,
+	workInformer workinformerv1.ManifestWorkInformer,
+	allowedClusterRoles []string,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterProfileAccessController{
+		kubeClient:           kubeClient,
+		clusterLister:        clusterInformer.Lister(),
+		clusterProfileLister: clusterProfileInformer.Lister(),
+		secretLister:         secretInformer.Lister(),
+		workLister:           workInformer.Lister(),
+		workApplier:          workapplier.NewWorkApplierWithTypedClient(workClient, workInformer.Lister()),
+		patcher: patcher.NewPatcher[
+			*cpv1alpha1.ClusterProfile, cpv1alpha1.ClusterProfileSpec, cpv1alpha1.ClusterProfileStatus](
+			clusterProfileClient.ApisV1alpha1().ClusterProfiles(ClusterProfileNamespace)),
+		allowedClusterRoles: sets.New[string](allowedClusterRoles...),
+		eventRecorder:       recorder.WithComponentSuffix("cluster-profile-access-controller"),
+		now:                 time.Now,
+	}
+
+	return factory.New().
+		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, clusterInformer.Informer(), clusterProfileInformer.Informer()).
+		WithFilteredEventsInformersQueueKeysFunc(
+			queue.QueueKeyByMetaNamespace,
+			queue.FileterByLabel(accessWorkLabelKey),
+			workInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterProfileAccessController", recorder)
+}
+
+func (c *clusterProfileAccessController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Reconciling ClusterProfile access", "ClusterName", clusterName)
+
+	clusterProfile, err := c.clusterProfileLister.ClusterProfiles(ClusterProfileNamespace).Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		// the access secret is deleted with the clusterprofile by the owner reference.
+		return c.workApplier.Delete(ctx, clusterName, accessWorkName)
+	case err != nil:
+		return err
+	}
+	if clusterProfile.Spec.ClusterManager.Name != ClusterProfileManagerName {
+		return nil
+	}
+
+	clusterRole, requested := clusterProfile.Annotations[ClusterProfileAccessAnnotationKey]
+	if !requested || len(clusterRole) == 0 {
+		return c.revokeAccess(ctx, clusterProfile)
+	}
+	// the annotation can be set by anyone able to update the ClusterProfile, so only the cluster roles allowed by
+	// the hub admin are granted.
+	if !c.allowedClusterRoles.Has(clusterRole) {
+		if err := c.removeAccess(ctx, clusterName); err != nil {
+			return err
+		}
+		return c.updateCondition(ctx, clusterProfile, metav1.ConditionFalse, "ClusterRoleNotAllowed",
+			fmt.Sprintf("the cluster role %q is not allowed by the hub", clusterRole))
+	}
+	rotation := defaultAccessRotation
+	if value, ok := clusterProfile.Annotations[ClusterProfileAccessRotationAnnotationKey]; ok {
+		rotation, err = time.ParseDuration(value)
+		if err != nil || rotation < minAccessRotation {
+			return c.updateCondition(ctx, clusterProfile, metav1.ConditionFalse, "InvalidRotation",
+				fmt.Sprintf("invalid token rotation %q, it should not be less than %v", value, minAccessRotation))
+		}
+	}
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if len(cluster.Spec.ManagedClusterClientConfigs) == 0 {
+		return c.updateCondition(ctx, clusterProfile, metav1.ConditionFalse, "EndpointNotFound",
+			"the managed cluster has no client config")
+	}
+
+	// rotate the token if it is issued more than the rotation interval ago.
+	now := c.now()
+	generation, issuedAt := 1, now
+	work, err := c.workLister.ManifestWorks(clusterName).Get(accessWorkName)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	default:
+		if g, err := strconv.Atoi(work.Annotations[helpers.ClusterProfileAccessGenerationAnnotationKey]); err == nil {
+			generation = g
+		}
+		if t, err := time.Parse(time.RFC3339, work.Annotations[accessIssuedAtAnnoKey]); err == nil {
+			issuedAt = t
+		}
+		if !now.Before(issuedAt.Add(rotation)) {
+			generation, issuedAt = generation+1, now
+		}
+	}
+
+	key, err := c.ensureAccessKey(ctx)
+	if err != nil {
+		return err
+	}
+	requiredWork, err := newAccessWork(clusterName, clusterRole, &key.PublicKey, generation, rotation, issuedAt)
+	if err != nil {
+		return err
+	}
+	work, err = c.workApplier.Apply(ctx, requiredWork)
+	if err != nil {
+		return err
+	}
+
+	token, expiration, err := accessToken(work, generation, key)
+	if err != nil {
+		return err
+	}
+	if len(token) == 0 {
+		return c.updateCondition(ctx, clusterProfile, metav1.ConditionFalse, "Provisioning",
+			"waiting for the token of the service account on the managed cluster")
+	}
+
+	secretName := clusterName + "-access"
+	secret, err := c.secretLister.Secrets(ClusterProfileNamespace).Get(secretName)
+	switch {
+	case errors.IsNotFound(err):
+		secret = nil
+	case err != nil:
+		return err
+	}
+	requiredSecret, err := newAccessSecret(clusterProfile, cluster, secretName, token, generation, expiration)
+	if err != nil {
+		return err
+	}
+	if err := c.applyAccessSecret(ctx, requiredSecret, secret); err != nil {
+		return err
+	}
+
+	syncCtx.Queue().AddAfter(clusterName, issuedAt.Add(rotation).Sub(now))
+	return c.updateCondition(ctx, clusterProfile, metav1.ConditionTrue, "AccessProvided",
+		fmt.Sprintf("the credential is in secret %s/%s", ClusterProfileNamespace, secretName))
+}
+
+func (c *clusterProfileAccessController) revokeAccess(ctx context.Context, clusterProfile *cpv1alpha1.ClusterProfile) error {
+	if err := c.removeAccess(ctx, clusterProfile.Name); err != nil {
+		return err
+	}
+
+	if meta.FindStatusCondition(clusterProfile.Status.Conditions, ClusterConditionAccessProvided) == nil {
+		return nil
+	}
+	newClusterProfile := clusterProfile.DeepCopy()
+	meta.RemoveStatusCondition(&newClusterProfile.Status.Conditions, ClusterConditionAccessProvided)
+	_, err := c.patcher.PatchStatus(ctx, newClusterProfile, newClusterProfile.Status, clusterProfile.Status)
+	return err
+}
+
+// removeAccess removes the service account on the managed cluster and the access secret.
+func (c *clusterProfileAccessController) removeAccess(ctx context.Context, clusterName string) error {
+	if err := c.workApplier.Delete(ctx, clusterName, accessWorkName); err != nil {
+		return err
+	}
+	err := c.kubeClient.CoreV1().Secrets(ClusterProfileNamespace).Delete(ctx, clusterName+"-access", metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ensureAccessKey returns the private key of the hub, a new key is generated if it does not exist.
+func (c *clusterProfileAccessController) ensureAccessKey(ctx context.Context) (*rsa.PrivateKey, error) {
+	secret, err := c.secretLister.Secrets(ClusterProfileNamespace).Get(accessKeySecretName)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return nil, err
+	default:
+		key, err := encryption.ParsePrivateKey(secret.Data[encryption.PrivateKeyDataKey])
+		if err != nil {
+			return nil, fmt.Errorf("invalid key in secret %s/%s: %v", ClusterProfileNamespace, accessKeySecretName, err)
+		}
+		return key, nil
+	}
+
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	keyData, err := encryption.EncodePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.kubeClient.CoreV1().Secrets(ClusterProfileNamespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      accessKeySecretName,
+			Namespace: ClusterProfileNamespace,
+			Labels:    map[string]string{cpv1alpha1.LabelClusterManagerKey: ClusterProfileManagerName},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{encryption.PrivateKeyDataKey: keyData},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (c *clusterProfileAccessController) applyAccessSecret(ctx context.Context, required, existing *corev1.Secret) error {
+	if existing == nil {
+		_, err := c.kubeClient.CoreV1().Secrets(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
+		if err == nil {
+			c.eventRecorder.Eventf("ClusterProfileAccessProvided", "the credential of cluster %s is published", required.Name)
+		}
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(required.Data, existing.Data) &&
+		equality.Semantic.DeepEqual(required.Annotations, existing.Annotations) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Labels = required.Labels
+	updated.Annotations = required.Annotations
+	updated.OwnerReferences = required.OwnerReferences
+	updated.Data = required.Data
+	_, err := c.kubeClient.CoreV1().Secrets(required.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *clusterProfileAccessController) updateCondition(ctx context.Context, clusterProfile *cpv1alpha1.ClusterProfile,
+	status metav1.ConditionStatus, reason, message string) error {
+	newClusterProfile := clusterProfile.DeepCopy()
+	meta.SetStatusCondition(&newClusterProfile.Status.Conditions, metav1.Condition{
+		Type:    ClusterConditionAccessProvided,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	_, err := c.patcher.PatchStatus(ctx, newClusterProfile, newClusterProfile.Status, clusterProfile.Status)
+	return err
+}
+
+// accessToken returns the token of the generation and the time it expires from the status feedback of the access
+// work, the token is decrypted with the private key of the hub. An empty token is returned if the token of the
+// generation is not issued yet.
+func accessToken(work *workapiv1.ManifestWork, generation int, key *rsa.PrivateKey) (string, time.Time, error) {
+	values := map[string]string{}
+	for _, manifest := range work.Status.ResourceStatus.Manifests {
+		if manifest.ResourceMeta.Kind != "ConfigMap" ||
+			manifest.ResourceMeta.Name != helpers.ClusterProfileAccessTokenConfigMapName {
+			continue
+		}
+		for _, value := range manifest.StatusFeedbacks.Values {
+			if value.Value.String != nil {
+				values[value.Name] = *value.Value.String
+			}
+		}
+	}
+	if values[helpers.ClusterProfileAccessGenerationKey] != strconv.Itoa(generation) ||
+		len(values[helpers.ClusterProfileAccessTokenKey]) == 0 {
+		return "", time.Time{}, nil
+	}
+
+	expiration, err := time.Parse(time.RFC3339, values[helpers.ClusterProfileAccessExpirationKey])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid expiration of the token: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(values[helpers.ClusterProfileAccessTokenKey])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode the token: %v", err)
+	}
+	encrypted := &encryption.EncryptedData{}
+	if err := json.Unmarshal(data, encrypted); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode the token: %v", err)
+	}
+	if encrypted.KeyID != encryption.KeyID(&key.PublicKey) {
+		// the key of the hub is changed, wait for the token encrypted with the new key.
+		return "", time.Time{}, nil
+	}
+	token, err := encryption.DecryptData(key, encrypted)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decrypt the token: %v", err)
+	}
+	return string(token), expiration, nil
+}
+
+func newAccessWork(clusterName, clusterRole string, publicKey *rsa.PublicKey, generation int,
+	rotation time.Duration, issuedAt time.Time) (*workapiv1.ManifestWork, error) {
+	encodedPublicKey, err := encryption.EncodePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	objects := []runtime.Object{
+		&corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: helpers.ClusterProfileAccessNamespace},
+		},
+		&corev1.ServiceAccount{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      helpers.ClusterProfileAccessServiceAccount,
+				Namespace: helpers.ClusterProfileAccessNamespace,
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: accessClusterRoleBinding},
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: clusterRole},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      helpers.ClusterProfileAccessServiceAccount,
+					Namespace: helpers.ClusterProfileAccessNamespace,
+				},
+			},
+		},
+		// the data of the configmap is written by the registration agent, so it is applied with the server side
+		// apply to keep the data.
+		&corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      helpers.ClusterProfileAccessTokenConfigMapName,
+				Namespace: helpers.ClusterProfileAccessNamespace,
+				Annotations: map[string]string{
+					helpers.ClusterProfileAccessGenerationAnnotationKey: strconv.Itoa(generation),
+					// the previous token is valid until the new token is published.
+					helpers.ClusterProfileAccessExpirationAnnotationKey: strconv.FormatInt(int64(2*rotation/time.Second), 10),
+					helpers.ClusterProfileAccessPublicKeyAnnotationKey:  encodedPublicKey,
+				},
+			},
+		},
+	}
+
+	var manifests []workapiv1.Manifest
+	for _, obj := range objects {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}})
+	}
+
+	return &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      accessWorkName,
+			Namespace: clusterName,
+			Labels:    map[string]string{accessWorkLabelKey: "true"},
+			Annotations: map[string]string{
+				helpers.ClusterProfileAccessGenerationAnnotationKey: strconv.Itoa(generation),
+				accessIssuedAtAnnoKey:                               issuedAt.UTC().Format(time.RFC3339),
+			},
+		},
+		Spec: workapiv1.ManifestWorkSpec{
+			Workload: workapiv1.ManifestsTemplate{Manifests: manifests},
+			ManifestConfigs: []workapiv1.ManifestConfigOption{
+				{
+					ResourceIdentifier: workapiv1.ResourceIdentifier{
+						Resource:  "configmaps",
+						Name:      helpers.ClusterProfileAccessTokenConfigMapName,
+						Namespace: helpers.ClusterProfileAccessNamespace,
+					},
+					UpdateStrategy: &workapiv1.UpdateStrategy{
+						Type:            workapiv1.UpdateStrategyTypeServerSideApply,
+						ServerSideApply: &workapiv1.ServerSideApplyConfig{Force: true},
+					},
+					FeedbackRules: []workapiv1.FeedbackRule{
+						{
+							Type: workapiv1.JSONPathsType,
+							JsonPaths: []workapiv1.JsonPath{
+								{Name: helpers.ClusterProfileAccessTokenKey, Path: ".data.token"},
+								{Name: helpers.ClusterProfileAccessGenerationKey, Path: ".data.generation"},
+								{Name: helpers.ClusterProfileAccessExpirationKey, Path: ".data.expiration"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func newAccessSecret(clusterProfile *cpv1alpha1.ClusterProfile, cluster *v1.ManagedCluster, name, token string,
+	generation int, expiration time.Time) (*corev1.Secret, error) {
+	clientConfig := cluster.Spec.ManagedClusterClientConfigs[0]
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[cluster.Name] = &clientcmdapi.Cluster{
+		Server:                   clientConfig.URL,
+		CertificateAuthorityData: clientConfig.CABundle,
+	}
+	kubeconfig.AuthInfos[helpers.ClusterProfileAccessServiceAccount] = &clientcmdapi.AuthInfo{Token: token}
+	kubeconfig.Contexts[cluster.Name] = &clientcmdapi.Context{
+		Cluster: cluster.Name, AuthInfo: helpers.ClusterProfileAccessServiceAccount}
+	kubeconfig.CurrentContext = cluster.Name
+	kubeconfigData, err := runtime.Encode(clientcmdlatest.Codec, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ClusterProfileNamespace,
+			Labels: map[string]string{
+				v1.ClusterNameLabelKey:            cluster.Name,
+				cpv1alpha1.LabelClusterManagerKey: ClusterProfileManagerName,
+			},
+			Annotations: map[string]string{
+				helpers.ClusterProfileAccessGenerationAnnotationKey: strconv.Itoa(generation),
+				AccessSecretExpirationAnnotationKey:                 expiration.UTC().Format(time.RFC3339),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(clusterProfile, cpv1alpha1.SchemeGroupVersion.WithKind("ClusterProfile")),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			AccessSecretServerKey:     []byte(clientConfig.URL),
+			AccessSecretCAKey:         clientConfig.CABundle,
+			AccessSecretTokenKey:      []byte(token),
+			AccessSecretKubeconfigKey: kubeconfigData,
+		},
+	}, nil
+}
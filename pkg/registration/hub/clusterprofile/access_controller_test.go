@@ -0,0 +1,402 @@
+package clusterprofile
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+	cpv1alpha1 "sigs.k8s.io/cluster-inventory-api/apis/v1alpha1"
+	cpfake "sigs.k8s.io/cluster-inventory-api/client/clientset/versioned/fake"
+	cpinformers "sigs.k8s.io/cluster-inventory-api/client/informers/externalversions"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	workfake "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncClusterProfileAccess(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clusterName := testinghelpers.TestManagedClusterName
+
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyData, err := encryption.EncodePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: accessKeySecretName, Namespace: ClusterProfileNamespace},
+		Data:       map[string][]byte{encryption.PrivateKeyDataKey: keyData},
+	}
+	otherKey, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	managedCluster := &v1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName},
+		Spec: v1.ManagedClusterSpec{
+			ManagedClusterClientConfigs: []v1.ClientConfig{{URL: "https://cluster1:6443", CABundle: []byte("ca")}},
+		},
+	}
+	newClusterProfile := func(annotations map[string]string) *cpv1alpha1.ClusterProfile {
+		return &cpv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        clusterName,
+				Namespace:   ClusterProfileNamespace,
+				Annotations: annotations,
+			},
+			Spec: cpv1alpha1.ClusterProfileSpec{
+				ClusterManager: cpv1alpha1.ClusterManager{Name: ClusterProfileManagerName},
+			},
+		}
+	}
+	newEncryptedToken := func(key *rsa.PublicKey, token string) string {
+		encrypted, err := encryption.EncryptData(key, []byte(token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(data)
+	}
+	newWork := func(generation int, issuedAt time.Time, encryptedToken string) *workapiv1.ManifestWork {
+		work, err := newAccessWork(clusterName, "view", &key.PublicKey, generation, defaultAccessRotation, issuedAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(encryptedToken) > 0 {
+			values := map[string]string{
+				helpers.ClusterProfileAccessTokenKey:      encryptedToken,
+				helpers.ClusterProfileAccessGenerationKey: strconv.Itoa(generation),
+				helpers.ClusterProfileAccessExpirationKey: "2024-01-02T00:00:00Z",
+			}
+			var feedbackValues []workapiv1.FeedbackValue
+			for name, value := range values {
+				feedbackValues = append(feedbackValues, workapiv1.FeedbackValue{
+					Name:  name,
+					Value: workapiv1.FieldValue{Type: workapiv1.String, String: ptr.To(value)},
+				})
+			}
+			work.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+				{
+					ResourceMeta: workapiv1.ManifestResourceMeta{
+						Kind:      "ConfigMap",
+						Name:      helpers.ClusterProfileAccessTokenConfigMapName,
+						Namespace: helpers.ClusterProfileAccessNamespace,
+					},
+					StatusFeedbacks: workapiv1.StatusFeedbackResult{Values: feedbackValues},
+				},
+			}
+		}
+		return work
+	}
+	accessRequested := map[string]string{ClusterProfileAccessAnnotationKey: "view"}
+	encryptedToken := newEncryptedToken(&key.PublicKey, "token1")
+	publishedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName + "-access",
+			Namespace: ClusterProfileNamespace,
+			Labels:    map[string]string{v1.ClusterNameLabelKey: clusterName},
+			Annotations: map[string]string{
+				helpers.ClusterProfileAccessGenerationAnnotationKey: "1",
+			},
+		},
+	}
+
+	cases := []struct {
+		name                   string
+		clusterProfiles        []runtime.Object
+		works                  []runtime.Object
+		secrets                []runtime.Object
+		validateWorkActions    func(t *testing.T, actions []clienttesting.Action)
+		validateKubeActions    func(t *testing.T, actions []clienttesting.Action)
+		validateProfileActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "provision the service account",
+			clusterProfiles: []runtime.Object{newClusterProfile(accessRequested)},
+			secrets:         []runtime.Object{keySecret},
+			validateWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create")
+				work := actions[0].(clienttesting.CreateAction).GetObject().(*workapiv1.ManifestWork)
+				if len(work.Spec.Workload.Manifests) != 4 || len(work.Spec.ManifestConfigs) != 1 {
+					t.Errorf("unexpected work %v", work.Spec)
+				}
+				configMap := &corev1.ConfigMap{}
+				if err := json.Unmarshal(work.Spec.Workload.Manifests[3].Raw, configMap); err != nil {
+					t.Fatal(err)
+				}
+				if configMap.Annotations[helpers.ClusterProfileAccessExpirationAnnotationKey] != "172800" {
+					t.Errorf("unexpected expiration %v", configMap.Annotations)
+				}
+				publicKey, err := encryption.ParsePublicKey(configMap.Annotations[helpers.ClusterProfileAccessPublicKeyAnnotationKey])
+				if err != nil || encryption.KeyID(publicKey) != encryption.KeyID(&key.PublicKey) {
+					t.Errorf("expected the public key of the hub, got %v", err)
+				}
+			},
+			validateKubeActions: testingcommon.AssertNoActions,
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionFalse, "Provisioning")
+			},
+		},
+		{
+			name:            "generate the key",
+			clusterProfiles: []runtime.Object{newClusterProfile(accessRequested)},
+			validateWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create")
+			},
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create")
+				secret := actions[0].(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+				if _, err := encryption.ParsePrivateKey(secret.Data[encryption.PrivateKeyDataKey]); err != nil {
+					t.Errorf("expected the private key, got %v", err)
+				}
+			},
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionFalse, "Provisioning")
+			},
+		},
+		{
+			name:                "publish the token",
+			clusterProfiles:     []runtime.Object{newClusterProfile(accessRequested)},
+			works:               []runtime.Object{newWork(1, now.Add(-time.Hour), encryptedToken)},
+			secrets:             []runtime.Object{keySecret},
+			validateWorkActions: testingcommon.AssertNoActions,
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create")
+				secret := actions[0].(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+				if string(secret.Data[AccessSecretTokenKey]) != "token1" ||
+					string(secret.Data[AccessSecretServerKey]) != "https://cluster1:6443" ||
+					len(secret.Data[AccessSecretKubeconfigKey]) == 0 {
+					t.Errorf("unexpected secret data %v", secret.Data)
+				}
+				if secret.Annotations[AccessSecretExpirationAnnotationKey] != "2024-01-02T00:00:00Z" {
+					t.Errorf("unexpected expiration %v", secret.Annotations)
+				}
+			},
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionTrue, "AccessProvided")
+			},
+		},
+		{
+			name:                "token encrypted with another key",
+			clusterProfiles:     []runtime.Object{newClusterProfile(accessRequested)},
+			works:               []runtime.Object{newWork(1, now.Add(-time.Hour), newEncryptedToken(&otherKey.PublicKey, "token1"))},
+			secrets:             []runtime.Object{keySecret},
+			validateWorkActions: testingcommon.AssertNoActions,
+			validateKubeActions: testingcommon.AssertNoActions,
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionFalse, "Provisioning")
+			},
+		},
+		{
+			name: "rotate the token",
+			clusterProfiles: []runtime.Object{newClusterProfile(map[string]string{
+				ClusterProfileAccessAnnotationKey:         "view",
+				ClusterProfileAccessRotationAnnotationKey: "1h",
+			})},
+			works:   []runtime.Object{newWork(1, now.Add(-2*time.Hour), encryptedToken)},
+			secrets: []runtime.Object{keySecret, publishedSecret},
+			validateWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				work := &workapiv1.ManifestWork{}
+				if err := json.Unmarshal(actions[0].(clienttesting.PatchAction).GetPatch(), work); err != nil {
+					t.Fatal(err)
+				}
+				if work.Annotations[helpers.ClusterProfileAccessGenerationAnnotationKey] != "2" {
+					t.Errorf("expect generation 2, got %v", work.Annotations)
+				}
+			},
+			validateKubeActions: testingcommon.AssertNoActions,
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionFalse, "Provisioning")
+			},
+		},
+		{
+			name: "invalid rotation",
+			clusterProfiles: []runtime.Object{newClusterProfile(map[string]string{
+				ClusterProfileAccessAnnotationKey:         "view",
+				ClusterProfileAccessRotationAnnotationKey: "abc",
+			})},
+			validateWorkActions: testingcommon.AssertNoActions,
+			validateKubeActions: testingcommon.AssertNoActions,
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionFalse, "InvalidRotation")
+			},
+		},
+		{
+			name: "rotation is too short",
+			clusterProfiles: []runtime.Object{newClusterProfile(map[string]string{
+				ClusterProfileAccessAnnotationKey:         "view",
+				ClusterProfileAccessRotationAnnotationKey: "1m",
+			})},
+			validateWorkActions: testingcommon.AssertNoActions,
+			validateKubeActions: testingcommon.AssertNoActions,
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionFalse, "InvalidRotation")
+			},
+		},
+		{
+			name: "cluster role is not allowed",
+			clusterProfiles: []runtime.Object{newClusterProfile(map[string]string{
+				ClusterProfileAccessAnnotationKey: "cluster-admin",
+			})},
+			works:   []runtime.Object{newWork(1, now, encryptedToken)},
+			secrets: []runtime.Object{keySecret, publishedSecret},
+			validateWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateProfileActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAccessCondition(t, actions, metav1.ConditionFalse, "ClusterRoleNotAllowed")
+			},
+		},
+		{
+			name:            "revoke the access",
+			clusterProfiles: []runtime.Object{newClusterProfile(nil)},
+			works:           []runtime.Object{newWork(1, now, encryptedToken)},
+			secrets:         []runtime.Object{publishedSecret},
+			validateWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateProfileActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:  "clusterprofile is deleted",
+			works: []runtime.Object{newWork(1, now, encryptedToken)},
+			validateWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateKubeActions:    testingcommon.AssertNoActions,
+			validateProfileActions: testingcommon.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(managedCluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(managedCluster); err != nil {
+				t.Fatal(err)
+			}
+
+			clusterProfileClient := cpfake.NewSimpleClientset(c.clusterProfiles...)
+			clusterProfileInformerFactory := cpinformers.NewSharedInformerFactory(clusterProfileClient, time.Minute*10)
+			for _, clusterProfile := range c.clusterProfiles {
+				if err := clusterProfileInformerFactory.Apis().V1alpha1().ClusterProfiles().Informer().GetStore().Add(clusterProfile); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			workClient := workfake.NewSimpleClientset(c.works...)
+			workInformerFactory := workinformers.NewSharedInformerFactory(workClient, time.Minute*10)
+			for _, work := range c.works {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.secrets...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			for _, secret := range c.secrets {
+				if err := kubeInformerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &clusterProfileAccessController{
+				kubeClient:           kubeClient,
+				clusterLister:        clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterProfileLister: clusterProfileInformerFactory.Apis().V1alpha1().ClusterProfiles().Lister(),
+				secretLister:         kubeInformerFactory.Core().V1().Secrets().Lister(),
+				workLister:           workInformerFactory.Work().V1().ManifestWorks().Lister(),
+				workApplier: workapplier.NewWorkApplierWithTypedClient(
+					workClient, workInformerFactory.Work().V1().ManifestWorks().Lister()),
+				patcher: patcher.NewPatcher[
+					*cpv1alpha1.ClusterProfile, cpv1alpha1.ClusterProfileSpec, cpv1alpha1.ClusterProfileStatus](
+					clusterProfileClient.ApisV1alpha1().ClusterProfiles(ClusterProfileNamespace)),
+				allowedClusterRoles: sets.New[string]("view"),
+				eventRecorder:       eventstesting.NewTestingEventRecorder(t),
+				now:                 func() time.Time { return now },
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, clusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateWorkActions(t, workClient.Actions())
+			c.validateKubeActions(t, kubeClient.Actions())
+			c.validateProfileActions(t, clusterProfileClient.Actions())
+		})
+	}
+}
+
+func TestNewExecCredential(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster1-access",
+			Namespace:   ClusterProfileNamespace,
+			Annotations: map[string]string{AccessSecretExpirationAnnotationKey: "2024-01-01T00:00:00Z"},
+		},
+		Data: map[string][]byte{AccessSecretTokenKey: []byte("token1")},
+	})
+
+	credential, err := NewExecCredential(context.TODO(), kubeClient, "cluster1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential.Status.Token != "token1" || credential.Status.ExpirationTimestamp == nil ||
+		!credential.Status.ExpirationTimestamp.Equal(&metav1.Time{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}) {
+		t.Errorf("unexpected credential %v", credential.Status)
+	}
+
+	if _, err := NewExecCredential(context.TODO(), kubeClient, "cluster2"); err == nil {
+		t.Errorf("expect error for the cluster without access secret")
+	}
+}
+
+func assertAccessCondition(t *testing.T, actions []clienttesting.Action, status metav1.ConditionStatus, reason string) {
+	testingcommon.AssertActions(t, actions, "patch")
+	clusterProfile := &cpv1alpha1.ClusterProfile{}
+	if err := json.Unmarshal(actions[0].(clienttesting.PatchAction).GetPatch(), clusterProfile); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(clusterProfile.Status.Conditions, ClusterConditionAccessProvided)
+	if cond == nil || cond.Status != status || cond.Reason != reason {
+		t.Errorf("expect condition %s/%s, got %v", status, reason, cond)
+	}
+}
@@ -0,0 +1,298 @@
+package clusterprofile
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	cpv1alpha1 "sigs.k8s.io/cluster-inventory-api/apis/v1alpha1"
+	cpclientset "sigs.k8s.io/cluster-inventory-api/client/clientset/versioned"
+	cpinformerv1alpha1 "sigs.k8s.io/cluster-inventory-api/client/informers/externalversions/apis/v1alpha1"
+	cplisterv1alpha1 "sigs.k8s.io/cluster-inventory-api/client/listers/apis/v1alpha1"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+// ClusterProfileMirrorLabelKey is the label on the ClusterProfiles mirrored from the ClusterProfile namespace into
+// the namespaces bound to the clusterset of the cluster.
+const ClusterProfileMirrorLabelKey = "open-cluster-management.io/cluster-profile-mirror"
+
+// clusterProfileBindingController mirrors the ClusterProfiles in the ClusterProfile namespace into each namespace
+// with a valid ManagedClusterSetBinding of the clusterset of the cluster, so a tenant which is only allowed to
+// access its namespace can only see the clusters of the clustersets bound to the namespace. The mirrored
+// ClusterProfiles are removed once the cluster is not in a clusterset bound to the namespace.
+type clusterProfileBindingController struct {
+	clusterLister           listerv1.ManagedClusterLister
+	clusterSetLister        clusterlisterv1beta2.ManagedClusterSetLister
+	clusterSetBindingLister clusterlisterv1beta2.ManagedClusterSetBindingLister
+	clusterProfileClient    cpclientset.Interface
+	clusterProfileLister    cplisterv1alpha1.ClusterProfileLister
+	eventRecorder           events.Recorder
+}
+
+// NewClusterProfileBindingController creates a new cluster profile binding controller
+func NewClusterProfileBindingController(
+	clusterInformer informerv1.ManagedClusterInformer,
+	clusterSetInformer clusterinformerv1beta2.ManagedClusterSetInformer,
+	clusterSetBindingInformer clusterinformerv1beta2.ManagedClusterSetBindingInformer,
+	clusterProfileClient cpclientset.Interface,
+	clusterProfileInformer cpinformerv1alpha1.ClusterProfileInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterProfileBindingController{
+		clusterLister:           clusterInformer.Lister(),
+		clusterSetLister:        clusterSetInformer.Lister(),
+		clusterSetBindingLister: clusterSetBindingInformer.Lister(),
+		clusterProfileClient:    clusterProfileClient,
+		clusterProfileLister:    clusterProfileInformer.Lister(),
+		eventRecorder:           recorder.WithComponentSuffix("cluster-profile-binding-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeysFunc(c.clusterQueueKeys, clusterInformer.Informer()).
+		WithInformersQueueKeysFunc(c.clusterSetQueueKeys, clusterSetInformer.Informer()).
+		WithInformersQueueKeysFunc(c.clusterSetBindingQueueKeys, clusterSetBindingInformer.Informer()).
+		WithInformersQueueKeysFunc(c.clusterProfileQueueKeys, clusterProfileInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterProfileBindingController", recorder)
+}
+
+// clusterQueueKeys returns the namespaces where the ClusterProfile of the cluster is mirrored or should be mirrored.
+func (c *clusterProfileBindingController) clusterQueueKeys(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	return c.namespacesOfCluster(accessor.GetName())
+}
+
+// clusterSetQueueKeys returns the namespaces bound to the clusterset or its ancestors.
+func (c *clusterProfileBindingController) clusterSetQueueKeys(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	clusterSetNames := sets.New[string](accessor.GetName())
+	if clusterSet, ok := obj.(*clusterv1beta2.ManagedClusterSet); ok {
+		ancestors, err := commonhelpers.GetClusterSetAncestors(clusterSet, c.clusterSetLister)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return nil
+		}
+		for _, ancestor := range ancestors {
+			clusterSetNames.Insert(ancestor.Name)
+		}
+	}
+
+	bindings, err := c.clusterSetBindingLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+
+	namespaces := sets.New[string]()
+	for _, binding := range bindings {
+		if clusterSetNames.Has(binding.Name) {
+			namespaces.Insert(binding.Namespace)
+		}
+	}
+	return sets.List(namespaces)
+}
+
+func (c *clusterProfileBindingController) clusterSetBindingQueueKeys(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	return []string{accessor.GetNamespace()}
+}
+
+func (c *clusterProfileBindingController) clusterProfileQueueKeys(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	if accessor.GetNamespace() != ClusterProfileNamespace {
+		return []string{accessor.GetNamespace()}
+	}
+	return c.namespacesOfCluster(accessor.GetName())
+}
+
+func (c *clusterProfileBindingController) namespacesOfCluster(clusterName string) []string {
+	namespaces := sets.New[string]()
+
+	clusterProfiles, err := c.clusterProfileLister.List(labels.SelectorFromSet(labels.Set{ClusterProfileMirrorLabelKey: "true"}))
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+	for _, clusterProfile := range clusterProfiles {
+		if clusterProfile.Name == clusterName {
+			namespaces.Insert(clusterProfile.Namespace)
+		}
+	}
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		return sets.List(namespaces)
+	case err != nil:
+		utilruntime.HandleError(err)
+		return nil
+	}
+	clusterSets, err := commonhelpers.GetClusterSetsOfClusterWithAncestors(cluster, c.clusterSetLister)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+	bindings, err := c.clusterSetBindingLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+	for _, clusterSet := range clusterSets {
+		for _, binding := range bindings {
+			if binding.Name == clusterSet.Name {
+				namespaces.Insert(binding.Namespace)
+			}
+		}
+	}
+	return sets.List(namespaces)
+}
+
+func (c *clusterProfileBindingController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	namespace := syncCtx.QueueKey()
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Reconciling ClusterProfiles in namespace", "namespace", namespace)
+
+	// the ClusterProfiles in the ClusterProfile namespace are the source of the mirrored ClusterProfiles.
+	if namespace == ClusterProfileNamespace {
+		return nil
+	}
+
+	requiredClusters, err := c.boundClusters(namespace)
+	if err != nil {
+		return err
+	}
+
+	for clusterName := range requiredClusters {
+		clusterProfile, err := c.clusterProfileLister.ClusterProfiles(ClusterProfileNamespace).Get(clusterName)
+		switch {
+		case errors.IsNotFound(err):
+			// the ClusterProfile has not been created yet, the namespace is requeued once it is created.
+			requiredClusters.Delete(clusterName)
+			continue
+		case err != nil:
+			return err
+		}
+		if clusterProfile.Spec.ClusterManager.Name != ClusterProfileManagerName {
+			requiredClusters.Delete(clusterName)
+			continue
+		}
+		if err := c.applyMirror(ctx, namespace, clusterProfile); err != nil {
+			return err
+		}
+	}
+
+	mirrors, err := c.clusterProfileLister.ClusterProfiles(namespace).List(
+		labels.SelectorFromSet(labels.Set{ClusterProfileMirrorLabelKey: "true"}))
+	if err != nil {
+		return err
+	}
+	for _, mirror := range mirrors {
+		if requiredClusters.Has(mirror.Name) {
+			continue
+		}
+		err := c.clusterProfileClient.ApisV1alpha1().ClusterProfiles(namespace).Delete(ctx, mirror.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		c.eventRecorder.Eventf("ClusterProfileMirrorDeleted",
+			"cluster profile %s is removed from namespace %s", mirror.Name, namespace)
+	}
+	return nil
+}
+
+// boundClusters returns the names of the clusters in the clustersets bound to the namespace.
+func (c *clusterProfileBindingController) boundClusters(namespace string) (sets.Set[string], error) {
+	clusters := sets.New[string]()
+	bindings, err := commonhelpers.GetValidManagedClusterSetBindings(namespace, c.clusterSetBindingLister, c.clusterSetLister)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings {
+		clusterSet, err := c.clusterSetLister.Get(binding.Name)
+		switch {
+		case errors.IsNotFound(err):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		members, err := commonhelpers.GetClustersFromClusterSetTree(clusterSet, c.clusterSetLister, c.clusterLister)
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range members {
+			if cluster.DeletionTimestamp.IsZero() {
+				clusters.Insert(cluster.Name)
+			}
+		}
+	}
+	return clusters, nil
+}
+
+func (c *clusterProfileBindingController) applyMirror(ctx context.Context, namespace string, source *cpv1alpha1.ClusterProfile) error {
+	requiredLabels := map[string]string{ClusterProfileMirrorLabelKey: "true"}
+	for key, value := range source.Labels {
+		requiredLabels[key] = value
+	}
+
+	mirror, err := c.clusterProfileLister.ClusterProfiles(namespace).Get(source.Name)
+	switch {
+	case errors.IsNotFound(err):
+		mirror = &cpv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      source.Name,
+				Namespace: namespace,
+				Labels:    requiredLabels,
+			},
+			Spec: source.Spec,
+		}
+		created, err := c.clusterProfileClient.ApisV1alpha1().ClusterProfiles(namespace).Create(ctx, mirror, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		c.eventRecorder.Eventf("ClusterProfileMirrorCreated",
+			"cluster profile %s is mirrored into namespace %s", source.Name, namespace)
+		mirror = created
+	case err != nil:
+		return err
+	}
+
+	// do not override the ClusterProfile created by others in the namespace.
+	if _, ok := mirror.Labels[ClusterProfileMirrorLabelKey]; !ok {
+		return nil
+	}
+
+	clusterProfilePatcher := patcher.NewPatcher[
+		*cpv1alpha1.ClusterProfile, cpv1alpha1.ClusterProfileSpec, cpv1alpha1.ClusterProfileStatus](
+		c.clusterProfileClient.ApisV1alpha1().ClusterProfiles(namespace))
+
+	newMirror := mirror.DeepCopy()
+	newMirror.Labels = requiredLabels
+	newMirror.Spec = source.Spec
+	newMirror.Status = source.Status
+	if !equality.Semantic.DeepEqual(newMirror.Labels, mirror.Labels) {
+		if _, err := clusterProfilePatcher.PatchLabelAnnotations(ctx, newMirror, newMirror.ObjectMeta, mirror.ObjectMeta); err != nil {
+			return err
+		}
+	}
+	if _, err := clusterProfilePatcher.PatchSpec(ctx, newMirror, newMirror.Spec, mirror.Spec); err != nil {
+		return err
+	}
+	_, err = clusterProfilePatcher.PatchStatus(ctx, newMirror, newMirror.Status, mirror.Status)
+	return err
+}
@@ -0,0 +1,243 @@
+package clusterprofile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	cpv1alpha1 "sigs.k8s.io/cluster-inventory-api/apis/v1alpha1"
+	cpfake "sigs.k8s.io/cluster-inventory-api/client/clientset/versioned/fake"
+	cpinformers "sigs.k8s.io/cluster-inventory-api/client/informers/externalversions"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	v1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestSyncClusterProfileBinding(t *testing.T) {
+	cluster1 := &v1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster1",
+			Labels: map[string]string{v1beta2.ClusterSetLabel: "set1"},
+		},
+	}
+	cluster2 := &v1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster2",
+			Labels: map[string]string{v1beta2.ClusterSetLabel: "set2"},
+		},
+	}
+	clusterSet1 := &v1beta2.ManagedClusterSet{ObjectMeta: metav1.ObjectMeta{Name: "set1"}}
+	clusterSet2 := &v1beta2.ManagedClusterSet{ObjectMeta: metav1.ObjectMeta{Name: "set2"}}
+	binding := &v1beta2.ManagedClusterSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "set1", Namespace: "tenant1"},
+		Spec:       v1beta2.ManagedClusterSetBindingSpec{ClusterSet: "set1"},
+	}
+	newClusterProfile := func(namespace, name string, mirror bool) *cpv1alpha1.ClusterProfile {
+		clusterProfile := &cpv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{cpv1alpha1.LabelClusterManagerKey: ClusterProfileManagerName},
+			},
+			Spec: cpv1alpha1.ClusterProfileSpec{
+				DisplayName:    name,
+				ClusterManager: cpv1alpha1.ClusterManager{Name: ClusterProfileManagerName},
+			},
+			Status: cpv1alpha1.ClusterProfileStatus{
+				Version: cpv1alpha1.ClusterVersion{Kubernetes: "v1.30.0"},
+			},
+		}
+		if mirror {
+			clusterProfile.Labels[ClusterProfileMirrorLabelKey] = "true"
+		}
+		return clusterProfile
+	}
+
+	cases := []struct {
+		name            string
+		queueKey        string
+		clusterObjs     []runtime.Object
+		clusterProfiles []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:        "mirror the clusterprofile of the bound clusterset",
+			queueKey:    "tenant1",
+			clusterObjs: []runtime.Object{cluster1, cluster2, clusterSet1, clusterSet2, binding},
+			clusterProfiles: []runtime.Object{
+				newClusterProfile(ClusterProfileNamespace, "cluster1", false),
+				newClusterProfile(ClusterProfileNamespace, "cluster2", false),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create", "patch")
+				mirror := actions[0].(clienttesting.CreateAction).GetObject().(*cpv1alpha1.ClusterProfile)
+				if mirror.Namespace != "tenant1" || mirror.Name != "cluster1" || mirror.Labels[ClusterProfileMirrorLabelKey] != "true" {
+					t.Errorf("unexpected mirrored clusterprofile %v", mirror)
+				}
+				if actions[1].GetSubresource() != "status" {
+					t.Errorf("expect status is patched, got %v", actions[1])
+				}
+			},
+		},
+		{
+			name:        "mirrored clusterprofile is synced",
+			queueKey:    "tenant1",
+			clusterObjs: []runtime.Object{cluster1, clusterSet1, binding},
+			clusterProfiles: []runtime.Object{
+				newClusterProfile(ClusterProfileNamespace, "cluster1", false),
+				newClusterProfile("tenant1", "cluster1", true),
+			},
+			validateActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:        "remove the clusterprofile when the binding is deleted",
+			queueKey:    "tenant1",
+			clusterObjs: []runtime.Object{cluster1, clusterSet1},
+			clusterProfiles: []runtime.Object{
+				newClusterProfile(ClusterProfileNamespace, "cluster1", false),
+				newClusterProfile("tenant1", "cluster1", true),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+		},
+		{
+			name:        "binding of a non-existent clusterset",
+			queueKey:    "tenant1",
+			clusterObjs: []runtime.Object{cluster1, binding},
+			clusterProfiles: []runtime.Object{
+				newClusterProfile(ClusterProfileNamespace, "cluster1", false),
+				newClusterProfile("tenant1", "cluster1", true),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+		},
+		{
+			name:        "clusterprofile not created by the mirror is kept",
+			queueKey:    "tenant1",
+			clusterObjs: []runtime.Object{cluster1, clusterSet1, binding},
+			clusterProfiles: []runtime.Object{
+				newClusterProfile(ClusterProfileNamespace, "cluster1", false),
+				newClusterProfile("tenant1", "cluster1", false),
+				newClusterProfile("tenant1", "cluster3", false),
+			},
+			validateActions: testingcommon.AssertNoActions,
+		},
+		{
+			name:        "clusterprofile namespace",
+			queueKey:    ClusterProfileNamespace,
+			clusterObjs: []runtime.Object{cluster1, clusterSet1, binding},
+			clusterProfiles: []runtime.Object{
+				newClusterProfile(ClusterProfileNamespace, "cluster1", false),
+			},
+			validateActions: testingcommon.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusterObjs...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			for _, obj := range c.clusterObjs {
+				switch obj.(type) {
+				case *v1.ManagedCluster:
+					if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(obj); err != nil {
+						t.Fatal(err)
+					}
+				case *v1beta2.ManagedClusterSet:
+					if err := clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().GetStore().Add(obj); err != nil {
+						t.Fatal(err)
+					}
+				case *v1beta2.ManagedClusterSetBinding:
+					if err := clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Informer().GetStore().Add(obj); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			clusterProfileClient := cpfake.NewSimpleClientset(c.clusterProfiles...)
+			clusterProfileInformerFactory := cpinformers.NewSharedInformerFactory(clusterProfileClient, time.Minute*10)
+			for _, clusterProfile := range c.clusterProfiles {
+				if err := clusterProfileInformerFactory.Apis().V1alpha1().ClusterProfiles().Informer().GetStore().Add(clusterProfile); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &clusterProfileBindingController{
+				clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+				clusterProfileClient:    clusterProfileClient,
+				clusterProfileLister:    clusterProfileInformerFactory.Apis().V1alpha1().ClusterProfiles().Lister(),
+				eventRecorder:           eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, c.queueKey))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterProfileClient.Actions())
+		})
+	}
+}
+
+func TestClusterProfileBindingQueueKeys(t *testing.T) {
+	cluster1 := &v1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster1",
+			Labels: map[string]string{v1beta2.ClusterSetLabel: "set1"},
+		},
+	}
+	clusterSet1 := &v1beta2.ManagedClusterSet{ObjectMeta: metav1.ObjectMeta{Name: "set1"}}
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), time.Minute*10)
+	clusterProfileInformerFactory := cpinformers.NewSharedInformerFactory(cpfake.NewSimpleClientset(), time.Minute*10)
+	for _, obj := range []runtime.Object{cluster1} {
+		if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().GetStore().Add(clusterSet1); err != nil {
+		t.Fatal(err)
+	}
+	for _, namespace := range []string{"tenant1", "tenant2"} {
+		if err := clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Informer().GetStore().Add(
+			&v1beta2.ManagedClusterSetBinding{ObjectMeta: metav1.ObjectMeta{Name: "set1", Namespace: namespace}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// cluster1 was in a clusterset bound to tenant3 before.
+	if err := clusterProfileInformerFactory.Apis().V1alpha1().ClusterProfiles().Informer().GetStore().Add(&cpv1alpha1.ClusterProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster1",
+			Namespace: "tenant3",
+			Labels:    map[string]string{ClusterProfileMirrorLabelKey: "true"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := &clusterProfileBindingController{
+		clusterLister:           clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		clusterSetLister:        clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+		clusterSetBindingLister: clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+		clusterProfileLister:    clusterProfileInformerFactory.Apis().V1alpha1().ClusterProfiles().Lister(),
+	}
+
+	keys := ctrl.clusterQueueKeys(cluster1)
+	if len(keys) != 3 || keys[0] != "tenant1" || keys[1] != "tenant2" || keys[2] != "tenant3" {
+		t.Errorf("unexpected keys of cluster %v", keys)
+	}
+	keys = ctrl.clusterSetQueueKeys(clusterSet1)
+	if len(keys) != 2 || keys[0] != "tenant1" || keys[1] != "tenant2" {
+		t.Errorf("unexpected keys of clusterset %v", keys)
+	}
+}
@@ -0,0 +1,42 @@
+package clusterprofile
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+// NewExecCredential returns the ExecCredential of the cluster from the access secret published by the cluster
+// profile access controller, it is used by the credential plugin to access the cluster with the ClusterProfile.
+func NewExecCredential(ctx context.Context, kubeClient kubernetes.Interface, clusterName string) (*clientauthenticationv1.ExecCredential, error) {
+	secret, err := kubeClient.CoreV1().Secrets(ClusterProfileNamespace).Get(ctx, clusterName+"-access", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	token := secret.Data[AccessSecretTokenKey]
+	if len(token) == 0 {
+		return nil, fmt.Errorf("no token in the access secret of cluster %s", clusterName)
+	}
+
+	status := &clientauthenticationv1.ExecCredentialStatus{Token: string(token)}
+	if value, ok := secret.Annotations[AccessSecretExpirationAnnotationKey]; ok {
+		expiration, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration of the access secret of cluster %s: %v", clusterName, err)
+		}
+		status.ExpirationTimestamp = &metav1.Time{Time: expiration}
+	}
+
+	return &clientauthenticationv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clientauthenticationv1.SchemeGroupVersion.String(),
+			Kind:       "ExecCredential",
+		},
+		Status: status,
+	}, nil
+}
@@ -0,0 +1,683 @@
+package decommission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	operatorv1 "open-cluster-management.io/api/operator/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/common/queue"
+)
+
+const (
+	// DecommissionModeAnnotationKey is the annotation on the ManagedCluster to decommission the cluster in the
+	// detach or destroy mode once the cluster is deleted.
+	DecommissionModeAnnotationKey = "cluster.open-cluster-management.io/decommission-mode"
+	// DecommissionDryRunAnnotationKey is the annotation on the ManagedCluster to list what will be removed by the
+	// decommission in the DecommissionPlanned condition without deleting the cluster.
+	DecommissionDryRunAnnotationKey = "cluster.open-cluster-management.io/decommission-dry-run"
+	// DecommissionDependsOnAnnotationKey is the annotation on the ManifestWork with a comma separated list of the
+	// works it depends on. The work is deleted before its dependencies in the destroy mode.
+	DecommissionDependsOnAnnotationKey = "cluster.open-cluster-management.io/decommission-depends-on"
+	// DecommissionHookLabelKey is the label on the ConfigMaps in the cluster ns holding the hooks, the only
+	// supported value is pre-delete. The ManifestWorkSpec of the hook is in the manifestwork key of the data.
+	DecommissionHookLabelKey = "cluster.open-cluster-management.io/decommission-hook"
+	// DecommissionHookCompletedAnnotationKey is the annotation on the hook ConfigMap set once the hook is completed.
+	DecommissionHookCompletedAnnotationKey = "cluster.open-cluster-management.io/decommission-hook-completed"
+	DecommissionHookDataKey                = "manifestwork"
+
+	// DecommissionModeDetach orphans all the workloads on the cluster and removes the agents.
+	DecommissionModeDetach = "detach"
+	// DecommissionModeDestroy deletes all the workloads in the reverse dependency order, then the addons and the
+	// agents.
+	DecommissionModeDestroy   = "destroy"
+	DecommissionHookPreDelete = "pre-delete"
+
+	// ManagedClusterConditionDecommissioning is the condition of the progress of the decommission.
+	ManagedClusterConditionDecommissioning = "Decommissioning"
+	// ManagedClusterConditionDecommissionPlanned is the condition listing the plan of the dry-run decommission.
+	ManagedClusterConditionDecommissionPlanned = "DecommissionPlanned"
+
+	ReasonPreDeleteHooksRunning   = "PreDeleteHooksRunning"
+	ReasonWorkloadsOrphaning      = "WorkloadsOrphaning"
+	ReasonWorkloadsDeleting       = "WorkloadsDeleting"
+	ReasonAddonsDeleting          = "AddonsDeleting"
+	ReasonAgentRemoving           = "AgentRemoving"
+	ReasonDecommissionCompleted   = "DecommissionCompleted"
+	ReasonDecommissionTimeout     = "DecommissionTimeout"
+	ReasonDecommissionDryRun      = "DryRun"
+	ReasonDecommissionModeInvalid = "InvalidDecommissionMode"
+
+	hookWorkNamePrefix    = commonhelpers.DecommissionHookWorkNamePrefix
+	agentWorkName         = commonhelpers.DecommissionAgentWorkName
+	defaultKlusterletName = "klusterlet"
+)
+
+var requeueError = commonhelpers.NewRequeueError("decommission requeue", 5*time.Second)
+
+type decommissionController struct {
+	kubeClient     kubernetes.Interface
+	clusterLister  clusterlisterv1.ManagedClusterLister
+	clusterPatcher patcher.Patcher[*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus]
+	workClient     workclientset.Interface
+	workLister     worklisterv1.ManifestWorkLister
+	hookLister     corev1listers.ConfigMapLister
+	addOnClient    addonclient.Interface
+	addOnLister    addonlisterv1alpha1.ManagedClusterAddOnLister
+	hookTimeout    time.Duration
+	timeout        time.Duration
+	eventRecorder  events.Recorder
+}
+
+// NewDecommissionController decommissions the deleting clusters with the decommission mode annotation. It runs the
+// pre-delete hooks, orphans or deletes the workloads, deletes the addons and removes the klusterlet in order, and
+// then hands the cluster over to the gc controller. The hook informer should only watch the ConfigMaps with the
+// decommission hook label. Once the decommission is not completed in the timeout since the cluster is deleted, the
+// workloads are forced to be orphaned in the detach mode, and the decommission is reported as timed out otherwise.
+func NewDecommissionController(
+	kubeClient kubernetes.Interface,
+	clusterClient clientset.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	workClient workclientset.Interface,
+	workInformer workinformerv1.ManifestWorkInformer,
+	hookInformer corev1informers.ConfigMapInformer,
+	addOnClient addonclient.Interface,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	hookTimeout time.Duration,
+	timeout time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &decommissionController{
+		kubeClient:    kubeClient,
+		clusterLister: clusterInformer.Lister(),
+		clusterPatcher: patcher.NewPatcher[
+			*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		workClient:    workClient,
+		workLister:    workInformer.Lister(),
+		hookLister:    hookInformer.Lister(),
+		addOnClient:   addOnClient,
+		addOnLister:   addOnInformer.Lister(),
+		hookTimeout:   hookTimeout,
+		timeout:       timeout,
+		eventRecorder: recorder.WithComponentSuffix("decommission-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, clusterInformer.Informer()).
+		WithInformersQueueKeysFunc(queue.QueueKeyByMetaNamespace,
+			workInformer.Informer(), hookInformer.Informer(), addOnInformer.Informer()).
+		WithSync(c.sync).
+		ToController("DecommissionController", recorder)
+}
+
+func (c *decommissionController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Reconciling decommission of cluster", "clusterName", clusterName)
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	mode := cluster.Annotations[DecommissionModeAnnotationKey]
+	validMode := mode == DecommissionModeDetach || mode == DecommissionModeDestroy
+	if cluster.DeletionTimestamp.IsZero() {
+		if validMode {
+			if _, err := c.clusterPatcher.AddFinalizer(ctx, cluster, commonhelpers.DecommissionFinalizer); err != nil {
+				return err
+			}
+		} else if commonhelpers.HasFinalizer(cluster.Finalizers, commonhelpers.DecommissionFinalizer) {
+			if err := c.clusterPatcher.RemoveFinalizer(ctx, cluster, commonhelpers.DecommissionFinalizer); err != nil {
+				return err
+			}
+		}
+		return c.syncDryRun(ctx, cluster, mode, validMode)
+	}
+
+	if !commonhelpers.HasFinalizer(cluster.Finalizers, commonhelpers.DecommissionFinalizer) {
+		return nil
+	}
+	// the mode is removed after the cluster is deleted, fall back to the resource cleanup of the gc controller.
+	if !validMode {
+		return c.clusterPatcher.RemoveFinalizer(ctx, cluster, commonhelpers.DecommissionFinalizer)
+	}
+
+	newCluster := cluster.DeepCopy()
+	completed, decommissionErr := c.decommission(ctx, newCluster, mode)
+	if _, err := c.clusterPatcher.PatchStatus(ctx, newCluster, newCluster.Status, cluster.Status); err != nil {
+		return err
+	}
+	if decommissionErr != nil {
+		return decommissionErr
+	}
+	if !completed {
+		// the timed out decommission is resynced on the changes of the blocking resources only.
+		condition := meta.FindStatusCondition(newCluster.Status.Conditions, ManagedClusterConditionDecommissioning)
+		if condition == nil || condition.Reason != ReasonDecommissionTimeout {
+			syncCtx.Queue().AddAfter(clusterName, requeueError.RequeueTime)
+		}
+		return nil
+	}
+
+	c.eventRecorder.Eventf("DecommissionCompleted", "cluster %s is decommissioned in %s mode", clusterName, mode)
+	return c.clusterPatcher.RemoveFinalizer(ctx, cluster, commonhelpers.DecommissionFinalizer)
+}
+
+// syncDryRun sets the decommission plan in the DecommissionPlanned condition of the cluster with the dry-run
+// annotation, and removes the condition once the annotation is removed.
+func (c *decommissionController) syncDryRun(ctx context.Context, cluster *clusterv1.ManagedCluster,
+	mode string, validMode bool) error {
+	newCluster := cluster.DeepCopy()
+	if cluster.Annotations[DecommissionDryRunAnnotationKey] != "true" {
+		meta.RemoveStatusCondition(&newCluster.Status.Conditions, ManagedClusterConditionDecommissionPlanned)
+		_, err := c.clusterPatcher.PatchStatus(ctx, newCluster, newCluster.Status, cluster.Status)
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:   ManagedClusterConditionDecommissionPlanned,
+		Status: metav1.ConditionFalse,
+		Reason: ReasonDecommissionModeInvalid,
+		Message: fmt.Sprintf("decommission mode %q is invalid, it must be %s or %s",
+			mode, DecommissionModeDetach, DecommissionModeDestroy),
+	}
+	if validMode {
+		hooks, err := c.pendingHooks(cluster.Name)
+		if err != nil {
+			return err
+		}
+		works, err := c.workLister.ManifestWorks(cluster.Name).List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		addons, err := c.addOnLister.ManagedClusterAddOns(cluster.Name).List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		klusterlet, hosted := klusterletOf(cluster)
+		condition = metav1.Condition{
+			Type:    ManagedClusterConditionDecommissionPlanned,
+			Status:  metav1.ConditionTrue,
+			Reason:  ReasonDecommissionDryRun,
+			Message: decommissionPlan(mode, hooks, works, addons, klusterlet, hosted),
+		}
+	}
+	meta.SetStatusCondition(&newCluster.Status.Conditions, condition)
+	_, err := c.clusterPatcher.PatchStatus(ctx, newCluster, newCluster.Status, cluster.Status)
+	return err
+}
+
+// decommission runs the phases of the decommission in order, and returns true once all the phases are completed.
+// The progress is set in the Decommissioning condition of the cluster. Once the timeout passes, the workloads are
+// forced to be orphaned in the detach mode, and the condition is set to false with the timeout reason if the
+// decommission is still blocked, it continues once the blocking resources are removed.
+func (c *decommissionController) decommission(ctx context.Context, cluster *clusterv1.ManagedCluster,
+	mode string) (bool, error) {
+	expired := c.timeout > 0 && time.Since(cluster.DeletionTimestamp.Time) > c.timeout
+	setProgress := func(reason, message string) {
+		condition := metav1.Condition{
+			Type:    ManagedClusterConditionDecommissioning,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		}
+		if expired {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonDecommissionTimeout
+			condition.Message = fmt.Sprintf("The decommission is not completed in %s, %s", c.timeout, message)
+		}
+		meta.SetStatusCondition(&cluster.Status.Conditions, condition)
+	}
+
+	works, err := c.workLister.ManifestWorks(cluster.Name).List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+
+	completed, message, err := c.runPreDeleteHooks(ctx, cluster.Name, works)
+	if !completed || err != nil {
+		setProgress(ReasonPreDeleteHooksRunning, message)
+		return false, err
+	}
+
+	var workloads []*workv1.ManifestWork
+	for _, work := range works {
+		if isWorkload(work) {
+			workloads = append(workloads, work)
+		}
+	}
+	if len(workloads) > 0 {
+		if mode == DecommissionModeDetach {
+			if expired {
+				// the workloads are left on the cluster in the detach mode, so the works are removed without
+				// waiting for the work agent.
+				return false, c.forceOrphanWorkloads(ctx, cluster.Name, workloads)
+			}
+			message, err = c.orphanWorkloads(ctx, workloads)
+			setProgress(ReasonWorkloadsOrphaning, message)
+			return false, err
+		}
+		message, err = c.deleteWorkloads(ctx, workloads)
+		setProgress(ReasonWorkloadsDeleting, message)
+		return false, err
+	}
+
+	addons, err := c.addOnLister.ManagedClusterAddOns(cluster.Name).List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	if len(addons) > 0 {
+		var names []string
+		for _, addon := range addons {
+			names = append(names, addon.Name)
+			if !addon.DeletionTimestamp.IsZero() {
+				continue
+			}
+			err := c.addOnClient.AddonV1alpha1().ManagedClusterAddOns(cluster.Name).Delete(
+				ctx, addon.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		setProgress(ReasonAddonsDeleting, fmt.Sprintf("deleting addons %s", strings.Join(names, ", ")))
+		return false, nil
+	}
+
+	completed, message, err = c.removeAgent(ctx, cluster, works)
+	if !completed || err != nil {
+		setProgress(ReasonAgentRemoving, message)
+		return false, err
+	}
+
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:    ManagedClusterConditionDecommissioning,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonDecommissionCompleted,
+		Message: fmt.Sprintf("The cluster is decommissioned in %s mode.", mode),
+	})
+	return true, nil
+}
+
+// pendingHooks returns the pre-delete hooks which are not completed.
+func (c *decommissionController) pendingHooks(clusterName string) ([]*corev1.ConfigMap, error) {
+	hookList, err := c.hookLister.ConfigMaps(clusterName).List(
+		labels.SelectorFromSet(labels.Set{DecommissionHookLabelKey: DecommissionHookPreDelete}))
+	if err != nil {
+		return nil, err
+	}
+	var hooks []*corev1.ConfigMap
+	for _, hook := range hookList {
+		if _, ok := hook.Annotations[DecommissionHookCompletedAnnotationKey]; !ok {
+			hooks = append(hooks, hook)
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].Name < hooks[j].Name })
+	return hooks, nil
+}
+
+// runPreDeleteHooks applies the ManifestWork of each pending hook and waits until it is completed or times out.
+// The work of the hook is deleted and the hook is marked completed afterwards.
+func (c *decommissionController) runPreDeleteHooks(ctx context.Context, clusterName string,
+	works []*workv1.ManifestWork) (bool, string, error) {
+	hooks, err := c.pendingHooks(clusterName)
+	if err != nil {
+		return false, "", err
+	}
+	if len(hooks) == 0 {
+		return true, "", nil
+	}
+
+	existing := map[string]*workv1.ManifestWork{}
+	for _, work := range works {
+		existing[work.Name] = work
+	}
+
+	var running []string
+	for _, hook := range hooks {
+		work, ok := existing[hookWorkNamePrefix+hook.Name]
+		if !ok {
+			required, err := hookWork(hook)
+			if err != nil {
+				return false, "", fmt.Errorf("invalid pre-delete hook %s: %v", hook.Name, err)
+			}
+			if _, err := c.workClient.WorkV1().ManifestWorks(clusterName).Create(
+				ctx, required, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+				return false, "", err
+			}
+			running = append(running, hook.Name)
+			continue
+		}
+
+		timeout := time.Since(work.CreationTimestamp.Time) > c.hookTimeout
+		if !hookCompleted(work) && !timeout {
+			running = append(running, hook.Name)
+			continue
+		}
+		if timeout && !hookCompleted(work) {
+			c.eventRecorder.Warningf("DecommissionHookTimeout",
+				"pre-delete hook %s of cluster %s is not completed in %s", hook.Name, clusterName, c.hookTimeout)
+		}
+
+		if err := c.workClient.WorkV1().ManifestWorks(clusterName).Delete(
+			ctx, work.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return false, "", err
+		}
+		completed := hook.DeepCopy()
+		if completed.Annotations == nil {
+			completed.Annotations = map[string]string{}
+		}
+		completed.Annotations[DecommissionHookCompletedAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+		if _, err := c.kubeClient.CoreV1().ConfigMaps(clusterName).Update(
+			ctx, completed, metav1.UpdateOptions{}); err != nil {
+			return false, "", err
+		}
+	}
+
+	if len(running) > 0 {
+		return false, fmt.Sprintf("running pre-delete hooks %s", strings.Join(running, ", ")), nil
+	}
+	return true, "", nil
+}
+
+// orphanWorkloads sets the orphan delete option on the works and deletes the works once the work agent has observed
+// the delete option.
+func (c *decommissionController) orphanWorkloads(ctx context.Context, works []*workv1.ManifestWork) (string, error) {
+	workPatcher := patcher.NewPatcher[*workv1.ManifestWork, workv1.ManifestWorkSpec, workv1.ManifestWorkStatus](
+		c.workClient.WorkV1().ManifestWorks(works[0].Namespace))
+
+	var pending, deleting []string
+	for _, work := range works {
+		if !work.DeletionTimestamp.IsZero() {
+			deleting = append(deleting, work.Name)
+			continue
+		}
+
+		if work.Spec.DeleteOption == nil ||
+			work.Spec.DeleteOption.PropagationPolicy != workv1.DeletePropagationPolicyTypeOrphan {
+			newWork := work.DeepCopy()
+			newWork.Spec.DeleteOption = &workv1.DeleteOption{PropagationPolicy: workv1.DeletePropagationPolicyTypeOrphan}
+			if _, err := workPatcher.PatchSpec(ctx, newWork, newWork.Spec, work.Spec); err != nil {
+				return "", err
+			}
+			pending = append(pending, work.Name)
+			continue
+		}
+
+		applied := meta.FindStatusCondition(work.Status.Conditions, workv1.WorkApplied)
+		if applied == nil || applied.ObservedGeneration != work.Generation {
+			pending = append(pending, work.Name)
+			continue
+		}
+
+		if err := c.workClient.WorkV1().ManifestWorks(work.Namespace).Delete(
+			ctx, work.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		deleting = append(deleting, work.Name)
+	}
+
+	var messages []string
+	if len(pending) > 0 {
+		messages = append(messages, fmt.Sprintf("orphaning the workloads of manifestworks %s", strings.Join(pending, ", ")))
+	}
+	if len(deleting) > 0 {
+		messages = append(messages, fmt.Sprintf("deleting manifestworks %s", strings.Join(deleting, ", ")))
+	}
+	return strings.Join(messages, "; "), nil
+}
+
+// forceOrphanWorkloads deletes the works and removes their finalizers, so the works are removed without the work
+// agent and the workloads are left on the cluster.
+func (c *decommissionController) forceOrphanWorkloads(ctx context.Context, clusterName string,
+	works []*workv1.ManifestWork) error {
+	workPatcher := patcher.NewPatcher[*workv1.ManifestWork, workv1.ManifestWorkSpec, workv1.ManifestWorkStatus](
+		c.workClient.WorkV1().ManifestWorks(clusterName))
+
+	var names []string
+	for _, work := range works {
+		names = append(names, work.Name)
+		if work.DeletionTimestamp.IsZero() {
+			if err := c.workClient.WorkV1().ManifestWorks(clusterName).Delete(
+				ctx, work.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		if err := workPatcher.RemoveFinalizer(ctx, work, workv1.ManifestWorkFinalizer); err != nil {
+			return err
+		}
+	}
+	c.eventRecorder.Warningf(ReasonDecommissionTimeout,
+		"the workloads of manifestworks %s of cluster %s are forced to be orphaned after %s",
+		strings.Join(names, ", "), clusterName, c.timeout)
+	return nil
+}
+
+// deleteWorkloads deletes the works in the first deletion wave.
+func (c *decommissionController) deleteWorkloads(ctx context.Context, works []*workv1.ManifestWork) (string, error) {
+	waves := deletionWaves(works)
+	var names []string
+	for _, work := range waves[0] {
+		names = append(names, work.Name)
+		if !work.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := c.workClient.WorkV1().ManifestWorks(work.Namespace).Delete(
+			ctx, work.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("deleting the workloads of manifestworks %s, %d manifestworks remaining",
+		strings.Join(names, ", "), len(works)-len(names)), nil
+}
+
+// removeAgent applies the klusterlet by a ManifestWork and then deletes the work, so the klusterlet is deleted on
+// the cluster and the agents are removed by the klusterlet operator. The agent removal completes once the cluster
+// is not available, and the finalizer of the work which cannot be removed by the work agent any more is removed.
+// The klusterlet in the hosted mode runs on the management cluster which cannot be reached by a ManifestWork of
+// the cluster, so it is left to be removed on the management cluster.
+func (c *decommissionController) removeAgent(ctx context.Context, cluster *clusterv1.ManagedCluster,
+	works []*workv1.ManifestWork) (bool, string, error) {
+	klusterletName, hosted := klusterletOf(cluster)
+	if hosted {
+		c.eventRecorder.Warningf("DecommissionKlusterletSkipped",
+			"klusterlet %s of cluster %s runs in the hosted mode, it must be removed on the management cluster",
+			klusterletName, cluster.Name)
+		return true, "", nil
+	}
+
+	var work *workv1.ManifestWork
+	for _, w := range works {
+		if w.Name == agentWorkName {
+			work = w
+		}
+	}
+
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable) {
+		if work == nil {
+			return true, "", nil
+		}
+		if err := c.workClient.WorkV1().ManifestWorks(cluster.Name).Delete(
+			ctx, work.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return false, "", err
+		}
+		workPatcher := patcher.NewPatcher[*workv1.ManifestWork, workv1.ManifestWorkSpec, workv1.ManifestWorkStatus](
+			c.workClient.WorkV1().ManifestWorks(cluster.Name))
+		if err := workPatcher.RemoveFinalizer(ctx, work, workv1.ManifestWorkFinalizer); err != nil {
+			return false, "", err
+		}
+		return true, "", nil
+	}
+
+	switch {
+	case work == nil:
+		required, err := agentWork(cluster.Name, klusterletName)
+		if err != nil {
+			return false, "", err
+		}
+		if _, err := c.workClient.WorkV1().ManifestWorks(cluster.Name).Create(
+			ctx, required, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return false, "", err
+		}
+		return false, fmt.Sprintf("applying the removal of klusterlet %s", klusterletName), nil
+	case !work.DeletionTimestamp.IsZero():
+		return false, fmt.Sprintf("waiting for the agents of klusterlet %s to be removed", klusterletName), nil
+	case !meta.IsStatusConditionTrue(work.Status.Conditions, workv1.WorkAvailable):
+		return false, fmt.Sprintf("applying the removal of klusterlet %s", klusterletName), nil
+	}
+
+	if err := c.workClient.WorkV1().ManifestWorks(cluster.Name).Delete(
+		ctx, work.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return false, "", err
+	}
+	return false, fmt.Sprintf("deleting klusterlet %s", klusterletName), nil
+}
+
+// klusterletOf returns the name of the klusterlet deploying the agents of the cluster and whether it runs in the
+// hosted mode, from the cluster claims reported by the registration agent.
+func klusterletOf(cluster *clusterv1.ManagedCluster) (string, bool) {
+	name, mode := defaultKlusterletName, ""
+	for _, claim := range cluster.Status.ClusterClaims {
+		switch {
+		case claim.Name == commonhelpers.KlusterletNameClusterClaimName && len(claim.Value) > 0:
+			name = claim.Value
+		case claim.Name == commonhelpers.KlusterletInstallModeClusterClaimName:
+			mode = claim.Value
+		}
+	}
+	return name, mode == string(operatorv1.InstallModeHosted) || mode == string(operatorv1.InstallModeSingletonHosted)
+}
+
+// hookWork builds the ManifestWork of the hook, the status of the jobs in the hook is fed back to check the
+// completion of the hook.
+func hookWork(hook *corev1.ConfigMap) (*workv1.ManifestWork, error) {
+	spec := workv1.ManifestWorkSpec{}
+	if err := yaml.Unmarshal([]byte(hook.Data[DecommissionHookDataKey]), &spec); err != nil {
+		return nil, err
+	}
+	if len(spec.Workload.Manifests) == 0 {
+		return nil, fmt.Errorf("no manifests in the hook")
+	}
+
+	for _, manifest := range spec.Workload.Manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			return nil, err
+		}
+		if obj.GroupVersionKind().Group != "batch" || obj.GetKind() != "Job" {
+			continue
+		}
+		spec.ManifestConfigs = append(spec.ManifestConfigs, workv1.ManifestConfigOption{
+			ResourceIdentifier: workv1.ResourceIdentifier{
+				Group:     "batch",
+				Resource:  "jobs",
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+			FeedbackRules: []workv1.FeedbackRule{{Type: workv1.WellKnownStatusType}},
+		})
+	}
+
+	return &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hookWorkNamePrefix + hook.Name,
+			Namespace: hook.Namespace,
+			Labels:    map[string]string{DecommissionHookLabelKey: DecommissionHookPreDelete},
+		},
+		Spec: spec,
+	}, nil
+}
+
+// hookCompleted returns true if the work of the hook is available and all the jobs in it are complete.
+func hookCompleted(work *workv1.ManifestWork) bool {
+	if !meta.IsStatusConditionTrue(work.Status.Conditions, workv1.WorkAvailable) {
+		return false
+	}
+	for _, manifest := range work.Status.ResourceStatus.Manifests {
+		if manifest.ResourceMeta.Group != "batch" || manifest.ResourceMeta.Kind != "Job" {
+			continue
+		}
+		complete := false
+		for _, value := range manifest.StatusFeedbacks.Values {
+			if value.Name == "JobComplete" && value.Value.String != nil && *value.Value.String == "True" {
+				complete = true
+			}
+		}
+		if !complete {
+			return false
+		}
+	}
+	return true
+}
+
+// agentWork builds the ManifestWork owning the klusterlet on the cluster, the klusterlet is applied with only
+// its name so the spec is not changed.
+func agentWork(clusterName, klusterletName string) (*workv1.ManifestWork, error) {
+	klusterlet, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "operator.open-cluster-management.io/v1",
+		"kind":       "Klusterlet",
+		"metadata":   map[string]interface{}{"name": klusterletName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agentWorkName,
+			Namespace: clusterName,
+		},
+		Spec: workv1.ManifestWorkSpec{
+			Workload: workv1.ManifestsTemplate{
+				Manifests: []workv1.Manifest{{RawExtension: runtime.RawExtension{Raw: klusterlet}}},
+			},
+			ManifestConfigs: []workv1.ManifestConfigOption{
+				{
+					ResourceIdentifier: workv1.ResourceIdentifier{
+						Group:    "operator.open-cluster-management.io",
+						Resource: "klusterlets",
+						Name:     klusterletName,
+					},
+					UpdateStrategy: &workv1.UpdateStrategy{
+						Type:            workv1.UpdateStrategyTypeServerSideApply,
+						ServerSideApply: &workv1.ServerSideApplyConfig{Force: true, FieldManager: "decommission"},
+					},
+				},
+			},
+		},
+	}, nil
+}
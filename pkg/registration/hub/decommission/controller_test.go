@@ -0,0 +1,509 @@
+package decommission
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	fakeaddonclient "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+const testHookSpec = `
+workload:
+  manifests:
+  - apiVersion: batch/v1
+    kind: Job
+    metadata:
+      name: backup
+      namespace: default
+`
+
+type fakeClients struct {
+	kubeClient    *kubefake.Clientset
+	clusterClient *fakeclusterclient.Clientset
+	workClient    *fakeworkclient.Clientset
+	addOnClient   *fakeaddonclient.Clientset
+}
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name     string
+		cluster  *clusterv1.ManagedCluster
+		hooks    []runtime.Object
+		works    []runtime.Object
+		addons   []runtime.Object
+		validate func(t *testing.T, clients fakeClients)
+	}{
+		{
+			name:    "add finalizer",
+			cluster: newCluster(false, DecommissionModeDestroy, false),
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.clusterClient.Actions(), "patch")
+				cluster := patchedCluster(t, clients.clusterClient.Actions()[0])
+				if !commonhelpers.HasFinalizer(cluster.Finalizers, commonhelpers.DecommissionFinalizer) {
+					t.Errorf("expected decommission finalizer")
+				}
+			},
+		},
+		{
+			name: "remove finalizer after the mode is removed",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newCluster(false, "", false)
+				cluster.Finalizers = []string{commonhelpers.DecommissionFinalizer}
+				return cluster
+			}(),
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.clusterClient.Actions(), "patch")
+				cluster := patchedCluster(t, clients.clusterClient.Actions()[0])
+				if len(cluster.Finalizers) != 0 {
+					t.Errorf("expected no finalizer, but got %v", cluster.Finalizers)
+				}
+			},
+		},
+		{
+			name: "dry run",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newCluster(false, DecommissionModeDestroy, false)
+				cluster.Finalizers = []string{commonhelpers.DecommissionFinalizer}
+				cluster.Annotations[DecommissionDryRunAnnotationKey] = "true"
+				return cluster
+			}(),
+			hooks: []runtime.Object{newHook("backup", false)},
+			works: []runtime.Object{
+				newWork("app", "db"),
+				newWork("db", ""),
+				newAddonWork("addon-deploy"),
+			},
+			addons: []runtime.Object{
+				testinghelpers.NewManagedClusterAddons("test", testinghelpers.TestManagedClusterName, nil, nil),
+			},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.clusterClient.Actions(), "patch")
+				cluster := patchedCluster(t, clients.clusterClient.Actions()[0])
+				testingcommon.AssertCondition(t, cluster.Status.Conditions, metav1.Condition{
+					Type:   ManagedClusterConditionDecommissionPlanned,
+					Status: metav1.ConditionTrue,
+					Reason: ReasonDecommissionDryRun,
+					Message: "Decommission in destroy mode:\n" +
+						"1. run pre-delete hooks backup\n" +
+						"2. delete the workloads of manifestworks app\n" +
+						"3. delete the workloads of manifestworks db\n" +
+						"4. delete addons test\n" +
+						"5. delete klusterlet klusterlet",
+				})
+				testingcommon.AssertNoActions(t, clients.workClient.Actions())
+				testingcommon.AssertNoActions(t, clients.addOnClient.Actions())
+			},
+		},
+		{
+			name:    "deleting without finalizer",
+			cluster: newCluster(true, DecommissionModeDestroy, true),
+			works:   []runtime.Object{newWork("app", "")},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertNoActions(t, clients.clusterClient.Actions())
+				testingcommon.AssertNoActions(t, clients.workClient.Actions())
+			},
+		},
+		{
+			name:    "run pre-delete hook",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDestroy, true)),
+			hooks:   []runtime.Object{newHook("backup", false)},
+			works:   []runtime.Object{newWork("app", "")},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "create")
+				work := clients.workClient.Actions()[0].(clienttesting.CreateAction).GetObject().(*workv1.ManifestWork)
+				if work.Name != "decommission-hook-backup" || len(work.Spec.ManifestConfigs) != 1 ||
+					work.Spec.ManifestConfigs[0].ResourceIdentifier.Resource != "jobs" {
+					t.Errorf("unexpected hook work %v", work)
+				}
+				assertProgress(t, clients, ReasonPreDeleteHooksRunning, "running pre-delete hooks backup")
+			},
+		},
+		{
+			name:    "pre-delete hook completed",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDestroy, true)),
+			hooks:   []runtime.Object{newHook("backup", false)},
+			works:   []runtime.Object{newCompletedHookWork("backup"), newWork("app", "")},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "delete", "delete")
+				testingcommon.AssertDelete(t, clients.workClient.Actions()[0], "manifestworks",
+					testinghelpers.TestManagedClusterName, "decommission-hook-backup")
+				testingcommon.AssertDelete(t, clients.workClient.Actions()[1], "manifestworks",
+					testinghelpers.TestManagedClusterName, "app")
+				testingcommon.AssertActions(t, clients.kubeClient.Actions(), "update")
+				hook := clients.kubeClient.Actions()[0].(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap)
+				if _, ok := hook.Annotations[DecommissionHookCompletedAnnotationKey]; !ok {
+					t.Errorf("expected hook completed")
+				}
+				assertProgress(t, clients, ReasonWorkloadsDeleting,
+					"deleting the workloads of manifestworks app, 0 manifestworks remaining")
+			},
+		},
+		{
+			name:    "destroy workloads in reverse dependency order",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDestroy, true)),
+			hooks:   []runtime.Object{newHook("backup", true)},
+			works:   []runtime.Object{newWork("app", "db"), newWork("db", ""), newAddonWork("addon-deploy")},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "delete")
+				testingcommon.AssertDelete(t, clients.workClient.Actions()[0], "manifestworks",
+					testinghelpers.TestManagedClusterName, "app")
+				assertProgress(t, clients, ReasonWorkloadsDeleting,
+					"deleting the workloads of manifestworks app, 1 manifestworks remaining")
+			},
+		},
+		{
+			name:    "detach workloads",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDetach, true)),
+			works: []runtime.Object{
+				newWork("app", ""),
+				func() *workv1.ManifestWork {
+					work := newWork("db", "")
+					work.Generation = 2
+					work.Spec.DeleteOption = &workv1.DeleteOption{PropagationPolicy: workv1.DeletePropagationPolicyTypeOrphan}
+					work.Status.Conditions = []metav1.Condition{
+						{Type: workv1.WorkApplied, Status: metav1.ConditionTrue, ObservedGeneration: 2},
+					}
+					return work
+				}(),
+			},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "patch", "delete")
+				work := &workv1.ManifestWork{}
+				if err := json.Unmarshal(clients.workClient.Actions()[0].(clienttesting.PatchAction).GetPatch(), work); err != nil {
+					t.Fatal(err)
+				}
+				if work.Spec.DeleteOption.PropagationPolicy != workv1.DeletePropagationPolicyTypeOrphan {
+					t.Errorf("expected orphan delete option, but got %v", work.Spec.DeleteOption)
+				}
+				testingcommon.AssertDelete(t, clients.workClient.Actions()[1], "manifestworks",
+					testinghelpers.TestManagedClusterName, "db")
+				assertProgress(t, clients, ReasonWorkloadsOrphaning,
+					"orphaning the workloads of manifestworks app; deleting manifestworks db")
+			},
+		},
+		{
+			name:    "force to orphan workloads after the timeout",
+			cluster: expired(withFinalizer(newCluster(true, DecommissionModeDetach, false))),
+			works: []runtime.Object{func() *workv1.ManifestWork {
+				work := newWork("app", "")
+				work.Finalizers = []string{workv1.ManifestWorkFinalizer}
+				return work
+			}()},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "delete", "patch")
+				testingcommon.AssertDelete(t, clients.workClient.Actions()[0], "manifestworks",
+					testinghelpers.TestManagedClusterName, "app")
+				work := &workv1.ManifestWork{}
+				if err := json.Unmarshal(clients.workClient.Actions()[1].(clienttesting.PatchAction).GetPatch(), work); err != nil {
+					t.Fatal(err)
+				}
+				if len(work.Finalizers) != 0 {
+					t.Errorf("expected the finalizer of the work removed, but got %v", work.Finalizers)
+				}
+			},
+		},
+		{
+			name:    "destroy workloads timed out",
+			cluster: expired(withFinalizer(newCluster(true, DecommissionModeDestroy, false))),
+			works: []runtime.Object{func() *workv1.ManifestWork {
+				work := newWork("app", "")
+				work.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				return work
+			}()},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertNoActions(t, clients.workClient.Actions())
+				testingcommon.AssertActions(t, clients.clusterClient.Actions(), "patch")
+				cluster := patchedCluster(t, clients.clusterClient.Actions()[0])
+				testingcommon.AssertCondition(t, cluster.Status.Conditions, metav1.Condition{
+					Type:   ManagedClusterConditionDecommissioning,
+					Status: metav1.ConditionFalse,
+					Reason: ReasonDecommissionTimeout,
+					Message: "The decommission is not completed in 1h0m0s, " +
+						"deleting the workloads of manifestworks app, 0 manifestworks remaining",
+				})
+			},
+		},
+		{
+			name:    "delete addons",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDetach, true)),
+			works:   []runtime.Object{newAddonWork("addon-deploy")},
+			addons: []runtime.Object{
+				testinghelpers.NewManagedClusterAddons("test", testinghelpers.TestManagedClusterName, nil, nil),
+			},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.addOnClient.Actions(), "delete")
+				testingcommon.AssertNoActions(t, clients.workClient.Actions())
+				assertProgress(t, clients, ReasonAddonsDeleting, "deleting addons test")
+			},
+		},
+		{
+			name:    "apply klusterlet removal",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDestroy, true)),
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "create")
+				work := clients.workClient.Actions()[0].(clienttesting.CreateAction).GetObject().(*workv1.ManifestWork)
+				if work.Name != agentWorkName || !strings.Contains(string(work.Spec.Workload.Manifests[0].Raw), "Klusterlet") {
+					t.Errorf("unexpected agent work %v", work)
+				}
+				assertProgress(t, clients, ReasonAgentRemoving, "applying the removal of klusterlet klusterlet")
+			},
+		},
+		{
+			name: "apply the removal of klusterlet with custom name",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := withFinalizer(newCluster(true, DecommissionModeDestroy, true))
+				cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+					{Name: commonhelpers.KlusterletNameClusterClaimName, Value: "agent"},
+					{Name: commonhelpers.KlusterletInstallModeClusterClaimName, Value: "Singleton"},
+				}
+				return cluster
+			}(),
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "create")
+				work := clients.workClient.Actions()[0].(clienttesting.CreateAction).GetObject().(*workv1.ManifestWork)
+				if !strings.Contains(string(work.Spec.Workload.Manifests[0].Raw), `"name":"agent"`) ||
+					work.Spec.ManifestConfigs[0].ResourceIdentifier.Name != "agent" {
+					t.Errorf("unexpected agent work %v", work)
+				}
+				assertProgress(t, clients, ReasonAgentRemoving, "applying the removal of klusterlet agent")
+			},
+		},
+		{
+			name: "skip klusterlet in hosted mode",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := withFinalizer(newCluster(true, DecommissionModeDestroy, true))
+				cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+					{Name: commonhelpers.KlusterletInstallModeClusterClaimName, Value: "Hosted"},
+				}
+				return cluster
+			}(),
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertNoActions(t, clients.workClient.Actions())
+				actions := clients.clusterClient.Actions()
+				testingcommon.AssertActions(t, actions, "patch", "patch")
+				cluster := patchedCluster(t, actions[0])
+				testingcommon.AssertCondition(t, cluster.Status.Conditions, metav1.Condition{
+					Type:    ManagedClusterConditionDecommissioning,
+					Status:  metav1.ConditionFalse,
+					Reason:  ReasonDecommissionCompleted,
+					Message: "The cluster is decommissioned in destroy mode.",
+				})
+			},
+		},
+		{
+			name:    "delete klusterlet",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDestroy, true)),
+			works: []runtime.Object{func() *workv1.ManifestWork {
+				work := newWork(agentWorkName, "")
+				work.Status.Conditions = []metav1.Condition{{Type: workv1.WorkAvailable, Status: metav1.ConditionTrue}}
+				return work
+			}()},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "delete")
+				assertProgress(t, clients, ReasonAgentRemoving, "deleting klusterlet klusterlet")
+			},
+		},
+		{
+			name:    "agent is removed",
+			cluster: withFinalizer(newCluster(true, DecommissionModeDestroy, false)),
+			works: []runtime.Object{func() *workv1.ManifestWork {
+				work := newWork(agentWorkName, "")
+				work.Finalizers = []string{workv1.ManifestWorkFinalizer}
+				work.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				return work
+			}()},
+			validate: func(t *testing.T, clients fakeClients) {
+				testingcommon.AssertActions(t, clients.workClient.Actions(), "delete", "patch")
+				actions := clients.clusterClient.Actions()
+				testingcommon.AssertActions(t, actions, "patch", "patch")
+				cluster := patchedCluster(t, actions[0])
+				testingcommon.AssertCondition(t, cluster.Status.Conditions, metav1.Condition{
+					Type:    ManagedClusterConditionDecommissioning,
+					Status:  metav1.ConditionFalse,
+					Reason:  ReasonDecommissionCompleted,
+					Message: "The cluster is decommissioned in destroy mode.",
+				})
+				cluster = patchedCluster(t, actions[1])
+				if len(cluster.Finalizers) != 0 {
+					t.Errorf("expected decommission finalizer removed, but got %v", cluster.Finalizers)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clients := fakeClients{
+				kubeClient:    kubefake.NewClientset(c.hooks...),
+				clusterClient: fakeclusterclient.NewSimpleClientset(c.cluster),
+				workClient:    fakeworkclient.NewSimpleClientset(c.works...),
+				addOnClient:   fakeaddonclient.NewSimpleClientset(c.addons...),
+			}
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clients.clusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+			workInformerFactory := workinformers.NewSharedInformerFactory(clients.workClient, 10*time.Minute)
+			for _, work := range c.works {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(clients.kubeClient, 10*time.Minute)
+			for _, hook := range c.hooks {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(hook); err != nil {
+					t.Fatal(err)
+				}
+			}
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(clients.addOnClient, 10*time.Minute)
+			for _, addon := range c.addons {
+				if err := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(addon); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &decommissionController{
+				kubeClient:    clients.kubeClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterPatcher: patcher.NewPatcher[
+					*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+					clients.clusterClient.ClusterV1().ManagedClusters()),
+				workClient:    clients.workClient,
+				workLister:    workInformerFactory.Work().V1().ManifestWorks().Lister(),
+				hookLister:    kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				addOnClient:   clients.addOnClient,
+				addOnLister:   addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				hookTimeout:   10 * time.Minute,
+				timeout:       time.Hour,
+				eventRecorder: testingcommon.NewFakeSyncContext(t, "").Recorder(),
+			}
+			clients.kubeClient.ClearActions()
+			clients.clusterClient.ClearActions()
+			clients.workClient.ClearActions()
+			clients.addOnClient.ClearActions()
+
+			syncCtx := testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+			c.validate(t, clients)
+		})
+	}
+}
+
+func newCluster(deleting bool, mode string, available bool) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Annotations = map[string]string{}
+	if len(mode) > 0 {
+		cluster.Annotations[DecommissionModeAnnotationKey] = mode
+	}
+	if deleting {
+		cluster.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	}
+	if available {
+		cluster.Status.Conditions = []metav1.Condition{
+			{Type: clusterv1.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue},
+		}
+	}
+	return cluster
+}
+
+func expired(cluster *clusterv1.ManagedCluster) *clusterv1.ManagedCluster {
+	cluster.DeletionTimestamp = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	return cluster
+}
+
+func withFinalizer(cluster *clusterv1.ManagedCluster) *clusterv1.ManagedCluster {
+	cluster.Finalizers = append(cluster.Finalizers, commonhelpers.DecommissionFinalizer)
+	return cluster
+}
+
+func newHook(name string, completed bool) *corev1.ConfigMap {
+	hook := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testinghelpers.TestManagedClusterName,
+			Labels:    map[string]string{DecommissionHookLabelKey: DecommissionHookPreDelete},
+		},
+		Data: map[string]string{DecommissionHookDataKey: testHookSpec},
+	}
+	if completed {
+		hook.Annotations = map[string]string{DecommissionHookCompletedAnnotationKey: "2024-01-01T00:00:00Z"}
+	}
+	return hook
+}
+
+func newWork(name, dependsOn string) *workv1.ManifestWork {
+	var annotations map[string]string
+	if len(dependsOn) > 0 {
+		annotations = map[string]string{DecommissionDependsOnAnnotationKey: dependsOn}
+	}
+	return testinghelpers.NewManifestWork(testinghelpers.TestManagedClusterName, name, nil, nil, annotations, nil)
+}
+
+func newAddonWork(name string) *workv1.ManifestWork {
+	return testinghelpers.NewManifestWork(testinghelpers.TestManagedClusterName, name, nil,
+		map[string]string{addonv1alpha1.AddonLabelKey: "test"}, nil, nil)
+}
+
+func newCompletedHookWork(name string) *workv1.ManifestWork {
+	work := testinghelpers.NewManifestWork(testinghelpers.TestManagedClusterName, hookWorkNamePrefix+name, nil,
+		map[string]string{DecommissionHookLabelKey: DecommissionHookPreDelete}, nil, nil)
+	work.CreationTimestamp = metav1.Now()
+	work.Status.Conditions = []metav1.Condition{{Type: workv1.WorkAvailable, Status: metav1.ConditionTrue}}
+	work.Status.ResourceStatus.Manifests = []workv1.ManifestCondition{
+		{
+			ResourceMeta: workv1.ManifestResourceMeta{Group: "batch", Kind: "Job", Name: "backup", Namespace: "default"},
+			StatusFeedbacks: workv1.StatusFeedbackResult{
+				Values: []workv1.FeedbackValue{
+					{Name: "JobComplete", Value: workv1.FieldValue{Type: workv1.String, String: ptr.To("True")}},
+				},
+			},
+		},
+	}
+	return work
+}
+
+func patchedCluster(t *testing.T, action clienttesting.Action) *clusterv1.ManagedCluster {
+	cluster := &clusterv1.ManagedCluster{}
+	if err := json.Unmarshal(action.(clienttesting.PatchAction).GetPatch(), cluster); err != nil {
+		t.Fatal(err)
+	}
+	return cluster
+}
+
+func assertProgress(t *testing.T, clients fakeClients, reason, message string) {
+	actions := clients.clusterClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	cluster := patchedCluster(t, actions[0])
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionDecommissioning)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != reason ||
+		condition.Message != message {
+		t.Errorf("expected progress %s %q, but got %v", reason, message, condition)
+	}
+}
@@ -0,0 +1,3 @@
+// Package decommission contains the hub-side reconciler to decommission a deleting cluster in the detach or destroy
+// mode before the resources in the cluster ns are cleaned up.
+package decommission
@@ -0,0 +1,133 @@
+package decommission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+// isWorkload returns true if the work is a workload on the cluster, the works of the addons, the hooks and the agent
+// are decommissioned in their own phases.
+func isWorkload(work *workv1.ManifestWork) bool {
+	if _, ok := work.Labels[addonv1alpha1.AddonLabelKey]; ok {
+		return false
+	}
+	if _, ok := work.Labels[DecommissionHookLabelKey]; ok {
+		return false
+	}
+	return work.Name != agentWorkName
+}
+
+// dependencies returns the names of the works the work depends on.
+func dependencies(work *workv1.ManifestWork) sets.Set[string] {
+	deps := sets.New[string]()
+	for _, dep := range strings.Split(work.Annotations[DecommissionDependsOnAnnotationKey], ",") {
+		if dep = strings.TrimSpace(dep); len(dep) > 0 && dep != work.Name {
+			deps.Insert(dep)
+		}
+	}
+	return deps
+}
+
+// deletionWaves orders the works in the reverse dependency order, the works in a wave are deleted after all the works
+// depending on them in the previous waves are deleted. The works in a dependency cycle are put in the last wave.
+func deletionWaves(works []*workv1.ManifestWork) [][]*workv1.ManifestWork {
+	remaining := map[string]*workv1.ManifestWork{}
+	for _, work := range works {
+		remaining[work.Name] = work
+	}
+
+	var waves [][]*workv1.ManifestWork
+	for len(remaining) > 0 {
+		dependedOn := sets.New[string]()
+		for _, work := range remaining {
+			dependedOn = dependedOn.Union(dependencies(work))
+		}
+
+		var wave []*workv1.ManifestWork
+		for name, work := range remaining {
+			if !dependedOn.Has(name) {
+				wave = append(wave, work)
+			}
+		}
+		if len(wave) == 0 {
+			for _, work := range remaining {
+				wave = append(wave, work)
+			}
+		}
+		sort.Slice(wave, func(i, j int) bool { return wave[i].Name < wave[j].Name })
+		for _, work := range wave {
+			delete(remaining, work.Name)
+		}
+		waves = append(waves, wave)
+	}
+	return waves
+}
+
+// decommissionPlan lists the resources which will be removed by the decommission in order.
+func decommissionPlan(mode string, hooks []*corev1.ConfigMap, works []*workv1.ManifestWork,
+	addons []*addonv1alpha1.ManagedClusterAddOn, klusterletName string, hosted bool) string {
+	var steps []string
+
+	var hookNames []string
+	for _, hook := range hooks {
+		hookNames = append(hookNames, hook.Name)
+	}
+	if len(hookNames) > 0 {
+		sort.Strings(hookNames)
+		steps = append(steps, fmt.Sprintf("run pre-delete hooks %s", strings.Join(hookNames, ", ")))
+	}
+
+	var workloads []*workv1.ManifestWork
+	for _, work := range works {
+		if isWorkload(work) {
+			workloads = append(workloads, work)
+		}
+	}
+	if len(workloads) > 0 {
+		switch mode {
+		case DecommissionModeDetach:
+			var names []string
+			for _, work := range workloads {
+				names = append(names, work.Name)
+			}
+			sort.Strings(names)
+			steps = append(steps, fmt.Sprintf("orphan the workloads of manifestworks %s", strings.Join(names, ", ")))
+		case DecommissionModeDestroy:
+			for _, wave := range deletionWaves(workloads) {
+				var names []string
+				for _, work := range wave {
+					names = append(names, work.Name)
+				}
+				steps = append(steps, fmt.Sprintf("delete the workloads of manifestworks %s", strings.Join(names, ", ")))
+			}
+		}
+	}
+
+	var addonNames []string
+	for _, addon := range addons {
+		addonNames = append(addonNames, addon.Name)
+	}
+	if len(addonNames) > 0 {
+		sort.Strings(addonNames)
+		steps = append(steps, fmt.Sprintf("delete addons %s", strings.Join(addonNames, ", ")))
+	}
+
+	if hosted {
+		steps = append(steps, fmt.Sprintf("skip klusterlet %s in the hosted mode, it must be removed on the management cluster",
+			klusterletName))
+	} else {
+		steps = append(steps, fmt.Sprintf("delete klusterlet %s", klusterletName))
+	}
+
+	for i := range steps {
+		steps[i] = fmt.Sprintf("%d. %s", i+1, steps[i])
+	}
+	return fmt.Sprintf("Decommission in %s mode:\n%s", mode, strings.Join(steps, "\n"))
+}
@@ -0,0 +1,73 @@
+package decommission
+
+import (
+	"reflect"
+	"testing"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestDeletionWaves(t *testing.T) {
+	cases := []struct {
+		name          string
+		works         []*workv1.ManifestWork
+		expectedWaves [][]string
+	}{
+		{
+			name:          "no dependencies",
+			works:         []*workv1.ManifestWork{newWork("b", ""), newWork("a", "")},
+			expectedWaves: [][]string{{"a", "b"}},
+		},
+		{
+			name: "chain",
+			works: []*workv1.ManifestWork{
+				newWork("db", ""), newWork("app", "db,cache"), newWork("cache", "db"), newWork("web", "app"),
+			},
+			expectedWaves: [][]string{{"web"}, {"app"}, {"cache"}, {"db"}},
+		},
+		{
+			name:          "cycle",
+			works:         []*workv1.ManifestWork{newWork("a", "b"), newWork("b", "a"), newWork("c", "a")},
+			expectedWaves: [][]string{{"c"}, {"a", "b"}},
+		},
+		{
+			name:          "missing dependency",
+			works:         []*workv1.ManifestWork{newWork("a", "missing")},
+			expectedWaves: [][]string{{"a"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var actual [][]string
+			for _, wave := range deletionWaves(c.works) {
+				var names []string
+				for _, work := range wave {
+					names = append(names, work.Name)
+				}
+				actual = append(actual, names)
+			}
+			if !reflect.DeepEqual(actual, c.expectedWaves) {
+				t.Errorf("expected waves %v, but got %v", c.expectedWaves, actual)
+			}
+		})
+	}
+}
+
+func TestDecommissionPlan(t *testing.T) {
+	plan := decommissionPlan(DecommissionModeDetach, nil,
+		[]*workv1.ManifestWork{newWork("b", "a"), newWork("a", ""), newAddonWork("addon")}, nil, "klusterlet", false)
+	expected := "Decommission in detach mode:\n" +
+		"1. orphan the workloads of manifestworks a, b\n" +
+		"2. delete klusterlet klusterlet"
+	if plan != expected {
+		t.Errorf("expected plan %q, but got %q", expected, plan)
+	}
+
+	plan = decommissionPlan(DecommissionModeDestroy, nil, nil, nil, "hosted-klusterlet", true)
+	expected = "Decommission in destroy mode:\n" +
+		"1. skip klusterlet hosted-klusterlet in the hosted mode, it must be removed on the management cluster"
+	if plan != expected {
+		t.Errorf("expected plan %q, but got %q", expected, plan)
+	}
+}
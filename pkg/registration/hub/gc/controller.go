@@ -93,6 +93,10 @@ func (r *GCController) sync(ctx context.Context, controllerContext factory.SyncC
 			_, err = r.clusterPatcher.AddFinalizer(ctx, cluster, commonhelper.GcFinalizer)
 			return err
 		}
+		// wait until the decommission of the cluster completes.
+		if commonhelper.HasFinalizer(cluster.Finalizers, commonhelper.DecommissionFinalizer) {
+			return nil
+		}
 		copyCluster = cluster.DeepCopy()
 	}
 
@@ -114,6 +114,16 @@ func TestGController(t *testing.T) {
 
 			},
 		},
+		{
+			name: "cluster is decommissioning",
+			key:  testinghelpers.TestManagedClusterName,
+			cluster: testinghelpers.NewDeletingManagedClusterWithFinalizers(
+				[]string{commonhelpers.GcFinalizer, commonhelpers.DecommissionFinalizer}),
+			objs: []runtime.Object{newAddonMetadata(testinghelpers.TestManagedClusterName, "test", nil)},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, clusterActions)
+			},
+		},
 		{
 			name:    "cluster is gone with resources",
 			key:     "test",
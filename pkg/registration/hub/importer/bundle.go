@@ -0,0 +1,438 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	certificatesv1informers "k8s.io/client-go/informers/certificates/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	certificatesv1listers "k8s.io/client-go/listers/certificates/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/queue"
+	"open-cluster-management.io/ocm/pkg/operator/helpers/chart"
+)
+
+const (
+	// ImportBundleAnnotationKey is the annotation on the ManagedCluster to request an offline import bundle, the
+	// bundle is written into the secret <cluster>-import-bundle in the namespace of the hub.
+	ImportBundleAnnotationKey = "import.open-cluster-management.io/import-bundle"
+	// ImportBundleLabelKey is the label of the secrets managed by the import bundle controller.
+	ImportBundleLabelKey = "import.open-cluster-management.io/import-bundle"
+
+	// ImportBundleStateAnnotationKey is the annotation on the bundle secret for the state of the bundle.
+	ImportBundleStateAnnotationKey = "import.open-cluster-management.io/bundle-state"
+	// ImportBundleExpirationAnnotationKey is the annotation on the bundle secret for the time the token in the
+	// bundle expires.
+	ImportBundleExpirationAnnotationKey = "import.open-cluster-management.io/bundle-expiration"
+	// ImportBundleUsedAtAnnotationKey is the annotation on the bundle secret for the time the cluster joined with
+	// the bundle.
+	ImportBundleUsedAtAnnotationKey = "import.open-cluster-management.io/bundle-used-at"
+	// ImportBundleConfigHashAnnotationKey is the annotation on the bundle secret for the hash of the import config
+	// the bundle is generated with, the bundle is regenerated once the import config of the cluster changes.
+	ImportBundleConfigHashAnnotationKey = "import.open-cluster-management.io/bundle-config-hash"
+
+	// ImportBundleStatePending means the bundle is not used yet.
+	ImportBundleStatePending = "Pending"
+	// ImportBundleStateUsed means the cluster joined and the token in the bundle is revoked.
+	ImportBundleStateUsed = "Used"
+
+	// The keys of the bundle secret.
+	ImportBundleManifestsKey = "import.yaml"
+	ImportBundleSignatureKey = "import.yaml.sig"
+
+	// ImportBundleSignerConfigMapName is the configmap in the namespace of the hub publishing the PEM encoded public
+	// key to verify the bundles. The key is distributed to the managed clusters out of band instead of with the
+	// bundles, so a tampered bundle cannot carry the key it is verified with.
+	ImportBundleSignerConfigMapName = "import-bundle-signer"
+	// ImportBundlePublicKeyKey is the key of the public key in the signer configmap.
+	ImportBundlePublicKeyKey = "signer.pub"
+
+	importBundleSigningKeySecretName = "import-bundle-signing-key"
+	importBundleSigningKeyKey        = "signer.key"
+)
+
+// ImportBundleController generates the import bundle for the clusters which cannot be reached by the hub. The
+// bundle contains all the manifests to deploy the klusterlet rendered by the same renderers as the importer,
+// signed by the signing key of the hub. The bootstrap token in the bundle is bound to a token secret, and the
+// token secret is deleted to revoke the token once the token is used to request the client certificate of the
+// cluster, or the cluster has joined.
+type ImportBundleController struct {
+	kubeClient    kubernetes.Interface
+	clusterLister clusterlisterv1.ManagedClusterLister
+	secretLister  corev1listers.SecretNamespaceLister
+	csrLister     certificatesv1listers.CertificateSigningRequestLister
+	namespace     string
+	resolver      *ImportConfigResolver
+	renders       func(boundObject *authv1.BoundObjectReference) []KlusterletConfigRenderer
+	recorder      events.Recorder
+	// now is replaceable in unit tests.
+	now func() time.Time
+}
+
+// NewImportBundleController creates the import bundle controller. The renders func returns the renderers of the
+// bundle with the bootstrap token bound to the object. The clusters are requeued when the import configs in the
+// configmaps of the informer change.
+func NewImportBundleController(
+	kubeClient kubernetes.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	secretInformer corev1informers.SecretInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	csrInformer certificatesv1informers.CertificateSigningRequestInformer,
+	namespace string,
+	resolver *ImportConfigResolver,
+	renders func(boundObject *authv1.BoundObjectReference) []KlusterletConfigRenderer,
+	recorder events.Recorder) factory.Controller {
+	c := &ImportBundleController{
+		kubeClient:    kubeClient,
+		clusterLister: clusterInformer.Lister(),
+		secretLister:  secretInformer.Lister().Secrets(namespace),
+		csrLister:     csrInformer.Lister(),
+		namespace:     namespace,
+		resolver:      resolver,
+		renders:       renders,
+		recorder:      recorder,
+		now:           time.Now,
+	}
+
+	return factory.New().
+		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeysFunc(
+			queue.QueueKeyByLabel(v1.ClusterNameLabelKey),
+			queue.FileterByLabel(ImportBundleLabelKey),
+			secretInformer.Informer()).
+		WithInformersQueueKeysFunc(resolver.ClusterQueueKeysFunc(clusterInformer.Lister()), configMapInformer.Informer()).
+		WithInformersQueueKeysFunc(queue.QueueKeyByLabel(v1.ClusterNameLabelKey), csrInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ImportBundleController", recorder)
+}
+
+func (c *ImportBundleController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Reconciling import bundle", "clusterName", clusterName)
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	switch {
+	case errors.IsNotFound(err):
+		return c.removeBundle(ctx, clusterName)
+	case err != nil:
+		return err
+	}
+	if !cluster.DeletionTimestamp.IsZero() || cluster.Annotations[ImportBundleAnnotationKey] != "true" {
+		return c.removeBundle(ctx, clusterName)
+	}
+
+	bundle, err := c.secretLister.Get(bundleSecretName(clusterName))
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	// revoke the token once it is used or the cluster has joined, so the token in the bundle can only be used once.
+	used, err := c.tokenUsed(clusterName)
+	if err != nil {
+		return err
+	}
+	if used || meta.IsStatusConditionTrue(cluster.Status.Conditions, v1.ManagedClusterConditionJoined) {
+		if bundle == nil || bundle.Annotations[ImportBundleStateAnnotationKey] == ImportBundleStateUsed {
+			return nil
+		}
+		if err := c.deleteSecret(ctx, tokenSecretName(clusterName)); err != nil {
+			return err
+		}
+		used := bundle.DeepCopy()
+		used.Annotations[ImportBundleStateAnnotationKey] = ImportBundleStateUsed
+		used.Annotations[ImportBundleUsedAtAnnotationKey] = c.now().UTC().Format(time.RFC3339)
+		if _, err := c.kubeClient.CoreV1().Secrets(c.namespace).Update(ctx, used, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		syncCtx.Recorder().Eventf("ImportBundleUsed",
+			"the import bundle of cluster %s is used, the bootstrap token is revoked", clusterName)
+		return nil
+	}
+
+	importConfig, err := c.resolver.Resolve(cluster)
+	if err != nil {
+		return err
+	}
+	configHash, err := hashImportConfig(importConfig)
+	if err != nil {
+		return err
+	}
+
+	// regenerate the bundle when the token expires or the import config changes.
+	if bundle != nil && bundle.Annotations[ImportBundleConfigHashAnnotationKey] == configHash {
+		expiration, err := time.Parse(time.RFC3339, bundle.Annotations[ImportBundleExpirationAnnotationKey])
+		if err == nil && c.now().Before(expiration) {
+			syncCtx.Queue().AddAfter(clusterName, expiration.Sub(c.now()))
+			return nil
+		}
+	}
+
+	expiration, err := c.generateBundle(ctx, cluster, importConfig, configHash)
+	if err != nil {
+		return err
+	}
+	syncCtx.Recorder().Eventf("ImportBundleGenerated", "import bundle of cluster %s is generated", clusterName)
+	syncCtx.Queue().AddAfter(clusterName, expiration.Sub(c.now()))
+	return nil
+}
+
+func (c *ImportBundleController) generateBundle(ctx context.Context, cluster *v1.ManagedCluster,
+	importConfig *ImportConfig, configHash string) (time.Time, error) {
+	// revoke the token of the previous bundle and bind the new token to a new token secret.
+	if err := c.deleteSecret(ctx, tokenSecretName(cluster.Name)); err != nil {
+		return time.Time{}, err
+	}
+	tokenSecret, err := c.kubeClient.CoreV1().Secrets(c.namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tokenSecretName(cluster.Name),
+			Namespace: c.namespace,
+			Labels:    map[string]string{ImportBundleLabelKey: "true", v1.ClusterNameLabelKey: cluster.Name},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	issuedAt := c.now()
+	config, err := RenderKlusterletChartConfig(ctx, cluster, c.renders(&authv1.BoundObjectReference{
+		Kind:       "Secret",
+		APIVersion: "v1",
+		Name:       tokenSecret.Name,
+		UID:        tokenSecret.UID,
+	}))
+	if err != nil {
+		return time.Time{}, err
+	}
+	crdObjs, rawObjs, err := chart.RenderKlusterletChart(config, kluterletNamespace)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var manifests bytes.Buffer
+	for _, obj := range append(crdObjs, rawObjs...) { //nolint:gocritic
+		manifests.WriteString("---\n")
+		manifests.Write(bytes.TrimSpace(obj))
+		manifests.WriteString("\n")
+	}
+
+	key, err := c.signingKey(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	signature, err := signBundle(key, manifests.Bytes())
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiration := issuedAt.Add(importConfig.bootstrapTokenTTL())
+	required := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bundleSecretName(cluster.Name),
+			Namespace: c.namespace,
+			Labels:    map[string]string{ImportBundleLabelKey: "true", v1.ClusterNameLabelKey: cluster.Name},
+			Annotations: map[string]string{
+				ImportBundleStateAnnotationKey:      ImportBundleStatePending,
+				ImportBundleExpirationAnnotationKey: expiration.UTC().Format(time.RFC3339),
+				ImportBundleConfigHashAnnotationKey: configHash,
+			},
+		},
+		Data: map[string][]byte{
+			ImportBundleManifestsKey: manifests.Bytes(),
+			ImportBundleSignatureKey: signature,
+		},
+	}
+
+	existing, err := c.kubeClient.CoreV1().Secrets(c.namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = c.kubeClient.CoreV1().Secrets(c.namespace).Create(ctx, required, metav1.CreateOptions{})
+		return expiration, err
+	case err != nil:
+		return time.Time{}, err
+	}
+	existing = existing.DeepCopy()
+	existing.Labels = required.Labels
+	existing.Annotations = required.Annotations
+	existing.Data = required.Data
+	_, err = c.kubeClient.CoreV1().Secrets(c.namespace).Update(ctx, existing, metav1.UpdateOptions{})
+	return expiration, err
+}
+
+// hashImportConfig returns the hex encoded sha256 of the import config.
+func hashImportConfig(config *ImportConfig) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// tokenUsed returns true if a certificate signing request of the cluster is created after the token of the bundle
+// is issued, which means the token has been used by the agent to bootstrap.
+func (c *ImportBundleController) tokenUsed(clusterName string) (bool, error) {
+	tokenSecret, err := c.secretLister.Get(tokenSecretName(clusterName))
+	switch {
+	case errors.IsNotFound(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	csrs, err := c.csrLister.List(labels.SelectorFromSet(labels.Set{v1.ClusterNameLabelKey: clusterName}))
+	if err != nil {
+		return false, err
+	}
+	for _, csr := range csrs {
+		if !csr.CreationTimestamp.Before(&tokenSecret.CreationTimestamp) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *ImportBundleController) removeBundle(ctx context.Context, clusterName string) error {
+	if err := c.deleteSecret(ctx, tokenSecretName(clusterName)); err != nil {
+		return err
+	}
+	return c.deleteSecret(ctx, bundleSecretName(clusterName))
+}
+
+func (c *ImportBundleController) deleteSecret(ctx context.Context, name string) error {
+	err := c.kubeClient.CoreV1().Secrets(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// signingKey returns the key to sign the bundles and publishes its public key in the signer configmap, the key is
+// generated at the first time.
+func (c *ImportBundleController) signingKey(ctx context.Context) (*ecdsa.PrivateKey, error) {
+	key, err := c.getOrCreateSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := encodePublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), c.recorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ImportBundleSignerConfigMapName,
+			Namespace: c.namespace,
+		},
+		Data: map[string]string{ImportBundlePublicKeyKey: string(publicKey)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (c *ImportBundleController) getOrCreateSigningKey(ctx context.Context) (*ecdsa.PrivateKey, error) {
+	secret, err := c.kubeClient.CoreV1().Secrets(c.namespace).Get(ctx, importBundleSigningKeySecretName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		keyBytes, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := encodePublicKey(&key.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		_, err = c.kubeClient.CoreV1().Secrets(c.namespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      importBundleSigningKeySecretName,
+				Namespace: c.namespace,
+			},
+			Data: map[string][]byte{
+				importBundleSigningKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}),
+				ImportBundlePublicKeyKey:  publicKey,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	case err != nil:
+		return nil, err
+	}
+
+	block, _ := pem.Decode(secret.Data[importBundleSigningKeyKey])
+	if block == nil {
+		return nil, fmt.Errorf("invalid signing key in secret %s/%s", c.namespace, importBundleSigningKeySecretName)
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// VerifyImportBundle verifies the signature of the manifests in the import bundle with the PEM encoded public key,
+// which should be read from the signer configmap of the hub instead of the bundle.
+func VerifyImportBundle(manifests, signature, publicKeyPEM []byte) error {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return fmt.Errorf("invalid public key")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	digest := sha256.Sum256(manifests)
+	if !ecdsa.VerifyASN1(ecdsaKey, digest[:], signature) {
+		return fmt.Errorf("invalid signature of the import bundle")
+	}
+	return nil
+}
+
+func signBundle(key *ecdsa.PrivateKey, manifests []byte) ([]byte, error) {
+	digest := sha256.Sum256(manifests)
+	return ecdsa.SignASN1(rand.Reader, key, digest[:])
+}
+
+func encodePublicKey(key *ecdsa.PublicKey) ([]byte, error) {
+	keyBytes, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyBytes}), nil
+}
+
+func bundleSecretName(clusterName string) string {
+	return clusterName + "-import-bundle"
+}
+
+func tokenSecretName(clusterName string) string {
+	return clusterName + "-import-bundle-token"
+}
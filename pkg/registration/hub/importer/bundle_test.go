@@ -0,0 +1,323 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+const testBundleNamespace = "open-cluster-management-hub"
+
+func TestSyncImportBundle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	configHash, err := hashImportConfig(&ImportConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotated := func(joined bool) *clusterv1.ManagedCluster {
+		cluster := &clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "cluster1",
+				Annotations: map[string]string{ImportBundleAnnotationKey: "true"},
+			},
+		}
+		if joined {
+			cluster.Status.Conditions = []metav1.Condition{
+				{Type: clusterv1.ManagedClusterConditionJoined, Status: metav1.ConditionTrue},
+			}
+		}
+		return cluster
+	}
+	newSecret := func(name string, annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   testBundleNamespace,
+				Labels:      map[string]string{ImportBundleLabelKey: "true", clusterv1.ClusterNameLabelKey: "cluster1"},
+				Annotations: annotations,
+			},
+		}
+	}
+
+	newCSR := func(created time.Time) *certificatesv1.CertificateSigningRequest {
+		return &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "cluster1-csr",
+				Labels:            map[string]string{clusterv1.ClusterNameLabelKey: "cluster1"},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		}
+	}
+	newTokenSecret := func(created time.Time) *corev1.Secret {
+		secret := newSecret("cluster1-import-bundle-token", nil)
+		secret.CreationTimestamp = metav1.NewTime(created)
+		return secret
+	}
+
+	cases := []struct {
+		name     string
+		cluster  *clusterv1.ManagedCluster
+		secrets  []runtime.Object
+		csrs     []*certificatesv1.CertificateSigningRequest
+		validate func(t *testing.T, client *kubefake.Clientset)
+	}{
+		{
+			name:    "bundle is not requested",
+			cluster: &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}},
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", nil),
+				newSecret("cluster1-import-bundle-token", nil),
+			},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				testingcommon.AssertActions(t, client.Actions(), "delete", "delete")
+				testingcommon.AssertDelete(t, client.Actions()[0], "secrets", testBundleNamespace, "cluster1-import-bundle-token")
+				testingcommon.AssertDelete(t, client.Actions()[1], "secrets", testBundleNamespace, "cluster1-import-bundle")
+			},
+		},
+		{
+			name:    "generate bundle",
+			cluster: annotated(false),
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				var boundObject *authenticationv1.BoundObjectReference
+				for _, action := range client.Actions() {
+					if action.GetVerb() == "create" && action.GetSubresource() == "token" {
+						boundObject = action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenRequest).Spec.BoundObjectRef
+					}
+				}
+				if boundObject == nil || boundObject.Kind != "Secret" || boundObject.Name != "cluster1-import-bundle-token" {
+					t.Errorf("expected token bound to the token secret, but got %v", boundObject)
+				}
+
+				bundle, err := client.CoreV1().Secrets(testBundleNamespace).Get(
+					context.TODO(), "cluster1-import-bundle", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if bundle.Annotations[ImportBundleStateAnnotationKey] != ImportBundleStatePending {
+					t.Errorf("expected bundle pending, but got %v", bundle.Annotations)
+				}
+				if bundle.Annotations[ImportBundleExpirationAnnotationKey] != now.Add(24*time.Hour).Format(time.RFC3339) {
+					t.Errorf("unexpected expiration %v", bundle.Annotations)
+				}
+				manifests := bundle.Data[ImportBundleManifestsKey]
+				if !strings.Contains(string(manifests), "kind: Klusterlet") {
+					t.Errorf("expected klusterlet in the bundle")
+				}
+				if _, ok := bundle.Data[ImportBundlePublicKeyKey]; ok {
+					t.Errorf("expected no public key in the bundle")
+				}
+				signer, err := client.CoreV1().ConfigMaps(testBundleNamespace).Get(
+					context.TODO(), ImportBundleSignerConfigMapName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				publicKey := []byte(signer.Data[ImportBundlePublicKeyKey])
+				if err := VerifyImportBundle(manifests, bundle.Data[ImportBundleSignatureKey], publicKey); err != nil {
+					t.Errorf("failed to verify bundle: %v", err)
+				}
+				if err := VerifyImportBundle(append(manifests, '\n'), bundle.Data[ImportBundleSignatureKey], publicKey); err == nil {
+					t.Errorf("expected tampered bundle failed to verify")
+				}
+				if _, err := client.CoreV1().Secrets(testBundleNamespace).Get(
+					context.TODO(), importBundleSigningKeySecretName, metav1.GetOptions{}); err != nil {
+					t.Errorf("expected signing key created: %v", err)
+				}
+			},
+		},
+		{
+			name:    "bundle is not expired",
+			cluster: annotated(false),
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", map[string]string{
+					ImportBundleStateAnnotationKey:      ImportBundleStatePending,
+					ImportBundleExpirationAnnotationKey: now.Add(time.Hour).Format(time.RFC3339),
+					ImportBundleConfigHashAnnotationKey: configHash,
+				}),
+			},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				testingcommon.AssertNoActions(t, client.Actions())
+			},
+		},
+		{
+			name:    "import config is changed",
+			cluster: annotated(false),
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", map[string]string{
+					ImportBundleStateAnnotationKey:      ImportBundleStatePending,
+					ImportBundleExpirationAnnotationKey: now.Add(time.Hour).Format(time.RFC3339),
+					ImportBundleConfigHashAnnotationKey: "changed",
+				}),
+			},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				bundle, err := client.CoreV1().Secrets(testBundleNamespace).Get(
+					context.TODO(), "cluster1-import-bundle", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if bundle.Annotations[ImportBundleConfigHashAnnotationKey] != configHash {
+					t.Errorf("expected bundle regenerated, but got %v", bundle.Annotations)
+				}
+			},
+		},
+		{
+			name:    "bundle is expired",
+			cluster: annotated(false),
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", map[string]string{
+					ImportBundleStateAnnotationKey:      ImportBundleStatePending,
+					ImportBundleExpirationAnnotationKey: now.Add(-time.Hour).Format(time.RFC3339),
+				}),
+				newSecret("cluster1-import-bundle-token", nil),
+			},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				testingcommon.AssertDelete(t, client.Actions()[0], "secrets", testBundleNamespace, "cluster1-import-bundle-token")
+				bundle, err := client.CoreV1().Secrets(testBundleNamespace).Get(
+					context.TODO(), "cluster1-import-bundle", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if bundle.Annotations[ImportBundleExpirationAnnotationKey] != now.Add(24*time.Hour).Format(time.RFC3339) {
+					t.Errorf("expected bundle regenerated, but got %v", bundle.Annotations)
+				}
+			},
+		},
+		{
+			name:    "cluster joined",
+			cluster: annotated(true),
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", map[string]string{
+					ImportBundleStateAnnotationKey:      ImportBundleStatePending,
+					ImportBundleExpirationAnnotationKey: now.Add(time.Hour).Format(time.RFC3339),
+				}),
+				newSecret("cluster1-import-bundle-token", nil),
+			},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				testingcommon.AssertActions(t, client.Actions(), "delete", "update")
+				testingcommon.AssertDelete(t, client.Actions()[0], "secrets", testBundleNamespace, "cluster1-import-bundle-token")
+				bundle := client.Actions()[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+				if bundle.Annotations[ImportBundleStateAnnotationKey] != ImportBundleStateUsed {
+					t.Errorf("expected bundle used, but got %v", bundle.Annotations)
+				}
+				if bundle.Annotations[ImportBundleUsedAtAnnotationKey] != now.Format(time.RFC3339) {
+					t.Errorf("unexpected used time %v", bundle.Annotations)
+				}
+			},
+		},
+		{
+			name:    "token is used",
+			cluster: annotated(false),
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", map[string]string{
+					ImportBundleStateAnnotationKey:      ImportBundleStatePending,
+					ImportBundleExpirationAnnotationKey: now.Add(time.Hour).Format(time.RFC3339),
+					ImportBundleConfigHashAnnotationKey: configHash,
+				}),
+				newTokenSecret(now.Add(-time.Hour)),
+			},
+			csrs: []*certificatesv1.CertificateSigningRequest{newCSR(now)},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				testingcommon.AssertActions(t, client.Actions(), "delete", "update")
+				testingcommon.AssertDelete(t, client.Actions()[0], "secrets", testBundleNamespace, "cluster1-import-bundle-token")
+				bundle := client.Actions()[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+				if bundle.Annotations[ImportBundleStateAnnotationKey] != ImportBundleStateUsed {
+					t.Errorf("expected bundle used, but got %v", bundle.Annotations)
+				}
+			},
+		},
+		{
+			name:    "csr is created before the token",
+			cluster: annotated(false),
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", map[string]string{
+					ImportBundleStateAnnotationKey:      ImportBundleStatePending,
+					ImportBundleExpirationAnnotationKey: now.Add(time.Hour).Format(time.RFC3339),
+					ImportBundleConfigHashAnnotationKey: configHash,
+				}),
+				newTokenSecret(now),
+			},
+			csrs: []*certificatesv1.CertificateSigningRequest{newCSR(now.Add(-time.Hour))},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				testingcommon.AssertNoActions(t, client.Actions())
+			},
+		},
+		{
+			name:    "bundle is used",
+			cluster: annotated(true),
+			secrets: []runtime.Object{
+				newSecret("cluster1-import-bundle", map[string]string{
+					ImportBundleStateAnnotationKey: ImportBundleStateUsed,
+				}),
+			},
+			validate: func(t *testing.T, client *kubefake.Clientset) {
+				testingcommon.AssertNoActions(t, client.Actions())
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewClientset(c.secrets...)
+			kubeClient.PrependReactor("create", "serviceaccounts/token",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					tokenReq := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+					tokenReq.Status.Token = "token"
+					return true, tokenReq, nil
+				},
+			)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, secret := range c.secrets {
+				if err := kubeInformerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, csr := range c.csrs {
+				if err := kubeInformerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			clusterClient := fakeclusterclient.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &ImportBundleController{
+				kubeClient:    kubeClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				secretLister:  kubeInformerFactory.Core().V1().Secrets().Lister().Secrets(testBundleNamespace),
+				csrLister:     kubeInformerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				namespace:     testBundleNamespace,
+				renders: func(boundObject *authenticationv1.BoundObjectReference) []KlusterletConfigRenderer {
+					return []KlusterletConfigRenderer{
+						RenderBoundBootstrapHubKubeConfig(kubeClient, "https://hub:6443",
+							"open-cluster-management/bootstrap-sa", nil, boundObject),
+					}
+				},
+				recorder: eventstesting.NewTestingEventRecorder(t),
+				now:      func() time.Time { return now },
+			}
+			kubeClient.ClearActions()
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "cluster1")); err != nil {
+				t.Fatal(err)
+			}
+			c.validate(t, kubeClient)
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/cert"
+
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	operatorv1 "open-cluster-management.io/api/operator/v1"
+)
+
+const (
+	// ImportConfigLabelKey is the label of the configmaps holding the import configs, the value must be "true".
+	ImportConfigLabelKey = "import.open-cluster-management.io/import-config"
+	// ImportConfigLabelSelector selects the configmaps holding the import configs.
+	ImportConfigLabelSelector = ImportConfigLabelKey + "=true"
+	// ImportConfigAnnotationKey is the annotation on the ManagedCluster to use the import config in the configmap
+	// with the name of the value. It takes precedence over the import configs selecting the cluster by labels.
+	ImportConfigAnnotationKey = "import.open-cluster-management.io/import-config"
+	// ImportConfigDataKey is the data key of the import config in the configmap.
+	ImportConfigDataKey = "config"
+
+	minBootstrapTokenTTL     = 10 * time.Minute
+	defaultBootstrapTokenTTL = 24 * time.Hour
+)
+
+// ImportConfig is the configuration to import the clusters. The fields which are set override the values of the
+// global import options.
+type ImportConfig struct {
+	// ClusterSelector selects the clusters the config applies to. The config applies to no cluster by labels if
+	// it is not set, and can only be used by the annotation on the ManagedCluster.
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// Priority decides the precedence of the configs selecting the same cluster, the config with higher priority
+	// overrides the fields set by the config with lower priority. The configs with the same priority are merged in
+	// the order of their names.
+	Priority int32 `json:"priority,omitempty"`
+
+	// HubAPIServerURL is the url of the hub apiserver which is accessible from the managed cluster.
+	HubAPIServerURL string `json:"hubAPIServerURL,omitempty"`
+	// HubCABundle is the PEM encoded CA bundle to verify the hub apiserver.
+	HubCABundle string `json:"hubCABundle,omitempty"`
+	// ProxyURL is the url of the proxy used by the agent to connect to the hub apiserver.
+	ProxyURL string `json:"proxyURL,omitempty"`
+	// ProxyCABundle is the PEM encoded CA bundle of the proxy, it is appended to the CA bundle of the hub.
+	ProxyCABundle string `json:"proxyCABundle,omitempty"`
+	// BootstrapTokenTTL is the lifetime of the bootstrap token, the default is 24h.
+	BootstrapTokenTTL *metav1.Duration `json:"bootstrapTokenTTL,omitempty"`
+
+	// RegistryMirrors replaces the registry of the agent images.
+	RegistryMirrors []RegistryMirror `json:"registryMirrors,omitempty"`
+	// NodePlacement is the node placement of the agent.
+	NodePlacement *operatorv1.NodePlacement `json:"nodePlacement,omitempty"`
+	// InstallMode is the install mode of the klusterlet, only Default and Singleton are supported.
+	InstallMode operatorv1.InstallMode `json:"installMode,omitempty"`
+	// RegistrationFeatureGates are the feature gates of the registration agent.
+	RegistrationFeatureGates []operatorv1.FeatureGate `json:"registrationFeatureGates,omitempty"`
+	// WorkFeatureGates are the feature gates of the work agent.
+	WorkFeatureGates []operatorv1.FeatureGate `json:"workFeatureGates,omitempty"`
+}
+
+// RegistryMirror replaces the Source prefix of an image with the Mirror.
+type RegistryMirror struct {
+	Source string `json:"source"`
+	Mirror string `json:"mirror"`
+}
+
+// ImportConfigResolver resolves the import config of a cluster from the import config configmaps.
+type ImportConfigResolver struct {
+	configMapLister corev1listers.ConfigMapNamespaceLister
+	recorder        events.Recorder
+
+	// reported is the resource version of the invalid import configs which are reported, so each invalid version
+	// of a config is only reported once.
+	reportedLock sync.Mutex
+	reported     map[string]string
+}
+
+// NewImportConfigResolver returns an ImportConfigResolver reading the configmaps in the namespace of the lister.
+// The invalid import configs are reported with warning events of the recorder.
+func NewImportConfigResolver(configMapLister corev1listers.ConfigMapNamespaceLister,
+	recorder events.Recorder) *ImportConfigResolver {
+	return &ImportConfigResolver{
+		configMapLister: configMapLister,
+		recorder:        recorder,
+		reported:        map[string]string{},
+	}
+}
+
+type namedImportConfig struct {
+	name   string
+	config *ImportConfig
+}
+
+// Resolve merges the import configs of the cluster by precedence, the config referred by the annotation of the
+// cluster takes the highest precedence. The invalid configs selecting the cluster by labels are skipped, while an
+// invalid config referred by the annotation fails the resolving. It returns an empty config if the resolver is nil.
+func (r *ImportConfigResolver) Resolve(cluster *v1.ManagedCluster) (*ImportConfig, error) {
+	resolved := &ImportConfig{}
+	if r == nil || cluster == nil {
+		return resolved, nil
+	}
+
+	configMaps, err := r.configMapLister.List(labels.SelectorFromSet(labels.Set{ImportConfigLabelKey: "true"}))
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []namedImportConfig
+	for _, cm := range configMaps {
+		config, selector, err := r.parse(cm)
+		if err != nil || selector == nil {
+			continue
+		}
+		if selector.Matches(labels.Set(cluster.Labels)) {
+			selected = append(selected, namedImportConfig{name: cm.Name, config: config})
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].config.Priority != selected[j].config.Priority {
+			return selected[i].config.Priority < selected[j].config.Priority
+		}
+		return selected[i].name < selected[j].name
+	})
+
+	if name := cluster.Annotations[ImportConfigAnnotationKey]; len(name) > 0 {
+		cm, err := r.configMapLister.Get(name)
+		switch {
+		case errors.IsNotFound(err):
+			return nil, fmt.Errorf("import config %s of the cluster is not found", name)
+		case err != nil:
+			return nil, err
+		}
+		config, _, err := r.parse(cm)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, namedImportConfig{name: name, config: config})
+	}
+
+	for _, s := range selected {
+		mergeImportConfig(resolved, s.config)
+	}
+	return resolved, nil
+}
+
+// ClusterQueueKeysFunc returns the names of the clusters affected by the change of an import config configmap,
+// which are the clusters selected by the config or referring the config by the annotation. All the clusters are
+// returned if the config is invalid, since the clusters it selected before are unknown.
+func (r *ImportConfigResolver) ClusterQueueKeysFunc(clusterLister clusterlisterv1.ManagedClusterLister) factory.ObjectQueueKeysFunc {
+	return func(obj runtime.Object) []string {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return nil
+		}
+		clusters, err := clusterLister.List(labels.Everything())
+		if err != nil {
+			return nil
+		}
+
+		_, selector, err := r.parse(cm)
+		keys := sets.New[string]()
+		for _, cluster := range clusters {
+			switch {
+			case err != nil:
+				keys.Insert(cluster.Name)
+			case cluster.Annotations[ImportConfigAnnotationKey] == cm.Name:
+				keys.Insert(cluster.Name)
+			case selector != nil && selector.Matches(labels.Set(cluster.Labels)):
+				keys.Insert(cluster.Name)
+			}
+		}
+		return sets.List(keys)
+	}
+}
+
+// parse returns the import config and the cluster selector in the configmap, the selector is nil if the config
+// does not select clusters by labels. An invalid config is reported with a warning event.
+func (r *ImportConfigResolver) parse(cm *corev1.ConfigMap) (*ImportConfig, labels.Selector, error) {
+	config, err := parseImportConfig(cm.Data[ImportConfigDataKey])
+	if err != nil {
+		err = fmt.Errorf("invalid import config %s: %v", cm.Name, err)
+		r.report(cm, err)
+		return nil, nil, err
+	}
+	if config.ClusterSelector == nil {
+		return config, nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(config.ClusterSelector)
+	if err != nil {
+		err = fmt.Errorf("invalid cluster selector of import config %s: %v", cm.Name, err)
+		r.report(cm, err)
+		return nil, nil, err
+	}
+	return config, selector, nil
+}
+
+func (r *ImportConfigResolver) report(cm *corev1.ConfigMap, err error) {
+	if r.recorder == nil {
+		return
+	}
+	r.reportedLock.Lock()
+	defer r.reportedLock.Unlock()
+	if r.reported[cm.Name] == cm.ResourceVersion {
+		return
+	}
+	r.reported[cm.Name] = cm.ResourceVersion
+	r.recorder.Warningf("ImportConfigInvalid", "%v", err)
+}
+
+// bootstrapTokenTTL returns the lifetime of the bootstrap token, it is 24h if not set in the config.
+func (c *ImportConfig) bootstrapTokenTTL() time.Duration {
+	if c.BootstrapTokenTTL == nil {
+		return defaultBootstrapTokenTTL
+	}
+	return c.BootstrapTokenTTL.Duration
+}
+
+func parseImportConfig(data string) (*ImportConfig, error) {
+	config := &ImportConfig{}
+	if err := yaml.Unmarshal([]byte(data), config); err != nil {
+		return nil, err
+	}
+	if err := validateImportConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func validateImportConfig(config *ImportConfig) error {
+	var errs []error
+	if len(config.HubAPIServerURL) > 0 {
+		u, err := url.Parse(config.HubAPIServerURL)
+		if err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+			errs = append(errs, fmt.Errorf("hubAPIServerURL %q must be a https url", config.HubAPIServerURL))
+		}
+	}
+	if len(config.ProxyURL) > 0 {
+		u, err := url.Parse(config.ProxyURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			errs = append(errs, fmt.Errorf("proxyURL %q must be a http or https url", config.ProxyURL))
+		}
+	}
+	if len(config.HubCABundle) > 0 {
+		if _, err := cert.ParseCertsPEM([]byte(config.HubCABundle)); err != nil {
+			errs = append(errs, fmt.Errorf("invalid hubCABundle: %v", err))
+		}
+	}
+	if len(config.ProxyCABundle) > 0 {
+		if _, err := cert.ParseCertsPEM([]byte(config.ProxyCABundle)); err != nil {
+			errs = append(errs, fmt.Errorf("invalid proxyCABundle: %v", err))
+		}
+	}
+	if config.BootstrapTokenTTL != nil && config.BootstrapTokenTTL.Duration < minBootstrapTokenTTL {
+		errs = append(errs, fmt.Errorf("bootstrapTokenTTL must be at least %s", minBootstrapTokenTTL))
+	}
+	for _, mirror := range config.RegistryMirrors {
+		if len(mirror.Source) == 0 || len(mirror.Mirror) == 0 {
+			errs = append(errs, fmt.Errorf("source and mirror are required in registryMirrors"))
+		}
+	}
+	switch config.InstallMode {
+	case "", operatorv1.InstallModeDefault, operatorv1.InstallModeSingleton:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported installMode %s", config.InstallMode))
+	}
+	for _, featureGate := range slices.Concat(config.RegistrationFeatureGates, config.WorkFeatureGates) {
+		if len(featureGate.Feature) == 0 {
+			errs = append(errs, fmt.Errorf("feature is required in feature gates"))
+		}
+		if featureGate.Mode != operatorv1.FeatureGateModeTypeEnable && featureGate.Mode != operatorv1.FeatureGateModeTypeDisable {
+			errs = append(errs, fmt.Errorf("unsupported mode %q of feature gate %s", featureGate.Mode, featureGate.Feature))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// mergeImportConfig overrides the fields of the resolved config with the fields set in the config.
+func mergeImportConfig(resolved, config *ImportConfig) {
+	if len(config.HubAPIServerURL) > 0 {
+		resolved.HubAPIServerURL = config.HubAPIServerURL
+	}
+	if len(config.HubCABundle) > 0 {
+		resolved.HubCABundle = config.HubCABundle
+	}
+	if len(config.ProxyURL) > 0 {
+		resolved.ProxyURL = config.ProxyURL
+	}
+	if len(config.ProxyCABundle) > 0 {
+		resolved.ProxyCABundle = config.ProxyCABundle
+	}
+	if config.BootstrapTokenTTL != nil {
+		resolved.BootstrapTokenTTL = config.BootstrapTokenTTL
+	}
+	if config.NodePlacement != nil {
+		resolved.NodePlacement = config.NodePlacement
+	}
+	if len(config.InstallMode) > 0 {
+		resolved.InstallMode = config.InstallMode
+	}
+	for _, mirror := range config.RegistryMirrors {
+		resolved.RegistryMirrors = mergeRegistryMirror(resolved.RegistryMirrors, mirror)
+	}
+	for _, featureGate := range config.RegistrationFeatureGates {
+		resolved.RegistrationFeatureGates = mergeFeatureGate(resolved.RegistrationFeatureGates, featureGate)
+	}
+	for _, featureGate := range config.WorkFeatureGates {
+		resolved.WorkFeatureGates = mergeFeatureGate(resolved.WorkFeatureGates, featureGate)
+	}
+}
+
+func mergeRegistryMirror(mirrors []RegistryMirror, mirror RegistryMirror) []RegistryMirror {
+	for i := range mirrors {
+		if mirrors[i].Source == mirror.Source {
+			mirrors[i] = mirror
+			return mirrors
+		}
+	}
+	return append(mirrors, mirror)
+}
+
+func mergeFeatureGate(featureGates []operatorv1.FeatureGate, featureGate operatorv1.FeatureGate) []operatorv1.FeatureGate {
+	for i := range featureGates {
+		if featureGates[i].Feature == featureGate.Feature {
+			featureGates[i] = featureGate
+			return featureGates
+		}
+	}
+	return append(featureGates, featureGate)
+}
+
+// mirrorImage replaces the registry of the image with the mirror of the longest matched source.
+func mirrorImage(image string, mirrors []RegistryMirror) string {
+	matched := -1
+	for i, mirror := range mirrors {
+		if strings.HasPrefix(image, mirror.Source) && (matched < 0 || len(mirror.Source) > len(mirrors[matched].Source)) {
+			matched = i
+		}
+	}
+	if matched < 0 {
+		return image
+	}
+	return mirrors[matched].Mirror + strings.TrimPrefix(image, mirrors[matched].Source)
+}
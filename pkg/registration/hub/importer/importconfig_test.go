@@ -0,0 +1,377 @@
+package importer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/cert"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	operatorv1 "open-cluster-management.io/api/operator/v1"
+
+	"open-cluster-management.io/ocm/pkg/operator/helpers/chart"
+)
+
+func newImportConfigMap(name, config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "open-cluster-management",
+			Labels:    map[string]string{ImportConfigLabelKey: "true"},
+		},
+		Data: map[string]string{ImportConfigDataKey: config},
+	}
+}
+
+func newImportConfigResolver(t *testing.T, configMaps ...*corev1.ConfigMap) *ImportConfigResolver {
+	informerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewClientset(), 10*time.Minute)
+	for _, cm := range configMaps {
+		if err := informerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(cm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewImportConfigResolver(informerFactory.Core().V1().ConfigMaps().Lister().ConfigMaps("open-cluster-management"),
+		eventstesting.NewTestingEventRecorder(t))
+}
+
+func TestResolveImportConfig(t *testing.T) {
+	cases := []struct {
+		name           string
+		configMaps     []*corev1.ConfigMap
+		cluster        *clusterv1.ManagedCluster
+		expectedErr    string
+		validateConfig func(t *testing.T, config *ImportConfig)
+	}{
+		{
+			name:    "no import config",
+			cluster: &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}},
+			validateConfig: func(t *testing.T, config *ImportConfig) {
+				if len(config.HubAPIServerURL) != 0 || len(config.InstallMode) != 0 {
+					t.Errorf("expect empty config, got %v", config)
+				}
+			},
+		},
+		{
+			name: "merge by priority and annotation",
+			configMaps: []*corev1.ConfigMap{
+				newImportConfigMap("region", `
+clusterSelector:
+  matchLabels:
+    region: east
+priority: 10
+hubAPIServerURL: https://east.hub:6443
+workFeatureGates:
+- feature: ExecutorValidatingCaches
+  mode: Enable
+`),
+				newImportConfigMap("all", `
+clusterSelector: {}
+hubAPIServerURL: https://hub:6443
+proxyURL: http://proxy:3128
+installMode: Singleton
+workFeatureGates:
+- feature: ExecutorValidatingCaches
+  mode: Disable
+- feature: RawFeedbackJsonString
+  mode: Enable
+`),
+				newImportConfigMap("cluster1", `
+proxyURL: http://proxy.cluster1:3128
+`),
+				newImportConfigMap("other", `
+clusterSelector:
+  matchLabels:
+    region: west
+hubAPIServerURL: https://west.hub:6443
+`),
+			},
+			cluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cluster1",
+					Labels:      map[string]string{"region": "east"},
+					Annotations: map[string]string{ImportConfigAnnotationKey: "cluster1"},
+				},
+			},
+			validateConfig: func(t *testing.T, config *ImportConfig) {
+				if config.HubAPIServerURL != "https://east.hub:6443" {
+					t.Errorf("expect the url of the config with higher priority, got %s", config.HubAPIServerURL)
+				}
+				if config.ProxyURL != "http://proxy.cluster1:3128" {
+					t.Errorf("expect the proxy of the config in annotation, got %s", config.ProxyURL)
+				}
+				if config.InstallMode != operatorv1.InstallModeSingleton {
+					t.Errorf("expect singleton mode, got %s", config.InstallMode)
+				}
+				if len(config.WorkFeatureGates) != 2 || config.WorkFeatureGates[0].Mode != operatorv1.FeatureGateModeTypeEnable {
+					t.Errorf("unexpected feature gates %v", config.WorkFeatureGates)
+				}
+			},
+		},
+		{
+			name: "import config in annotation is not found",
+			cluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cluster1",
+					Annotations: map[string]string{ImportConfigAnnotationKey: "cluster1"},
+				},
+			},
+			expectedErr: "import config cluster1 of the cluster is not found",
+		},
+		{
+			name: "invalid import config is skipped",
+			configMaps: []*corev1.ConfigMap{
+				newImportConfigMap("invalid", `
+clusterSelector: {}
+hubAPIServerURL: http://hub:6443
+installMode: Hosted
+bootstrapTokenTTL: 1m
+hubCABundle: abc
+registrationFeatureGates:
+- feature: AddonManagement
+  mode: On
+`),
+				newImportConfigMap("valid", `
+clusterSelector: {}
+installMode: Singleton
+`),
+			},
+			cluster: &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}},
+			validateConfig: func(t *testing.T, config *ImportConfig) {
+				if len(config.HubAPIServerURL) != 0 || config.InstallMode != operatorv1.InstallModeSingleton {
+					t.Errorf("expect only the valid config resolved, got %v", config)
+				}
+			},
+		},
+		{
+			name: "invalid import config in annotation",
+			configMaps: []*corev1.ConfigMap{
+				newImportConfigMap("invalid", `
+installMode: Hosted
+`),
+			},
+			cluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cluster1",
+					Annotations: map[string]string{ImportConfigAnnotationKey: "invalid"},
+				},
+			},
+			expectedErr: "invalid import config invalid",
+		},
+		{
+			name: "config without the label value is ignored",
+			configMaps: []*corev1.ConfigMap{
+				func() *corev1.ConfigMap {
+					cm := newImportConfigMap("disabled", `
+clusterSelector: {}
+installMode: Singleton
+`)
+					cm.Labels[ImportConfigLabelKey] = "false"
+					return cm
+				}(),
+			},
+			cluster: &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}},
+			validateConfig: func(t *testing.T, config *ImportConfig) {
+				if len(config.InstallMode) != 0 {
+					t.Errorf("expect empty config, got %v", config)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config, err := newImportConfigResolver(t, c.configMaps...).Resolve(c.cluster)
+			if len(c.expectedErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+					t.Fatalf("expect error %q, got %v", c.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.validateConfig(t, config)
+		})
+	}
+}
+
+func TestClusterQueueKeysFunc(t *testing.T) {
+	clusters := []*clusterv1.ManagedCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "east", Labels: map[string]string{"region": "east"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "west", Labels: map[string]string{"region": "west"}}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name:        "annotated",
+			Annotations: map[string]string{ImportConfigAnnotationKey: "config"},
+		}},
+	}
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fakeclusterclient.NewSimpleClientset(), 10*time.Minute)
+	for _, cluster := range clusters {
+		if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name         string
+		configMap    *corev1.ConfigMap
+		expectedKeys []string
+	}{
+		{
+			name: "selected and annotated clusters",
+			configMap: newImportConfigMap("config", `
+clusterSelector:
+  matchLabels:
+    region: east
+`),
+			expectedKeys: []string{"annotated", "east"},
+		},
+		{
+			name:         "annotated clusters",
+			configMap:    newImportConfigMap("config", `installMode: Singleton`),
+			expectedKeys: []string{"annotated"},
+		},
+		{
+			name:         "invalid config",
+			configMap:    newImportConfigMap("other", `installMode: Hosted`),
+			expectedKeys: []string{"annotated", "east", "west"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			keysFunc := newImportConfigResolver(t).ClusterQueueKeysFunc(
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister())
+			keys := keysFunc(c.configMap)
+			if !reflect.DeepEqual(keys, c.expectedKeys) {
+				t.Errorf("expected keys %v, got %v", c.expectedKeys, keys)
+			}
+		})
+	}
+}
+
+func TestValidateImportConfig(t *testing.T) {
+	config := &ImportConfig{
+		HubAPIServerURL:   "http://hub:6443",
+		ProxyURL:          "proxy",
+		HubCABundle:       "abc",
+		InstallMode:       operatorv1.InstallModeHosted,
+		BootstrapTokenTTL: &metav1.Duration{Duration: time.Minute},
+		RegistryMirrors:   []RegistryMirror{{Source: "quay.io"}},
+		WorkFeatureGates:  []operatorv1.FeatureGate{{Feature: "A", Mode: "On"}},
+	}
+	err := validateImportConfig(config)
+	if err == nil {
+		t.Fatal("expect validation errors")
+	}
+	for _, expected := range []string{"hubAPIServerURL", "proxyURL", "hubCABundle", "installMode", "bootstrapTokenTTL",
+		"registryMirrors", "feature gate A"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expect error of %s, got %v", expected, err)
+		}
+	}
+}
+
+func TestRenderImportConfig(t *testing.T) {
+	resolver := newImportConfigResolver(t, newImportConfigMap("all", `
+clusterSelector: {}
+registryMirrors:
+- source: quay.io/open-cluster-management
+  mirror: mirror.local/ocm
+- source: quay.io
+  mirror: mirror.local/quay
+nodePlacement:
+  nodeSelector:
+    node-role.kubernetes.io/infra: ""
+installMode: Singleton
+registrationFeatureGates:
+- feature: ClusterClaim
+  mode: Disable
+`))
+
+	config := &chart.KlusterletChartConfig{}
+	config.Images.Overrides.OperatorImage = "quay.io/open-cluster-management/registration-operator:latest"
+	config.Klusterlet.RegistrationConfiguration.FeatureGates = []operatorv1.FeatureGate{
+		{Feature: "ClusterClaim", Mode: operatorv1.FeatureGateModeTypeEnable},
+	}
+	config, err := RenderImportConfig(resolver)(context.TODO(), &clusterv1.ManagedCluster{}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Images.Overrides.OperatorImage != "mirror.local/ocm/registration-operator:latest" {
+		t.Errorf("unexpected image %s", config.Images.Overrides.OperatorImage)
+	}
+	if _, ok := config.Klusterlet.NodePlacement.NodeSelector["node-role.kubernetes.io/infra"]; !ok {
+		t.Errorf("unexpected node placement %v", config.Klusterlet.NodePlacement)
+	}
+	if _, ok := config.NodeSelector["node-role.kubernetes.io/infra"]; !ok {
+		t.Errorf("unexpected node selector of operator %v", config.NodeSelector)
+	}
+	if config.Klusterlet.Mode != operatorv1.InstallModeSingleton {
+		t.Errorf("unexpected mode %s", config.Klusterlet.Mode)
+	}
+	featureGates := config.Klusterlet.RegistrationConfiguration.FeatureGates
+	if len(featureGates) != 1 || featureGates[0].Mode != operatorv1.FeatureGateModeTypeDisable {
+		t.Errorf("unexpected feature gates %v", featureGates)
+	}
+}
+
+func TestRenderBootstrapHubKubeConfigWithImportConfig(t *testing.T) {
+	caPEM, _, err := cert.GenerateSelfSignedCertKey("hub", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := newImportConfigResolver(t, newImportConfigMap("all", `
+clusterSelector: {}
+hubAPIServerURL: https://hub.example.com:6443
+proxyURL: http://proxy:3128
+bootstrapTokenTTL: 1h
+hubCABundle: |
+`+indent(string(caPEM))))
+
+	var expirationSeconds int64
+	client := kubefake.NewClientset()
+	client.PrependReactor("create", "serviceaccounts/token",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			tokenReq := action.(clienttesting.CreateActionImpl).Object.(*authenticationv1.TokenRequest)
+			expirationSeconds = *tokenReq.Spec.ExpirationSeconds
+			tokenReq.Status.Token = "token"
+			return true, tokenReq, nil
+		},
+	)
+
+	config, err := RenderBootstrapHubKubeConfig(client, "https://127.0.0.1:6443", "open-cluster-management/bootstrap-sa", resolver)(
+		context.TODO(), &clusterv1.ManagedCluster{}, &chart.KlusterletChartConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expirationSeconds != 3600 {
+		t.Errorf("expect token ttl 3600s, got %d", expirationSeconds)
+	}
+	kConfig, err := clientcmd.Load([]byte(config.BootstrapHubKubeConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := kConfig.Clusters[kConfig.Contexts[kConfig.CurrentContext].Cluster]
+	if hub.Server != "https://hub.example.com:6443" || hub.ProxyURL != "http://proxy:3128" ||
+		string(hub.CertificateAuthorityData) != string(caPEM) {
+		t.Errorf("unexpected hub cluster %v", hub)
+	}
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n  ") + "\n"
+}
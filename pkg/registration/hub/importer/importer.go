@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
 
@@ -56,8 +57,8 @@ func init() {
 }
 
 // KlusterletConfigRenderer renders the config for klusterlet chart.
-type KlusterletConfigRenderer func(
-	ctx context.Context, config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error)
+type KlusterletConfigRenderer func(ctx context.Context, cluster *v1.ManagedCluster,
+	config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error)
 
 type Importer struct {
 	providers     []cloudproviders.Interface
@@ -67,11 +68,14 @@ type Importer struct {
 	patcher       patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
 }
 
-// NewImporter creates an auto import controller
+// NewImporter creates an auto import controller. The clusters are requeued when the import configs in the
+// configmaps of the informer change.
 func NewImporter(
 	renders []KlusterletConfigRenderer,
 	clusterClient clusterclientset.Interface,
 	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	resolver *ImportConfigResolver,
 	providers []cloudproviders.Interface,
 	recorder events.Recorder) factory.Controller {
 	controllerName := "managed-cluster-importer"
@@ -92,6 +96,7 @@ func NewImporter(
 	}
 
 	return factory.New().WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, clusterInformer.Informer()).
+		WithInformersQueueKeysFunc(resolver.ClusterQueueKeysFunc(clusterInformer.Lister()), configMapInformer.Informer()).
 		WithSyncContext(syncCtx).WithSync(i.sync).ToController(controllerName, recorder)
 }
 
@@ -174,29 +179,16 @@ func (i *Importer) reconcile(
 	}
 
 	// render the klusterlet chart config
-	klusterletChartConfig := &chart.KlusterletChartConfig{
-		ReplicaCount:    1,
-		CreateNamespace: true,
-		Klusterlet: chart.KlusterletConfig{
-			Create:      true,
-			ClusterName: cluster.Name,
-			ResourceRequirement: &operatorv1.ResourceRequirement{
-				Type: operatorv1.ResourceQosClassDefault,
-			},
-		},
-	}
-	for _, renderer := range i.renders {
-		klusterletChartConfig, err = renderer(ctx, klusterletChartConfig)
-		if err != nil {
-			meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
-				Type:   ManagedClusterConditionImported,
-				Status: metav1.ConditionFalse,
-				Reason: "ConfigRendererFailed",
-				Message: fmt.Sprintf("failed to render config. See errors:\n%s",
-					err.Error()),
-			})
-			return cluster, err
-		}
+	klusterletChartConfig, err := RenderKlusterletChartConfig(ctx, cluster, i.renders)
+	if err != nil {
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:   ManagedClusterConditionImported,
+			Status: metav1.ConditionFalse,
+			Reason: "ConfigRendererFailed",
+			Message: fmt.Sprintf("failed to render config. See errors:\n%s",
+				err.Error()),
+		})
+		return cluster, err
 	}
 	crdObjs, rawObjs, err := chart.RenderKlusterletChart(klusterletChartConfig, kluterletNamespace)
 	if err != nil {
@@ -259,6 +251,30 @@ func (i *Importer) reconcile(
 	return cluster, utilerrors.NewAggregate(errs)
 }
 
+// RenderKlusterletChartConfig renders the klusterlet chart config of the cluster with the renderers.
+func RenderKlusterletChartConfig(ctx context.Context, cluster *v1.ManagedCluster,
+	renders []KlusterletConfigRenderer) (*chart.KlusterletChartConfig, error) {
+	var err error
+	klusterletChartConfig := &chart.KlusterletChartConfig{
+		ReplicaCount:    1,
+		CreateNamespace: true,
+		Klusterlet: chart.KlusterletConfig{
+			Create:      true,
+			ClusterName: cluster.Name,
+			ResourceRequirement: &operatorv1.ResourceRequirement{
+				Type: operatorv1.ResourceQosClassDefault,
+			},
+		},
+	}
+	for _, renderer := range renders {
+		klusterletChartConfig, err = renderer(ctx, cluster, klusterletChartConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return klusterletChartConfig, nil
+}
+
 func ApplyKlusterlet(
 	ctx context.Context,
 	client operatorclient.Interface,
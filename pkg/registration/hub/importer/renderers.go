@@ -14,6 +14,7 @@ import (
 	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 	"k8s.io/utils/ptr"
 
+	v1 "open-cluster-management.io/api/cluster/v1"
 	sdkhelpers "open-cluster-management.io/sdk-go/pkg/helpers"
 
 	"open-cluster-management.io/ocm/pkg/operator/helpers/chart"
@@ -21,10 +22,27 @@ import (
 
 const imagePullSecretName = "open-cluster-management-image-pull-credentials"
 
+// RenderBootstrapHubKubeConfig renders the bootstrap kubeconfig of the hub. The hub apiserver url, CA bundle,
+// proxy and token TTL in the import config of the cluster override the global values.
 func RenderBootstrapHubKubeConfig(
-	kubeClient kubernetes.Interface, apiServerURL, bootstrapSA string) KlusterletConfigRenderer {
-	return func(ctx context.Context, config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error) {
-		// get bootstrap token
+	kubeClient kubernetes.Interface, apiServerURL, bootstrapSA string, resolver *ImportConfigResolver) KlusterletConfigRenderer {
+	return RenderBoundBootstrapHubKubeConfig(kubeClient, apiServerURL, bootstrapSA, resolver, nil)
+}
+
+// RenderBoundBootstrapHubKubeConfig renders the bootstrap kubeconfig of the hub with a token bound to the object,
+// the token is revoked once the object is deleted.
+func RenderBoundBootstrapHubKubeConfig(
+	kubeClient kubernetes.Interface, apiServerURL, bootstrapSA string, resolver *ImportConfigResolver,
+	boundObject *authv1.BoundObjectReference) KlusterletConfigRenderer {
+	return func(ctx context.Context, cluster *v1.ManagedCluster,
+		config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error) {
+		importConfig, err := resolver.Resolve(cluster)
+		if err != nil {
+			return config, err
+		}
+
+		// get bootstrap token, the token expires in 24 hours by default
+		ttl := importConfig.bootstrapTokenTTL()
 		bootstrapSANamespace, bootstrapSAName, err := cache.SplitMetaNamespaceKey(bootstrapSA)
 		if err != nil {
 			return config, err
@@ -33,8 +51,8 @@ func RenderBootstrapHubKubeConfig(
 			ServiceAccounts(bootstrapSANamespace).
 			CreateToken(ctx, bootstrapSAName, &authv1.TokenRequest{
 				Spec: authv1.TokenRequestSpec{
-					// token expired in 24 hours
-					ExpirationSeconds: ptr.To[int64](24 * 3600),
+					ExpirationSeconds: ptr.To[int64](int64(ttl.Seconds())),
+					BoundObjectRef:    boundObject,
 				},
 			}, metav1.CreateOptions{})
 		if err != nil {
@@ -44,6 +62,9 @@ func RenderBootstrapHubKubeConfig(
 
 		// get apisever url
 		url := apiServerURL
+		if len(importConfig.HubAPIServerURL) > 0 {
+			url = importConfig.HubAPIServerURL
+		}
 		if len(url) == 0 {
 			url, err = sdkhelpers.GetAPIServer(kubeClient)
 			if err != nil {
@@ -52,9 +73,17 @@ func RenderBootstrapHubKubeConfig(
 		}
 
 		// get cabundle
-		ca, err := sdkhelpers.GetCACert(kubeClient)
-		if err != nil {
-			return config, err
+		var ca []byte
+		if len(importConfig.HubCABundle) > 0 {
+			ca = []byte(importConfig.HubCABundle)
+		} else {
+			ca, err = sdkhelpers.GetCACert(kubeClient)
+			if err != nil {
+				return config, err
+			}
+		}
+		if len(importConfig.ProxyCABundle) > 0 {
+			ca = append(append(ca, '\n'), importConfig.ProxyCABundle...)
 		}
 
 		clientConfig := clientcmdapiv1.Config{
@@ -65,6 +94,7 @@ func RenderBootstrapHubKubeConfig(
 					Cluster: clientcmdapiv1.Cluster{
 						Server:                   url,
 						CertificateAuthorityData: ca,
+						ProxyURL:                 importConfig.ProxyURL,
 					},
 				},
 			},
@@ -102,7 +132,8 @@ func RenderBootstrapHubKubeConfig(
 }
 
 func RenderImage(image string) KlusterletConfigRenderer {
-	return func(ctx context.Context, config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error) {
+	return func(ctx context.Context, cluster *v1.ManagedCluster,
+		config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error) {
 		if len(image) == 0 {
 			return config, nil
 		}
@@ -114,7 +145,8 @@ func RenderImage(image string) KlusterletConfigRenderer {
 }
 
 func RenderImagePullSecret(kubeClient kubernetes.Interface, namespace string) KlusterletConfigRenderer {
-	return func(ctx context.Context, config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error) {
+	return func(ctx context.Context, cluster *v1.ManagedCluster,
+		config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error) {
 		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, imagePullSecretName, metav1.GetOptions{})
 		switch {
 		case errors.IsNotFound(err):
@@ -132,3 +164,44 @@ func RenderImagePullSecret(kubeClient kubernetes.Interface, namespace string) Kl
 		return config, nil
 	}
 }
+
+// RenderImportConfig renders the registry mirrors, node placement, install mode and feature gates in the import
+// config of the cluster. It should be the last renderer so the registry mirrors apply to all the images.
+func RenderImportConfig(resolver *ImportConfigResolver) KlusterletConfigRenderer {
+	return func(ctx context.Context, cluster *v1.ManagedCluster,
+		config *chart.KlusterletChartConfig) (*chart.KlusterletChartConfig, error) {
+		importConfig, err := resolver.Resolve(cluster)
+		if err != nil {
+			return config, err
+		}
+
+		if len(importConfig.RegistryMirrors) > 0 {
+			config.Images.Registry = mirrorImage(config.Images.Registry, importConfig.RegistryMirrors)
+			overrides := &config.Images.Overrides
+			for _, image := range []*string{
+				&overrides.OperatorImage, &overrides.RegistrationImage, &overrides.WorkImage,
+			} {
+				if len(*image) > 0 {
+					*image = mirrorImage(*image, importConfig.RegistryMirrors)
+				}
+			}
+		}
+		if importConfig.NodePlacement != nil {
+			config.Klusterlet.NodePlacement = *importConfig.NodePlacement
+			config.NodeSelector = importConfig.NodePlacement.NodeSelector
+			config.Tolerations = importConfig.NodePlacement.Tolerations
+		}
+		if len(importConfig.InstallMode) > 0 {
+			config.Klusterlet.Mode = importConfig.InstallMode
+		}
+		for _, featureGate := range importConfig.RegistrationFeatureGates {
+			config.Klusterlet.RegistrationConfiguration.FeatureGates = mergeFeatureGate(
+				config.Klusterlet.RegistrationConfiguration.FeatureGates, featureGate)
+		}
+		for _, featureGate := range importConfig.WorkFeatureGates {
+			config.Klusterlet.WorkConfiguration.FeatureGates = mergeFeatureGate(
+				config.Klusterlet.WorkConfiguration.FeatureGates, featureGate)
+		}
+		return config, nil
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
 	"open-cluster-management.io/ocm/pkg/operator/helpers/chart"
 )
 
@@ -81,7 +83,7 @@ func TestRenderBootstrapHubKubeConfig(t *testing.T) {
 				},
 			)
 			config := &chart.KlusterletChartConfig{}
-			config, err := RenderBootstrapHubKubeConfig(client, c.apiserverURL, c.bootstrapSA)(context.TODO(), config)
+			config, err := RenderBootstrapHubKubeConfig(client, c.apiserverURL, c.bootstrapSA, nil)(context.TODO(), &clusterv1.ManagedCluster{}, config)
 			if err != nil {
 				t.Fatalf("failed to render bootstrap hub kubeconfig: %v", err)
 			}
@@ -121,7 +123,7 @@ func TestRenderImage(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			config := &chart.KlusterletChartConfig{}
 			render := RenderImage(c.image)
-			config, err := render(context.TODO(), config)
+			config, err := render(context.TODO(), &clusterv1.ManagedCluster{}, config)
 			if err != nil {
 				t.Fatalf("failed to render image: %v", err)
 			}
@@ -178,7 +180,7 @@ func TestRenderImagePullSecret(t *testing.T) {
 			client := kubefake.NewClientset(c.secrets...)
 			config := &chart.KlusterletChartConfig{}
 			render := RenderImagePullSecret(client, "test")
-			config, err := render(context.TODO(), config)
+			config, err := render(context.TODO(), &clusterv1.ManagedCluster{}, config)
 			if err != nil {
 				t.Fatalf("failed to render image: %v", err)
 			}
@@ -137,6 +137,10 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 	newManagedCluster := managedCluster.DeepCopy()
 
 	if !managedCluster.DeletionTimestamp.IsZero() {
+		// the agents need the cluster rbac until the decommission of the cluster completes.
+		if commonhelper.HasFinalizer(managedCluster.Finalizers, commonhelper.DecommissionFinalizer) {
+			return nil
+		}
 		if err = c.hubDriver.Cleanup(ctx, managedCluster); err != nil {
 			return err
 		}
@@ -0,0 +1,136 @@
+package managedclusterset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	clustersetv1beta2 "open-cluster-management.io/api/client/cluster/clientset/versioned/typed/cluster/v1beta2"
+	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+// childManagedClusterSetController creates the child clustersets requested by the admins of a clusterset with the
+// ChildClusterSetsAnnotation, so the admins of a clusterset can create child clustersets without the rights to
+// create ManagedClusterSets. The child clustersets are never deleted by the controller.
+type childManagedClusterSetController struct {
+	clusterSetClient clustersetv1beta2.ClusterV1beta2Interface
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister
+	eventRecorder    events.Recorder
+}
+
+func NewChildManagedClusterSetController(
+	clusterSetClient clustersetv1beta2.ClusterV1beta2Interface,
+	clusterSetInformer clusterinformerv1beta2.ManagedClusterSetInformer,
+	recorder events.Recorder) factory.Controller {
+
+	c := &childManagedClusterSetController{
+		clusterSetClient: clusterSetClient,
+		clusterSetLister: clusterSetInformer.Lister(),
+		eventRecorder:    recorder.WithComponentSuffix("child-managed-cluster-set-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeysFunc(func(obj runtime.Object) []string {
+			clusterSet, ok := obj.(*clusterv1beta2.ManagedClusterSet)
+			if !ok {
+				return nil
+			}
+			// enqueue the parent as well to recreate a deleted child clusterset
+			keys := []string{clusterSet.Name}
+			if parent := clusterSet.Labels[commonhelpers.ParentClusterSetLabel]; len(parent) > 0 {
+				keys = append(keys, parent)
+			}
+			return keys
+		}, clusterSetInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ChildManagedClusterSetController", recorder)
+}
+
+func (c *childManagedClusterSetController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx)
+	clusterSetName := syncCtx.QueueKey()
+	clusterSet, err := c.clusterSetLister.Get(clusterSetName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !clusterSet.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	var errs []error
+	for _, childName := range ChildClusterSetNames(clusterSet) {
+		child, err := c.clusterSetLister.Get(childName)
+		switch {
+		case errors.IsNotFound(err):
+			if err := c.createChildClusterSet(ctx, clusterSet.Name, childName); err != nil {
+				errs = append(errs, err)
+			}
+		case err != nil:
+			errs = append(errs, err)
+		case child.Labels[commonhelpers.ParentClusterSetLabel] != clusterSet.Name:
+			logger.V(2).Info("The requested child clusterset exists under another parent",
+				"clusterSetName", clusterSet.Name, "childClusterSetName", childName)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *childManagedClusterSetController) createChildClusterSet(ctx context.Context, parent, name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid child clusterset name %q of clusterset %q: %s", name, parent, strings.Join(errs, ","))
+	}
+
+	child := &clusterv1beta2.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				commonhelpers.ParentClusterSetLabel: parent,
+			},
+		},
+		Spec: clusterv1beta2.ManagedClusterSetSpec{
+			ClusterSelector: clusterv1beta2.ManagedClusterSelector{
+				SelectorType: clusterv1beta2.ExclusiveClusterSetLabel,
+			},
+		},
+	}
+	_, err := c.clusterSetClient.ManagedClusterSets().Create(ctx, child, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.eventRecorder.Eventf("ChildManagedClusterSetCreated", "Created child ManagedClusterSet %s of %s", name, parent)
+	return nil
+}
+
+// ChildClusterSetNames returns the names of the child clustersets requested with the ChildClusterSetsAnnotation.
+func ChildClusterSetNames(clusterSet *clusterv1beta2.ManagedClusterSet) []string {
+	names := sets.New[string]()
+	for _, name := range strings.Split(clusterSet.Annotations[commonhelpers.ChildClusterSetsAnnotation], ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 && name != clusterSet.Name {
+			names.Insert(name)
+		}
+	}
+	return sets.List(names)
+}
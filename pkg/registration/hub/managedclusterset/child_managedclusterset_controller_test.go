@@ -0,0 +1,123 @@
+package managedclusterset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestSyncChildClusterSet(t *testing.T) {
+	cases := []struct {
+		name                string
+		existingClusterSets []runtime.Object
+		validateActions     func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name: "no child clusterset",
+			existingClusterSets: []runtime.Object{
+				newNestedClusterSet("parent", "", ""),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "create child clusterset",
+			existingClusterSets: []runtime.Object{
+				newNestedClusterSet("parent", "", "child1, child2,parent"),
+				newNestedClusterSet("child1", "parent", ""),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create")
+				clusterSet := actions[0].(clienttesting.CreateAction).GetObject().(*clusterv1beta2.ManagedClusterSet)
+				if clusterSet.Name != "child2" || clusterSet.Labels[commonhelpers.ParentClusterSetLabel] != "parent" {
+					t.Errorf("unexpected child clusterset %v", clusterSet)
+				}
+				if clusterSet.Spec.ClusterSelector.SelectorType != clusterv1beta2.ExclusiveClusterSetLabel {
+					t.Errorf("unexpected selector type %q", clusterSet.Spec.ClusterSelector.SelectorType)
+				}
+			},
+		},
+		{
+			name: "child clusterset exists under another parent",
+			existingClusterSets: []runtime.Object{
+				newNestedClusterSet("parent", "", "child1"),
+				newNestedClusterSet("child1", "other", ""),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "deleting clusterset",
+			existingClusterSets: []runtime.Object{
+				func() *clusterv1beta2.ManagedClusterSet {
+					clusterSet := newNestedClusterSet("parent", "", "child1")
+					now := metav1.Now()
+					clusterSet.DeletionTimestamp = &now
+					return clusterSet
+				}(),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterSetClient := clusterfake.NewSimpleClientset(c.existingClusterSets...)
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterSetClient, 5*time.Minute)
+			for _, clusterSet := range c.existingClusterSets {
+				if err := informerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().GetStore().Add(clusterSet); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := childManagedClusterSetController{
+				clusterSetClient: clusterSetClient.ClusterV1beta2(),
+				clusterSetLister: informerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				eventRecorder:    eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "parent"))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterSetClient.Actions())
+		})
+	}
+}
+
+func newNestedClusterSet(name, parent, children string) *clusterv1beta2.ManagedClusterSet {
+	clusterSet := &clusterv1beta2.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: clusterv1beta2.ManagedClusterSetSpec{
+			ClusterSelector: clusterv1beta2.ManagedClusterSelector{
+				SelectorType: clusterv1beta2.ExclusiveClusterSetLabel,
+			},
+		},
+	}
+	if len(parent) > 0 {
+		clusterSet.Labels = map[string]string{commonhelpers.ParentClusterSetLabel: parent}
+	}
+	if len(children) > 0 {
+		clusterSet.Annotations = map[string]string{commonhelpers.ChildClusterSetsAnnotation: children}
+	}
+	return clusterSet
+}
@@ -23,10 +23,9 @@ import (
 	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
-	clustersdkv1beta2 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta2"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
-	"open-cluster-management.io/ocm/pkg/common/queue"
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 const (
@@ -115,10 +114,23 @@ func NewManagedClusterSetController(
 		utilruntime.HandleError(err)
 	}
 
+	// the clusters of a child clusterset are also the clusters of its ancestors, so enqueue the ancestors of the
+	// clusterset before and after the change.
+	_, err = clusterSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueClusterSetWithAncestors,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.enqueueClusterSetWithAncestors(oldObj)
+			c.enqueueClusterSetWithAncestors(newObj)
+		},
+		DeleteFunc: c.enqueueClusterSetWithAncestors,
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
 	return factory.New().
 		WithSyncContext(syncCtx).
-		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, clusterSetInformer.Informer()).
-		WithBareInformers(clusterInformer.Informer()).
+		WithBareInformers(clusterInformer.Informer(), clusterSetInformer.Informer()).
 		WithSync(c.sync).
 		ToController("ManagedClusterSetController", recorder)
 }
@@ -154,7 +166,7 @@ func (c *managedClusterSetController) sync(ctx context.Context, syncCtx factory.
 // syncClusterSet syncs a particular cluster set
 func (c *managedClusterSetController) syncClusterSet(ctx context.Context, originalClusterSet *clusterv1beta2.ManagedClusterSet) error {
 	clusterSet := originalClusterSet.DeepCopy()
-	clusters, err := clustersdkv1beta2.GetClustersFromClusterSet(clusterSet, c.clusterLister)
+	clusters, err := commonhelpers.GetClustersFromClusterSetTree(clusterSet, c.clusterSetLister, c.clusterLister)
 	if err != nil {
 		return err
 	}
@@ -182,9 +194,31 @@ func (c *managedClusterSetController) syncClusterSet(ctx context.Context, origin
 	return nil
 }
 
+// enqueueClusterSetWithAncestors enqueues the clusterset and its ancestors
+func (c *managedClusterSetController) enqueueClusterSetWithAncestors(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	clusterSet, ok := obj.(*clusterv1beta2.ManagedClusterSet)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("error to get object: %v", obj))
+		return
+	}
+
+	c.queue.Add(clusterSet.Name)
+	ancestors, err := commonhelpers.GetClusterSetAncestors(clusterSet, c.clusterSetLister)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("error to get ancestors of clusterset %s. Error %v", clusterSet.Name, err))
+		return
+	}
+	for _, ancestor := range ancestors {
+		c.queue.Add(ancestor.Name)
+	}
+}
+
 // enqueueClusterClusterSet enqueue a cluster related clusterset
 func (c *managedClusterSetController) enqueueClusterClusterSet(cluster *v1.ManagedCluster) {
-	clusterSets, err := clustersdkv1beta2.GetClusterSetsOfCluster(cluster, c.clusterSetLister)
+	clusterSets, err := commonhelpers.GetClusterSetsOfClusterWithAncestors(cluster, c.clusterSetLister)
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("error to get GetClusterSetsOfCluster. Error %v", err))
 		return
@@ -197,12 +231,12 @@ func (c *managedClusterSetController) enqueueClusterClusterSet(cluster *v1.Manag
 // enqueueUpdateClusterClusterSet get the oldCluster related clustersets and newCluster related clustersets,
 // then enqueue the diff clustersets(added clustersets and removed clustersets)
 func (c *managedClusterSetController) enqueueUpdateClusterClusterSet(oldCluster, newCluster *v1.ManagedCluster) {
-	oldClusterSets, err := clustersdkv1beta2.GetClusterSetsOfCluster(oldCluster, c.clusterSetLister)
+	oldClusterSets, err := commonhelpers.GetClusterSetsOfClusterWithAncestors(oldCluster, c.clusterSetLister)
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("error to get GetClusterSetsOfCluster. Error %v", err))
 		return
 	}
-	newClusterSets, err := clustersdkv1beta2.GetClusterSetsOfCluster(newCluster, c.clusterSetLister)
+	newClusterSets, err := commonhelpers.GetClusterSetsOfClusterWithAncestors(newCluster, c.clusterSetLister)
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("error to get GetClusterSetsOfCluster. Error %v", err))
 		return
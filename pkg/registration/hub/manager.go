@@ -7,13 +7,16 @@ import (
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/spf13/pflag"
+	authv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	generate "k8s.io/kubectl/pkg/generate"
+	cpv1alpha1 "sigs.k8s.io/cluster-inventory-api/apis/v1alpha1"
 	cpclientset "sigs.k8s.io/cluster-inventory-api/client/clientset/versioned"
 	cpinformerv1alpha1 "sigs.k8s.io/cluster-inventory-api/client/informers/externalversions"
 
@@ -33,6 +36,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/registration/hub/addon"
 	"open-cluster-management.io/ocm/pkg/registration/hub/clusterprofile"
 	"open-cluster-management.io/ocm/pkg/registration/hub/clusterrole"
+	"open-cluster-management.io/ocm/pkg/registration/hub/decommission"
 	"open-cluster-management.io/ocm/pkg/registration/hub/gc"
 	"open-cluster-management.io/ocm/pkg/registration/hub/importer"
 	importeroptions "open-cluster-management.io/ocm/pkg/registration/hub/importer/options"
@@ -62,6 +66,10 @@ type HubManagerOptions struct {
 	Labels                     string
 	GRPCCAFile                 string
 	GRPCCAKeyFile              string
+	DecommissionHookTimeout    time.Duration
+	DecommissionTimeout        time.Duration
+	// ClusterProfileAccessClusterRoles are the ClusterRoles allowed to be requested by the ClusterProfile access.
+	ClusterProfileAccessClusterRoles []string
 }
 
 // NewHubManagerOptions returns a HubManagerOptions
@@ -71,6 +79,8 @@ func NewHubManagerOptions() *HubManagerOptions {
 			"work.open-cluster-management.io/v1/manifestworks"},
 		ImportOption:               importeroptions.New(),
 		EnabledRegistrationDrivers: []string{commonhelpers.CSRAuthType},
+		DecommissionHookTimeout:    10 * time.Minute,
+		DecommissionTimeout:        time.Hour,
 	}
 }
 
@@ -98,6 +108,14 @@ func (m *HubManagerOptions) AddFlags(fs *pflag.FlagSet) {
 		"Labels to be added to the resources created by registration controller. The format is key1=value1,key2=value2.")
 	fs.StringVar(&m.GRPCCAFile, "grpc-ca-file", m.GRPCCAFile, "ca file to sign client cert for grpc")
 	fs.StringVar(&m.GRPCCAKeyFile, "grpc-key-file", m.GRPCCAKeyFile, "ca key file to sign client cert for grpc")
+	fs.DurationVar(&m.DecommissionHookTimeout, "decommission-hook-timeout", m.DecommissionHookTimeout,
+		"The time to wait for a pre-delete hook to complete before the decommission of the cluster continues.")
+	fs.DurationVar(&m.DecommissionTimeout, "decommission-timeout", m.DecommissionTimeout,
+		"The time to wait for the decommission of a deleted cluster, the workloads are forced to be orphaned in "+
+			"the detach mode and the decommission is reported as timed out otherwise once it passes.")
+	fs.StringSliceVar(&m.ClusterProfileAccessClusterRoles, "cluster-profile-access-cluster-roles", m.ClusterProfileAccessClusterRoles,
+		"A list of ClusterRoles allowed to be granted on the managed clusters by the ClusterProfile access requests. "+
+			"No access is granted if it is empty.")
 	m.ImportOption.AddFlags(fs)
 }
 
@@ -162,7 +180,7 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 
 	return m.RunControllerManagerWithInformers(
 		ctx, controllerContext,
-		kubeClient, metadataClient, clusterClient, clusterProfileClient, addOnClient,
+		kubeClient, metadataClient, clusterClient, clusterProfileClient, workClient, addOnClient,
 		kubeInfomers, clusterInformers, clusterProfileInformers, workInformers, addOnInformers,
 	)
 }
@@ -174,6 +192,7 @@ func (m *HubManagerOptions) RunControllerManagerWithInformers(
 	metadataClient metadata.Interface,
 	clusterClient clusterv1client.Interface,
 	clusterProfileClient cpclientset.Interface,
+	workClient workv1client.Interface,
 	addOnClient addonclient.Interface,
 	kubeInformers kubeinformers.SharedInformerFactory,
 	clusterInformers clusterv1informers.SharedInformerFactory,
@@ -265,6 +284,12 @@ func (m *HubManagerOptions) RunControllerManagerWithInformers(
 		controllerContext.EventRecorder,
 	)
 
+	childManagedClusterSetController := managedclusterset.NewChildManagedClusterSetController(
+		clusterClient.ClusterV1beta2(),
+		clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+		controllerContext.EventRecorder,
+	)
+
 	managedClusterSetBindingController := managedclustersetbinding.NewManagedClusterSetBindingController(
 		clusterClient,
 		clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
@@ -308,33 +333,120 @@ func (m *HubManagerOptions) RunControllerManagerWithInformers(
 		)
 	}
 
-	var clusterProfileController factory.Controller
+	var clusterProfileController, clusterProfileAccessController, clusterProfileBindingController factory.Controller
+	var clusterProfileSecretInformers kubeinformers.SharedInformerFactory
 	if features.HubMutableFeatureGate.Enabled(ocmfeature.ClusterProfile) {
+		clusterProfileSecretInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute,
+			kubeinformers.WithNamespace(clusterprofile.ClusterProfileNamespace),
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.LabelSelector = labels.SelectorFromSet(labels.Set{
+					cpv1alpha1.LabelClusterManagerKey: clusterprofile.ClusterProfileManagerName,
+				}).String()
+			}))
 		clusterProfileController = clusterprofile.NewClusterProfileController(
 			clusterInformers.Cluster().V1().ManagedClusters(),
 			clusterProfileClient,
 			clusterProfileInformers.Apis().V1alpha1().ClusterProfiles(),
 			controllerContext.EventRecorder,
 		)
+		clusterProfileAccessController = clusterprofile.NewClusterProfileAccessController(
+			kubeClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			clusterProfileClient,
+			clusterProfileInformers.Apis().V1alpha1().ClusterProfiles(),
+			clusterProfileSecretInformers.Core().V1().Secrets(),
+			workClient,
+			workInformers.Work().V1().ManifestWorks(),
+			m.ClusterProfileAccessClusterRoles,
+			controllerContext.EventRecorder,
+		)
+		clusterProfileBindingController = clusterprofile.NewClusterProfileBindingController(
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+			clusterInformers.Cluster().V1beta2().ManagedClusterSetBindings(),
+			clusterProfileClient,
+			clusterProfileInformers.Apis().V1alpha1().ClusterProfiles(),
+			controllerContext.EventRecorder,
+		)
 	}
 
 	var providers []cloudproviders.Interface
-	var clusterImporter factory.Controller
+	var clusterImporter, importBundleController factory.Controller
+	var importConfigInformers, importBundleInformers kubeinformers.SharedInformerFactory
 	if features.HubMutableFeatureGate.Enabled(ocmfeature.ClusterImporter) {
 		providers = []cloudproviders.Interface{
 			capi.NewCAPIProvider(controllerContext.KubeConfig, clusterInformers.Cluster().V1().ManagedClusters()),
 		}
+		importConfigInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute,
+			kubeinformers.WithNamespace(controllerContext.OperatorNamespace),
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.LabelSelector = importer.ImportConfigLabelSelector
+			}))
+		importConfigResolver := importer.NewImportConfigResolver(
+			importConfigInformers.Core().V1().ConfigMaps().Lister().ConfigMaps(controllerContext.OperatorNamespace),
+			controllerContext.EventRecorder)
 		clusterImporter = importer.NewImporter(
 			[]importer.KlusterletConfigRenderer{
-				importer.RenderBootstrapHubKubeConfig(kubeClient, m.ImportOption.APIServerURL, m.ImportOption.BootstrapSA),
+				importer.RenderBootstrapHubKubeConfig(
+					kubeClient, m.ImportOption.APIServerURL, m.ImportOption.BootstrapSA, importConfigResolver),
 				importer.RenderImage(m.ImportOption.AgentImage),
 				importer.RenderImagePullSecret(kubeClient, controllerContext.OperatorNamespace),
+				importer.RenderImportConfig(importConfigResolver),
 			},
 			clusterClient,
 			clusterInformers.Cluster().V1().ManagedClusters(),
+			importConfigInformers.Core().V1().ConfigMaps(),
+			importConfigResolver,
 			providers,
 			controllerContext.EventRecorder,
 		)
+		importBundleInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute,
+			kubeinformers.WithNamespace(controllerContext.OperatorNamespace),
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.LabelSelector = importer.ImportBundleLabelKey
+			}))
+		importBundleController = importer.NewImportBundleController(
+			kubeClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			importBundleInformers.Core().V1().Secrets(),
+			importConfigInformers.Core().V1().ConfigMaps(),
+			kubeInformers.Certificates().V1().CertificateSigningRequests(),
+			controllerContext.OperatorNamespace,
+			importConfigResolver,
+			func(boundObject *authv1.BoundObjectReference) []importer.KlusterletConfigRenderer {
+				return []importer.KlusterletConfigRenderer{
+					importer.RenderBoundBootstrapHubKubeConfig(kubeClient, m.ImportOption.APIServerURL,
+						m.ImportOption.BootstrapSA, importConfigResolver, boundObject),
+					importer.RenderImage(m.ImportOption.AgentImage),
+					importer.RenderImagePullSecret(kubeClient, controllerContext.OperatorNamespace),
+					importer.RenderImportConfig(importConfigResolver),
+				}
+			},
+			controllerContext.EventRecorder,
+		)
+	}
+
+	// the decommission hands the deleted clusters over to the gc controller, so it is enabled with the gc controller.
+	var decommissionController factory.Controller
+	var decommissionHookInformers kubeinformers.SharedInformerFactory
+	if features.HubMutableFeatureGate.Enabled(ocmfeature.ResourceCleanup) {
+		decommissionHookInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute,
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.LabelSelector = decommission.DecommissionHookLabelKey
+			}))
+		decommissionController = decommission.NewDecommissionController(
+			kubeClient,
+			clusterClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			workClient,
+			workInformers.Work().V1().ManifestWorks(),
+			decommissionHookInformers.Core().V1().ConfigMaps(),
+			addOnClient,
+			addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
+			m.DecommissionHookTimeout,
+			m.DecommissionTimeout,
+			controllerContext.EventRecorder,
+		)
 	}
 
 	gcController := gc.NewGCController(
@@ -359,6 +471,7 @@ func (m *HubManagerOptions) RunControllerManagerWithInformers(
 	go leaseController.Run(ctx, 1)
 	go clockSyncController.Run(ctx, 1)
 	go managedClusterSetController.Run(ctx, 1)
+	go childManagedClusterSetController.Run(ctx, 1)
 	go managedClusterSetBindingController.Run(ctx, 1)
 	go clusterroleController.Run(ctx, 1)
 	go addOnHealthCheckController.Run(ctx, 1)
@@ -368,16 +481,28 @@ func (m *HubManagerOptions) RunControllerManagerWithInformers(
 		go globalManagedClusterSetController.Run(ctx, 1)
 	}
 	if features.HubMutableFeatureGate.Enabled(ocmfeature.ClusterProfile) {
+		go clusterProfileSecretInformers.Start(ctx.Done())
 		go clusterProfileController.Run(ctx, 1)
+		go clusterProfileAccessController.Run(ctx, 1)
+		go clusterProfileBindingController.Run(ctx, 1)
 	}
 	if features.HubMutableFeatureGate.Enabled(ocmfeature.ClusterImporter) {
 		for _, provider := range providers {
 			go provider.Run(ctx)
 		}
-		go clusterImporter.Run(ctx, 1)
+		importConfigInformers.Start(ctx.Done())
+		importBundleInformers.Start(ctx.Done())
+		go func() {
+			// wait for the import configs so the clusters are not imported without their configs.
+			importConfigInformers.WaitForCacheSync(ctx.Done())
+			go importBundleController.Run(ctx, 1)
+			clusterImporter.Run(ctx, 1)
+		}()
 	}
 
 	if features.HubMutableFeatureGate.Enabled(ocmfeature.ResourceCleanup) {
+		go decommissionHookInformers.Start(ctx.Done())
+		go decommissionController.Run(ctx, 1)
 		go gcController.Run(ctx, 1)
 	}
 
@@ -43,11 +43,13 @@ type GRPCDriver struct {
 	csrDriver      *csr.CSRDriver
 	control        *ceCSRControl
 	opt            *Option
+	config         any
 	configTemplate []byte
 }
 
 var _ register.RegisterDriver = &GRPCDriver{}
 var _ register.AddonDriver = &GRPCDriver{}
+var _ register.TunnelDriver = &GRPCDriver{}
 
 func NewGRPCDriver(opt *Option, csrOption *csr.Option, secretOption register.SecretOption) (register.RegisterDriver, error) {
 	secretOption.Signer = helpers.GRPCCAuthSigner
@@ -66,6 +68,7 @@ func (d *GRPCDriver) BuildClients(ctx context.Context, secretOption register.Sec
 	if err != nil {
 		return nil, err
 	}
+	d.config = config
 	d.configTemplate = configData
 
 	clusterWatchStore := cloudeventsstore.NewAgentInformerWatcherStore[*clusterv1.ManagedCluster]()
@@ -164,6 +167,10 @@ func (d *GRPCDriver) Fork(addonName string, secretOption register.SecretOption)
 	}
 }
 
+func (d *GRPCDriver) TunnelConfig() any {
+	return d.config
+}
+
 func (d *GRPCDriver) Process(
 	ctx context.Context, controllerName string, secret *corev1.Secret, additionalSecretData map[string][]byte,
 	recorder events.Recorder) (*corev1.Secret, *metav1.Condition, error) {
@@ -86,6 +86,13 @@ type AddonDriver interface {
 	Fork(addonName string, secretOption SecretOption) RegisterDriver
 }
 
+// TunnelDriver is an interface for the driver to carry the requests from the hub to the managed cluster over the
+// outbound connection of the agent.
+type TunnelDriver interface {
+	// TunnelConfig returns the cloudevents config loaded by BuildClients to build the tunnel client.
+	TunnelConfig() any
+}
+
 // HubDriver interface is used to implement operations required to complete aws-irsa registration and csr registration.
 // The Approver interface above is used to implement operations related to approving the CSR request, and permission
 // creation is not related to CSR approval. Hence, created CreatePermissions under a separate interface.
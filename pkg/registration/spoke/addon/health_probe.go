@@ -0,0 +1,280 @@
+package addon
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+const (
+	// probeConditionTypePrefix is the prefix of the addon condition type reporting the result of each probe.
+	probeConditionTypePrefix = "probe.addon.open-cluster-management.io/"
+
+	defaultProbeTimeout = 5 * time.Second
+	// maxProbeResponseBytes limits the size of the response body read by a http probe.
+	maxProbeResponseBytes = 1 << 20
+
+	reasonProbeSucceeded = "ProbeSucceeded"
+	reasonProbeFailed    = "ProbeFailed"
+	reasonProbeInvalid   = "ProbeInvalid"
+)
+
+// getHealthProbes returns the health probes of the addon, nil is returned if the addon has no probes.
+func getHealthProbes(addOn *addonv1alpha1.ManagedClusterAddOn) ([]helpers.HealthProbe, error) {
+	value, ok := addOn.Annotations[helpers.AddonHealthProbesAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	probes, err := helpers.ParseHealthProbes(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid health probes of addon %q: %v", addOn.Name, err)
+	}
+	return probes, nil
+}
+
+// healthProber runs the health probes of the addons.
+type healthProber struct {
+	env *cel.Env
+	// dial and httpDo are replaceable in unit tests.
+	dial   func(ctx context.Context, address string) (net.Conn, error)
+	httpDo func(req *http.Request) (*http.Response, error)
+}
+
+func newHealthProber() (*healthProber, error) {
+	env, err := helpers.NewHealthProbeEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{}
+	client := &http.Client{
+		Transport: &http.Transport{
+			// the addon pods are probed by ip, the serving certificates can not be verified.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &healthProber{
+		env: env,
+		dial: func(ctx context.Context, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", address)
+		},
+		httpDo: client.Do,
+	}, nil
+}
+
+// probe runs the probe against the selected pods in the namespace, the result is returned as a condition.
+func (p *healthProber) probe(ctx context.Context, podClient corev1client.PodsGetter,
+	namespace string, probe helpers.HealthProbe) metav1.Condition {
+	condition := metav1.Condition{
+		Type:   probeConditionTypePrefix + probe.Name,
+		Status: metav1.ConditionFalse,
+		Reason: reasonProbeFailed,
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&probe.Selector)
+	if err != nil {
+		condition.Reason = reasonProbeInvalid
+		condition.Message = fmt.Sprintf("invalid selector: %v", err)
+		return condition
+	}
+	pods, err := podClient.Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		condition.Status = metav1.ConditionUnknown
+		condition.Message = fmt.Sprintf("failed to list pods: %v", err)
+		return condition
+	}
+
+	probed := 0
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || len(pod.Status.PodIP) == 0 || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if err := p.probePod(ctx, &pod, probe); err != nil {
+			condition.Message = fmt.Sprintf("pod %s: %v", pod.Name, err)
+			return condition
+		}
+		probed++
+	}
+	if probed == 0 {
+		condition.Message = fmt.Sprintf("no running pod is selected by %q in namespace %q", selector.String(), namespace)
+		return condition
+	}
+
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = reasonProbeSucceeded
+	condition.Message = fmt.Sprintf("%d pods passed the probe", probed)
+	return condition
+}
+
+func (p *healthProber) probePod(ctx context.Context, pod *corev1.Pod, probe helpers.HealthProbe) error {
+	timeout := defaultProbeTimeout
+	if probe.TimeoutSeconds > 0 {
+		timeout = time.Duration(probe.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	address := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(probe.Port)))
+	switch probe.Type {
+	case helpers.TCPHealthProbe:
+		conn, err := p.dial(ctx, address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case helpers.HTTPHealthProbe:
+		return p.probeHTTP(ctx, address, probe)
+	default:
+		return fmt.Errorf("unsupported probe type %q", probe.Type)
+	}
+}
+
+func (p *healthProber) probeHTTP(ctx context.Context, address string, probe helpers.HealthProbe) error {
+	scheme := strings.ToLower(probe.Scheme)
+	if len(scheme) == 0 {
+		scheme = "http"
+	}
+	path := probe.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s%s", scheme, address, path), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpDo(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeResponseBytes))
+	if err != nil {
+		return err
+	}
+
+	if len(probe.Expression) == 0 {
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("http probe failed with status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+
+	result, err := p.evaluate(ctx, probe.Expression, probeResponse(resp.StatusCode, body))
+	if err != nil {
+		return err
+	}
+	if !result {
+		return fmt.Errorf("expression %q is false, status code %d", probe.Expression, resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *healthProber) evaluate(ctx context.Context, expression string, response map[string]any) (bool, error) {
+	ast, iss := p.env.Compile(expression)
+	if iss.Err() != nil {
+		return false, iss.Err()
+	}
+	prg, err := p.env.Program(ast,
+		cel.CostLimit(celconfig.PerCallLimit),
+		cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+	)
+	if err != nil {
+		return false, err
+	}
+	out, _, err := prg.ContextEval(ctx, map[string]any{"response": response})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate expression %q: %v", expression, err)
+	}
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expected bool result of expression %q, got %v", expression, reflect.TypeOf(out.Value()))
+	}
+	return result, nil
+}
+
+// probeResponse converts the http response to the input of the probe expression.
+func probeResponse(statusCode int, body []byte) map[string]any {
+	response := map[string]any{
+		"statusCode": int64(statusCode),
+		"body":       string(body),
+		"json":       nil,
+		"metrics":    map[string]float64{},
+	}
+
+	var jsonBody any
+	if err := json.Unmarshal(body, &jsonBody); err == nil {
+		response["json"] = jsonBody
+		return response
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(string(body)))
+	if err != nil {
+		return response
+	}
+	metrics := map[string]float64{}
+	for name, family := range families {
+		for _, metric := range family.Metric {
+			switch {
+			case metric.Gauge != nil:
+				metrics[name] += metric.Gauge.GetValue()
+			case metric.Counter != nil:
+				metrics[name] += metric.Counter.GetValue()
+			case metric.Untyped != nil:
+				metrics[name] += metric.Untyped.GetValue()
+			}
+		}
+	}
+	response["metrics"] = metrics
+	return response
+}
+
+// probeAvailableCondition aggregates the probe conditions into the available condition of the addon.
+func probeAvailableCondition(addOnName string, probeConditions []metav1.Condition) metav1.Condition {
+	var failed []string
+	for _, cond := range probeConditions {
+		if cond.Status != metav1.ConditionTrue {
+			failed = append(failed, fmt.Sprintf("probe %s: %s",
+				strings.TrimPrefix(cond.Type, probeConditionTypePrefix), cond.Message))
+		}
+	}
+
+	if len(failed) > 0 {
+		return metav1.Condition{
+			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ManagedClusterAddOnProbeFailed",
+			Message: fmt.Sprintf("%s add-on is not available, %s", addOnName, strings.Join(failed, "; ")),
+		}
+	}
+	return metav1.Condition{
+		Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ManagedClusterAddOnProbeSucceeded",
+		Message: fmt.Sprintf("%s add-on is available.", addOnName),
+	}
+}
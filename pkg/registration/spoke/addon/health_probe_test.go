@@ -0,0 +1,264 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newAddonPod(name, namespace, ip string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "addon"},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			PodIP: ip,
+		},
+	}
+}
+
+func newTestHealthProber(t *testing.T, statusCode int, body string) *healthProber {
+	prober, err := newHealthProber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prober.httpDo = func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+	prober.dial = func(ctx context.Context, address string) (net.Conn, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	return prober
+}
+
+func TestGetHealthProbes(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedProbes int
+		expectedErr    bool
+	}{
+		{
+			name: "no probes",
+		},
+		{
+			name: "probes",
+			annotations: map[string]string{
+				helpers.AddonHealthProbesAnnotationKey: `[{"name":"http","type":"HTTP","port":8080},{"name":"tcp","type":"TCP","port":8443}]`,
+			},
+			expectedProbes: 2,
+		},
+		{
+			name:        "invalid json",
+			annotations: map[string]string{helpers.AddonHealthProbesAnnotationKey: `{`},
+			expectedErr: true,
+		},
+		{
+			name: "duplicated name",
+			annotations: map[string]string{
+				helpers.AddonHealthProbesAnnotationKey: `[{"name":"http","type":"HTTP"},{"name":"http","type":"TCP"}]`,
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: c.annotations},
+			}
+			probes, err := getHealthProbes(addOn)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if len(probes) != c.expectedProbes {
+				t.Errorf("expected %d probes, got %d", c.expectedProbes, len(probes))
+			}
+		})
+	}
+}
+
+func TestHealthProbe(t *testing.T) {
+	selector := metav1.LabelSelector{MatchLabels: map[string]string{"app": "addon"}}
+	cases := []struct {
+		name           string
+		pods           []runtime.Object
+		statusCode     int
+		body           string
+		probe          helpers.HealthProbe
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "no running pods",
+			pods:           []runtime.Object{newAddonPod("addon", "test", "", corev1.PodPending)},
+			probe:          helpers.HealthProbe{Name: "http", Type: helpers.HTTPHealthProbe, Selector: selector, Port: 8080},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonProbeFailed,
+		},
+		{
+			name:           "http probe succeeded",
+			pods:           []runtime.Object{newAddonPod("addon", "test", "10.0.0.1", corev1.PodRunning)},
+			statusCode:     http.StatusOK,
+			probe:          helpers.HealthProbe{Name: "http", Type: helpers.HTTPHealthProbe, Selector: selector, Port: 8080},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: reasonProbeSucceeded,
+		},
+		{
+			name:           "http probe failed",
+			pods:           []runtime.Object{newAddonPod("addon", "test", "10.0.0.1", corev1.PodRunning)},
+			statusCode:     http.StatusServiceUnavailable,
+			probe:          helpers.HealthProbe{Name: "http", Type: helpers.HTTPHealthProbe, Selector: selector, Port: 8080},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonProbeFailed,
+		},
+		{
+			name: "json expression",
+			pods: []runtime.Object{
+				newAddonPod("addon1", "test", "10.0.0.1", corev1.PodRunning),
+				newAddonPod("addon2", "test", "10.0.0.2", corev1.PodRunning),
+			},
+			statusCode: http.StatusOK,
+			body:       `{"queue": {"depth": 3}}`,
+			probe: helpers.HealthProbe{Name: "json", Type: helpers.HTTPHealthProbe, Selector: selector, Port: 8080,
+				Expression: `response.statusCode == 200 && response.json.queue.depth < 10`},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: reasonProbeSucceeded,
+		},
+		{
+			name:       "metrics expression",
+			pods:       []runtime.Object{newAddonPod("addon", "test", "10.0.0.1", corev1.PodRunning)},
+			statusCode: http.StatusOK,
+			body:       "# TYPE queue_depth gauge\nqueue_depth{queue=\"a\"} 60\nqueue_depth{queue=\"b\"} 50\n",
+			probe: helpers.HealthProbe{Name: "metrics", Type: helpers.HTTPHealthProbe, Selector: selector, Port: 8080,
+				Expression: `response.metrics["queue_depth"] < 100.0`},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonProbeFailed,
+		},
+		{
+			name:           "tcp probe failed",
+			pods:           []runtime.Object{newAddonPod("addon", "test", "10.0.0.1", corev1.PodRunning)},
+			probe:          helpers.HealthProbe{Name: "tcp", Type: helpers.TCPHealthProbe, Selector: selector, Port: 8443},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonProbeFailed,
+		},
+		{
+			name:           "exec probe is not supported",
+			pods:           []runtime.Object{newAddonPod("addon", "test", "10.0.0.1", corev1.PodRunning)},
+			probe:          helpers.HealthProbe{Name: "exec", Type: "Exec", Selector: selector},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonProbeFailed,
+		},
+		{
+			name:           "unsupported probe",
+			pods:           []runtime.Object{newAddonPod("addon", "test", "10.0.0.1", corev1.PodRunning)},
+			probe:          helpers.HealthProbe{Name: "grpc", Type: "GRPC", Selector: selector},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: reasonProbeFailed,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewClientset(c.pods...)
+			prober := newTestHealthProber(t, c.statusCode, c.body)
+			cond := prober.probe(context.TODO(), kubeClient.CoreV1(), "test", c.probe)
+			if cond.Type != probeConditionTypePrefix+c.probe.Name {
+				t.Errorf("unexpected condition type %q", cond.Type)
+			}
+			if cond.Status != c.expectedStatus || cond.Reason != c.expectedReason {
+				t.Errorf("expected %s %s, got %v", c.expectedStatus, c.expectedReason, cond)
+			}
+		})
+	}
+}
+
+func TestSyncProbes(t *testing.T) {
+	addOn := &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      "test",
+			Annotations: map[string]string{
+				helpers.AddonHealthProbesAnnotationKey: `[{"name":"http","type":"HTTP","port":8080,` +
+					`"selector":{"matchLabels":{"app":"addon"}}}]`,
+			},
+		},
+		Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+			InstallNamespace: "test",
+		},
+		Status: addonv1alpha1.ManagedClusterAddOnStatus{
+			Conditions: []metav1.Condition{
+				{Type: probeConditionTypePrefix + "removed", Status: metav1.ConditionTrue},
+			},
+		},
+	}
+	addOnClient := addonfake.NewSimpleClientset(addOn)
+	addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+	if err := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(addOn); err != nil {
+		t.Fatal(err)
+	}
+	spokeKubeClient := kubefake.NewClientset(newAddonPod("addon", "test", "10.0.0.1", corev1.PodRunning))
+
+	ctrl := &managedClusterAddOnLeaseController{
+		clusterName: testinghelpers.TestManagedClusterName,
+		clock:       clocktesting.NewFakeClock(time.Now()),
+		patcher: patcher.NewPatcher[
+			*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnSpec, addonv1alpha1.ManagedClusterAddOnStatus](
+			addOnClient.AddonV1alpha1().ManagedClusterAddOns(testinghelpers.TestManagedClusterName)),
+		addOnLister:           addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+		managementLeaseClient: kubefake.NewClientset().CoordinationV1(),
+		spokeLeaseClient:      spokeKubeClient.CoordinationV1(),
+		managementPodClient:   kubefake.NewClientset().CoreV1(),
+		spokePodClient:        spokeKubeClient.CoreV1(),
+		prober:                newTestHealthProber(t, http.StatusOK, ""),
+	}
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	patch := actions[0].(clienttesting.PatchAction).GetPatch()
+	patched := &addonv1alpha1.ManagedClusterAddOn{}
+	if err := json.Unmarshal(patch, patched); err != nil {
+		t.Fatal(err)
+	}
+	if cond := meta.FindStatusCondition(patched.Status.Conditions, "Available"); cond == nil ||
+		cond.Status != metav1.ConditionTrue || cond.Reason != "ManagedClusterAddOnProbeSucceeded" {
+		t.Errorf("expected addon available by probes, got %v", cond)
+	}
+	if cond := meta.FindStatusCondition(patched.Status.Conditions, probeConditionTypePrefix+"http"); cond == nil ||
+		cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected probe condition succeeded, got %v", cond)
+	}
+	if cond := meta.FindStatusCondition(patched.Status.Conditions, probeConditionTypePrefix+"removed"); cond != nil {
+		t.Errorf("expected condition of removed probe is deleted, got %v", cond)
+	}
+}
@@ -3,6 +3,8 @@ package addon
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -12,7 +14,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	coordv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/utils/clock"
 
@@ -21,6 +25,8 @@ import (
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 const leaseDurationTimes = 5
@@ -39,6 +45,9 @@ type managedClusterAddOnLeaseController struct {
 	addOnLister           addonlisterv1alpha1.ManagedClusterAddOnLister
 	managementLeaseClient coordv1client.CoordinationV1Interface
 	spokeLeaseClient      coordv1client.CoordinationV1Interface
+	managementPodClient   corev1client.PodsGetter
+	spokePodClient        corev1client.PodsGetter
+	prober                *healthProber
 }
 
 // NewManagedClusterAddOnLeaseController returns an instance of managedClusterAddOnLeaseController
@@ -47,8 +56,13 @@ func NewManagedClusterAddOnLeaseController(clusterName string,
 	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
 	managementLeaseClient coordv1client.CoordinationV1Interface,
 	spokeLeaseClient coordv1client.CoordinationV1Interface,
+	managementPodClient corev1client.PodsGetter,
+	spokePodClient corev1client.PodsGetter,
 	resyncInterval time.Duration,
 	recorder events.Recorder) factory.Controller {
+	prober, err := newHealthProber()
+	utilruntime.Must(err)
+
 	c := &managedClusterAddOnLeaseController{
 		clusterName: clusterName,
 		clock:       clock.RealClock{},
@@ -58,6 +72,9 @@ func NewManagedClusterAddOnLeaseController(clusterName string,
 		addOnLister:           addOnInformer.Lister(),
 		managementLeaseClient: managementLeaseClient,
 		spokeLeaseClient:      spokeLeaseClient,
+		managementPodClient:   managementPodClient,
+		spokePodClient:        spokePodClient,
+		prober:                prober,
 	}
 
 	// TODO We do not add leaser informer to support kubernetes version lower than 1.17. Lease v1 api
@@ -105,9 +122,42 @@ func (c *managedClusterAddOnLeaseController) sync(ctx context.Context, syncCtx f
 		return nil
 	}
 
+	// the addon health is determined by the probes instead of the lease if the probes are defined.
+	probes, err := getHealthProbes(addOn)
+	if err != nil {
+		return c.updateAvailableCondition(ctx, addOn, metav1.Condition{
+			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "ManagedClusterAddOnProbeInvalid",
+			Message: err.Error(),
+		}, nil, syncCtx.Recorder())
+	}
+	if len(probes) > 0 {
+		return c.syncProbes(ctx, addOnNamespace, addOn, probes, syncCtx.Recorder())
+	}
+
 	return c.syncSingle(ctx, addOnNamespace, addOn, syncCtx.Recorder())
 }
 
+func (c *managedClusterAddOnLeaseController) syncProbes(ctx context.Context,
+	addOnNamespace string,
+	addOn *addonv1alpha1.ManagedClusterAddOn,
+	probes []helpers.HealthProbe,
+	recorder events.Recorder) error {
+	podClient := c.spokePodClient
+	if isAddonRunningOutsideManagedCluster(addOn) {
+		podClient = c.managementPodClient
+	}
+
+	var probeConditions []metav1.Condition
+	for _, probe := range probes {
+		probeConditions = append(probeConditions, c.prober.probe(ctx, podClient, addOnNamespace, probe))
+	}
+
+	return c.updateAvailableCondition(ctx, addOn, probeAvailableCondition(addOn.Name, probeConditions),
+		probeConditions, recorder)
+}
+
 func (c *managedClusterAddOnLeaseController) syncSingle(ctx context.Context,
 	leaseNamespace string,
 	addOn *addonv1alpha1.ManagedClusterAddOn,
@@ -157,7 +207,26 @@ func (c *managedClusterAddOnLeaseController) syncSingle(ctx context.Context,
 		}
 	}
 
+	return c.updateAvailableCondition(ctx, addOn, condition, nil, recorder)
+}
+
+// updateAvailableCondition updates the available condition and the probe conditions of the addon, the
+// conditions of the probes which no longer exist are removed.
+func (c *managedClusterAddOnLeaseController) updateAvailableCondition(ctx context.Context,
+	addOn *addonv1alpha1.ManagedClusterAddOn,
+	condition metav1.Condition,
+	probeConditions []metav1.Condition,
+	recorder events.Recorder) error {
 	newAddon := addOn.DeepCopy()
+	newAddon.Status.Conditions = slices.DeleteFunc(newAddon.Status.Conditions, func(cond metav1.Condition) bool {
+		return strings.HasPrefix(cond.Type, probeConditionTypePrefix) &&
+			!slices.ContainsFunc(probeConditions, func(probeCond metav1.Condition) bool {
+				return probeCond.Type == cond.Type
+			})
+	})
+	for _, probeCondition := range probeConditions {
+		meta.SetStatusCondition(&newAddon.Status.Conditions, probeCondition)
+	}
 	meta.SetStatusCondition(&newAddon.Status.Conditions, condition)
 	updated, err := c.patcher.PatchStatus(ctx, newAddon, newAddon.Status, addOn.Status)
 	if err != nil {
@@ -165,8 +234,8 @@ func (c *managedClusterAddOnLeaseController) syncSingle(ctx context.Context,
 	}
 	if updated {
 		recorder.Eventf("ManagedClusterAddOnStatusUpdated",
-			"update managed cluster addon %q available condition to %q with its lease %q/%q status",
-			addOn.Name, condition.Status, leaseNamespace, addOn.Name)
+			"update managed cluster addon %q available condition to %q with reason %q",
+			addOn.Name, condition.Status, condition.Reason)
 	}
 
 	return nil
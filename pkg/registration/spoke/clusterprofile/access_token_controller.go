@@ -0,0 +1,133 @@
+package clusterprofile
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/common/queue"
+)
+
+// accessTokenController requests a token of the ClusterProfile access service account when the hub changes the
+// generation of the token configmap, and writes the token encrypted with the public key of the hub into the
+// configmap, so the token is never returned to the hub in plaintext.
+type accessTokenController struct {
+	kubeClient      kubernetes.Interface
+	configMapLister corev1listers.ConfigMapLister
+	recorder        events.Recorder
+}
+
+// NewAccessTokenController returns a controller requesting the tokens of the ClusterProfile access. The informer
+// should only watch the token configmap in the ClusterProfile access namespace.
+func NewAccessTokenController(
+	kubeClient kubernetes.Interface,
+	configMapInformer corev1informers.ConfigMapInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &accessTokenController{
+		kubeClient:      kubeClient,
+		configMapLister: configMapInformer.Lister(),
+		recorder:        recorder,
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeysFunc(
+			queue.QueueKeyByMetaName,
+			queue.FilterByNames(helpers.ClusterProfileAccessTokenConfigMapName),
+			configMapInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterProfileAccessTokenController", recorder)
+}
+
+func (c *accessTokenController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Reconciling the ClusterProfile access token")
+
+	configMap, err := c.configMapLister.ConfigMaps(helpers.ClusterProfileAccessNamespace).Get(
+		helpers.ClusterProfileAccessTokenConfigMapName)
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	generation := configMap.Annotations[helpers.ClusterProfileAccessGenerationAnnotationKey]
+	if len(generation) == 0 {
+		return nil
+	}
+	publicKey, err := encryption.ParsePublicKey(configMap.Annotations[helpers.ClusterProfileAccessPublicKeyAnnotationKey])
+	if err != nil {
+		return fmt.Errorf("invalid public key of the ClusterProfile access: %v", err)
+	}
+	// a new token is requested when the generation or the key of the hub is changed.
+	if configMap.Data[helpers.ClusterProfileAccessGenerationKey] == generation &&
+		tokenKeyID(configMap.Data[helpers.ClusterProfileAccessTokenKey]) == encryption.KeyID(publicKey) {
+		return nil
+	}
+	expirationSeconds, err := strconv.ParseInt(configMap.Annotations[helpers.ClusterProfileAccessExpirationAnnotationKey], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiration seconds of the ClusterProfile access: %v", err)
+	}
+
+	tokenRequest, err := c.kubeClient.CoreV1().ServiceAccounts(helpers.ClusterProfileAccessNamespace).CreateToken(ctx,
+		helpers.ClusterProfileAccessServiceAccount,
+		&authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+		},
+		metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryption.EncryptData(publicKey, []byte(tokenRequest.Status.Token))
+	if err != nil {
+		return err
+	}
+	encryptedData, err := json.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+
+	updated := configMap.DeepCopy()
+	if updated.Data == nil {
+		updated.Data = map[string]string{}
+	}
+	updated.Data[helpers.ClusterProfileAccessTokenKey] = base64.StdEncoding.EncodeToString(encryptedData)
+	updated.Data[helpers.ClusterProfileAccessGenerationKey] = generation
+	updated.Data[helpers.ClusterProfileAccessExpirationKey] = tokenRequest.Status.ExpirationTimestamp.UTC().Format(time.RFC3339)
+	if _, err := c.kubeClient.CoreV1().ConfigMaps(helpers.ClusterProfileAccessNamespace).Update(
+		ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	c.recorder.Eventf("ClusterProfileAccessTokenIssued", "the token of generation %s is issued", generation)
+	return nil
+}
+
+// tokenKeyID returns the id of the key the token is encrypted with, it is empty if the token is invalid.
+func tokenKeyID(token string) string {
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return ""
+	}
+	encrypted := &encryption.EncryptedData{}
+	if err := json.Unmarshal(data, encrypted); err != nil {
+		return ""
+	}
+	return encrypted.KeyID
+}
@@ -0,0 +1,178 @@
+package clusterprofile
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func newTokenConfigMap(generation, publicKey string, data map[string]string) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      helpers.ClusterProfileAccessTokenConfigMapName,
+			Namespace: helpers.ClusterProfileAccessNamespace,
+			Annotations: map[string]string{
+				helpers.ClusterProfileAccessExpirationAnnotationKey: "7200",
+				helpers.ClusterProfileAccessPublicKeyAnnotationKey:  publicKey,
+			},
+		},
+		Data: data,
+	}
+	if len(generation) > 0 {
+		configMap.Annotations[helpers.ClusterProfileAccessGenerationAnnotationKey] = generation
+	}
+	return configMap
+}
+
+func TestSync(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := encryption.EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiration := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	encrypted, err := encryption.EncryptData(&key.PublicKey, []byte("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedData, err := json.Marshal(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedToken := base64.StdEncoding.EncodeToString(encryptedData)
+
+	cases := []struct {
+		name            string
+		configMap       *corev1.ConfigMap
+		expectedActions []string
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "no configmap",
+			expectedActions: []string{},
+		},
+		{
+			name:            "no generation",
+			configMap:       newTokenConfigMap("", publicKey, nil),
+			expectedActions: []string{},
+		},
+		{
+			name: "token is issued",
+			configMap: newTokenConfigMap("1", publicKey, map[string]string{
+				helpers.ClusterProfileAccessGenerationKey: "1",
+				helpers.ClusterProfileAccessTokenKey:      encryptedToken,
+			}),
+			expectedActions: []string{},
+		},
+		{
+			name: "key is changed",
+			configMap: newTokenConfigMap("1", publicKey, map[string]string{
+				helpers.ClusterProfileAccessGenerationKey: "1",
+				helpers.ClusterProfileAccessTokenKey:      "invalid",
+			}),
+			expectedActions: []string{"create", "update"},
+		},
+		{
+			name: "issue token",
+			configMap: newTokenConfigMap("2", publicKey, map[string]string{
+				helpers.ClusterProfileAccessGenerationKey: "1",
+				helpers.ClusterProfileAccessTokenKey:      encryptedToken,
+			}),
+			expectedActions: []string{"create", "update"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				tokenRequest := actions[0].(clienttesting.CreateActionImpl).Object.(*authenticationv1.TokenRequest)
+				if *tokenRequest.Spec.ExpirationSeconds != 7200 {
+					t.Errorf("expected expiration seconds 7200, got %d", *tokenRequest.Spec.ExpirationSeconds)
+				}
+
+				configMap := actions[1].(clienttesting.UpdateActionImpl).Object.(*corev1.ConfigMap)
+				if configMap.Data[helpers.ClusterProfileAccessGenerationKey] != "2" {
+					t.Errorf("expected generation 2, got %v", configMap.Data)
+				}
+				if configMap.Data[helpers.ClusterProfileAccessExpirationKey] != expiration.Format(time.RFC3339) {
+					t.Errorf("unexpected expiration %v", configMap.Data)
+				}
+				if configMap.Data[helpers.ClusterProfileAccessTokenKey] == "token" {
+					t.Errorf("expected the token is encrypted")
+				}
+				data, err := base64.StdEncoding.DecodeString(configMap.Data[helpers.ClusterProfileAccessTokenKey])
+				if err != nil {
+					t.Fatal(err)
+				}
+				encrypted := &encryption.EncryptedData{}
+				if err := json.Unmarshal(data, encrypted); err != nil {
+					t.Fatal(err)
+				}
+				token, err := encryption.DecryptData(key, encrypted)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(token) != "token" {
+					t.Errorf("expected token, got %s", token)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if c.configMap != nil {
+				objects = append(objects, c.configMap)
+			}
+			kubeClient := kubefake.NewSimpleClientset(objects...)
+			kubeClient.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "token" {
+					return false, nil, nil
+				}
+				tokenRequest := action.(clienttesting.CreateActionImpl).Object.(*authenticationv1.TokenRequest).DeepCopy()
+				tokenRequest.Status = authenticationv1.TokenRequestStatus{
+					Token:               "token",
+					ExpirationTimestamp: metav1.NewTime(expiration),
+				}
+				return true, tokenRequest, nil
+			})
+
+			informerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			if c.configMap != nil {
+				if err := informerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(c.configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := &accessTokenController{
+				kubeClient:      kubeClient,
+				configMapLister: informerFactory.Core().V1().ConfigMaps().Lister(),
+				recorder:        eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncContext := testingcommon.NewFakeSyncContext(t, helpers.ClusterProfileAccessTokenConfigMapName)
+			if err := controller.sync(context.TODO(), syncContext); err != nil {
+				t.Fatal(err)
+			}
+
+			testingcommon.AssertActions(t, kubeClient.Actions(), c.expectedActions...)
+			if c.validateActions != nil {
+				c.validateActions(t, kubeClient.Actions())
+			}
+		})
+	}
+}
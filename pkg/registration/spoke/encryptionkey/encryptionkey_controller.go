@@ -0,0 +1,158 @@
+package encryptionkey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	clusterv1alpha1client "open-cluster-management.io/api/client/cluster/clientset/versioned/typed/cluster/v1alpha1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/common/queue"
+)
+
+// encryptionKeyController generates the key pair of the managed cluster into a secret in the agent namespace, and
+// publishes the public key with a cluster claim, so the hub is able to encrypt the manifests delivered to the
+// cluster, and only the work agent is able to decrypt them.
+type encryptionKeyController struct {
+	secretNamespace string
+	secretClient    corev1client.SecretsGetter
+	secretLister    corev1listers.SecretLister
+	claimClient     clusterv1alpha1client.ClusterClaimInterface
+	recorder        events.Recorder
+}
+
+// NewEncryptionKeyController returns a controller publishing the encryption key of the managed cluster
+func NewEncryptionKeyController(
+	secretNamespace string,
+	secretClient corev1client.SecretsGetter,
+	secretInformer corev1informers.SecretInformer,
+	claimClient clusterv1alpha1client.ClusterClaimInterface,
+	recorder events.Recorder) factory.Controller {
+	c := &encryptionKeyController{
+		secretNamespace: secretNamespace,
+		secretClient:    secretClient,
+		secretLister:    secretInformer.Lister(),
+		claimClient:     claimClient,
+		recorder:        recorder,
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeysFunc(
+			queue.QueueKeyByMetaName,
+			queue.FilterByNames(encryption.KeySecretName),
+			secretInformer.Informer()).
+		WithSync(c.sync).
+		// the cluster claim is not watched, resync to recover it once it is changed.
+		ResyncEvery(5*time.Minute).
+		ToController("EncryptionKeyController", recorder)
+}
+
+func (c *encryptionKeyController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Reconciling the encryption key", "secretName", encryption.KeySecretName)
+
+	publicKey, err := c.ensureKeySecret(ctx)
+	if err != nil {
+		return err
+	}
+
+	claim, err := c.claimClient.Get(ctx, encryption.PublicKeyClusterClaimName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = c.claimClient.Create(ctx, &clusterv1alpha1.ClusterClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: encryption.PublicKeyClusterClaimName,
+			},
+			Spec: clusterv1alpha1.ClusterClaimSpec{
+				Value: publicKey,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		c.recorder.Eventf("EncryptionKeyPublished", "The public encryption key is published with the cluster claim %s",
+			encryption.PublicKeyClusterClaimName)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if claim.Spec.Value == publicKey {
+		return nil
+	}
+	claim = claim.DeepCopy()
+	claim.Spec.Value = publicKey
+	_, err = c.claimClient.Update(ctx, claim, metav1.UpdateOptions{})
+	return err
+}
+
+// ensureKeySecret returns the public key of the key secret, a new key pair is generated if the secret does not
+// exist or the private key is invalid.
+func (c *encryptionKeyController) ensureKeySecret(ctx context.Context) (string, error) {
+	secret, err := c.secretLister.Secrets(c.secretNamespace).Get(encryption.KeySecretName)
+	switch {
+	case errors.IsNotFound(err):
+		secret = nil
+	case err != nil:
+		return "", err
+	}
+
+	if secret != nil {
+		privateKey, err := encryption.ParsePrivateKey(secret.Data[encryption.PrivateKeyDataKey])
+		if err == nil {
+			return encryption.EncodePublicKey(&privateKey.PublicKey)
+		}
+		klog.FromContext(ctx).Error(err, "The encryption key is invalid, regenerate it", "secretName", secret.Name)
+	}
+
+	privateKey, err := encryption.GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	privateKeyData, err := encryption.EncodePrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	publicKey, err := encryption.EncodePublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string][]byte{
+		encryption.PrivateKeyDataKey: privateKeyData,
+		encryption.PublicKeyDataKey:  []byte(publicKey),
+	}
+	if secret == nil {
+		_, err = c.secretClient.Secrets(c.secretNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      encryption.KeySecretName,
+				Namespace: c.secretNamespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}, metav1.CreateOptions{})
+	} else {
+		secret = secret.DeepCopy()
+		secret.Data = data
+		_, err = c.secretClient.Secrets(c.secretNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save the encryption key: %w", err)
+	}
+
+	c.recorder.Eventf("EncryptionKeyGenerated", "The encryption key is generated in secret %s/%s",
+		c.secretNamespace, encryption.KeySecretName)
+	return publicKey, nil
+}
@@ -0,0 +1,152 @@
+package encryptionkey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+const testNamespace = "open-cluster-management-agent"
+
+func newKeySecret(t *testing.T) (*corev1.Secret, string) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyData, err := encryption.EncodePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := encryption.EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      encryption.KeySecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			encryption.PrivateKeyDataKey: privateKeyData,
+			encryption.PublicKeyDataKey:  []byte(publicKey),
+		},
+	}, publicKey
+}
+
+func newClaim(value string) *clusterv1alpha1.ClusterClaim {
+	return &clusterv1alpha1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: encryption.PublicKeyClusterClaimName},
+		Spec:       clusterv1alpha1.ClusterClaimSpec{Value: value},
+	}
+}
+
+func TestSync(t *testing.T) {
+	keySecret, publicKey := newKeySecret(t)
+	invalidSecret := keySecret.DeepCopy()
+	invalidSecret.Data[encryption.PrivateKeyDataKey] = []byte("invalid")
+
+	cases := []struct {
+		name                 string
+		secret               *corev1.Secret
+		claim                *clusterv1alpha1.ClusterClaim
+		expectedKubeActions  []string
+		expectedClaimActions []string
+		validateClaim        func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:                 "generate key",
+			expectedKubeActions:  []string{"create"},
+			expectedClaimActions: []string{"get", "create"},
+			validateClaim: func(t *testing.T, actions []clienttesting.Action) {
+				claim := actions[1].(clienttesting.CreateActionImpl).Object.(*clusterv1alpha1.ClusterClaim)
+				if _, err := encryption.ParsePublicKey(claim.Spec.Value); err != nil {
+					t.Errorf("expected the public key is published, got %v", err)
+				}
+			},
+		},
+		{
+			name:                 "regenerate invalid key",
+			secret:               invalidSecret,
+			claim:                newClaim(publicKey),
+			expectedKubeActions:  []string{"update"},
+			expectedClaimActions: []string{"get", "update"},
+		},
+		{
+			name:                 "publish existing key",
+			secret:               keySecret,
+			expectedClaimActions: []string{"get", "create"},
+			validateClaim: func(t *testing.T, actions []clienttesting.Action) {
+				claim := actions[1].(clienttesting.CreateActionImpl).Object.(*clusterv1alpha1.ClusterClaim)
+				if claim.Spec.Value != publicKey {
+					t.Errorf("expected the existing public key is published")
+				}
+			},
+		},
+		{
+			name:                 "key is published",
+			secret:               keySecret,
+			claim:                newClaim(publicKey),
+			expectedClaimActions: []string{"get"},
+		},
+		{
+			name:                 "claim is changed",
+			secret:               keySecret,
+			claim:                newClaim("changed"),
+			expectedClaimActions: []string{"get", "update"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var kubeObjects, claimObjects []runtime.Object
+			if c.secret != nil {
+				kubeObjects = append(kubeObjects, c.secret)
+			}
+			if c.claim != nil {
+				claimObjects = append(claimObjects, c.claim)
+			}
+			kubeClient := kubefake.NewSimpleClientset(kubeObjects...)
+			clusterClient := clusterfake.NewSimpleClientset(claimObjects...)
+
+			informerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			if c.secret != nil {
+				if err := informerFactory.Core().V1().Secrets().Informer().GetStore().Add(c.secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := &encryptionKeyController{
+				secretNamespace: testNamespace,
+				secretClient:    kubeClient.CoreV1(),
+				secretLister:    informerFactory.Core().V1().Secrets().Lister(),
+				claimClient:     clusterClient.ClusterV1alpha1().ClusterClaims(),
+				recorder:        eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncContext := testingcommon.NewFakeSyncContext(t, encryption.KeySecretName)
+			if err := controller.sync(context.TODO(), syncContext); err != nil {
+				t.Fatal(err)
+			}
+
+			testingcommon.AssertActions(t, kubeClient.Actions(), c.expectedKubeActions...)
+			testingcommon.AssertActions(t, clusterClient.Actions(), c.expectedClaimActions...)
+			if c.validateClaim != nil {
+				c.validateClaim(t, clusterClient.Actions())
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	ocmfeature "open-cluster-management.io/api/feature"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	"open-cluster-management.io/ocm/pkg/features"
 )
 
@@ -29,6 +30,8 @@ type claimReconcile struct {
 	aboutLister                  aboutv1alpha1listers.ClusterPropertyLister
 	maxCustomClusterClaims       int
 	reservedClusterClaimSuffixes []string
+	// agentClaims are the reserved claims reported by the agent itself, they override the claims with the same name.
+	agentClaims []clusterv1.ManagedClusterClaim
 }
 
 func (r *claimReconcile) reconcile(ctx context.Context, cluster *clusterv1.ManagedCluster) (*clusterv1.ManagedCluster, reconcileState, error) {
@@ -88,9 +91,18 @@ func (r *claimReconcile) exposeClaims(_ context.Context, cluster *clusterv1.Mana
 		}
 	}
 
+	for _, claim := range r.agentClaims {
+		claimsMap[claim.Name] = claim
+	}
+
 	// check if the cluster claim is one of the reserved claims or has a reserved suffix.
 	// if so, it will be treated as a reserved claim and will always be exposed.
 	reservedClaimNames := sets.New(clusterv1alpha1.ReservedClusterClaimNames[:]...)
+	// the public key is always exposed, otherwise the hub cannot deliver encrypted manifests to the cluster.
+	reservedClaimNames.Insert(encryption.PublicKeyClusterClaimName)
+	for _, claim := range r.agentClaims {
+		reservedClaimNames.Insert(claim.Name)
+	}
 	reservedClaimSuffixes := sets.New(r.reservedClusterClaimSuffixes...)
 
 	for _, managedClusterClaim := range claimsMap {
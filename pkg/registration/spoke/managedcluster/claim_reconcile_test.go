@@ -214,6 +214,7 @@ func TestSync(t *testing.T) {
 				kubeInformerFactory.Core().V1().Nodes(),
 				20,
 				[]string{},
+				nil,
 				eventstesting.NewTestingEventRecorder(t),
 				hubEventRecorder,
 			)
@@ -234,6 +235,7 @@ func TestExposeClaims(t *testing.T) {
 		properties                   []*aboutv1alpha1.ClusterProperty
 		maxCustomClusterClaims       int
 		reservedClusterClaimSuffixes []string
+		agentClaims                  []clusterv1.ManagedClusterClaim
 		validateActions              func(t *testing.T, actions []clienttesting.Action)
 		expectedErr                  string
 	}{
@@ -344,6 +346,58 @@ func TestExposeClaims(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:    "expose agent claims as reserved claims",
+			cluster: testinghelpers.NewJoinedManagedCluster(),
+			claims: []*clusterv1alpha1.ClusterClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "a",
+					},
+					Spec: clusterv1alpha1.ClusterClaimSpec{
+						Value: "b",
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: helpers.KlusterletNameClusterClaimName,
+					},
+					Spec: clusterv1alpha1.ClusterClaimSpec{
+						Value: "fake",
+					},
+				},
+			},
+			agentClaims: []clusterv1.ManagedClusterClaim{
+				{
+					Name:  helpers.KlusterletNameClusterClaimName,
+					Value: "klusterlet",
+				},
+			},
+			maxCustomClusterClaims: 20,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				cluster := &clusterv1.ManagedCluster{}
+				err := json.Unmarshal(patch, cluster)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := []clusterv1.ManagedClusterClaim{
+					{
+						Name:  helpers.KlusterletNameClusterClaimName,
+						Value: "klusterlet",
+					},
+					{
+						Name:  "a",
+						Value: "b",
+					},
+				}
+				actual := cluster.Status.ClusterClaims
+				if !reflect.DeepEqual(actual, expected) {
+					t.Errorf("expected cluster claim %v but got: %v", expected, actual)
+				}
+			},
+		},
 		{
 			name:    "keep custom reserved cluster claims",
 			cluster: testinghelpers.NewJoinedManagedCluster(),
@@ -590,6 +644,7 @@ func TestExposeClaims(t *testing.T) {
 				kubeInformerFactory.Core().V1().Nodes(),
 				c.maxCustomClusterClaims,
 				c.reservedClusterClaimSuffixes,
+				c.agentClaims,
 				eventstesting.NewTestingEventRecorder(t),
 				hubEventRecorder,
 			)
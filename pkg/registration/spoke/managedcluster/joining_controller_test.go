@@ -102,6 +102,7 @@ func TestSyncManagedCluster(t *testing.T) {
 				kubeInformerFactory.Core().V1().Nodes(),
 				20,
 				[]string{},
+				nil,
 				eventstesting.NewTestingEventRecorder(t),
 				hubEventRecorder,
 			)
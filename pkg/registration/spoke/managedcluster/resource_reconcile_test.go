@@ -336,6 +336,7 @@ func TestHealthCheck(t *testing.T) {
 				kubeInformerFactory.Core().V1().Nodes(),
 				20,
 				[]string{},
+				nil,
 				eventstesting.NewTestingEventRecorder(t),
 				hubEventRecorder,
 			)
@@ -60,6 +60,7 @@ func NewManagedClusterStatusController(
 	nodeInformer corev1informers.NodeInformer,
 	maxCustomClusterClaims int,
 	reservedClusterClaimSuffixes []string,
+	agentClaims []clusterv1.ManagedClusterClaim,
 	resyncInterval time.Duration,
 	recorder events.Recorder,
 	hubEventRecorder kevents.EventRecorder) factory.Controller {
@@ -73,6 +74,7 @@ func NewManagedClusterStatusController(
 		nodeInformer,
 		maxCustomClusterClaims,
 		reservedClusterClaimSuffixes,
+		agentClaims,
 		recorder,
 		hubEventRecorder,
 	)
@@ -101,6 +103,7 @@ func newManagedClusterStatusController(
 	nodeInformer corev1informers.NodeInformer,
 	maxCustomClusterClaims int,
 	reservedClusterClaimSuffixes []string,
+	agentClaims []clusterv1.ManagedClusterClaim,
 	recorder events.Recorder,
 	hubEventRecorder kevents.EventRecorder) *managedClusterStatusController {
 	return &managedClusterStatusController{
@@ -114,6 +117,7 @@ func newManagedClusterStatusController(
 			&claimReconcile{claimLister: claimInformer.Lister(), recorder: recorder,
 				maxCustomClusterClaims:       maxCustomClusterClaims,
 				reservedClusterClaimSuffixes: reservedClusterClaimSuffixes,
+				agentClaims:                  agentClaims,
 				aboutLister:                  propertyInformer.Lister(),
 			},
 		},
@@ -9,6 +9,7 @@ import (
 
 	ocmfeature "open-cluster-management.io/api/feature"
 
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	registerfactory "open-cluster-management.io/ocm/pkg/registration/register/factory"
@@ -37,6 +38,26 @@ type SpokeAgentOptions struct {
 	ReservedClusterClaimSuffixes []string
 	ClusterAnnotations           map[string]string
 
+	// KlusterletName and KlusterletInstallMode are the name and the install mode of the klusterlet deploying the
+	// agent, they are reported with the reserved cluster claims.
+	KlusterletName        string
+	KlusterletInstallMode string
+
+	// EnableHubTunnel enables the tunnel to proxy the requests from the hub to the kube-apiserver of the managed
+	// cluster over the outbound connection of the agent, it is only supported by the grpc registration driver.
+	EnableHubTunnel bool
+	// HubTunnelNamespace is the namespace of the hub tunnel service account on the managed cluster, it is the
+	// component namespace if it is empty.
+	HubTunnelNamespace string
+
+	// EnableEncryptionKey generates the key pair of the managed cluster and publishes the public key with a
+	// cluster claim, so the hub is able to deliver encrypted manifests to the cluster.
+	EnableEncryptionKey bool
+
+	// EnableClusterProfileAccess requests the tokens of the ClusterProfile access service account provisioned by the
+	// hub, and returns them encrypted with the public key of the hub.
+	EnableClusterProfileAccess bool
+
 	RegisterDriverOption *registerfactory.Options
 }
 
@@ -76,6 +97,18 @@ func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 		"A list of suffixes for reserved cluster claims.")
 	fs.StringToStringVar(&o.ClusterAnnotations, "cluster-annotations", o.ClusterAnnotations, `the annotations with the reserve
 	 prefix "agent.open-cluster-management.io" set on ManagedCluster when creating only, other actors can update it afterwards.`)
+	fs.StringVar(&o.KlusterletName, "klusterlet-name", o.KlusterletName,
+		"The name of the klusterlet deploying the agent, it is reported with a cluster claim.")
+	fs.StringVar(&o.KlusterletInstallMode, "klusterlet-install-mode", o.KlusterletInstallMode,
+		"The install mode of the klusterlet deploying the agent, it is reported with a cluster claim.")
+	fs.BoolVar(&o.EnableHubTunnel, "enable-hub-tunnel", o.EnableHubTunnel,
+		"Enable the tunnel to proxy the requests from the hub to the managed cluster kube-apiserver, only supported with the grpc registration auth.")
+	fs.StringVar(&o.HubTunnelNamespace, "hub-tunnel-namespace", o.HubTunnelNamespace,
+		"The namespace of the hub tunnel service account on the managed cluster, the component namespace is used if it is empty.")
+	fs.BoolVar(&o.EnableEncryptionKey, "enable-encryption-key", o.EnableEncryptionKey,
+		"Generate the encryption key of the managed cluster and publish the public key with a cluster claim, so the hub is able to deliver encrypted manifests.")
+	fs.BoolVar(&o.EnableClusterProfileAccess, "enable-cluster-profile-access", o.EnableClusterProfileAccess,
+		"Request the tokens of the ClusterProfile access service account provisioned by the hub and return them encrypted with the public key of the hub.")
 
 	o.RegisterDriverOption.AddFlags(fs)
 }
@@ -102,6 +135,10 @@ func (o *SpokeAgentOptions) Validate() error {
 		return errors.New("cluster healthcheck period must greater than zero")
 	}
 
+	if o.EnableHubTunnel && o.RegisterDriverOption.RegistrationAuth != commonhelpers.GRPCCAuthType {
+		return fmt.Errorf("hub tunnel is only supported with the %s registration auth", commonhelpers.GRPCCAuthType)
+	}
+
 	if err := o.RegisterDriverOption.Validate(); err != nil {
 		return err
 	}
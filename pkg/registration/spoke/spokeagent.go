@@ -9,6 +9,8 @@ import (
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/informers"
@@ -21,6 +23,7 @@ import (
 	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterscheme "open-cluster-management.io/api/client/cluster/clientset/versioned/scheme"
 	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	ocmfeature "open-cluster-management.io/api/feature"
 
 	"open-cluster-management.io/ocm/pkg/common/helpers"
@@ -28,9 +31,12 @@ import (
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/registration/register"
 	"open-cluster-management.io/ocm/pkg/registration/spoke/addon"
+	"open-cluster-management.io/ocm/pkg/registration/spoke/clusterprofile"
+	"open-cluster-management.io/ocm/pkg/registration/spoke/encryptionkey"
 	"open-cluster-management.io/ocm/pkg/registration/spoke/lease"
 	"open-cluster-management.io/ocm/pkg/registration/spoke/managedcluster"
 	"open-cluster-management.io/ocm/pkg/registration/spoke/registration"
+	"open-cluster-management.io/ocm/pkg/registration/spoke/tunnel"
 )
 
 // AddOnLeaseControllerSyncInterval is exposed so that integration tests can crank up the controller sync speed.
@@ -358,6 +364,20 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	if err != nil {
 		return fmt.Errorf("failed to create event recorder: %w", err)
 	}
+	var agentClaims []clusterv1.ManagedClusterClaim
+	if len(o.registrationOption.KlusterletName) > 0 {
+		agentClaims = append(agentClaims, clusterv1.ManagedClusterClaim{
+			Name:  helpers.KlusterletNameClusterClaimName,
+			Value: o.registrationOption.KlusterletName,
+		})
+	}
+	if len(o.registrationOption.KlusterletInstallMode) > 0 {
+		agentClaims = append(agentClaims, clusterv1.ManagedClusterClaim{
+			Name:  helpers.KlusterletInstallModeClusterClaimName,
+			Value: o.registrationOption.KlusterletInstallMode,
+		})
+	}
+
 	// create NewManagedClusterStatusController to update the spoke cluster status
 	managedClusterHealthCheckController := managedcluster.NewManagedClusterStatusController(
 		o.agentOptions.SpokeClusterName,
@@ -369,6 +389,7 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		spokeKubeInformerFactory.Core().V1().Nodes(),
 		o.registrationOption.MaxCustomClusterClaims,
 		o.registrationOption.ReservedClusterClaimSuffixes,
+		agentClaims,
 		o.registrationOption.ClusterHealthCheckPeriod,
 		recorder,
 		hubEventRecorder,
@@ -383,6 +404,8 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 			hubClient.AddonInformer,
 			managementKubeClient.CoordinationV1(),
 			spokeKubeClient.CoordinationV1(),
+			managementKubeClient.CoreV1(),
+			spokeKubeClient.CoreV1(),
 			AddOnLeaseControllerSyncInterval, //TODO: this interval time should be allowed to change from outside
 			recorder,
 		)
@@ -403,6 +426,25 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		}
 	}
 
+	var tunnelAgent *tunnel.TunnelAgent
+	if o.registrationOption.EnableHubTunnel {
+		tunnelDriver, ok := o.driver.(register.TunnelDriver)
+		if !ok {
+			return fmt.Errorf("hub tunnel is not supported by the registration driver")
+		}
+		// the hub tunnel service account is in the klusterlet namespace on the managed cluster, which is different
+		// from the component namespace in the hosted mode.
+		tunnelNamespace := o.registrationOption.HubTunnelNamespace
+		if len(tunnelNamespace) == 0 {
+			tunnelNamespace = o.agentOptions.ComponentNamespace
+		}
+		tunnelAgent, err = tunnel.NewTunnelAgent(o.agentOptions.SpokeClusterName, tunnelDriver.TunnelConfig(), spokeClientConfig,
+			spokeKubeClient, tunnelNamespace)
+		if err != nil {
+			return err
+		}
+	}
+
 	var hubAcceptController, hubTimeoutController factory.Controller
 	if features.SpokeMutableFeatureGate.Enabled(ocmfeature.MultipleHubs) {
 		hubAcceptController = registration.NewHubAcceptController(
@@ -429,6 +471,39 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		)
 	}
 
+	var encryptionKeyController factory.Controller
+	if o.registrationOption.EnableEncryptionKey {
+		spokeClusterClient, err := clusterv1client.NewForConfig(spokeClientConfig)
+		if err != nil {
+			return err
+		}
+		encryptionKeyController = encryptionkey.NewEncryptionKeyController(
+			o.agentOptions.ComponentNamespace,
+			managementKubeClient.CoreV1(),
+			namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
+			spokeClusterClient.ClusterV1alpha1().ClusterClaims(),
+			recorder,
+		)
+	}
+
+	var accessTokenController factory.Controller
+	var accessTokenInformerFactory informers.SharedInformerFactory
+	if o.registrationOption.EnableClusterProfileAccess {
+		// only watch the token configmap, the agent is not allowed to list the other configmaps.
+		accessTokenInformerFactory = informers.NewSharedInformerFactoryWithOptions(
+			spokeKubeClient, 10*time.Minute,
+			informers.WithNamespace(helpers.ClusterProfileAccessNamespace),
+			informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector(
+					"metadata.name", helpers.ClusterProfileAccessTokenConfigMapName).String()
+			}))
+		accessTokenController = clusterprofile.NewAccessTokenController(
+			spokeKubeClient,
+			accessTokenInformerFactory.Core().V1().ConfigMaps(),
+			recorder,
+		)
+	}
+
 	if hubDriverInformer != nil {
 		go hubDriverInformer.Run(ctx.Done())
 	}
@@ -446,6 +521,13 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	}
 
 	go secretController.Run(ctx, 1)
+	if encryptionKeyController != nil {
+		go encryptionKeyController.Run(ctx, 1)
+	}
+	if accessTokenController != nil {
+		go accessTokenInformerFactory.Start(ctx.Done())
+		go accessTokenController.Run(ctx, 1)
+	}
 	go managedClusterLeaseController.Run(ctx, 1)
 	go managedClusterHealthCheckController.Run(ctx, 1)
 	if features.SpokeMutableFeatureGate.Enabled(ocmfeature.AddonManagement) {
@@ -456,6 +538,15 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		}
 	}
 
+	if tunnelAgent != nil {
+		// the tunnel agent blocks until the context is done once it connects the hub, retry if it fails to connect.
+		go wait.UntilWithContext(ctx, func(ctx context.Context) {
+			if err := tunnelAgent.Run(ctx); err != nil {
+				logger.Error(err, "Failed to run the hub tunnel agent")
+			}
+		}, 30*time.Second)
+	}
+
 	// start health checking of hub client certificate
 	if o.hubKubeConfigChecker != nil {
 		o.hubKubeConfigChecker.setBootstrapped()
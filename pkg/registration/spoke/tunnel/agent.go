@@ -0,0 +1,247 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic"
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic/types"
+
+	"open-cluster-management.io/ocm/pkg/common/tunnel"
+)
+
+// requestTimeout is the max duration to serve a request on the managed cluster, the hub gives up waiting for the
+// response earlier with its own timeout.
+const requestTimeout = 2 * time.Minute
+
+const (
+	// ServiceAccountName is the service account on the managed cluster which is allowed to impersonate the hub
+	// users and groups, the agent itself is only allowed to request the tokens of this service account.
+	ServiceAccountName = "klusterlet-hub-tunnel"
+
+	tokenExpirationSeconds = int64(3600)
+)
+
+var (
+	forwardedRequestHeaders  = []string{"Accept", "Content-Type"}
+	forwardedResponseHeaders = []string{"Content-Type", "Warning"}
+)
+
+type publisher interface {
+	Publish(ctx context.Context, eventType types.CloudEventsType, req *tunnel.TunnelRequest) error
+}
+
+// TunnelAgent subscribes the tunnel requests of the cluster from the hub over the outbound connection of the agent,
+// serves them on the kube-apiserver of the managed cluster by impersonating the hub user, and publishes the
+// responses back to the hub. The requests are sent with the token of the dedicated tunnel service account, so the
+// agent itself does not need the permission to impersonate.
+type TunnelAgent struct {
+	clusterName  string
+	config       any
+	serverURL    *url.URL
+	roundTripper http.RoundTripper
+	kubeClient   kubernetes.Interface
+	namespace    string
+
+	tokenLock  sync.Mutex
+	token      string
+	tokenRenew time.Time
+}
+
+// NewTunnelAgent returns a TunnelAgent. The config is the cloudevents config to connect the hub and the
+// spokeClientConfig is used to connect the kube-apiserver of the managed cluster. The kubeClient requests the
+// tokens of the tunnel service account in the namespace.
+func NewTunnelAgent(clusterName string, config any, spokeClientConfig *rest.Config,
+	kubeClient kubernetes.Interface, namespace string) (*TunnelAgent, error) {
+	serverURL, _, err := rest.DefaultServerUrlFor(spokeClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	// the credentials of the agent are never used to serve the tunnel requests.
+	roundTripper, err := rest.TransportFor(rest.AnonymousClientConfig(spokeClientConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TunnelAgent{
+		clusterName:  clusterName,
+		config:       config,
+		serverURL:    serverURL,
+		roundTripper: roundTripper,
+		kubeClient:   kubeClient,
+		namespace:    namespace,
+	}, nil
+}
+
+// Run subscribes the tunnel requests until the context is done.
+func (a *TunnelAgent) Run(ctx context.Context) error {
+	options, err := generic.BuildCloudEventsAgentOptions(a.config, a.clusterName, fmt.Sprintf("%s-tunnel", a.clusterName))
+	if err != nil {
+		return err
+	}
+
+	// the requests are never cached on the agent, so each received request is an added one.
+	client, err := generic.NewCloudEventAgentClient[*tunnel.TunnelRequest](
+		ctx,
+		options,
+		&noopLister{},
+		func(*tunnel.TunnelRequest) (string, error) { return "", nil },
+		tunnel.NewTunnelRequestCodec(),
+	)
+	if err != nil {
+		return err
+	}
+
+	client.Subscribe(ctx, func(action types.ResourceAction, req *tunnel.TunnelRequest) error {
+		if action != types.Added {
+			return nil
+		}
+		go a.respond(ctx, client, req)
+		return nil
+	})
+
+	klog.Infof("Tunnel agent of cluster %s is started", a.clusterName)
+	<-ctx.Done()
+	return nil
+}
+
+func (a *TunnelAgent) respond(ctx context.Context, publisher publisher, req *tunnel.TunnelRequest) {
+	req.Response = a.serve(ctx, req)
+	// the request body is not needed by the hub any more
+	req.Header = nil
+	req.Body = nil
+
+	eventType := types.CloudEventsType{
+		CloudEventsDataType: tunnel.TunnelRequestEventDataType,
+		SubResource:         types.SubResourceStatus,
+		Action:              types.UpdateRequestAction,
+	}
+	if err := publisher.Publish(ctx, eventType, req); err != nil {
+		klog.Errorf("failed to publish the response of tunnel request %s: %v", req.ID, err)
+	}
+}
+
+// serve sends the request to the kube-apiserver of the managed cluster as the hub user with the impersonation prefix.
+func (a *TunnelAgent) serve(ctx context.Context, req *tunnel.TunnelRequest) *tunnel.TunnelResponse {
+	if len(req.User) == 0 {
+		return &tunnel.TunnelResponse{Error: "the user of the request is empty"}
+	}
+	target, err := a.targetURL(req.Path)
+	if err != nil {
+		return &tunnel.TunnelResponse{Error: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target.String(), bytes.NewReader(req.Body))
+	if err != nil {
+		return &tunnel.TunnelResponse{Error: fmt.Sprintf("failed to build the request: %v", err)}
+	}
+	for _, key := range forwardedRequestHeaders {
+		for _, value := range req.Header.Values(key) {
+			httpReq.Header.Add(key, value)
+		}
+	}
+
+	token, err := a.serviceAccountToken(ctx)
+	if err != nil {
+		return &tunnel.TunnelResponse{Error: fmt.Sprintf("failed to request the token of the tunnel: %v", err)}
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	impersonate := transport.ImpersonationConfig{UserName: tunnel.ImpersonationPrefix + req.User}
+	for _, group := range req.Groups {
+		impersonate.Groups = append(impersonate.Groups, tunnel.ImpersonationPrefix+group)
+	}
+	resp, err := transport.NewImpersonatingRoundTripper(impersonate, a.roundTripper).RoundTrip(httpReq)
+	if err != nil {
+		return &tunnel.TunnelResponse{Error: fmt.Sprintf("failed to send the request to the cluster: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, tunnel.MaxBodySize+1))
+	if err != nil {
+		return &tunnel.TunnelResponse{Error: fmt.Sprintf("failed to read the response body: %v", err)}
+	}
+	if len(body) > tunnel.MaxBodySize {
+		return &tunnel.TunnelResponse{
+			Error: fmt.Sprintf("the response body exceeds %d bytes, paginate the request with the limit parameter",
+				tunnel.MaxBodySize),
+		}
+	}
+
+	header := http.Header{}
+	for _, key := range forwardedResponseHeaders {
+		for _, value := range resp.Header.Values(key) {
+			header.Add(key, value)
+		}
+	}
+	return &tunnel.TunnelResponse{
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       body,
+	}
+}
+
+// serviceAccountToken returns the token of the tunnel service account, a new token is requested once 80% of the
+// lifetime of the current token passes.
+func (a *TunnelAgent) serviceAccountToken(ctx context.Context) (string, error) {
+	a.tokenLock.Lock()
+	defer a.tokenLock.Unlock()
+
+	if len(a.token) > 0 && time.Now().Before(a.tokenRenew) {
+		return a.token, nil
+	}
+
+	expirationSeconds := tokenExpirationSeconds
+	tokenRequest, err := a.kubeClient.CoreV1().ServiceAccounts(a.namespace).CreateToken(ctx, ServiceAccountName,
+		&authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+		},
+		metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := time.Until(tokenRequest.Status.ExpirationTimestamp.Time)
+	a.token = tokenRequest.Status.Token
+	a.tokenRenew = time.Now().Add(lifetime * 4 / 5)
+	return a.token, nil
+}
+
+// targetURL joins the path of the request to the kube-apiserver url, the path must be an absolute path on the
+// kube-apiserver.
+func (a *TunnelAgent) targetURL(path string) (*url.URL, error) {
+	ref, err := url.ParseRequestURI(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request path %q: %v", path, err)
+	}
+	if len(ref.Scheme) > 0 || len(ref.Host) > 0 || !strings.HasPrefix(ref.Path, "/") {
+		return nil, fmt.Errorf("invalid request path %q: an absolute path is required", path)
+	}
+
+	target := *a.serverURL
+	target.Path = strings.TrimSuffix(target.Path, "/") + ref.Path
+	target.RawQuery = ref.RawQuery
+	return &target, nil
+}
+
+type noopLister struct{}
+
+func (l *noopLister) List(options types.ListOptions) ([]*tunnel.TunnelRequest, error) {
+	return nil, nil
+}
@@ -0,0 +1,203 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic/types"
+
+	"open-cluster-management.io/ocm/pkg/common/tunnel"
+)
+
+type fakePublisher struct {
+	eventType types.CloudEventsType
+	request   *tunnel.TunnelRequest
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, eventType types.CloudEventsType, req *tunnel.TunnelRequest) error {
+	p.eventType = eventType
+	p.request = req
+	return nil
+}
+
+func TestRespond(t *testing.T) {
+	cases := []struct {
+		name     string
+		request  *tunnel.TunnelRequest
+		validate func(t *testing.T, r *http.Request)
+		response func(w http.ResponseWriter)
+		expected *tunnel.TunnelResponse
+	}{
+		{
+			name:     "no user",
+			request:  &tunnel.TunnelRequest{ID: "test", Method: http.MethodGet, Path: "/api"},
+			expected: &tunnel.TunnelResponse{Error: "the user of the request is empty"},
+		},
+		{
+			name: "invalid path",
+			request: &tunnel.TunnelRequest{
+				ID: "test", User: "user1", Method: http.MethodGet, Path: "https://another.host/api",
+			},
+			expected: &tunnel.TunnelResponse{
+				Error: `invalid request path "https://another.host/api": an absolute path is required`,
+			},
+		},
+		{
+			name: "response too large",
+			request: &tunnel.TunnelRequest{
+				ID: "test", User: "user1", Method: http.MethodGet, Path: "/api/v1/configmaps",
+			},
+			response: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte(strings.Repeat("a", tunnel.MaxBodySize+1)))
+			},
+			expected: &tunnel.TunnelResponse{
+				Error: "the response body exceeds 2097152 bytes, paginate the request with the limit parameter",
+			},
+		},
+		{
+			name: "served",
+			request: &tunnel.TunnelRequest{
+				ID:     "test",
+				User:   "user1",
+				Groups: []string{"group1"},
+				Method: http.MethodPost,
+				Path:   "/api/v1/namespaces?dryRun=All",
+				Header: http.Header{"Content-Type": []string{"application/json"}, "Authorization": []string{"Bearer x"}},
+				Body:   []byte(`{"kind":"Namespace"}`),
+			},
+			validate: func(t *testing.T, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/api/v1/namespaces" || r.URL.RawQuery != "dryRun=All" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+				if user := r.Header.Get("Impersonate-User"); user != "open-cluster-management:hub:user1" {
+					t.Errorf("unexpected impersonated user %q", user)
+				}
+				if groups := r.Header.Values("Impersonate-Group"); len(groups) != 1 ||
+					groups[0] != "open-cluster-management:hub:group1" {
+					t.Errorf("unexpected impersonated groups %v", groups)
+				}
+				if auth := r.Header.Get("Authorization"); auth != "Bearer tunnel-token" {
+					t.Errorf("unexpected authorization header %q", auth)
+				}
+				if body, _ := io.ReadAll(r.Body); string(body) != `{"kind":"Namespace"}` {
+					t.Errorf("unexpected body %s", string(body))
+				}
+			},
+			response: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Audit-Id", "test")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"kind":"Namespace"}`))
+			},
+			expected: &tunnel.TunnelResponse{
+				StatusCode: http.StatusCreated,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       []byte(`{"kind":"Namespace"}`),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if c.validate != nil {
+					c.validate(t, r)
+				}
+				if c.response != nil {
+					c.response(w)
+				}
+			}))
+			defer server.Close()
+
+			kubeClient := newTokenKubeClient()
+			agent, err := NewTunnelAgent("cluster1", nil, &rest.Config{Host: server.URL, BearerToken: "spoke-token"},
+				kubeClient, "open-cluster-management-agent")
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			publisher := &fakePublisher{}
+			agent.respond(context.Background(), publisher, c.request)
+
+			if publisher.eventType.SubResource != types.SubResourceStatus ||
+				publisher.eventType.Action != types.UpdateRequestAction {
+				t.Errorf("unexpected event type %v", publisher.eventType)
+			}
+			if publisher.request.Body != nil || publisher.request.Header != nil {
+				t.Errorf("expected the request body and header are dropped")
+			}
+			actual := publisher.request.Response
+			if actual.StatusCode != c.expected.StatusCode || actual.Error != c.expected.Error ||
+				string(actual.Body) != string(c.expected.Body) {
+				t.Errorf("expected response %v, but got %v", c.expected, actual)
+			}
+			if len(actual.Header) != len(c.expected.Header) ||
+				actual.Header.Get("Content-Type") != c.expected.Header.Get("Content-Type") {
+				t.Errorf("expected header %v, but got %v", c.expected.Header, actual.Header)
+			}
+		})
+	}
+}
+
+func TestServiceAccountToken(t *testing.T) {
+	kubeClient := newTokenKubeClient()
+	agent, err := NewTunnelAgent("cluster1", nil, &rest.Config{Host: "https://localhost"},
+		kubeClient, "open-cluster-management-agent")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := agent.serviceAccountToken(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if token != "tunnel-token" {
+			t.Errorf("unexpected token %q", token)
+		}
+	}
+	if len(kubeClient.Actions()) != 1 {
+		t.Fatalf("expected the token is cached, but got actions %v", kubeClient.Actions())
+	}
+	action := kubeClient.Actions()[0].(clienttesting.CreateAction)
+	if action.GetNamespace() != "open-cluster-management-agent" || action.GetSubresource() != "token" {
+		t.Errorf("unexpected action %v", action)
+	}
+
+	// a new token is requested once the current token needs to be renewed
+	agent.tokenRenew = time.Now().Add(-time.Second)
+	if _, err := agent.serviceAccountToken(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(kubeClient.Actions()) != 2 {
+		t.Errorf("expected a new token is requested, but got actions %v", kubeClient.Actions())
+	}
+}
+
+func newTokenKubeClient() *kubefake.Clientset {
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "serviceaccounts",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "token" {
+				return false, nil, nil
+			}
+			return true, &authenticationv1.TokenRequest{
+				Status: authenticationv1.TokenRequestStatus{
+					Token:               "tunnel-token",
+					ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+				},
+			}, nil
+		})
+	return kubeClient
+}
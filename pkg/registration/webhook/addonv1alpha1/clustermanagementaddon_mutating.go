@@ -0,0 +1,46 @@
+package addonv1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"open-cluster-management.io/api/addon/v1alpha1"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+var _ webhook.CustomDefaulter = &ClusterManagementAddOnWebhook{}
+
+// Default authorizes the break-glass override of the cluster freeze and records it in the freeze audit of the
+// ClusterManagementAddOn. The addon is installed and configured on any cluster, so the user is required to be
+// authorized to override the freeze of all the clusters.
+func (r *ClusterManagementAddOnWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	cma, ok := obj.(*v1alpha1.ClusterManagementAddOn)
+	if !ok {
+		return apierrors.NewBadRequest("Request clustermanagementaddon obj format is not right")
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+
+	var oldAnnotations map[string]string
+	if req.Operation == admissionv1.Update {
+		oldCMA := &v1alpha1.ClusterManagementAddOn{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldCMA); err != nil {
+			return apierrors.NewBadRequest(fmt.Sprintf("invalid old clustermanagementaddon: %v", err))
+		}
+		oldAnnotations = oldCMA.Annotations
+	}
+
+	return commonhelpers.AdmitFreezeOverride(ctx, r.kubeClient, req.UserInfo, "", cma, oldAnnotations, time.Now())
+}
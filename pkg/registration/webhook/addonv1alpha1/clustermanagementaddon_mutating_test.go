@@ -0,0 +1,104 @@
+package addonv1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"open-cluster-management.io/api/addon/v1alpha1"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+func TestDefault(t *testing.T) {
+	newCMA := func(annotations map[string]string) *v1alpha1.ClusterManagementAddOn {
+		return &v1alpha1.ClusterManagementAddOn{
+			ObjectMeta: metav1.ObjectMeta{Name: "addon1", Annotations: annotations},
+		}
+	}
+	overridden := newCMA(map[string]string{
+		commonhelpers.FreezeOverrideAnnotationKey: "true",
+		commonhelpers.FreezeAuditAnnotationKey:    `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`,
+	})
+
+	cases := []struct {
+		name             string
+		cma              *v1alpha1.ClusterManagementAddOn
+		oldCMA           *v1alpha1.ClusterManagementAddOn
+		allowed          bool
+		expectedErr      bool
+		expectedOverride bool
+	}{
+		{
+			name: "no override",
+			cma:  newCMA(nil),
+		},
+		{
+			name:        "override without permission",
+			cma:         newCMA(map[string]string{commonhelpers.FreezeOverrideAnnotationKey: "true"}),
+			expectedErr: true,
+		},
+		{
+			name:             "override with permission",
+			cma:              newCMA(map[string]string{commonhelpers.FreezeOverrideAnnotationKey: "true"}),
+			allowed:          true,
+			expectedOverride: true,
+		},
+		{
+			name:             "override is kept on update",
+			cma:              newCMA(map[string]string{commonhelpers.FreezeOverrideAnnotationKey: "true"}),
+			oldCMA:           overridden,
+			expectedOverride: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					if len(sar.Spec.ResourceAttributes.Name) != 0 {
+						t.Errorf("expected the authorization on all the clusters, got %s", sar.Spec.ResourceAttributes.Name)
+					}
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: c.allowed},
+					}, nil
+				},
+			)
+			w := &ClusterManagementAddOnWebhook{kubeClient: kubeClient}
+
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  authenticationv1.UserInfo{Username: "user1"},
+			}}
+			if c.oldCMA != nil {
+				raw, err := json.Marshal(c.oldCMA)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Operation = admissionv1.Update
+				req.OldObject = runtime.RawExtension{Raw: raw}
+			}
+
+			err := w.Default(admission.NewContextWithRequest(context.TODO(), req), c.cma)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if actual := commonhelpers.IsFreezeOverridden(c.cma.Annotations); actual != c.expectedOverride {
+				t.Errorf("expected override %v, got %v", c.expectedOverride, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package addonv1alpha1
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"open-cluster-management.io/api/addon/v1alpha1"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+var _ webhook.CustomValidator = &ClusterManagementAddOnWebhook{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterManagementAddOnWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (
+	admission.Warnings, error) {
+	cma, ok := obj.(*v1alpha1.ClusterManagementAddOn)
+	if !ok {
+		return nil, apierrors.NewBadRequest("Request clustermanagementaddon obj format is not right")
+	}
+	return nil, validateAnnotations(cma)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterManagementAddOnWebhook) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (
+	admission.Warnings, error) {
+	cma, ok := newObj.(*v1alpha1.ClusterManagementAddOn)
+	if !ok {
+		return nil, apierrors.NewBadRequest("Request clustermanagementaddon obj format is not right")
+	}
+	return nil, validateAnnotations(cma)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterManagementAddOnWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (
+	admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateAnnotations validates the annotations of the ClusterManagementAddOn which configure the health probes,
+// the rollout gate and the freeze override of the addon.
+func validateAnnotations(cma *v1alpha1.ClusterManagementAddOn) error {
+	if err := commonhelpers.ValidateHealthProbes(cma.Annotations); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	if err := commonhelpers.ValidateRolloutGate(cma.Annotations); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	if err := commonhelpers.ValidateClusterFreeze(nil, cma.Annotations); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	return nil
+}
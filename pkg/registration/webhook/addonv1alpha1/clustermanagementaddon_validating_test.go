@@ -0,0 +1,68 @@
+package addonv1alpha1
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"open-cluster-management.io/api/addon/v1alpha1"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+func TestValidateAnnotations(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "valid annotations",
+			annotations: map[string]string{
+				commonhelpers.AddonHealthProbesAnnotationKey: `[{"name":"tcp","type":"TCP","port":8443}]`,
+				commonhelpers.RolloutGateAnnotationKey:       `{"expression":"feedback.ready == true"}`,
+				commonhelpers.FreezeOverrideAnnotationKey:    "true",
+			},
+		},
+		{
+			name:        "invalid health probes",
+			annotations: map[string]string{commonhelpers.AddonHealthProbesAnnotationKey: `[{"type":"TCP"}]`},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid rollout gate",
+			annotations: map[string]string{commonhelpers.RolloutGateAnnotationKey: `{"expression":"feedback.ready =="}`},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid freeze override",
+			annotations: map[string]string{commonhelpers.FreezeOverrideAnnotationKey: "yes"},
+			expectedErr: true,
+		},
+	}
+
+	w := &ClusterManagementAddOnWebhook{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cma := &v1alpha1.ClusterManagementAddOn{
+				ObjectMeta: metav1.ObjectMeta{Name: "addon1", Annotations: c.annotations},
+			}
+			_, err := w.ValidateCreate(context.Background(), cma)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if err != nil && !apierrors.IsBadRequest(err) {
+				t.Errorf("expected bad request error, got %v", err)
+			}
+			_, err = w.ValidateUpdate(context.Background(), cma, cma)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v on update, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package addonv1alpha1
+
+import (
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// ClusterManagementAddOnWebhook validates the annotations of the ClusterManagementAddOns and authorizes the
+// break-glass override of the cluster freeze on them.
+type ClusterManagementAddOnWebhook struct {
+	kubeClient kubernetes.Interface
+}
+
+func (r *ClusterManagementAddOnWebhook) Init(mgr ctrl.Manager) error {
+	err := r.SetupWebhookWithManager(mgr)
+	if err != nil {
+		return err
+	}
+	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	return err
+}
+
+// SetExternalKubeClientSet is function to enable the webhook injecting to kube admssion
+func (r *ClusterManagementAddOnWebhook) SetExternalKubeClientSet(client kubernetes.Interface) {
+	r.kubeClient = client
+}
+
+func (r *ClusterManagementAddOnWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		WithValidator(r).
+		WithDefaulter(r).
+		For(&v1alpha1.ClusterManagementAddOn{}).
+		Complete()
+}
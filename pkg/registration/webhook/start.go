@@ -13,8 +13,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
+	internaladdonv1alpha1 "open-cluster-management.io/ocm/pkg/registration/webhook/addonv1alpha1"
 	internalv1 "open-cluster-management.io/ocm/pkg/registration/webhook/v1"
 	internalv1beta2 "open-cluster-management.io/ocm/pkg/registration/webhook/v1beta2"
 )
@@ -27,6 +29,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(clusterv1.Install(scheme))
 	utilruntime.Must(internalv1beta2.Install(scheme))
+	utilruntime.Must(addonv1alpha1.Install(scheme))
 }
 
 func (c *Options) RunWebhookServer() error {
@@ -71,6 +74,14 @@ func (c *Options) RunWebhookServer() error {
 		logger.Error(err, "unable to create ManagedClusterSetBinding webhook", "version", "v1beta2")
 		return err
 	}
+	if err = (&internalv1beta2.ManagedClusterSetWebhook{}).Init(mgr); err != nil {
+		logger.Error(err, "unable to create ManagedClusterSet webhook", "version", "v1beta2")
+		return err
+	}
+	if err = (&internaladdonv1alpha1.ClusterManagementAddOnWebhook{}).Init(mgr); err != nil {
+		logger.Error(err, "unable to create ClusterManagementAddOn webhook", "version", "v1alpha1")
+		return err
+	}
 
 	logger.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
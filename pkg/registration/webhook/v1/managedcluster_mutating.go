@@ -17,6 +17,7 @@ import (
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	ocmfeature "open-cluster-management.io/api/feature"
 
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
@@ -58,6 +59,36 @@ func (r *ManagedClusterWebhook) Default(ctx context.Context, obj runtime.Object)
 		r.addDefaultClusterSetLabel(managedCluster)
 	}
 
+	// Record who froze or unfroze the cluster
+	return r.processFreezeAudit(req.UserInfo.Username, managedCluster, oldManagedCluster)
+}
+
+// processFreezeAudit appends a freeze record into the freeze audit annotation when the cluster is frozen or
+// unfrozen. The annotation can only be changed by the webhook, the changes from the request are reverted.
+func (r *ManagedClusterWebhook) processFreezeAudit(username string, managedCluster, oldManagedCluster *clusterv1.ManagedCluster) error {
+	var oldAnnotations map[string]string
+	if oldManagedCluster != nil {
+		oldAnnotations = oldManagedCluster.Annotations
+	}
+	commonhelpers.KeepFreezeAudit(managedCluster, oldAnnotations)
+
+	frozen := commonhelpers.IsClusterFrozen(managedCluster)
+	if frozen == commonhelpers.IsClusterFrozen(oldManagedCluster) {
+		return nil
+	}
+
+	action := commonhelpers.FreezeActionFreeze
+	if !frozen {
+		action = commonhelpers.FreezeActionUnfreeze
+	}
+	err := commonhelpers.AppendFreezeRecord(managedCluster, commonhelpers.FreezeRecord{
+		Action: action,
+		User:   username,
+		Time:   metav1.NewTime(nowFunc()),
+	})
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
 	return nil
 }
 
@@ -18,6 +18,7 @@ import (
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	ocmfeature "open-cluster-management.io/api/feature"
 
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/features"
 )
 
@@ -514,3 +515,82 @@ func newTime(time time.Time, offset time.Duration) metav1.Time {
 	mt := metav1.NewTime(time.Add(offset))
 	return mt
 }
+
+func TestProcessFreezeAudit(t *testing.T) {
+	frozenCluster := func(annotations map[string]string) *clusterv1.ManagedCluster {
+		return &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster1",
+			Labels:      map[string]string{commonhelpers.ClusterFrozenLabelKey: "true"},
+			Annotations: annotations,
+		}}
+	}
+	cluster := func(annotations map[string]string) *clusterv1.ManagedCluster {
+		return &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Annotations: annotations}}
+	}
+	audit := `[{"action":"Freeze","user":"admin","time":"2024-01-01T00:00:00Z"}]`
+
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		oldCluster      *clusterv1.ManagedCluster
+		expectedActions []commonhelpers.FreezeAction
+	}{
+		{
+			name:    "create cluster",
+			cluster: cluster(nil),
+		},
+		{
+			name:            "create frozen cluster",
+			cluster:         frozenCluster(nil),
+			expectedActions: []commonhelpers.FreezeAction{commonhelpers.FreezeActionFreeze},
+		},
+		{
+			name:            "freeze cluster",
+			cluster:         frozenCluster(nil),
+			oldCluster:      cluster(nil),
+			expectedActions: []commonhelpers.FreezeAction{commonhelpers.FreezeActionFreeze},
+		},
+		{
+			name:       "unfreeze cluster",
+			cluster:    cluster(map[string]string{commonhelpers.FreezeAuditAnnotationKey: audit}),
+			oldCluster: frozenCluster(map[string]string{commonhelpers.FreezeAuditAnnotationKey: audit}),
+			expectedActions: []commonhelpers.FreezeAction{
+				commonhelpers.FreezeActionFreeze, commonhelpers.FreezeActionUnfreeze},
+		},
+		{
+			name:            "audit is removed",
+			cluster:         frozenCluster(nil),
+			oldCluster:      frozenCluster(map[string]string{commonhelpers.FreezeAuditAnnotationKey: audit}),
+			expectedActions: []commonhelpers.FreezeAction{commonhelpers.FreezeActionFreeze},
+		},
+		{
+			name:       "audit is forged",
+			cluster:    cluster(map[string]string{commonhelpers.FreezeAuditAnnotationKey: audit}),
+			oldCluster: cluster(nil),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := ManagedClusterWebhook{}
+			if err := w.processFreezeAudit("user1", c.cluster, c.oldCluster); err != nil {
+				t.Fatal(err)
+			}
+			records, err := commonhelpers.GetFreezeRecords(c.cluster.Annotations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actions []commonhelpers.FreezeAction
+			for _, record := range records {
+				actions = append(actions, record.Action)
+			}
+			if !reflect.DeepEqual(actions, c.expectedActions) {
+				t.Errorf("expected actions %v, got %v", c.expectedActions, actions)
+			}
+			changed := commonhelpers.IsClusterFrozen(c.cluster) != commonhelpers.IsClusterFrozen(c.oldCluster)
+			if changed && records[len(records)-1].User != "user1" {
+				t.Errorf("expected the record of user1, got %v", records)
+			}
+		})
+	}
+}
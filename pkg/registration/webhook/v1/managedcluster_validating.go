@@ -19,7 +19,9 @@ import (
 	v1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	internalv1beta2 "open-cluster-management.io/ocm/pkg/registration/webhook/v1beta2"
 )
 
 var _ webhook.CustomValidator = &ManagedClusterWebhook{}
@@ -41,6 +43,10 @@ func (r *ManagedClusterWebhook) ValidateCreate(ctx context.Context, obj runtime.
 		return nil, err
 	}
 
+	if err := validateAnnotations(managedCluster, nil); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
 	// the HubAcceptsClient field is changed, we need to:
 	// 1. check whether cluster namespace is terminating.
 	// 2. check the request user whether has been allowed to change the HubAcceptsClient field with
@@ -54,6 +60,13 @@ func (r *ManagedClusterWebhook) ValidateCreate(ctx context.Context, obj runtime.
 		}
 	}
 
+	// check whether the request user has been allowed to freeze the cluster
+	if commonhelpers.IsClusterFrozen(managedCluster) {
+		if err := r.allowFreezeCluster(managedCluster.Name, req.UserInfo); err != nil {
+			return nil, err
+		}
+	}
+
 	// check whether the request user has been allowed to set clusterset label
 	var clusterSetName string
 	if len(managedCluster.Labels) > 0 {
@@ -85,6 +98,10 @@ func (r *ManagedClusterWebhook) ValidateUpdate(ctx context.Context, oldObj, newO
 		return nil, err
 	}
 
+	if err := validateAnnotations(managedCluster, oldManagedCluster); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
 	// the HubAcceptsClient field is changed, we need to:
 	// 1. check whether cluster namespace is terminating.
 	// 2. check the request user whether has been allowed to change the HubAcceptsClient field with
@@ -100,6 +117,13 @@ func (r *ManagedClusterWebhook) ValidateUpdate(ctx context.Context, oldObj, newO
 		}
 	}
 
+	// check whether the request user has been allowed to freeze or unfreeze the cluster
+	if commonhelpers.IsClusterFrozen(managedCluster) != commonhelpers.IsClusterFrozen(oldManagedCluster) {
+		if err := r.allowFreezeCluster(managedCluster.Name, req.UserInfo); err != nil {
+			return nil, err
+		}
+	}
+
 	// check whether the request user has been allowed to set clusterset label
 	var originalClusterSetName, currentClusterSetName string
 	if len(oldManagedCluster.Labels) > 0 {
@@ -117,6 +141,34 @@ func (r *ManagedClusterWebhook) ValidateDelete(_ context.Context, obj runtime.Ob
 	return nil, nil
 }
 
+// validateAnnotations validates the maintenance windows and the freeze of the cluster. The values which are not
+// changed by an update are not validated, so the cluster with an existing invalid value can still be updated.
+func validateAnnotations(cluster, oldCluster *v1.ManagedCluster) error {
+	changed := func(values, oldValues map[string]string, key string) bool {
+		if oldCluster == nil {
+			return true
+		}
+		value, ok := values[key]
+		oldValue, oldOk := oldValues[key]
+		return ok != oldOk || value != oldValue
+	}
+	var oldLabels, oldAnnotations map[string]string
+	if oldCluster != nil {
+		oldLabels, oldAnnotations = oldCluster.Labels, oldCluster.Annotations
+	}
+
+	if changed(cluster.Annotations, oldAnnotations, commonhelpers.MaintenanceWindowsAnnotationKey) {
+		if err := commonhelpers.ValidateMaintenanceWindows(cluster.Annotations); err != nil {
+			return err
+		}
+	}
+	if changed(cluster.Labels, oldLabels, commonhelpers.ClusterFrozenLabelKey) ||
+		changed(cluster.Annotations, oldAnnotations, commonhelpers.FreezeOverrideAnnotationKey) {
+		return commonhelpers.ValidateClusterFreeze(cluster.Labels, cluster.Annotations)
+	}
+	return nil
+}
+
 // validateManagedClusterObj validates the fileds of ManagedCluster object
 func (r *ManagedClusterWebhook) validateManagedClusterObj(cluster v1.ManagedCluster) error {
 	errs := []error{}
@@ -184,6 +236,26 @@ func (r *ManagedClusterWebhook) allowUpdateAcceptField(clusterName string, userI
 	return nil
 }
 
+// allowFreezeCluster checks whether a request user has been authorized to freeze or unfreeze the cluster
+func (r *ManagedClusterWebhook) allowFreezeCluster(clusterName string, userInfo authenticationv1.UserInfo) error {
+	allowed, err := commonhelpers.AllowClusterFreeze(context.TODO(), r.kubeClient, userInfo, clusterName)
+	if err != nil {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters/"+commonhelpers.FreezeSubresource),
+			clusterName,
+			err,
+		)
+	}
+	if !allowed {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters/"+commonhelpers.FreezeSubresource),
+			clusterName,
+			fmt.Errorf("user %q cannot freeze or unfreeze the cluster", userInfo.Username),
+		)
+	}
+	return nil
+}
+
 // validateClusterNamespace checks the cluster namespace, if the namespace is terminating, reject the accept request.
 func (r *ManagedClusterWebhook) validateAcceptByClusterNamespace(clusterName string) error {
 	clusterNamespace, err := r.kubeClient.CoreV1().Namespaces().Get(context.TODO(), clusterName, metav1.GetOptions{})
@@ -231,44 +303,34 @@ func (r *ManagedClusterWebhook) allowSetClusterSetLabel(userInfo authenticationv
 }
 
 // allowUpdateClusterSet checks whether a request user has been authorized to add/remove a ManagedCluster
-// to/from the ManagedClusterSet
+// to/from the ManagedClusterSet. The admins of an ancestor of a nested ManagedClusterSet are authorized as well.
 func (r *ManagedClusterWebhook) allowUpdateClusterSet(userInfo authenticationv1.UserInfo, clusterSetName string) error {
-	extra := make(map[string]authorizationv1.ExtraValue)
-	for k, v := range userInfo.Extra {
-		extra[k] = authorizationv1.ExtraValue(v)
-	}
-
-	sar := &authorizationv1.SubjectAccessReview{
-		Spec: authorizationv1.SubjectAccessReviewSpec{
-			User:   userInfo.Username,
-			UID:    userInfo.UID,
-			Groups: userInfo.Groups,
-			Extra:  extra,
-			ResourceAttributes: &authorizationv1.ResourceAttributes{
-				Group:       "cluster.open-cluster-management.io",
-				Resource:    "managedclustersets",
-				Subresource: "join",
-				Name:        clusterSetName,
-				Verb:        "create",
-			},
-		},
-	}
-	sar, err := r.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
-	if err != nil {
-		return apierrors.NewForbidden(
-			v1.Resource("managedclustersets/join"),
-			clusterSetName,
-			err,
-		)
+	clusterSetNames := []string{clusterSetName}
+	if r.clusterClient != nil {
+		ancestors, err := internalv1beta2.GetClusterSetAncestorNames(context.TODO(), r.clusterClient, clusterSetName)
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+		clusterSetNames = append(clusterSetNames, ancestors...)
 	}
 
-	if !sar.Status.Allowed {
-		return apierrors.NewForbidden(
-			v1.Resource("managedclustersets/join"),
-			clusterSetName,
-			fmt.Errorf("user %q cannot add/remove a ManagedCluster to/from ManagedClusterSet %q", userInfo.Username, clusterSetName),
-		)
+	for _, name := range clusterSetNames {
+		allowed, err := internalv1beta2.AllowClusterSetSubresource(r.kubeClient, userInfo, name, "join")
+		if err != nil {
+			return apierrors.NewForbidden(
+				v1.Resource("managedclustersets/join"),
+				clusterSetName,
+				err,
+			)
+		}
+		if allowed {
+			return nil
+		}
 	}
 
-	return nil
+	return apierrors.NewForbidden(
+		v1.Resource("managedclustersets/join"),
+		clusterSetName,
+		fmt.Errorf("user %q cannot add/remove a ManagedCluster to/from ManagedClusterSet %q", userInfo.Username, clusterSetName),
+	)
 }
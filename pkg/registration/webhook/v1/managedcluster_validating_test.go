@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,8 +14,11 @@ import (
 	clienttesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/api/cluster/v1beta2"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 func TestValidateCreate(t *testing.T) {
@@ -26,6 +30,7 @@ func TestValidateCreate(t *testing.T) {
 		allowUpdateAcceptField bool
 		allowClusterset        bool
 		allowUpdateClusterSets map[string]bool
+		allowFreeze            bool
 	}{
 		{
 			name:          "Empty spec cluster",
@@ -138,6 +143,27 @@ func TestValidateCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "validate creating a frozen cluster without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set-1",
+					Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "true"},
+				},
+			},
+		},
+		{
+			name:          "validate creating a frozen cluster with permission",
+			expectedError: false,
+			allowFreeze:   true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set-1",
+					Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "true"},
+				},
+			},
+		},
 		{
 			name:          "validate create cluster with invalid config",
 			expectedError: true,
@@ -194,6 +220,9 @@ func TestValidateCreate(t *testing.T) {
 					switch sar.Spec.ResourceAttributes.Resource {
 					case "managedclusters":
 						allowed = c.allowUpdateAcceptField
+						if sar.Spec.ResourceAttributes.Subresource == commonhelpers.FreezeSubresource {
+							allowed = c.allowFreeze
+						}
 					case "managedclustersets":
 						allowed = c.allowUpdateClusterSets[sar.Spec.ResourceAttributes.Name]
 					}
@@ -246,6 +275,7 @@ func TestValidateUpdate(t *testing.T) {
 		allowUpdateAcceptField bool
 		allowClusterset        bool
 		allowUpdateClusterSets map[string]bool
+		allowFreeze            bool
 	}{
 		{
 			name:                   "validate update an accepted ManagedCluster without permission",
@@ -505,6 +535,68 @@ func TestValidateUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "validate freezing a cluster without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "true"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
+		{
+			name:          "validate unfreezing a cluster without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "true"},
+				},
+			},
+		},
+		{
+			name:          "validate unfreezing a cluster with permission",
+			expectedError: false,
+			allowFreeze:   true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "true"},
+				},
+			},
+		},
+		{
+			name:          "validate updating a frozen cluster without permission",
+			expectedError: false,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "true", "env": "prod"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "true"},
+				},
+			},
+		},
 		{
 			name:          "validate update cluster with invalid config",
 			expectedError: true,
@@ -562,6 +654,9 @@ func TestValidateUpdate(t *testing.T) {
 					switch sar.Spec.ResourceAttributes.Resource {
 					case "managedclusters":
 						allowed = c.allowUpdateAcceptField
+						if sar.Spec.ResourceAttributes.Subresource == commonhelpers.FreezeSubresource {
+							allowed = c.allowFreeze
+						}
 					case "managedclustersets":
 						allowed = c.allowUpdateClusterSets[sar.Spec.ResourceAttributes.Name]
 					}
@@ -603,3 +698,100 @@ func TestValidateUpdate(t *testing.T) {
 		t.Errorf("Non cluster obj, Expect Error but got nil")
 	}
 }
+
+func TestAllowUpdateNestedClusterSet(t *testing.T) {
+	clusterSets := []runtime.Object{
+		&v1beta2.ManagedClusterSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "child",
+				Labels: map[string]string{commonhelpers.ParentClusterSetLabel: "parent"},
+			},
+		},
+		&v1beta2.ManagedClusterSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "parent"},
+		},
+		&v1beta2.ManagedClusterSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.PrependReactor(
+		"create",
+		"subjectaccessreviews",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			return true, &authorizationv1.SubjectAccessReview{
+				Status: authorizationv1.SubjectAccessReviewStatus{
+					Allowed: sar.Spec.ResourceAttributes.Name == "parent",
+				},
+			}, nil
+		},
+	)
+	w := ManagedClusterWebhook{
+		kubeClient:    kubeClient,
+		clusterClient: clusterfake.NewSimpleClientset(clusterSets...),
+	}
+
+	userInfo := authenticationv1.UserInfo{Username: "user1"}
+	if err := w.allowUpdateClusterSet(userInfo, "child"); err != nil {
+		t.Errorf("expected the admin of the parent clusterset is allowed, but got %v", err)
+	}
+	if err := w.allowUpdateClusterSet(userInfo, "other"); err == nil {
+		t.Errorf("expected the admin of the parent clusterset is not allowed on another clusterset")
+	}
+}
+
+func TestValidateAnnotations(t *testing.T) {
+	invalidWindows := map[string]string{commonhelpers.MaintenanceWindowsAnnotationKey: `[{"schedule": "every day"}]`}
+	cases := []struct {
+		name        string
+		cluster     *v1.ManagedCluster
+		oldCluster  *v1.ManagedCluster
+		expectedErr bool
+	}{
+		{
+			name:    "no annotations",
+			cluster: &v1.ManagedCluster{},
+		},
+		{
+			name: "valid maintenance windows",
+			cluster: &v1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				commonhelpers.MaintenanceWindowsAnnotationKey: `[{"schedule": "0 2 * * 6", "duration": "4h"}]`,
+			}}},
+		},
+		{
+			name:        "invalid maintenance windows",
+			cluster:     &v1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Annotations: invalidWindows}},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid maintenance windows changed",
+			cluster:     &v1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Annotations: invalidWindows}},
+			oldCluster:  &v1.ManagedCluster{},
+			expectedErr: true,
+		},
+		{
+			name:       "invalid maintenance windows not changed",
+			cluster:    &v1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Annotations: invalidWindows}},
+			oldCluster: &v1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Annotations: invalidWindows}},
+		},
+		{
+			name: "invalid frozen label",
+			cluster: &v1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{commonhelpers.ClusterFrozenLabelKey: "yes"},
+			}},
+			oldCluster:  &v1.ManagedCluster{},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateAnnotations(c.cluster, c.oldCluster)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
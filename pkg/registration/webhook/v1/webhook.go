@@ -4,11 +4,13 @@ import (
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	v1 "open-cluster-management.io/api/cluster/v1"
 )
 
 type ManagedClusterWebhook struct {
-	kubeClient kubernetes.Interface
+	kubeClient    kubernetes.Interface
+	clusterClient clusterv1client.Interface
 }
 
 func (r *ManagedClusterWebhook) Init(mgr ctrl.Manager) error {
@@ -17,6 +19,10 @@ func (r *ManagedClusterWebhook) Init(mgr ctrl.Manager) error {
 		return err
 	}
 	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.clusterClient, err = clusterv1client.NewForConfig(mgr.GetConfig())
 	return err
 }
 
@@ -0,0 +1,238 @@
+package v1beta2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/api/apps/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	"open-cluster-management.io/api/cluster/v1beta2"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+var _ webhook.CustomValidator = &ManagedClusterSetWebhook{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (s *ManagedClusterSetWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (
+	admission.Warnings, error) {
+	clusterSet, ok := obj.(*v1beta2.ManagedClusterSet)
+	if !ok {
+		return nil, apierrors.NewBadRequest("Request clusterset obj format is not right")
+	}
+
+	if err := validateChildClusterSets(clusterSet); err != nil {
+		return nil, err
+	}
+
+	parent := clusterSet.Labels[commonhelpers.ParentClusterSetLabel]
+	if len(parent) == 0 {
+		return nil, nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	if err := s.validateParent(ctx, clusterSet, parent); err != nil {
+		return nil, err
+	}
+	return nil, s.allowNestClusterSet(ctx, req.UserInfo, parent)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (s *ManagedClusterSetWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (
+	admission.Warnings, error) {
+	clusterSet, ok := newObj.(*v1beta2.ManagedClusterSet)
+	if !ok {
+		return nil, apierrors.NewBadRequest("Request clusterset obj format is not right")
+	}
+	oldClusterSet, ok := oldObj.(*v1beta2.ManagedClusterSet)
+	if !ok {
+		return nil, apierrors.NewBadRequest("Request clusterset obj format is not right")
+	}
+
+	if err := validateChildClusterSets(clusterSet); err != nil {
+		return nil, err
+	}
+
+	originalParent := oldClusterSet.Labels[commonhelpers.ParentClusterSetLabel]
+	parent := clusterSet.Labels[commonhelpers.ParentClusterSetLabel]
+	if len(parent) > 0 {
+		if err := s.validateParent(ctx, clusterSet, parent); err != nil {
+			return nil, err
+		}
+	}
+	if originalParent == parent {
+		return nil, nil
+	}
+
+	// moving a clusterset requires the rights on both the original and the new parent
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	for _, name := range []string{originalParent, parent} {
+		if len(name) == 0 {
+			continue
+		}
+		if err := s.allowNestClusterSet(ctx, req.UserInfo, name); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (s *ManagedClusterSetWebhook) ValidateDelete(_ context.Context, obj runtime.Object) (
+	admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateChildClusterSets checks the names of the child clustersets requested with the ChildClusterSetsAnnotation
+func validateChildClusterSets(clusterSet *v1beta2.ManagedClusterSet) error {
+	value, ok := clusterSet.Annotations[commonhelpers.ChildClusterSetsAnnotation]
+	if !ok {
+		return nil
+	}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		if name == clusterSet.Name {
+			return apierrors.NewBadRequest(fmt.Sprintf(
+				"The ManagedClusterSet %q cannot be a child of itself", clusterSet.Name))
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return apierrors.NewBadRequest(fmt.Sprintf(
+				"Invalid child ManagedClusterSet name %q: %s", name, strings.Join(errs, ",")))
+		}
+	}
+	return nil
+}
+
+// validateParent checks the parent exists and the clusterset is not an ancestor of its parent. Only the
+// ExclusiveClusterSetLabel clustersets can be nested, so each cluster belongs to one branch of the tree.
+func (s *ManagedClusterSetWebhook) validateParent(ctx context.Context, clusterSet *v1beta2.ManagedClusterSet, parent string) error {
+	if parent == clusterSet.Name {
+		return apierrors.NewBadRequest(fmt.Sprintf(
+			"The ManagedClusterSet %q cannot be the parent of itself", clusterSet.Name))
+	}
+
+	selectorType := clusterSet.Spec.ClusterSelector.SelectorType
+	if len(selectorType) > 0 && selectorType != v1beta2.ExclusiveClusterSetLabel {
+		return apierrors.NewBadRequest(fmt.Sprintf(
+			"Only the ManagedClusterSet with the selector type %q can have a parent", v1beta2.ExclusiveClusterSetLabel))
+	}
+
+	_, err := s.clusterClient.ClusterV1beta2().ManagedClusterSets().Get(ctx, parent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return apierrors.NewBadRequest(fmt.Sprintf("The parent ManagedClusterSet %q does not exist", parent))
+	}
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	ancestors, err := GetClusterSetAncestorNames(ctx, s.clusterClient, parent)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if sets.New(ancestors...).Has(clusterSet.Name) {
+		return apierrors.NewBadRequest(fmt.Sprintf(
+			"The ManagedClusterSet %q cannot be nested under its descendant %q", clusterSet.Name, parent))
+	}
+	return nil
+}
+
+// allowNestClusterSet checks whether a request user has been authorized to add/remove a child ManagedClusterSet
+// to/from the parent ManagedClusterSet. The admins of an ancestor of the parent are authorized as well.
+func (s *ManagedClusterSetWebhook) allowNestClusterSet(ctx context.Context, userInfo authenticationv1.UserInfo, parent string) error {
+	ancestors, err := GetClusterSetAncestorNames(ctx, s.clusterClient, parent)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	for _, name := range append([]string{parent}, ancestors...) {
+		allowed, err := AllowClusterSetSubresource(s.kubeClient, userInfo, name, "nest")
+		if err != nil {
+			return apierrors.NewForbidden(v1beta1.Resource("managedclustersets/nest"), parent, err)
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	return apierrors.NewForbidden(
+		v1beta1.Resource("managedclustersets/nest"),
+		parent,
+		fmt.Errorf("user %q cannot add/remove a child ManagedClusterSet to/from ManagedClusterSet %q", userInfo.Username, parent),
+	)
+}
+
+// GetClusterSetAncestorNames returns the names of the ancestors of the clusterset from its parent to the root. It
+// stops at a non-existent clusterset or a cycle of the parent references.
+func GetClusterSetAncestorNames(ctx context.Context, clusterClient clusterv1client.Interface, clusterSetName string) ([]string, error) {
+	var ancestors []string
+	visited := sets.New[string](clusterSetName)
+	for name := clusterSetName; ; {
+		clusterSet, err := clusterClient.ClusterV1beta2().ManagedClusterSets().Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return ancestors, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name = clusterSet.Labels[commonhelpers.ParentClusterSetLabel]
+		if len(name) == 0 || visited.Has(name) {
+			return ancestors, nil
+		}
+		visited.Insert(name)
+		ancestors = append(ancestors, name)
+	}
+}
+
+// AllowClusterSetSubresource checks whether a request user has been authorized to create the virtual subresource
+// of a ManagedClusterSet
+func AllowClusterSetSubresource(kubeClient kubernetes.Interface, userInfo authenticationv1.UserInfo,
+	clusterSetName, subresource string) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range userInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       "cluster.open-cluster-management.io",
+				Resource:    "managedclustersets",
+				Subresource: subresource,
+				Verb:        "create",
+				Name:        clusterSetName,
+			},
+		},
+	}
+	sar, err := kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed, nil
+}
@@ -0,0 +1,227 @@
+package v1beta2
+
+import (
+	"context"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	"open-cluster-management.io/api/cluster/v1beta2"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+func newClusterSet(name, parent string) *v1beta2.ManagedClusterSet {
+	clusterSet := &v1beta2.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1beta2.ManagedClusterSetSpec{
+			ClusterSelector: v1beta2.ManagedClusterSelector{
+				SelectorType: v1beta2.ExclusiveClusterSetLabel,
+			},
+		},
+	}
+	if len(parent) > 0 {
+		clusterSet.Labels = map[string]string{commonhelpers.ParentClusterSetLabel: parent}
+	}
+	return clusterSet
+}
+
+func newManagedClusterSetWebhook(allowNest map[string]bool, clusterSets ...runtime.Object) *ManagedClusterSetWebhook {
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.PrependReactor(
+		"create",
+		"subjectaccessreviews",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			allowed := sar.Spec.ResourceAttributes.Subresource == "nest" && allowNest[sar.Spec.ResourceAttributes.Name]
+			return true, &authorizationv1.SubjectAccessReview{
+				Status: authorizationv1.SubjectAccessReviewStatus{
+					Allowed: allowed,
+				},
+			}, nil
+		},
+	)
+	return &ManagedClusterSetWebhook{
+		kubeClient:    kubeClient,
+		clusterClient: clusterfake.NewSimpleClientset(clusterSets...),
+	}
+}
+
+func TestValidateCreateClusterSet(t *testing.T) {
+	cases := []struct {
+		name          string
+		clusterSet    *v1beta2.ManagedClusterSet
+		existingSets  []runtime.Object
+		allowNest     map[string]bool
+		expectedError bool
+	}{
+		{
+			name:       "root clusterset",
+			clusterSet: newClusterSet("set1", ""),
+		},
+		{
+			name: "invalid child clusterset name",
+			clusterSet: func() *v1beta2.ManagedClusterSet {
+				clusterSet := newClusterSet("set1", "")
+				clusterSet.Annotations = map[string]string{commonhelpers.ChildClusterSetsAnnotation: "child1, Child_2"}
+				return clusterSet
+			}(),
+			expectedError: true,
+		},
+		{
+			name: "clusterset is a child of itself",
+			clusterSet: func() *v1beta2.ManagedClusterSet {
+				clusterSet := newClusterSet("set1", "")
+				clusterSet.Annotations = map[string]string{commonhelpers.ChildClusterSetsAnnotation: "set1"}
+				return clusterSet
+			}(),
+			expectedError: true,
+		},
+		{
+			name:          "parent does not exist",
+			clusterSet:    newClusterSet("set1", "parent"),
+			allowNest:     map[string]bool{"parent": true},
+			expectedError: true,
+		},
+		{
+			name: "label selector clusterset",
+			clusterSet: func() *v1beta2.ManagedClusterSet {
+				clusterSet := newClusterSet("set1", "parent")
+				clusterSet.Spec.ClusterSelector.SelectorType = v1beta2.LabelSelector
+				return clusterSet
+			}(),
+			existingSets:  []runtime.Object{newClusterSet("parent", "")},
+			allowNest:     map[string]bool{"parent": true},
+			expectedError: true,
+		},
+		{
+			name:          "no permission",
+			clusterSet:    newClusterSet("set1", "parent"),
+			existingSets:  []runtime.Object{newClusterSet("parent", "")},
+			expectedError: true,
+		},
+		{
+			name:         "permission on the parent",
+			clusterSet:   newClusterSet("set1", "parent"),
+			existingSets: []runtime.Object{newClusterSet("parent", "")},
+			allowNest:    map[string]bool{"parent": true},
+		},
+		{
+			name:         "permission on an ancestor",
+			clusterSet:   newClusterSet("set1", "parent"),
+			existingSets: []runtime.Object{newClusterSet("parent", "root"), newClusterSet("root", "")},
+			allowNest:    map[string]bool{"root": true},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := newManagedClusterSetWebhook(c.allowNest, c.existingSets...)
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: "user1"},
+				},
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), req)
+
+			_, err := w.ValidateCreate(ctx, c.clusterSet)
+			if err != nil && !c.expectedError {
+				t.Errorf("Case:%v, Expect nil Error but got err:%v", c.name, err)
+			}
+			if err == nil && c.expectedError {
+				t.Errorf("Case:%v, Expect Error but got nil", c.name)
+			}
+		})
+	}
+	w := ManagedClusterSetWebhook{}
+	_, err := w.ValidateCreate(context.Background(), &v1beta2.ManagedClusterSetBinding{})
+	if err == nil {
+		t.Errorf("Non clusterset obj, Expect Error but got nil")
+	}
+}
+
+func TestValidateUpdateClusterSet(t *testing.T) {
+	cases := []struct {
+		name          string
+		oldClusterSet *v1beta2.ManagedClusterSet
+		clusterSet    *v1beta2.ManagedClusterSet
+		existingSets  []runtime.Object
+		allowNest     map[string]bool
+		expectedError bool
+	}{
+		{
+			name:          "parent is not changed",
+			oldClusterSet: newClusterSet("set1", "parent"),
+			clusterSet:    newClusterSet("set1", "parent"),
+			existingSets:  []runtime.Object{newClusterSet("parent", "")},
+		},
+		{
+			name:          "nested under itself",
+			oldClusterSet: newClusterSet("set1", ""),
+			clusterSet:    newClusterSet("set1", "set1"),
+			existingSets:  []runtime.Object{newClusterSet("set1", "")},
+			allowNest:     map[string]bool{"set1": true},
+			expectedError: true,
+		},
+		{
+			name:          "nested under its descendant",
+			oldClusterSet: newClusterSet("set1", ""),
+			clusterSet:    newClusterSet("set1", "grandchild"),
+			existingSets: []runtime.Object{
+				newClusterSet("set1", ""), newClusterSet("child", "set1"), newClusterSet("grandchild", "child"),
+			},
+			allowNest:     map[string]bool{"grandchild": true},
+			expectedError: true,
+		},
+		{
+			name:          "no permission on the original parent",
+			oldClusterSet: newClusterSet("set1", "parent1"),
+			clusterSet:    newClusterSet("set1", "parent2"),
+			existingSets:  []runtime.Object{newClusterSet("parent1", ""), newClusterSet("parent2", "")},
+			allowNest:     map[string]bool{"parent2": true},
+			expectedError: true,
+		},
+		{
+			name:          "moved to another parent",
+			oldClusterSet: newClusterSet("set1", "parent1"),
+			clusterSet:    newClusterSet("set1", "parent2"),
+			existingSets:  []runtime.Object{newClusterSet("parent1", ""), newClusterSet("parent2", "")},
+			allowNest:     map[string]bool{"parent1": true, "parent2": true},
+		},
+		{
+			name:          "removed from the parent",
+			oldClusterSet: newClusterSet("set1", "parent"),
+			clusterSet:    newClusterSet("set1", ""),
+			existingSets:  []runtime.Object{newClusterSet("parent", "")},
+			allowNest:     map[string]bool{"parent": true},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := newManagedClusterSetWebhook(c.allowNest, c.existingSets...)
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: "user1"},
+				},
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), req)
+
+			_, err := w.ValidateUpdate(ctx, c.oldClusterSet, c.clusterSet)
+			if err != nil && !c.expectedError {
+				t.Errorf("Case:%v, Expect nil Error but got err:%v", c.name, err)
+			}
+			if err == nil && c.expectedError {
+				t.Errorf("Case:%v, Expect Error but got nil", c.name)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	"open-cluster-management.io/api/cluster/v1beta2"
 )
 
@@ -18,17 +19,13 @@ var (
 // Adds the list of known types to api.Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(v1beta2.GroupVersion,
-		&ManagedClusterSet{},
+		&v1beta2.ManagedClusterSet{},
 		&v1beta2.ManagedClusterSetBinding{},
 	)
 	metav1.AddToGroupVersion(scheme, v1beta2.GroupVersion)
 	return nil
 }
 
-type ManagedClusterSet struct {
-	v1beta2.ManagedClusterSet
-}
-
 type ManagedClusterSetBindingWebhook struct {
 	kubeClient kubernetes.Interface
 }
@@ -53,3 +50,28 @@ func (b *ManagedClusterSetBindingWebhook) SetupWebhookWithManager(mgr ctrl.Manag
 		For(&v1beta2.ManagedClusterSetBinding{}).
 		Complete()
 }
+
+type ManagedClusterSetWebhook struct {
+	kubeClient    kubernetes.Interface
+	clusterClient clusterv1client.Interface
+}
+
+func (s *ManagedClusterSetWebhook) Init(mgr ctrl.Manager) error {
+	err := s.SetupWebhookWithManager(mgr)
+	if err != nil {
+		return err
+	}
+	s.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	s.clusterClient, err = clusterv1client.NewForConfig(mgr.GetConfig())
+	return err
+}
+
+func (s *ManagedClusterSetWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		WithValidator(s).
+		For(&v1beta2.ManagedClusterSet{}).
+		Complete()
+}
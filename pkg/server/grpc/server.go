@@ -28,11 +28,13 @@ import (
 	grpcoptions "open-cluster-management.io/sdk-go/pkg/cloudevents/server/grpc/options"
 
 	commonoptions "open-cluster-management.io/ocm/pkg/common/options"
+	commontunnel "open-cluster-management.io/ocm/pkg/common/tunnel"
 	"open-cluster-management.io/ocm/pkg/server/services/addon"
 	"open-cluster-management.io/ocm/pkg/server/services/cluster"
 	"open-cluster-management.io/ocm/pkg/server/services/csr"
 	"open-cluster-management.io/ocm/pkg/server/services/event"
 	"open-cluster-management.io/ocm/pkg/server/services/lease"
+	"open-cluster-management.io/ocm/pkg/server/services/tunnel"
 	"open-cluster-management.io/ocm/pkg/server/services/work"
 	"open-cluster-management.io/ocm/pkg/version"
 )
@@ -40,6 +42,7 @@ import (
 func NewGRPCServer() *cobra.Command {
 	opts := commonoptions.NewOptions()
 	grpcServerOpts := grpcoptions.NewGRPCServerOptions()
+	tunnelOpts := tunnel.NewOptions()
 	cmdConfig := opts.
 		NewControllerCommandConfig(
 			"grpc-server",
@@ -50,7 +53,14 @@ func NewGRPCServer() *cobra.Command {
 					return err
 				}
 
-				return grpcoptions.NewServer(grpcServerOpts).WithPreStartHooks(clients).WithAuthenticator(
+				tunnelService := tunnel.NewTunnelService()
+				proxyServer, err := tunnel.NewProxyServer(
+					tunnelOpts, grpcServerOpts.TLSCertFile, grpcServerOpts.TLSKeyFile, clients.kubeClient, tunnelService)
+				if err != nil {
+					return err
+				}
+
+				return grpcoptions.NewServer(grpcServerOpts).WithPreStartHooks(clients, proxyServer).WithAuthenticator(
 					grpcauthn.NewTokenAuthenticator(clients.kubeClient),
 				).WithAuthenticator(
 					grpcauthn.NewMtlsAuthenticator(),
@@ -72,6 +82,9 @@ func NewGRPCServer() *cobra.Command {
 				).WithService(
 					payload.ManifestBundleEventDataType,
 					work.NewWorkService(clients.workClient, clients.workInformers.Work().V1().ManifestWorks()),
+				).WithService(
+					commontunnel.TunnelRequestEventDataType,
+					tunnelService,
 				).Run(ctx)
 			},
 			clock.RealClock{},
@@ -83,6 +96,7 @@ func NewGRPCServer() *cobra.Command {
 	flags := cmd.Flags()
 	opts.AddFlags(flags)
 	grpcServerOpts.AddFlags(flags)
+	tunnelOpts.AddFlags(flags)
 
 	return cmd
 }
@@ -0,0 +1,71 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// AuditEvent records a request proxied by the tunnel.
+type AuditEvent struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"requestID"`
+	User       string    `json:"user,omitempty"`
+	Groups     []string  `json:"groups,omitempty"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path,omitempty"`
+	StatusCode int       `json:"statusCode"`
+	Latency    string    `json:"latency"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// AuditLogger writes the audit events as json lines. If no writer is specified, the audit events are written to
+// the log of the server.
+type AuditLogger struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+// NewAuditLogger returns an AuditLogger appending the audit events to the file of the path, the audit events are
+// written to the log of the server if the path is empty.
+func NewAuditLogger(path string) (*AuditLogger, error) {
+	if len(path) == 0 {
+		return &AuditLogger{}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLogger{writer: file}, nil
+}
+
+func (l *AuditLogger) Log(event *AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if l.writer == nil {
+		klog.InfoS("Tunnel request audit", "requestID", event.RequestID, "user", event.User, "groups", event.Groups,
+			"cluster", event.Cluster, "method", event.Method, "path", event.Path, "statusCode", event.StatusCode,
+			"latency", event.Latency, "error", event.Error)
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		klog.Errorf("failed to marshal the audit event of tunnel request %s: %v", event.RequestID, err)
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, err := l.writer.Write(append(data, '\n')); err != nil {
+		klog.Errorf("failed to write the audit event of tunnel request %s: %v", event.RequestID, err)
+	}
+}
@@ -0,0 +1,273 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/tunnel"
+)
+
+const clustersPathPrefix = "/clusters/"
+
+// forwardedRequestHeaders and forwardedResponseHeaders are the headers carried by the tunnel, the other headers,
+// e.g. Authorization and Impersonate-*, are dropped.
+var (
+	forwardedRequestHeaders  = []string{"Accept", "Content-Type"}
+	forwardedResponseHeaders = []string{"Content-Type", "Warning"}
+)
+
+// requester sends the tunnel request to the agent and waits for its response.
+type requester interface {
+	Do(ctx context.Context, req *tunnel.TunnelRequest) (*tunnel.TunnelResponse, error)
+}
+
+// ProxyHandler serves the requests to the kube-apiserver of the managed clusters with the path
+// /clusters/<cluster name>/<kube-apiserver path>. The hub user is authenticated with a TokenReview and authorized with
+// a SubjectAccessReview on the proxy subresource of the managed cluster, then the request is sent to the agent of the
+// cluster which impersonates the hub user on the managed cluster.
+type ProxyHandler struct {
+	kubeClient kubernetes.Interface
+	requester  requester
+	timeout    time.Duration
+	audiences  []string
+	auditor    *AuditLogger
+}
+
+func NewProxyHandler(kubeClient kubernetes.Interface, requester requester, timeout time.Duration,
+	audiences []string, auditor *AuditLogger) *ProxyHandler {
+	return &ProxyHandler{
+		kubeClient: kubeClient,
+		requester:  requester,
+		timeout:    timeout,
+		audiences:  audiences,
+		auditor:    auditor,
+	}
+}
+
+func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	event := &AuditEvent{
+		RequestID: uuid.NewString(),
+		Method:    r.Method,
+	}
+	defer func() {
+		event.Latency = time.Since(startTime).String()
+		h.auditor.Log(event)
+	}()
+
+	clusterName, path, ok := splitClusterPath(r.URL.Path)
+	if !ok {
+		h.error(w, event, http.StatusNotFound, "the path must be /clusters/<cluster name>/<path>")
+		return
+	}
+	event.Cluster = clusterName
+	event.Path = path
+	if len(r.URL.RawQuery) > 0 {
+		event.Path = path + "?" + r.URL.RawQuery
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		h.error(w, event, http.StatusUnauthorized, "a bearer token is required")
+		return
+	}
+	userInfo, err := h.authenticate(r.Context(), token)
+	if err != nil {
+		h.error(w, event, http.StatusUnauthorized, err.Error())
+		return
+	}
+	event.User = userInfo.Username
+	event.Groups = userInfo.Groups
+
+	verb, ok := proxyVerb(r.Method)
+	if !ok {
+		h.error(w, event, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not supported", r.Method))
+		return
+	}
+	if err := h.authorize(r.Context(), userInfo, clusterName, verb); err != nil {
+		h.error(w, event, http.StatusForbidden, err.Error())
+		return
+	}
+
+	if isStreaming(r) {
+		h.error(w, event, http.StatusBadRequest, "watch, follow and upgrade requests are not supported by the tunnel")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, tunnel.MaxBodySize+1))
+	if err != nil {
+		h.error(w, event, http.StatusBadRequest, fmt.Sprintf("failed to read the request body: %v", err))
+		return
+	}
+	if len(body) > tunnel.MaxBodySize {
+		h.error(w, event, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("the request body exceeds %d bytes", tunnel.MaxBodySize))
+		return
+	}
+
+	req := &tunnel.TunnelRequest{
+		ID:          event.RequestID,
+		ClusterName: clusterName,
+		User:        userInfo.Username,
+		Groups:      userInfo.Groups,
+		Method:      r.Method,
+		Path:        event.Path,
+		Header:      filterHeader(r.Header, forwardedRequestHeaders),
+		Body:        body,
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	resp, err := h.requester.Do(ctx, req)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		h.error(w, event, http.StatusGatewayTimeout,
+			fmt.Sprintf("the cluster %s does not respond in %s", clusterName, h.timeout))
+		return
+	case errors.Is(err, ErrClusterNotConnected):
+		h.error(w, event, http.StatusBadGateway, fmt.Sprintf("the agent of the cluster %s is not connected", clusterName))
+		return
+	case err != nil:
+		h.error(w, event, http.StatusBadGateway, err.Error())
+		return
+	}
+	if len(resp.Error) > 0 {
+		h.error(w, event, http.StatusBadGateway, resp.Error)
+		return
+	}
+
+	for key, values := range filterHeader(resp.Header, forwardedResponseHeaders) {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	event.StatusCode = resp.StatusCode
+	w.WriteHeader(resp.StatusCode)
+	if _, err := w.Write(resp.Body); err != nil {
+		klog.Errorf("failed to write the response of tunnel request %s: %v", req.ID, err)
+	}
+}
+
+func (h *ProxyHandler) error(w http.ResponseWriter, event *AuditEvent, code int, message string) {
+	event.StatusCode = code
+	event.Error = message
+	http.Error(w, message, code)
+}
+
+func (h *ProxyHandler) authenticate(ctx context.Context, token string) (*authenticationv1.UserInfo, error) {
+	review, err := h.kubeClient.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: h.audiences},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review the token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, fmt.Errorf("the token is not authenticated: %s", review.Status.Error)
+	}
+	// the status audiences must be validated to ensure the TokenReview server is audience aware.
+	if len(h.audiences) > 0 && !sets.New(h.audiences...).HasAny(review.Status.Audiences...) {
+		return nil, fmt.Errorf("the token is not issued for the audiences %v", h.audiences)
+	}
+	return &review.Status.User, nil
+}
+
+func (h *ProxyHandler) authorize(ctx context.Context, userInfo *authenticationv1.UserInfo, clusterName, verb string) error {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range userInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review, err := h.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			Groups: userInfo.Groups,
+			UID:    userInfo.UID,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       clusterv1.GroupName,
+				Resource:    "managedclusters",
+				Subresource: "proxy",
+				Name:        clusterName,
+				Verb:        verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to review the access: %v", err)
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("user %q cannot %s the proxy of managed cluster %q", userInfo.Username, verb, clusterName)
+	}
+	return nil
+}
+
+// splitClusterPath splits the url path /clusters/<cluster name>/<path> to the cluster name and the path.
+func splitClusterPath(urlPath string) (string, string, bool) {
+	if !strings.HasPrefix(urlPath, clustersPathPrefix) {
+		return "", "", false
+	}
+	clusterName, path, ok := strings.Cut(strings.TrimPrefix(urlPath, clustersPathPrefix), "/")
+	if !ok || len(clusterName) == 0 || len(path) == 0 {
+		return "", "", false
+	}
+	return clusterName, "/" + path, true
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	return token, ok && len(token) > 0
+}
+
+func proxyVerb(method string) (string, bool) {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "get", true
+	case http.MethodPost:
+		return "create", true
+	case http.MethodPut:
+		return "update", true
+	case http.MethodPatch:
+		return "patch", true
+	case http.MethodDelete:
+		return "delete", true
+	default:
+		return "", false
+	}
+}
+
+// isStreaming returns true if the request expects a long-running response, the tunnel only carries the requests
+// with a complete response.
+func isStreaming(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, param := range []string{"watch", "follow"} {
+		if value := query.Get(param); value == "true" || value == "1" {
+			return true
+		}
+	}
+	return strings.Contains(r.URL.Path, "/watch/") || len(r.Header.Get("Upgrade")) > 0
+}
+
+func filterHeader(header http.Header, keys []string) http.Header {
+	filtered := http.Header{}
+	for _, key := range keys {
+		for _, value := range header.Values(key) {
+			filtered.Add(key, value)
+		}
+	}
+	return filtered
+}
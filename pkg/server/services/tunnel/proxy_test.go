@@ -0,0 +1,223 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"open-cluster-management.io/ocm/pkg/common/tunnel"
+)
+
+type fakeRequester struct {
+	request  *tunnel.TunnelRequest
+	response *tunnel.TunnelResponse
+	err      error
+}
+
+func (r *fakeRequester) Do(ctx context.Context, req *tunnel.TunnelRequest) (*tunnel.TunnelResponse, error) {
+	r.request = req
+	return r.response, r.err
+}
+
+func TestServeHTTP(t *testing.T) {
+	cases := []struct {
+		name               string
+		method             string
+		path               string
+		token              string
+		header             http.Header
+		allowed            bool
+		requester          *fakeRequester
+		expectedStatusCode int
+		expectedBody       string
+		validateRequest    func(t *testing.T, req *tunnel.TunnelRequest)
+	}{
+		{
+			name:               "invalid path",
+			method:             http.MethodGet,
+			path:               "/api/v1/namespaces",
+			token:              "valid",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "no token",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "invalid token",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces",
+			token:              "invalid",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "wrong audience",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces",
+			token:              "invalid-audience",
+			allowed:            true,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "forbidden",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces",
+			token:              "valid",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "watch",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces?watch=true",
+			token:              "valid",
+			allowed:            true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "upgrade",
+			method:             http.MethodPost,
+			path:               "/clusters/cluster1/api/v1/namespaces/ns1/pods/pod1/exec",
+			token:              "valid",
+			header:             http.Header{"Upgrade": []string{"SPDY/3.1"}},
+			allowed:            true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "not connected",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces",
+			token:              "valid",
+			allowed:            true,
+			requester:          &fakeRequester{err: ErrClusterNotConnected},
+			expectedStatusCode: http.StatusBadGateway,
+		},
+		{
+			name:               "timeout",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces",
+			token:              "valid",
+			allowed:            true,
+			requester:          &fakeRequester{err: context.DeadlineExceeded},
+			expectedStatusCode: http.StatusGatewayTimeout,
+		},
+		{
+			name:               "agent error",
+			method:             http.MethodGet,
+			path:               "/clusters/cluster1/api/v1/namespaces",
+			token:              "valid",
+			allowed:            true,
+			requester:          &fakeRequester{response: &tunnel.TunnelResponse{Error: "connection refused"}},
+			expectedStatusCode: http.StatusBadGateway,
+		},
+		{
+			name:    "proxied",
+			method:  http.MethodGet,
+			path:    "/clusters/cluster1/api/v1/namespaces?limit=10",
+			token:   "valid",
+			header:  http.Header{"Accept": []string{"application/json"}, "Impersonate-User": []string{"admin"}},
+			allowed: true,
+			requester: &fakeRequester{response: &tunnel.TunnelResponse{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       []byte(`{"kind":"NamespaceList"}`),
+			}},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"kind":"NamespaceList"}`,
+			validateRequest: func(t *testing.T, req *tunnel.TunnelRequest) {
+				if req.ClusterName != "cluster1" || req.Path != "/api/v1/namespaces?limit=10" {
+					t.Errorf("unexpected request %s %s", req.ClusterName, req.Path)
+				}
+				if req.User != "user1" || len(req.Groups) != 1 || req.Groups[0] != "group1" {
+					t.Errorf("unexpected user %s %v", req.User, req.Groups)
+				}
+				if len(req.Header) != 1 || req.Header.Get("Accept") != "application/json" {
+					t.Errorf("unexpected header %v", req.Header)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "tokenreviews",
+				func(action clienttesting.Action) (bool, runtime.Object, error) {
+					review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+					switch review.Spec.Token {
+					case "valid":
+						review.Status.Authenticated = true
+						review.Status.Audiences = review.Spec.Audiences
+						review.Status.User = authenticationv1.UserInfo{Username: "user1", Groups: []string{"group1"}}
+					case "invalid-audience":
+						review.Status.Authenticated = true
+						review.Status.User = authenticationv1.UserInfo{Username: "user1", Groups: []string{"group1"}}
+					}
+					return true, review, nil
+				})
+			kubeClient.PrependReactor("create", "subjectaccessreviews",
+				func(action clienttesting.Action) (bool, runtime.Object, error) {
+					review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					attributes := review.Spec.ResourceAttributes
+					if attributes.Resource != "managedclusters" || attributes.Subresource != "proxy" ||
+						attributes.Name != "cluster1" {
+						t.Errorf("unexpected resource attributes %v", attributes)
+					}
+					review.Status.Allowed = c.allowed
+					return true, review, nil
+				})
+
+			requester := c.requester
+			if requester == nil {
+				requester = &fakeRequester{}
+			}
+			auditLog := &bytes.Buffer{}
+			handler := NewProxyHandler(kubeClient, requester, time.Second,
+				[]string{DefaultTokenAudience}, &AuditLogger{writer: auditLog})
+
+			r := httptest.NewRequest(c.method, c.path, nil)
+			for key, values := range c.header {
+				r.Header[key] = values
+			}
+			if len(c.token) > 0 {
+				r.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d, but got %d: %s", c.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if len(c.expectedBody) > 0 && w.Body.String() != c.expectedBody {
+				t.Errorf("expected body %s, but got %s", c.expectedBody, w.Body.String())
+			}
+			if c.validateRequest != nil {
+				c.validateRequest(t, requester.request)
+			}
+
+			event := &AuditEvent{}
+			if err := json.Unmarshal(bytes.TrimSpace(auditLog.Bytes()), event); err != nil {
+				t.Fatalf("failed to unmarshal audit event %q: %v", auditLog.String(), err)
+			}
+			if event.StatusCode != c.expectedStatusCode || event.Method != c.method {
+				t.Errorf("unexpected audit event %v", event)
+			}
+			if len(c.token) > 0 && !strings.HasPrefix(c.token, "invalid") && strings.HasPrefix(c.path, "/clusters/") &&
+				event.User != "user1" {
+				t.Errorf("expected user1 in audit event, but got %q", event.User)
+			}
+		})
+	}
+}
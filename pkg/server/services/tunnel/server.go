@@ -0,0 +1,107 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// Options holds the configuration of the tunnel proxy server.
+type Options struct {
+	// BindAddress is the address the proxy server listens on, the proxy server is disabled if it is empty.
+	BindAddress    string
+	RequestTimeout time.Duration
+	AuditLogPath   string
+	// TokenAudiences are the audiences the bearer tokens of the hub users must be issued for.
+	TokenAudiences []string
+}
+
+// DefaultTokenAudience is the audience of the tokens accepted by the proxy server by default, so the tokens issued
+// for the kube-apiserver or the other services are not replayed to the managed clusters.
+const DefaultTokenAudience = "open-cluster-management-hub-tunnel"
+
+func NewOptions() *Options {
+	return &Options{
+		RequestTimeout: 30 * time.Second,
+		TokenAudiences: []string{DefaultTokenAudience},
+	}
+}
+
+func (o *Options) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.BindAddress, "tunnel-bind-address", o.BindAddress,
+		"The address the tunnel proxy server listens on to proxy the requests to the managed clusters, "+
+			"the proxy server is disabled if it is empty")
+	flags.DurationVar(&o.RequestTimeout, "tunnel-request-timeout", o.RequestTimeout,
+		"The timeout to wait for the response of a managed cluster")
+	flags.StringVar(&o.AuditLogPath, "tunnel-audit-log-path", o.AuditLogPath,
+		"The path of the file to write the audit events of the proxied requests, the audit events are written to "+
+			"the log if it is empty")
+	flags.StringSliceVar(&o.TokenAudiences, "tunnel-token-audiences", o.TokenAudiences,
+		"The audiences the bearer tokens of the hub users must be issued for, a token is rejected if none of its "+
+			"audiences is in the list")
+}
+
+// ProxyServer serves the ProxyHandler over https with the serving certificate of the gRPC server.
+type ProxyServer struct {
+	options     *Options
+	certFile    string
+	keyFile     string
+	kubeClient  kubernetes.Interface
+	service     *TunnelService
+	auditLogger *AuditLogger
+}
+
+func NewProxyServer(options *Options, certFile, keyFile string,
+	kubeClient kubernetes.Interface, service *TunnelService) (*ProxyServer, error) {
+	auditLogger, err := NewAuditLogger(options.AuditLogPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProxyServer{
+		options:     options,
+		certFile:    certFile,
+		keyFile:     keyFile,
+		kubeClient:  kubeClient,
+		service:     service,
+		auditLogger: auditLogger,
+	}, nil
+}
+
+// Run starts the proxy server in the background if the bind address is specified.
+func (s *ProxyServer) Run(ctx context.Context) {
+	if len(s.options.BindAddress) == 0 {
+		return
+	}
+
+	server := &http.Server{
+		Addr: s.options.BindAddress,
+		Handler: NewProxyHandler(
+			s.kubeClient, s.service, s.options.RequestTimeout, s.options.TokenAudiences, s.auditLogger),
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		klog.Infof("Starting tunnel proxy server on %s", s.options.BindAddress)
+		if err := server.ListenAndServeTLS(s.certFile, s.keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("failed to serve the tunnel proxy server: %v", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		klog.Infof("Shutting down tunnel proxy server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("failed to shutdown the tunnel proxy server: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,153 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cloudeventstypes "github.com/cloudevents/sdk-go/v2/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic/types"
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/server"
+
+	"open-cluster-management.io/ocm/pkg/common/tunnel"
+	"open-cluster-management.io/ocm/pkg/server/services"
+)
+
+var tunnelRequestResource = schema.GroupResource{
+	Group:    tunnel.TunnelRequestEventDataType.Group,
+	Resource: tunnel.TunnelRequestEventDataType.Resource,
+}
+
+// ErrClusterNotConnected is returned when the agent of the cluster does not subscribe to the tunnel requests.
+var ErrClusterNotConnected = fmt.Errorf("the agent of the cluster is not connected")
+
+// subscriptionChecker is implemented by the grpc broker to check if an agent subscribes to it.
+type subscriptionChecker interface {
+	IsConsumerSubscribed(consumerName string) bool
+}
+
+type pendingRequest struct {
+	request  *tunnel.TunnelRequest
+	response chan *tunnel.TunnelResponse
+}
+
+// TunnelService sends the tunnel requests to the agents and receives their responses. The requests are only kept in
+// memory until they are responded or timed out, so they are never resynced to a reconnected agent.
+type TunnelService struct {
+	codec *tunnel.TunnelRequestCodec
+
+	lock    sync.RWMutex
+	handler server.EventHandler
+	pending map[string]*pendingRequest
+}
+
+func NewTunnelService() *TunnelService {
+	return &TunnelService{
+		codec:   tunnel.NewTunnelRequestCodec(),
+		pending: map[string]*pendingRequest{},
+	}
+}
+
+func (s *TunnelService) Get(_ context.Context, resourceID string) (*cloudevents.Event, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	pending, ok := s.pending[resourceID]
+	if !ok {
+		return nil, errors.NewNotFound(tunnelRequestResource, resourceID)
+	}
+
+	return s.codec.Encode(services.CloudEventsSourceKube, types.CloudEventsType{CloudEventsDataType: tunnel.TunnelRequestEventDataType}, pending.request)
+}
+
+// List always returns nothing, the pending requests are not resynced to the agents.
+func (s *TunnelService) List(listOpts types.ListOptions) ([]*cloudevents.Event, error) {
+	return nil, nil
+}
+
+func (s *TunnelService) HandleStatusUpdate(ctx context.Context, evt *cloudevents.Event) error {
+	eventType, err := types.ParseCloudEventsType(evt.Type())
+	if err != nil {
+		return fmt.Errorf("failed to parse cloud event type %s, %v", evt.Type(), err)
+	}
+	if eventType.Action != types.UpdateRequestAction || eventType.SubResource != types.SubResourceStatus {
+		return fmt.Errorf("unsupported event type %s for tunnel requests", eventType)
+	}
+
+	req, err := s.codec.Decode(evt)
+	if err != nil {
+		return err
+	}
+	clusterName, err := cloudeventstypes.ToString(evt.Extensions()[types.ExtensionClusterName])
+	if err != nil {
+		return fmt.Errorf("failed to get clustername extension: %v", err)
+	}
+
+	klog.V(4).Infof("tunnel request %s of cluster %s is responded", req.ID, clusterName)
+
+	s.lock.RLock()
+	pending, ok := s.pending[req.ID]
+	s.lock.RUnlock()
+	if !ok {
+		// the request is timed out, do nothing
+		return nil
+	}
+	// only the agent of the requested cluster can respond the request
+	if pending.request.ClusterName != clusterName {
+		return fmt.Errorf("tunnel request %s is not sent to cluster %s", req.ID, clusterName)
+	}
+	if req.Response == nil {
+		return fmt.Errorf("tunnel request %s has no response", req.ID)
+	}
+
+	select {
+	case pending.response <- req.Response:
+	default:
+		// the request has been responded, ignore the duplicated one
+	}
+	return nil
+}
+
+func (s *TunnelService) RegisterHandler(handler server.EventHandler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handler = handler
+}
+
+// Do sends the request to the agent of the cluster and waits for the response until the context is done.
+func (s *TunnelService) Do(ctx context.Context, req *tunnel.TunnelRequest) (*tunnel.TunnelResponse, error) {
+	s.lock.Lock()
+	handler := s.handler
+	pending := &pendingRequest{request: req, response: make(chan *tunnel.TunnelResponse, 1)}
+	s.pending[req.ID] = pending
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.pending, req.ID)
+	}()
+
+	if handler == nil {
+		return nil, ErrClusterNotConnected
+	}
+	if checker, ok := handler.(subscriptionChecker); ok && !checker.IsConsumerSubscribed(req.ClusterName) {
+		return nil, ErrClusterNotConnected
+	}
+
+	if err := handler.OnCreate(ctx, tunnel.TunnelRequestEventDataType, req.ID); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-pending.response:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,224 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic/types"
+
+	"open-cluster-management.io/ocm/pkg/common/tunnel"
+)
+
+// fakeAgent is registered to the service as the broker, it responds the created requests like an agent.
+type fakeAgent struct {
+	service    *TunnelService
+	subscribed bool
+	response   *tunnel.TunnelResponse
+}
+
+func (a *fakeAgent) OnCreate(ctx context.Context, t types.CloudEventsDataType, resourceID string) error {
+	evt, err := a.service.Get(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	if a.response == nil {
+		return nil
+	}
+
+	req, err := tunnel.NewTunnelRequestCodec().Decode(evt)
+	if err != nil {
+		return err
+	}
+	req.Response = a.response
+	go func() {
+		if err := a.service.HandleStatusUpdate(ctx, newStatusEvent(req)); err != nil {
+			panic(err)
+		}
+	}()
+	return nil
+}
+
+func (a *fakeAgent) OnUpdate(ctx context.Context, t types.CloudEventsDataType, resourceID string) error {
+	return nil
+}
+
+func (a *fakeAgent) OnDelete(ctx context.Context, t types.CloudEventsDataType, resourceID string) error {
+	return nil
+}
+
+func (a *fakeAgent) IsConsumerSubscribed(consumerName string) bool {
+	return a.subscribed
+}
+
+func newStatusEvent(req *tunnel.TunnelRequest) *cloudevents.Event {
+	evt, err := tunnel.NewTunnelRequestCodec().Encode("test-agent", types.CloudEventsType{
+		CloudEventsDataType: tunnel.TunnelRequestEventDataType,
+		SubResource:         types.SubResourceStatus,
+		Action:              types.UpdateRequestAction,
+	}, req)
+	if err != nil {
+		panic(err)
+	}
+	return evt
+}
+
+func TestGet(t *testing.T) {
+	service := NewTunnelService()
+	if _, err := service.Get(context.Background(), "test"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found error, but got %v", err)
+	}
+
+	service.pending["test"] = &pendingRequest{request: &tunnel.TunnelRequest{ID: "test", ClusterName: "cluster1"}}
+	evt, err := service.Get(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	req, err := tunnel.NewTunnelRequestCodec().Decode(evt)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if req.ID != "test" || req.ClusterName != "cluster1" {
+		t.Errorf("unexpected request %v", req)
+	}
+	if clusterName := evt.Extensions()[types.ExtensionClusterName]; clusterName != "cluster1" {
+		t.Errorf("expected cluster name cluster1, but got %v", clusterName)
+	}
+}
+
+func TestList(t *testing.T) {
+	service := NewTunnelService()
+	service.pending["test"] = &pendingRequest{request: &tunnel.TunnelRequest{ID: "test", ClusterName: "cluster1"}}
+	evts, err := service.List(types.ListOptions{ClusterName: "cluster1"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(evts) != 0 {
+		t.Errorf("expected no events, but got %d", len(evts))
+	}
+}
+
+func TestHandleStatusUpdate(t *testing.T) {
+	cases := []struct {
+		name             string
+		evt              *cloudevents.Event
+		expectedError    bool
+		expectedResponse bool
+	}{
+		{
+			name: "invalid event type",
+			evt: func() *cloudevents.Event {
+				evt := types.NewEventBuilder("test", types.CloudEventsType{}).NewEvent()
+				return &evt
+			}(),
+			expectedError: true,
+		},
+		{
+			name: "unknown request",
+			evt: newStatusEvent(&tunnel.TunnelRequest{
+				ID: "unknown", ClusterName: "cluster1", Response: &tunnel.TunnelResponse{StatusCode: http.StatusOK},
+			}),
+		},
+		{
+			name: "responded by another cluster",
+			evt: newStatusEvent(&tunnel.TunnelRequest{
+				ID: "test", ClusterName: "cluster2", Response: &tunnel.TunnelResponse{StatusCode: http.StatusOK},
+			}),
+			expectedError: true,
+		},
+		{
+			name:          "no response",
+			evt:           newStatusEvent(&tunnel.TunnelRequest{ID: "test", ClusterName: "cluster1"}),
+			expectedError: true,
+		},
+		{
+			name: "responded",
+			evt: newStatusEvent(&tunnel.TunnelRequest{
+				ID: "test", ClusterName: "cluster1", Response: &tunnel.TunnelResponse{StatusCode: http.StatusOK},
+			}),
+			expectedResponse: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service := NewTunnelService()
+			pending := &pendingRequest{
+				request:  &tunnel.TunnelRequest{ID: "test", ClusterName: "cluster1"},
+				response: make(chan *tunnel.TunnelResponse, 1),
+			}
+			service.pending["test"] = pending
+
+			err := service.HandleStatusUpdate(context.Background(), c.evt)
+			if c.expectedError && err == nil {
+				t.Errorf("expected error, but failed")
+			}
+			if !c.expectedError && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if len(pending.response) == 1 != c.expectedResponse {
+				t.Errorf("expected response %v, but got %d responses", c.expectedResponse, len(pending.response))
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	cases := []struct {
+		name               string
+		agent              *fakeAgent
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:          "no handler",
+			expectedError: ErrClusterNotConnected,
+		},
+		{
+			name:          "agent is not subscribed",
+			agent:         &fakeAgent{},
+			expectedError: ErrClusterNotConnected,
+		},
+		{
+			name:          "agent does not respond",
+			agent:         &fakeAgent{subscribed: true},
+			expectedError: context.DeadlineExceeded,
+		},
+		{
+			name: "agent responds",
+			agent: &fakeAgent{
+				subscribed: true,
+				response:   &tunnel.TunnelResponse{StatusCode: http.StatusCreated},
+			},
+			expectedStatusCode: http.StatusCreated,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service := NewTunnelService()
+			if c.agent != nil {
+				c.agent.service = service
+				service.RegisterHandler(c.agent)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			resp, err := service.Do(ctx, &tunnel.TunnelRequest{ID: "test", ClusterName: "cluster1"})
+			if !errors.Is(err, c.expectedError) {
+				t.Errorf("expected error %v, but got %v", c.expectedError, err)
+			}
+			if err == nil && resp.StatusCode != c.expectedStatusCode {
+				t.Errorf("expected status code %d, but got %d", c.expectedStatusCode, resp.StatusCode)
+			}
+			if len(service.pending) != 0 {
+				t.Errorf("expected the pending request is removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package helper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+	ocmcellibrary "open-cluster-management.io/sdk-go/pkg/cel/library"
+)
+
+// FeedbackRulesAnnotationKey is the annotation on the ManifestWork to define the feedback rules of the types not
+// supported by the feedbackRules in the manifestConfigs. The value is the json of a list of ManifestFeedbackRules,
+// for example:
+//
+//	[{"resourceIdentifier": {"group": "apps", "resource": "deployments", "namespace": "default", "name": "web"},
+//	  "rules": [{"type": "CEL", "name": "readyPercentage", "expression": "object.status.readyReplicas * 100 / object.spec.replicas"},
+//	            {"type": "RelatedPods", "name": "pods"}]}]
+const FeedbackRulesAnnotationKey = "work.open-cluster-management.io/feedback-rules"
+
+// FeedbackRuleType is the type of the feedback rules in the FeedbackRulesAnnotationKey annotation.
+type FeedbackRuleType string
+
+const (
+	// CELFeedbackRuleType computes the value with a CEL expression. The expression is evaluated with the variable
+	// object, and the result of int, string and bool is returned as the typed value, other results are returned as
+	// JsonRaw, e.g. `object.status.readyReplicas * 100 / object.spec.replicas`.
+	CELFeedbackRuleType FeedbackRuleType = "CEL"
+	// RelatedPodsFeedbackRuleType returns the number of the related pods by phase and the reasons of the not ready
+	// containers, e.g. {"total":3,"ready":2,"phases":{"Running":3},"reasons":{"CrashLoopBackOff":1}}.
+	RelatedPodsFeedbackRuleType FeedbackRuleType = "RelatedPods"
+	// RelatedEventsFeedbackRuleType returns the latest warning events of the resource, the replicasets owned by the
+	// resource and the related pods, e.g.
+	// [{"object":"Pod/web-1","reason":"BackOff","message":"Back-off restarting failed container","count":3,...}].
+	RelatedEventsFeedbackRuleType FeedbackRuleType = "RelatedEvents"
+)
+
+// ManifestFeedbackRules are the feedback rules of the manifests matching the resource identifier.
+type ManifestFeedbackRules struct {
+	ResourceIdentifier workapiv1.ResourceIdentifier `json:"resourceIdentifier"`
+	Rules              []FeedbackRule               `json:"rules"`
+}
+
+// FeedbackRule returns a feedback value with the name. The related objects are selected by the selector in the
+// spec of the resource, or owned by the resource if the resource has no selector, they are returned as JsonRaw
+// which requires the RawFeedbackJsonString feature.
+type FeedbackRule struct {
+	Type FeedbackRuleType `json:"type"`
+	Name string           `json:"name"`
+	// Expression is the CEL expression of the CEL rule.
+	Expression string `json:"expression,omitempty"`
+}
+
+// GetFeedbackRules returns the feedback rules in the annotations of the ManifestWork, nil is returned if the
+// annotation is not set.
+func GetFeedbackRules(annotations map[string]string) ([]ManifestFeedbackRules, error) {
+	value, ok := annotations[FeedbackRulesAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var manifestRules []ManifestFeedbackRules
+	if err := json.Unmarshal([]byte(value), &manifestRules); err != nil {
+		return nil, fmt.Errorf("failed to decode feedback rules: %w", err)
+	}
+	for _, manifestRule := range manifestRules {
+		identifier := manifestRule.ResourceIdentifier
+		if len(identifier.Resource) == 0 || len(identifier.Name) == 0 {
+			return nil, fmt.Errorf("the resource and name of the resource identifier of the feedback rules are required")
+		}
+		names := sets.New[string]()
+		for _, rule := range manifestRule.Rules {
+			if len(rule.Name) == 0 {
+				return nil, fmt.Errorf("the name of the feedback rule of %s %s is required", identifier.Resource, identifier.Name)
+			}
+			if names.Has(rule.Name) {
+				return nil, fmt.Errorf("the name %s of the feedback rule of %s %s is duplicated", rule.Name, identifier.Resource, identifier.Name)
+			}
+			names.Insert(rule.Name)
+
+			switch rule.Type {
+			case CELFeedbackRuleType:
+				if len(rule.Expression) == 0 {
+					return nil, fmt.Errorf("the expression of the CEL feedback rule %s is required", rule.Name)
+				}
+			case RelatedPodsFeedbackRuleType, RelatedEventsFeedbackRuleType:
+				if len(rule.Expression) != 0 {
+					return nil, fmt.Errorf("the expression is not supported by the %s feedback rule %s", rule.Type, rule.Name)
+				}
+			default:
+				return nil, fmt.Errorf("unsupported type %q of the feedback rule %s", rule.Type, rule.Name)
+			}
+		}
+	}
+	return manifestRules, nil
+}
+
+// ValidateFeedbackRules validates the feedback rules in the annotations, the CEL expressions of the rules must
+// compile in the environment where the work agent evaluates them.
+func ValidateFeedbackRules(annotations map[string]string) error {
+	manifestRules, err := GetFeedbackRules(annotations)
+	if err != nil || len(manifestRules) == 0 {
+		return err
+	}
+	env, err := cel.NewEnv(slices.Concat(
+		[]cel.EnvOption{cel.Variable("object", cel.DynType)},
+		ocmcelcommon.BaseEnvOpts,
+		[]cel.EnvOption{ocmcellibrary.ConditionsLib()},
+	)...)
+	if err != nil {
+		return err
+	}
+	for _, manifestRule := range manifestRules {
+		for _, rule := range manifestRule.Rules {
+			if rule.Type != CELFeedbackRuleType {
+				continue
+			}
+			if _, iss := env.Compile(rule.Expression); iss.Err() != nil {
+				// Trim CEL code snippets out of message
+				return fmt.Errorf("invalid expression of the feedback rule %s: %w", rule.Name,
+					errors.New(strings.Split(iss.String(), "\n | ")[0]))
+			}
+		}
+	}
+	return nil
+}
+
+// FindFeedbackRules returns the rules of the first ManifestFeedbackRules matching the resource.
+func FindFeedbackRules(resourceMeta workapiv1.ManifestResourceMeta, manifestRules []ManifestFeedbackRules) []FeedbackRule {
+	for _, manifestRule := range manifestRules {
+		if ResourceMatch(resourceMeta, manifestRule.ResourceIdentifier) {
+			return manifestRule.Rules
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestGetFeedbackRules(t *testing.T) {
+	cases := []struct {
+		name          string
+		annotations   map[string]string
+		expectedRules []ManifestFeedbackRules
+		expectedErr   bool
+	}{
+		{
+			name: "no feedback rules",
+		},
+		{
+			name: "feedback rules",
+			annotations: map[string]string{FeedbackRulesAnnotationKey: `[{"resourceIdentifier":{"group":"apps",` +
+				`"resource":"deployments","namespace":"default","name":"web"},"rules":[{"type":"CEL","name":"kind",` +
+				`"expression":"object.kind"},{"type":"RelatedPods","name":"pods"}]}]`},
+			expectedRules: []ManifestFeedbackRules{
+				{
+					ResourceIdentifier: workapiv1.ResourceIdentifier{
+						Group: "apps", Resource: "deployments", Namespace: "default", Name: "web",
+					},
+					Rules: []FeedbackRule{
+						{Type: CELFeedbackRuleType, Name: "kind", Expression: "object.kind"},
+						{Type: RelatedPodsFeedbackRuleType, Name: "pods"},
+					},
+				},
+			},
+		},
+		{
+			name:        "invalid json",
+			annotations: map[string]string{FeedbackRulesAnnotationKey: `invalid`},
+			expectedErr: true,
+		},
+		{
+			name: "no resource identifier",
+			annotations: map[string]string{FeedbackRulesAnnotationKey: `[{"rules":[{"type":"RelatedPods",` +
+				`"name":"pods"}]}]`},
+			expectedErr: true,
+		},
+		{
+			name: "unsupported type",
+			annotations: map[string]string{FeedbackRulesAnnotationKey: `[{"resourceIdentifier":{"resource":"pods",` +
+				`"name":"web"},"rules":[{"type":"RelatedServices","name":"services"}]}]`},
+			expectedErr: true,
+		},
+		{
+			name: "CEL rule without expression",
+			annotations: map[string]string{FeedbackRulesAnnotationKey: `[{"resourceIdentifier":{"resource":"pods",` +
+				`"name":"web"},"rules":[{"type":"CEL","name":"kind"}]}]`},
+			expectedErr: true,
+		},
+		{
+			name: "duplicated names",
+			annotations: map[string]string{FeedbackRulesAnnotationKey: `[{"resourceIdentifier":{"resource":"pods",` +
+				`"name":"web"},"rules":[{"type":"RelatedPods","name":"pods"},{"type":"RelatedEvents","name":"pods"}]}]`},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules, err := GetFeedbackRules(c.annotations)
+			if (err != nil) != c.expectedErr {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if !reflect.DeepEqual(rules, c.expectedRules) {
+				t.Errorf("expected rules %v, got %v", c.expectedRules, rules)
+			}
+		})
+	}
+}
+
+func TestFindFeedbackRules(t *testing.T) {
+	manifestRules := []ManifestFeedbackRules{
+		{
+			ResourceIdentifier: workapiv1.ResourceIdentifier{Group: "apps", Resource: "deployments", Namespace: "*", Name: "web"},
+			Rules:              []FeedbackRule{{Type: RelatedPodsFeedbackRuleType, Name: "pods"}},
+		},
+	}
+
+	rules := FindFeedbackRules(workapiv1.ManifestResourceMeta{
+		Group: "apps", Resource: "deployments", Namespace: "default", Name: "web",
+	}, manifestRules)
+	if len(rules) != 1 || rules[0].Name != "pods" {
+		t.Errorf("expected the rules of the deployment, got %v", rules)
+	}
+
+	rules = FindFeedbackRules(workapiv1.ManifestResourceMeta{
+		Group: "apps", Resource: "deployments", Namespace: "default", Name: "api",
+	}, manifestRules)
+	if len(rules) != 0 {
+		t.Errorf("expected no rules, got %v", rules)
+	}
+}
@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+)
+
+// HealthAggregationAnnotationKey is the annotation on the manifestwork to define how the conditions of the
+// manifests are aggregated into the Available, Progressing and Degraded conditions of the manifestwork. The value
+// is the json of HealthAggregation, for example:
+//
+//	{"available": {"type": "Weighted", "weights": [{"resource": "deployments", "name": "monitor", "weight": 0}]}}
+const HealthAggregationAnnotationKey = "work.open-cluster-management.io/health-aggregation"
+
+// AggregationPolicyType is the type of the aggregation policy.
+type AggregationPolicyType string
+
+const (
+	// AggregationPolicyAll requires the condition is true on all the manifests, it is the default policy of the
+	// Available and Progressing conditions.
+	AggregationPolicyAll AggregationPolicyType = "All"
+	// AggregationPolicyAny requires the condition is true on at least one manifest, it is the default policy of
+	// the Degraded condition.
+	AggregationPolicyAny AggregationPolicyType = "Any"
+	// AggregationPolicyPercentage requires the condition is true on at least the threshold percentage of the
+	// manifests.
+	AggregationPolicyPercentage AggregationPolicyType = "Percentage"
+	// AggregationPolicyWeighted requires the total weight of the manifests with the true condition is at least
+	// the threshold percentage of the total weight of the manifests.
+	AggregationPolicyWeighted AggregationPolicyType = "Weighted"
+	// AggregationPolicyCEL requires the CEL expression over the manifests returns true.
+	AggregationPolicyCEL AggregationPolicyType = "CEL"
+)
+
+// HealthAggregation defines the aggregation policies of the conditions of the manifestwork. The Available
+// condition is aggregated with the All policy if the policy is not set, the Progressing and Degraded conditions
+// are only aggregated when the policies are set. The Degraded condition is aggregated with the Any policy if the
+// type of the policy is not set.
+type HealthAggregation struct {
+	Available   *AggregationPolicy `json:"available,omitempty"`
+	Progressing *AggregationPolicy `json:"progressing,omitempty"`
+	Degraded    *AggregationPolicy `json:"degraded,omitempty"`
+}
+
+// AggregationPolicy defines how a condition of the manifests is aggregated.
+type AggregationPolicy struct {
+	// Type is the type of the policy, default is Any for the Degraded condition and All for the other conditions.
+	Type AggregationPolicyType `json:"type,omitempty"`
+	// Threshold is the percentage from 0 to 100 for the Percentage and Weighted policies, default is 100.
+	Threshold *int32 `json:"threshold,omitempty"`
+	// Weights is the weights of the manifests for the Weighted policy, the first matched weight is used and the
+	// weight of the manifest is 1 if no weight is matched. A manifest with weight 0 is optional.
+	Weights []ManifestWeight `json:"weights,omitempty"`
+	// Expression is the CEL expression for the CEL policy, it returns a bool with the variable manifests, which is
+	// the list of the manifests, each has group, version, kind, resource, namespace, name and conditions. The
+	// conditions is a map of the condition type to the condition status.
+	// For example: `manifests.filter(m, m.kind == "Deployment").all(m, m.conditions.Available == "True")`
+	Expression string `json:"expression,omitempty"`
+}
+
+// ManifestWeight is the weight of the manifests matching the resource identifier.
+type ManifestWeight struct {
+	workapiv1.ResourceIdentifier `json:",inline"`
+	Weight                       int32 `json:"weight"`
+}
+
+// GetHealthAggregation returns the health aggregation in the annotations, nil is returned if it is not set.
+func GetHealthAggregation(annotations map[string]string) (*HealthAggregation, error) {
+	value, ok := annotations[HealthAggregationAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	aggregation := &HealthAggregation{}
+	if err := json.Unmarshal([]byte(value), aggregation); err != nil {
+		return nil, fmt.Errorf("failed to decode health aggregation: %w", err)
+	}
+	for _, policy := range []*AggregationPolicy{aggregation.Available, aggregation.Progressing, aggregation.Degraded} {
+		if err := validateAggregationPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+	return aggregation, nil
+}
+
+func validateAggregationPolicy(policy *AggregationPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	switch policy.Type {
+	case "", AggregationPolicyAll, AggregationPolicyAny:
+	case AggregationPolicyPercentage, AggregationPolicyWeighted:
+		if policy.Threshold != nil && (*policy.Threshold < 0 || *policy.Threshold > 100) {
+			return fmt.Errorf("the threshold %d of the %s policy is not in 0-100", *policy.Threshold, policy.Type)
+		}
+	case AggregationPolicyCEL:
+		if len(policy.Expression) == 0 {
+			return fmt.Errorf("the expression of the CEL policy is empty")
+		}
+	default:
+		return fmt.Errorf("unsupported aggregation policy type %q", policy.Type)
+	}
+	return nil
+}
+
+// NewHealthAggregationEnv returns the CEL environment of the expressions of the CEL policies, the manifests are
+// accessed with the variable manifests.
+func NewHealthAggregationEnv() (*cel.Env, error) {
+	return cel.NewEnv(append(
+		[]cel.EnvOption{cel.Variable("manifests", cel.ListType(cel.DynType))},
+		ocmcelcommon.BaseEnvOpts...,
+	)...)
+}
+
+// ValidateHealthAggregation validates the health aggregation in the annotations, the expressions of the CEL
+// policies must compile.
+func ValidateHealthAggregation(annotations map[string]string) error {
+	aggregation, err := GetHealthAggregation(annotations)
+	if err != nil || aggregation == nil {
+		return err
+	}
+	env, err := NewHealthAggregationEnv()
+	if err != nil {
+		return err
+	}
+	for _, policy := range []*AggregationPolicy{aggregation.Available, aggregation.Progressing, aggregation.Degraded} {
+		if policy == nil || policy.Type != AggregationPolicyCEL {
+			continue
+		}
+		if _, iss := env.Compile(policy.Expression); iss.Err() != nil {
+			return fmt.Errorf("invalid expression of the CEL policy: %w", iss.Err())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package helper
+
+import "testing"
+
+func TestGetHealthAggregation(t *testing.T) {
+	cases := []struct {
+		name        string
+		value       string
+		expectedErr bool
+	}{
+		{name: "valid", value: `{"available":{"type":"Any"},"degraded":{"type":"Percentage","threshold":50}}`},
+		{name: "invalid json", value: `{`, expectedErr: true},
+		{name: "invalid type", value: `{"available":{"type":"None"}}`, expectedErr: true},
+		{name: "invalid threshold", value: `{"available":{"type":"Percentage","threshold":101}}`, expectedErr: true},
+		{name: "empty expression", value: `{"available":{"type":"CEL"}}`, expectedErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := GetHealthAggregation(map[string]string{HealthAggregationAnnotationKey: c.value})
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateHealthAggregation(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{name: "no health aggregation"},
+		{
+			name: "valid",
+			annotations: map[string]string{
+				HealthAggregationAnnotationKey: `{"available":{"type":"CEL","expression":"manifests.all(m, m.conditions.Available == 'True')"}}`,
+			},
+		},
+		{
+			name:        "invalid threshold",
+			annotations: map[string]string{HealthAggregationAnnotationKey: `{"available":{"type":"Weighted","threshold":-1}}`},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid expression",
+			annotations: map[string]string{HealthAggregationAnnotationKey: `{"available":{"type":"CEL","expression":"manifests.all("}}`},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateHealthAggregation(c.annotations)
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -29,15 +30,23 @@ import (
 	"k8s.io/klog/v2"
 
 	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	clustersdkv1beta1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta1"
+
+	commonhelper "open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 const (
 	// unknownKind is returned by resourcehelper.GuessObjectGroupVersionKind() when it
 	// cannot tell the kind of the given object
 	unknownKind = "<unknown>"
+
+	// WorkComplete represents that all the manifests with the Complete condition rules in the manifestwork
+	// have completed. A complete manifestwork is deleted by the hub once its TTLSecondsAfterFinished elapses.
+	WorkComplete = "Complete"
 )
 
 var (
@@ -329,6 +338,40 @@ func AppliedManifestworkHubHashFilter(hubHash string) factory.EventFilterFunc {
 	}
 }
 
+// ClusterFreezeQueueKeysFunc returns the names of all the manifestworks in the lister as the queue keys when the
+// freeze state of the managed cluster changes, so the held manifestworks are reconciled once the cluster is
+// unfrozen.
+func ClusterFreezeQueueKeysFunc(lister worklister.ManifestWorkNamespaceLister) factory.ObjectQueueKeysFunc {
+	var lock sync.Mutex
+	frozen := map[string]bool{}
+	return func(obj runtime.Object) []string {
+		cluster, ok := obj.(*clusterv1.ManagedCluster)
+		if !ok {
+			return nil
+		}
+
+		lock.Lock()
+		lastFrozen, seen := frozen[cluster.Name]
+		frozen[cluster.Name] = commonhelper.IsClusterFrozen(cluster)
+		changed := !seen || lastFrozen != frozen[cluster.Name]
+		lock.Unlock()
+		if !changed {
+			return nil
+		}
+
+		works, err := lister.List(labels.Everything())
+		if err != nil {
+			klog.Errorf("failed to list manifestworks: %v", err)
+			return nil
+		}
+		var keys []string
+		for _, work := range works {
+			keys = append(keys, work.Name)
+		}
+		return keys
+	}
+}
+
 // HubHash returns a hash of hubserver
 // NOTE: the length of hash string is 64, meaning the length of manifestwork name should be less than 189
 func HubHash(hubServer string) string {
@@ -354,7 +397,9 @@ func NewAppliedManifestWorkOwner(appliedWork *workapiv1.AppliedManifestWork) *me
 	}
 }
 
-func resourceMatch(resourceMeta workapiv1.ManifestResourceMeta, resource workapiv1.ResourceIdentifier) bool {
+// ResourceMatch checks if the resource meta matches the resource identifier, the wildcard is supported in the
+// namespace and name of the identifier.
+func ResourceMatch(resourceMeta workapiv1.ManifestResourceMeta, resource workapiv1.ResourceIdentifier) bool {
 	return resourceMeta.Group == resource.Group &&
 		resourceMeta.Resource == resource.Resource &&
 		wildcardMatch(resourceMeta.Namespace, resource.Namespace) &&
@@ -375,7 +420,7 @@ func FindManifestConfiguration(resourceMeta workapiv1.ManifestResourceMeta,
 
 	for i := 0; i < len(manifestOptions); i++ {
 		option := manifestOptions[i]
-		if !resourceMatch(resourceMeta, option.ResourceIdentifier) {
+		if !ResourceMatch(resourceMeta, option.ResourceIdentifier) {
 			continue
 		}
 
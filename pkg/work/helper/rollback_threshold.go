@@ -0,0 +1,28 @@
+package helper
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ManifestWorkReplicaSetRollbackThresholdAnnotationKey enables the automated rollback of the
+// manifestworkreplicaset. The value is the number or the percentage of the selected clusters, when the
+// clusters that Failed or TimeOut with the current template reach the threshold, the manifestworks are
+// reverted to the last template which was successfully rolled out to all the clusters.
+const ManifestWorkReplicaSetRollbackThresholdAnnotationKey = "work.open-cluster-management.io/rollback-failure-threshold"
+
+// ValidateRollbackThreshold validates the rollback failure threshold in the annotations, it must be a non-negative
+// number or a percentage.
+func ValidateRollbackThreshold(annotations map[string]string) error {
+	value, ok := annotations[ManifestWorkReplicaSetRollbackThresholdAnnotationKey]
+	if !ok {
+		return nil
+	}
+	threshold := intstr.Parse(value)
+	failureThreshold, err := intstr.GetScaledValueFromIntOrPercent(&threshold, 100, true)
+	if err != nil || failureThreshold < 0 {
+		return fmt.Errorf("invalid rollback failure threshold %q, it must be a non-negative number or a percentage", value)
+	}
+	return nil
+}
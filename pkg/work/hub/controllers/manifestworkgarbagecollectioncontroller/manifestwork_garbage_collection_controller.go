@@ -0,0 +1,185 @@
+package manifestworkgarbagecollectioncontroller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	workinformerv1alpha1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1alpha1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	worklisterv1alpha1 "open-cluster-management.io/api/client/work/listers/work/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/queue"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+// recordWaitInterval is the interval to recheck a completed manifestwork of a ManifestWorkReplicaSet until
+// the completion is recorded on the ManifestWorkReplicaSet, so it is not recreated after the deletion.
+const recordWaitInterval = 5 * time.Second
+
+// manifestWorkGarbageCollectionController deletes the manifestworks whose TTLSecondsAfterFinished has elapsed
+// since they were marked Complete by the work agent.
+type manifestWorkGarbageCollectionController struct {
+	workClient         workclientset.Interface
+	manifestWorkLister worklisterv1.ManifestWorkLister
+	replicaSetLister   worklisterv1alpha1.ManifestWorkReplicaSetLister
+	recorder           events.Recorder
+}
+
+// NewManifestWorkGarbageCollectionController returns a controller deleting the finished manifestworks. The
+// manifestWorkInformer must not be shared with other controllers, since only the fields needed by the garbage
+// collection are kept in its cache. It must be called before the informers are started.
+func NewManifestWorkGarbageCollectionController(
+	recorder events.Recorder,
+	workClient workclientset.Interface,
+	manifestWorkInformer workinformerv1.ManifestWorkInformer,
+	replicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
+) (factory.Controller, error) {
+	if err := manifestWorkInformer.Informer().SetTransform(trimManifestWork); err != nil {
+		return nil, err
+	}
+
+	c := &manifestWorkGarbageCollectionController{
+		workClient:         workClient,
+		manifestWorkLister: manifestWorkInformer.Lister(),
+		replicaSetLister:   replicaSetInformer.Lister(),
+		recorder:           recorder,
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeysFunc(
+			queue.QueueKeyByMetaNamespaceName,
+			func(obj interface{}) bool {
+				work, ok := obj.(*workapiv1.ManifestWork)
+				return ok && ttlSecondsAfterFinished(work) != nil
+			},
+			manifestWorkInformer.Informer()).
+		WithBareInformers(replicaSetInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ManifestWorkGarbageCollectionController", recorder), nil
+}
+
+func (c *manifestWorkGarbageCollectionController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+	key := controllerContext.QueueKey()
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		// ignore bad format key
+		return nil
+	}
+
+	work, err := c.manifestWorkLister.ManifestWorks(namespace).Get(name)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	ttl := ttlSecondsAfterFinished(work)
+	if ttl == nil || !work.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	completeCondition := meta.FindStatusCondition(work.Status.Conditions, helper.WorkComplete)
+	if completeCondition == nil || completeCondition.Status != metav1.ConditionTrue {
+		return nil
+	}
+
+	expireAt := completeCondition.LastTransitionTime.Add(time.Duration(*ttl) * time.Second)
+	if remaining := time.Until(expireAt); remaining > 0 {
+		controllerContext.Queue().AddAfter(key, remaining)
+		return nil
+	}
+
+	recorded, err := c.completionRecorded(work)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		klog.V(4).Infof("Waiting for the completion of manifestwork %s to be recorded by its ManifestWorkReplicaSet", key)
+		controllerContext.Queue().AddAfter(key, recordWaitInterval)
+		return nil
+	}
+
+	err = c.workClient.WorkV1().ManifestWorks(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &work.UID},
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.recorder.Eventf("ManifestWorkDeleted",
+		"ManifestWork %s is deleted %d seconds after it completed", key, *ttl)
+	return nil
+}
+
+// completionRecorded returns true if the manifestwork is not created by a ManifestWorkReplicaSet, or its
+// completion is recorded on the ManifestWorkReplicaSet.
+func (c *manifestWorkGarbageCollectionController) completionRecorded(work *workapiv1.ManifestWork) (bool, error) {
+	replicaSetKey, ok := work.Labels[manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey]
+	if !ok {
+		return true, nil
+	}
+	keys := strings.SplitN(replicaSetKey, ".", 2)
+	if len(keys) != 2 {
+		return true, nil
+	}
+
+	replicaSet, err := c.replicaSetLister.ManifestWorkReplicaSets(keys[0]).Get(keys[1])
+	switch {
+	case apierrors.IsNotFound(err):
+		return true, nil
+	case err != nil:
+		return false, err
+	}
+	return manifestworkreplicasetcontroller.IsClusterCompleted(replicaSet, work.Namespace), nil
+}
+
+func ttlSecondsAfterFinished(work *workapiv1.ManifestWork) *int64 {
+	if work.Spec.DeleteOption == nil {
+		return nil
+	}
+	return work.Spec.DeleteOption.TTLSecondsAfterFinished
+}
+
+// trimManifestWork only keeps the fields of the manifestwork needed by the garbage collection, so the
+// manifests and resource status of all the manifestworks are not cached.
+func trimManifestWork(obj interface{}) (interface{}, error) {
+	work, ok := obj.(*workapiv1.ManifestWork)
+	if !ok {
+		return obj, nil
+	}
+	return &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              work.Name,
+			Namespace:         work.Namespace,
+			UID:               work.UID,
+			ResourceVersion:   work.ResourceVersion,
+			Generation:        work.Generation,
+			Labels:            work.Labels,
+			CreationTimestamp: work.CreationTimestamp,
+			DeletionTimestamp: work.DeletionTimestamp,
+		},
+		Spec: workapiv1.ManifestWorkSpec{
+			DeleteOption: work.Spec.DeleteOption,
+		},
+		Status: workapiv1.ManifestWorkStatus{
+			Conditions: work.Status.Conditions,
+		},
+	}, nil
+}
@@ -0,0 +1,190 @@
+package manifestworkgarbagecollectioncontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+func newManifestWork(ttl *int64, completedAt *time.Time, replicaSet string) *workapiv1.ManifestWork {
+	work := &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "work1",
+			Namespace: "cluster1",
+			UID:       "uid1",
+		},
+	}
+	if ttl != nil {
+		work.Spec.DeleteOption = &workapiv1.DeleteOption{
+			PropagationPolicy:       workapiv1.DeletePropagationPolicyTypeForeground,
+			TTLSecondsAfterFinished: ttl,
+		}
+	}
+	if completedAt != nil {
+		work.Status.Conditions = []metav1.Condition{
+			{
+				Type:               helper.WorkComplete,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(*completedAt),
+			},
+		}
+	}
+	if len(replicaSet) > 0 {
+		work.Labels = map[string]string{
+			manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey: "default." + replicaSet,
+		}
+	}
+	return work
+}
+
+func newReplicaSet(name string, completedClusters string) *workapiv1alpha1.ManifestWorkReplicaSet {
+	replicaSet := &workapiv1alpha1.ManifestWorkReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+	if len(completedClusters) > 0 {
+		replicaSet.Annotations = map[string]string{
+			manifestworkreplicasetcontroller.CompletedClustersAnnotationKey: completedClusters,
+		}
+	}
+	return replicaSet
+}
+
+func TestSync(t *testing.T) {
+	longAgo := time.Now().Add(-time.Hour)
+	justNow := time.Now()
+
+	cases := []struct {
+		name            string
+		work            *workapiv1.ManifestWork
+		replicaSet      *workapiv1alpha1.ManifestWorkReplicaSet
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name: "no ttl",
+			work: newManifestWork(nil, &longAgo, ""),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "not complete",
+			work: newManifestWork(ptr.To[int64](0), nil, ""),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "ttl not elapsed",
+			work: newManifestWork(ptr.To[int64](600), &justNow, ""),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "ttl elapsed",
+			work: newManifestWork(ptr.To[int64](600), &longAgo, ""),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+				deleteAction := actions[0].(clienttesting.DeleteActionImpl)
+				if deleteAction.Namespace != "cluster1" || deleteAction.Name != "work1" {
+					t.Errorf("unexpected deleted manifestwork %s/%s", deleteAction.Namespace, deleteAction.Name)
+				}
+				if uid := deleteAction.DeleteOptions.Preconditions.UID; uid == nil || *uid != "uid1" {
+					t.Errorf("expected the uid precondition")
+				}
+			},
+		},
+		{
+			name:       "completion not recorded by the manifestworkreplicaset",
+			work:       newManifestWork(ptr.To[int64](0), &longAgo, "mwrs1"),
+			replicaSet: newReplicaSet("mwrs1", ""),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:       "completion recorded by the manifestworkreplicaset",
+			work:       newManifestWork(ptr.To[int64](0), &longAgo, "mwrs1"),
+			replicaSet: newReplicaSet("mwrs1", `{"templateHash":"abc","clusters":["cluster1"]}`),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+		},
+		{
+			name: "manifestworkreplicaset is deleted",
+			work: newManifestWork(ptr.To[int64](0), &longAgo, "mwrs1"),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objects := []runtime.Object{c.work}
+			if c.replicaSet != nil {
+				objects = append(objects, c.replicaSet)
+			}
+			workClient := fakeworkclient.NewSimpleClientset(objects...)
+			informerFactory := workinformers.NewSharedInformerFactory(workClient, 10*time.Minute)
+			if err := informerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(c.work); err != nil {
+				t.Fatal(err)
+			}
+			if c.replicaSet != nil {
+				if err := informerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer().GetStore().Add(c.replicaSet); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := &manifestWorkGarbageCollectionController{
+				workClient:         workClient,
+				manifestWorkLister: informerFactory.Work().V1().ManifestWorks().Lister(),
+				replicaSetLister:   informerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+				recorder:           eventstesting.NewTestingEventRecorder(t),
+			}
+
+			workClient.ClearActions()
+			syncContext := testingcommon.NewFakeSyncContext(t, "cluster1/work1")
+			if err := controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			c.validateActions(t, workClient.Actions())
+		})
+	}
+}
+
+func TestTrimManifestWork(t *testing.T) {
+	work := newManifestWork(ptr.To[int64](10), nil, "mwrs1")
+	work.Spec.Workload.Manifests = []workapiv1.Manifest{{}}
+	work.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{{}}
+
+	obj, err := trimManifestWork(work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trimmed := obj.(*workapiv1.ManifestWork)
+	if len(trimmed.Spec.Workload.Manifests) != 0 || len(trimmed.Status.ResourceStatus.Manifests) != 0 {
+		t.Errorf("expected the manifests are trimmed")
+	}
+	if *trimmed.Spec.DeleteOption.TTLSecondsAfterFinished != 10 || len(trimmed.Labels) != 1 {
+		t.Errorf("expected the ttl and labels are kept")
+	}
+}
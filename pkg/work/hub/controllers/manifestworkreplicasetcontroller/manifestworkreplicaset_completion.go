@@ -0,0 +1,122 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+
+	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+// CompletedClustersAnnotationKey records the clusters where the manifestwork of the current template has
+// completed. The manifestworks on these clusters are not recreated after they are deleted by the garbage
+// collector once their TTLSecondsAfterFinished elapses. The record is reset when the template is changed.
+const CompletedClustersAnnotationKey = "work.open-cluster-management.io/completed-clusters"
+
+// maxCompletedClusters bounds the number of clusters in the CompletedClustersAnnotationKey record. The completed
+// manifestworks on the clusters beyond the bound are not recorded, so they are kept by the garbage collector until
+// the record has room for them.
+const maxCompletedClusters = 1000
+
+// completedClusters is the value of the CompletedClustersAnnotationKey annotation.
+type completedClusters struct {
+	TemplateHash string   `json:"templateHash"`
+	Clusters     []string `json:"clusters"`
+}
+
+func getCompletedClusters(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) completedClusters {
+	record := completedClusters{}
+	if value, ok := mwrSet.Annotations[CompletedClustersAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			// the manifestworks of a broken record are recreated and complete again.
+			return completedClusters{}
+		}
+	}
+	return record
+}
+
+// IsClusterCompleted returns true if the manifestwork of the manifestworkreplicaset has completed on the
+// cluster and is recorded, so it can be deleted without being recreated.
+func IsClusterCompleted(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusterName string) bool {
+	return sets.New(getCompletedClusters(mwrSet).Clusters...).Has(clusterName)
+}
+
+// completedClusterRolloutStatus returns the rollout status of a cluster whose completed manifestwork has
+// been deleted, the cluster is treated as succeeded so the manifestwork is not rolled out again.
+func completedClusterRolloutStatus(clusterName string) clustersdkv1alpha1.ClusterRolloutStatus {
+	return clustersdkv1alpha1.ClusterRolloutStatus{
+		ClusterName:        clusterName,
+		Status:             clustersdkv1alpha1.Succeeded,
+		LastTransitionTime: &metav1.Time{},
+	}
+}
+
+// placementDecisionClusters returns the clusters currently decided by the placement.
+func placementDecisionClusters(lister clusterlister.PlacementDecisionLister,
+	placement *clusterv1beta1.Placement) (sets.Set[string], error) {
+	tracker := helper.GetPlacementTracker(lister, placement, sets.New[string]())
+	if err := tracker.Refresh(); err != nil {
+		return nil, err
+	}
+	return tracker.ExistingClusterGroupsBesides().GetClusters(), nil
+}
+
+func isManifestWorkComplete(mw *workv1.ManifestWork) bool {
+	return apimeta.IsStatusConditionTrue(mw.Status.Conditions, helper.WorkComplete)
+}
+
+// boundCompletedClusters drops the completed clusters no longer decided by the placements, the decided clusters
+// are unknown if it is nil, and keeps at most maxCompletedClusters clusters. The recorded clusters are kept first
+// since their manifestworks might have been deleted already.
+func boundCompletedClusters(recorded []string, clusters, decided sets.Set[string]) sets.Set[string] {
+	if decided != nil {
+		clusters = clusters.Intersection(decided)
+	}
+	bounded := sets.New[string]()
+	for _, clusterName := range append(append([]string{}, recorded...), sets.List(clusters)...) {
+		if bounded.Len() >= maxCompletedClusters {
+			break
+		}
+		if clusters.Has(clusterName) {
+			bounded.Insert(clusterName)
+		}
+	}
+	return bounded
+}
+
+// recordCompletedClusters updates the CompletedClustersAnnotationKey annotation if the completed clusters
+// of the template are changed.
+func (d *deployReconciler) recordCompletedClusters(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	hash string, clusters, decided sets.Set[string]) error {
+	record := getCompletedClusters(mwrSet)
+	var recorded []string
+	if record.TemplateHash == hash {
+		recorded = record.Clusters
+	}
+	clusters = boundCompletedClusters(recorded, clusters, decided)
+	if record.TemplateHash == hash && sets.New(record.Clusters...).Equal(clusters) {
+		return nil
+	}
+	// nothing to reset
+	if len(record.TemplateHash) == 0 && clusters.Len() == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(completedClusters{TemplateHash: hash, Clusters: sets.List(clusters)})
+	if err != nil {
+		return err
+	}
+	return patchRevisionAnnotations(ctx, d.workClient, mwrSet, map[string]*string{
+		CompletedClustersAnnotationKey: ptr.To(string(data)),
+	})
+}
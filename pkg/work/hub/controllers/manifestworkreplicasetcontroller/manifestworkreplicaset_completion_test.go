@@ -0,0 +1,136 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestCompletedManifestWorkNotRecreated(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	template := mwrSet.Spec.ManifestWorkTemplate
+
+	// the manifestwork on cls1 completes and is recorded.
+	completedWork := newRolloutManifestWork(t, mwrSet, "cls1", template, metav1.ConditionTrue)
+	apimeta.SetStatusCondition(&completedWork.Status.Conditions, metav1.Condition{Type: helper.WorkComplete, Status: metav1.ConditionTrue})
+	works := []runtime.Object{
+		completedWork,
+		newRolloutManifestWork(t, mwrSet, "cls2", template, metav1.ConditionTrue),
+	}
+	deploy, _ := newRollbackDeployReconciler(t, kubefake.NewSimpleClientset(), mwrSet, works, "cls1", "cls2")
+	mwrSet, _, err := deploy.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsClusterCompleted(mwrSet, "cls1") || IsClusterCompleted(mwrSet, "cls2") {
+		t.Fatalf("expected only cls1 is completed, got %v", mwrSet.Annotations)
+	}
+
+	// the completed manifestwork is deleted by the garbage collector and is not recreated.
+	works = []runtime.Object{newRolloutManifestWork(t, mwrSet, "cls2", template, metav1.ConditionTrue)}
+	deploy, fWorkClient := newRollbackDeployReconciler(t, kubefake.NewSimpleClientset(), mwrSet, works, "cls1", "cls2")
+	mwrSet, _, err = deploy.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range fWorkClient.Actions() {
+		if action.Matches("create", "manifestworks") {
+			t.Errorf("expected the completed manifestwork is not recreated, got %v",
+				action.(clienttesting.CreateAction).GetObject())
+		}
+	}
+	if !IsClusterCompleted(mwrSet, "cls1") {
+		t.Errorf("expected cls1 is still completed, got %v", mwrSet.Annotations)
+	}
+
+	// the completed cluster is not counted and is removed from the record once it is no longer decided by
+	// the placement.
+	deploy, fWorkClient = newRollbackDeployReconciler(t, kubefake.NewSimpleClientset(), mwrSet, works, "cls2")
+	undecided, _, err := deploy.reconcile(context.TODO(), mwrSet.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range fWorkClient.Actions() {
+		if action.GetNamespace() == "cls1" {
+			t.Errorf("expected cls1 is not counted in the rollout, got %v", action)
+		}
+	}
+	if IsClusterCompleted(undecided, "cls1") {
+		t.Errorf("expected cls1 is removed from the completed clusters, got %v", undecided.Annotations)
+	}
+
+	// the manifestwork is created again once the template is changed.
+	mwrSet.Spec.ManifestWorkTemplate = helpertest.CreateTestManifestWorkSpecWithSecret("v1", "Secret", "test", "v2")
+	deploy, fWorkClient = newRollbackDeployReconciler(t, kubefake.NewSimpleClientset(), mwrSet, works, "cls1", "cls2")
+	mwrSet, _, err = deploy.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := false
+	for _, action := range fWorkClient.Actions() {
+		if action.Matches("create", "manifestworks") && action.GetNamespace() == "cls1" {
+			created = true
+		}
+	}
+	if !created {
+		t.Errorf("expected the manifestwork on cls1 is created for the new template")
+	}
+	if IsClusterCompleted(mwrSet, "cls1") {
+		t.Errorf("expected the completed clusters are reset, got %v", mwrSet.Annotations)
+	}
+}
+
+func TestBoundCompletedClusters(t *testing.T) {
+	var many []string
+	for i := 0; i < maxCompletedClusters+10; i++ {
+		many = append(many, fmt.Sprintf("cls%04d", i))
+	}
+
+	cases := []struct {
+		name     string
+		recorded []string
+		clusters sets.Set[string]
+		decided  sets.Set[string]
+		expected sets.Set[string]
+	}{
+		{
+			name:     "decided clusters unknown",
+			clusters: sets.New("cls1", "cls2"),
+			expected: sets.New("cls1", "cls2"),
+		},
+		{
+			name:     "undecided clusters dropped",
+			recorded: []string{"cls1"},
+			clusters: sets.New("cls1", "cls2"),
+			decided:  sets.New("cls2"),
+			expected: sets.New("cls2"),
+		},
+		{
+			name:     "recorded clusters kept first",
+			recorded: many[10:],
+			clusters: sets.New(many...),
+			expected: sets.New(many[10:]...),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := boundCompletedClusters(c.recorded, c.clusters, c.decided)
+			if !actual.Equal(c.expected) {
+				t.Errorf("expected %d clusters %v, but got %d clusters %v",
+					c.expected.Len(), sets.List(c.expected.Difference(actual)), actual.Len(),
+					sets.List(actual.Difference(c.expected)))
+			}
+		})
+	}
+}
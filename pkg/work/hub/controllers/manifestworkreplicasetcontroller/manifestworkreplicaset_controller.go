@@ -16,9 +16,11 @@ import (
 	"k8s.io/apimachinery/pkg/selection"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterinformerv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
@@ -30,6 +32,7 @@ import (
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	"open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/common/queue"
 )
@@ -48,6 +51,17 @@ const (
 	// related manifestworks is deleted
 	ManifestWorkReplicaSetFinalizer = "work.open-cluster-management.io/manifest-work-cleanup"
 
+	// ReasonWaitingForMaintenanceWindow is the reason of the PlacementRolledOut condition when the rollout is
+	// held by the maintenance windows of the clusters.
+	ReasonWaitingForMaintenanceWindow = "WaitingForMaintenanceWindow"
+
+	// ReasonClustersFrozen is the reason of the PlacementRolledOut condition when the rollout is held by the freeze
+	// of the clusters.
+	ReasonClustersFrozen = "ClustersFrozen"
+
+	// frozenRecheckTime is the interval to recheck the clusters held by the freeze.
+	frozenRecheckTime = time.Minute
+
 	// maxRequeueTime is the same as the informer resync period
 	maxRequeueTime = 30 * time.Minute
 )
@@ -75,20 +89,26 @@ const (
 
 func NewManifestWorkReplicaSetController(
 	recorder events.Recorder,
+	kubeClient kubernetes.Interface,
 	workClient workclientset.Interface,
 	workApplier *workapplier.WorkApplier,
 	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
 	manifestWorkInformer workinformerv1.ManifestWorkInformer,
 	placementInformer clusterinformerv1beta1.PlacementInformer,
 	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	encryptor *encryption.ManifestEncryptor,
 ) factory.Controller {
 	controller := newController(
+		kubeClient,
 		workClient,
 		workApplier,
 		manifestWorkReplicaSetInformer,
 		manifestWorkInformer,
 		placementInformer,
 		placeDecisionInformer,
+		clusterInformer,
+		encryptor,
 	)
 
 	err := manifestWorkReplicaSetInformer.Informer().AddIndexers(
@@ -121,13 +141,20 @@ func NewManifestWorkReplicaSetController(
 }
 
 func newController(
+	kubeClient kubernetes.Interface,
 	workClient workclientset.Interface,
 	workApplier *workapplier.WorkApplier,
 	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
 	manifestWorkInformer workinformerv1.ManifestWorkInformer,
 	placementInformer clusterinformerv1beta1.PlacementInformer,
 	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	encryptor *encryption.ManifestEncryptor,
 ) *ManifestWorkReplicaSetController {
+	gateEvaluator, err := helpers.NewRolloutGateEvaluator()
+	utilruntime.Must(err)
+	freezeFilter := helpers.NewClusterFreezeFilter(clusterInformer.Lister())
+
 	return &ManifestWorkReplicaSetController{
 		workClient:                    workClient,
 		manifestWorkReplicaSetLister:  manifestWorkReplicaSetInformer.Lister(),
@@ -138,15 +165,26 @@ func newController(
 				workApplier:        workApplier,
 				workClient:         workClient,
 				manifestWorkLister: manifestWorkInformer.Lister(),
+				freezeFilter:       freezeFilter,
 			},
 			&addFinalizerReconciler{
 				workClient: workClient,
 			},
+			&rollbackReconciler{
+				kubeClient: kubeClient,
+				workClient: workClient,
+			},
 			&deployReconciler{
+				kubeClient:          kubeClient,
+				workClient:          workClient,
 				workApplier:         workApplier,
 				manifestWorkLister:  manifestWorkInformer.Lister(),
 				placementLister:     placementInformer.Lister(),
 				placeDecisionLister: placeDecisionInformer.Lister(),
+				gateEvaluator:       gateEvaluator,
+				windowFilter:        helpers.NewMaintenanceWindowFilter(clusterInformer.Lister()),
+				freezeFilter:        freezeFilter,
+				encryptor:           encryptor,
 			},
 			&statusReconciler{manifestWorkLister: manifestWorkInformer.Lister()},
 		},
@@ -10,6 +10,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 
 	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
@@ -237,12 +238,15 @@ func TestManifestWorkReplicaSetControllerPatchStatus(t *testing.T) {
 			}
 
 			ctrl := newController(
+				kubefake.NewSimpleClientset(),
 				fakeClient,
 				workapplier.NewWorkApplierWithTypedClient(fakeClient, workInformers.Work().V1().ManifestWorks().Lister()),
 				workInformers.Work().V1alpha1().ManifestWorkReplicaSets(),
 				workInformers.Work().V1().ManifestWorks(),
 				clusterInformers.Cluster().V1beta1().Placements(),
 				clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+				clusterInformers.Cluster().V1().ManagedClusters(),
+				nil,
 			)
 
 			controllerContext := testingcommon.NewFakeSyncContext(t, c.mwrSet.Namespace+"/"+c.mwrSet.Name)
@@ -3,30 +3,48 @@ package manifestworkreplicasetcontroller
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
 
 	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
 	workv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	"open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/work/helper"
 )
 
 // deployReconciler is to manage ManifestWork based on the placement.
+// templateAppliedTimeAnnotationKey records on the manifestwork the time the template of the manifestworkreplicaset
+// was applied, the soak period of the rollout gate starts after it.
+const templateAppliedTimeAnnotationKey = "work.open-cluster-management.io/template-applied-time"
+
 type deployReconciler struct {
+	kubeClient          kubernetes.Interface
+	workClient          workclientset.Interface
 	workApplier         *workapplier.WorkApplier
 	manifestWorkLister  worklisterv1.ManifestWorkLister
 	placeDecisionLister clusterlister.PlacementDecisionLister
 	placementLister     clusterlister.PlacementLister
+	gateEvaluator       *helpers.RolloutGateEvaluator
+	windowFilter        *helpers.MaintenanceWindowFilter
+	freezeFilter        *helpers.ClusterFreezeFilter
+	// encryptor encrypts the manifests before the manifestworks are applied, so the encrypted manifests are
+	// reused when the manifestworks are not changed and the manifestworks published with the cloudevents
+	// drivers are encrypted as well.
+	encryptor *encryption.ManifestEncryptor
 }
 
 func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
@@ -36,6 +54,40 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	var plcsSummary []workapiv1alpha1.PlacementSummary
 	minRequeue := maxRequeueTime
 	count, total := 0, 0
+	// failedClusters and succeeded are the clusters failed or succeeded with the current template.
+	failedClusters := sets.New[string]()
+	succeeded := 0
+	// waitingClusters are the clusters to apply held until their maintenance windows open.
+	var waitingClusters []string
+	// frozenClusters are the clusters to apply or delete held by the freeze of the clusters.
+	var frozenClusters []string
+
+	gate, err := helpers.GetRolloutGate(mwrSet.Annotations)
+	if err != nil {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementRollOut(workapiv1alpha1.ReasonNotAsExpected, err.Error()))
+		return mwrSet, reconcileContinue, err
+	}
+
+	// the clusters where the manifestwork of the template has completed, the manifestworks deleted after
+	// completion are not recreated.
+	hash, err := templateHash(mwrSet.Spec.ManifestWorkTemplate)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	completedClusters := sets.New[string]()
+	if record := getCompletedClusters(mwrSet); record.TemplateHash == hash {
+		completedClusters.Insert(record.Clusters...)
+	}
+	allManifestWorks, err := listManifestWorksByManifestWorkReplicaSet(mwrSet, d.manifestWorkLister)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	clustersWithManifestWork := sets.New[string]()
+	for _, mw := range allManifestWorks {
+		clustersWithManifestWork.Insert(mw.Namespace)
+	}
+	// the clusters decided by all the placements, it is nil if the decisions of a placement are unknown.
+	allDecidedClusters := sets.New[string]()
 	// Getting the placements and the created ManifestWorks related to each placement
 	for _, placementRef := range mwrSet.Spec.PlacementRefs {
 		var existingRolloutClsStatus []clustersdkv1alpha1.ClusterRolloutStatus
@@ -68,13 +120,46 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			}
 
 			existingClusterNames.Insert(mw.Namespace)
+			if isManifestWorkComplete(mw) {
+				completedClusters.Insert(mw.Namespace)
+			}
 			rolloutClusterStatus, err := d.clusterRolloutStatusFunc(mw.Namespace, *mw)
 
 			if err != nil {
 				errs = append(errs, err)
 				continue
 			}
+			if gate != nil {
+				var recheckAfter time.Duration
+				rolloutClusterStatus, recheckAfter = d.evaluateRolloutGate(ctx, gate, rolloutClusterStatus, mw)
+				if recheckAfter > 0 && recheckAfter < minRequeue {
+					minRequeue = recheckAfter
+				}
+			}
 			existingRolloutClsStatus = append(existingRolloutClsStatus, rolloutClusterStatus)
+			switch rolloutClusterStatus.Status {
+			case clustersdkv1alpha1.Failed:
+				failedClusters.Insert(mw.Namespace)
+			case clustersdkv1alpha1.Succeeded:
+				succeeded++
+			}
+		}
+
+		// only the completed clusters still decided by this placement are counted for it.
+		decidedClusters, err := placementDecisionClusters(d.placeDecisionLister, placement)
+		if err != nil {
+			allDecidedClusters = nil
+			errs = append(errs, err)
+			continue
+		}
+		if allDecidedClusters != nil {
+			allDecidedClusters = allDecidedClusters.Union(decidedClusters)
+		}
+		completedWithoutManifestWork := completedClusters.Intersection(decidedClusters).Difference(clustersWithManifestWork)
+		for _, clusterName := range sets.List(completedWithoutManifestWork) {
+			existingClusterNames.Insert(clusterName)
+			existingRolloutClsStatus = append(existingRolloutClsStatus, completedClusterRolloutStatus(clusterName))
+			succeeded++
 		}
 
 		placeTracker := helper.GetPlacementTracker(d.placeDecisionLister, placement, existingClusterNames)
@@ -91,7 +176,16 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			continue
 		}
 
-		_, rolloutResult, err := rolloutHandler.GetRolloutCluster(placementRef.RolloutStrategy, existingRolloutClsStatus)
+		// the clusters outside their maintenance windows are held before the clusters to rollout are selected, so
+		// they do not take the places of the other clusters in the rollout.
+		waiting, windowRecheck := d.windowFilter.Hold(decidedClusters, existingRolloutClsStatus)
+		waitingClusters = append(waitingClusters, waiting...)
+		if windowRecheck > 0 && windowRecheck < minRequeue {
+			minRequeue = windowRecheck
+		}
+
+		_, rolloutResult, err := rolloutHandler.GetRolloutCluster(placementRef.RolloutStrategy,
+			helpers.SkipClusters(existingRolloutClsStatus, waiting))
 
 		if err != nil {
 			errs = append(errs, err)
@@ -100,10 +194,20 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			continue
 		}
 
+		for _, cls := range rolloutResult.ClustersTimeOut {
+			failedClusters.Insert(cls.ClusterName)
+		}
+
 		if rolloutResult.RecheckAfter != nil && *rolloutResult.RecheckAfter < minRequeue {
 			minRequeue = *rolloutResult.RecheckAfter
 		}
 
+		frozen := d.freezeFilter.Filter(&rolloutResult, mwrSet.Annotations)
+		frozenClusters = append(frozenClusters, frozen...)
+		if len(frozen) > 0 && frozenRecheckTime < minRequeue {
+			minRequeue = frozenRecheckTime
+		}
+
 		// Create ManifestWorks
 		for _, rolloutStatue := range rolloutResult.ClustersToRollout {
 			if rolloutStatue.Status == clustersdkv1alpha1.ToApply {
@@ -113,6 +217,16 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 					continue
 				}
 
+				if mw.Annotations == nil {
+					mw.Annotations = map[string]string{}
+				}
+				mw.Annotations[templateAppliedTimeAnnotationKey] = metav1.Now().UTC().Format(time.RFC3339)
+
+				if err := d.encryptManifests(ctx, mw); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+
 				_, err = d.workApplier.Apply(ctx, mw)
 				if err != nil {
 					fmt.Printf("err is %v\n", err)
@@ -125,7 +239,7 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 
 		for _, cls := range rolloutResult.ClustersRemoved {
 			// Delete manifestWork for removed clusters
-			err = d.workApplier.Delete(ctx, cls.ClusterName, mwrSet.Name)
+			err = deleteManifestWork(ctx, d.workApplier, d.manifestWorkLister, mwrSet, cls.ClusterName)
 			if err != nil {
 				errs = append(errs, err)
 				continue
@@ -166,12 +280,32 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(workapiv1alpha1.ReasonAsExpected, ""))
 	}
 
-	if total == count {
+	switch {
+	case total == count:
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementRollOut(workapiv1alpha1.ReasonComplete, ""))
-	} else {
+	case len(frozenClusters) > 0:
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementRollOut(ReasonClustersFrozen,
+			fmt.Sprintf("%d clusters are frozen: %s", len(frozenClusters), strings.Join(frozenClusters, ", "))))
+	case len(waitingClusters) > 0:
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementRollOut(ReasonWaitingForMaintenanceWindow,
+			fmt.Sprintf("%d clusters are waiting for the maintenance windows: %s",
+				len(waitingClusters), strings.Join(waitingClusters, ", "))))
+	default:
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementRollOut(workapiv1alpha1.ReasonProgressing, ""))
 	}
 
+	if err := d.recordCompletedClusters(ctx, mwrSet, hash, completedClusters, allDecidedClusters); err != nil {
+		errs = append(errs, err)
+	}
+
+	rolledBack, err := d.evaluateRollout(ctx, mwrSet, failedClusters, succeeded, total)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if rolledBack {
+		minRequeue = rollbackRequeueTime
+	}
+
 	if len(errs) > 0 {
 		return mwrSet, reconcileContinue, utilerrors.NewAggregate(errs)
 	}
@@ -183,6 +317,29 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	return mwrSet, reconcileContinue, nil
 }
 
+// evaluateRolloutGate applies the rollout gate on the rollout status of the manifestwork. The soak period starts
+// when the current generation of the manifestwork becomes available, so it is not skipped when the template is
+// updated on a manifestwork which is already available.
+func (d *deployReconciler) evaluateRolloutGate(ctx context.Context, gate *helpers.RolloutGate,
+	status clustersdkv1alpha1.ClusterRolloutStatus, mw *workv1.ManifestWork) (clustersdkv1alpha1.ClusterRolloutStatus, time.Duration) {
+	if status.Status != clustersdkv1alpha1.Succeeded || d.gateEvaluator == nil {
+		return status, 0
+	}
+	cond := apimeta.FindStatusCondition(mw.Status.Conditions, workv1.WorkAvailable)
+	if cond == nil || cond.ObservedGeneration != mw.Generation {
+		// the available condition is not updated for the current generation yet.
+		status.Status = clustersdkv1alpha1.Progressing
+		return status, 0
+	}
+	soakStart := cond.LastTransitionTime
+	if appliedTime, err := time.Parse(time.RFC3339, mw.Annotations[templateAppliedTimeAnnotationKey]); err == nil &&
+		appliedTime.After(soakStart.Time) {
+		soakStart = metav1.NewTime(appliedTime)
+	}
+	status.LastTransitionTime = &soakStart
+	return d.gateEvaluator.Evaluate(ctx, gate, status, []*workv1.ManifestWork{mw})
+}
+
 func (d *deployReconciler) clusterRolloutStatusFunc(clusterName string, manifestWork workv1.ManifestWork) (clustersdkv1alpha1.ClusterRolloutStatus, error) {
 	clsRolloutStatus := clustersdkv1alpha1.ClusterRolloutStatus{
 		ClusterName:        clusterName,
@@ -191,6 +348,12 @@ func (d *deployReconciler) clusterRolloutStatusFunc(clusterName string, manifest
 		Status: clustersdkv1alpha1.ToApply,
 	}
 
+	// the completed manifestwork is succeeded and will not be updated on the managed cluster any more.
+	if isManifestWorkComplete(&manifestWork) {
+		clsRolloutStatus.Status = clustersdkv1alpha1.Succeeded
+		return clsRolloutStatus, nil
+	}
+
 	appliedCondition := apimeta.FindStatusCondition(manifestWork.Status.Conditions, workv1.WorkApplied)
 
 	// Applied condition not exist return status as ToApply.
@@ -273,19 +436,48 @@ func CreateManifestWork(
 
 	// TODO consider how to trace the manifestworks spec changes for cloudevents work client
 
-	return &workv1.ManifestWork{
+	labels := map[string]string{
+		ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet),
+		ManifestWorkReplicaSetPlacementNameLabelKey:  placementRefName,
+	}
+	// the manifests sealed in the template are encrypted with the public key of the cluster
+	if mwrSet.Labels[encryption.EncryptManifestsLabelKey] == "true" {
+		labels[encryption.EncryptManifestsLabelKey] = "true"
+	}
+
+	mw := &workv1.ManifestWork{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      mwrSet.Name,
 			Namespace: clusterNS,
-			Labels: map[string]string{
-				ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet),
-				ManifestWorkReplicaSetPlacementNameLabelKey:  placementRefName,
-			},
+			Labels:    labels,
 		},
-		Spec: mwrSet.Spec.ManifestWorkTemplate,
-	}, nil
+		Spec: *mwrSet.Spec.ManifestWorkTemplate.DeepCopy(),
+	}
+	// the override of the freeze is decided on the manifestWorkReplicaSet, it is copied with its audit so the work
+	// agent applies the manifestwork on the frozen cluster.
+	helpers.CopyFreezeOverride(mw, mwrSet.Annotations)
+	return mw, nil
 }
 
 func getAvailableDecisionGroupProgressMessage(groupNum int, existingClsCount int, totalCls int32) string {
 	return fmt.Sprintf("%d (%d / %d clusters applied)", groupNum, existingClsCount, totalCls)
 }
+
+// encryptManifests opens the manifests sealed in the template and encrypts the manifests of the manifestwork, the
+// encrypted manifests of the existing manifestwork are reused if the manifests are not changed.
+func (d *deployReconciler) encryptManifests(ctx context.Context, mw *workv1.ManifestWork) error {
+	if d.encryptor == nil {
+		return nil
+	}
+	if err := d.encryptor.Unseal(ctx, mw.Spec.Workload.Manifests); err != nil {
+		return err
+	}
+	existing, err := d.manifestWorkLister.ManifestWorks(mw.Namespace).Get(mw.Name)
+	switch {
+	case errors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return err
+	}
+	return d.encryptor.Encrypt(ctx, mw, existing)
+}
@@ -11,17 +11,22 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 
 	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	"open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
 )
 
@@ -629,3 +634,192 @@ func TestRequeueWithProgressDeadline(t *testing.T) {
 		t.Errorf("expect to get err %t", err)
 	}
 }
+
+func TestDeployReconcileWithRolloutGate(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		helpers.RolloutGateAnnotationKey: `{"expression":"cluster != \"cls2\"","soakPeriod":"10m"}`,
+	}
+	works := []runtime.Object{
+		newRolloutManifestWork(t, mwrSet, "cls1", mwrSet.Spec.ManifestWorkTemplate, metav1.ConditionTrue),
+		newRolloutManifestWork(t, mwrSet, "cls2", mwrSet.Spec.ManifestWorkTemplate, metav1.ConditionTrue),
+	}
+	deploy, _ := newRollbackDeployReconciler(t, kubefake.NewSimpleClientset(), mwrSet, works, "cls1", "cls2")
+	gateEvaluator, err := helpers.NewRolloutGateEvaluator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy.gateEvaluator = gateEvaluator
+	gate, err := helpers.GetRolloutGate(mwrSet.Annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the available works are soaking, and the gate fails on cls2.
+	status, recheck := deploy.evaluateRolloutGate(context.TODO(), gate, clustersdkv1alpha1.ClusterRolloutStatus{
+		ClusterName: "cls1", Status: clustersdkv1alpha1.Succeeded}, works[0].(*workapiv1.ManifestWork))
+	if status.Status != clustersdkv1alpha1.Progressing || recheck <= 0 {
+		t.Errorf("expected cls1 progressing, got %v, recheck after %v", status.Status, recheck)
+	}
+	status, _ = deploy.evaluateRolloutGate(context.TODO(), gate, clustersdkv1alpha1.ClusterRolloutStatus{
+		ClusterName: "cls2", Status: clustersdkv1alpha1.Succeeded}, works[1].(*workapiv1.ManifestWork))
+	if status.Status != clustersdkv1alpha1.Failed {
+		t.Errorf("expected cls2 failed, got %v", status.Status)
+	}
+
+	// the rollout is rechecked after the soak period.
+	_, _, err = deploy.reconcile(context.TODO(), mwrSet)
+	var rqe helpers.RequeueError
+	if !errors.As(err, &rqe) || rqe.RequeueTime > 10*time.Minute {
+		t.Errorf("expected requeue in soak period, got %v", err)
+	}
+}
+
+func TestEvaluateRolloutGateAfterTemplateUpdate(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		helpers.RolloutGateAnnotationKey: `{"expression":"true","soakPeriod":"10m"}`,
+	}
+	deploy, _ := newRollbackDeployReconciler(t, kubefake.NewSimpleClientset(), mwrSet, nil, "cls1")
+	gateEvaluator, err := helpers.NewRolloutGateEvaluator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy.gateEvaluator = gateEvaluator
+	gate, err := helpers.GetRolloutGate(mwrSet.Annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the work has been available for an hour, and the template is updated just now.
+	mw := newRolloutManifestWork(t, mwrSet, "cls1", mwrSet.Spec.ManifestWorkTemplate, metav1.ConditionTrue)
+	mw.Generation = 2
+	mw.Annotations = map[string]string{
+		templateAppliedTimeAnnotationKey: metav1.Now().UTC().Format(time.RFC3339),
+	}
+	mw.Status.Conditions = []metav1.Condition{{
+		Type:               workapiv1.WorkAvailable,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: 1,
+		LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+	}}
+
+	// the new generation is not available yet.
+	status, _ := deploy.evaluateRolloutGate(context.TODO(), gate, clustersdkv1alpha1.ClusterRolloutStatus{
+		ClusterName: "cls1", Status: clustersdkv1alpha1.Succeeded}, mw)
+	if status.Status != clustersdkv1alpha1.Progressing {
+		t.Errorf("expected cls1 progressing before the new generation is available, got %v", status.Status)
+	}
+
+	// the soak period starts when the template is applied rather than the last transition time.
+	mw.Status.Conditions[0].ObservedGeneration = 2
+	status, recheck := deploy.evaluateRolloutGate(context.TODO(), gate, clustersdkv1alpha1.ClusterRolloutStatus{
+		ClusterName: "cls1", Status: clustersdkv1alpha1.Succeeded}, mw)
+	if status.Status != clustersdkv1alpha1.Progressing || recheck <= 0 || recheck > 10*time.Minute {
+		t.Errorf("expected cls1 soaking after the template update, got %v, recheck after %v", status.Status, recheck)
+	}
+
+	// the soak period passes.
+	mw.Annotations[templateAppliedTimeAnnotationKey] = metav1.NewTime(time.Now().Add(-20 * time.Minute)).UTC().Format(time.RFC3339)
+	status, _ = deploy.evaluateRolloutGate(context.TODO(), gate, clustersdkv1alpha1.ClusterRolloutStatus{
+		ClusterName: "cls1", Status: clustersdkv1alpha1.Succeeded}, mw)
+	if status.Status != clustersdkv1alpha1.Succeeded {
+		t.Errorf("expected cls1 succeeded after the soak period, got %v", status.Status)
+	}
+}
+
+func TestDeployReconcileWithSealedManifests(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := encryption.EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cls1"}}
+	cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+		{Name: encryption.PublicKeyClusterClaimName, Value: publicKey},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	if err := encryption.EnsureDigestKey(context.TODO(), kubeClient, "open-cluster-management-hub"); err != nil {
+		t.Fatal(err)
+	}
+	fClusterClient := fakeclusterclient.NewSimpleClientset(cluster)
+	encryptor := encryption.NewManifestEncryptor(kubeClient, "open-cluster-management-hub",
+		func(ctx context.Context, name string) (*clusterv1.ManagedCluster, error) {
+			return fClusterClient.ClusterV1().ManagedClusters().Get(ctx, name, metav1.GetOptions{})
+		})
+
+	secret, err := testingcommon.NewUnstructured("v1", "Secret", "ns1", "s1").MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Labels = map[string]string{encryption.EncryptManifestsLabelKey: "true"}
+	mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests = []workapiv1.Manifest{
+		{RawExtension: runtime.RawExtension{Raw: secret}},
+	}
+	if err := encryptor.Seal(context.TODO(), mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests, nil); err != nil {
+		t.Fatal(err)
+	}
+	sealed := mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests[0].Raw
+
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1")
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+		fakeclusterclient.NewSimpleClientset(placement, placementDecision), 1*time.Second)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	pmwDeployController := deployReconciler{
+		workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister:  mwLister,
+		placeDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+		placementLister:     clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+		encryptor:           encryptor,
+	}
+
+	if _, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+
+	// the template of the manifestWorkReplicaSet is not changed
+	assert.Equal(t, sealed, mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests[0].Raw)
+
+	mw, err := fWorkClient.WorkV1().ManifestWorks("cls1").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "true", mw.Labels[encryption.EncryptManifestsLabelKey])
+	decrypted, err := encryption.DecryptManifest(key, mw.Spec.Workload.Manifests[0].Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, string(secret), string(decrypted))
+}
+
+func TestCreateManifestWorkWithFreezeOverride(t *testing.T) {
+	audit := `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		helpers.FreezeOverrideAnnotationKey: "true",
+		helpers.FreezeAuditAnnotationKey:    audit,
+	}
+
+	mw, err := CreateManifestWork(mwrSet, "cls1", "place-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "true", mw.Annotations[helpers.FreezeOverrideAnnotationKey])
+	assert.Equal(t, audit, mw.Annotations[helpers.FreezeAuditAnnotationKey])
+	assert.True(t, helpers.IsFreezeOverridden(mw.Annotations))
+}
@@ -2,6 +2,7 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -11,6 +12,8 @@ import (
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 // finalizeReconciler is to finalize the manifestWorkReplicaSet by deleting all related manifestWorks.
@@ -18,6 +21,7 @@ type finalizeReconciler struct {
 	workApplier        *workapplier.WorkApplier
 	workClient         workclientset.Interface
 	manifestWorkLister worklisterv1.ManifestWorkLister
+	freezeFilter       *helpers.ClusterFreezeFilter
 }
 
 func (f *finalizeReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
@@ -26,9 +30,15 @@ func (f *finalizeReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alp
 		return mwrSet, reconcileContinue, nil
 	}
 
-	if err := f.finalizeManifestWorkReplicaSet(ctx, mwrSet); err != nil {
+	frozen, err := f.finalizeManifestWorkReplicaSet(ctx, mwrSet)
+	if err != nil {
 		return mwrSet, reconcileContinue, err
 	}
+	// keep the finalizer until the manifestworks on the frozen clusters are deleted.
+	if frozen > 0 {
+		return mwrSet, reconcileStop, helpers.NewRequeueError(
+			fmt.Sprintf("%d manifestworks are held by the frozen clusters", frozen), frozenRecheckTime)
+	}
 
 	workSetPatcher := patcher.NewPatcher[
 		*workapiv1alpha1.ManifestWorkReplicaSet, workapiv1alpha1.ManifestWorkReplicaSetSpec, workapiv1alpha1.ManifestWorkReplicaSetStatus](
@@ -53,19 +63,47 @@ func (f *finalizeReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alp
 	return mwrSet, reconcileStop, nil
 }
 
-func (f *finalizeReconciler) finalizeManifestWorkReplicaSet(ctx context.Context, manifestWorkReplicaSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+// finalizeManifestWorkReplicaSet deletes the manifestworks of the manifestWorkReplicaSet, and returns the number of
+// the manifestworks held by the frozen clusters.
+func (f *finalizeReconciler) finalizeManifestWorkReplicaSet(ctx context.Context,
+	manifestWorkReplicaSet *workapiv1alpha1.ManifestWorkReplicaSet) (int, error) {
 	manifestWorks, err := listManifestWorksByManifestWorkReplicaSet(manifestWorkReplicaSet, f.manifestWorkLister)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	var errs []error
+	frozen := 0
 	for _, mw := range manifestWorks {
-		err = f.workApplier.Delete(ctx, mw.Namespace, mw.Name)
+		if f.freezeFilter.IsFrozen(mw.Namespace, manifestWorkReplicaSet.Annotations) {
+			frozen++
+			continue
+		}
+		err = deleteManifestWork(ctx, f.workApplier, f.manifestWorkLister, manifestWorkReplicaSet, mw.Namespace)
 		if err != nil && !errors.IsNotFound(err) {
 			errs = append(errs, err)
 		}
 	}
 
-	return utilerrors.NewAggregate(errs)
+	return frozen, utilerrors.NewAggregate(errs)
+}
+
+// deleteManifestWork deletes the manifestwork of the manifestWorkReplicaSet on the cluster. The override of the
+// freeze of the manifestWorkReplicaSet is copied to the manifestwork before it is deleted, so the work agent
+// removes the resources on the frozen cluster.
+func deleteManifestWork(ctx context.Context, workApplier *workapplier.WorkApplier, manifestWorkLister worklisterv1.ManifestWorkLister,
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusterName string) error {
+	mw, err := manifestWorkLister.ManifestWorks(clusterName).Get(mwrSet.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil && helpers.IsFreezeOverridden(mwrSet.Annotations) && !helpers.IsFreezeOverridden(mw.Annotations) {
+		mw = mw.DeepCopy()
+		helpers.CopyFreezeOverride(mw, mwrSet.Annotations)
+		if _, err := workApplier.Apply(ctx, mw); err != nil {
+			return err
+		}
+	}
+	return workApplier.Delete(ctx, clusterName, mwrSet.Name)
 }
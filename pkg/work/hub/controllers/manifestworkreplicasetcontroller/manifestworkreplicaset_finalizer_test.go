@@ -2,14 +2,21 @@ package manifestworkreplicasetcontroller
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
 
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
@@ -77,6 +84,32 @@ func TestFinalizeReconcile(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "deleting manifestWorkReplicaSet with works on frozen cluster",
+			resources: func() (*workapiv1alpha1.ManifestWorkReplicaSet, []*workapiv1.ManifestWork) {
+				mwrSetTest := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+				timeNow := metav1.Now()
+				mwrSetTest.DeletionTimestamp = &timeNow
+				mwrSetTest.Finalizers = append(mwrSetTest.Finalizers, ManifestWorkReplicaSetFinalizer)
+
+				mw1, _ := CreateManifestWork(mwrSetTest, "cluster1", "place-test")
+				mw2, _ := CreateManifestWork(mwrSetTest, "frozen", "place-test")
+				return mwrSetTest, []*workapiv1.ManifestWork{mw1, mw2}
+			},
+			validateActions: func(t *testing.T, manifestWorkReplicaSet *workapiv1alpha1.ManifestWorkReplicaSet,
+				state reconcileState, err error) {
+				var rqe commonhelper.RequeueError
+				if !errors.As(err, &rqe) {
+					t.Fatalf("expected requeue error, got %v", err)
+				}
+				if state != reconcileStop {
+					t.Fatalf("manifestWorkReplicaSet should be stopped after finalizeReconcile")
+				}
+				if !commonhelper.HasFinalizer(manifestWorkReplicaSet.Finalizers, ManifestWorkReplicaSetFinalizer) {
+					t.Fatalf("Finalizer %v is deleted", manifestWorkReplicaSet.Finalizers)
+				}
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -95,10 +128,19 @@ func TestFinalizeReconcile(t *testing.T) {
 					t.Fatal(err)
 				}
 			}
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fakeclusterclient.NewSimpleClientset(), 1*time.Second)
+			frozenCluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+				Name:   "frozen",
+				Labels: map[string]string{commonhelper.ClusterFrozenLabelKey: "true"},
+			}}
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(frozenCluster); err != nil {
+				t.Fatal(err)
+			}
 			finalizerController := finalizeReconciler{
 				workClient:         fakeClient,
 				manifestWorkLister: mwLister,
 				workApplier:        workapplier.NewWorkApplierWithTypedClient(fakeClient, mwLister),
+				freezeFilter:       commonhelper.NewClusterFreezeFilter(clusterInformerFactory.Cluster().V1().ManagedClusters().Lister()),
 			}
 
 			_, state, rstErr := finalizerController.reconcile(context.TODO(), manifestWorkReplicaSet)
@@ -112,3 +154,41 @@ func TestFinalizeReconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteManifestWorkWithFreezeOverride(t *testing.T) {
+	mwrSetTest := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mw, _ := CreateManifestWork(mwrSetTest, "frozen", "place-test")
+	mwrSetTest.Annotations = map[string]string{
+		commonhelper.FreezeOverrideAnnotationKey: "true",
+		commonhelper.FreezeAuditAnnotationKey:    `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`,
+	}
+
+	fakeClient := fakeclient.NewSimpleClientset(mwrSetTest, mw)
+	manifestWorkInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeClient, 1*time.Second)
+	mwLister := manifestWorkInformerFactory.Work().V1().ManifestWorks().Lister()
+	if err := manifestWorkInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+		t.Fatal(err)
+	}
+
+	err := deleteManifestWork(context.TODO(), workapplier.NewWorkApplierWithTypedClient(fakeClient, mwLister),
+		mwLister, mwrSetTest, "frozen")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the override is copied to the manifestwork before it is deleted
+	var actions []string
+	for _, action := range fakeClient.Actions() {
+		if action.GetResource().Resource != "manifestworks" {
+			continue
+		}
+		actions = append(actions, action.GetVerb())
+		if patch, ok := action.(clienttesting.PatchAction); ok &&
+			!strings.Contains(string(patch.GetPatch()), commonhelper.FreezeOverrideAnnotationKey) {
+			t.Errorf("expected the override is copied, got patch %s", patch.GetPatch())
+		}
+	}
+	if !reflect.DeepEqual(actions, []string{"patch", "delete"}) {
+		t.Errorf("expected the manifestwork is patched and deleted, got %v", actions)
+	}
+}
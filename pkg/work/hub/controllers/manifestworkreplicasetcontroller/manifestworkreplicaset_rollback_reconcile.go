@@ -0,0 +1,301 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// The annotations maintained by the controller to track the template revisions. The last good template itself
+	// is stored in a controllerrevision owned by the manifestworkreplicaset, since a template may exceed the size
+	// limit of the annotations.
+	lastGoodTemplateHashAnnotationKey = "work.open-cluster-management.io/last-good-template-hash"
+	failedTemplateHashAnnotationKey   = "work.open-cluster-management.io/failed-template-hash"
+	// RevisionHistoryAnnotationKey records the latest template revisions and their rollout results.
+	RevisionHistoryAnnotationKey = "work.open-cluster-management.io/revision-history"
+
+	// ManifestWorkReplicaSetConditionRolledBack is true when the manifestworks are reverted to the last
+	// good template because the rollout of the current template failed.
+	ManifestWorkReplicaSetConditionRolledBack = "RolledBack"
+	ReasonRolloutFailed                       = "RolloutFailed"
+	ReasonNotRolledBack                       = "NotRolledBack"
+
+	RevisionSucceeded  = "Succeeded"
+	RevisionRolledBack = "RolledBack"
+
+	maxRevisionHistory = 10
+	// maxReasonClusters limits the number of failed clusters listed in the rollback reason.
+	maxReasonClusters = 10
+
+	// rollbackRequeueTime is the time to requeue after the rollback so the last good template is deployed.
+	rollbackRequeueTime = time.Second
+)
+
+// TemplateRevision is an entry of the revision history of a manifestworkreplicaset.
+type TemplateRevision struct {
+	Hash   string      `json:"hash"`
+	Result string      `json:"result"`
+	Reason string      `json:"reason,omitempty"`
+	Time   metav1.Time `json:"time"`
+}
+
+// rollbackReconciler replaces the template of the manifestworkreplicaset with the last good template in memory
+// if the current template was rolled back, so the following reconcilers deploy and check the last good template.
+type rollbackReconciler struct {
+	kubeClient kubernetes.Interface
+	workClient workclientset.Interface
+}
+
+func (r *rollbackReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+) (*workapiv1alpha1.ManifestWorkReplicaSet, reconcileState, error) {
+	failedHash, ok := mwrSet.Annotations[failedTemplateHashAnnotationKey]
+	if !ok {
+		return mwrSet, reconcileContinue, nil
+	}
+
+	hash, err := templateHash(mwrSet.Spec.ManifestWorkTemplate)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+
+	// the template is updated after the rollback, roll out the new template.
+	if hash != failedHash {
+		err := patchRevisionAnnotations(ctx, r.workClient, mwrSet, map[string]*string{failedTemplateHashAnnotationKey: nil})
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionRolledBack,
+			ReasonNotRolledBack, fmt.Sprintf("rolling out template %s", hash), metav1.ConditionFalse))
+		return mwrSet, reconcileContinue, err
+	}
+
+	lastGoodTemplate, err := getTemplateRevision(ctx, r.kubeClient, mwrSet, mwrSet.Annotations[lastGoodTemplateHashAnnotationKey])
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	mwrSet.Spec.ManifestWorkTemplate = *lastGoodTemplate
+	return mwrSet, reconcileContinue, nil
+}
+
+// evaluateRollout records the template as the last good template once it is rolled out to all the clusters
+// successfully, and rolls back the template when the failed clusters reach the rollback threshold. It is a
+// no-op if the rollback threshold is not set on the manifestworkreplicaset. It returns true if the template is
+// rolled back.
+func (d *deployReconciler) evaluateRollout(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	failedClusters sets.Set[string], succeeded, total int) (bool, error) {
+	thresholdValue, ok := mwrSet.Annotations[helper.ManifestWorkReplicaSetRollbackThresholdAnnotationKey]
+	if !ok {
+		return false, nil
+	}
+	// the last good template is being rolled out.
+	if _, ok := mwrSet.Annotations[failedTemplateHashAnnotationKey]; ok {
+		return false, nil
+	}
+
+	hash, err := templateHash(mwrSet.Spec.ManifestWorkTemplate)
+	if err != nil {
+		return false, err
+	}
+	lastGoodHash := mwrSet.Annotations[lastGoodTemplateHashAnnotationKey]
+
+	if total > 0 && succeeded == total && hash != lastGoodHash {
+		if err := createTemplateRevision(ctx, d.kubeClient, mwrSet, hash); err != nil {
+			return false, err
+		}
+		history, err := appendRevisionHistory(mwrSet, TemplateRevision{Hash: hash, Result: RevisionSucceeded, Time: metav1.Now()})
+		if err != nil {
+			return false, err
+		}
+		if err := patchRevisionAnnotations(ctx, d.workClient, mwrSet, map[string]*string{
+			lastGoodTemplateHashAnnotationKey: ptr.To(hash),
+			RevisionHistoryAnnotationKey:      ptr.To(history),
+		}); err != nil {
+			return false, err
+		}
+		// the previous last good template is not needed anymore.
+		return false, deleteTemplateRevision(ctx, d.kubeClient, mwrSet, lastGoodHash)
+	}
+
+	threshold := intstr.Parse(thresholdValue)
+	failureThreshold, err := intstr.GetScaledValueFromIntOrPercent(&threshold, total, true)
+	if err != nil {
+		return false, fmt.Errorf("invalid rollback failure threshold %q: %w", thresholdValue, err)
+	}
+	if failedClusters.Len() == 0 || failedClusters.Len() < failureThreshold {
+		return false, nil
+	}
+
+	listed := sets.List(failedClusters)
+	if len(listed) > maxReasonClusters {
+		listed = listed[:maxReasonClusters]
+	}
+	reason := fmt.Sprintf("%d clusters failed or timed out: %s", failedClusters.Len(), strings.Join(listed, ","))
+	if len(lastGoodHash) == 0 || hash == lastGoodHash {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionRolledBack,
+			ReasonNotRolledBack, fmt.Sprintf("no previous template to roll back, %s", reason), metav1.ConditionFalse))
+		return false, nil
+	}
+
+	history, err := appendRevisionHistory(mwrSet, TemplateRevision{Hash: hash, Result: RevisionRolledBack, Reason: reason, Time: metav1.Now()})
+	if err != nil {
+		return false, err
+	}
+	if err := patchRevisionAnnotations(ctx, d.workClient, mwrSet, map[string]*string{
+		failedTemplateHashAnnotationKey: ptr.To(hash),
+		RevisionHistoryAnnotationKey:    ptr.To(history),
+	}); err != nil {
+		return false, err
+	}
+	apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionRolledBack,
+		ReasonRolloutFailed, fmt.Sprintf("template %s is rolled back to %s, %s", hash, lastGoodHash, reason), metav1.ConditionTrue))
+	return true, nil
+}
+
+func appendRevisionHistory(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, revision TemplateRevision) (string, error) {
+	var history []TemplateRevision
+	if value, ok := mwrSet.Annotations[RevisionHistoryAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(value), &history); err != nil {
+			// the history is only informative, start a new one if it is broken.
+			history = nil
+		}
+	}
+	history = append(history, revision)
+	if len(history) > maxRevisionHistory {
+		history = history[len(history)-maxRevisionHistory:]
+	}
+	data, err := json.Marshal(history)
+	return string(data), err
+}
+
+// patchRevisionAnnotations patches the annotations of the manifestworkreplicaset, an annotation is removed if
+// its value is nil. The resource version of the manifestworkreplicaset is updated so the status can be patched
+// afterward.
+func patchRevisionAnnotations(ctx context.Context, workClient workclientset.Interface,
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, annotations map[string]*string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"uid":             mwrSet.UID,
+			"resourceVersion": mwrSet.ResourceVersion,
+			"annotations":     annotations,
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	patched, err := workClient.WorkV1alpha1().ManifestWorkReplicaSets(mwrSet.Namespace).Patch(
+		ctx, mwrSet.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+
+	mwrSet.ResourceVersion = patched.ResourceVersion
+	if mwrSet.Annotations == nil {
+		mwrSet.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		if value == nil {
+			delete(mwrSet.Annotations, key)
+		} else {
+			mwrSet.Annotations[key] = *value
+		}
+	}
+	return nil
+}
+
+// templateRevisionName returns the name of the controllerrevision storing the template with the hash.
+func templateRevisionName(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, hash string) string {
+	name := mwrSet.Name
+	if maxLength := validation.DNS1123SubdomainMaxLength - len(hash) - 1; len(name) > maxLength {
+		name = strings.TrimRight(name[:maxLength], "-.")
+	}
+	return fmt.Sprintf("%s-%s", name, hash)
+}
+
+// createTemplateRevision stores the current template of the manifestworkreplicaset in a controllerrevision, the
+// controllerrevision is garbage collected with the manifestworkreplicaset.
+func createTemplateRevision(ctx context.Context, kubeClient kubernetes.Interface,
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, hash string) error {
+	template, err := json.Marshal(mwrSet.Spec.ManifestWorkTemplate)
+	if err != nil {
+		return err
+	}
+	revision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      templateRevisionName(mwrSet, hash),
+			Namespace: mwrSet.Namespace,
+			Labels: map[string]string{
+				ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(mwrSet, workapiv1alpha1.GroupVersion.WithKind("ManifestWorkReplicaSet")),
+			},
+		},
+		Data: runtime.RawExtension{Raw: template},
+	}
+	_, err = kubeClient.AppsV1().ControllerRevisions(mwrSet.Namespace).Create(ctx, revision, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// getTemplateRevision returns the template stored in the controllerrevision of the hash.
+func getTemplateRevision(ctx context.Context, kubeClient kubernetes.Interface,
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, hash string) (*workv1.ManifestWorkSpec, error) {
+	revision, err := kubeClient.AppsV1().ControllerRevisions(mwrSet.Namespace).Get(
+		ctx, templateRevisionName(mwrSet, hash), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the last good template %s: %w", hash, err)
+	}
+
+	template := &workv1.ManifestWorkSpec{}
+	if err := json.Unmarshal(revision.Data.Raw, template); err != nil {
+		return nil, fmt.Errorf("failed to decode the last good template %s: %w", hash, err)
+	}
+	if revisionHash, err := templateHash(*template); err != nil || revisionHash != hash {
+		return nil, fmt.Errorf("the last good template %s does not match its hash", hash)
+	}
+	return template, nil
+}
+
+func deleteTemplateRevision(ctx context.Context, kubeClient kubernetes.Interface,
+	mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, hash string) error {
+	if len(hash) == 0 {
+		return nil
+	}
+	err := kubeClient.AppsV1().ControllerRevisions(mwrSet.Namespace).Delete(
+		ctx, templateRevisionName(mwrSet, hash), metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func templateHash(template workv1.ManifestWorkSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16], nil
+}
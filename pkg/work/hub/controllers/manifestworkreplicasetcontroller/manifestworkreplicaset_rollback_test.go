@@ -0,0 +1,181 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func newRollbackDeployReconciler(t *testing.T, kubeClient kubernetes.Interface, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	works []runtime.Object, clusters ...string) (*deployReconciler, *fakeworkclient.Clientset) {
+	fWorkClient := fakeworkclient.NewSimpleClientset(append(works, mwrSet)...)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+	for _, work := range works {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", clusters...)
+	fClusterClient := fakeclusterclient.NewSimpleClientset(placement, placementDecision)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fClusterClient, 1*time.Second)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	return &deployReconciler{
+		kubeClient:          kubeClient,
+		workClient:          fWorkClient,
+		workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister:  mwLister,
+		placeDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+		placementLister:     clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+	}, fWorkClient
+}
+
+func newRolloutManifestWork(t *testing.T, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, cluster string,
+	template workapiv1.ManifestWorkSpec, applied metav1.ConditionStatus) *workapiv1.ManifestWork {
+	mw, err := CreateManifestWork(mwrSet, cluster, "place-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mw.Spec = template
+	apimeta.SetStatusCondition(&mw.Status.Conditions, metav1.Condition{Type: workapiv1.WorkApplied, Status: applied})
+	if applied == metav1.ConditionTrue {
+		apimeta.SetStatusCondition(&mw.Status.Conditions, metav1.Condition{Type: workapiv1.WorkAvailable, Status: metav1.ConditionTrue})
+	}
+	return mw
+}
+
+func TestRollbackOnFailedRollout(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{helper.ManifestWorkReplicaSetRollbackThresholdAnnotationKey: "50%"}
+	goodTemplate := mwrSet.Spec.ManifestWorkTemplate
+	badTemplate := helpertest.CreateTestManifestWorkSpecWithSecret("v1", "Secret", "test", "bad")
+
+	// the template is recorded as the last good template once all the clusters succeeded.
+	works := []runtime.Object{
+		newRolloutManifestWork(t, mwrSet, "cls1", goodTemplate, metav1.ConditionTrue),
+		newRolloutManifestWork(t, mwrSet, "cls2", goodTemplate, metav1.ConditionTrue),
+	}
+	kubeClient := kubefake.NewSimpleClientset()
+	deploy, _ := newRollbackDeployReconciler(t, kubeClient, mwrSet, works, "cls1", "cls2")
+	mwrSet, _, err := deploy.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goodHash, _ := templateHash(goodTemplate)
+	if mwrSet.Annotations[lastGoodTemplateHashAnnotationKey] != goodHash {
+		t.Fatalf("expected last good template %s, got %v", goodHash, mwrSet.Annotations)
+	}
+	revision, err := kubeClient.AppsV1().ControllerRevisions("default").Get(
+		context.TODO(), templateRevisionName(mwrSet, goodHash), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the last good template is stored in a controllerrevision: %v", err)
+	}
+	if len(revision.OwnerReferences) != 1 || revision.OwnerReferences[0].Name != mwrSet.Name {
+		t.Errorf("expected the controllerrevision is owned by the manifestworkreplicaset, got %v", revision.OwnerReferences)
+	}
+
+	// the new template failed on one of the two clusters, which reaches the threshold.
+	mwrSet.Spec.ManifestWorkTemplate = badTemplate
+	works = []runtime.Object{
+		newRolloutManifestWork(t, mwrSet, "cls1", badTemplate, metav1.ConditionFalse),
+		newRolloutManifestWork(t, mwrSet, "cls2", badTemplate, metav1.ConditionTrue),
+	}
+	deploy, fWorkClient := newRollbackDeployReconciler(t, kubeClient, mwrSet, works, "cls1", "cls2")
+	mwrSet, _, err = deploy.reconcile(context.TODO(), mwrSet)
+	var rqe helpers.RequeueError
+	if !errors.As(err, &rqe) || rqe.RequeueTime != rollbackRequeueTime {
+		t.Errorf("expected requeue after rollback, got %v", err)
+	}
+	badHash, _ := templateHash(badTemplate)
+	if mwrSet.Annotations[failedTemplateHashAnnotationKey] != badHash {
+		t.Errorf("expected failed template %s, got %v", badHash, mwrSet.Annotations)
+	}
+	cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != ReasonRolloutFailed {
+		t.Errorf("expected rolled back condition, got %v", cond)
+	}
+	var history []TemplateRevision
+	if err := json.Unmarshal([]byte(mwrSet.Annotations[RevisionHistoryAnnotationKey]), &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Result != RevisionSucceeded || history[1].Result != RevisionRolledBack {
+		t.Errorf("unexpected revision history %v", history)
+	}
+	patched, err := fWorkClient.WorkV1alpha1().ManifestWorkReplicaSets("default").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Annotations[failedTemplateHashAnnotationKey] != badHash {
+		t.Errorf("expected failed template is patched, got %v", patched.Annotations)
+	}
+
+	// the last good template is deployed while the failed template is not changed.
+	rollback := &rollbackReconciler{kubeClient: kubeClient, workClient: fWorkClient}
+	reverted, _, err := rollback.reconcile(context.TODO(), mwrSet.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revertedHash, _ := templateHash(reverted.Spec.ManifestWorkTemplate); revertedHash != goodHash {
+		t.Errorf("expected the last good template %s is deployed, got %s", goodHash, revertedHash)
+	}
+
+	// the new template is rolled out once the template is updated.
+	mwrSet.Spec.ManifestWorkTemplate = helpertest.CreateTestManifestWorkSpecWithSecret("v1", "Secret", "test", "fixed")
+	updated, _, err := rollback.reconcile(context.TODO(), mwrSet.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated.Annotations[failedTemplateHashAnnotationKey]; ok {
+		t.Errorf("expected failed template is removed, got %v", updated.Annotations)
+	}
+	if apimeta.IsStatusConditionTrue(updated.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack) {
+		t.Errorf("expected rolled back condition is false")
+	}
+}
+
+func TestNoRollbackWithoutThreshold(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	works := []runtime.Object{
+		newRolloutManifestWork(t, mwrSet, "cls1", mwrSet.Spec.ManifestWorkTemplate, metav1.ConditionFalse),
+	}
+	deploy, fWorkClient := newRollbackDeployReconciler(t, kubefake.NewSimpleClientset(), mwrSet, works, "cls1")
+	mwrSet, _, err := deploy.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mwrSet.Annotations) != 0 {
+		t.Errorf("expected no revision annotations, got %v", mwrSet.Annotations)
+	}
+	for _, action := range fWorkClient.Actions() {
+		if action.GetResource().Resource == "manifestworkreplicasets" {
+			t.Errorf("unexpected action %v", action)
+		}
+	}
+}
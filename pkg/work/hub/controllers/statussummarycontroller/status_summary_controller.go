@@ -0,0 +1,446 @@
+package statussummarycontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/queue"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+const (
+	// StatusSummaryLabelKey is the label key on the configmaps requesting a status summary of manifestworks.
+	// Only the configmaps in the namespace of the work controller are summarized, since the summaries
+	// contain the status of the manifestworks across all the cluster namespaces.
+	StatusSummaryLabelKey = "work.open-cluster-management.io/status-summary"
+
+	// The keys in the data of the status summary configmap. ManifestWorkReplicaSetKey is the namespace/name
+	// of a ManifestWorkReplicaSet, the namespace of the configmap is used if only the name is set.
+	// ManifestWorkSelectorKey is a label selector of manifestworks across all the cluster namespaces,
+	// only one of them should be set. GroupByKey is a
+	// comma separated list of the feedback value names to group the manifestworks by. ConditionTypesKey
+	// is a comma separated list of the condition types counted, default is Applied, Available, Progressing
+	// and Degraded.
+	ManifestWorkReplicaSetKey = "manifestWorkReplicaSet"
+	ManifestWorkSelectorKey   = "manifestWorkSelector"
+	GroupByKey                = "groupBy"
+	ConditionTypesKey         = "conditionTypes"
+	// SummaryKey is the key of the status summary written by the controller.
+	SummaryKey = "summary"
+
+	// NoValue is the group of the manifestworks not reporting the feedback value.
+	NoValue = "<none>"
+
+	// maxClustersPerGroup limits the number of cluster names listed in each group, and maxGroupsPerFeedback
+	// limits the number of groups listed for each feedback name, so the summary of a large fleet can still
+	// be saved in a configmap. The count of the group is always accurate.
+	maxClustersPerGroup  = 100
+	maxGroupsPerFeedback = 50
+	// maxGroupBy and maxConditionTypes limit the number of feedback names and condition types summarized.
+	maxGroupBy        = 10
+	maxConditionTypes = 10
+	// maxSummaryBytes is the max size of the summary, which is less than the size limit of a configmap.
+	maxSummaryBytes = 512 * 1024
+
+	summaryByManifestWorkReplicaSet = "summaryByManifestWorkReplicaSet"
+
+	// resyncInterval is the interval to refresh the summaries of the label selected manifestworks,
+	// which are listed from the hub instead of the informer.
+	resyncInterval = time.Minute
+)
+
+var defaultConditionTypes = []string{
+	workapiv1.WorkApplied,
+	workapiv1.WorkAvailable,
+	workapiv1.WorkProgressing,
+	workapiv1.WorkDegraded,
+}
+
+// StatusSummary is the aggregated status of a set of manifestworks.
+type StatusSummary struct {
+	// Total is the number of the manifestworks summarized.
+	Total int `json:"total"`
+	// Conditions is the count of the manifestworks by condition type and status.
+	Conditions map[string]ConditionSummary `json:"conditions"`
+	// FeedbackGroups is the manifestworks grouped by the value of each feedback name in groupBy. At most
+	// 50 largest groups are listed for each feedback name.
+	FeedbackGroups map[string][]FeedbackGroup `json:"feedbackGroups,omitempty"`
+	// OmittedGroups is the number of the groups not listed for each feedback name.
+	OmittedGroups map[string]int `json:"omittedGroups,omitempty"`
+	// Error is set if the summary can not be computed.
+	Error string `json:"error,omitempty"`
+}
+
+type ConditionSummary struct {
+	True    int `json:"true"`
+	False   int `json:"false"`
+	Unknown int `json:"unknown"`
+}
+
+type FeedbackGroup struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	// Clusters is the sorted names of the clusters in the group, at most 100 clusters are listed.
+	Clusters []string `json:"clusters"`
+}
+
+// statusSummaryController maintains the status summaries requested by the configmaps with the status
+// summary label. Each summary aggregates the conditions and feedback values of the manifestworks of a
+// ManifestWorkReplicaSet or selected by labels, so the status across the clusters can be queried
+// without listing all the manifestworks.
+type statusSummaryController struct {
+	namespace          string
+	kubeClient         kubernetes.Interface
+	workClient         workclientset.Interface
+	configMapLister    corev1listers.ConfigMapLister
+	configMapIndexer   cache.Indexer
+	manifestWorkLister worklisterv1.ManifestWorkLister
+}
+
+// NewStatusSummaryController returns a controller maintaining the status summaries in the configmaps of the
+// namespace. The configMapInformer should only watch the configmaps with the status summary label in the
+// namespace, and the manifestWorkInformer is the informer of the manifestworks created by ManifestWorkReplicaSets.
+func NewStatusSummaryController(
+	recorder events.Recorder,
+	namespace string,
+	kubeClient kubernetes.Interface,
+	workClient workclientset.Interface,
+	configMapInformer corev1informers.ConfigMapInformer,
+	manifestWorkInformer workinformerv1.ManifestWorkInformer,
+) factory.Controller {
+	c := &statusSummaryController{
+		namespace:          namespace,
+		kubeClient:         kubeClient,
+		workClient:         workClient,
+		configMapLister:    configMapInformer.Lister(),
+		configMapIndexer:   configMapInformer.Informer().GetIndexer(),
+		manifestWorkLister: manifestWorkInformer.Lister(),
+	}
+
+	err := configMapInformer.Informer().AddIndexers(cache.Indexers{
+		summaryByManifestWorkReplicaSet: indexSummaryByManifestWorkReplicaSet,
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeysFunc(
+			queue.QueueKeyByMetaNamespaceName,
+			queue.FileterByLabel(StatusSummaryLabelKey),
+			configMapInformer.Informer()).
+		WithFilteredEventsInformersQueueKeysFunc(
+			c.manifestWorkQueueKeysFunc,
+			queue.FileterByLabel(manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey),
+			manifestWorkInformer.Informer()).
+		WithSync(c.sync).
+		ResyncEvery(resyncInterval).
+		ToController("StatusSummaryController", recorder)
+}
+
+// indexSummaryByManifestWorkReplicaSet indexes the summary configmaps by the key of the ManifestWorkReplicaSet,
+// which is the same as the value of the ManifestWorkReplicaSet label on the manifestworks.
+func indexSummaryByManifestWorkReplicaSet(obj interface{}) ([]string, error) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not a configmap", obj)
+	}
+	namespace, name := manifestWorkReplicaSetRef(cm)
+	if len(name) == 0 {
+		return []string{}, nil
+	}
+	return []string{fmt.Sprintf("%s.%s", namespace, name)}, nil
+}
+
+// manifestWorkReplicaSetRef returns the namespace and name of the ManifestWorkReplicaSet summarized by the configmap.
+func manifestWorkReplicaSetRef(cm *corev1.ConfigMap) (string, string) {
+	ref := cm.Data[ManifestWorkReplicaSetKey]
+	if namespace, name, found := strings.Cut(ref, "/"); found {
+		return namespace, name
+	}
+	return cm.Namespace, ref
+}
+
+func (c *statusSummaryController) manifestWorkQueueKeysFunc(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	mwrsKey, ok := accessor.GetLabels()[manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey]
+	if !ok {
+		return []string{}
+	}
+
+	objs, err := c.configMapIndexer.ByIndex(summaryByManifestWorkReplicaSet, mwrsKey)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+
+	var keys []string
+	for _, o := range objs {
+		cm := o.(*corev1.ConfigMap)
+		keys = append(keys, fmt.Sprintf("%s/%s", cm.Namespace, cm.Name))
+	}
+	return keys
+}
+
+func (c *statusSummaryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	key := syncCtx.QueueKey()
+	if key == factory.DefaultQueueKey {
+		// refresh the summaries of the label selected manifestworks, the summaries of the
+		// ManifestWorkReplicaSets are refreshed by the manifestwork events.
+		cms, err := c.configMapLister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, cm := range cms {
+			if cm.Namespace == c.namespace && len(cm.Data[ManifestWorkSelectorKey]) > 0 {
+				syncCtx.Queue().Add(fmt.Sprintf("%s/%s", cm.Namespace, cm.Name))
+			}
+		}
+		return nil
+	}
+	klog.V(4).Infof("Reconciling status summary %q", key)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		// ignore the configmap whose key is not in format: namespace/name
+		utilruntime.HandleError(err)
+		return nil
+	}
+	if namespace != c.namespace {
+		// the status summaries are only written into the configmaps of the controller namespace
+		return nil
+	}
+
+	cm, err := c.configMapLister.ConfigMaps(namespace).Get(name)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	summary, err := c.summarize(ctx, cm)
+	if err != nil {
+		summary = &StatusSummary{Error: err.Error()}
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxSummaryBytes {
+		data, err = json.Marshal(&StatusSummary{
+			Error: fmt.Sprintf("the summary size %d exceeds the limit %d", len(data), maxSummaryBytes),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if cm.Data[SummaryKey] == string(data) {
+		return nil
+	}
+
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[SummaryKey] = string(data)
+	_, err = c.kubeClient.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// summarize lists the manifestworks requested by the configmap and aggregates their status.
+func (c *statusSummaryController) summarize(ctx context.Context, cm *corev1.ConfigMap) (*StatusSummary, error) {
+	mwrsNamespace, mwrsName := manifestWorkReplicaSetRef(cm)
+	workSelector := cm.Data[ManifestWorkSelectorKey]
+
+	var works []*workapiv1.ManifestWork
+	switch {
+	case len(mwrsName) > 0 && len(workSelector) > 0:
+		return nil, fmt.Errorf("only one of %s and %s can be set", ManifestWorkReplicaSetKey, ManifestWorkSelectorKey)
+	case len(mwrsName) > 0:
+		selector := labels.SelectorFromSet(labels.Set{
+			manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey: fmt.Sprintf("%s.%s", mwrsNamespace, mwrsName),
+		})
+		listed, err := c.manifestWorkLister.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		works = listed
+	case len(workSelector) > 0:
+		selector, err := labels.Parse(workSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", ManifestWorkSelectorKey, workSelector, err)
+		}
+		listed, err := c.workClient.WorkV1().ManifestWorks(metav1.NamespaceAll).List(
+			ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		for i := range listed.Items {
+			works = append(works, &listed.Items[i])
+		}
+	default:
+		return nil, fmt.Errorf("one of %s and %s must be set", ManifestWorkReplicaSetKey, ManifestWorkSelectorKey)
+	}
+
+	conditionTypes := splitList(cm.Data[ConditionTypesKey])
+	if len(conditionTypes) == 0 {
+		conditionTypes = defaultConditionTypes
+	}
+	if len(conditionTypes) > maxConditionTypes {
+		return nil, fmt.Errorf("at most %d condition types can be set in %s", maxConditionTypes, ConditionTypesKey)
+	}
+	groupBy := splitList(cm.Data[GroupByKey])
+	if len(groupBy) > maxGroupBy {
+		return nil, fmt.Errorf("at most %d feedback names can be set in %s", maxGroupBy, GroupByKey)
+	}
+	return summarizeManifestWorks(works, conditionTypes, groupBy), nil
+}
+
+// summarizeManifestWorks counts the conditions of the manifestworks and groups the clusters of the
+// manifestworks by the value of each feedback name in groupBy.
+func summarizeManifestWorks(works []*workapiv1.ManifestWork, conditionTypes, groupBy []string) *StatusSummary {
+	summary := &StatusSummary{
+		Conditions: map[string]ConditionSummary{},
+	}
+	for _, conditionType := range conditionTypes {
+		summary.Conditions[conditionType] = ConditionSummary{}
+	}
+	// groups is the clusters by feedback name and value.
+	groups := map[string]map[string][]string{}
+	for _, name := range groupBy {
+		groups[name] = map[string][]string{}
+	}
+
+	for _, work := range works {
+		if !work.DeletionTimestamp.IsZero() {
+			continue
+		}
+		summary.Total++
+
+		for _, conditionType := range conditionTypes {
+			conditionSummary := summary.Conditions[conditionType]
+			cond := meta.FindStatusCondition(work.Status.Conditions, conditionType)
+			switch {
+			case cond == nil || cond.Status == metav1.ConditionUnknown:
+				conditionSummary.Unknown++
+			case cond.Status == metav1.ConditionTrue:
+				conditionSummary.True++
+			default:
+				conditionSummary.False++
+			}
+			summary.Conditions[conditionType] = conditionSummary
+		}
+
+		for _, name := range groupBy {
+			values := feedbackValues(work, name)
+			if len(values) == 0 {
+				values = []string{NoValue}
+			}
+			for _, value := range values {
+				groups[name][value] = append(groups[name][value], work.Namespace)
+			}
+		}
+	}
+
+	if len(groupBy) == 0 {
+		return summary
+	}
+	summary.FeedbackGroups = map[string][]FeedbackGroup{}
+	for name, valueGroups := range groups {
+		feedbackGroups := []FeedbackGroup{}
+		for value, clusters := range valueGroups {
+			sort.Strings(clusters)
+			group := FeedbackGroup{Value: value, Count: len(clusters), Clusters: clusters}
+			if len(clusters) > maxClustersPerGroup {
+				group.Clusters = clusters[:maxClustersPerGroup]
+			}
+			feedbackGroups = append(feedbackGroups, group)
+		}
+		// the largest group is listed first
+		sort.Slice(feedbackGroups, func(i, j int) bool {
+			if feedbackGroups[i].Count != feedbackGroups[j].Count {
+				return feedbackGroups[i].Count > feedbackGroups[j].Count
+			}
+			return feedbackGroups[i].Value < feedbackGroups[j].Value
+		})
+		if len(feedbackGroups) > maxGroupsPerFeedback {
+			if summary.OmittedGroups == nil {
+				summary.OmittedGroups = map[string]int{}
+			}
+			summary.OmittedGroups[name] = len(feedbackGroups) - maxGroupsPerFeedback
+			feedbackGroups = feedbackGroups[:maxGroupsPerFeedback]
+		}
+		summary.FeedbackGroups[name] = feedbackGroups
+	}
+	return summary
+}
+
+// feedbackValues returns the distinct values of the feedback name reported by the manifests of the work.
+func feedbackValues(work *workapiv1.ManifestWork, name string) []string {
+	var values []string
+	for _, manifest := range work.Status.ResourceStatus.Manifests {
+		for _, feedback := range manifest.StatusFeedbacks.Values {
+			if feedback.Name != name {
+				continue
+			}
+			value, ok := formatFieldValue(feedback.Value)
+			if !ok || slices.Contains(values, value) {
+				continue
+			}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func formatFieldValue(value workapiv1.FieldValue) (string, bool) {
+	switch {
+	case value.Type == workapiv1.Integer && value.Integer != nil:
+		return strconv.FormatInt(*value.Integer, 10), true
+	case value.Type == workapiv1.String && value.String != nil:
+		return *value.String, true
+	case value.Type == workapiv1.Boolean && value.Boolean != nil:
+		return strconv.FormatBool(*value.Boolean), true
+	case value.Type == workapiv1.JsonRaw && value.JsonRaw != nil:
+		return *value.JsonRaw, true
+	}
+	return "", false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,216 @@
+package statussummarycontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+func newWork(cluster string, labels map[string]string, available metav1.ConditionStatus, image string) *workapiv1.ManifestWork {
+	work := &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "work",
+			Namespace: cluster,
+			Labels:    labels,
+		},
+	}
+	if len(available) > 0 {
+		work.Status.Conditions = []metav1.Condition{{Type: workapiv1.WorkAvailable, Status: available}}
+	}
+	if len(image) > 0 {
+		work.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+			{
+				StatusFeedbacks: workapiv1.StatusFeedbackResult{
+					Values: []workapiv1.FeedbackValue{
+						{Name: "image", Value: workapiv1.FieldValue{Type: workapiv1.String, String: ptr.To(image)}},
+						{Name: "replicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: ptr.To[int64](1)}},
+					},
+				},
+			},
+		}
+	}
+	return work
+}
+
+const summaryNamespace = "open-cluster-management-hub"
+
+func newSummaryConfigMap(namespace string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "summary",
+			Namespace: namespace,
+			Labels:    map[string]string{StatusSummaryLabelKey: ""},
+		},
+		Data: data,
+	}
+}
+
+func TestSummarizeManifestWorks(t *testing.T) {
+	works := []*workapiv1.ManifestWork{
+		newWork("cluster1", nil, metav1.ConditionTrue, "app:v1"),
+		newWork("cluster2", nil, metav1.ConditionTrue, "app:v2"),
+		newWork("cluster3", nil, metav1.ConditionFalse, "app:v1"),
+		newWork("cluster4", nil, "", ""),
+	}
+
+	summary := summarizeManifestWorks(works, []string{workapiv1.WorkAvailable}, []string{"image"})
+	expected := &StatusSummary{
+		Total: 4,
+		Conditions: map[string]ConditionSummary{
+			workapiv1.WorkAvailable: {True: 2, False: 1, Unknown: 1},
+		},
+		FeedbackGroups: map[string][]FeedbackGroup{
+			"image": {
+				{Value: "app:v1", Count: 2, Clusters: []string{"cluster1", "cluster3"}},
+				{Value: NoValue, Count: 1, Clusters: []string{"cluster4"}},
+				{Value: "app:v2", Count: 1, Clusters: []string{"cluster2"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("expected summary %v, got %v", expected, summary)
+	}
+}
+
+func TestSummarizeManifestWorksOmitGroups(t *testing.T) {
+	var works []*workapiv1.ManifestWork
+	for i := 0; i < maxGroupsPerFeedback+2; i++ {
+		works = append(works, newWork(fmt.Sprintf("cluster%d", i), nil, metav1.ConditionTrue, fmt.Sprintf("app:v%d", i)))
+	}
+
+	summary := summarizeManifestWorks(works, []string{workapiv1.WorkAvailable}, []string{"image"})
+	if len(summary.FeedbackGroups["image"]) != maxGroupsPerFeedback {
+		t.Errorf("expected %d groups, got %d", maxGroupsPerFeedback, len(summary.FeedbackGroups["image"]))
+	}
+	if summary.OmittedGroups["image"] != 2 {
+		t.Errorf("expected 2 groups omitted, got %v", summary.OmittedGroups)
+	}
+}
+
+func TestSync(t *testing.T) {
+	mwrsLabels := map[string]string{
+		manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey: "default.mwrs",
+	}
+	appLabels := map[string]string{"app": "test"}
+
+	cases := []struct {
+		name            string
+		configMap       *corev1.ConfigMap
+		mwrsWorks       []runtime.Object
+		works           []runtime.Object
+		expectedSummary *StatusSummary
+	}{
+		{
+			name: "summary of manifestworkreplicaset",
+			configMap: newSummaryConfigMap(summaryNamespace,
+				map[string]string{ManifestWorkReplicaSetKey: "default/mwrs", ConditionTypesKey: "Available"}),
+			mwrsWorks: []runtime.Object{
+				newWork("cluster1", mwrsLabels, metav1.ConditionTrue, "app:v1"),
+				newWork("cluster2", map[string]string{
+					manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey: "default.other",
+				}, metav1.ConditionTrue, "app:v1"),
+			},
+			expectedSummary: &StatusSummary{
+				Total:      1,
+				Conditions: map[string]ConditionSummary{workapiv1.WorkAvailable: {True: 1}},
+			},
+		},
+		{
+			name: "summary of label selected manifestworks",
+			configMap: newSummaryConfigMap(summaryNamespace, map[string]string{
+				ManifestWorkSelectorKey: "app=test", GroupByKey: "replicas", ConditionTypesKey: "Available"}),
+			works: []runtime.Object{
+				newWork("cluster1", appLabels, metav1.ConditionTrue, "app:v1"),
+				newWork("cluster2", appLabels, metav1.ConditionFalse, "app:v2"),
+				newWork("cluster3", nil, metav1.ConditionFalse, "app:v2"),
+			},
+			expectedSummary: &StatusSummary{
+				Total:      2,
+				Conditions: map[string]ConditionSummary{workapiv1.WorkAvailable: {True: 1, False: 1}},
+				FeedbackGroups: map[string][]FeedbackGroup{
+					"replicas": {{Value: "1", Count: 2, Clusters: []string{"cluster1", "cluster2"}}},
+				},
+			},
+		},
+		{
+			name:            "invalid summary",
+			configMap:       newSummaryConfigMap(summaryNamespace, nil),
+			expectedSummary: &StatusSummary{Error: "one of manifestWorkReplicaSet and manifestWorkSelector must be set"},
+		},
+		{
+			name:            "too many feedback names",
+			configMap:       newSummaryConfigMap(summaryNamespace, map[string]string{ManifestWorkSelectorKey: "app=test", GroupByKey: "a,b,c,d,e,f,g,h,i,j,k"}),
+			expectedSummary: &StatusSummary{Error: "at most 10 feedback names can be set in groupBy"},
+		},
+		{
+			name:      "configmap out of the controller namespace",
+			configMap: newSummaryConfigMap("default", map[string]string{ManifestWorkSelectorKey: "app=test"}),
+			works: []runtime.Object{
+				newWork("cluster1", appLabels, metav1.ConditionTrue, "app:v1"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := fakekube.NewSimpleClientset(c.configMap)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(c.configMap); err != nil {
+				t.Fatal(err)
+			}
+
+			workClient := fakeworkclient.NewSimpleClientset(c.works...)
+			workInformerFactory := workinformers.NewSharedInformerFactory(fakeworkclient.NewSimpleClientset(), 10*time.Minute)
+			for _, work := range c.mwrsWorks {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &statusSummaryController{
+				namespace:          summaryNamespace,
+				kubeClient:         kubeClient,
+				workClient:         workClient,
+				configMapLister:    kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister(),
+			}
+			syncCtx := testingcommon.NewFakeSyncContext(t, c.configMap.Namespace+"/"+c.configMap.Name)
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Fatal(err)
+			}
+
+			actions := kubeClient.Actions()
+			if c.expectedSummary == nil {
+				testingcommon.AssertNoActions(t, actions)
+				return
+			}
+			testingcommon.AssertActions(t, actions, "update")
+			cm := actions[0].(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap)
+			summary := &StatusSummary{}
+			if err := json.Unmarshal([]byte(cm.Data[SummaryKey]), summary); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(summary, c.expectedSummary) {
+				t.Errorf("expected summary %v, got %v", c.expectedSummary, summary)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
 	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
@@ -13,6 +15,7 @@ import (
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 	workv1informer "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/options"
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work"
@@ -20,7 +23,10 @@ import (
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/store"
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkgarbagecollectioncontroller"
 	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/statussummarycontroller"
 )
 
 const sourceID = "mwrsctrl"
@@ -70,6 +76,9 @@ func (c *WorkHubManagerConfig) RunWorkHubManager(ctx context.Context, controller
 
 	var workClient workclientset.Interface
 	var watcherStore *store.SourceInformerWatcherStore
+	// the garbage collection of the finished manifestworks watches all the manifestworks on the hub, it is only
+	// enabled with the kube driver since the manifestworks of cloudevents drivers are not stored on the hub.
+	var gcWorkInformer workv1informer.ManifestWorkInformer
 
 	if c.workOptions.WorkDriver == "kube" {
 		config := controllerContext.KubeConfig
@@ -84,6 +93,7 @@ func (c *WorkHubManagerConfig) RunWorkHubManager(ctx context.Context, controller
 		if err != nil {
 			return err
 		}
+		gcWorkInformer = workinformers.NewSharedInformerFactory(workClient, 30*time.Minute).Work().V1().ManifestWorks()
 	} else {
 		// For cloudevents drivers, we build ManifestWork client that implements the
 		// ManifestWorkInterface and ManifestWork informer based on different driver configuration.
@@ -123,6 +133,7 @@ func (c *WorkHubManagerConfig) RunWorkHubManager(ctx context.Context, controller
 		replicaSetsClient,
 		workClient,
 		informer,
+		gcWorkInformer,
 		clusterInformerFactory,
 	)
 }
@@ -133,23 +144,75 @@ func RunControllerManagerWithInformers(
 	replicaSetClient workclientset.Interface,
 	workClient workclientset.Interface,
 	workInformer workv1informer.ManifestWorkInformer,
+	gcWorkInformer workv1informer.ManifestWorkInformer,
 	clusterInformers clusterinformers.SharedInformerFactory,
 ) error {
 	replicaSetInformerFactory := workinformers.NewSharedInformerFactory(replicaSetClient, 30*time.Minute)
 
+	kubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	// only watch the configmaps requesting the status summaries in the controller namespace
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute,
+		kubeinformers.WithNamespace(controllerContext.OperatorNamespace),
+		kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+			selector := &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      statussummarycontroller.StatusSummaryLabelKey,
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			}
+			listOptions.LabelSelector = metav1.FormatLabelSelector(selector)
+		}))
+
 	manifestWorkReplicaSetController := manifestworkreplicasetcontroller.NewManifestWorkReplicaSetController(
 		controllerContext.EventRecorder,
+		kubeClient,
 		replicaSetClient,
 		workapplier.NewWorkApplierWithTypedClient(workClient, workInformer.Lister()),
 		replicaSetInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets(),
 		workInformer,
 		clusterInformers.Cluster().V1beta1().Placements(),
 		clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		encryption.NewManifestEncryptor(kubeClient, controllerContext.OperatorNamespace,
+			func(ctx context.Context, name string) (*clusterv1.ManagedCluster, error) {
+				return clusterInformers.Cluster().V1().ManagedClusters().Lister().Get(name)
+			}),
+	)
+
+	statusSummaryController := statussummarycontroller.NewStatusSummaryController(
+		controllerContext.EventRecorder,
+		controllerContext.OperatorNamespace,
+		kubeClient,
+		workClient,
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		workInformer,
 	)
 
+	if gcWorkInformer != nil {
+		garbageCollectionController, err := manifestworkgarbagecollectioncontroller.NewManifestWorkGarbageCollectionController(
+			controllerContext.EventRecorder,
+			workClient,
+			gcWorkInformer,
+			replicaSetInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets(),
+		)
+		if err != nil {
+			return err
+		}
+		go gcWorkInformer.Informer().Run(ctx.Done())
+		go garbageCollectionController.Run(ctx, 1)
+	}
+
 	go clusterInformers.Start(ctx.Done())
 	go replicaSetInformerFactory.Start(ctx.Done())
+	go kubeInformerFactory.Start(ctx.Done())
 	go manifestWorkReplicaSetController.Run(ctx, 5)
+	go statusSummaryController.Run(ctx, 1)
 
 	go workInformer.Informer().Run(ctx.Done())
 
@@ -2,6 +2,7 @@ package conditions
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"slices"
@@ -48,6 +49,12 @@ func NewConditionReader() (*ConditionReader, error) {
 	}, nil
 }
 
+// WithWellKnownConditionResolver sets the resolver of the well known condition rules.
+func (s *ConditionReader) WithWellKnownConditionResolver(resolver rules.WellKnownConditionRuleResolver) *ConditionReader {
+	s.wellKnownConditions = resolver
+	return s
+}
+
 func (s *ConditionReader) EvaluateConditions(ctx context.Context, obj *unstructured.Unstructured, rules []workapiv1.ConditionRule) []metav1.Condition {
 	var conditionResults []metav1.Condition
 	remainingBudget := globalCostBudget
@@ -174,6 +181,32 @@ func (s *ConditionReader) evaluateCelExpressions(
 	return metav1.ConditionTrue, workapiv1.ConditionRuleEvaluated, remainingBudget, nil
 }
 
+// EvaluateExpression evaluates a CEL expression with the object in the environment of the condition rules, it
+// returns the result and the remaining budget.
+func (s *ConditionReader) EvaluateExpression(
+	ctx context.Context, obj *unstructured.Unstructured, expression string, budget int64,
+) (ref.Val, int64, error) {
+	ast, iss := s.ruleEnv.Compile(expression)
+	if iss.Err() != nil {
+		// Trim CEL code snippets out of message
+		return nil, budget, errors.New(strings.Split(iss.String(), "\n | ")[0])
+	}
+
+	prg, err := s.ruleEnv.Program(
+		ast,
+		cel.CostLimit(celconfig.PerCallLimit),
+		cel.CostTracking(newEstimator()),
+		cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+	)
+	if err != nil {
+		return nil, budget, err
+	}
+
+	return evaluate(ctx, prg, budget, expression, map[string]any{
+		"object": obj.Object,
+	})
+}
+
 func (s *ConditionReader) getConditionMessageByRule(
 	ctx context.Context, obj *unstructured.Unstructured, rule workapiv1.ConditionRule, result bool, budget int64,
 ) (string, int64, error) {
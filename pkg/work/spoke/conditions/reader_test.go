@@ -36,6 +36,33 @@ const (
 		}
 	}
 	`
+	pvcJsonPending = `
+	{
+		"apiVersion":"v1",
+		"kind":"PersistentVolumeClaim",
+		"metadata":{
+			"name":"test"
+		},
+		"status":{
+			"phase":"Pending"
+		}
+	}
+	`
+	serviceJsonLoadBalancer = `
+	{
+		"apiVersion":"v1",
+		"kind":"Service",
+		"metadata":{
+			"name":"test"
+		},
+		"spec":{
+			"type":"LoadBalancer"
+		},
+		"status":{
+			"loadBalancer":{}
+		}
+	}
+	`
 	jobJsonComplete = `
 	{
 		"apiVersion": "batch/v1",
@@ -267,6 +294,39 @@ func TestConditionReader(t *testing.T) {
 				Message: "should work",
 			},
 		},
+		{
+			name:   "Deployment available",
+			object: unstrctureObject(deploymentJson),
+			rule:   workapiv1.ConditionRule{Type: workapiv1.WellKnownConditionsType, Condition: workapiv1.ManifestAvailable},
+			expectedCondition: metav1.Condition{
+				Type:    workapiv1.ManifestAvailable,
+				Status:  metav1.ConditionTrue,
+				Reason:  workapiv1.ConditionRuleEvaluated,
+				Message: "Deployment is available",
+			},
+		},
+		{
+			name:   "PVC pending",
+			object: unstrctureObject(pvcJsonPending),
+			rule:   workapiv1.ConditionRule{Type: workapiv1.WellKnownConditionsType, Condition: workapiv1.ManifestAvailable},
+			expectedCondition: metav1.Condition{
+				Type:    workapiv1.ManifestAvailable,
+				Status:  metav1.ConditionFalse,
+				Reason:  workapiv1.ConditionRuleEvaluated,
+				Message: "PersistentVolumeClaim is in phase Pending",
+			},
+		},
+		{
+			name:   "LoadBalancer service not provisioned",
+			object: unstrctureObject(serviceJsonLoadBalancer),
+			rule:   workapiv1.ConditionRule{Type: workapiv1.WellKnownConditionsType, Condition: workapiv1.ManifestAvailable},
+			expectedCondition: metav1.Condition{
+				Type:    workapiv1.ManifestAvailable,
+				Status:  metav1.ConditionFalse,
+				Reason:  workapiv1.ConditionRuleEvaluated,
+				Message: "LoadBalancer of Service is not provisioned",
+			},
+		},
 		{
 			name:   "Job complete",
 			object: unstrctureObject(jobJsonComplete),
@@ -1,6 +1,8 @@
 package rules
 
 import (
+	"sync"
+
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	workapiv1 "open-cluster-management.io/api/work/v1"
@@ -30,20 +32,216 @@ var podCompleteRule = workapiv1.ConditionRule{
 	MessageExpression: `"Pod is in phase " + object.status.phase`,
 }
 
+var deploymentAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		`hasConditions(object.status)
+			? object.status.conditions.exists(c, c.type == 'Available' && c.status == 'True')
+			: false`,
+	},
+	MessageExpression: `result ? "Deployment is available" : "Deployment is not available"`,
+}
+
+var statefulsetAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		`has(object.spec.replicas) && object.spec.replicas == 0 ||
+			has(object.status.readyReplicas) && object.status.readyReplicas >= (has(object.spec.replicas) ? object.spec.replicas : 1)`,
+	},
+	MessageExpression: `result ? "StatefulSet is available" : "StatefulSet is not available"`,
+}
+
+var daemonsetAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		`has(object.status.desiredNumberScheduled) &&
+			(has(object.status.numberAvailable) ? object.status.numberAvailable : 0) >= object.status.desiredNumberScheduled`,
+	},
+	MessageExpression: `result ? "DaemonSet is available" : "DaemonSet is not available"`,
+}
+
+var serviceAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		`!has(object.spec.type) || object.spec.type != 'LoadBalancer' ||
+			has(object.status.loadBalancer.ingress) && size(object.status.loadBalancer.ingress) > 0`,
+	},
+	MessageExpression: `result ? "Service is available" : "LoadBalancer of Service is not provisioned"`,
+}
+
+var pvcAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		"has(object.status.phase) && object.status.phase == 'Bound'",
+	},
+	MessageExpression: `has(object.status.phase) ? "PersistentVolumeClaim is in phase " + object.status.phase : "PersistentVolumeClaim is pending"`,
+}
+
+var ingressAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		"has(object.status.loadBalancer.ingress) && size(object.status.loadBalancer.ingress) > 0",
+	},
+	MessageExpression: `result ? "Ingress is available" : "LoadBalancer of Ingress is not provisioned"`,
+}
+
+var crdAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		`hasConditions(object.status)
+			? object.status.conditions.exists(c, c.type == 'Established' && c.status == 'True')
+			: false`,
+	},
+	MessageExpression: `result ? "CustomResourceDefinition is established" : "CustomResourceDefinition is not established"`,
+}
+
+var hpaAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		`hasConditions(object.status)
+			? object.status.conditions.exists(c, c.type == 'AbleToScale' && c.status == 'True')
+			: false`,
+	},
+	MessageExpression: `result ? "HorizontalPodAutoscaler is able to scale" : "HorizontalPodAutoscaler is not able to scale"`,
+}
+
+// readyConditionAvailableRule is for the resources reporting the readiness with a Ready condition, e.g. the
+// resources of cert-manager and flux.
+var readyConditionAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		`hasConditions(object.status)
+			? object.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')
+			: false`,
+	},
+	MessageExpression: `result ? "Resource is ready" : "Resource is not ready"`,
+}
+
+var argoApplicationAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		"has(object.status.health.status) && object.status.health.status == 'Healthy'",
+	},
+	MessageExpression: `has(object.status.health.status) ? "Application is " + object.status.health.status : "Application health is unknown"`,
+}
+
+var olmCSVAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		"has(object.status.phase) && object.status.phase == 'Succeeded'",
+	},
+	MessageExpression: `has(object.status.phase) ? "ClusterServiceVersion is in phase " + object.status.phase : "ClusterServiceVersion is pending"`,
+}
+
+var olmSubscriptionAvailableRule = workapiv1.ConditionRule{
+	Condition: workapiv1.ManifestAvailable,
+	Type:      workapiv1.CelConditionExpressionsType,
+	CelExpressions: []string{
+		"has(object.status.state) && object.status.state == 'AtLatestKnown'",
+	},
+	MessageExpression: `has(object.status.state) ? "Subscription is in state " + object.status.state : "Subscription is pending"`,
+}
+
 func DefaultWellKnownConditionResolver() WellKnownConditionRuleResolver {
-	return &defaultWellKnownConditionResolver{
+	return NewWellKnownConditionResolver()
+}
+
+// NewWellKnownConditionResolver returns a resolver with the built-in well known condition rules.
+func NewWellKnownConditionResolver() *DefaultWellKnownConditionRuleResolver {
+	return &DefaultWellKnownConditionRuleResolver{
 		rules: map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule{
 			{Group: "batch", Version: "v1", Kind: "Job"}: {workapiv1.ManifestComplete: jobCompleteRule},
 			{Group: "", Version: "v1", Kind: "Pod"}:      {workapiv1.ManifestComplete: podCompleteRule},
+			{Group: "apps", Version: "v1", Kind: "Deployment"}: {
+				workapiv1.ManifestAvailable: deploymentAvailableRule,
+			},
+			{Group: "apps", Version: "v1", Kind: "StatefulSet"}: {
+				workapiv1.ManifestAvailable: statefulsetAvailableRule,
+			},
+			{Group: "apps", Version: "v1", Kind: "DaemonSet"}: {
+				workapiv1.ManifestAvailable: daemonsetAvailableRule,
+			},
+			{Group: "", Version: "v1", Kind: "Service"}: {
+				workapiv1.ManifestAvailable: serviceAvailableRule,
+			},
+			{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"}: {
+				workapiv1.ManifestAvailable: pvcAvailableRule,
+			},
+			{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}: {
+				workapiv1.ManifestAvailable: ingressAvailableRule,
+			},
+			{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}: {
+				workapiv1.ManifestAvailable: crdAvailableRule,
+			},
+			{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}: {
+				workapiv1.ManifestAvailable: hpaAvailableRule,
+			},
+			{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}: {
+				workapiv1.ManifestAvailable: readyConditionAvailableRule,
+			},
+			{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}: {
+				workapiv1.ManifestAvailable: readyConditionAvailableRule,
+			},
+			{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}: {
+				workapiv1.ManifestAvailable: readyConditionAvailableRule,
+			},
+			{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"}: {
+				workapiv1.ManifestAvailable: readyConditionAvailableRule,
+			},
+			{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"}: {
+				workapiv1.ManifestAvailable: readyConditionAvailableRule,
+			},
+			{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"}: {
+				workapiv1.ManifestAvailable: readyConditionAvailableRule,
+			},
+			{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}: {
+				workapiv1.ManifestAvailable: argoApplicationAvailableRule,
+			},
+			{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "Subscription"}: {
+				workapiv1.ManifestAvailable: olmSubscriptionAvailableRule,
+			},
+			{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "ClusterServiceVersion"}: {
+				workapiv1.ManifestAvailable: olmCSVAvailableRule,
+			},
 		},
 	}
 }
 
-type defaultWellKnownConditionResolver struct {
+// DefaultWellKnownConditionRuleResolver resolves the built-in well known condition rules. Additional rules can
+// be set at runtime by SetCustomRules, a custom rule overrides the built-in rule of the same condition.
+type DefaultWellKnownConditionRuleResolver struct {
 	rules map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule
+
+	lock        sync.RWMutex
+	customRules map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule
 }
 
-func (w *defaultWellKnownConditionResolver) GetRuleByKindCondition(gvk schema.GroupVersionKind, condition string) workapiv1.ConditionRule {
+// SetCustomRules replaces the custom condition rules of the resolver.
+func (w *DefaultWellKnownConditionRuleResolver) SetCustomRules(rules map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.customRules = rules
+}
+
+func (w *DefaultWellKnownConditionRuleResolver) GetRuleByKindCondition(gvk schema.GroupVersionKind, condition string) workapiv1.ConditionRule {
+	w.lock.RLock()
+	customRule, ok := w.customRules[gvk][condition]
+	w.lock.RUnlock()
+	if ok {
+		return customRule
+	}
+
 	if conditionRules, ok := w.rules[gvk]; ok {
 		return conditionRules[condition]
 	}
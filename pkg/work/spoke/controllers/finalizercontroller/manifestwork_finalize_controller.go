@@ -12,12 +12,14 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
 	workinformer "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
 	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
+	commonhelper "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/common/queue"
 	"open-cluster-management.io/ocm/pkg/work/helper"
 )
@@ -29,6 +31,7 @@ type ManifestWorkFinalizeController struct {
 	appliedManifestWorkClient workv1client.AppliedManifestWorkInterface
 	appliedManifestWorkLister worklister.AppliedManifestWorkLister
 	hubHash                   string
+	freezeFilter              *commonhelper.ClusterFreezeFilter
 	rateLimiter               workqueue.RateLimiter
 }
 
@@ -40,6 +43,7 @@ func NewManifestWorkFinalizeController(
 	appliedManifestWorkClient workv1client.AppliedManifestWorkInterface,
 	appliedManifestWorkInformer workinformer.AppliedManifestWorkInformer,
 	hubHash string,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
 ) factory.Controller {
 
 	controller := &ManifestWorkFinalizeController{
@@ -53,13 +57,19 @@ func NewManifestWorkFinalizeController(
 		rateLimiter:               workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
 	}
 
-	return factory.New().
+	controllerFactory := factory.New().
 		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, manifestWorkInformer.Informer()).
 		WithFilteredEventsInformersQueueKeyFunc(
 			helper.AppliedManifestworkQueueKeyFunc(hubHash),
 			helper.AppliedManifestworkHubHashFilter(hubHash),
-			appliedManifestWorkInformer.Informer()).
-		WithSync(controller.sync).ToController("ManifestWorkFinalizer", recorder)
+			appliedManifestWorkInformer.Informer())
+	if clusterInformer != nil {
+		controller.freezeFilter = commonhelper.NewClusterFreezeFilter(clusterInformer.Lister())
+		controllerFactory = controllerFactory.WithInformersQueueKeysFunc(
+			helper.ClusterFreezeQueueKeysFunc(manifestWorkLister), clusterInformer.Informer())
+	}
+
+	return controllerFactory.WithSync(controller.sync).ToController("ManifestWorkFinalizer", recorder)
 }
 
 func (m *ManifestWorkFinalizeController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
@@ -77,6 +87,12 @@ func (m *ManifestWorkFinalizeController) sync(ctx context.Context, controllerCon
 	case err != nil:
 		return err
 	case !manifestWork.DeletionTimestamp.IsZero():
+		// hold the deletion of the resources on the frozen cluster, it is resynced when the cluster is unfrozen.
+		if !commonhelper.IsFreezeExempted(manifestWork) &&
+			m.freezeFilter.IsFrozen(manifestWork.Namespace, manifestWork.Annotations) {
+			klog.V(2).Infof("The deletion of ManifestWork %q is held by the frozen cluster", manifestWorkName)
+			return nil
+		}
 		err := m.deleteAppliedManifestWork(ctx, appliedManifestWorkName)
 		if err != nil {
 			return err
@@ -11,11 +11,16 @@ import (
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/util/workqueue"
 
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
+	commonhelper "open-cluster-management.io/ocm/pkg/common/helpers"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 )
 
@@ -27,6 +32,7 @@ func TestSyncManifestWorkController(t *testing.T) {
 		workName                           string
 		work                               *workapiv1.ManifestWork
 		appliedWork                        *workapiv1.AppliedManifestWork
+		frozen                             bool
 		validateAppliedManifestWorkActions func(t *testing.T, actions []clienttesting.Action)
 		validateManifestWorkActions        func(t *testing.T, actions []clienttesting.Action)
 		expectedQueueLen                   int
@@ -160,6 +166,78 @@ func TestSyncManifestWorkController(t *testing.T) {
 			validateManifestWorkActions:        testingcommon.AssertNoActions,
 			expectedQueueLen:                   0,
 		},
+		{
+			name:     "hold deletion when cluster is frozen",
+			workName: "work",
+			work: &workapiv1.ManifestWork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "work",
+					Namespace:         "cluster1",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{workapiv1.ManifestWorkFinalizer},
+				},
+			},
+			appliedWork: &workapiv1.AppliedManifestWork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("%s-work", hubHash),
+				},
+			},
+			frozen:                             true,
+			validateAppliedManifestWorkActions: testingcommon.AssertNoActions,
+			validateManifestWorkActions:        testingcommon.AssertNoActions,
+			expectedQueueLen:                   0,
+		},
+		{
+			name:     "delete applied work when freeze is overridden",
+			workName: "work",
+			work: &workapiv1.ManifestWork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "work",
+					Namespace:         "cluster1",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{workapiv1.ManifestWorkFinalizer},
+					Annotations: map[string]string{
+						commonhelper.FreezeOverrideAnnotationKey: "true",
+						commonhelper.FreezeAuditAnnotationKey:    `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`,
+					},
+				},
+			},
+			appliedWork: &workapiv1.AppliedManifestWork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("%s-work", hubHash),
+				},
+			},
+			frozen: true,
+			validateAppliedManifestWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateManifestWorkActions: testingcommon.AssertNoActions,
+			expectedQueueLen:            1,
+		},
+		{
+			name:     "delete applied work of the addon when cluster is frozen",
+			workName: "addon-test-deploy-0",
+			work: &workapiv1.ManifestWork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "addon-test-deploy-0",
+					Namespace:         "cluster1",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{workapiv1.ManifestWorkFinalizer},
+					Labels:            map[string]string{addonv1alpha1.AddonLabelKey: "test"},
+				},
+			},
+			appliedWork: &workapiv1.AppliedManifestWork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("%s-addon-test-deploy-0", hubHash),
+				},
+			},
+			frozen: true,
+			validateAppliedManifestWorkActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+			validateManifestWorkActions: testingcommon.AssertNoActions,
+			expectedQueueLen:            1,
+		},
 	}
 
 	for _, c := range cases {
@@ -182,6 +260,17 @@ func TestSyncManifestWorkController(t *testing.T) {
 				hubHash:                   hubHash,
 				rateLimiter:               workqueue.NewItemExponentialFailureRateLimiter(0, 1*time.Second),
 			}
+			if c.frozen {
+				clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fakeclusterclient.NewSimpleClientset(), 5*time.Minute)
+				cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cluster1",
+					Labels: map[string]string{commonhelper.ClusterFrozenLabelKey: "true"},
+				}}
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+				controller.freezeFilter = commonhelper.NewClusterFreezeFilter(clusterInformerFactory.Cluster().V1().ManagedClusters().Lister())
+			}
 
 			controllerContext := testingcommon.NewFakeSyncContext(t, c.workName)
 			err := controller.sync(context.TODO(), controllerContext)
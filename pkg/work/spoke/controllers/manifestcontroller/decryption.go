@@ -0,0 +1,31 @@
+package manifestcontroller
+
+import (
+	"fmt"
+
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+)
+
+// decryptManifest returns the original manifest of an encrypted manifest with the private key in the key secret
+// of the agent namespace. The raw manifest is returned directly if it is not encrypted.
+func decryptManifest(keySecretLister corev1listers.SecretNamespaceLister, raw []byte) ([]byte, error) {
+	if !encryption.IsEncryptedManifest(raw) {
+		return raw, nil
+	}
+
+	if keySecretLister == nil {
+		return nil, fmt.Errorf("the manifest is encrypted, but the manifest decryption is not enabled")
+	}
+	secret, err := keySecretLister.Get(encryption.KeySecretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the encryption key: %w", err)
+	}
+	key, err := encryption.ParsePrivateKey(secret.Data[encryption.PrivateKeyDataKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	return encryption.DecryptManifest(key, raw)
+}
@@ -0,0 +1,90 @@
+package manifestcontroller
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestDecryptManifest(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyData, err := encryption.EncodePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := testingcommon.NewUnstructuredSecret("ns1", "n1", false, "ns1-n1").MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encryption.EncryptManifest(&key.PublicKey, nil, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newLister := func(secrets ...*corev1.Secret) corev1listers.SecretNamespaceLister {
+		informerFactory := kubeinformers.NewSharedInformerFactory(fakekube.NewSimpleClientset(), 10*time.Minute)
+		for _, secret := range secrets {
+			if err := informerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return informerFactory.Core().V1().Secrets().Lister().Secrets("open-cluster-management-agent")
+	}
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: encryption.KeySecretName, Namespace: "open-cluster-management-agent"},
+		Data:       map[string][]byte{encryption.PrivateKeyDataKey: privateKeyData},
+	}
+
+	cases := []struct {
+		name        string
+		lister      corev1listers.SecretNamespaceLister
+		raw         []byte
+		expectedErr bool
+	}{
+		{
+			name: "not encrypted",
+			raw:  raw,
+		},
+		{
+			name:   "decrypt",
+			lister: newLister(keySecret),
+			raw:    encrypted,
+		},
+		{
+			name:        "decryption is not enabled",
+			raw:         encrypted,
+			expectedErr: true,
+		},
+		{
+			name:        "no encryption key",
+			lister:      newLister(),
+			raw:         encrypted,
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := decryptManifest(c.lister, c.raw)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if err == nil && !bytes.Equal(actual, raw) {
+				t.Errorf("expected %s, got %s", raw, actual)
+			}
+		})
+	}
+}
@@ -15,9 +15,11 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
 	workinformer "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
 	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
@@ -38,6 +40,10 @@ var (
 	ResyncInterval = 5 * time.Minute
 )
 
+// WorkClusterFrozen is the condition type of the manifestwork when its changes are held by the freeze of the
+// managed cluster.
+const WorkClusterFrozen = "ClusterFrozen"
+
 type workReconcile interface {
 	reconcile(ctx context.Context, controllerContext factory.SyncContext, mw *workapiv1.ManifestWork,
 		amw *workapiv1.AppliedManifestWork) (*workapiv1.ManifestWork, *workapiv1.AppliedManifestWork, error)
@@ -53,6 +59,8 @@ type ManifestWorkController struct {
 	appliedManifestWorkLister  worklister.AppliedManifestWorkLister
 	hubHash                    string
 	agentID                    string
+	freezeFilter               *commonhelper.ClusterFreezeFilter
+	sourceWatcher              *valueSourceWatcher
 	reconcilers                []workReconcile
 }
 
@@ -69,8 +77,11 @@ func NewManifestWorkController(
 	appliedManifestWorkInformer workinformer.AppliedManifestWorkInformer,
 	hubHash, agentID string,
 	restMapper meta.RESTMapper,
-	validator auth.ExecutorValidator) factory.Controller {
+	validator auth.ExecutorValidator,
+	keySecretLister corev1listers.SecretNamespaceLister,
+	clusterInformer clusterinformerv1.ManagedClusterInformer) factory.Controller {
 
+	sourceWatcher := newValueSourceWatcher(spokeDynamicClient)
 	controller := &ManifestWorkController{
 		manifestWorkPatcher: patcher.NewPatcher[
 			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
@@ -83,11 +94,15 @@ func NewManifestWorkController(
 		appliedManifestWorkLister: appliedManifestWorkInformer.Lister(),
 		hubHash:                   hubHash,
 		agentID:                   agentID,
+		sourceWatcher:             sourceWatcher,
 		reconcilers: []workReconcile{
 			&manifestworkReconciler{
-				restMapper: restMapper,
-				appliers:   apply.NewAppliers(spokeDynamicClient, spokeKubeClient, spokeAPIExtensionClient),
-				validator:  validator,
+				restMapper:      restMapper,
+				dynamicClient:   spokeDynamicClient,
+				appliers:        apply.NewAppliers(spokeDynamicClient, spokeKubeClient, spokeAPIExtensionClient),
+				validator:       validator,
+				keySecretLister: keySecretLister,
+				sourceWatcher:   sourceWatcher,
 			},
 			&appliedManifestWorkReconciler{
 				spokeDynamicClient: spokeDynamicClient,
@@ -96,13 +111,19 @@ func NewManifestWorkController(
 		},
 	}
 
-	return factory.New().
+	controllerFactory := factory.New().
 		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, manifestWorkInformer.Informer()).
 		WithFilteredEventsInformersQueueKeyFunc(
 			helper.AppliedManifestworkQueueKeyFunc(hubHash),
 			helper.AppliedManifestworkHubHashFilter(hubHash),
-			appliedManifestWorkInformer.Informer()).
-		WithSync(controller.sync).ResyncEvery(ResyncInterval).ToController("ManifestWorkAgent", recorder)
+			appliedManifestWorkInformer.Informer())
+	if clusterInformer != nil {
+		controller.freezeFilter = commonhelper.NewClusterFreezeFilter(clusterInformer.Lister())
+		controllerFactory = controllerFactory.WithInformersQueueKeysFunc(
+			helper.ClusterFreezeQueueKeysFunc(manifestWorkLister), clusterInformer.Informer())
+	}
+
+	return controllerFactory.WithSync(controller.sync).ResyncEvery(ResyncInterval).ToController("ManifestWorkAgent", recorder)
 }
 
 // sync is the main reconcile loop for manifest work. It is triggered in two scenarios
@@ -114,7 +135,8 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 
 	oldManifestWork, err := m.manifestWorkLister.Get(manifestWorkName)
 	if apierrors.IsNotFound(err) {
-		// work not found, could have been deleted, do nothing.
+		// work not found, could have been deleted, stop watching the sources of its value references.
+		m.sourceWatcher.watch(ctx, controllerContext.Queue(), manifestWorkName, nil)
 		return nil
 	}
 	if err != nil {
@@ -133,6 +155,22 @@ func (m *ManifestWorkController) sync(ctx context.Context, controllerContext fac
 		return nil
 	}
 
+	// hold the changes of the manifestwork on the frozen cluster, the status is still reported by the status
+	// controllers. The manifestworks owned by the system are decided by the hub.
+	if !commonhelper.IsFreezeExempted(manifestWork) &&
+		m.freezeFilter.IsFrozen(manifestWork.Namespace, manifestWork.Annotations) {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               WorkClusterFrozen,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ClusterFrozen",
+			Message:            "The cluster is frozen, the changes of the manifestwork are held",
+			ObservedGeneration: manifestWork.Generation,
+		})
+		_, err := m.manifestWorkPatcher.PatchStatus(ctx, manifestWork, manifestWork.Status, oldManifestWork.Status)
+		return err
+	}
+	meta.RemoveStatusCondition(&manifestWork.Status.Conditions, WorkClusterFrozen)
+
 	// Apply appliedManifestWork
 	appliedManifestWork, err := m.applyAppliedManifestWork(ctx, manifestWork.Name, m.hubHash, m.agentID)
 	if err != nil {
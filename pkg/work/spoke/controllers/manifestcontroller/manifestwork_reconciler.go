@@ -14,6 +14,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
@@ -34,9 +36,16 @@ type applyResult struct {
 }
 
 type manifestworkReconciler struct {
-	restMapper meta.RESTMapper
-	appliers   *apply.Appliers
-	validator  auth.ExecutorValidator
+	restMapper    meta.RESTMapper
+	dynamicClient dynamic.Interface
+	appliers      *apply.Appliers
+	validator     auth.ExecutorValidator
+
+	// keySecretLister gets the key secret to decrypt the encrypted manifests, it is nil if the manifest
+	// decryption is not enabled.
+	keySecretLister corev1listers.SecretNamespaceLister
+	// sourceWatcher watches the source objects of the value references.
+	sourceWatcher *valueSourceWatcher
 }
 
 func (m *manifestworkReconciler) reconcile(
@@ -50,9 +59,17 @@ func (m *manifestworkReconciler) reconcile(
 	var errs []error
 	// Apply resources on spoke cluster.
 	resourceResults := make([]applyResult, len(manifestWork.Spec.Workload.Manifests))
+	resolver := &valueResolver{
+		restMapper:      m.restMapper,
+		dynamicClient:   m.dynamicClient,
+		results:         resourceResults,
+		manifests:       manifestWork.Spec.Workload.Manifests,
+		keySecretLister: m.keySecretLister,
+	}
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		resourceResults = m.applyManifests(
-			ctx, manifestWork.Spec.Workload.Manifests, manifestWork.Spec, controllerContext.Recorder(), *owner, resourceResults)
+			ctx, manifestWork.Spec.Workload.Manifests, manifestWork.Spec, controllerContext.Recorder(), *owner,
+			resourceResults, resolver)
 
 		for _, result := range resourceResults {
 			if apierrors.IsConflict(result.Error) {
@@ -66,6 +83,9 @@ func (m *manifestworkReconciler) reconcile(
 		klog.Errorf("failed to apply resource with error %v", err)
 	}
 
+	// resolve the value references again once the source objects change
+	m.sourceWatcher.watch(ctx, controllerContext.Queue(), manifestWork.Name, resolver.sources)
+
 	var newManifestConditions []workapiv1.ManifestCondition
 	var requeueTime = ResyncInterval
 	for _, result := range resourceResults {
@@ -121,7 +141,7 @@ func (m *manifestworkReconciler) reconcile(
 		err = utilerrors.NewAggregate(errs)
 	} else if requeueTime != ResyncInterval {
 		err = commonhelper.NewRequeueError(
-			fmt.Sprintf("requeu work %s due to authorization err", manifestWork.Name),
+			fmt.Sprintf("requeue work %s after %v", manifestWork.Name, requeueTime),
 			requeueTime,
 		)
 	}
@@ -135,16 +155,17 @@ func (m *manifestworkReconciler) applyManifests(
 	workSpec workapiv1.ManifestWorkSpec,
 	recorder events.Recorder,
 	owner metav1.OwnerReference,
-	existingResults []applyResult) []applyResult {
+	existingResults []applyResult,
+	resolver *valueResolver) []applyResult {
 
 	for index, manifest := range manifests {
 		switch {
 		case existingResults[index].Result == nil:
 			// Apply if there is no result.
-			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner)
+			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner, resolver)
 		case apierrors.IsConflict(existingResults[index].Error):
 			// Apply if there is a resource conflict error.
-			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner)
+			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner, resolver)
 		}
 	}
 
@@ -157,13 +178,19 @@ func (m *manifestworkReconciler) applyOneManifest(
 	manifest workapiv1.Manifest,
 	workSpec workapiv1.ManifestWorkSpec,
 	recorder events.Recorder,
-	owner metav1.OwnerReference) applyResult {
+	owner metav1.OwnerReference,
+	resolver *valueResolver) applyResult {
 
 	result := applyResult{}
 
 	// parse the required and set resource meta
+	raw, err := decryptManifest(m.keySecretLister, manifest.Raw)
+	if err != nil {
+		result.Error = err
+		return result
+	}
 	required := &unstructured.Unstructured{}
-	if err := required.UnmarshalJSON(manifest.Raw); err != nil {
+	if err := required.UnmarshalJSON(raw); err != nil {
 		result.Error = err
 		return result
 	}
@@ -181,6 +208,12 @@ func (m *manifestworkReconciler) applyOneManifest(
 		return result
 	}
 
+	// set the values of the references before the required object is validated and applied
+	if err := resolver.resolveValueReferences(ctx, required); err != nil {
+		result.Error = err
+		return result
+	}
+
 	// check if the resource to be applied should be owned by the manifest work
 	ownedByTheWork := helper.OwnedByTheWork(gvr, resMeta.Namespace, resMeta.Name, workSpec.DeleteOption)
 
@@ -20,11 +20,15 @@ import (
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
+	commonhelper "open-cluster-management.io/ocm/pkg/common/helpers"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/apply"
@@ -81,6 +85,7 @@ func newController(t *testing.T, work *workapiv1.ManifestWork, appliedWork *work
 
 func (t *testController) toController() *ManifestWorkController {
 	t.mwReconciler.appliers = apply.NewAppliers(t.dynamicClient, t.kubeClient, nil)
+	t.mwReconciler.dynamicClient = t.dynamicClient
 	t.controller.reconcilers = []workReconcile{
 		t.mwReconciler,
 	}
@@ -360,6 +365,40 @@ func TestSync(t *testing.T) {
 	}
 }
 
+// Test the changes are held on the frozen cluster
+func TestSyncFrozenCluster(t *testing.T) {
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fakeclusterclient.NewSimpleClientset(), 5*time.Minute)
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:   "cluster1",
+		Labels: map[string]string{commonhelper.ClusterFrozenLabelKey: "true"},
+	}}
+	if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	work, workKey := spoketesting.NewManifestWork(0, testingcommon.NewUnstructured("v1", "Secret", "ns1", "test"))
+	work.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+	controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).withKubeObject().withUnstructuredObject()
+	controller.controller.freezeFilter = commonhelper.NewClusterFreezeFilter(
+		clusterInformerFactory.Cluster().V1().ManagedClusters().Lister())
+
+	syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+	if err := controller.toController().sync(context.TODO(), syncContext); err != nil {
+		t.Fatal(err)
+	}
+
+	testingcommon.AssertNoActions(t, controller.kubeClient.Actions())
+	testingcommon.AssertActions(t, controller.workClient.Actions(), "patch")
+	p := controller.workClient.Actions()[0].(clienttesting.PatchActionImpl).Patch
+	patchedWork := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(p, patchedWork); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(patchedWork.Status.Conditions, WorkClusterFrozen) {
+		t.Errorf("expected condition %s, got %v", WorkClusterFrozen, patchedWork.Status.Conditions)
+	}
+}
+
 // Test applying resource failed
 func TestFailedToApplyResource(t *testing.T) {
 	tc := newTestCase("multiple create&update resource").
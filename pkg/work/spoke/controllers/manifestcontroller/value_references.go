@@ -0,0 +1,342 @@
+package manifestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/workqueue"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// ValueReferencesAnnotationKey is an annotation key on a manifest of a manifestwork, its value is a json list
+	// of value references. Each reference copies the value of a field of another object on the managed cluster
+	// into a field of the manifest before it is applied, e.g.
+	//	[{"source":{"resource":"services","namespace":"default","name":"web"},
+	//	  "fieldPath":".spec.clusterIP","targetPath":".data.clusterIP"}]
+	// The source must be another manifest of the same manifestwork, so only the objects applied by the
+	// manifestwork are read. The annotation is removed from the manifest before it is applied.
+	ValueReferencesAnnotationKey = "work.open-cluster-management.io/value-references"
+)
+
+// valueReference is a reference from a field of a manifest to a field of another object.
+type valueReference struct {
+	// Source identifies the object whose field value is copied.
+	Source workapiv1.ResourceIdentifier `json:"source"`
+	// FieldPath is the JSONPath of the field of the source object, e.g. .data.ca\.crt
+	FieldPath string `json:"fieldPath"`
+	// TargetPath is the path of the field in the manifest the value is copied to, the fields are separated by
+	// dots and a dot in a field name is escaped with a backslash, e.g. .data.ca\.crt. The path must not go
+	// through a list.
+	TargetPath string `json:"targetPath"`
+}
+
+// valueSource is a source object of the value references.
+type valueSource struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+// valueResolver resolves the value references of the manifests of a manifestwork. The objects applied in the
+// current reconcile are read from the apply results, and the other manifests are read from the managed cluster.
+type valueResolver struct {
+	restMapper    meta.RESTMapper
+	dynamicClient dynamic.Interface
+	results       []applyResult
+
+	// manifests are the manifests of the manifestwork, a source must be one of them.
+	manifests       []workapiv1.Manifest
+	keySecretLister corev1listers.SecretNamespaceLister
+	manifestMetas   []workapiv1.ManifestResourceMeta
+
+	// sources are the source objects of the resolved references, they are watched to resolve the references
+	// again once they change.
+	sources sets.Set[valueSource]
+}
+
+// resolveValueReferences sets the values of the references declared on the required object, and removes the
+// value references annotation from it.
+func (r *valueResolver) resolveValueReferences(ctx context.Context, required *unstructured.Unstructured) error {
+	annotations := required.GetAnnotations()
+	value, ok := annotations[ValueReferencesAnnotationKey]
+	if !ok {
+		return nil
+	}
+	delete(annotations, ValueReferencesAnnotationKey)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	required.SetAnnotations(annotations)
+
+	var references []valueReference
+	if err := json.Unmarshal([]byte(value), &references); err != nil {
+		return fmt.Errorf("invalid value references annotation %s: %v", ValueReferencesAnnotationKey, err)
+	}
+
+	for _, reference := range references {
+		targetFields := splitFieldPath(reference.TargetPath)
+		if len(targetFields) == 0 {
+			return fmt.Errorf("the target path of the value reference to %s is empty", sourceKey(reference.Source))
+		}
+
+		if !r.isManifest(reference.Source) {
+			return fmt.Errorf("the source %s of the value reference is not a manifest of the manifestwork",
+				sourceKey(reference.Source))
+		}
+		source, err := r.getSource(ctx, reference.Source)
+		if err != nil {
+			return err
+		}
+
+		fieldValue, err := findField(source, reference.FieldPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve the field %s of %s: %v", reference.FieldPath, sourceKey(reference.Source), err)
+		}
+
+		if err := unstructured.SetNestedField(required.Object, runtime.DeepCopyJSONValue(fieldValue), targetFields...); err != nil {
+			return fmt.Errorf("failed to set the field %s: %v", reference.TargetPath, err)
+		}
+	}
+
+	return nil
+}
+
+// isManifest returns true if the source is a manifest of the manifestwork. The manifests are only decoded when
+// a reference is resolved the first time.
+func (r *valueResolver) isManifest(source workapiv1.ResourceIdentifier) bool {
+	if r.manifestMetas == nil {
+		r.manifestMetas = []workapiv1.ManifestResourceMeta{}
+		for index, manifest := range r.manifests {
+			raw, err := decryptManifest(r.keySecretLister, manifest.Raw)
+			if err != nil {
+				continue
+			}
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(raw); err != nil {
+				continue
+			}
+			resMeta, _, err := helper.BuildResourceMeta(index, obj, r.restMapper)
+			if err != nil {
+				continue
+			}
+			r.manifestMetas = append(r.manifestMetas, resMeta)
+		}
+	}
+
+	for _, resMeta := range r.manifestMetas {
+		if resMeta.Group == source.Group && resMeta.Resource == source.Resource &&
+			resMeta.Namespace == source.Namespace && resMeta.Name == source.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// getSource returns the source object of a reference. The result of an applied manifest is used if the source is
+// applied in the current reconcile, so the value created by the apply is visible to the following manifests.
+func (r *valueResolver) getSource(ctx context.Context, source workapiv1.ResourceIdentifier) (map[string]interface{}, error) {
+	gvr, err := r.restMapper.ResourceFor(schema.GroupVersionResource{Group: source.Group, Resource: source.Resource})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the resource of %s: %v", sourceKey(source), err)
+	}
+	if r.sources == nil {
+		r.sources = sets.New[valueSource]()
+	}
+	r.sources.Insert(valueSource{gvr: gvr, namespace: source.Namespace, name: source.Name})
+
+	for _, result := range r.results {
+		if result.Result == nil || result.Error != nil {
+			continue
+		}
+		resMeta := result.resourceMeta
+		if resMeta.Group != source.Group || resMeta.Resource != source.Resource ||
+			resMeta.Namespace != source.Namespace || resMeta.Name != source.Name {
+			continue
+		}
+		if obj, ok := result.Result.(*unstructured.Unstructured); ok {
+			return obj.Object, nil
+		}
+		return runtime.DefaultUnstructuredConverter.ToUnstructured(result.Result)
+	}
+
+	obj, err := r.dynamicClient.Resource(gvr).Namespace(source.Namespace).Get(ctx, source.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the source %s of the value reference: %v", sourceKey(source), err)
+	}
+	return obj.Object, nil
+}
+
+// findField returns the single value the JSONPath points to in the object, an error is returned if the field
+// does not exist or is empty.
+func findField(obj map[string]interface{}, fieldPath string) (interface{}, error) {
+	finder := jsonpath.New("valueReference")
+	if err := finder.Parse(fmt.Sprintf("{%s}", fieldPath)); err != nil {
+		return nil, err
+	}
+	results, err := finder.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != 1 || len(results[0]) != 1 {
+		return nil, fmt.Errorf("the field path must point to exactly one value")
+	}
+
+	value := results[0][0].Interface()
+	if value == nil || value == "" {
+		return nil, fmt.Errorf("the field is empty")
+	}
+	return value, nil
+}
+
+// splitFieldPath splits a path like .data.ca\.crt into the fields [data ca.crt].
+func splitFieldPath(path string) []string {
+	var fields []string
+	var field strings.Builder
+	escaped := false
+	for _, c := range strings.TrimPrefix(path, ".") {
+		switch {
+		case escaped:
+			field.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '.':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(c)
+		}
+	}
+	if field.Len() > 0 || len(fields) > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+func sourceKey(source workapiv1.ResourceIdentifier) string {
+	gr := schema.GroupResource{Group: source.Group, Resource: source.Resource}
+	if len(source.Namespace) == 0 {
+		return fmt.Sprintf("%s %s", gr, source.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", gr, source.Namespace, source.Name)
+}
+
+// valueSourceWatcher watches the source objects of the value references, and enqueues the manifestworks
+// referencing a source object once it changes, so the references are resolved again. Each source object is
+// watched with an informer of the single object, which is stopped once no manifestwork references it.
+type valueSourceWatcher struct {
+	dynamicClient dynamic.Interface
+
+	lock    sync.Mutex
+	watches map[valueSource]*valueSourceWatch
+	works   map[string]sets.Set[valueSource]
+}
+
+type valueSourceWatch struct {
+	cancel context.CancelFunc
+	works  sets.Set[string]
+}
+
+func newValueSourceWatcher(dynamicClient dynamic.Interface) *valueSourceWatcher {
+	return &valueSourceWatcher{
+		dynamicClient: dynamicClient,
+		watches:       map[valueSource]*valueSourceWatch{},
+		works:         map[string]sets.Set[valueSource]{},
+	}
+}
+
+// watch replaces the source objects watched for the manifestwork, the manifestwork is added into the queue once
+// any of them changes. The informers are stopped when the ctx is done.
+func (w *valueSourceWatcher) watch(ctx context.Context, queue workqueue.RateLimitingInterface,
+	workName string, sources sets.Set[valueSource]) {
+	if w == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	existing := w.works[workName]
+	for source := range existing.Difference(sources) {
+		sourceWatch := w.watches[source]
+		sourceWatch.works.Delete(workName)
+		if sourceWatch.works.Len() == 0 {
+			sourceWatch.cancel()
+			delete(w.watches, source)
+		}
+	}
+	for source := range sources.Difference(existing) {
+		sourceWatch, ok := w.watches[source]
+		if !ok {
+			sourceWatch = w.startWatch(ctx, queue, source)
+			w.watches[source] = sourceWatch
+		}
+		sourceWatch.works.Insert(workName)
+	}
+
+	if sources.Len() == 0 {
+		delete(w.works, workName)
+		return
+	}
+	w.works[workName] = sources.Clone()
+}
+
+func (w *valueSourceWatcher) startWatch(ctx context.Context, queue workqueue.RateLimitingInterface,
+	source valueSource) *valueSourceWatch {
+	sourceWatch := &valueSourceWatch{works: sets.New[string]()}
+	enqueue := func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		for workName := range sourceWatch.works {
+			queue.Add(workName)
+		}
+	}
+
+	informer := dynamicinformer.NewFilteredDynamicInformer(w.dynamicClient, source.gvr, source.namespace, 0,
+		cache.Indexers{}, func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", source.name).String()
+		})
+	_, _ = informer.Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(_ interface{}, isInInitialList bool) {
+			// the source object is read in the reconcile which starts the watch
+			if !isInInitialList {
+				enqueue()
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSource, oldOk := oldObj.(*unstructured.Unstructured)
+			newSource, newOk := newObj.(*unstructured.Unstructured)
+			if oldOk && newOk && oldSource.GetResourceVersion() == newSource.GetResourceVersion() {
+				return
+			}
+			enqueue()
+		},
+		DeleteFunc: func(_ interface{}) {
+			enqueue()
+		},
+	})
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	sourceWatch.cancel = cancel
+	go informer.Informer().Run(watchCtx.Done())
+	return sourceWatch
+}
@@ -0,0 +1,196 @@
+package manifestcontroller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/util/workqueue"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newObjectWithValueReferences(references string) *unstructured.Unstructured {
+	obj := testingcommon.NewUnstructuredSecret("ns1", "target", false, "")
+	if len(references) > 0 {
+		obj.SetAnnotations(map[string]string{ValueReferencesAnnotationKey: references})
+	}
+	return obj
+}
+
+func newSourceSecret() *unstructured.Unstructured {
+	source := testingcommon.NewUnstructuredSecret("ns1", "source", false, "")
+	if err := unstructured.SetNestedField(source.Object, "Y2E=", "data", "ca.crt"); err != nil {
+		panic(err)
+	}
+	return source
+}
+
+func TestResolveValueReferences(t *testing.T) {
+	reference := `[{"source":{"resource":"secrets","namespace":"ns1","name":"source"},` +
+		`"fieldPath":".data.ca\\.crt","targetPath":".data.ca\\.crt"}]`
+
+	work, _ := spoketesting.NewManifestWork(0, newSourceSecret(), newObjectWithValueReferences(reference))
+
+	cases := []struct {
+		name          string
+		required      *unstructured.Unstructured
+		manifests     []workapiv1.Manifest
+		results       []applyResult
+		spokeObjects  []runtime.Object
+		expectedValue string
+		expectedErr   bool
+	}{
+		{
+			name:     "no references",
+			required: newObjectWithValueReferences(""),
+		},
+		{
+			name:     "source in the apply results",
+			required: newObjectWithValueReferences(reference),
+			results: []applyResult{
+				{
+					Result:       newSourceSecret(),
+					resourceMeta: workapiv1.ManifestResourceMeta{Resource: "secrets", Namespace: "ns1", Name: "source"},
+				},
+			},
+			expectedValue: "Y2E=",
+		},
+		{
+			name:          "source on the managed cluster",
+			required:      newObjectWithValueReferences(reference),
+			spokeObjects:  []runtime.Object{newSourceSecret()},
+			expectedValue: "Y2E=",
+		},
+		{
+			name:         "source not a manifest of the manifestwork",
+			required:     newObjectWithValueReferences(reference),
+			manifests:    []workapiv1.Manifest{},
+			spokeObjects: []runtime.Object{newSourceSecret()},
+			expectedErr:  true,
+		},
+		{
+			name:        "source not found",
+			required:    newObjectWithValueReferences(reference),
+			expectedErr: true,
+		},
+		{
+			name: "field not found",
+			required: newObjectWithValueReferences(`[{"source":{"resource":"secrets","namespace":"ns1","name":"source"},` +
+				`"fieldPath":".data.tls\\.crt","targetPath":".data.ca\\.crt"}]`),
+			spokeObjects: []runtime.Object{newSourceSecret()},
+			expectedErr:  true,
+		},
+		{
+			name:        "invalid annotation",
+			required:    newObjectWithValueReferences("invalid"),
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			manifests := work.Spec.Workload.Manifests
+			if c.manifests != nil {
+				manifests = c.manifests
+			}
+			resolver := &valueResolver{
+				restMapper:    spoketesting.NewFakeRestMapper(),
+				dynamicClient: fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), c.spokeObjects...),
+				results:       c.results,
+				manifests:     manifests,
+			}
+
+			err := resolver.resolveValueReferences(context.TODO(), c.required)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if _, ok := c.required.GetAnnotations()[ValueReferencesAnnotationKey]; ok {
+				t.Errorf("expected the value references annotation is removed")
+			}
+			if err != nil {
+				return
+			}
+
+			value, _, _ := unstructured.NestedString(c.required.Object, "data", "ca.crt")
+			if value != c.expectedValue {
+				t.Errorf("expected value %q, got %q", c.expectedValue, value)
+			}
+			if len(c.expectedValue) > 0 && !resolver.sources.Has(valueSource{
+				gvr: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, namespace: "ns1", name: "source"}) {
+				t.Errorf("expected the source is watched, got %v", resolver.sources)
+			}
+		})
+	}
+}
+
+func TestSplitFieldPath(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected []string
+	}{
+		{path: "", expected: nil},
+		{path: ".spec.clusterIP", expected: []string{"spec", "clusterIP"}},
+		{path: "data.ca\\.crt", expected: []string{"data", "ca.crt"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			if actual := splitFieldPath(c.path); !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValueSourceWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	source := newSourceSecret()
+	dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), source)
+	watcher := newValueSourceWatcher(dynamicClient)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	sourceKey := valueSource{gvr: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, namespace: "ns1", name: "source"}
+
+	watcher.watch(ctx, queue, "work1", sets.New(sourceKey))
+	watcher.watch(ctx, queue, "work2", sets.New(sourceKey))
+	testingcommon.AssertEqualNumber(t, len(watcher.watches), 1)
+
+	// the manifestworks are enqueued once the source changes
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			updated := source.DeepCopy()
+			updated.SetResourceVersion(fmt.Sprintf("%d", time.Now().UnixNano()))
+			if _, err := dynamicClient.Resource(sourceKey.gvr).Namespace("ns1").Update(
+				ctx, updated, metav1.UpdateOptions{}); err != nil {
+				return false, err
+			}
+			return queue.Len() == 2, nil
+		}); err != nil {
+		t.Errorf("expected the manifestworks are enqueued, got %d", queue.Len())
+	}
+
+	// the watch is stopped once no manifestwork references the source
+	watcher.watch(ctx, queue, "work1", nil)
+	testingcommon.AssertEqualNumber(t, len(watcher.watches), 1)
+	watcher.watch(ctx, queue, "work2", sets.New[valueSource]())
+	testingcommon.AssertEqualNumber(t, len(watcher.watches), 0)
+	testingcommon.AssertEqualNumber(t, len(watcher.works), 0)
+
+	// a nil watcher does nothing
+	var nilWatcher *valueSourceWatcher
+	nilWatcher.watch(ctx, queue, "work1", sets.New(sourceKey))
+}
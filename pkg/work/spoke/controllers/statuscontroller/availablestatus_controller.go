@@ -3,6 +3,7 @@ package statuscontroller
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -24,11 +25,14 @@ import (
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	commonhelper "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/common/queue"
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/conditions"
+	conditionrules "open-cluster-management.io/ocm/pkg/work/spoke/conditions/rules"
 	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
+	statusrules "open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
 )
 
 const statusFeedbackConditionType = "StatusFeedbackSynced"
@@ -43,6 +47,7 @@ type AvailableStatusController struct {
 	spokeDynamicClient dynamic.Interface
 	statusReader       *statusfeedback.StatusReader
 	conditionReader    *conditions.ConditionReader
+	healthAggregator   *healthAggregator
 	syncInterval       time.Duration
 }
 
@@ -53,6 +58,9 @@ func NewAvailableStatusController(
 	manifestWorkClient workv1client.ManifestWorkInterface,
 	manifestWorkInformer workinformer.ManifestWorkInformer,
 	manifestWorkLister worklister.ManifestWorkNamespaceLister,
+	statusRuleResolver statusrules.WellKnownStatusRuleResolver,
+	conditionRuleResolver conditionrules.WellKnownConditionRuleResolver,
+	relatedObjectReader *statusfeedback.RelatedObjectReader,
 	maxJSONRawLength int32,
 	syncInterval time.Duration,
 ) (factory.Controller, error) {
@@ -60,6 +68,10 @@ func NewAvailableStatusController(
 	if err != nil {
 		return nil, err
 	}
+	healthAggregator, err := newHealthAggregator()
+	if err != nil {
+		return nil, err
+	}
 
 	controller := &AvailableStatusController{
 		patcher: patcher.NewPatcher[
@@ -68,8 +80,13 @@ func NewAvailableStatusController(
 		manifestWorkLister: manifestWorkLister,
 		spokeDynamicClient: spokeDynamicClient,
 		syncInterval:       syncInterval,
-		statusReader:       statusfeedback.NewStatusReader().WithMaxJsonRawLength(maxJSONRawLength),
-		conditionReader:    conditionReader,
+		statusReader: statusfeedback.NewStatusReader().
+			WithMaxJsonRawLength(maxJSONRawLength).
+			WithWellKnownStatusResolver(statusRuleResolver).
+			WithCelEvaluator(conditionReader).
+			WithRelatedObjectReader(relatedObjectReader),
+		conditionReader:  conditionReader.WithWellKnownConditionResolver(conditionRuleResolver),
+		healthAggregator: healthAggregator,
 	}
 
 	return factory.New().
@@ -113,6 +130,9 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 		return nil
 	}
 
+	// the feedback rules of the types not supported by the manifestConfigs.
+	feedbackRules, feedbackRulesErr := helper.GetFeedbackRules(manifestWork.Annotations)
+
 	// handle status condition of manifests
 	// TODO revist this controller since this might bring races when user change the manifests in spec.
 	for index, manifest := range manifestWork.Status.ResourceStatus.Manifests {
@@ -126,8 +146,21 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 
 		option := helper.FindManifestConfiguration(manifest.ResourceMeta, manifestWork.Spec.ManifestConfigs)
 
-		// Read status of the resource according to feedback rules.
-		values, statusFeedbackCondition := c.getFeedbackValues(obj, option)
+		// Read status of the resource according to feedback rules, the status of the encrypted manifests is not
+		// returned to the hub since it might contain the sensitive data.
+		var values []workapiv1.FeedbackValue
+		var statusFeedbackCondition metav1.Condition
+		if isEncryptedManifest(manifestWork, manifest.ResourceMeta.Ordinal) {
+			statusFeedbackCondition = metav1.Condition{
+				Type:    statusFeedbackConditionType,
+				Reason:  "StatusFeedbackRedacted",
+				Status:  metav1.ConditionTrue,
+				Message: "The status feedback of the encrypted manifest is redacted",
+			}
+		} else {
+			values, statusFeedbackCondition = c.getFeedbackValues(ctx, obj, option,
+				helper.FindFeedbackRules(manifest.ResourceMeta, feedbackRules), feedbackRulesErr)
+		}
 		meta.SetStatusCondition(manifestConditions, statusFeedbackCondition)
 		manifestWork.Status.ResourceStatus.Manifests[index].StatusFeedbacks.Values = values
 
@@ -139,8 +172,14 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 	}
 
 	// aggregate ManifestConditions and update work status condition
-	workAvailableStatusCondition := aggregateManifestConditions(manifestWork.Generation, manifestWork.Status.ResourceStatus.Manifests)
-	meta.SetStatusCondition(&manifestWork.Status.Conditions, workAvailableStatusCondition)
+	c.aggregateWorkConditions(ctx, manifestWork)
+
+	// aggregate the Complete conditions of manifests into the Complete condition of the work
+	if workCompleteStatusCondition := aggregateManifestCompleteConditions(manifestWork); workCompleteStatusCondition != nil {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, *workCompleteStatusCondition)
+	} else {
+		meta.RemoveStatusCondition(&manifestWork.Status.Conditions, helper.WorkComplete)
+	}
 
 	// no work if the status of manifestwork does not change
 	if equality.Semantic.DeepEqual(originalManifestWork.Status.ResourceStatus, manifestWork.Status.ResourceStatus) &&
@@ -153,6 +192,49 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 	return err
 }
 
+// aggregateWorkConditions sets the Available, Progressing and Degraded conditions of the manifestwork with the
+// health aggregation policies of the manifestwork.
+func (c *AvailableStatusController) aggregateWorkConditions(ctx context.Context, manifestWork *workapiv1.ManifestWork) {
+	manifests := manifestWork.Status.ResourceStatus.Manifests
+	aggregation, err := helper.GetHealthAggregation(manifestWork.Annotations)
+	if err != nil {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               workapiv1.WorkAvailable,
+			Status:             metav1.ConditionUnknown,
+			Reason:             "AggregationPolicyInvalid",
+			ObservedGeneration: manifestWork.Generation,
+			Message:            err.Error(),
+		})
+		return
+	}
+	if aggregation == nil {
+		aggregation = &helper.HealthAggregation{}
+	}
+
+	if aggregation.Available == nil {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, aggregateManifestConditions(manifestWork.Generation, manifests))
+	} else {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, c.healthAggregator.aggregate(
+			ctx, manifestWork.Generation, workapiv1.WorkAvailable, aggregation.Available, manifests))
+	}
+
+	for conditionType, policy := range map[string]*helper.AggregationPolicy{
+		workapiv1.WorkProgressing: aggregation.Progressing,
+		workapiv1.WorkDegraded:    aggregation.Degraded,
+	} {
+		if policy != nil {
+			meta.SetStatusCondition(&manifestWork.Status.Conditions, c.healthAggregator.aggregate(
+				ctx, manifestWork.Generation, conditionType, policy, manifests))
+			continue
+		}
+		// remove the condition aggregated before the policy is removed
+		if cond := meta.FindStatusCondition(manifestWork.Status.Conditions, conditionType); cond != nil &&
+			strings.HasPrefix(cond.Reason, "AggregationPolicy") {
+			meta.RemoveStatusCondition(&manifestWork.Status.Conditions, conditionType)
+		}
+	}
+}
+
 // aggregateManifestConditions aggregates status conditions of manifests and returns a status
 // condition for manifestwork
 func aggregateManifestConditions(generation int64, manifests []workapiv1.ManifestCondition) metav1.Condition {
@@ -210,13 +292,81 @@ func aggregateManifestConditions(generation int64, manifests []workapiv1.Manifes
 	}
 }
 
+// aggregateManifestCompleteConditions returns the Complete condition of the manifestwork, or nil if no manifest
+// has a Complete condition rule. The manifestwork is complete when all the manifests with the Complete condition
+// rules are complete. Once complete, the condition is kept until the spec of the manifestwork is changed, since
+// the completed resources might be cleaned up afterward.
+func aggregateManifestCompleteConditions(manifestWork *workapiv1.ManifestWork) *metav1.Condition {
+	if cond := meta.FindStatusCondition(manifestWork.Status.Conditions, helper.WorkComplete); cond != nil &&
+		cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == manifestWork.Generation {
+		return cond
+	}
+
+	complete, total := 0, 0
+	for _, manifest := range manifestWork.Status.ResourceStatus.Manifests {
+		option := helper.FindManifestConfiguration(manifest.ResourceMeta, manifestWork.Spec.ManifestConfigs)
+		if option == nil || !hasCompleteConditionRule(option.ConditionRules) {
+			continue
+		}
+
+		total += 1
+		if meta.IsStatusConditionTrue(manifest.Conditions, workapiv1.ManifestComplete) {
+			complete += 1
+		}
+	}
+
+	switch {
+	case total == 0:
+		return nil
+	case complete < total:
+		return &metav1.Condition{
+			Type:               helper.WorkComplete,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ResourcesNotComplete",
+			ObservedGeneration: manifestWork.Generation,
+			Message:            fmt.Sprintf("%d of %d resources are complete", complete, total),
+		}
+	default:
+		return &metav1.Condition{
+			Type:               helper.WorkComplete,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ResourcesComplete",
+			ObservedGeneration: manifestWork.Generation,
+			Message:            "All resources with the Complete condition rules are complete",
+		}
+	}
+}
+
+func hasCompleteConditionRule(rules []workapiv1.ConditionRule) bool {
+	for _, rule := range rules {
+		if rule.Condition == workapiv1.ManifestComplete {
+			return true
+		}
+	}
+	return false
+}
+
+// isEncryptedManifest checks if the manifest with the ordinal in the spec of the manifestwork is encrypted
+func isEncryptedManifest(manifestWork *workapiv1.ManifestWork, ordinal int32) bool {
+	if ordinal < 0 || int(ordinal) >= len(manifestWork.Spec.Workload.Manifests) {
+		return false
+	}
+	return encryption.IsEncryptedManifest(manifestWork.Spec.Workload.Manifests[ordinal].Raw)
+}
+
 func (c *AvailableStatusController) getFeedbackValues(
+	ctx context.Context,
 	obj *unstructured.Unstructured,
-	option *workapiv1.ManifestConfigOption) ([]workapiv1.FeedbackValue, metav1.Condition) {
+	option *workapiv1.ManifestConfigOption,
+	feedbackRules []helper.FeedbackRule,
+	feedbackRulesErr error) ([]workapiv1.FeedbackValue, metav1.Condition) {
 	var errs []error
 	var values []workapiv1.FeedbackValue
 
-	if option == nil || len(option.FeedbackRules) == 0 {
+	if feedbackRulesErr != nil {
+		errs = append(errs, feedbackRulesErr)
+	}
+	if (option == nil || len(option.FeedbackRules) == 0) && len(feedbackRules) == 0 && len(errs) == 0 {
 		return values, metav1.Condition{
 			Type:   statusFeedbackConditionType,
 			Reason: "NoStatusFeedbackSynced",
@@ -224,14 +374,24 @@ func (c *AvailableStatusController) getFeedbackValues(
 		}
 	}
 
-	for _, rule := range option.FeedbackRules {
-		valuesByRule, err := c.statusReader.GetValuesByRule(obj, rule)
+	if option != nil {
+		for _, rule := range option.FeedbackRules {
+			valuesByRule, err := c.statusReader.GetValuesByRule(ctx, obj, rule)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if len(valuesByRule) > 0 {
+				values = append(values, valuesByRule...)
+			}
+		}
+	}
+
+	if len(feedbackRules) > 0 {
+		valuesByRules, err := c.statusReader.GetValuesByFeedbackRules(ctx, obj, feedbackRules)
 		if err != nil {
 			errs = append(errs, err)
 		}
-		if len(valuesByRule) > 0 {
-			values = append(values, valuesByRule...)
-		}
+		values = append(values, valuesByRules...)
 	}
 
 	err := utilerrors.NewAggregate(errs)
@@ -20,8 +20,10 @@ import (
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/conditions"
 	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
 	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
@@ -228,6 +230,7 @@ func TestStatusFeedback(t *testing.T) {
 		name              string
 		existingResources []runtime.Object
 		configOption      []workapiv1.ManifestConfigOption
+		annotations       map[string]string
 		manifests         []workapiv1.ManifestCondition
 		validateActions   func(t *testing.T, actions []clienttesting.Action)
 	}{
@@ -453,13 +456,86 @@ func TestStatusFeedback(t *testing.T) {
 
 			},
 		},
+		{
+			name: "get values by the feedback rules in the annotation",
+			existingResources: []runtime.Object{
+				testingcommon.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", "deploy1",
+					map[string]interface{}{
+						"status": map[string]interface{}{"readyReplicas": int64(2), "replicas": int64(4)},
+					}),
+			},
+			configOption: []workapiv1.ManifestConfigOption{
+				{
+					ResourceIdentifier: workapiv1.ResourceIdentifier{Group: "apps", Resource: "deployments", Name: "deploy1", Namespace: "ns1"},
+					FeedbackRules: []workapiv1.FeedbackRule{
+						{
+							Type:      workapiv1.JSONPathsType,
+							JsonPaths: []workapiv1.JsonPath{{Name: "replicas", Path: ".status.replicas"}},
+						},
+					},
+				},
+			},
+			annotations: map[string]string{helper.FeedbackRulesAnnotationKey: `[{"resourceIdentifier":` +
+				`{"group":"apps","resource":"deployments","namespace":"ns1","name":"deploy1"},"rules":[{"type":"CEL",` +
+				`"name":"readyPercentage","expression":"object.status.readyReplicas * 100 / object.status.replicas"}]}]`},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				p := actions[0].(clienttesting.PatchActionImpl).Patch
+				work := &workapiv1.ManifestWork{}
+				if err := json.Unmarshal(p, work); err != nil {
+					t.Fatal(err)
+				}
+				expectedValues := []workapiv1.FeedbackValue{
+					{Name: "replicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: pointer.Int64(4)}},
+					{Name: "readyPercentage", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: pointer.Int64(50)}},
+				}
+				if !equality.Semantic.DeepEqual(work.Status.ResourceStatus.Manifests[0].StatusFeedbacks.Values, expectedValues) {
+					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests[0].StatusFeedbacks.Values))
+				}
+				if !hasStatusCondition(work.Status.ResourceStatus.Manifests[0].Conditions, statusFeedbackConditionType, metav1.ConditionTrue) {
+					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests[0].Conditions))
+				}
+			},
+		},
+		{
+			name: "invalid feedback rules in the annotation",
+			existingResources: []runtime.Object{
+				testingcommon.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", "deploy1",
+					map[string]interface{}{
+						"status": map[string]interface{}{"replicas": int64(4)},
+					}),
+			},
+			annotations: map[string]string{helper.FeedbackRulesAnnotationKey: `invalid`},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				p := actions[0].(clienttesting.PatchActionImpl).Patch
+				work := &workapiv1.ManifestWork{}
+				if err := json.Unmarshal(p, work); err != nil {
+					t.Fatal(err)
+				}
+				if !hasStatusCondition(work.Status.ResourceStatus.Manifests[0].Conditions, statusFeedbackConditionType, metav1.ConditionFalse) {
+					t.Fatal(spew.Sdump(work.Status.ResourceStatus.Manifests[0].Conditions))
+				}
+			},
+		},
 	}
 
+	conditionReader, err := conditions.NewConditionReader()
+	if err != nil {
+		t.Fatal(err)
+	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			testingWork, _ := spoketesting.NewManifestWork(0)
 			testingWork.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
 			testingWork.Spec.ManifestConfigs = c.configOption
+			testingWork.Annotations = c.annotations
 			testingWork.Status = workapiv1.ManifestWorkStatus{
 				ResourceStatus: workapiv1.ManifestResourceStatus{
 					Manifests: c.manifests,
@@ -473,7 +549,7 @@ func TestStatusFeedback(t *testing.T) {
 			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), c.existingResources...)
 			controller := AvailableStatusController{
 				spokeDynamicClient: fakeDynamicClient,
-				statusReader:       statusfeedback.NewStatusReader(),
+				statusReader:       statusfeedback.NewStatusReader().WithCelEvaluator(conditionReader),
 				patcher: patcher.NewPatcher[
 					*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
 					fakeClient.WorkV1().ManifestWorks(testingWork.Namespace)),
@@ -488,6 +564,75 @@ func TestStatusFeedback(t *testing.T) {
 	}
 }
 
+func TestRedactedStatusFeedback(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy := testingcommon.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", "deploy1",
+		map[string]interface{}{
+			"status": map[string]interface{}{"readyReplicas": int64(2), "replicas": int64(3), "availableReplicas": int64(2)},
+		})
+	raw, err := deploy.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encryption.EncryptManifest(&key.PublicKey, nil, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testingWork, _ := spoketesting.NewManifestWork(0)
+	testingWork.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+	testingWork.Spec.Workload.Manifests = []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: encrypted}}}
+	testingWork.Spec.ManifestConfigs = []workapiv1.ManifestConfigOption{
+		{
+			ResourceIdentifier: workapiv1.ResourceIdentifier{Group: "apps", Resource: "deployments", Name: "deploy1", Namespace: "ns1"},
+			FeedbackRules:      []workapiv1.FeedbackRule{{Type: workapiv1.WellKnownStatusType}},
+		},
+	}
+	testingWork.Status = workapiv1.ManifestWorkStatus{
+		ResourceStatus: workapiv1.ManifestResourceStatus{
+			Manifests: []workapiv1.ManifestCondition{newManifest("apps", "v1", "deployments", "ns1", "deploy1")},
+		},
+		Conditions: []metav1.Condition{
+			{Type: workapiv1.WorkApplied},
+		},
+	}
+
+	fakeClient := fakeworkclient.NewSimpleClientset(testingWork)
+	fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), deploy)
+	controller := AvailableStatusController{
+		spokeDynamicClient: fakeDynamicClient,
+		statusReader:       statusfeedback.NewStatusReader(),
+		patcher: patcher.NewPatcher[
+			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+			fakeClient.WorkV1().ManifestWorks(testingWork.Namespace)),
+	}
+
+	if err := controller.syncManifestWork(context.TODO(), testingWork); err != nil {
+		t.Fatal(err)
+	}
+
+	actions := fakeClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	work := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, work); err != nil {
+		t.Fatal(err)
+	}
+	manifest := work.Status.ResourceStatus.Manifests[0]
+	if len(manifest.StatusFeedbacks.Values) != 0 {
+		t.Errorf("expected the status feedback is redacted, got %s", spew.Sdump(manifest.StatusFeedbacks.Values))
+	}
+	cond := meta.FindStatusCondition(manifest.Conditions, statusFeedbackConditionType)
+	if cond == nil || cond.Reason != "StatusFeedbackRedacted" {
+		t.Errorf("expected the status feedback redacted condition, got %s", spew.Sdump(manifest.Conditions))
+	}
+	if !hasStatusCondition(manifest.Conditions, workapiv1.ManifestAvailable, metav1.ConditionTrue) {
+		t.Errorf("expected the manifest is available, got %s", spew.Sdump(manifest.Conditions))
+	}
+}
+
 func TestConditionRules(t *testing.T) {
 	activeRule := workapiv1.ConditionRule{
 		Type:      workapiv1.CelConditionExpressionsType,
@@ -665,3 +810,93 @@ func hasStatusCondition(conditions []metav1.Condition, conditionType string, sta
 
 	return false
 }
+
+func TestAggregateManifestCompleteConditions(t *testing.T) {
+	completeRule := workapiv1.ConditionRule{
+		Type:      workapiv1.WellKnownConditionsType,
+		Condition: workapiv1.ManifestComplete,
+	}
+	jobOption := workapiv1.ManifestConfigOption{
+		ResourceIdentifier: workapiv1.ResourceIdentifier{Group: "batch", Resource: "jobs", Namespace: "ns1", Name: "job1"},
+		ConditionRules:     []workapiv1.ConditionRule{completeRule},
+	}
+	newJobManifest := func(status metav1.ConditionStatus) workapiv1.ManifestCondition {
+		manifest := newManifest("batch", "v1", "jobs", "ns1", "job1")
+		if len(status) > 0 {
+			manifest.Conditions = []metav1.Condition{{Type: workapiv1.ManifestComplete, Status: status}}
+		}
+		return manifest
+	}
+
+	cases := []struct {
+		name               string
+		configOption       []workapiv1.ManifestConfigOption
+		manifests          []workapiv1.ManifestCondition
+		existingConditions []metav1.Condition
+		expectedStatus     metav1.ConditionStatus
+	}{
+		{
+			name: "no complete condition rule",
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("", "v1", "configmaps", "ns1", "cm1"),
+			},
+		},
+		{
+			name:         "not evaluated",
+			configOption: []workapiv1.ManifestConfigOption{jobOption},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("", "v1", "configmaps", "ns1", "cm1"),
+				newJobManifest(""),
+			},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:         "complete",
+			configOption: []workapiv1.ManifestConfigOption{jobOption},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("", "v1", "configmaps", "ns1", "cm1"),
+				newJobManifest(metav1.ConditionTrue),
+			},
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:         "keep complete after the resource is cleaned up",
+			configOption: []workapiv1.ManifestConfigOption{jobOption},
+			manifests:    []workapiv1.ManifestCondition{newJobManifest(metav1.ConditionFalse)},
+			existingConditions: []metav1.Condition{
+				{Type: helper.WorkComplete, Status: metav1.ConditionTrue, ObservedGeneration: 1},
+			},
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:         "reevaluate after the work is updated",
+			configOption: []workapiv1.ManifestConfigOption{jobOption},
+			manifests:    []workapiv1.ManifestCondition{newJobManifest(metav1.ConditionFalse)},
+			existingConditions: []metav1.Condition{
+				{Type: helper.WorkComplete, Status: metav1.ConditionTrue, ObservedGeneration: 0},
+			},
+			expectedStatus: metav1.ConditionFalse,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, _ := spoketesting.NewManifestWork(0)
+			work.Generation = 1
+			work.Spec.ManifestConfigs = c.configOption
+			work.Status.ResourceStatus.Manifests = c.manifests
+			work.Status.Conditions = c.existingConditions
+
+			cond := aggregateManifestCompleteConditions(work)
+			if len(c.expectedStatus) == 0 {
+				if cond != nil {
+					t.Errorf("expected no complete condition, but got %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != c.expectedStatus {
+				t.Errorf("expected complete condition with status %s, but got %v", c.expectedStatus, cond)
+			}
+		})
+	}
+}
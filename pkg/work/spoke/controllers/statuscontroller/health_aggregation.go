@@ -0,0 +1,194 @@
+package statuscontroller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/utils/lru"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+// maxCachedAggregationPolicies is the number of the compiled expressions of the CEL policies kept in the cache.
+const maxCachedAggregationPolicies = 256
+
+// healthAggregator aggregates the conditions of the manifests with the aggregation policies.
+type healthAggregator struct {
+	env *cel.Env
+	// programs caches the compiled programs keyed by the expressions, so an expression is compiled once rather than
+	// each time the status of the manifestwork is aggregated.
+	programs *lru.Cache
+}
+
+func newHealthAggregator() (*healthAggregator, error) {
+	env, err := helper.NewHealthAggregationEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &healthAggregator{env: env, programs: lru.New(maxCachedAggregationPolicies)}, nil
+}
+
+// aggregate returns the condition of the manifestwork with the condition type aggregated from the conditions of
+// the manifests with the policy. The condition is true if the policy is satisfied, it is unknown if the policy
+// would be satisfied when the manifests without the condition or with the unknown condition were true, otherwise
+// it is false.
+func (a *healthAggregator) aggregate(ctx context.Context, generation int64, conditionType string,
+	policy *helper.AggregationPolicy, manifests []workapiv1.ManifestCondition) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		ObservedGeneration: generation,
+	}
+
+	policyType := policy.Type
+	switch {
+	case len(policyType) > 0:
+	case conditionType == workapiv1.WorkDegraded:
+		// the manifestwork is degraded once any manifest is degraded
+		policyType = helper.AggregationPolicyAny
+	default:
+		policyType = helper.AggregationPolicyAll
+	}
+
+	if policyType == helper.AggregationPolicyCEL {
+		satisfied, err := a.evaluate(ctx, policy.Expression, manifests)
+		switch {
+		case err != nil:
+			condition.Status = metav1.ConditionUnknown
+			condition.Reason = "AggregationPolicyInvalid"
+			condition.Message = fmt.Sprintf("failed to evaluate the CEL policy: %v", err)
+		case satisfied:
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "AggregationPolicySatisfied"
+			condition.Message = fmt.Sprintf("the CEL policy of %s is satisfied", conditionType)
+		default:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "AggregationPolicyNotSatisfied"
+			condition.Message = fmt.Sprintf("the CEL policy of %s is not satisfied", conditionType)
+		}
+		return condition
+	}
+
+	// the weights of the manifests with the true, false and unknown condition.
+	var trueWeight, falseWeight, unknownWeight, trueCount int64
+	for _, manifest := range manifests {
+		weight := manifestWeight(policy, manifest.ResourceMeta)
+		cond := meta.FindStatusCondition(manifest.Conditions, conditionType)
+		switch {
+		case cond != nil && cond.Status == metav1.ConditionTrue:
+			trueWeight += weight
+			trueCount += 1
+		case cond != nil && cond.Status == metav1.ConditionFalse:
+			falseWeight += weight
+		default:
+			unknownWeight += weight
+		}
+	}
+
+	satisfied := func(trueWeight, totalWeight int64) bool {
+		switch policyType {
+		case helper.AggregationPolicyAny:
+			return trueWeight > 0
+		case helper.AggregationPolicyPercentage, helper.AggregationPolicyWeighted:
+			threshold := int64(100)
+			if policy.Threshold != nil {
+				threshold = int64(*policy.Threshold)
+			}
+			return totalWeight > 0 && trueWeight*100 >= threshold*totalWeight
+		default:
+			return totalWeight > 0 && trueWeight == totalWeight
+		}
+	}
+
+	totalWeight := trueWeight + falseWeight + unknownWeight
+	message := fmt.Sprintf("%d of %d resources are %s", trueCount, len(manifests), conditionType)
+	switch {
+	case satisfied(trueWeight, totalWeight):
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "AggregationPolicySatisfied"
+		condition.Message = fmt.Sprintf("%s, the %s policy is satisfied", message, policyType)
+	case satisfied(trueWeight+unknownWeight, totalWeight):
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "AggregationPolicyUnknown"
+		condition.Message = fmt.Sprintf("%s, the %s policy is unknown", message, policyType)
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "AggregationPolicyNotSatisfied"
+		condition.Message = fmt.Sprintf("%s, the %s policy is not satisfied", message, policyType)
+	}
+	return condition
+}
+
+func manifestWeight(policy *helper.AggregationPolicy, resourceMeta workapiv1.ManifestResourceMeta) int64 {
+	if policy.Type != helper.AggregationPolicyWeighted {
+		return 1
+	}
+	for _, weight := range policy.Weights {
+		if helper.ResourceMatch(resourceMeta, weight.ResourceIdentifier) {
+			return int64(weight.Weight)
+		}
+	}
+	return 1
+}
+
+// program returns the compiled program of the expression from the cache, the expression is compiled if it is
+// not in the cache.
+func (a *healthAggregator) program(expression string) (cel.Program, error) {
+	if prg, ok := a.programs.Get(expression); ok {
+		return prg.(cel.Program), nil
+	}
+
+	ast, iss := a.env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	prg, err := a.env.Program(ast,
+		cel.CostLimit(celconfig.PerCallLimit),
+		cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.programs.Add(expression, prg)
+	return prg, nil
+}
+
+func (a *healthAggregator) evaluate(ctx context.Context, expression string, manifests []workapiv1.ManifestCondition) (bool, error) {
+	prg, err := a.program(expression)
+	if err != nil {
+		return false, err
+	}
+
+	values := []any{}
+	for _, manifest := range manifests {
+		conditions := map[string]any{}
+		for _, cond := range manifest.Conditions {
+			conditions[cond.Type] = string(cond.Status)
+		}
+		values = append(values, map[string]any{
+			"group":      manifest.ResourceMeta.Group,
+			"version":    manifest.ResourceMeta.Version,
+			"kind":       manifest.ResourceMeta.Kind,
+			"resource":   manifest.ResourceMeta.Resource,
+			"namespace":  manifest.ResourceMeta.Namespace,
+			"name":       manifest.ResourceMeta.Name,
+			"conditions": conditions,
+		})
+	}
+
+	out, _, err := prg.ContextEval(ctx, map[string]any{"manifests": values})
+	if err != nil {
+		return false, err
+	}
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expected bool result, got %v", reflect.TypeOf(out.Value()))
+	}
+	return result, nil
+}
@@ -0,0 +1,239 @@
+package statuscontroller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newManifestWithStatus(resource, name string, conditionType string, status metav1.ConditionStatus) workapiv1.ManifestCondition {
+	manifest := newManifest("apps", "v1", resource, "ns1", name)
+	if len(status) > 0 {
+		manifest.Conditions = []metav1.Condition{{Type: conditionType, Status: status}}
+	}
+	return manifest
+}
+
+func TestAggregate(t *testing.T) {
+	aggregator, err := newHealthAggregator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appFailed := []workapiv1.ManifestCondition{
+		newManifestWithStatus("deployments", "app", workapiv1.ManifestAvailable, metav1.ConditionFalse),
+		newManifestWithStatus("deployments", "monitor", workapiv1.ManifestAvailable, metav1.ConditionTrue),
+	}
+	monitorFailed := []workapiv1.ManifestCondition{
+		newManifestWithStatus("deployments", "app", workapiv1.ManifestAvailable, metav1.ConditionTrue),
+		newManifestWithStatus("deployments", "monitor", workapiv1.ManifestAvailable, metav1.ConditionFalse),
+	}
+	monitorUnknown := []workapiv1.ManifestCondition{
+		newManifestWithStatus("deployments", "app", workapiv1.ManifestAvailable, metav1.ConditionTrue),
+		newManifestWithStatus("deployments", "monitor", workapiv1.ManifestAvailable, ""),
+	}
+	monitorOptional := &helper.AggregationPolicy{
+		Type: helper.AggregationPolicyWeighted,
+		Weights: []helper.ManifestWeight{
+			{ResourceIdentifier: workapiv1.ResourceIdentifier{Group: "apps", Resource: "deployments", Name: "monitor", Namespace: "*"}, Weight: 0},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		policy         *helper.AggregationPolicy
+		manifests      []workapiv1.ManifestCondition
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "all is not satisfied",
+			policy:         &helper.AggregationPolicy{},
+			manifests:      monitorFailed,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "AggregationPolicyNotSatisfied",
+		},
+		{
+			name:           "all is unknown",
+			policy:         &helper.AggregationPolicy{Type: helper.AggregationPolicyAll},
+			manifests:      monitorUnknown,
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "AggregationPolicyUnknown",
+		},
+		{
+			name:           "any is satisfied",
+			policy:         &helper.AggregationPolicy{Type: helper.AggregationPolicyAny},
+			manifests:      monitorFailed,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AggregationPolicySatisfied",
+		},
+		{
+			name:           "percentage is satisfied",
+			policy:         &helper.AggregationPolicy{Type: helper.AggregationPolicyPercentage, Threshold: pointer.Int32(50)},
+			manifests:      monitorFailed,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AggregationPolicySatisfied",
+		},
+		{
+			name:           "percentage is not satisfied",
+			policy:         &helper.AggregationPolicy{Type: helper.AggregationPolicyPercentage, Threshold: pointer.Int32(60)},
+			manifests:      monitorFailed,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "AggregationPolicyNotSatisfied",
+		},
+		{
+			name:           "optional manifest is failed",
+			policy:         monitorOptional,
+			manifests:      monitorFailed,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AggregationPolicySatisfied",
+		},
+		{
+			name:           "required manifest is failed",
+			policy:         monitorOptional,
+			manifests:      appFailed,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "AggregationPolicyNotSatisfied",
+		},
+		{
+			name: "cel is satisfied",
+			policy: &helper.AggregationPolicy{
+				Type:       helper.AggregationPolicyCEL,
+				Expression: `manifests.filter(m, m.name == "app").all(m, m.conditions.Available == "True")`,
+			},
+			manifests:      monitorFailed,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AggregationPolicySatisfied",
+		},
+		{
+			name: "cel is not satisfied",
+			policy: &helper.AggregationPolicy{
+				Type:       helper.AggregationPolicyCEL,
+				Expression: `manifests.filter(m, m.name == "app").all(m, m.conditions.Available == "True")`,
+			},
+			manifests:      appFailed,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "AggregationPolicyNotSatisfied",
+		},
+		{
+			name:           "cel is invalid",
+			policy:         &helper.AggregationPolicy{Type: helper.AggregationPolicyCEL, Expression: `manifests.size()`},
+			manifests:      appFailed,
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "AggregationPolicyInvalid",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			condition := aggregator.aggregate(context.TODO(), 1, workapiv1.WorkAvailable, c.policy, c.manifests)
+			if condition.Status != c.expectedStatus || condition.Reason != c.expectedReason {
+				t.Errorf("expected %s %s, got %#v", c.expectedStatus, c.expectedReason, condition)
+			}
+		})
+	}
+
+	// each expression of the CEL policies is compiled once
+	testingcommon.AssertEqualNumber(t, aggregator.programs.Len(), 2)
+}
+
+func TestAggregateWorkConditions(t *testing.T) {
+	aggregator, err := newHealthAggregator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := &AvailableStatusController{healthAggregator: aggregator}
+
+	cases := []struct {
+		name               string
+		annotation         string
+		existingConditions []metav1.Condition
+		expectedConditions map[string]metav1.ConditionStatus
+	}{
+		{
+			name: "default aggregation",
+			expectedConditions: map[string]metav1.ConditionStatus{
+				workapiv1.WorkAvailable: metav1.ConditionFalse,
+			},
+		},
+		{
+			name:       "aggregate with policies",
+			annotation: `{"available":{"type":"Any"},"degraded":{"type":"Any"}}`,
+			expectedConditions: map[string]metav1.ConditionStatus{
+				workapiv1.WorkAvailable: metav1.ConditionTrue,
+				workapiv1.WorkDegraded:  metav1.ConditionTrue,
+			},
+		},
+		{
+			name:       "degraded is aggregated with the any policy by default",
+			annotation: `{"degraded":{}}`,
+			expectedConditions: map[string]metav1.ConditionStatus{
+				workapiv1.WorkAvailable: metav1.ConditionFalse,
+				workapiv1.WorkDegraded:  metav1.ConditionTrue,
+			},
+		},
+		{
+			name:       "invalid policies",
+			annotation: `{"available":{"type":"None"}}`,
+			expectedConditions: map[string]metav1.ConditionStatus{
+				workapiv1.WorkAvailable: metav1.ConditionUnknown,
+			},
+		},
+		{
+			name: "policy is removed",
+			existingConditions: []metav1.Condition{
+				{Type: workapiv1.WorkDegraded, Status: metav1.ConditionTrue, Reason: "AggregationPolicySatisfied"},
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				workapiv1.WorkAvailable: metav1.ConditionFalse,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, _ := spoketesting.NewManifestWork(0)
+			if len(c.annotation) > 0 {
+				work.Annotations = map[string]string{helper.HealthAggregationAnnotationKey: c.annotation}
+			}
+			work.Status.Conditions = c.existingConditions
+			work.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+				{
+					ResourceMeta: workapiv1.ManifestResourceMeta{Group: "apps", Resource: "deployments", Namespace: "ns1", Name: "app"},
+					Conditions: []metav1.Condition{
+						{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionTrue},
+						{Type: workapiv1.ManifestDegraded, Status: metav1.ConditionFalse},
+					},
+				},
+				{
+					ResourceMeta: workapiv1.ManifestResourceMeta{Group: "apps", Resource: "deployments", Namespace: "ns1", Name: "monitor"},
+					Conditions: []metav1.Condition{
+						{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionFalse},
+						{Type: workapiv1.ManifestDegraded, Status: metav1.ConditionTrue},
+					},
+				},
+			}
+
+			controller.aggregateWorkConditions(context.TODO(), work)
+
+			if len(work.Status.Conditions) != len(c.expectedConditions) {
+				t.Errorf("expected %d conditions, got %#v", len(c.expectedConditions), work.Status.Conditions)
+			}
+			for conditionType, status := range c.expectedConditions {
+				cond := meta.FindStatusCondition(work.Status.Conditions, conditionType)
+				if cond == nil || cond.Status != status {
+					t.Errorf("expected condition %s %s, got %#v", conditionType, status, work.Status.Conditions)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package statuscontroller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/queue"
+	conditionrules "open-cluster-management.io/ocm/pkg/work/spoke/conditions/rules"
+	statusrules "open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
+)
+
+const (
+	// StatusRulesDataKey is the data key in the well known rules configmap for the status rules, the value is a
+	// list of WellKnownStatusRule in yaml or json.
+	StatusRulesDataKey = "statusRules"
+	// ConditionRulesDataKey is the data key in the well known rules configmap for the condition rules, the value
+	// is a list of WellKnownConditionRule in yaml or json.
+	ConditionRulesDataKey = "conditionRules"
+)
+
+// WellKnownStatusRule defines the json paths of the WellKnownStatus feedback rule for a resource kind.
+type WellKnownStatusRule struct {
+	Group     string               `json:"group,omitempty"`
+	Version   string               `json:"version"`
+	Kind      string               `json:"kind"`
+	JsonPaths []workapiv1.JsonPath `json:"jsonPaths"`
+}
+
+// WellKnownConditionRule defines the CEL condition rules of the WellKnownConditions condition rule for a
+// resource kind.
+type WellKnownConditionRule struct {
+	Group   string                    `json:"group,omitempty"`
+	Version string                    `json:"version"`
+	Kind    string                    `json:"kind"`
+	Rules   []workapiv1.ConditionRule `json:"rules"`
+}
+
+// WellKnownRulesController loads the custom well known status and condition rules from a configmap, the rules
+// are merged with the built-in rules and take effect on the next status sync of the manifestworks.
+type WellKnownRulesController struct {
+	configMapLister       corev1listers.ConfigMapNamespaceLister
+	configMapName         string
+	statusRuleResolver    *statusrules.DefaultWellKnownStatusResolver
+	conditionRuleResolver *conditionrules.DefaultWellKnownConditionRuleResolver
+}
+
+// NewWellKnownRulesController returns a WellKnownRulesController
+func NewWellKnownRulesController(
+	recorder events.Recorder,
+	configMapInformer corev1informers.ConfigMapInformer,
+	namespace, configMapName string,
+	statusRuleResolver *statusrules.DefaultWellKnownStatusResolver,
+	conditionRuleResolver *conditionrules.DefaultWellKnownConditionRuleResolver,
+) factory.Controller {
+	controller := &WellKnownRulesController{
+		configMapLister:       configMapInformer.Lister().ConfigMaps(namespace),
+		configMapName:         configMapName,
+		statusRuleResolver:    statusRuleResolver,
+		conditionRuleResolver: conditionRuleResolver,
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeysFunc(
+			func(obj runtime.Object) []string {
+				return []string{factory.DefaultQueueKey}
+			},
+			queue.FilterByNames(configMapName),
+			configMapInformer.Informer()).
+		WithSync(controller.sync).ToController("WellKnownRulesController", recorder)
+}
+
+func (c *WellKnownRulesController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+	logger := klog.FromContext(ctx)
+
+	configMap, err := c.configMapLister.Get(c.configMapName)
+	switch {
+	case errors.IsNotFound(err):
+		c.statusRuleResolver.SetCustomRules(nil)
+		c.conditionRuleResolver.SetCustomRules(nil)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	// keep the rules loaded previously if the configmap is invalid.
+	statusRules, err := parseStatusRules(configMap.Data[StatusRulesDataKey])
+	if err != nil {
+		controllerContext.Recorder().Warningf("WellKnownRulesInvalid", "invalid status rules in configmap %s: %v", c.configMapName, err)
+		return nil
+	}
+	conditionRules, err := parseConditionRules(configMap.Data[ConditionRulesDataKey])
+	if err != nil {
+		controllerContext.Recorder().Warningf("WellKnownRulesInvalid", "invalid condition rules in configmap %s: %v", c.configMapName, err)
+		return nil
+	}
+
+	c.statusRuleResolver.SetCustomRules(statusRules)
+	c.conditionRuleResolver.SetCustomRules(conditionRules)
+	logger.V(4).Info("Loaded the custom well known rules", "configMap", c.configMapName,
+		"statusRules", len(statusRules), "conditionRules", len(conditionRules))
+	return nil
+}
+
+func parseStatusRules(data string) (map[schema.GroupVersionKind][]workapiv1.JsonPath, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var statusRules []WellKnownStatusRule
+	if err := yaml.Unmarshal([]byte(data), &statusRules); err != nil {
+		return nil, err
+	}
+
+	rules := map[schema.GroupVersionKind][]workapiv1.JsonPath{}
+	for _, rule := range statusRules {
+		gvk := schema.GroupVersionKind{Group: rule.Group, Version: rule.Version, Kind: rule.Kind}
+		if len(gvk.Version) == 0 || len(gvk.Kind) == 0 {
+			return nil, fmt.Errorf("version and kind are required in the status rule of %s", gvk)
+		}
+		for _, path := range rule.JsonPaths {
+			if len(path.Name) == 0 || len(path.Path) == 0 {
+				return nil, fmt.Errorf("name and path are required in the json paths of %s", gvk)
+			}
+		}
+		rules[gvk] = append(rules[gvk], rule.JsonPaths...)
+	}
+	return rules, nil
+}
+
+func parseConditionRules(data string) (map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var conditionRules []WellKnownConditionRule
+	if err := yaml.Unmarshal([]byte(data), &conditionRules); err != nil {
+		return nil, err
+	}
+
+	rules := map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule{}
+	for _, rule := range conditionRules {
+		gvk := schema.GroupVersionKind{Group: rule.Group, Version: rule.Version, Kind: rule.Kind}
+		if len(gvk.Version) == 0 || len(gvk.Kind) == 0 {
+			return nil, fmt.Errorf("version and kind are required in the condition rule of %s", gvk)
+		}
+		for _, r := range rule.Rules {
+			if len(r.Condition) == 0 || len(r.CelExpressions) == 0 {
+				return nil, fmt.Errorf("condition and celExpressions are required in the condition rules of %s", gvk)
+			}
+			if len(r.Type) == 0 {
+				r.Type = workapiv1.CelConditionExpressionsType
+			}
+			if r.Type != workapiv1.CelConditionExpressionsType {
+				return nil, fmt.Errorf("unsupported condition rule type %s of %s", r.Type, gvk)
+			}
+			if _, ok := rules[gvk]; !ok {
+				rules[gvk] = map[string]workapiv1.ConditionRule{}
+			}
+			rules[gvk][r.Condition] = r
+		}
+	}
+	return rules, nil
+}
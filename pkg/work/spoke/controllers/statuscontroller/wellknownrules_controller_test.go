@@ -0,0 +1,143 @@
+package statuscontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	conditionrules "open-cluster-management.io/ocm/pkg/work/spoke/conditions/rules"
+	statusrules "open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
+)
+
+const (
+	testStatusRules = `
+- group: apps
+  version: v1
+  kind: Deployment
+  jsonPaths:
+  - name: ReadyReplicas
+    path: .status.ready
+  - name: ObservedGeneration
+    path: .status.observedGeneration
+- group: example.io
+  version: v1
+  kind: Foo
+  jsonPaths:
+  - name: Phase
+    path: .status.phase
+`
+	testConditionRules = `
+- group: example.io
+  version: v1
+  kind: Foo
+  rules:
+  - condition: Available
+    celExpressions:
+    - object.status.phase == 'Running'
+`
+)
+
+func TestSyncWellKnownRules(t *testing.T) {
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	fooGVK := schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Foo"}
+
+	cases := []struct {
+		name                string
+		configMap           *corev1.ConfigMap
+		previousStatusRules map[schema.GroupVersionKind][]workapiv1.JsonPath
+		expectedDeployPaths int
+		expectedReadyPath   string
+		expectedFooPaths    int
+		expectedFooRule     bool
+	}{
+		{
+			name:                "no configmap",
+			previousStatusRules: map[schema.GroupVersionKind][]workapiv1.JsonPath{fooGVK: {{Name: "A", Path: ".a"}}},
+			expectedDeployPaths: 3,
+			expectedReadyPath:   ".status.readyReplicas",
+		},
+		{
+			name: "custom rules",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "rules", Namespace: "agent"},
+				Data: map[string]string{
+					StatusRulesDataKey:    testStatusRules,
+					ConditionRulesDataKey: testConditionRules,
+				},
+			},
+			// ReadyReplicas is overridden and ObservedGeneration is added.
+			expectedDeployPaths: 4,
+			expectedReadyPath:   ".status.ready",
+			expectedFooPaths:    1,
+			expectedFooRule:     true,
+		},
+		{
+			name: "invalid rules",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "rules", Namespace: "agent"},
+				Data: map[string]string{
+					StatusRulesDataKey: `- kind: Foo`,
+				},
+			},
+			previousStatusRules: map[schema.GroupVersionKind][]workapiv1.JsonPath{fooGVK: {{Name: "A", Path: ".a"}}},
+			expectedDeployPaths: 3,
+			expectedReadyPath:   ".status.readyReplicas",
+			expectedFooPaths:    1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := fakekube.NewSimpleClientset()
+			informerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			if c.configMap != nil {
+				if err := informerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(c.configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			statusRuleResolver := statusrules.NewWellKnownStatusResolver()
+			statusRuleResolver.SetCustomRules(c.previousStatusRules)
+			conditionRuleResolver := conditionrules.NewWellKnownConditionResolver()
+
+			controller := &WellKnownRulesController{
+				configMapLister:       informerFactory.Core().V1().ConfigMaps().Lister().ConfigMaps("agent"),
+				configMapName:         "rules",
+				statusRuleResolver:    statusRuleResolver,
+				conditionRuleResolver: conditionRuleResolver,
+			}
+			if err := controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "rules")); err != nil {
+				t.Fatal(err)
+			}
+
+			deployPaths := statusRuleResolver.GetPathsByKind(deploymentGVK)
+			if len(deployPaths) != c.expectedDeployPaths {
+				t.Errorf("expected %d deployment paths, got %v", c.expectedDeployPaths, deployPaths)
+			}
+			for _, path := range deployPaths {
+				if path.Name == "ReadyReplicas" && path.Path != c.expectedReadyPath {
+					t.Errorf("expected ReadyReplicas path %s, got %s", c.expectedReadyPath, path.Path)
+				}
+			}
+			if paths := statusRuleResolver.GetPathsByKind(fooGVK); len(paths) != c.expectedFooPaths {
+				t.Errorf("expected %d foo paths, got %v", c.expectedFooPaths, paths)
+			}
+			rule := conditionRuleResolver.GetRuleByKindCondition(fooGVK, workapiv1.ManifestAvailable)
+			if (len(rule.CelExpressions) > 0) != c.expectedFooRule {
+				t.Errorf("expected foo rule %v, got %v", c.expectedFooRule, rule)
+			}
+			if c.expectedFooRule && rule.Type != workapiv1.CelConditionExpressionsType {
+				t.Errorf("expected the rule type is defaulted, got %s", rule.Type)
+			}
+		})
+	}
+}
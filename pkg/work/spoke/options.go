@@ -20,6 +20,12 @@ type WorkloadAgentOptions struct {
 	CloudEventsClientID                    string
 	CloudEventsClientCodecs                []string
 	DefaultUserAgent                       string
+	WellKnownRulesConfigMap                string
+	EnableRelatedObjectFeedback            bool
+	EnableManifestWorkSnapshot             bool
+	ManifestWorkSnapshotNamespace          string
+	EnableManifestDecryption               bool
+	EnableClusterFreeze                    bool
 }
 
 // NewWorkloadAgentOptions returns the flags with default value set
@@ -31,6 +37,7 @@ func NewWorkloadAgentOptions() *WorkloadAgentOptions {
 		WorkloadSourceDriver:                   "kube",
 		WorkloadSourceConfig:                   "/spoke/hub-kubeconfig/kubeconfig",
 		DefaultUserAgent:                       defaultUserAgent,
+		WellKnownRulesConfigMap:                "work-well-known-rules",
 	}
 }
 
@@ -50,4 +57,24 @@ func (o *WorkloadAgentOptions) AddFlags(fs *pflag.FlagSet) {
 		o.CloudEventsClientID, "The ID of the cloudevents client when workload source source is based on cloudevents")
 	fs.StringSliceVar(&o.CloudEventsClientCodecs, "cloudevents-client-codecs", o.CloudEventsClientCodecs,
 		"The codecs for cloudevents client when workload source source is based on cloudevents, the valid codecs: manifest or manifestbundle")
+	fs.StringVar(&o.WellKnownRulesConfigMap, "well-known-rules-configmap", o.WellKnownRulesConfigMap,
+		"The name of the configmap in the agent namespace to load the custom well known status and condition rules, "+
+			"the custom rules are disabled if it is empty")
+	fs.BoolVar(&o.EnableRelatedObjectFeedback, "enable-related-object-feedback", o.EnableRelatedObjectFeedback,
+		"Watch the pods, replicasets and events in the namespaces of the resources with the related feedback rules "+
+			"on the managed cluster to return the related objects in status feedback")
+	fs.BoolVar(&o.EnableManifestWorkSnapshot, "enable-manifestwork-snapshot", o.EnableManifestWorkSnapshot,
+		"Persist the manifestworks synced from the hub into secrets of the snapshot namespace encrypted with a key "+
+			"in the agent namespace, "+
+			"keep reconciling them from the snapshot and buffer the status when the hub is unreachable, "+
+			"it is only supported with the kube workload source driver")
+	fs.StringVar(&o.ManifestWorkSnapshotNamespace, "manifestwork-snapshot-namespace", o.ManifestWorkSnapshotNamespace,
+		"The namespace of the secrets persisting the manifestwork snapshot on the cluster where the agent is running, "+
+			"it must be different from the agent namespace where the snapshot key is stored")
+	fs.BoolVar(&o.EnableManifestDecryption, "enable-manifest-decryption", o.EnableManifestDecryption,
+		"Decrypt the manifests encrypted by the hub with the encryption key published by the registration agent, "+
+			"and redact the status feedback of the encrypted manifests")
+	fs.BoolVar(&o.EnableClusterFreeze, "enable-cluster-freeze", o.EnableClusterFreeze,
+		"Hold the applies and the deletions of the manifestworks when the managed cluster is frozen on the hub, "+
+			"it is only supported with the kube workload source driver")
 }
@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
+	workv1informers "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// errListedFromSnapshot is returned by the watch of the informer when the last list is served by the snapshot,
+// so the informer relists the manifestworks from the hub instead of watching from an unknown resource version.
+var errListedFromSnapshot = errors.New("the manifestworks are listed from the snapshot, relist them from the hub")
+
+type manifestWorkInformer struct {
+	informer cache.SharedIndexInformer
+}
+
+// NewManifestWorkInformer returns an informer of the manifestworks in the cluster namespace on the hub. When the
+// hub is unreachable, the manifestworks are listed from the snapshot persisted in the snapshot namespace, so the
+// agent keeps reconciling the manifestworks synced last time, even after it restarts. The informer keeps
+// relisting from the hub, once the hub is reachable again, the differences are delivered to the controllers as
+// update and delete events, and the status buffered while the hub is unreachable is published.
+func NewManifestWorkInformer(
+	workClient workv1client.ManifestWorkInterface,
+	kubeClient kubernetes.Interface,
+	namespace, hubHash string,
+	key []byte,
+	statusBuffer *StatusBuffer,
+	resyncPeriod time.Duration,
+) workv1informers.ManifestWorkInformer {
+	lw := &snapshotListWatcher{
+		workClient:   workClient,
+		kubeClient:   kubeClient,
+		namespace:    namespace,
+		hubHash:      hubHash,
+		key:          key,
+		statusBuffer: statusBuffer,
+	}
+
+	return &manifestWorkInformer{
+		informer: cache.NewSharedIndexInformer(
+			&cache.ListWatch{
+				ListFunc:  lw.list,
+				WatchFunc: lw.watch,
+			},
+			&workapiv1.ManifestWork{},
+			resyncPeriod,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		),
+	}
+}
+
+func (i *manifestWorkInformer) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+func (i *manifestWorkInformer) Lister() worklister.ManifestWorkLister {
+	return worklister.NewManifestWorkLister(i.informer.GetIndexer())
+}
+
+type snapshotListWatcher struct {
+	workClient workv1client.ManifestWorkInterface
+	kubeClient kubernetes.Interface
+	namespace  string
+	hubHash    string
+	key        []byte
+	// statusBuffer is flushed once the manifestworks are listed from the hub after they are listed from the
+	// snapshot, it is nil if the status is not buffered.
+	statusBuffer *StatusBuffer
+
+	// listedFromSnapshot is true if the last list is served by the snapshot
+	listedFromSnapshot atomic.Bool
+}
+
+func (l *snapshotListWatcher) list(options metav1.ListOptions) (runtime.Object, error) {
+	ctx := context.Background()
+	works, err := l.workClient.List(ctx, options)
+	if err == nil || !IsHubUnreachable(err) {
+		if l.listedFromSnapshot.Swap(false) && err == nil && l.statusBuffer != nil {
+			go l.statusBuffer.Flush(ctx)
+		}
+		return works, err
+	}
+
+	snapshot, snapshotErr := listSnapshot(ctx, l.kubeClient, l.namespace, l.hubHash, l.key)
+	if snapshotErr != nil {
+		klog.Errorf("Failed to list the manifestwork snapshot: %v", snapshotErr)
+		return nil, err
+	}
+	if len(snapshot) == 0 {
+		return nil, err
+	}
+
+	klog.Warningf("The hub is unreachable, reconcile %d manifestworks from the snapshot: %v", len(snapshot), err)
+	l.listedFromSnapshot.Store(true)
+	return &workapiv1.ManifestWorkList{Items: snapshot}, nil
+}
+
+func (l *snapshotListWatcher) watch(options metav1.ListOptions) (watch.Interface, error) {
+	if l.listedFromSnapshot.Load() {
+		return nil, errListedFromSnapshot
+	}
+	return l.workClient.Watch(context.Background(), options)
+}
+
+// IsHubUnreachable returns true if the error indicates the hub cannot be connected or is not able to serve the
+// requests, rather than rejects the requests.
+func IsHubUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		// the request does not get a response from the hub
+		return true
+	}
+
+	return apierrors.IsTimeout(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsUnexpectedServerError(err) ||
+		apierrors.IsInternalError(err)
+}
@@ -0,0 +1,174 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+)
+
+const (
+	// SnapshotLabelKey is the label key on the secrets in the snapshot namespace which persist the manifestworks
+	// synced from the hub.
+	SnapshotLabelKey = "work.open-cluster-management.io/manifestwork-snapshot"
+
+	// ManifestWorkNameAnnotationKey is the annotation key on a snapshot secret, its value is the name of the
+	// persisted manifestwork. An annotation is used since the name of a manifestwork may exceed the length limit
+	// of a label value.
+	ManifestWorkNameAnnotationKey = "work.open-cluster-management.io/manifestwork-name"
+
+	// HubHashAnnotationKey is the annotation key on a snapshot secret, its value is the hash of the hub the
+	// manifestwork is synced from. The snapshots of other hubs are ignored.
+	HubHashAnnotationKey = "work.open-cluster-management.io/hub-hash"
+
+	// KeySecretName is the name of the secret in the agent namespace which stores the key the snapshots are
+	// encrypted with. The key is kept out of the snapshot namespace, so the snapshots cannot be decrypted with the
+	// access to the snapshot namespace only. The key is generated by the work agent if it does not exist, the
+	// snapshots encrypted with a lost key are skipped and persisted again.
+	KeySecretName = "manifestwork-snapshot-key"
+
+	manifestWorkDataKey = "manifestwork"
+	keyDataKey          = "key"
+	secretNamePrefix    = "manifestwork-snapshot-"
+)
+
+// SecretName returns the name of the secret which persists the manifestwork with the given name.
+func SecretName(workName string) string {
+	return fmt.Sprintf("%s%x", secretNamePrefix, sha256.Sum256([]byte(workName)))[:len(secretNamePrefix)+32]
+}
+
+// EnsureKey returns the key in the given namespace the snapshots are encrypted with, the key is generated if it
+// does not exist.
+func EnsureKey(ctx context.Context, kubeClient kubernetes.Interface, namespace string) ([]byte, error) {
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, KeySecretName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		key, err := encryption.GenerateDataKey()
+		if err != nil {
+			return nil, err
+		}
+		secret, err = kubeClient.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      KeySecretName,
+				Namespace: namespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{keyDataKey: key},
+		}, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			// the key is generated by another replica of the agent
+			return EnsureKey(ctx, kubeClient, namespace)
+		}
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	if len(secret.Data[keyDataKey]) != 32 {
+		return nil, fmt.Errorf("the snapshot key in secret %s/%s is not a 256 bits key", namespace, KeySecretName)
+	}
+	return secret.Data[keyDataKey], nil
+}
+
+// encodeManifestWork returns the manifestwork to persist. The managed fields are dropped since they are not
+// needed to reconcile the manifestwork.
+func encodeManifestWork(work *workapiv1.ManifestWork) ([]byte, error) {
+	work = work.DeepCopy()
+	work.ManagedFields = nil
+	return json.Marshal(work)
+}
+
+// newSnapshotSecret returns the secret persisting the manifestwork encrypted with the key.
+func newSnapshotSecret(namespace, hubHash string, key []byte, work *workapiv1.ManifestWork) (*corev1.Secret, error) {
+	data, err := encodeManifestWork(work)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := encryption.SealData(key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName(work.Name),
+			Namespace: namespace,
+			Labels: map[string]string{
+				SnapshotLabelKey: "true",
+			},
+			Annotations: map[string]string{
+				ManifestWorkNameAnnotationKey: work.Name,
+				HubHashAnnotationKey:          hubHash,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			manifestWorkDataKey: sealed,
+		},
+	}, nil
+}
+
+// decryptSnapshotSecret returns the decrypted manifestwork data persisted in the secret.
+func decryptSnapshotSecret(secret *corev1.Secret, key []byte) ([]byte, error) {
+	data, err := encryption.OpenData(key, secret.Data[manifestWorkDataKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the manifestwork snapshot %s/%s: %v", secret.Namespace, secret.Name, err)
+	}
+	return data, nil
+}
+
+// decodeSnapshotSecret returns the manifestwork persisted in the secret, nil is returned if the secret is not
+// a snapshot of the hub.
+func decodeSnapshotSecret(secret *corev1.Secret, hubHash string, key []byte) (*workapiv1.ManifestWork, error) {
+	if secret.Labels[SnapshotLabelKey] != "true" || secret.Annotations[HubHashAnnotationKey] != hubHash {
+		return nil, nil
+	}
+
+	data, err := decryptSnapshotSecret(secret, key)
+	if err != nil {
+		return nil, err
+	}
+	work := &workapiv1.ManifestWork{}
+	if err := json.Unmarshal(data, work); err != nil {
+		return nil, fmt.Errorf("failed to decode the manifestwork snapshot %s/%s: %v", secret.Namespace, secret.Name, err)
+	}
+	return work, nil
+}
+
+// listSnapshot lists the manifestworks of the hub persisted in the secrets of the snapshot namespace. A snapshot
+// that cannot be decoded is skipped, so a single broken snapshot does not stop the agent.
+func listSnapshot(ctx context.Context, kubeClient kubernetes.Interface, namespace, hubHash string,
+	key []byte) ([]workapiv1.ManifestWork, error) {
+	secrets, err := kubeClient.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", SnapshotLabelKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var works []workapiv1.ManifestWork
+	for i := range secrets.Items {
+		work, err := decodeSnapshotSecret(&secrets.Items[i], hubHash, key)
+		if err != nil {
+			klog.Warningf("Skip the manifestwork snapshot: %v", err)
+			continue
+		}
+		if work == nil {
+			continue
+		}
+		works = append(works, *work)
+	}
+	return works, nil
+}
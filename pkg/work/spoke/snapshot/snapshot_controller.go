@@ -0,0 +1,142 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	workinformer "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
+	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/queue"
+)
+
+// snapshotController persists the manifestworks synced from the hub into the secrets of the snapshot namespace,
+// and deletes the snapshot of a manifestwork once it is removed from the hub. The snapshots are encrypted with the
+// key in the agent namespace.
+type snapshotController struct {
+	kubeClient         kubernetes.Interface
+	secretLister       corev1listers.SecretNamespaceLister
+	manifestWorkLister worklister.ManifestWorkNamespaceLister
+	namespace          string
+	hubHash            string
+	key                []byte
+	recorder           events.Recorder
+}
+
+// NewSnapshotController returns a controller persisting the manifestworks into the snapshot. The secretInformer
+// should only watch the secrets with the SnapshotLabelKey label in the snapshot namespace.
+func NewSnapshotController(
+	recorder events.Recorder,
+	kubeClient kubernetes.Interface,
+	secretInformer corev1informers.SecretInformer,
+	manifestWorkInformer workinformer.ManifestWorkInformer,
+	manifestWorkLister worklister.ManifestWorkNamespaceLister,
+	namespace, hubHash string,
+	key []byte,
+) factory.Controller {
+	c := &snapshotController{
+		kubeClient:         kubeClient,
+		secretLister:       secretInformer.Lister().Secrets(namespace),
+		manifestWorkLister: manifestWorkLister,
+		namespace:          namespace,
+		hubHash:            hubHash,
+		key:                key,
+		recorder:           recorder,
+	}
+
+	return factory.New().
+		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, manifestWorkInformer.Informer()).
+		WithFilteredEventsInformersQueueKeysFunc(
+			queueKeyByManifestWorkName,
+			queue.FileterByLabelKeyValue(SnapshotLabelKey, "true"),
+			secretInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ManifestWorkSnapshotController", recorder)
+}
+
+func (c *snapshotController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
+	workName := controllerContext.QueueKey()
+	klog.V(5).Infof("Reconciling the snapshot of ManifestWork %q", workName)
+
+	work, err := c.manifestWorkLister.Get(workName)
+	switch {
+	case apierrors.IsNotFound(err):
+		return c.deleteSnapshot(ctx, workName)
+	case err != nil:
+		return err
+	}
+
+	existing, err := c.secretLister.Get(SecretName(workName))
+	switch {
+	case apierrors.IsNotFound(err):
+		required, err := newSnapshotSecret(c.namespace, c.hubHash, c.key, work)
+		if err != nil {
+			return err
+		}
+		_, err = c.kubeClient.CoreV1().Secrets(c.namespace).Create(ctx, required, metav1.CreateOptions{})
+		return err
+	case err != nil:
+		return err
+	}
+
+	// the snapshot is encrypted with a random nonce, so it is compared with the manifestwork after it is decrypted
+	if existing.Annotations[ManifestWorkNameAnnotationKey] == workName &&
+		existing.Annotations[HubHashAnnotationKey] == c.hubHash {
+		data, err := encodeManifestWork(work)
+		if err != nil {
+			return err
+		}
+		if existingData, err := decryptSnapshotSecret(existing, c.key); err == nil && bytes.Equal(existingData, data) {
+			return nil
+		}
+	}
+
+	required, err := newSnapshotSecret(c.namespace, c.hubHash, c.key, work)
+	if err != nil {
+		return err
+	}
+	updated := existing.DeepCopy()
+	updated.Labels = required.Labels
+	updated.Annotations = required.Annotations
+	updated.Data = required.Data
+	_, err = c.kubeClient.CoreV1().Secrets(c.namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *snapshotController) deleteSnapshot(ctx context.Context, workName string) error {
+	name := SecretName(workName)
+	if _, err := c.secretLister.Get(name); apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	err := c.kubeClient.CoreV1().Secrets(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.recorder.Eventf("ManifestWorkSnapshotDeleted", "The snapshot of ManifestWork %s is deleted", workName)
+	return nil
+}
+
+func queueKeyByManifestWorkName(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	name := accessor.GetAnnotations()[ManifestWorkNameAnnotationKey]
+	if len(name) == 0 {
+		return []string{}
+	}
+	return []string{name}
+}
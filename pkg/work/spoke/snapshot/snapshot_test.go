@@ -0,0 +1,320 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+const (
+	testAgentNamespace = "open-cluster-management-agent"
+	testClusterName    = "cluster1"
+	testHubHash        = "hub1"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newManifestWork(name string, manifests int) *workapiv1.ManifestWork {
+	work := &workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testClusterName,
+		},
+	}
+	for i := 0; i < manifests; i++ {
+		work.Spec.Workload.Manifests = append(work.Spec.Workload.Manifests, workapiv1.Manifest{
+			RawExtension: runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm%d","namespace":"default"}}`, i))},
+		})
+	}
+	return work
+}
+
+func newSecret(t *testing.T, hubHash string, work *workapiv1.ManifestWork) *corev1.Secret {
+	secret, err := newSnapshotSecret(testAgentNamespace, hubHash, testKey, work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+func TestSecretName(t *testing.T) {
+	name := SecretName("work1")
+	if name != SecretName("work1") || name == SecretName("work2") {
+		t.Errorf("expected the secret name is unique to the manifestwork")
+	}
+	testingcommon.AssertEqualNumber(t, len(name), len(secretNamePrefix)+32)
+}
+
+func TestListSnapshot(t *testing.T) {
+	broken := newSecret(t, testHubHash, newManifestWork("work3", 1))
+	broken.Data[manifestWorkDataKey] = []byte("broken")
+	otherKey, err := newSnapshotSecret(testAgentNamespace, testHubHash, []byte("abcdef0123456789abcdef0123456789"),
+		newManifestWork("work4", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey.Name = SecretName("work4")
+
+	kubeClient := fakekube.NewSimpleClientset(
+		newSecret(t, testHubHash, newManifestWork("work1", 1)),
+		newSecret(t, "hub2", newManifestWork("work2", 1)),
+		broken,
+		otherKey,
+	)
+
+	works, err := listSnapshot(context.TODO(), kubeClient, testAgentNamespace, testHubHash, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertEqualNumber(t, len(works), 1)
+	if works[0].Name != "work1" || len(works[0].Spec.Workload.Manifests) != 1 {
+		t.Errorf("unexpected manifestwork %v", works[0])
+	}
+}
+
+func TestEnsureKey(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset()
+	key, err := EnsureKey(context.TODO(), kubeClient, testAgentNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertEqualNumber(t, len(key), 32)
+	testingcommon.AssertActions(t, kubeClient.Actions(), "get", "create")
+
+	kubeClient.ClearActions()
+	existing, err := EnsureKey(context.TODO(), kubeClient, testAgentNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, existing) {
+		t.Errorf("expected the existing key is returned")
+	}
+	testingcommon.AssertActions(t, kubeClient.Actions(), "get")
+}
+
+func TestIsHubUnreachable(t *testing.T) {
+	gr := schema.GroupResource{Group: workapiv1.GroupName, Resource: "manifestworks"}
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "no error"},
+		{name: "connection error", err: fmt.Errorf("dial tcp 10.0.0.1:6443: connect: connection refused"), expected: true},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("unavailable"), expected: true},
+		{name: "timeout", err: apierrors.NewServerTimeout(gr, "list", 1), expected: true},
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "", fmt.Errorf("forbidden"))},
+		{name: "not found", err: apierrors.NewNotFound(gr, "work1")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := IsHubUnreachable(c.err); actual != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSnapshotListWatcher(t *testing.T) {
+	kubeClient := fakekube.NewSimpleClientset(newSecret(t, testHubHash, newManifestWork("work1", 1)))
+	workClient := fakeworkclient.NewSimpleClientset(newManifestWork("work2", 1))
+
+	lw := &snapshotListWatcher{
+		workClient: workClient.WorkV1().ManifestWorks(testClusterName),
+		kubeClient: kubeClient,
+		namespace:  testAgentNamespace,
+		hubHash:    testHubHash,
+		key:        testKey,
+	}
+
+	// the hub is reachable
+	obj, err := lw.list(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertManifestWorks(t, obj, "work2")
+	if _, err := lw.watch(metav1.ListOptions{}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// the hub is unreachable
+	workClient.PrependReactor("list", "manifestworks", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("connection refused")
+	})
+	obj, err = lw.list(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertManifestWorks(t, obj, "work1")
+	if _, err := lw.watch(metav1.ListOptions{}); err != errListedFromSnapshot {
+		t.Errorf("expected the informer relists after the snapshot is listed, got %v", err)
+	}
+
+	// the hub is unreachable and there is no snapshot
+	lw.hubHash = "hub2"
+	if _, err := lw.list(metav1.ListOptions{}); err == nil {
+		t.Errorf("expected error when there is no snapshot")
+	}
+}
+
+func assertManifestWorks(t *testing.T, obj runtime.Object, names ...string) {
+	works, ok := obj.(*workapiv1.ManifestWorkList)
+	if !ok {
+		t.Fatalf("expected manifestwork list, got %T", obj)
+	}
+	testingcommon.AssertEqualNumber(t, len(works.Items), len(names))
+	for i, name := range names {
+		if works.Items[i].Name != name {
+			t.Errorf("expected manifestwork %s, got %s", name, works.Items[i].Name)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		queueKey        string
+		works           []runtime.Object
+		secrets         []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:     "create snapshot",
+			queueKey: "work1",
+			works:    []runtime.Object{newManifestWork("work1", 1)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "create")
+				secret := actions[0].(clienttesting.CreateActionImpl).Object.(*corev1.Secret)
+				if secret.Name != SecretName("work1") || secret.Annotations[ManifestWorkNameAnnotationKey] != "work1" {
+					t.Errorf("unexpected snapshot %v", secret)
+				}
+				if bytes.Contains(secret.Data[manifestWorkDataKey], []byte("ConfigMap")) {
+					t.Errorf("expected the snapshot is encrypted")
+				}
+			},
+		},
+		{
+			name:     "snapshot is up to date",
+			queueKey: "work1",
+			works:    []runtime.Object{newManifestWork("work1", 1)},
+			secrets:  []runtime.Object{newSecret(t, testHubHash, newManifestWork("work1", 1))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "update snapshot",
+			queueKey: "work1",
+			works:    []runtime.Object{newManifestWork("work1", 2)},
+			secrets:  []runtime.Object{newSecret(t, testHubHash, newManifestWork("work1", 1))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+			},
+		},
+		{
+			name:     "update snapshot encrypted with another key",
+			queueKey: "work1",
+			works:    []runtime.Object{newManifestWork("work1", 1)},
+			secrets: []runtime.Object{func() runtime.Object {
+				secret := newSecret(t, testHubHash, newManifestWork("work1", 1))
+				secret.Data[manifestWorkDataKey] = []byte("broken")
+				return secret
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+			},
+		},
+		{
+			name:     "update snapshot of another hub",
+			queueKey: "work1",
+			works:    []runtime.Object{newManifestWork("work1", 1)},
+			secrets:  []runtime.Object{newSecret(t, "hub2", newManifestWork("work1", 1))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "update")
+			},
+		},
+		{
+			name:     "delete snapshot",
+			queueKey: "work1",
+			secrets:  []runtime.Object{newSecret(t, testHubHash, newManifestWork("work1", 1))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "delete")
+			},
+		},
+		{
+			name:     "no snapshot",
+			queueKey: "work1",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := fakekube.NewSimpleClientset(c.secrets...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, secret := range c.secrets {
+				if err := kubeInformerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			workClient := fakeworkclient.NewSimpleClientset(c.works...)
+			workInformerFactory := workinformers.NewSharedInformerFactory(workClient, 10*time.Minute)
+			for _, work := range c.works {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(work); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := &snapshotController{
+				kubeClient:   kubeClient,
+				secretLister: kubeInformerFactory.Core().V1().Secrets().Lister().Secrets(testAgentNamespace),
+				manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister().
+					ManifestWorks(testClusterName),
+				namespace: testAgentNamespace,
+				hubHash:   testHubHash,
+				key:       testKey,
+				recorder:  eventstesting.NewTestingEventRecorder(t),
+			}
+
+			kubeClient.ClearActions()
+			syncContext := testingcommon.NewFakeSyncContext(t, c.queueKey)
+			if err := controller.sync(context.TODO(), syncContext); err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func TestQueueKeyByManifestWorkName(t *testing.T) {
+	secret := newSecret(t, testHubHash, newManifestWork("work1", 1))
+	keys := queueKeyByManifestWorkName(secret)
+	if len(keys) != 1 || keys[0] != "work1" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	keys = queueKeyByManifestWorkName(&corev1.Secret{})
+	testingcommon.AssertEqualNumber(t, len(keys), 0)
+}
@@ -0,0 +1,125 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// StatusBuffer buffers the latest status patch of each manifestwork which fails to be published because the hub
+// is unreachable, and publishes the buffered status once the hub is reachable again, rather than waiting for the
+// backoff of the status controllers. The status is not persisted, since it is computed again from the managed
+// cluster after the agent restarts.
+type StatusBuffer struct {
+	client workv1client.ManifestWorkInterface
+
+	lock    sync.Mutex
+	patches map[string][]byte
+}
+
+// NewStatusBuffer returns a buffer of the status patches of the manifestworks published with the client.
+func NewStatusBuffer(client workv1client.ManifestWorkInterface) *StatusBuffer {
+	return &StatusBuffer{
+		client:  client,
+		patches: map[string][]byte{},
+	}
+}
+
+// Client returns the client of the manifestworks, the status patches which fail because the hub is unreachable
+// are buffered. The errors are still returned, so the controllers keep retrying.
+func (b *StatusBuffer) Client() workv1client.ManifestWorkInterface {
+	return &statusBufferingClient{ManifestWorkInterface: b.client, buffer: b}
+}
+
+// Flush publishes the buffered status patches. A patch is dropped once it is published or rejected by the hub,
+// it is kept if the hub is still unreachable.
+func (b *StatusBuffer) Flush(ctx context.Context) {
+	b.lock.Lock()
+	patches := make(map[string][]byte, len(b.patches))
+	for name, patch := range b.patches {
+		patches[name] = patch
+	}
+	b.lock.Unlock()
+
+	for name, patch := range patches {
+		_, err := b.client.Patch(ctx, name, types.MergePatchType, withoutResourceVersion(patch),
+			metav1.PatchOptions{}, "status")
+		switch {
+		case IsHubUnreachable(err):
+			continue
+		case err != nil:
+			klog.Warningf("Drop the buffered status of ManifestWork %s: %v", name, err)
+		default:
+			klog.V(4).Infof("Published the buffered status of ManifestWork %s", name)
+		}
+		b.remove(name, patch)
+	}
+}
+
+func (b *StatusBuffer) add(name string, patch []byte) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.patches[name] = patch
+}
+
+// remove drops the buffered patch of the manifestwork, a nil patch drops any buffered patch, otherwise the
+// buffered patch is only dropped if it is not replaced.
+func (b *StatusBuffer) remove(name string, patch []byte) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if patch == nil || bytes.Equal(b.patches[name], patch) {
+		delete(b.patches, name)
+	}
+}
+
+// withoutResourceVersion removes the resource version from the patch, so the buffered status is published
+// even if the manifestwork is changed on the hub while the hub is unreachable from the agent. The status is
+// corrected by the status controllers afterwards.
+func withoutResourceVersion(patch []byte) []byte {
+	patchMap := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &patchMap); err != nil {
+		return patch
+	}
+	metadata, ok := patchMap["metadata"].(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	delete(metadata, "resourceVersion")
+	if len(metadata) == 0 {
+		delete(patchMap, "metadata")
+	}
+	data, err := json.Marshal(patchMap)
+	if err != nil {
+		return patch
+	}
+	return data
+}
+
+type statusBufferingClient struct {
+	workv1client.ManifestWorkInterface
+	buffer *StatusBuffer
+}
+
+func (c *statusBufferingClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte,
+	opts metav1.PatchOptions, subresources ...string) (*workapiv1.ManifestWork, error) {
+	work, err := c.ManifestWorkInterface.Patch(ctx, name, pt, data, opts, subresources...)
+	if pt != types.MergePatchType || len(subresources) != 1 || subresources[0] != "status" {
+		return work, err
+	}
+
+	switch {
+	case err == nil:
+		c.buffer.remove(name, nil)
+	case IsHubUnreachable(err):
+		c.buffer.add(name, data)
+	}
+	return work, err
+}
@@ -0,0 +1,79 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestStatusBuffer(t *testing.T) {
+	work := newManifestWork("work1", 1)
+	work.ResourceVersion = "1"
+	workClient := fakeworkclient.NewSimpleClientset(work)
+
+	var patchErr error
+	var patches []string
+	workClient.PrependReactor("patch", "manifestworks", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patches = append(patches, string(action.(clienttesting.PatchActionImpl).Patch))
+		if patchErr != nil {
+			return true, nil, patchErr
+		}
+		return true, work, nil
+	})
+
+	buffer := NewStatusBuffer(workClient.WorkV1().ManifestWorks(testClusterName))
+	client := buffer.Client()
+	statusPatch := []byte(`{"metadata":{"resourceVersion":"1"},"status":{"conditions":[{"type":"Applied"}]}}`)
+
+	// the status is buffered when the hub is unreachable
+	patchErr = fmt.Errorf("connection refused")
+	if _, err := client.Patch(context.TODO(), "work1", types.MergePatchType, statusPatch,
+		metav1.PatchOptions{}, "status"); err == nil {
+		t.Errorf("expected the error is returned")
+	}
+	// the patches of the spec are not buffered
+	_, _ = client.Patch(context.TODO(), "work2", types.MergePatchType, []byte(`{}`), metav1.PatchOptions{})
+	testingcommon.AssertEqualNumber(t, len(buffer.patches), 1)
+
+	// the buffered status is kept if the hub is still unreachable
+	buffer.Flush(context.TODO())
+	testingcommon.AssertEqualNumber(t, len(buffer.patches), 1)
+
+	// the buffered status is published without the resource version once the hub is reachable
+	patchErr = nil
+	patches = nil
+	buffer.Flush(context.TODO())
+	testingcommon.AssertEqualNumber(t, len(buffer.patches), 0)
+	if len(patches) != 1 || patches[0] != `{"status":{"conditions":[{"type":"Applied"}]}}` {
+		t.Errorf("unexpected patches %v", patches)
+	}
+
+	// the buffered status is dropped once the status is published by the controllers
+	patchErr = fmt.Errorf("connection refused")
+	_, _ = client.Patch(context.TODO(), "work1", types.MergePatchType, statusPatch, metav1.PatchOptions{}, "status")
+	patchErr = nil
+	if _, err := client.Patch(context.TODO(), "work1", types.MergePatchType, statusPatch,
+		metav1.PatchOptions{}, "status"); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertEqualNumber(t, len(buffer.patches), 0)
+
+	// the buffered status is dropped if it is rejected by the hub
+	patchErr = fmt.Errorf("connection refused")
+	_, _ = client.Patch(context.TODO(), "work1", types.MergePatchType, statusPatch, metav1.PatchOptions{}, "status")
+	patchErr = apierrors.NewNotFound(schema.GroupResource{Group: workapiv1.GroupName, Resource: "manifestworks"}, "work1")
+	buffer.Flush(context.TODO())
+	testingcommon.AssertEqualNumber(t, len(buffer.patches), 0)
+}
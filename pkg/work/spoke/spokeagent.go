@@ -2,17 +2,25 @@ package spoke
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
@@ -24,13 +32,18 @@ import (
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/store"
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic"
 
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 	"open-cluster-management.io/ocm/pkg/common/options"
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth"
+	conditionrules "open-cluster-management.io/ocm/pkg/work/spoke/conditions/rules"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/finalizercontroller"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/manifestcontroller"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/statuscontroller"
+	"open-cluster-management.io/ocm/pkg/work/spoke/snapshot"
+	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
+	statusrules "open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
 )
 
 const (
@@ -103,7 +116,33 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		return err
 	}
 
-	hubHost, hubWorkClient, hubWorkInformer, err := o.newWorkClientAndInformer(ctx, restMapper)
+	// the snapshot of the manifestworks is persisted in the snapshot namespace on the cluster where the agent is
+	// running, and the key of the snapshot is stored in the agent namespace.
+	var snapshotKubeClient kubernetes.Interface
+	var snapshotKey []byte
+	if o.workOptions.EnableManifestWorkSnapshot {
+		// the manifestworks of the cloudevents drivers are not listed from the hub apiserver, so they cannot be
+		// listed from the snapshot when the hub is unreachable.
+		if o.workOptions.WorkloadSourceDriver != "kube" {
+			return fmt.Errorf("the manifestwork snapshot is not supported with the %s workload source driver",
+				o.workOptions.WorkloadSourceDriver)
+		}
+		if len(o.workOptions.ManifestWorkSnapshotNamespace) == 0 ||
+			o.workOptions.ManifestWorkSnapshotNamespace == o.agentOptions.ComponentNamespace {
+			return fmt.Errorf("the manifestwork snapshot namespace must be set and different from the agent namespace %s",
+				o.agentOptions.ComponentNamespace)
+		}
+		snapshotKubeClient, err = kubernetes.NewForConfig(controllerContext.KubeConfig)
+		if err != nil {
+			return err
+		}
+		snapshotKey, err = snapshot.EnsureKey(ctx, snapshotKubeClient, o.agentOptions.ComponentNamespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	hubHost, hubWorkClient, hubWorkInformer, err := o.newWorkClientAndInformer(ctx, restMapper, snapshotKubeClient, snapshotKey)
 	if err != nil {
 		return err
 	}
@@ -124,6 +163,53 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		restMapper,
 	).NewExecutorValidator(ctx, features.SpokeMutableFeatureGate.Enabled(ocmfeature.ExecutorValidatingCaches))
 
+	// the encryption key is generated by the registration agent in the agent namespace on the cluster where
+	// the agent is running.
+	var keySecretLister corev1listers.SecretNamespaceLister
+	if o.workOptions.EnableManifestDecryption {
+		kubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
+		if err != nil {
+			return err
+		}
+		keySecretInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+			kubeClient,
+			10*time.Minute,
+			informers.WithNamespace(o.agentOptions.ComponentNamespace),
+			informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", encryption.KeySecretName).String()
+			}),
+		)
+		keySecretLister = keySecretInformerFactory.Core().V1().Secrets().Lister().Secrets(o.agentOptions.ComponentNamespace)
+		go keySecretInformerFactory.Start(ctx.Done())
+	}
+
+	// the freeze of the cluster is read from the managed cluster on the hub, which is only accessible with the kube
+	// workload source driver.
+	var clusterInformerFactory clusterinformers.SharedInformerFactory
+	var clusterInformer clusterinformerv1.ManagedClusterInformer
+	if o.workOptions.EnableClusterFreeze {
+		if o.workOptions.WorkloadSourceDriver != "kube" {
+			return fmt.Errorf("the cluster freeze is not supported with the %s workload source driver",
+				o.workOptions.WorkloadSourceDriver)
+		}
+		hubConfig, err := clientcmd.BuildConfigFromFlags("", o.workOptions.WorkloadSourceConfig)
+		if err != nil {
+			return err
+		}
+		hubClusterClient, err := clusterclientset.NewForConfig(hubConfig)
+		if err != nil {
+			return err
+		}
+		clusterInformerFactory = clusterinformers.NewSharedInformerFactoryWithOptions(
+			hubClusterClient,
+			10*time.Minute,
+			clusterinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", o.agentOptions.SpokeClusterName).String()
+			}),
+		)
+		clusterInformer = clusterInformerFactory.Cluster().V1().ManagedClusters()
+	}
+
 	manifestWorkController := manifestcontroller.NewManifestWorkController(
 		controllerContext.EventRecorder,
 		spokeDynamicClient,
@@ -137,6 +223,8 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		hubHash, agentID,
 		restMapper,
 		validator,
+		keySecretLister,
+		clusterInformer,
 	)
 	addFinalizerController := finalizercontroller.NewAddFinalizerController(
 		controllerContext.EventRecorder,
@@ -159,6 +247,7 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		spokeWorkClient.WorkV1().AppliedManifestWorks(),
 		spokeWorkInformerFactory.Work().V1().AppliedManifestWorks(),
 		hubHash,
+		clusterInformer,
 	)
 	unmanagedAppliedManifestWorkController := finalizercontroller.NewUnManagedAppliedWorkController(
 		controllerContext.EventRecorder,
@@ -169,12 +258,22 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		o.workOptions.AppliedManifestWorkEvictionGracePeriod,
 		hubHash, agentID,
 	)
+	var relatedObjectReader *statusfeedback.RelatedObjectReader
+	if o.workOptions.EnableRelatedObjectFeedback {
+		relatedObjectReader = statusfeedback.NewRelatedObjectReader(ctx, spokeKubeClient, 10*time.Minute)
+	}
+
+	statusRuleResolver := statusrules.NewWellKnownStatusResolver()
+	conditionRuleResolver := conditionrules.NewWellKnownConditionResolver()
 	availableStatusController, err := statuscontroller.NewAvailableStatusController(
 		controllerContext.EventRecorder,
 		spokeDynamicClient,
 		hubWorkClient,
 		hubWorkInformer,
 		hubWorkInformer.Lister().ManifestWorks(o.agentOptions.SpokeClusterName),
+		statusRuleResolver,
+		conditionRuleResolver,
+		relatedObjectReader,
 		o.workOptions.MaxJSONRawLength,
 		o.workOptions.StatusSyncInterval,
 	)
@@ -182,8 +281,60 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		return err
 	}
 
+	if len(o.workOptions.WellKnownRulesConfigMap) > 0 {
+		// the custom rules are loaded from the agent namespace on the cluster where the agent is running.
+		kubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
+		if err != nil {
+			return err
+		}
+		kubeInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+			kubeClient,
+			10*time.Minute,
+			informers.WithNamespace(o.agentOptions.ComponentNamespace),
+			informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", o.workOptions.WellKnownRulesConfigMap).String()
+			}),
+		)
+		wellKnownRulesController := statuscontroller.NewWellKnownRulesController(
+			controllerContext.EventRecorder,
+			kubeInformerFactory.Core().V1().ConfigMaps(),
+			o.agentOptions.ComponentNamespace,
+			o.workOptions.WellKnownRulesConfigMap,
+			statusRuleResolver,
+			conditionRuleResolver,
+		)
+		go kubeInformerFactory.Start(ctx.Done())
+		go wellKnownRulesController.Run(ctx, 1)
+	}
+
+	if snapshotKubeClient != nil {
+		snapshotInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+			snapshotKubeClient,
+			10*time.Minute,
+			informers.WithNamespace(o.workOptions.ManifestWorkSnapshotNamespace),
+			informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.LabelSelector = fmt.Sprintf("%s=true", snapshot.SnapshotLabelKey)
+			}),
+		)
+		snapshotController := snapshot.NewSnapshotController(
+			controllerContext.EventRecorder,
+			snapshotKubeClient,
+			snapshotInformerFactory.Core().V1().Secrets(),
+			hubWorkInformer,
+			hubWorkInformer.Lister().ManifestWorks(o.agentOptions.SpokeClusterName),
+			o.workOptions.ManifestWorkSnapshotNamespace,
+			hubHash,
+			snapshotKey,
+		)
+		go snapshotInformerFactory.Start(ctx.Done())
+		go snapshotController.Run(ctx, 1)
+	}
+
 	go spokeWorkInformerFactory.Start(ctx.Done())
 	go hubWorkInformer.Informer().Run(ctx.Done())
+	if clusterInformerFactory != nil {
+		go clusterInformerFactory.Start(ctx.Done())
+	}
 
 	go addFinalizerController.Run(ctx, 1)
 	go appliedManifestWorkFinalizeController.Run(ctx, appliedManifestWorkFinalizeControllerWorkers)
@@ -200,6 +351,8 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 func (o *WorkAgentConfig) newWorkClientAndInformer(
 	ctx context.Context,
 	restMapper meta.RESTMapper,
+	snapshotKubeClient kubernetes.Interface,
+	snapshotKey []byte,
 ) (string, workv1client.ManifestWorkInterface, workv1informers.ManifestWorkInformer, error) {
 	var workClient workclientset.Interface
 	var watcherStore *store.AgentInformerWatcherStore
@@ -245,19 +398,38 @@ func (o *WorkAgentConfig) newWorkClientAndInformer(
 		workClient = clientHolder.WorkInterface()
 	}
 
-	factory := workinformers.NewSharedInformerFactoryWithOptions(
-		workClient,
-		// resyncing at a small interval may cause performance issues when the number of ManifestWorks
-		// is large.
-		24*time.Hour,
-		workinformers.WithNamespace(o.agentOptions.SpokeClusterName),
-	)
-	informer := factory.Work().V1().ManifestWorks()
+	// resyncing at a small interval may cause performance issues when the number of ManifestWorks
+	// is large.
+	resyncPeriod := 24 * time.Hour
+	var informer workv1informers.ManifestWorkInformer
+	manifestWorkClient := workClient.WorkV1().ManifestWorks(o.agentOptions.SpokeClusterName)
+	if snapshotKubeClient != nil {
+		// list the manifestworks from the snapshot in the snapshot namespace when the hub is unreachable, and
+		// publish the status buffered meanwhile once the hub is reachable again.
+		statusBuffer := snapshot.NewStatusBuffer(manifestWorkClient)
+		informer = snapshot.NewManifestWorkInformer(
+			manifestWorkClient,
+			snapshotKubeClient,
+			o.workOptions.ManifestWorkSnapshotNamespace,
+			helper.HubHash(hubHost),
+			snapshotKey,
+			statusBuffer,
+			resyncPeriod,
+		)
+		manifestWorkClient = statusBuffer.Client()
+	} else {
+		factory := workinformers.NewSharedInformerFactoryWithOptions(
+			workClient,
+			resyncPeriod,
+			workinformers.WithNamespace(o.agentOptions.SpokeClusterName),
+		)
+		informer = factory.Work().V1().ManifestWorks()
+	}
 
 	// For cloudevents work client, we use the informer store as the client store
 	if watcherStore != nil {
 		watcherStore.SetInformer(informer.Informer())
 	}
 
-	return hubHost, workClient.WorkV1().ManifestWorks(o.agentOptions.SpokeClusterName), informer, nil
+	return hubHost, manifestWorkClient, informer, nil
 }
@@ -1,12 +1,17 @@
 package statusfeedback
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
 
+	"github.com/google/cel-go/common/types/ref"
+	"google.golang.org/protobuf/types/known/structpb"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
 	"k8s.io/client-go/util/jsonpath"
 	"k8s.io/utils/pointer"
 
@@ -14,14 +19,24 @@ import (
 	workapiv1 "open-cluster-management.io/api/work/v1"
 
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
 )
 
 const maxJSONRawLength = 1024
 
+var celCostBudget = int64(celconfig.RuntimeCELCostBudget)
+
+// CelEvaluator evaluates a CEL expression with the object and returns the result and the remaining budget.
+type CelEvaluator interface {
+	EvaluateExpression(ctx context.Context, obj *unstructured.Unstructured, expression string, budget int64) (ref.Val, int64, error)
+}
+
 type StatusReader struct {
-	wellKnownStatus  rules.WellKnownStatusRuleResolver
-	maxJSONRawLength int32
+	wellKnownStatus     rules.WellKnownStatusRuleResolver
+	celEvaluator        CelEvaluator
+	relatedObjectReader *RelatedObjectReader
+	maxJSONRawLength    int32
 }
 
 func NewStatusReader() *StatusReader {
@@ -36,7 +51,26 @@ func (s *StatusReader) WithMaxJsonRawLength(length int32) *StatusReader {
 	return s
 }
 
-func (s *StatusReader) GetValuesByRule(obj *unstructured.Unstructured, rule workapiv1.FeedbackRule) ([]workapiv1.FeedbackValue, error) {
+// WithWellKnownStatusResolver sets the resolver of the well known statuses.
+func (s *StatusReader) WithWellKnownStatusResolver(resolver rules.WellKnownStatusRuleResolver) *StatusReader {
+	s.wellKnownStatus = resolver
+	return s
+}
+
+// WithCelEvaluator sets the evaluator of the CEL feedback rules, the CEL rules are not supported if it is not set.
+func (s *StatusReader) WithCelEvaluator(evaluator CelEvaluator) *StatusReader {
+	s.celEvaluator = evaluator
+	return s
+}
+
+// WithRelatedObjectReader sets the reader of the related objects, the related feedback rules are not supported
+// if it is not set.
+func (s *StatusReader) WithRelatedObjectReader(reader *RelatedObjectReader) *StatusReader {
+	s.relatedObjectReader = reader
+	return s
+}
+
+func (s *StatusReader) GetValuesByRule(ctx context.Context, obj *unstructured.Unstructured, rule workapiv1.FeedbackRule) ([]workapiv1.FeedbackValue, error) {
 	var errs []error
 	var values []workapiv1.FeedbackValue
 
@@ -81,6 +115,92 @@ func (s *StatusReader) GetValuesByRule(obj *unstructured.Unstructured, rule work
 	return values, utilerrors.NewAggregate(errs)
 }
 
+// GetValuesByFeedbackRules returns the values of the feedback rules in the helper.FeedbackRulesAnnotationKey annotation,
+// the CEL expressions of the rules share the cost budget.
+func (s *StatusReader) GetValuesByFeedbackRules(ctx context.Context, obj *unstructured.Unstructured, rules []helper.FeedbackRule) ([]workapiv1.FeedbackValue, error) {
+	var errs []error
+	var values []workapiv1.FeedbackValue
+	budget := celCostBudget
+
+	for _, rule := range rules {
+		var value *workapiv1.FeedbackValue
+		var err error
+		switch rule.Type {
+		case helper.CELFeedbackRuleType:
+			value, budget, err = s.getValueByCelExpression(ctx, rule.Name, rule.Expression, obj, budget)
+		case helper.RelatedPodsFeedbackRuleType, helper.RelatedEventsFeedbackRuleType:
+			value, err = s.getValueByRelatedObjects(rule.Name, rule.Type, obj)
+		default:
+			err = fmt.Errorf("unsupported type %q of the feedback rule %s", rule.Type, rule.Name)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if value != nil {
+			values = append(values, *value)
+		}
+	}
+
+	return values, utilerrors.NewAggregate(errs)
+}
+
+func (s *StatusReader) getValueByCelExpression(
+	ctx context.Context, name, expression string, obj *unstructured.Unstructured, budget int64,
+) (*workapiv1.FeedbackValue, int64, error) {
+	if s.celEvaluator == nil {
+		return nil, budget, fmt.Errorf("cel expression of %s is not supported", name)
+	}
+	if budget < 0 {
+		return nil, budget, fmt.Errorf("failed to evaluate cel expression of %s: CEL evaluation budget exceeded", name)
+	}
+
+	out, remainingBudget, err := s.celEvaluator.EvaluateExpression(ctx, obj, expression, budget)
+	if err != nil {
+		return nil, remainingBudget, fmt.Errorf("failed to evaluate cel expression of %s: %v", name, err)
+	}
+
+	switch value := out.Value().(type) {
+	case int64:
+		return &workapiv1.FeedbackValue{
+			Name:  name,
+			Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: &value},
+		}, remainingBudget, nil
+	case string:
+		return &workapiv1.FeedbackValue{
+			Name:  name,
+			Value: workapiv1.FieldValue{Type: workapiv1.String, String: &value},
+		}, remainingBudget, nil
+	case bool:
+		return &workapiv1.FeedbackValue{
+			Name:  name,
+			Value: workapiv1.FieldValue{Type: workapiv1.Boolean, Boolean: &value},
+		}, remainingBudget, nil
+	case structpb.NullValue:
+		// ignore the result if it is null
+		return nil, remainingBudget, nil
+	}
+
+	if !features.SpokeMutableFeatureGate.Enabled(ocmfeature.RawFeedbackJsonString) {
+		return nil, remainingBudget, fmt.Errorf("the type %s of the cel expression result for %s is not supported", out.Type().TypeName(), name)
+	}
+	native, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, remainingBudget, fmt.Errorf("failed to convert the cel expression result for %s: %v", name, err)
+	}
+	jsonRaw, err := json.Marshal(native.(*structpb.Value).AsInterface())
+	if err != nil {
+		return nil, remainingBudget, fmt.Errorf("failed to parse the cel expression result to json string for name %s: %v", name, err)
+	}
+	if len(jsonRaw) > int(s.maxJSONRawLength) {
+		return nil, remainingBudget, fmt.Errorf("the length of returned json raw string for name %s is larger than the maximum length %d", name, s.maxJSONRawLength)
+	}
+	return &workapiv1.FeedbackValue{
+		Name:  name,
+		Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(string(jsonRaw))},
+	}, remainingBudget, nil
+}
+
 func (s *StatusReader) getValueByJsonPath(name, path string, obj *unstructured.Unstructured) (*workapiv1.FeedbackValue, error) {
 	j := jsonpath.New(name).AllowMissingKeys(true)
 	err := j.Parse(fmt.Sprintf("{%s}", path))
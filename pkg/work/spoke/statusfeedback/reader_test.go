@@ -1,18 +1,22 @@
 package statusfeedback
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
 	"k8s.io/utils/pointer"
 
 	ocmfeature "open-cluster-management.io/api/feature"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/conditions"
 )
 
 const (
@@ -388,7 +392,7 @@ func TestStatusReader(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			values, err := reader.GetValuesByRule(c.object, c.rule)
+			values, err := reader.GetValuesByRule(context.TODO(), c.object, c.rule)
 			if err == nil && c.expectError {
 				t.Errorf("Expect error but got no error")
 			}
@@ -403,3 +407,94 @@ func TestStatusReader(t *testing.T) {
 		})
 	}
 }
+
+func TestStatusReaderCelRules(t *testing.T) {
+	utilruntime.Must(features.SpokeMutableFeatureGate.Add(ocmfeature.DefaultSpokeWorkFeatureGates))
+	cases := []struct {
+		name          string
+		enableRaw     bool
+		rules         []helper.FeedbackRule
+		budget        int64
+		expectError   bool
+		expectedValue []workapiv1.FeedbackValue
+	}{
+		{
+			name: "typed values",
+			rules: []helper.FeedbackRule{
+				{Type: helper.CELFeedbackRuleType, Name: "readyPercentage", Expression: "object.status.readyReplicas * 100 / object.status.replicas"},
+				{Type: helper.CELFeedbackRuleType, Name: "kind", Expression: "object.kind"},
+				{Type: helper.CELFeedbackRuleType, Name: "allReady", Expression: "object.status.readyReplicas == object.status.replicas"},
+			},
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "readyPercentage", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: pointer.Int64(50)}},
+				{Name: "kind", Value: workapiv1.FieldValue{Type: workapiv1.String, String: pointer.String("Deployment")}},
+				{Name: "allReady", Value: workapiv1.FieldValue{Type: workapiv1.Boolean, Boolean: pointer.Bool(false)}},
+			},
+		},
+		{
+			name:      "json value",
+			enableRaw: true,
+			rules: []helper.FeedbackRule{
+				{Type: helper.CELFeedbackRuleType, Name: "conditions", Expression: "object.status.conditions.map(c, c.type)"},
+				{Type: helper.CELFeedbackRuleType, Name: "ratio", Expression: "double(object.status.readyReplicas) / double(object.status.replicas)"},
+			},
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "conditions", Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(`["Available"]`)}},
+				{Name: "ratio", Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(`0.5`)}},
+			},
+		},
+		{
+			name: "json value without raw feedback enabled",
+			rules: []helper.FeedbackRule{
+				{Type: helper.CELFeedbackRuleType, Name: "conditions", Expression: "object.status.conditions.map(c, c.type)"},
+			},
+			expectError: true,
+		},
+		{
+			name: "errors are returned per value",
+			rules: []helper.FeedbackRule{
+				{Type: helper.CELFeedbackRuleType, Name: "invalid", Expression: "object.status.readyReplicas +"},
+				{Type: helper.CELFeedbackRuleType, Name: "missing", Expression: "object.status.missing"},
+				{Type: helper.CELFeedbackRuleType, Name: "kind", Expression: "object.kind"},
+			},
+			expectError: true,
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "kind", Value: workapiv1.FieldValue{Type: workapiv1.String, String: pointer.String("Deployment")}},
+			},
+		},
+		{
+			name: "budget exceeded",
+			rules: []helper.FeedbackRule{
+				{Type: helper.CELFeedbackRuleType, Name: "kind", Expression: "object.kind"},
+			},
+			budget:      1,
+			expectError: true,
+		},
+	}
+
+	conditionReader, err := conditions.NewConditionReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := NewStatusReader().WithCelEvaluator(conditionReader)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := features.SpokeMutableFeatureGate.Set(fmt.Sprintf("%s=%t", ocmfeature.RawFeedbackJsonString, c.enableRaw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.budget > 0 {
+				celCostBudget = c.budget
+				defer func() { celCostBudget = int64(celconfig.RuntimeCELCostBudget) }()
+			}
+
+			values, err := reader.GetValuesByFeedbackRules(context.TODO(), unstrctureObject(deploymentJson), c.rules)
+			if (err != nil) != c.expectError {
+				t.Errorf("Expect error %v but got %v", c.expectError, err)
+			}
+			if !apiequality.Semantic.DeepEqual(c.expectedValue, values) {
+				t.Errorf("Expect value %v, but got %v", c.expectedValue, values)
+			}
+		})
+	}
+}
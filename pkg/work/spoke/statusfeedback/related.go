@@ -0,0 +1,466 @@
+package statusfeedback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	ocmfeature "open-cluster-management.io/api/feature"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	maxRelatedEvents      = 5
+	maxEventMessageLength = 256
+
+	involvedObjectUIDIndex = "involvedObjectUID"
+
+	// relatedInformerIdleTimeout is the time the informers of a namespace are stopped after the related objects
+	// in the namespace are not read.
+	relatedInformerIdleTimeout = 30 * time.Minute
+)
+
+// PodSummary is the summary of the related pods.
+type PodSummary struct {
+	Total   int            `json:"total"`
+	Ready   int            `json:"ready"`
+	Phases  map[string]int `json:"phases,omitempty"`
+	Reasons map[string]int `json:"reasons,omitempty"`
+}
+
+// EventSummary is a warning event of the resource or the related pods.
+type EventSummary struct {
+	Object        string      `json:"object"`
+	Reason        string      `json:"reason"`
+	Message       string      `json:"message"`
+	Count         int32       `json:"count"`
+	LastTimestamp metav1.Time `json:"lastTimestamp"`
+}
+
+// RelatedObjectReader reads the objects related to a resource from the informers of the managed cluster. The
+// informers are scoped to the namespaces of the resources with the related feedback rules, they are started when
+// the related objects in a namespace are read the first time, and stopped once they are not read for a while.
+type RelatedObjectReader struct {
+	ctx          context.Context
+	kubeClient   kubernetes.Interface
+	resyncPeriod time.Duration
+
+	lock       sync.Mutex
+	namespaces map[string]*namespaceInformers
+	// now is replaceable in unit tests.
+	now func() time.Time
+}
+
+// namespaceInformers are the informers of the related objects in a namespace.
+type namespaceInformers struct {
+	podLister        corev1listers.PodLister
+	replicaSetLister appsv1listers.ReplicaSetLister
+	eventIndexer     cache.Indexer
+	hasSynced        []cache.InformerSynced
+	cancel           context.CancelFunc
+	lastRead         time.Time
+}
+
+// NewRelatedObjectReader returns a RelatedObjectReader, the informers are stopped when the ctx is done.
+func NewRelatedObjectReader(ctx context.Context, kubeClient kubernetes.Interface, resyncPeriod time.Duration) *RelatedObjectReader {
+	return &RelatedObjectReader{
+		ctx:          ctx,
+		kubeClient:   kubeClient,
+		resyncPeriod: resyncPeriod,
+		namespaces:   map[string]*namespaceInformers{},
+		now:          time.Now,
+	}
+}
+
+// informers returns the synced informers of the namespace, the informers are started if they are not started yet.
+func (r *RelatedObjectReader) informers(namespace string) (*namespaceInformers, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	for ns, nsInformers := range r.namespaces {
+		if ns != namespace && now.Sub(nsInformers.lastRead) > relatedInformerIdleTimeout {
+			nsInformers.cancel()
+			delete(r.namespaces, ns)
+		}
+	}
+
+	nsInformers, ok := r.namespaces[namespace]
+	if !ok {
+		var err error
+		nsInformers, err = r.startInformers(namespace)
+		if err != nil {
+			return nil, err
+		}
+		r.namespaces[namespace] = nsInformers
+	}
+	nsInformers.lastRead = now
+
+	for _, hasSynced := range nsInformers.hasSynced {
+		if !hasSynced() {
+			return nil, fmt.Errorf("the related objects in namespace %s are not synced yet", namespace)
+		}
+	}
+	return nsInformers, nil
+}
+
+func (r *RelatedObjectReader) startInformers(namespace string) (*namespaceInformers, error) {
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(r.kubeClient, r.resyncPeriod, informers.WithNamespace(namespace))
+	podInformer := informerFactory.Core().V1().Pods()
+	if err := podInformer.Informer().SetTransform(trimPod); err != nil {
+		return nil, err
+	}
+	replicaSetInformer := informerFactory.Apps().V1().ReplicaSets()
+	if err := replicaSetInformer.Informer().SetTransform(trimReplicaSet); err != nil {
+		return nil, err
+	}
+	eventInformer := informerFactory.Core().V1().Events()
+	if err := eventInformer.Informer().AddIndexers(cache.Indexers{
+		involvedObjectUIDIndex: indexByInvolvedObjectUID,
+	}); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	informerFactory.Start(ctx.Done())
+	return &namespaceInformers{
+		podLister:        podInformer.Lister(),
+		replicaSetLister: replicaSetInformer.Lister(),
+		eventIndexer:     eventInformer.Informer().GetIndexer(),
+		hasSynced: []cache.InformerSynced{
+			podInformer.Informer().HasSynced,
+			replicaSetInformer.Informer().HasSynced,
+			eventInformer.Informer().HasSynced,
+		},
+		cancel: cancel,
+	}, nil
+}
+
+func (r *RelatedObjectReader) summarizePods(obj *unstructured.Unstructured) (*PodSummary, error) {
+	summary := &PodSummary{Phases: map[string]int{}, Reasons: map[string]int{}}
+	if len(obj.GetNamespace()) == 0 {
+		return summary, nil
+	}
+	nsInformers, err := r.informers(obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	pods, err := nsInformers.relatedPods(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Total = len(pods)
+	for _, pod := range pods {
+		summary.Phases[string(pod.Status.Phase)]++
+		if isPodReady(pod) {
+			summary.Ready++
+		}
+		if len(pod.Status.Reason) > 0 {
+			summary.Reasons[pod.Status.Reason]++
+		}
+		for _, status := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+			if reason := containerReason(status); len(reason) > 0 {
+				summary.Reasons[reason]++
+			}
+		}
+	}
+	return summary, nil
+}
+
+// warningEvents returns the latest warning events of the resource, the replicasets owned by the resource and the
+// related pods, the latest event is the first one.
+func (r *RelatedObjectReader) warningEvents(obj *unstructured.Unstructured) ([]EventSummary, error) {
+	events := []EventSummary{}
+	if len(obj.GetNamespace()) == 0 {
+		return events, nil
+	}
+	nsInformers, err := r.informers(obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	uids := sets.New[types.UID](obj.GetUID())
+	// the events of the pods failed to create are on the replicasets of a deployment.
+	replicaSets, err := nsInformers.replicaSetLister.ReplicaSets(obj.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, replicaSet := range replicaSets {
+		if isOwnedBy(replicaSet.OwnerReferences, obj.GetUID()) {
+			uids.Insert(replicaSet.UID)
+		}
+	}
+	pods, err := nsInformers.relatedPods(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		uids.Insert(pod.UID)
+	}
+
+	for _, uid := range sets.List(uids) {
+		objs, err := nsInformers.eventIndexer.ByIndex(involvedObjectUIDIndex, string(uid))
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objs {
+			event, ok := o.(*corev1.Event)
+			if !ok || event.Type != corev1.EventTypeWarning {
+				continue
+			}
+			message := event.Message
+			if len(message) > maxEventMessageLength {
+				message = message[:maxEventMessageLength]
+			}
+			events = append(events, EventSummary{
+				Object:        fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+				Reason:        event.Reason,
+				Message:       message,
+				Count:         event.Count,
+				LastTimestamp: eventTime(event),
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[j].LastTimestamp.Before(&events[i].LastTimestamp)
+	})
+	if len(events) > maxRelatedEvents {
+		events = events[:maxRelatedEvents]
+	}
+	return events, nil
+}
+
+// relatedPods returns the pods selected by the selector in the spec of the resource, or the pods owned by the
+// resource if the resource has no selector.
+func (n *namespaceInformers) relatedPods(obj *unstructured.Unstructured) ([]*corev1.Pod, error) {
+	if obj.GetAPIVersion() == "v1" && obj.GetKind() == "Pod" {
+		pod, err := n.podLister.Pods(obj.GetNamespace()).Get(obj.GetName())
+		switch {
+		case errors.IsNotFound(err):
+			return nil, nil
+		case err != nil:
+			return nil, err
+		}
+		return []*corev1.Pod{pod}, nil
+	}
+
+	selector, found, err := specSelector(obj)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return n.podLister.Pods(obj.GetNamespace()).List(selector)
+	}
+
+	pods, err := n.podLister.Pods(obj.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var owned []*corev1.Pod
+	for _, pod := range pods {
+		if isOwnedBy(pod.OwnerReferences, obj.GetUID()) {
+			owned = append(owned, pod)
+		}
+	}
+	return owned, nil
+}
+
+func isOwnedBy(owners []metav1.OwnerReference, uid types.UID) bool {
+	for _, owner := range owners {
+		if owner.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *StatusReader) getValueByRelatedObjects(name string, ruleType helper.FeedbackRuleType, obj *unstructured.Unstructured) (*workapiv1.FeedbackValue, error) {
+	if s.relatedObjectReader == nil {
+		return nil, fmt.Errorf("related objects of %s are not supported", name)
+	}
+	if !features.SpokeMutableFeatureGate.Enabled(ocmfeature.RawFeedbackJsonString) {
+		return nil, fmt.Errorf("related objects of %s requires the %s feature", name, ocmfeature.RawFeedbackJsonString)
+	}
+
+	var jsonRaw []byte
+	switch ruleType {
+	case helper.RelatedPodsFeedbackRuleType:
+		summary, err := s.relatedObjectReader.summarizePods(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize the related pods of %s: %v", name, err)
+		}
+		jsonRaw, err = json.Marshal(summary)
+		if err != nil {
+			return nil, err
+		}
+	case helper.RelatedEventsFeedbackRuleType:
+		events, err := s.relatedObjectReader.warningEvents(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the related events of %s: %v", name, err)
+		}
+		// drop the oldest events until the value fits in the maximum length.
+		for {
+			jsonRaw, err = json.Marshal(events)
+			if err != nil {
+				return nil, err
+			}
+			if len(jsonRaw) <= int(s.maxJSONRawLength) || len(events) == 0 {
+				break
+			}
+			events = events[:len(events)-1]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported related objects %q of %s", ruleType, name)
+	}
+
+	if len(jsonRaw) > int(s.maxJSONRawLength) {
+		return nil, fmt.Errorf("the length of returned json raw string for name %s is larger than the maximum length %d", name, s.maxJSONRawLength)
+	}
+	return &workapiv1.FeedbackValue{
+		Name:  name,
+		Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(string(jsonRaw))},
+	}, nil
+}
+
+func specSelector(obj *unstructured.Unstructured) (labels.Selector, bool, error) {
+	selectorMap, found, err := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if err != nil || !found {
+		return nil, false, nil
+	}
+
+	_, hasMatchLabels := selectorMap["matchLabels"]
+	_, hasMatchExpressions := selectorMap["matchExpressions"]
+	if !hasMatchLabels && !hasMatchExpressions {
+		// the selector of a service is a map of labels.
+		set := labels.Set{}
+		for key, value := range selectorMap {
+			if v, ok := value.(string); ok {
+				set[key] = v
+			}
+		}
+		if len(set) == 0 {
+			return nil, false, nil
+		}
+		return labels.SelectorFromSet(set), true, nil
+	}
+
+	labelSelector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, labelSelector); err != nil {
+		return nil, false, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, false, err
+	}
+	return selector, true, nil
+}
+
+// containerReason returns the reason of a container which is not running normally.
+func containerReason(status corev1.ContainerStatus) string {
+	switch {
+	case status.State.Waiting != nil:
+		return status.State.Waiting.Reason
+	case status.State.Terminated != nil && status.State.Terminated.ExitCode != 0:
+		return status.State.Terminated.Reason
+	case !status.Ready && status.LastTerminationState.Terminated != nil:
+		return status.LastTerminationState.Terminated.Reason
+	}
+	return ""
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func eventTime(event *corev1.Event) metav1.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp
+	case !event.EventTime.IsZero():
+		return metav1.Time{Time: event.EventTime.Time}
+	default:
+		return event.CreationTimestamp
+	}
+}
+
+func indexByInvolvedObjectUID(obj interface{}) ([]string, error) {
+	event, ok := obj.(*corev1.Event)
+	if !ok || len(event.InvolvedObject.UID) == 0 {
+		return []string{}, nil
+	}
+	return []string{string(event.InvolvedObject.UID)}, nil
+}
+
+// trimReplicaSet only keeps the fields of the replicaset used to find the replicasets owned by a resource.
+func trimReplicaSet(obj interface{}) (interface{}, error) {
+	replicaSet, ok := obj.(*appsv1.ReplicaSet)
+	if !ok {
+		return obj, nil
+	}
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            replicaSet.Name,
+			Namespace:       replicaSet.Namespace,
+			UID:             replicaSet.UID,
+			ResourceVersion: replicaSet.ResourceVersion,
+			OwnerReferences: replicaSet.OwnerReferences,
+		},
+	}, nil
+}
+
+// trimPod only keeps the fields of the pod used to summarize the pods to reduce the memory of the informer.
+func trimPod(obj interface{}) (interface{}, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return obj, nil
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              pod.Name,
+			Namespace:         pod.Namespace,
+			UID:               pod.UID,
+			ResourceVersion:   pod.ResourceVersion,
+			Labels:            pod.Labels,
+			OwnerReferences:   pod.OwnerReferences,
+			CreationTimestamp: pod.CreationTimestamp,
+			DeletionTimestamp: pod.DeletionTimestamp,
+		},
+		Status: corev1.PodStatus{
+			Phase:                 pod.Status.Phase,
+			Reason:                pod.Status.Reason,
+			Conditions:            pod.Status.Conditions,
+			InitContainerStatuses: pod.Status.InitContainerStatuses,
+			ContainerStatuses:     pod.Status.ContainerStatuses,
+		},
+	}, nil
+}
@@ -0,0 +1,231 @@
+package statusfeedback
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	ocmfeature "open-cluster-management.io/api/feature"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const deploymentWithSelectorJson = `
+{
+	"apiVersion":"apps/v1",
+	"kind":"Deployment",
+	"metadata":{
+		"name":"web",
+		"namespace":"default",
+		"uid":"deploy-uid"
+	},
+	"spec":{
+		"selector":{
+			"matchLabels":{
+				"app":"web"
+			}
+		}
+	}
+}
+`
+
+func newRelatedPod(name string, phase corev1.PodPhase, ready bool, waitingReason string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name + "-uid"),
+			Labels:    map[string]string{"app": "web"},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+	if ready {
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	}
+	if len(waitingReason) > 0 {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{Name: "web", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}}},
+		}
+	}
+	return pod
+}
+
+func newWarningEvent(name string, pod *corev1.Pod, reason string, lastTimestamp time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: "Pod", Name: pod.Name, Namespace: pod.Namespace, UID: pod.UID,
+		},
+		Type:          corev1.EventTypeWarning,
+		Reason:        reason,
+		Message:       "message",
+		Count:         1,
+		LastTimestamp: metav1.NewTime(lastTimestamp),
+	}
+}
+
+func TestStatusReaderRelatedObjects(t *testing.T) {
+	utilruntime.Must(features.SpokeMutableFeatureGate.Add(ocmfeature.DefaultSpokeWorkFeatureGates))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	running := newRelatedPod("web-1", corev1.PodRunning, true, "")
+	pulling := newRelatedPod("web-2", corev1.PodPending, false, "ImagePullBackOff")
+	other := newRelatedPod("other", corev1.PodRunning, false, "CrashLoopBackOff")
+	other.Labels = map[string]string{"app": "other"}
+
+	otherNamespace := newRelatedPod("web-3", corev1.PodRunning, false, "CrashLoopBackOff")
+	otherNamespace.Namespace = "other"
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-rs",
+			Namespace:       "default",
+			UID:             "web-rs-uid",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "deploy-uid"}},
+		},
+	}
+	replicaSetEvent := newWarningEvent("e4", running, "FailedCreate", now.Add(-2*time.Minute))
+	replicaSetEvent.InvolvedObject = corev1.ObjectReference{
+		Kind: "ReplicaSet", Name: replicaSet.Name, Namespace: replicaSet.Namespace, UID: replicaSet.UID,
+	}
+
+	kubeClient := fakekube.NewSimpleClientset(running, pulling, other, otherNamespace, replicaSet,
+		newWarningEvent("e1", pulling, "Failed", now.Add(-time.Minute)),
+		newWarningEvent("e2", pulling, "BackOff", now),
+		newWarningEvent("e3", other, "BackOff", now),
+		replicaSetEvent,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	relatedObjectReader := NewRelatedObjectReader(ctx, kubeClient, 10*time.Minute)
+	// wait until the informers of the namespace are synced
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true,
+		func(context.Context) (bool, error) {
+			_, err := relatedObjectReader.informers("default")
+			return err == nil, nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	if len(relatedObjectReader.namespaces) != 1 {
+		t.Errorf("expected only the informers of the namespace default are started, got %d", len(relatedObjectReader.namespaces))
+	}
+
+	cases := []struct {
+		name          string
+		enableRaw     bool
+		maxLength     int32
+		reader        *RelatedObjectReader
+		ruleType      helper.FeedbackRuleType
+		expectError   bool
+		expectedValue []workapiv1.FeedbackValue
+	}{
+		{
+			name:      "pods",
+			enableRaw: true,
+			reader:    relatedObjectReader,
+			ruleType:  helper.RelatedPodsFeedbackRuleType,
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "value", Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(
+					`{"total":2,"ready":1,"phases":{"Pending":1,"Running":1},"reasons":{"ImagePullBackOff":1}}`)}},
+			},
+		},
+		{
+			name:      "events",
+			enableRaw: true,
+			reader:    relatedObjectReader,
+			ruleType:  helper.RelatedEventsFeedbackRuleType,
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "value", Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(
+					`[{"object":"Pod/web-2","reason":"BackOff","message":"message","count":1,"lastTimestamp":"2024-01-01T00:00:00Z"},` +
+						`{"object":"Pod/web-2","reason":"Failed","message":"message","count":1,"lastTimestamp":"2023-12-31T23:59:00Z"},` +
+						`{"object":"ReplicaSet/web-rs","reason":"FailedCreate","message":"message","count":1,"lastTimestamp":"2023-12-31T23:58:00Z"}]`)}},
+			},
+		},
+		{
+			name:      "events are truncated",
+			enableRaw: true,
+			maxLength: 120,
+			reader:    relatedObjectReader,
+			ruleType:  helper.RelatedEventsFeedbackRuleType,
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "value", Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(
+					`[{"object":"Pod/web-2","reason":"BackOff","message":"message","count":1,"lastTimestamp":"2024-01-01T00:00:00Z"}]`)}},
+			},
+		},
+		{
+			name:        "raw feedback is disabled",
+			reader:      relatedObjectReader,
+			ruleType:    helper.RelatedPodsFeedbackRuleType,
+			expectError: true,
+		},
+		{
+			name:        "related objects are not enabled",
+			enableRaw:   true,
+			ruleType:    helper.RelatedPodsFeedbackRuleType,
+			expectError: true,
+		},
+		{
+			name:        "unsupported related objects",
+			enableRaw:   true,
+			reader:      relatedObjectReader,
+			ruleType:    "RelatedServices",
+			expectError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := features.SpokeMutableFeatureGate.Set(fmt.Sprintf("%s=%t", ocmfeature.RawFeedbackJsonString, c.enableRaw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader := NewStatusReader().WithRelatedObjectReader(c.reader)
+			if c.maxLength > 0 {
+				reader.WithMaxJsonRawLength(c.maxLength)
+			}
+
+			values, err := reader.GetValuesByFeedbackRules(context.TODO(), unstrctureObject(deploymentWithSelectorJson),
+				[]helper.FeedbackRule{{Type: c.ruleType, Name: "value"}})
+			if (err != nil) != c.expectError {
+				t.Errorf("Expect error %v but got %v", c.expectError, err)
+			}
+			if !apiequality.Semantic.DeepEqual(c.expectedValue, values) {
+				t.Errorf("Expect value %v, but got %v", c.expectedValue, values)
+			}
+		})
+	}
+}
+
+func TestRelatedObjectReaderStopIdleInformers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Now()
+	reader := NewRelatedObjectReader(ctx, fakekube.NewSimpleClientset(), 10*time.Minute)
+	reader.now = func() time.Time { return now }
+
+	// the informers are not synced right after they are started.
+	_, _ = reader.informers("ns1")
+	now = now.Add(relatedInformerIdleTimeout / 2)
+	_, _ = reader.informers("ns2")
+	if len(reader.namespaces) != 2 {
+		t.Fatalf("expected the informers of 2 namespaces, got %d", len(reader.namespaces))
+	}
+
+	now = now.Add(relatedInformerIdleTimeout)
+	_, _ = reader.informers("ns2")
+	if _, ok := reader.namespaces["ns1"]; ok || len(reader.namespaces) != 1 {
+		t.Errorf("expected the informers of the idle namespace ns1 are stopped, got %d namespaces", len(reader.namespaces))
+	}
+}
@@ -1,6 +1,8 @@
 package rules
 
 import (
+	"sync"
+
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	workapiv1 "open-cluster-management.io/api/work/v1"
@@ -10,8 +12,13 @@ type WellKnownStatusRuleResolver interface {
 	GetPathsByKind(schema.GroupVersionKind) []workapiv1.JsonPath
 }
 
+// DefaultWellKnownStatusResolver resolves the json paths of the built-in well known statuses. Additional json
+// paths can be set at runtime by SetCustomRules, a custom path overrides the built-in path with the same name.
 type DefaultWellKnownStatusResolver struct {
 	rules map[schema.GroupVersionKind][]workapiv1.JsonPath
+
+	lock        sync.RWMutex
+	customRules map[schema.GroupVersionKind][]workapiv1.JsonPath
 }
 
 var deploymentRule = []workapiv1.JsonPath{
@@ -29,6 +36,48 @@ var deploymentRule = []workapiv1.JsonPath{
 	},
 }
 
+var statefulsetRule = []workapiv1.JsonPath{
+	{
+		Name: "ReadyReplicas",
+		Path: ".status.readyReplicas",
+	},
+	{
+		Name: "Replicas",
+		Path: ".status.replicas",
+	},
+	{
+		Name: "AvailableReplicas",
+		Path: ".status.availableReplicas",
+	},
+	{
+		Name: "UpdatedReplicas",
+		Path: ".status.updatedReplicas",
+	},
+	{
+		Name: "CurrentRevision",
+		Path: ".status.currentRevision",
+	},
+	{
+		Name: "UpdateRevision",
+		Path: ".status.updateRevision",
+	},
+}
+
+var replicasetRule = []workapiv1.JsonPath{
+	{
+		Name: "ReadyReplicas",
+		Path: ".status.readyReplicas",
+	},
+	{
+		Name: "Replicas",
+		Path: ".status.replicas",
+	},
+	{
+		Name: "AvailableReplicas",
+		Path: ".status.availableReplicas",
+	},
+}
+
 var daemonsetRule = []workapiv1.JsonPath{
 	{
 		Name: "NumberReady",
@@ -66,17 +115,170 @@ var podRule = []workapiv1.JsonPath{
 	},
 }
 
+var serviceRule = []workapiv1.JsonPath{
+	{
+		Name: "LoadBalancerIP",
+		Path: `.status.loadBalancer.ingress[*].ip`,
+	},
+	{
+		Name: "LoadBalancerHostname",
+		Path: `.status.loadBalancer.ingress[*].hostname`,
+	},
+}
+
+var pvcRule = []workapiv1.JsonPath{
+	{
+		Name: "PVCPhase",
+		Path: `.status.phase`,
+	},
+	{
+		Name: "Capacity",
+		Path: `.status.capacity.storage`,
+	},
+}
+
+var ingressRule = []workapiv1.JsonPath{
+	{
+		Name: "LoadBalancerIP",
+		Path: `.status.loadBalancer.ingress[*].ip`,
+	},
+	{
+		Name: "LoadBalancerHostname",
+		Path: `.status.loadBalancer.ingress[*].hostname`,
+	},
+}
+
+var crdRule = []workapiv1.JsonPath{
+	{
+		Name: "Established",
+		Path: `.status.conditions[?(@.type=="Established")].status`,
+	},
+	{
+		Name: "NamesAccepted",
+		Path: `.status.conditions[?(@.type=="NamesAccepted")].status`,
+	},
+}
+
+var hpaRule = []workapiv1.JsonPath{
+	{
+		Name: "CurrentReplicas",
+		Path: `.status.currentReplicas`,
+	},
+	{
+		Name: "DesiredReplicas",
+		Path: `.status.desiredReplicas`,
+	},
+}
+
+var namespaceRule = []workapiv1.JsonPath{
+	{
+		Name: "NamespacePhase",
+		Path: `.status.phase`,
+	},
+}
+
+// readyConditionRule is for the resources reporting the readiness with a Ready condition, e.g. the resources
+// of cert-manager and flux.
+var readyConditionRule = []workapiv1.JsonPath{
+	{
+		Name: "Ready",
+		Path: `.status.conditions[?(@.type=="Ready")].status`,
+	},
+}
+
+var argoApplicationRule = []workapiv1.JsonPath{
+	{
+		Name: "SyncStatus",
+		Path: `.status.sync.status`,
+	},
+	{
+		Name: "HealthStatus",
+		Path: `.status.health.status`,
+	},
+}
+
+var olmSubscriptionRule = []workapiv1.JsonPath{
+	{
+		Name: "State",
+		Path: `.status.state`,
+	},
+	{
+		Name: "InstalledCSV",
+		Path: `.status.installedCSV`,
+	},
+}
+
+var olmCSVRule = []workapiv1.JsonPath{
+	{
+		Name: "Phase",
+		Path: `.status.phase`,
+	},
+}
+
 func DefaultWellKnownStatusRule() WellKnownStatusRuleResolver {
+	return NewWellKnownStatusResolver()
+}
+
+// NewWellKnownStatusResolver returns a resolver with the built-in well known statuses.
+func NewWellKnownStatusResolver() *DefaultWellKnownStatusResolver {
 	return &DefaultWellKnownStatusResolver{
 		rules: map[schema.GroupVersionKind][]workapiv1.JsonPath{
-			{Group: "apps", Version: "v1", Kind: "Deployment"}: deploymentRule,
-			{Group: "batch", Version: "v1", Kind: "Job"}:       jobRule,
-			{Group: "", Version: "v1", Kind: "Pod"}:            podRule,
-			{Group: "apps", Version: "v1", Kind: "DaemonSet"}:  daemonsetRule,
+			{Group: "apps", Version: "v1", Kind: "Deployment"}:                                  deploymentRule,
+			{Group: "apps", Version: "v1", Kind: "StatefulSet"}:                                 statefulsetRule,
+			{Group: "apps", Version: "v1", Kind: "ReplicaSet"}:                                  replicasetRule,
+			{Group: "batch", Version: "v1", Kind: "Job"}:                                        jobRule,
+			{Group: "", Version: "v1", Kind: "Pod"}:                                             podRule,
+			{Group: "apps", Version: "v1", Kind: "DaemonSet"}:                                   daemonsetRule,
+			{Group: "", Version: "v1", Kind: "Service"}:                                         serviceRule,
+			{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"}:                           pvcRule,
+			{Group: "", Version: "v1", Kind: "Namespace"}:                                       namespaceRule,
+			{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}:                        ingressRule,
+			{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}:    crdRule,
+			{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}:              hpaRule,
+			{Group: "autoscaling", Version: "v1", Kind: "HorizontalPodAutoscaler"}:              hpaRule,
+			{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}:                      readyConditionRule,
+			{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}:                           readyConditionRule,
+			{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}:                    readyConditionRule,
+			{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"}:        readyConditionRule,
+			{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"}:               readyConditionRule,
+			{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"}:           readyConditionRule,
+			{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}:                    argoApplicationRule,
+			{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "Subscription"}:          olmSubscriptionRule,
+			{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "ClusterServiceVersion"}: olmCSVRule,
 		},
 	}
 }
 
+// SetCustomRules replaces the custom json paths of the resolver.
+func (w *DefaultWellKnownStatusResolver) SetCustomRules(rules map[schema.GroupVersionKind][]workapiv1.JsonPath) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.customRules = rules
+}
+
 func (w *DefaultWellKnownStatusResolver) GetPathsByKind(gvk schema.GroupVersionKind) []workapiv1.JsonPath {
-	return w.rules[gvk]
+	w.lock.RLock()
+	customPaths := w.customRules[gvk]
+	w.lock.RUnlock()
+
+	if len(customPaths) == 0 {
+		return w.rules[gvk]
+	}
+
+	var paths []workapiv1.JsonPath
+	for _, path := range w.rules[gvk] {
+		if !containsPath(customPaths, path.Name) {
+			paths = append(paths, path)
+		}
+	}
+	return append(paths, customPaths...)
+}
+
+func containsPath(paths []workapiv1.JsonPath, name string) bool {
+	for _, path := range paths {
+		if path.Name == name {
+			return true
+		}
+	}
+	return false
 }
@@ -7,6 +7,7 @@ type Options struct {
 	Port          int
 	CertDir       string
 	ManifestLimit int
+	HubNamespace  string
 }
 
 // NewOptions constructs a new set of default options for webhook.
@@ -14,6 +15,7 @@ func NewOptions() *Options {
 	return &Options{
 		Port:          9443,
 		ManifestLimit: 500 * 1024, // the default manifest limit is 500k.
+		HubNamespace:  "open-cluster-management-hub",
 	}
 }
 
@@ -25,4 +27,7 @@ func (c *Options) AddFlags(fs *pflag.FlagSet) {
 			"webhook server would look up the server key and certificate in {TempDir}/k8s-webhook-server/serving-certs")
 	fs.IntVar(&c.ManifestLimit, "manifestLimit", c.ManifestLimit,
 		"ManifestLimit is the max size of manifests in a manifestWork. If not set, the default is 500k.")
+	fs.StringVar(&c.HubNamespace, "hub-namespace", c.HubNamespace,
+		"HubNamespace is the namespace of the cluster manager on the hub, where the digest key of the encrypted "+
+			"manifests and the sealed manifests of the manifestWorkReplicaSets is stored.")
 }
@@ -70,13 +70,13 @@ func (c *Options) RunWebhookServer() error {
 
 	common.ManifestValidator.WithLimit(c.ManifestLimit)
 
-	if err = (&webhookv1.ManifestWorkWebhook{}).Init(mgr); err != nil {
+	if err = (&webhookv1.ManifestWorkWebhook{HubNamespace: c.HubNamespace}).Init(mgr); err != nil {
 		logger.Error(err, "unable to create ManifestWork webhook")
 		return err
 	}
 
 	if features.HubMutableFeatureGate.Enabled(ocmfeature.ManifestWorkReplicaSet) {
-		if err = (&webhookv1alpha1.ManifestWorkReplicaSetWebhook{}).Init(mgr); err != nil {
+		if err = (&webhookv1alpha1.ManifestWorkReplicaSetWebhook{HubNamespace: c.HubNamespace}).Init(mgr); err != nil {
 			logger.Error(err, "unable to create ManifestWorkReplicaSet webhook")
 			return err
 		}
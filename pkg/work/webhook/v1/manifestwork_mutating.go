@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+var _ webhook.CustomDefaulter = &ManifestWorkWebhook{}
+
+// FreezeOverridePath is the path of the webhook admitting the break-glass override of the cluster freeze on the
+// manifestworks. It is served apart from the encryption of the manifests, since the override is admitted for all
+// the manifestworks while only the manifestworks with the encrypt manifests label are encrypted.
+const FreezeOverridePath = "/mutate-work-open-cluster-management-io-v1-manifestwork-freeze-override"
+
+// Default encrypts the manifests of the manifestwork with the public key published by the cluster when the
+// manifestwork has the encrypt manifests label, so the sensitive manifests are never stored in plaintext. The
+// encrypted manifests of the old manifestwork are kept if the manifests are not changed on update.
+func (r *ManifestWorkWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	work, ok := obj.(*workv1.ManifestWork)
+	if !ok {
+		return apierrors.NewBadRequest("Request manifestwork obj format is not right")
+	}
+
+	if work.Labels[encryption.EncryptManifestsLabelKey] != "true" {
+		return nil
+	}
+
+	oldWork, _, err := oldManifestWork(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.encryptor.Encrypt(ctx, work, oldWork); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	return nil
+}
+
+// freezeOverrideDefaulter authorizes the break-glass override of the cluster freeze and records it in the freeze
+// audit of the manifestwork. The override of a manifestwork of a manifestWorkReplicaSet is decided on the
+// manifestWorkReplicaSet, so the override and its audit are copied from the manifestWorkReplicaSet instead.
+type freezeOverrideDefaulter struct {
+	webhook *ManifestWorkWebhook
+}
+
+var _ webhook.CustomDefaulter = &freezeOverrideDefaulter{}
+
+func (d *freezeOverrideDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	work, ok := obj.(*workv1.ManifestWork)
+	if !ok {
+		return apierrors.NewBadRequest("Request manifestwork obj format is not right")
+	}
+
+	if work.Annotations[commonhelpers.FreezeOverrideAnnotationKey] == "true" {
+		mwrSet, err := d.webhook.getManifestWorkReplicaSet(ctx, work)
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+		if mwrSet != nil && commonhelpers.IsFreezeOverridden(mwrSet.Annotations) {
+			commonhelpers.CopyFreezeOverride(work, mwrSet.Annotations)
+			return nil
+		}
+	}
+
+	oldWork, userInfo, err := oldManifestWork(ctx)
+	if err != nil {
+		return err
+	}
+	var oldAnnotations map[string]string
+	if oldWork != nil {
+		oldAnnotations = oldWork.Annotations
+	}
+	return commonhelpers.AdmitFreezeOverride(
+		ctx, d.webhook.kubeClient, userInfo, work.Namespace, work, oldAnnotations, time.Now())
+}
+
+// getManifestWorkReplicaSet returns the manifestWorkReplicaSet the manifestwork is generated from, or nil if the
+// manifestwork is not generated from a manifestWorkReplicaSet.
+func (r *ManifestWorkWebhook) getManifestWorkReplicaSet(
+	ctx context.Context, work *workv1.ManifestWork) (*workv1alpha1.ManifestWorkReplicaSet, error) {
+	key := work.Labels[manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey]
+	namespace, name, found := strings.Cut(key, ".")
+	if !found || name != work.Name {
+		return nil, nil
+	}
+
+	mwrSet, err := r.workClient.WorkV1alpha1().ManifestWorkReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return mwrSet, err
+}
+
+// oldManifestWork returns the old manifestwork of an update request and the user of the request.
+func oldManifestWork(ctx context.Context) (*workv1.ManifestWork, authenticationv1.UserInfo, error) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || req.Operation != admissionv1.Update {
+		return nil, req.UserInfo, nil
+	}
+
+	oldWork := &workv1.ManifestWork{}
+	if err := json.Unmarshal(req.OldObject.Raw, oldWork); err != nil {
+		return nil, req.UserInfo, apierrors.NewBadRequest(fmt.Sprintf("invalid old manifestwork: %v", err))
+	}
+	return oldWork, req.UserInfo, nil
+}
@@ -0,0 +1,329 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	workfake "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newManifestWorkWebhook(clusters ...runtime.Object) *ManifestWorkWebhook {
+	w := &ManifestWorkWebhook{
+		HubNamespace:  "open-cluster-management-hub",
+		kubeClient:    kubefake.NewSimpleClientset(),
+		clusterClient: clusterfake.NewSimpleClientset(clusters...),
+		workClient:    workfake.NewSimpleClientset(),
+	}
+	_ = encryption.EnsureDigestKey(context.TODO(), w.kubeClient, w.HubNamespace)
+	w.encryptor = encryption.NewManifestEncryptor(w.kubeClient, w.HubNamespace,
+		func(ctx context.Context, name string) (*clusterv1.ManagedCluster, error) {
+			return w.clusterClient.ClusterV1().ManagedClusters().Get(ctx, name, metav1.GetOptions{})
+		})
+	return w
+}
+
+func TestDefault(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := encryption.EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCluster := func(publicKey string) *clusterv1.ManagedCluster {
+		cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}
+		if len(publicKey) > 0 {
+			cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+				{Name: encryption.PublicKeyClusterClaimName, Value: publicKey},
+			}
+		}
+		return cluster
+	}
+	newWork := func(encrypt bool) *workv1.ManifestWork {
+		work, _ := spoketesting.NewManifestWork(0,
+			testingcommon.NewUnstructured("v1", "Secret", "ns1", "s1"),
+			testingcommon.NewUnstructured("v1", "ConfigMap", "ns1", "cm1"),
+		)
+		work.Namespace = "cluster1"
+		if encrypt {
+			work.Labels = map[string]string{encryption.EncryptManifestsLabelKey: "true"}
+		}
+		return work
+	}
+
+	cases := []struct {
+		name              string
+		cluster           *clusterv1.ManagedCluster
+		work              *workv1.ManifestWork
+		expectedErr       bool
+		expectedEncrypted []bool
+	}{
+		{
+			name:              "not labeled",
+			work:              newWork(false),
+			expectedEncrypted: []bool{false, false},
+		},
+		{
+			name:              "encrypt secrets",
+			cluster:           newCluster(publicKey),
+			work:              newWork(true),
+			expectedEncrypted: []bool{true, false},
+		},
+		{
+			name:        "no public key",
+			cluster:     newCluster(""),
+			work:        newWork(true),
+			expectedErr: true,
+		},
+		{
+			name:        "no cluster",
+			work:        newWork(true),
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if c.cluster != nil {
+				objects = append(objects, c.cluster)
+			}
+			w := newManifestWorkWebhook(objects...)
+
+			err := w.Default(context.Background(), c.work)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			for index, expected := range c.expectedEncrypted {
+				raw := c.work.Spec.Workload.Manifests[index].Raw
+				if encryption.IsEncryptedManifest(raw) != expected {
+					t.Errorf("expected manifest %d encrypted %v", index, expected)
+				}
+				if !expected {
+					continue
+				}
+				decrypted, err := encryption.DecryptManifest(key, raw)
+				if err != nil {
+					t.Fatal(err)
+				}
+				obj := &unstructured.Unstructured{}
+				if err := obj.UnmarshalJSON(decrypted); err != nil || obj.GetName() != "s1" {
+					t.Errorf("unexpected decrypted manifest %s", decrypted)
+				}
+			}
+
+			// the encrypted manifests are still valid objects
+			for index, manifest := range c.work.Spec.Workload.Manifests {
+				if err := (&unstructured.Unstructured{}).UnmarshalJSON(manifest.Raw); err != nil {
+					t.Errorf("invalid manifest %d: %v", index, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultUpdate(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := encryption.EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}
+	cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+		{Name: encryption.PublicKeyClusterClaimName, Value: publicKey},
+	}
+	newWork := func(secretName string) *workv1.ManifestWork {
+		work, _ := spoketesting.NewManifestWork(0, testingcommon.NewUnstructured("v1", "Secret", "ns1", secretName))
+		work.Namespace = "cluster1"
+		work.Labels = map[string]string{encryption.EncryptManifestsLabelKey: "true"}
+		return work
+	}
+	w := newManifestWorkWebhook(cluster)
+
+	oldWork := newWork("s1")
+	if err := w.Default(context.TODO(), oldWork); err != nil {
+		t.Fatal(err)
+	}
+	oldRaw, err := json.Marshal(oldWork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updateCtx := admission.NewContextWithRequest(context.TODO(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Update,
+			OldObject: runtime.RawExtension{Raw: oldRaw},
+		},
+	})
+
+	// the encrypted manifest is kept if the manifest is not changed
+	unchanged := newWork("s1")
+	if err := w.Default(updateCtx, unchanged); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unchanged.Spec, oldWork.Spec) {
+		t.Errorf("expected the encrypted manifest is kept")
+	}
+
+	// the manifest is encrypted again if it is changed
+	changed := newWork("s2")
+	if err := w.Default(updateCtx, changed); err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(changed.Spec, oldWork.Spec) {
+		t.Errorf("expected the manifest is encrypted again")
+	}
+	decrypted, err := encryption.DecryptManifest(key, changed.Spec.Workload.Manifests[0].Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(decrypted); err != nil || obj.GetName() != "s2" {
+		t.Errorf("unexpected decrypted manifest %s", decrypted)
+	}
+}
+
+func TestDefaultFreezeOverride(t *testing.T) {
+	cases := []struct {
+		name             string
+		allowed          bool
+		expectedErr      bool
+		expectedOverride bool
+	}{
+		{
+			name:        "override without permission",
+			expectedErr: true,
+		},
+		{
+			name:             "override with permission",
+			allowed:          true,
+			expectedOverride: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := newManifestWorkWebhook()
+			w.kubeClient.(*kubefake.Clientset).PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: c.allowed},
+					}, nil
+				},
+			)
+			work, _ := spoketesting.NewManifestWork(0, testingcommon.NewUnstructured("v1", "ConfigMap", "ns1", "cm1"))
+			work.Namespace = "cluster1"
+			work.Annotations = map[string]string{commonhelpers.FreezeOverrideAnnotationKey: "true"}
+			ctx := admission.NewContextWithRequest(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Create,
+					UserInfo:  authenticationv1.UserInfo{Username: "user1"},
+				},
+			})
+
+			err := (&freezeOverrideDefaulter{webhook: w}).Default(ctx, work)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if actual := commonhelpers.IsFreezeOverridden(work.Annotations); actual != c.expectedOverride {
+				t.Errorf("expected override %v, got %v", c.expectedOverride, actual)
+			}
+		})
+	}
+}
+
+func TestDefaultFreezeOverrideOfManifestWorkReplicaSet(t *testing.T) {
+	audit := `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`
+	cases := []struct {
+		name             string
+		mwrSetAnnotation map[string]string
+		expectedErr      bool
+		expectedAudit    string
+	}{
+		{
+			name:             "override copied from the manifestworkreplicaset",
+			mwrSetAnnotation: map[string]string{commonhelpers.FreezeOverrideAnnotationKey: "true", commonhelpers.FreezeAuditAnnotationKey: audit},
+			expectedAudit:    audit,
+		},
+		{
+			name:        "manifestworkreplicaset not overridden",
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := newManifestWorkWebhook()
+			w.workClient = workfake.NewSimpleClientset(&workv1alpha1.ManifestWorkReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "mwrset1", Namespace: "default", Annotations: c.mwrSetAnnotation},
+			})
+			w.kubeClient.(*kubefake.Clientset).PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{}, nil
+				},
+			)
+
+			work, _ := spoketesting.NewManifestWork(0, testingcommon.NewUnstructured("v1", "ConfigMap", "ns1", "cm1"))
+			work.Name = "mwrset1"
+			work.Namespace = "cluster1"
+			work.Labels = map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey: "default.mwrset1",
+			}
+			work.Annotations = map[string]string{
+				commonhelpers.FreezeOverrideAnnotationKey: "true",
+				commonhelpers.FreezeAuditAnnotationKey:    audit,
+			}
+			ctx := admission.NewContextWithRequest(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Create,
+					UserInfo:  authenticationv1.UserInfo{Username: "system:serviceaccount:open-cluster-management-hub:work-controller"},
+				},
+			})
+
+			err := (&freezeOverrideDefaulter{webhook: w}).Default(ctx, work)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if actual := work.Annotations[commonhelpers.FreezeAuditAnnotationKey]; actual != c.expectedAudit {
+				t.Errorf("expected audit %s, got %s", c.expectedAudit, actual)
+			}
+		})
+	}
+}
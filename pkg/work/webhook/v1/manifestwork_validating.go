@@ -17,7 +17,9 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 	workv1 "open-cluster-management.io/api/work/v1"
 
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
@@ -62,6 +64,10 @@ func (r *ManifestWorkWebhook) validateRequest(newWork, oldWork *workv1.ManifestW
 		return apierrors.NewBadRequest(err.Error())
 	}
 
+	if err := validateAnnotations(newWork); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+
 	req, err := admission.RequestFromContext(ctx)
 	if err != nil {
 		return apierrors.NewBadRequest(err.Error())
@@ -74,6 +80,17 @@ func (r *ManifestWorkWebhook) validateRequest(newWork, oldWork *workv1.ManifestW
 	return validateExecutor(r.kubeClient, newWork, req.UserInfo)
 }
 
+// validateAnnotations validates the annotations of the manifestwork which configure the work agent.
+func validateAnnotations(work *workv1.ManifestWork) error {
+	if err := helper.ValidateFeedbackRules(work.Annotations); err != nil {
+		return err
+	}
+	if err := helper.ValidateHealthAggregation(work.Annotations); err != nil {
+		return err
+	}
+	return commonhelpers.ValidateClusterFreeze(nil, work.Annotations)
+}
+
 func validateExecutor(kubeClient kubernetes.Interface, work *workv1.ManifestWork, userInfo authenticationv1.UserInfo) error {
 	executor := work.Spec.Executor
 	if !features.HubMutableFeatureGate.Enabled(ocmfeature.NilExecutorValidating) {
@@ -21,7 +21,9 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 	workv1 "open-cluster-management.io/api/work/v1"
 
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
 )
 
@@ -322,3 +324,62 @@ func TestManifestWorkExecutorValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAnnotations(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "valid feedback rules",
+			annotations: map[string]string{helper.FeedbackRulesAnnotationKey: `[{"resourceIdentifier":` +
+				`{"group":"apps","resource":"deployments","namespace":"ns1","name":"deploy1"},"rules":[{"type":"CEL",` +
+				`"name":"ready","expression":"object.status.readyReplicas == object.status.replicas"}]}]`},
+		},
+		{
+			name: "invalid feedback rules",
+			annotations: map[string]string{helper.FeedbackRulesAnnotationKey: `[{"resourceIdentifier":` +
+				`{"group":"apps","resource":"deployments","namespace":"ns1","name":"deploy1"},"rules":[{"type":"Unknown",` +
+				`"name":"ready"}]}]`},
+			expectedErr: true,
+		},
+		{
+			name: "feedback rule not compiled",
+			annotations: map[string]string{helper.FeedbackRulesAnnotationKey: `[{"resourceIdentifier":` +
+				`{"group":"apps","resource":"deployments","namespace":"ns1","name":"deploy1"},"rules":[{"type":"CEL",` +
+				`"name":"ready","expression":"object.status.readyReplicas =="}]}]`},
+			expectedErr: true,
+		},
+		{
+			name: "valid health aggregation",
+			annotations: map[string]string{
+				helper.HealthAggregationAnnotationKey: `{"available":{"type":"Percentage","threshold":50}}`},
+		},
+		{
+			name: "invalid health aggregation",
+			annotations: map[string]string{
+				helper.HealthAggregationAnnotationKey: `{"available":{"type":"CEL","expression":"manifests.all("}}`},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid freeze override",
+			annotations: map[string]string{commonhelpers.FreezeOverrideAnnotationKey: "yes"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, _ := spoketesting.NewManifestWork(0)
+			work.Annotations = c.annotations
+			err := validateAnnotations(work)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
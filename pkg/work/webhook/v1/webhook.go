@@ -1,14 +1,30 @@
 package v1
 
 import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	v1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 )
 
 type ManifestWorkWebhook struct {
-	kubeClient kubernetes.Interface
+	// HubNamespace is the namespace of the cluster manager on the hub, where the digest key of the encrypted
+	// manifests is stored.
+	HubNamespace string
+
+	kubeClient    kubernetes.Interface
+	clusterClient clusterv1client.Interface
+	workClient    workv1client.Interface
+	encryptor     *encryption.ManifestEncryptor
 }
 
 func (r *ManifestWorkWebhook) Init(mgr ctrl.Manager) error {
@@ -17,7 +33,22 @@ func (r *ManifestWorkWebhook) Init(mgr ctrl.Manager) error {
 		return err
 	}
 	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
-	return err
+	if err != nil {
+		return err
+	}
+	r.clusterClient, err = clusterv1client.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.workClient, err = workv1client.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.encryptor = encryption.NewManifestEncryptor(r.kubeClient, r.HubNamespace,
+		func(ctx context.Context, name string) (*clusterv1.ManagedCluster, error) {
+			return r.clusterClient.ClusterV1().ManagedClusters().Get(ctx, name, metav1.GetOptions{})
+		})
+	return nil
 }
 
 // SetExternalKubeClientSet is function to enable the webhook injecting to kube admission
@@ -26,8 +57,12 @@ func (r *ManifestWorkWebhook) SetExternalKubeClientSet(client kubernetes.Interfa
 }
 
 func (r *ManifestWorkWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(FreezeOverridePath,
+		admission.WithCustomDefaulter(mgr.GetScheme(), &v1.ManifestWork{}, &freezeOverrideDefaulter{webhook: r}))
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		WithValidator(r).
+		WithDefaulter(r).
 		For(&v1.ManifestWork{}).
 		Complete()
 }
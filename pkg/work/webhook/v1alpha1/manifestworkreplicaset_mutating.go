@@ -0,0 +1,65 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+var _ webhook.CustomDefaulter = &ManifestWorkReplicaSetWebhook{}
+
+// Default authorizes the break-glass override of the cluster freeze and records it in the freeze audit of the
+// manifestWorkReplicaSet. The manifestWorkReplicaSet is rolled out to the clusters selected by the placements, so
+// the user is required to be authorized to override the freeze of all the clusters.
+// It also seals the sensitive manifests of the template with the key of the hub when the manifestWorkReplicaSet has
+// the encrypt manifests label, the manifests are only encrypted with the public keys of the clusters once the
+// clusters are decided, and they are never stored in plaintext in the template.
+func (r *ManifestWorkReplicaSetWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	mwrSet, ok := obj.(*workv1alpha1.ManifestWorkReplicaSet)
+	if !ok {
+		return apierrors.NewBadRequest("Request manifestWorkReplicaSet obj format is not right")
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+
+	var oldAnnotations map[string]string
+	var oldManifests []workv1.Manifest
+	if req.Operation == admissionv1.Update {
+		oldMWRSet := &workv1alpha1.ManifestWorkReplicaSet{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldMWRSet); err != nil {
+			return apierrors.NewBadRequest(fmt.Sprintf("invalid old manifestWorkReplicaSet: %v", err))
+		}
+		oldAnnotations = oldMWRSet.Annotations
+		oldManifests = oldMWRSet.Spec.ManifestWorkTemplate.Workload.Manifests
+	}
+
+	if err := helpers.AdmitFreezeOverride(ctx, r.kubeClient, req.UserInfo, "",
+		mwrSet, oldAnnotations, time.Now()); err != nil {
+		return err
+	}
+
+	if mwrSet.Labels[encryption.EncryptManifestsLabelKey] != "true" {
+		return nil
+	}
+
+	if err := r.encryptor.Seal(ctx, mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests, oldManifests); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	return nil
+}
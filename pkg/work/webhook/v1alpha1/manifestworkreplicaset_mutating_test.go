@@ -0,0 +1,187 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestDefault(t *testing.T) {
+	newMWRSet := func(annotations map[string]string) *workv1alpha1.ManifestWorkReplicaSet {
+		return &workv1alpha1.ManifestWorkReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "mwrset1", Namespace: "default", Annotations: annotations},
+		}
+	}
+	overridden := newMWRSet(map[string]string{
+		helpers.FreezeOverrideAnnotationKey: "true",
+		helpers.FreezeAuditAnnotationKey:    `[{"action":"Override","user":"admin","time":"2024-01-01T00:00:00Z"}]`,
+	})
+
+	cases := []struct {
+		name             string
+		mwrSet           *workv1alpha1.ManifestWorkReplicaSet
+		oldMWRSet        *workv1alpha1.ManifestWorkReplicaSet
+		allowed          bool
+		expectedErr      bool
+		expectedOverride bool
+	}{
+		{
+			name:   "no override",
+			mwrSet: newMWRSet(nil),
+		},
+		{
+			name:        "override without permission",
+			mwrSet:      newMWRSet(map[string]string{helpers.FreezeOverrideAnnotationKey: "true"}),
+			expectedErr: true,
+		},
+		{
+			name:             "override with permission",
+			mwrSet:           newMWRSet(map[string]string{helpers.FreezeOverrideAnnotationKey: "true"}),
+			allowed:          true,
+			expectedOverride: true,
+		},
+		{
+			name:             "override is kept on update",
+			mwrSet:           newMWRSet(map[string]string{helpers.FreezeOverrideAnnotationKey: "true"}),
+			oldMWRSet:        overridden,
+			expectedOverride: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					if len(sar.Spec.ResourceAttributes.Name) != 0 {
+						t.Errorf("expected the authorization on all the clusters, got %s", sar.Spec.ResourceAttributes.Name)
+					}
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: c.allowed},
+					}, nil
+				},
+			)
+			w := &ManifestWorkReplicaSetWebhook{kubeClient: kubeClient}
+
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  authenticationv1.UserInfo{Username: "user1"},
+			}}
+			if c.oldMWRSet != nil {
+				raw, err := json.Marshal(c.oldMWRSet)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Operation = admissionv1.Update
+				req.OldObject = runtime.RawExtension{Raw: raw}
+			}
+
+			err := w.Default(admission.NewContextWithRequest(context.TODO(), req), c.mwrSet)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if actual := helpers.IsFreezeOverridden(c.mwrSet.Annotations); actual != c.expectedOverride {
+				t.Errorf("expected override %v, got %v", c.expectedOverride, actual)
+			}
+		})
+	}
+}
+
+func TestDefaultSeal(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	if err := encryption.EnsureDigestKey(context.TODO(), kubeClient, "open-cluster-management-hub"); err != nil {
+		t.Fatal(err)
+	}
+	w := &ManifestWorkReplicaSetWebhook{
+		HubNamespace: "open-cluster-management-hub",
+		kubeClient:   kubeClient,
+		encryptor:    encryption.NewManifestEncryptor(kubeClient, "open-cluster-management-hub", nil),
+	}
+	newMWRSet := func(encrypt bool, secretName string) *workv1alpha1.ManifestWorkReplicaSet {
+		mwrSet := &workv1alpha1.ManifestWorkReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "mwrset1", Namespace: "default"},
+		}
+		if encrypt {
+			mwrSet.Labels = map[string]string{encryption.EncryptManifestsLabelKey: "true"}
+		}
+		for _, obj := range []*unstructured.Unstructured{
+			testingcommon.NewUnstructured("v1", "Secret", "ns1", secretName),
+			testingcommon.NewUnstructured("v1", "ConfigMap", "ns1", "cm1"),
+		} {
+			raw, _ := obj.MarshalJSON()
+			mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests = append(
+				mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests,
+				workv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}})
+		}
+		return mwrSet
+	}
+	newContext := func(old *workv1alpha1.ManifestWorkReplicaSet) context.Context {
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create}}
+		if old != nil {
+			raw, err := json.Marshal(old)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Operation = admissionv1.Update
+			req.OldObject = runtime.RawExtension{Raw: raw}
+		}
+		return admission.NewContextWithRequest(context.TODO(), req)
+	}
+
+	// the manifests are not sealed without the label
+	plain := newMWRSet(false, "s1")
+	if err := w.Default(newContext(nil), plain); err != nil {
+		t.Fatal(err)
+	}
+	if encryption.IsSealedManifest(plain.Spec.ManifestWorkTemplate.Workload.Manifests[0].Raw) {
+		t.Errorf("expected the manifest is not sealed")
+	}
+
+	sealed := newMWRSet(true, "s1")
+	if err := w.Default(newContext(nil), sealed); err != nil {
+		t.Fatal(err)
+	}
+	manifests := sealed.Spec.ManifestWorkTemplate.Workload.Manifests
+	if !encryption.IsSealedManifest(manifests[0].Raw) || encryption.IsSealedManifest(manifests[1].Raw) {
+		t.Errorf("expected only the secret is sealed")
+	}
+
+	// the sealed manifest is kept if the manifest is not changed
+	unchanged := newMWRSet(true, "s1")
+	if err := w.Default(newContext(sealed), unchanged); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unchanged.Spec, sealed.Spec) {
+		t.Errorf("expected the sealed manifest is kept")
+	}
+
+	// the manifest is sealed again if it is changed
+	changed := newMWRSet(true, "s2")
+	if err := w.Default(newContext(sealed), changed); err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(changed.Spec, sealed.Spec) {
+		t.Errorf("expected the manifest is sealed again")
+	}
+}
@@ -12,7 +12,9 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/webhook/common"
 )
 
@@ -65,6 +67,18 @@ func (r *ManifestWorkReplicaSetWebhook) validateRequest(
 		return apierrors.NewBadRequest(err.Error())
 	}
 
+	if err := helpers.ValidateRolloutGate(newmwrSet.Annotations); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+
+	if err := helper.ValidateRollbackThreshold(newmwrSet.Annotations); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+
+	if err := helpers.ValidateClusterFreeze(nil, newmwrSet.Annotations); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+
 	_, err := admission.RequestFromContext(ctx)
 	if err != nil {
 		return apierrors.NewBadRequest(err.Error())
@@ -13,7 +13,9 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
 )
 
@@ -120,6 +122,81 @@ func TestWebHookUpdateRequest(t *testing.T) {
 	}
 }
 
+func TestWebHookValidateAnnotations(t *testing.T) {
+	setupFeatureGate(t)
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name:        "valid rollout gate",
+			annotations: map[string]string{helpers.RolloutGateAnnotationKey: `{"expression":"feedback.ready == true","soakPeriod":"10m"}`},
+		},
+		{
+			name:        "invalid rollout gate",
+			annotations: map[string]string{helpers.RolloutGateAnnotationKey: `{"soakPeriod":"10m"}`},
+			expectedErr: true,
+		},
+		{
+			name:        "rollout gate not compiled",
+			annotations: map[string]string{helpers.RolloutGateAnnotationKey: `{"expression":"feedback.ready =="}`},
+			expectedErr: true,
+		},
+		{
+			name: "valid rollback threshold",
+			annotations: map[string]string{
+				helper.ManifestWorkReplicaSetRollbackThresholdAnnotationKey: "20%",
+			},
+		},
+		{
+			name: "invalid rollback threshold",
+			annotations: map[string]string{
+				helper.ManifestWorkReplicaSetRollbackThresholdAnnotationKey: "twenty",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "negative rollback threshold",
+			annotations: map[string]string{
+				helper.ManifestWorkReplicaSetRollbackThresholdAnnotationKey: "-1",
+			},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid freeze override",
+			annotations: map[string]string{helpers.FreezeOverrideAnnotationKey: "yes"},
+			expectedErr: true,
+		},
+	}
+
+	request := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Resource:  manifestWorkReplicaSetSchema,
+			Operation: admissionv1.Create,
+		},
+	}
+	ctx := admission.NewContextWithRequest(context.Background(), request)
+	webHook := ManifestWorkReplicaSetWebhook{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+			mwrSet.Annotations = c.annotations
+			_, err := webHook.ValidateCreate(ctx, mwrSet)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+			if err != nil && !apierrors.IsBadRequest(err) {
+				t.Errorf("expected bad request error, got %v", err)
+			}
+		})
+	}
+}
+
 func setupFeatureGate(t *testing.T) {
 	defaultFG := features.HubMutableFeatureGate
 	if err := defaultFG.Add(ocmfeature.DefaultHubWorkFeatureGates); err != nil {
@@ -5,10 +5,17 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/encryption"
 )
 
 type ManifestWorkReplicaSetWebhook struct {
+	// HubNamespace is the namespace of the cluster manager on the hub, where the key sealing the sensitive
+	// manifests of the template is derived from.
+	HubNamespace string
+
 	kubeClient kubernetes.Interface
+	encryptor  *encryption.ManifestEncryptor
 }
 
 func (r *ManifestWorkReplicaSetWebhook) Init(mgr ctrl.Manager) error {
@@ -17,7 +24,11 @@ func (r *ManifestWorkReplicaSetWebhook) Init(mgr ctrl.Manager) error {
 		return err
 	}
 	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
-	return err
+	if err != nil {
+		return err
+	}
+	r.encryptor = encryption.NewManifestEncryptor(r.kubeClient, r.HubNamespace, nil)
+	return nil
 }
 
 // SetExternalKubeClientSet is function to enable the webhook injecting to kube admssion
@@ -28,6 +39,7 @@ func (r *ManifestWorkReplicaSetWebhook) SetExternalKubeClientSet(client kubernet
 func (r *ManifestWorkReplicaSetWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		WithValidator(r).
+		WithDefaulter(r).
 		For(&v1alpha1.ManifestWorkReplicaSet{}).
 		Complete()
 }